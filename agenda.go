@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var agendaCmd = &cobra.Command{
+	Use:   "agenda",
+	Short: "Show due-dated items across all lists",
+	Long: `Show every pending item with a due date across all lists, soonest first.
+
+Use --ical-feed to print the calendar subscription URL served by 'todo serve',
+so calendar apps stay up to date instead of importing a one-off export.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		if feed, _ := cmd.Flags().GetBool("ical-feed"); feed {
+			addr, _ := cmd.Flags().GetString("addr")
+			if jsonOutput(cmd) {
+				printJSON(map[string]string{"url": agendaFeedURL(addr)})
+				return
+			}
+			fmt.Println(agendaFeedURL(addr))
+			pkg.Tip("\nRun 'todo serve' and subscribe to this URL in your calendar app.")
+			return
+		}
+
+		all, _ := cmd.Flags().GetBool("all")
+		agenda, err := loadAgenda(all)
+		if err != nil {
+			fmt.Printf("Failed to load agenda: %v\n", err)
+			return
+		}
+
+		version, ok := porcelain(cmd)
+		if !ok {
+			return
+		}
+		if version != "" {
+			for _, entry := range agenda {
+				pkg.WritePorcelainItem(os.Stdout, entry.List, entry.Item)
+			}
+			return
+		}
+		if jsonOutput(cmd) {
+			printListItems(agenda)
+			return
+		}
+
+		if len(agenda) == 0 {
+			fmt.Println("No items with due dates.")
+			return
+		}
+
+		fmt.Println("Agenda:")
+		today := pkg.Now().Format(pkg.DueDateFormat)
+		currentDate := ""
+		for i, entry := range agenda {
+			date := entry.Item.DueDate.Format(pkg.DueDateFormat)
+			if date != currentDate {
+				label := entry.Item.DueDate.Format("Monday, January 2, 2006")
+				if date < today && !entry.Item.Completed {
+					label += " (overdue)"
+				}
+				pkg.Blank()
+				fmt.Printf("%s%s\n", pkg.Emoji("📅"), label)
+				currentDate = date
+			}
+
+			status := "[ ]"
+			if entry.Item.Completed {
+				status = "[x]"
+			}
+			fmt.Printf("  %d. %s %s [%s #%d]\n", i+1, status, entry.Item.Text, entry.List, entry.Item.ID)
+		}
+		pkg.SaveView(agenda)
+	},
+}
+
+// loadAgenda asks a running daemon for the agenda, or reads every list
+func loadAgenda(all bool) ([]pkg.ListItem, error) {
+	var agenda []pkg.ListItem
+	query := []string{}
+	if all {
+		query = append(query, "completed")
+	}
+	if err := pkg.AskDaemon(&agenda, "agenda", query...); err == nil {
+		return agenda, nil
+	}
+	return pkg.GetAgenda(all)
+}
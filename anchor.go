@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var anchorCmd = &cobra.Command{
+	Use:   "anchor [item-number|section.item|id] <path[:line]>",
+	Short: "Link an item to a location in the code",
+	Long: `Link an item of the current list to the file and line it is about:
+
+  todo anchor 3 src/auth.go:42
+  todo anchor 3 --remove
+
+The location is shown by 'todo show' and opened by 'todo open'. Items
+imported from review comments are anchored to the commented line.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		remove, _ := cmd.Flags().GetBool("remove")
+		if remove == (len(args) == 2) {
+			fmt.Println("Error: give either a location or --remove")
+			return
+		}
+
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		currentList, itemID, err := pkg.ResolveViewItemRef(currentList, args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		var anchor pkg.Anchor
+		if !remove {
+			if anchor, err = pkg.ParseAnchor(args[1]); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			anchor.Path = argPath(anchor.Path)
+		}
+		if err := pkg.SetItemAnchor(currentList, itemID, anchor); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if remove {
+			fmt.Printf("Removed the anchor of item %s\n", args[0])
+			return
+		}
+		fmt.Printf("Anchored item %s to %s\n", args[0], args[1])
+	},
+}
+
+var openCmd = &cobra.Command{
+	Use:   "open [item-number|section.item|id]",
+	Short: "Open your editor at an item's code location",
+	Long:  `Open $EDITOR at the file and line an item of the current list is anchored to (see 'todo anchor').`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		currentList, item, ok := currentItem(currentList, args[0])
+		if !ok {
+			return
+		}
+		anchor, ok := pkg.ItemAnchor(item)
+		if !ok {
+			fmt.Printf("Error: item %s isn't anchored to a location\n", args[0])
+			pkg.Tip(fmt.Sprintf("Add one with 'todo anchor %s <path:line>'.", args[0]))
+			return
+		}
+		if err := pkg.OpenAnchor(anchor); err != nil {
+			fmt.Printf("Error opening editor: %v\n", err)
+		}
+	},
+}
+
+// currentItem returns the item a reference points to, in the current list
+// or the last aggregated view, along with the list it is in. It prints an
+// error if there is none.
+func currentItem(currentList, ref string) (string, pkg.TodoItem, bool) {
+	listName, itemID, err := pkg.ResolveViewItemRef(currentList, ref)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return "", pkg.TodoItem{}, false
+	}
+	todoList, err := pkg.ParseTodoFile(listName)
+	if err != nil {
+		fmt.Printf("Error reading list: %v\n", err)
+		return "", pkg.TodoItem{}, false
+	}
+	if itemID < 1 || itemID > len(todoList.Items) {
+		fmt.Printf("Error: invalid item ID: %d\n", itemID)
+		return "", pkg.TodoItem{}, false
+	}
+	return listName, todoList.Items[itemID-1], true
+}
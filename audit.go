@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show who changed which lists and when",
+	Long: `Show the audit log: for each command that changed lists, who ran it (their
+git user.name and user.email), when, and which lists it changed, most recent
+last.
+
+Auditing is off by default. Turn it on for a shared .todo directory with
+'audit: true' in .todo/config.yaml; entries are written to .todo/audit.jsonl,
+which is committed along with the lists.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		listName, _ := cmd.Flags().GetString("list")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		entries, err := pkg.ReadAudit()
+		if err != nil {
+			fmt.Printf("Error reading audit log: %v\n", err)
+			return
+		}
+		if listName != "" {
+			listName = pkg.ResolveListName(listName)
+			entries = slices.DeleteFunc(entries, func(entry pkg.AuditEntry) bool {
+				return !slices.Contains(entry.Lists, listName)
+			})
+		}
+		if limit > 0 && len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+		if jsonOutput(cmd) {
+			entries = append([]pkg.AuditEntry{}, entries...)
+			for i := range entries {
+				entries[i].Time = entries[i].Time.Local()
+			}
+			printJSON(entries)
+			return
+		}
+		if len(entries) == 0 {
+			fmt.Println("No audit entries")
+			if cfg, err := pkg.LoadConfig(); err == nil && !cfg.Audit {
+				pkg.Tip("Auditing is off; set 'audit: true' in .todo/config.yaml to turn it on")
+			}
+			return
+		}
+
+		for _, entry := range entries {
+			who := entry.Name
+			if entry.Email != "" {
+				who = fmt.Sprintf("%s <%s>", entry.Name, entry.Email)
+			}
+			fmt.Printf("%s  %s  %s  (%s)\n", pkg.FormatTimestamp(entry.Time.Local()), who, entry.Command, strings.Join(entry.Lists, ", "))
+		}
+	},
+}
+
+// recordAudit adds the command that just ran to the audit log if it changed
+// any lists
+func recordAudit(cmd *cobra.Command, args []string) {
+	cfg, err := pkg.LoadConfig()
+	if err != nil {
+		return
+	}
+
+	command := strings.Join(append([]string{cmd.CommandPath()}, args...), " ")
+	if err := pkg.RecordAudit(cfg, command, time.Now()); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// autoCommit commits what the command that just ran changed under .todo, when
+// auto_commit is on or it was run with --commit
+func autoCommit(cmd *cobra.Command) {
+	cfg, err := pkg.LoadConfig()
+	if err != nil {
+		return
+	}
+	flag, _ := cmd.Flags().GetBool("commit")
+	if flag && !pkg.GitEnabled(cfg) {
+		if err := pkg.RequireGit(); err != nil {
+			fmt.Printf("Warning: not committing: %v\n", err)
+		}
+		return
+	}
+	if !pkg.AutoCommitEnabled(cfg, flag) {
+		return
+	}
+
+	message, err := pkg.CommitChanges(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "))
+	if err != nil {
+		fmt.Printf("Warning: failed to commit .todo: %v\n", err)
+		return
+	}
+	if message != "" {
+		pkg.Tip("Committed: %s", message)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage credentials for sync providers",
+	Long: `Store and inspect the tokens used by sync providers.
+
+Tokens are kept in the OS keychain (macOS Keychain, libsecret on Linux,
+Windows Credential Manager). When no keychain is available they are stored
+in an encrypted file in your user config directory, protected by a
+passphrase (read from TODO_CREDENTIALS_PASSPHRASE or prompted for).
+
+A TODO_<PROVIDER>_TOKEN environment variable always takes precedence over a
+stored token.`,
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login <provider>",
+	Short: "Store a token for a sync provider",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		provider := strings.ToLower(args[0])
+
+		token, err := readSecret(fmt.Sprintf("Token for %s: ", provider))
+		if err != nil {
+			fmt.Printf("Error reading token: %v\n", err)
+			return
+		}
+		if token == "" {
+			fmt.Println("No token entered, nothing stored.")
+			return
+		}
+
+		backend, err := pkg.SaveCredential(provider, token)
+		if err != nil {
+			fmt.Printf("Error storing credential: %v\n", err)
+			return
+		}
+		fmt.Printf("Stored %s credential in %s\n", provider, backend)
+	},
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which providers have stored credentials",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		infos, err := pkg.CredentialStatus()
+		if err != nil {
+			fmt.Printf("Error reading credentials: %v\n", err)
+			return
+		}
+		if jsonOutput(cmd) {
+			printJSON(append([]pkg.CredentialInfo{}, infos...))
+			return
+		}
+
+		if len(infos) == 0 {
+			fmt.Println("No credentials stored. Run 'todo auth login <provider>' to add one.")
+			return
+		}
+
+		fmt.Println("Credentials:")
+		fmt.Println()
+		for _, info := range infos {
+			fmt.Printf("  %s - %s\n", info.Provider, info.Backend)
+		}
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout <provider>",
+	Short: "Remove the stored token for a sync provider",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		provider := strings.ToLower(args[0])
+
+		if err := pkg.DeleteCredential(provider); err != nil {
+			if errors.Is(err, pkg.ErrCredentialNotFound) {
+				fmt.Printf("No credential stored for %s\n", provider)
+				return
+			}
+			fmt.Printf("Error removing credential: %v\n", err)
+			return
+		}
+		fmt.Printf("Removed %s credential\n", provider)
+	},
+}
+
+// readSecret prompts for a value without echoing it when stdin is a
+// terminal, and reads a plain line otherwise so tokens can be piped in
+func readSecret(question string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Print(question)
+		secret, err := term.ReadPassword(fd)
+		fmt.Println()
+		return strings.TrimSpace(string(secret)), err
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// promptCredentialPassphrase asks for the credential file passphrase when it
+// isn't set in the environment
+func promptCredentialPassphrase() (string, error) {
+	if passphrase := os.Getenv("TODO_CREDENTIALS_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no keychain available; set TODO_CREDENTIALS_PASSPHRASE to use the encrypted credential file")
+	}
+
+	passphrase, err := readSecret("Credential file passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("a passphrase is required for the encrypted credential file")
+	}
+	return passphrase, nil
+}
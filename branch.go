@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var branchCmd = &cobra.Command{
+	Use:   "branch <name>",
+	Short: "Create or switch to a git branch and its todo list",
+	Long: `Check out a git branch and make its todo list the current one, creating
+either when it doesn't exist yet. The list is named as branch tracking names
+it: feature/auth uses the auth list and fix/login-bug uses fix-login-bug.
+
+  todo branch feature/auth   Create or switch to feature/auth and the auth list`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+		if err := pkg.RequireGit(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		branchName := args[0]
+		if pkg.BranchExists(branchName) {
+			if err := pkg.SwitchBranch(branchName); err != nil {
+				fmt.Printf("Error switching branch: %v\n", err)
+				return
+			}
+			fmt.Printf("Switched to branch '%s'\n", branchName)
+		} else {
+			if err := pkg.CreateBranch(branchName); err != nil {
+				fmt.Printf("Error creating branch: %v\n", err)
+				return
+			}
+			fmt.Printf("Created branch '%s'\n", branchName)
+		}
+
+		listName := pkg.ResolveListName(pkg.GetFeatureName(branchName))
+		if err := pkg.SetCurrentList(listName); err != nil {
+			fmt.Printf("Error setting current list: %v\n", err)
+			return
+		}
+		if !pkg.TodoFileExists(listName) {
+			if err := pkg.CreateTodoFile(listName); err != nil {
+				fmt.Printf("Error creating todo file: %v\n", err)
+				return
+			}
+			fmt.Printf("Created todo list '%s'\n", listName)
+		} else {
+			fmt.Printf("Switched to list '%s'\n", listName)
+		}
+		if err := pkg.LinkListToBranch(listName, branchName); err != nil {
+			fmt.Printf("Error linking list to branch: %v\n", err)
+		}
+	},
+}
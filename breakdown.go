@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var breakdownCmd = &cobra.Command{
+	Use:     "breakdown [item-number|section.item|id]",
+	Aliases: []string{"explain"},
+	Short:   "Split an item into subtasks suggested by a model",
+	Long: `Send an item of the current list, with the rest of the list for context, to
+a model and add the subtasks it suggests under the item.
+
+Breakdowns are opt-in. Set one provider in .todo/config.yaml or your user
+config (~/.config/todo/config.yaml):
+
+  breakdown:
+    command: llm -m gpt-4o-mini    # reads the prompt on stdin
+
+  breakdown:
+    endpoint: https://api.openai.com/v1   # any OpenAI-compatible API
+    model: gpt-4o-mini
+
+A command is only run from your user config: one set in a project's
+.todo/config.yaml came with the repository, and is ignored. Commands get the prompt on stdin and TODO_BREAKDOWN_LIST and
+TODO_BREAKDOWN_ITEM in their environment, and print one subtask per line.
+Endpoints are sent the key stored with 'todo auth login llm' or set in
+TODO_LLM_TOKEN, if there is one.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		cfg, err := pkg.LoadConfig()
+		if err != nil {
+			fmt.Printf("Error loading config: %v\n", err)
+			return
+		}
+		provider, err := pkg.NewBreakdownProvider(cfg)
+		if errors.Is(err, pkg.ErrBreakdownNotConfigured) {
+			fmt.Println("Error: breakdowns are not set up")
+			pkg.Tip("Set breakdown.command or breakdown.endpoint in .todo/config.yaml; see 'todo breakdown --help'.")
+			return
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		currentList, item, ok := currentItem(currentList, args[0])
+		if !ok {
+			return
+		}
+
+		fmt.Printf("Asking %s to break down '%s'...\n", provider.Name(), item.Text)
+		subtasks, err := pkg.BreakdownItem(provider, currentList, item.ID)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if dryRun {
+			fmt.Printf("Would add these subtasks under '%s':\n", item.Text)
+			for _, text := range subtasks {
+				fmt.Printf("  [ ] %s\n", text)
+			}
+			return
+		}
+
+		ids, err := pkg.AddSubtasks(currentList, item.ID, subtasks)
+		if err != nil {
+			fmt.Printf("Error adding subtasks: %v\n", err)
+			return
+		}
+		fmt.Printf("Added subtasks under '%s':\n", item.Text)
+		for i, text := range subtasks {
+			fmt.Printf("  %d. [ ] %s\n", ids[i], text)
+		}
+	},
+}
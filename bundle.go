@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Export a list to a file, or import one",
+	Long: `Package a list into a single file that can be handed to someone who
+doesn't share your repository:
+
+  todo bundle export <list> <file.todo>  Write the list and its archive to a file
+  todo bundle import <file.todo>         Create a list from a bundle
+
+A bundle keeps the list exactly as it is stored, including its frontmatter
+and item metadata. Importing never overwrites an existing list; use --as to
+import under a different name.`,
+}
+
+var bundleExportCmd = &cobra.Command{
+	Use:   "export <list> <file>",
+	Short: "Write a list and its archive to a bundle file",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		listName := pkg.ResolveListName(args[0])
+
+		if err := pkg.ExportBundle(listName, argPath(args[1])); err != nil {
+			fmt.Printf("Error exporting list: %v\n", err)
+			return
+		}
+		fmt.Printf("Exported list '%s' to %s\n", listName, args[1])
+	},
+}
+
+var bundleImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Create a list from a bundle file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		bundle, err := pkg.ReadBundle(argPath(args[0]))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		listName, _ := cmd.Flags().GetString("as")
+		if listName != "" {
+			listName = pkg.ResolveListName(listName)
+		}
+
+		listName, err = pkg.ImportBundle(bundle, listName)
+		if err != nil {
+			fmt.Printf("Error importing list: %v\n", err)
+			if pkg.TodoFileExists(bundle.List) {
+				pkg.Tip("Import it under another name with 'todo bundle import %s --as <name>'", args[0])
+			}
+			return
+		}
+		fmt.Printf("Imported list '%s' from %s\n", listName, args[0])
+	},
+}
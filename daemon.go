@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/scttymn/todo-cli/pkg/notify"
+	"github.com/spf13/cobra"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep lists indexed in memory for fast queries",
+	Long: `Run a daemon for this project until interrupted. It keeps every list parsed
+in memory, watches the files for changes and answers queries over a unix
+socket in .todo (daemon.sock). While it runs, commands such as 'todo count'
+and 'todo agenda' ask it instead of reading every list, and 'todo remind'
+leaves reminders to it instead of the OS scheduler. Editor extensions can use
+the same socket to list, toggle and watch items; see "Editor protocol" in the
+README.
+
+Start it from your shell profile or a user service, e.g.:
+
+  (cd /path/to/project && todo daemon &)
+
+  todo daemon          Run the daemon in the foreground
+  todo daemon status   Show whether a daemon is running`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		daemon := pkg.NewDaemon()
+		daemon.OnReminder = func(reminder pkg.Reminder) {
+			if err := notify.Desktop("todo: "+reminder.List, reminder.Text); err != nil {
+				fmt.Printf("Error: %v\n", err)
+			}
+		}
+		if err := daemon.Listen(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		stop := make(chan struct{})
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			close(stop)
+		}()
+
+		fmt.Printf("Daemon listening on %s (Ctrl-C to stop)\n", pkg.GetDaemonSocketPath())
+		if err := daemon.Serve(stop); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	},
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether a daemon is running",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		var status pkg.DaemonStatus
+		if err := pkg.AskDaemon(&status, "ping"); err != nil {
+			if errors.Is(err, pkg.ErrDaemonUnavailable) && jsonOutput(cmd) {
+				printJSON(map[string]bool{"running": false})
+				return
+			}
+			if errors.Is(err, pkg.ErrDaemonUnavailable) {
+				fmt.Println("No daemon is running.")
+				pkg.Tip("Start one with 'todo daemon'.")
+				return
+			}
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if jsonOutput(cmd) {
+			status.Started = status.Started.Local()
+			printJSON(struct {
+				Running bool `json:"running"`
+				pkg.DaemonStatus
+			}{true, status})
+			return
+		}
+		fmt.Printf("Daemon running (pid %d) since %s\n", status.PID, status.Started.Local().Format(time.DateTime))
+		fmt.Printf("Lists indexed: %d\n", status.Lists)
+		fmt.Printf("Reminders scheduled: %d\n", status.Reminders)
+	},
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var dueCmd = &cobra.Command{
+	Use:   "due",
+	Short: "Show items coming due across all lists",
+	Long: `Show the pending items due today or in the next days across all lists,
+soonest first. Overdue items are left out; 'todo overdue' shows those.
+
+Use --days to look further ahead than a week.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		days, _ := cmd.Flags().GetInt("days")
+		if days < 0 {
+			fmt.Println("Error: --days can't be negative")
+			return
+		}
+
+		agenda, err := loadAgenda(false)
+		if err != nil {
+			fmt.Printf("Failed to load upcoming items: %v\n", err)
+			return
+		}
+		now := pkg.Now()
+		upcoming := pkg.UpcomingItems(agenda, now, days)
+
+		version, ok := porcelain(cmd)
+		if !ok {
+			return
+		}
+		if version != "" {
+			for _, entry := range upcoming {
+				pkg.WritePorcelainItem(os.Stdout, entry.List, entry.Item)
+			}
+			return
+		}
+		if jsonOutput(cmd) {
+			printListItems(upcoming)
+			return
+		}
+
+		if len(upcoming) == 0 {
+			fmt.Printf("Nothing due in the next %d days.\n", days)
+			return
+		}
+
+		fmt.Printf("Due in the next %d days:\n", days)
+		label := ""
+		for i, entry := range upcoming {
+			if next := pkg.DueLabel(*entry.Item.DueDate, now); next != label {
+				label = next
+				pkg.Blank()
+				fmt.Printf("%s%s (%s):\n", pkg.Emoji("📅"), strings.ToUpper(label[:1])+label[1:], entry.Item.DueDate.Format("Mon Jan 2"))
+			}
+			fmt.Printf("  %d. [ ] %s [%s #%d]\n", i+1, entry.Item.Text, entry.List, entry.Item.ID)
+		}
+		pkg.SaveView(upcoming)
+	},
+}
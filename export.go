@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write lists in a format other tools understand",
+	Long: `Write a list (default: the current list), or every list with --all, for
+reporting or another tool, with completion times and metadata:
+
+  todo export --format json             An array of lists with their items
+  todo export --format csv --all        A row per item of every list
+  todo export --format md --list auth   The list as its markdown file reads
+  todo export --format todotxt --all    A todo.txt line per item
+
+The export goes to standard output unless --out names a file. The
+subcommands write other formats:
+
+  todo export issue-body [list]   GitHub task list markdown for an issue or PR description
+  todo export timeseries [list...]  Daily pending and completed counts per list as CSV
+
+With storage: sqlite, 'todo export --markdown' writes every list in
+.todo/todo.db back to its .todo/<list>.md file.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		markdown, _ := cmd.Flags().GetBool("markdown")
+		format, _ := cmd.Flags().GetString("format")
+		if markdown && format != "" {
+			fmt.Println("Error: --markdown can't be combined with --format")
+			return
+		}
+		if !markdown && format == "" {
+			cmd.Help()
+			return
+		}
+		if requiresInit() {
+			return
+		}
+
+		if markdown {
+			names, err := pkg.ExportMarkdown()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Printf("Wrote %d list file(s) from %s\n", len(names), pkg.GetDatabasePath())
+			return
+		}
+
+		listName, _ := cmd.Flags().GetString("list")
+		all, _ := cmd.Flags().GetBool("all")
+		var names []string
+		switch {
+		case all && listName != "":
+			fmt.Println("Error: --all can't be combined with --list")
+			return
+		case all:
+			var err error
+			if names, err = pkg.GetAllLists(); err != nil {
+				fmt.Printf("Error reading lists: %v\n", err)
+				return
+			}
+		case listName != "":
+			listName = pkg.ResolveListName(listName)
+			if !pkg.TodoFileExists(listName) {
+				fmt.Printf("Error: list '%s' does not exist\n", listName)
+				return
+			}
+			names = []string{listName}
+		default:
+			current, err := pkg.GetCurrentList()
+			if err != nil {
+				fmt.Printf("Error getting current list: %v\n", err)
+				return
+			}
+			names = []string{current}
+		}
+
+		var out bytes.Buffer
+		if err := pkg.WriteExport(&out, format, names); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		path, _ := cmd.Flags().GetString("out")
+		path = argPath(path)
+		if path == "" {
+			os.Stdout.Write(out.Bytes())
+			return
+		}
+		if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+			fmt.Printf("Error writing export: %v\n", err)
+			return
+		}
+		fmt.Printf("Wrote %d list(s) to %s\n", len(names), path)
+	},
+}
+
+var exportIssueBodyCmd = &cobra.Command{
+	Use:   "issue-body [list]",
+	Short: "Print a list as GitHub task list markdown",
+	Long: `Print a list (default: the current list) as GitHub-flavored task list
+markdown, ready to paste into an issue or pull request description, where
+GitHub renders the checkboxes and shows their progress. Sections become
+headings, due dates stay visible and the todo file's metadata comments are
+left out:
+
+  todo export issue-body | pbcopy
+  gh issue create --title "Release" --body "$(todo export issue-body release)"`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		var listName string
+		if len(args) == 1 {
+			listName = pkg.ResolveListName(args[0])
+			if !pkg.TodoFileExists(listName) {
+				fmt.Printf("Error: list '%s' does not exist\n", listName)
+				return
+			}
+		} else {
+			var err error
+			if listName, err = pkg.GetCurrentList(); err != nil {
+				fmt.Printf("Error getting current list: %v\n", err)
+				return
+			}
+		}
+
+		todoList, err := pkg.ParseTodoFile(listName)
+		if err != nil {
+			fmt.Printf("Error reading list: %v\n", err)
+			return
+		}
+		fmt.Print(pkg.FormatIssueBody(todoList))
+	},
+}
+
+var exportTimeseriesCmd = &cobra.Command{
+	Use:   "timeseries [list...]",
+	Short: "Write daily pending and completed counts per list as CSV",
+	Long: `Write a row per list per day with its pending and completed counts, for
+charting burnup in a spreadsheet:
+
+  date,list,pending,completed,total,percent,recorded_percent
+  2024-07-01,auth,4,2,6,33,33
+
+Counts are worked out from the days items were added and completed, so
+items deleted since are left out and items from before lists recorded the
+day they were added count from the first day. recorded_percent is the
+percentage 'todo list' recorded that day, following the configured progress
+mode, and is empty on days without one. Rows start at the first day in the
+lists' history, or --since, and end today. Every list is included unless
+lists are named.
+
+  todo export timeseries --out progress.csv
+  todo export timeseries auth --since 2024-06-01`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		names, err := pkg.GetAllLists()
+		if err != nil {
+			fmt.Printf("Error reading lists: %v\n", err)
+			return
+		}
+		if len(args) > 0 {
+			names = nil
+			for _, arg := range args {
+				listName := pkg.ResolveListName(arg)
+				if !pkg.TodoFileExists(listName) {
+					fmt.Printf("Error: list '%s' does not exist\n", listName)
+					return
+				}
+				names = append(names, listName)
+			}
+		}
+
+		var since time.Time
+		if value, _ := cmd.Flags().GetString("since"); value != "" {
+			if since, err = time.ParseInLocation(pkg.DueDateFormat, value, time.Local); err != nil {
+				fmt.Printf("Error: invalid --since date '%s' (expected YYYY-MM-DD)\n", value)
+				return
+			}
+		}
+
+		points, err := pkg.ProgressTimeSeries(pkg.ParseLists(names), pkg.LoadSnapshots(), since, pkg.Now())
+		if err != nil {
+			fmt.Printf("Error reading lists: %v\n", err)
+			return
+		}
+
+		var out bytes.Buffer
+		if err := pkg.WriteProgressCSV(&out, points); err != nil {
+			fmt.Printf("Error writing time series: %v\n", err)
+			return
+		}
+		path, _ := cmd.Flags().GetString("out")
+		path = argPath(path)
+		if path == "" {
+			os.Stdout.Write(out.Bytes())
+			return
+		}
+		if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+			fmt.Printf("Error writing time series: %v\n", err)
+			return
+		}
+		fmt.Printf("Wrote %d rows to %s\n", len(points), path)
+	},
+}
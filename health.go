@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/scttymn/todo-cli/pkg"
+)
+
+// healthEmoji marks each health label
+var healthEmoji = map[string]string{"good": "🟢", "fair": "🟡", "poor": "🔴"}
+
+// printListHealth prints the health of every list, least healthy first, as
+// text or JSON
+func printListHealth(asJSON bool) {
+	cfg, err := pkg.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	healths, err := pkg.GetListHealth(cfg, pkg.Now())
+	if err != nil {
+		fmt.Printf("Error scoring lists: %v\n", err)
+		return
+	}
+	if asJSON {
+		printJSON(append([]pkg.ListHealth{}, healths...))
+		return
+	}
+	if len(healths) == 0 {
+		fmt.Println("No lists found")
+		return
+	}
+
+	nameWidth := 0
+	for _, health := range healths {
+		nameWidth = max(nameWidth, pkg.DisplayWidth(health.Name))
+	}
+
+	fmt.Println("List health:")
+	pkg.Blank()
+	for _, health := range healths {
+		label := pkg.HealthLabel(health.Score)
+		line := fmt.Sprintf("  %s %s%3d %s", pkg.PadRight(health.Name, nameWidth), pkg.Emoji(healthEmoji[label]), health.Score, label)
+		switch {
+		case health.Pending == 0:
+			line += " - finished"
+		case len(health.Reasons) > 0:
+			line += " - " + strings.Join(health.Reasons, ", ")
+		}
+		fmt.Println(line)
+	}
+	pkg.Tip("\nTune the score with health.weights (staleness, overdue, velocity, wip) and health.wip_limit in .todo/config.yaml.")
+}
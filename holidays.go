@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var holidaysCmd = &cobra.Command{
+	Use:   "holidays",
+	Short: "Show the holidays skipped by working-day due dates",
+	Long: `Show the upcoming holidays. Holidays are days off: due dates given in working
+days, such as 'todo add --due +3bd', skip them along with the days of the
+week outside calendar.working_days (default monday to friday), and
+recurring items that would fall on one move to the next working day.
+
+Import holidays from an iCalendar file or URL with 'todo holidays import'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		holidays, err := pkg.UpcomingHolidays(pkg.Now())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		limit, _ := cmd.Flags().GetInt("limit")
+		if limit > 0 && len(holidays) > limit {
+			holidays = holidays[:limit]
+		}
+		if jsonOutput(cmd) {
+			type jsonHoliday struct {
+				Date string `json:"date"`
+				Name string `json:"name"`
+			}
+			out := make([]jsonHoliday, 0, len(holidays))
+			for _, holiday := range holidays {
+				out = append(out, jsonHoliday{holiday.Date.Format(pkg.DueDateFormat), holiday.Name})
+			}
+			printJSON(out)
+			return
+		}
+		if len(holidays) == 0 {
+			fmt.Println("No upcoming holidays")
+			pkg.Tip("Import some with 'todo holidays import <file.ics|url>'.")
+			return
+		}
+
+		fmt.Println("Upcoming holidays:")
+		pkg.Blank()
+		for _, holiday := range holidays {
+			fmt.Printf("  %s  %s\n", holiday.Date.Format("Mon 2006-01-02"), holiday.Name)
+		}
+	},
+}
+
+var holidaysImportCmd = &cobra.Command{
+	Use:   "import <file.ics|url>",
+	Short: "Import holidays from an iCalendar file or URL",
+	Long: `Import the events of an iCalendar file or URL as holidays, such as a public
+holiday calendar. Every day an event covers becomes a day off, and yearly
+events are repeated for the next few years. Holidays are stored in
+.todo/holidays.json; importing again adds to them.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		source := args[0]
+		if !strings.Contains(source, "://") {
+			source = argPath(source)
+		}
+		added, err := pkg.ImportHolidays(source, pkg.Now())
+		if err != nil {
+			fmt.Printf("Error importing holidays: %v\n", err)
+			return
+		}
+		fmt.Printf("Imported %d holidays from %s\n", added, args[0])
+	},
+}
+
+var holidaysClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Forget every imported holiday",
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		if err := pkg.ClearHolidays(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Println("Cleared the holidays")
+	},
+}
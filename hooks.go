@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Manage the git hooks used by todo",
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the todo git hooks into the current repository",
+	Long: `Install git hooks that keep todo lists in step with your branches:
+
+  post-checkout       Show (or create) the branch's list after switching branches
+  post-merge          Offer to archive a feature list once its branch is merged
+  prepare-commit-msg  Add the merged list's summary to the merge commit message
+
+Existing hooks that were not installed by todo are left untouched.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		if err := pkg.InstallHooks(); err != nil {
+			fmt.Printf("Error installing hooks: %v\n", err)
+			return
+		}
+
+		// Record in the config that the hooks are installed
+		cfg, err := pkg.LoadProjectConfig()
+		if err == nil && !cfg.Hooks {
+			cfg.Hooks = true
+			err = pkg.SaveConfig(cfg)
+		}
+		if err != nil {
+			fmt.Printf("Error recording hooks in config: %v\n", err)
+			return
+		}
+
+		fmt.Println("Installed git hooks: post-checkout, post-merge, prepare-commit-msg")
+	},
+}
+
+var hookCmd = &cobra.Command{
+	Use:    "hook [hook-name] [hook-args...]",
+	Short:  "Entry point for git hooks installed by todo",
+	Hidden: true,
+	Args:   cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := pkg.LoadConfig()
+		if err != nil || !pkg.GitEnabled(cfg) {
+			return
+		}
+
+		switch args[0] {
+		case "post-checkout":
+			runPostCheckoutHook(cfg, args[1:])
+		case "post-merge":
+			runPostMergeHook(cfg)
+		case "prepare-commit-msg":
+			runPrepareCommitMsgHook(args[1:])
+		default:
+			fmt.Printf("Error: unknown hook: %s\n", args[0])
+		}
+	},
+}
+
+// runPostCheckoutHook shows the branch's list after switching branches
+func runPostCheckoutHook(cfg *pkg.Config, args []string) {
+	// post-checkout receives <prev-head> <new-head> <branch-flag>;
+	// file checkouts pass a branch flag of 0
+	if len(args) > 2 && args[2] != "1" {
+		return
+	}
+	if !cfg.BranchTracking {
+		return
+	}
+
+	currentList, err := pkg.GetCurrentList()
+	if err != nil {
+		return
+	}
+
+	if !pkg.TodoFileExists(currentList) {
+		if err := pkg.CreateTodoFile(currentList); err != nil {
+			fmt.Printf("Error creating todo file: %v\n", err)
+			return
+		}
+		fmt.Printf("Created todo list '%s'\n", currentList)
+	} else {
+		fmt.Printf("Switched to list '%s'\n", currentList)
+	}
+	if branch, err := pkg.GetCurrentBranch(); err == nil {
+		pkg.LinkListToBranch(currentList, branch)
+	}
+
+	pkg.DisplayTodoList(currentList)
+}
+
+// runPostMergeHook offers to archive the list of a branch that was just
+// merged into the default branch
+func runPostMergeHook(cfg *pkg.Config) {
+	listName := pkg.MergedList(pkg.MergedBranchFromReflog())
+	if listName == "" {
+		return
+	}
+
+	switch cfg.ArchiveOnMerge {
+	case pkg.ArchiveOnMergeOff:
+		return
+	case pkg.ArchiveOnMergePrompt:
+		// Hooks don't get the terminal on stdin, so ask on the tty directly
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			fmt.Printf("List '%s' belongs to a merged branch; archive it with 'todo prune'\n", listName)
+			return
+		}
+		defer tty.Close()
+
+		if !promptYesNo(bufio.NewReader(tty), fmt.Sprintf("Archive list '%s' now that its branch is merged?", listName), false) {
+			return
+		}
+	}
+
+	if err := pkg.ArchiveList(listName); err != nil {
+		fmt.Printf("Error archiving list '%s': %v\n", listName, err)
+		return
+	}
+	fmt.Printf("Archived list '%s'\n", listName)
+}
+
+// runPrepareCommitMsgHook appends the merged list's summary to the message
+// of a merge commit
+func runPrepareCommitMsgHook(args []string) {
+	// prepare-commit-msg receives <message-file> [<source> [<sha>]]
+	if len(args) < 2 || args[1] != "merge" {
+		return
+	}
+
+	messageFile := args[0]
+	message, err := os.ReadFile(messageFile)
+	if err != nil {
+		return
+	}
+
+	listName := pkg.MergedList(pkg.MergedBranchFromMessage(string(message)))
+	if listName == "" {
+		return
+	}
+
+	summary, err := pkg.ListSummary(listName)
+	if err != nil {
+		return
+	}
+
+	file, err := os.OpenFile(messageFile, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	fmt.Fprintf(file, "\n%s", summary)
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import [file.md|todo.txt]",
+	Short: "Import items from a markdown or todo.txt file, or other tools",
+	Long: `Import the task list of a markdown file, such as an existing TODO.md or an
+issue body saved to disk, into a list (default: the current list):
+
+  todo import TODO.md                 Append its tasks to the current list
+  todo import notes.md --list ideas   Append them to another list
+  todo import ~/todo/todo.txt         Import the tasks of a todo.txt file
+
+Every '- [ ]' and '- [x]' item is imported, checked or not, as 'todo paste'
+imports them: headings, and plain bullets with tasks nested under them,
+become sections.
+
+Files ending in .txt are read as todo.txt (--format md or todotxt picks
+the format). Priorities (A) to (C) become p1 to p3, later letters p3, and
+completion dates, creation dates and due: tags are kept; +projects,
+@contexts and other tags stay in the text.
+
+The subcommands import from other tools.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			cmd.Help()
+			return
+		}
+		if requiresInit() {
+			return
+		}
+
+		content, err := os.ReadFile(argPath(args[0]))
+		if err != nil {
+			fmt.Printf("Error reading %s: %v\n", args[0], err)
+			return
+		}
+
+		listName, _ := cmd.Flags().GetString("list")
+		if listName == "" {
+			if listName, err = pkg.GetCurrentList(); err != nil {
+				fmt.Printf("Error getting current list: %v\n", err)
+				return
+			}
+		} else {
+			listName = pkg.ResolveListName(listName)
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		if format == "" {
+			format = "md"
+			if strings.EqualFold(filepath.Ext(args[0]), ".txt") {
+				format = "todotxt"
+			}
+		}
+		var items []pkg.TodoItem
+		switch format {
+		case "md":
+			items = pkg.ParsePastedMarkdown(string(content))
+		case "todotxt":
+			items = pkg.ParseTodoTxt(string(content))
+		default:
+			fmt.Printf("Error: invalid import format '%s' (expected md or todotxt)\n", format)
+			return
+		}
+		if len(items) == 0 {
+			if format == "md" {
+				fmt.Printf("No task list items found in %s (expected lines such as '- [ ] Task').\n", args[0])
+			} else {
+				fmt.Printf("No tasks found in %s.\n", args[0])
+			}
+			return
+		}
+		if err := pkg.PasteItems(listName, items); err != nil {
+			fmt.Printf("Error importing items: %v\n", err)
+			return
+		}
+
+		completed := 0
+		for _, item := range items {
+			if item.Completed {
+				completed++
+			}
+		}
+		fmt.Printf("Imported %d item(s) (%d completed) from %s into list '%s'\n", len(items), completed, args[0], listName)
+	},
+}
+
+var importPRCommentsCmd = &cobra.Command{
+	Use:   "pr-comments <pr#>",
+	Short: "Import unresolved review comments of a GitHub pull request",
+	Long: `Add the unresolved review comments of a GitHub pull request to a list
+(default: the current list), one item per review thread, each linking to the
+file and line it was made on. Comments imported before are skipped, so the
+command can be run again as the review goes on.
+
+The repository is taken from the origin remote unless --repo is given. A
+GitHub token is read from TODO_GITHUB_TOKEN or 'todo auth login github'.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil || number < 1 {
+			fmt.Printf("Error: invalid pull request number: %s\n", args[0])
+			return
+		}
+
+		listName, _ := cmd.Flags().GetString("list")
+		if listName == "" {
+			if listName, err = pkg.GetCurrentList(); err != nil {
+				fmt.Printf("Error getting current list: %v\n", err)
+				return
+			}
+		} else {
+			listName = pkg.ResolveListName(listName)
+		}
+
+		owner, name, ok := gitHubRepo(cmd)
+		if !ok {
+			return
+		}
+
+		client, err := pkg.NewGitHubClient()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		comments, err := pkg.UnresolvedReviewComments(client, owner, name, number)
+		if err != nil {
+			fmt.Printf("Failed to fetch review comments: %v\n", err)
+			return
+		}
+
+		added, err := pkg.ImportReviewComments(listName, comments)
+		if err != nil {
+			fmt.Printf("Failed to import review comments: %v\n", err)
+			return
+		}
+		if skipped := len(comments) - added; skipped > 0 {
+			fmt.Printf("Imported %d review comment(s) from %s/%s#%d into list '%s' (%d already imported)\n", added, owner, name, number, listName, skipped)
+		} else {
+			fmt.Printf("Imported %d review comment(s) from %s/%s#%d into list '%s'\n", added, owner, name, number, listName)
+		}
+	},
+}
+
+// gitHubRepo returns the repository named by --repo, or the one the origin
+// remote points to. It prints the problem and reports false on failure.
+func gitHubRepo(cmd *cobra.Command) (string, string, bool) {
+	if repo, _ := cmd.Flags().GetString("repo"); repo != "" {
+		owner, name, ok := strings.Cut(repo, "/")
+		if !ok || owner == "" || name == "" {
+			fmt.Printf("Error: --repo must be owner/name, got %q\n", repo)
+			return "", "", false
+		}
+		return owner, name, true
+	}
+
+	owner, name, err := pkg.CurrentGitHubRepo()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		pkg.Tip("Use --repo owner/name to pick the repository")
+		return "", "", false
+	}
+	return owner, name, true
+}
+
+var importLinearCmd = &cobra.Command{
+	Use:   "linear [list]",
+	Short: "Import issues from Linear into the lists mapped to it",
+	Long: `Add the issues of the Linear teams and projects mapped in .todo/config.yaml
+to their lists, or only to the given list, and bring already imported items
+up to date with their issues:
+
+  linear:
+    lists:
+      auth:
+        team: ENG
+        project: Authentication
+
+Use 'todo sync --provider linear' to also send your changes to Linear. An
+API key is read from TODO_LINEAR_TOKEN or 'todo auth login linear'.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		listName := ""
+		if len(args) == 1 {
+			listName = pkg.ResolveListName(args[0])
+		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		force, _ := cmd.Flags().GetBool("force")
+
+		ctx, stop := interruptible(cmd)
+		defer stop()
+		changes, err := pkg.ImportFromLinear(ctx, listName, pkg.SyncOptions{DryRun: dryRun, Force: force})
+		if printInterrupted(err) {
+			return
+		}
+		if err != nil {
+			fmt.Printf("Failed to import from Linear: %v\n", err)
+			return
+		}
+		reportSyncChanges(changes, dryRun, "imported", "import")
+	},
+}
+
+var importAsanaCmd = &cobra.Command{
+	Use:   "asana --project <id>",
+	Short: "Import the tasks of an Asana project",
+	Long: `Add the tasks of an Asana project to a list (default: the current list).
+Each Asana section becomes a section of the list, tags become #tags, and due
+dates, completion and assignees are kept. Tasks imported before are updated
+from Asana instead of being added again, so the command can be rerun to
+refresh the list.
+
+An Asana personal access token is read from TODO_ASANA_TOKEN or 'todo auth
+login asana'.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		project, _ := cmd.Flags().GetString("project")
+		if project == "" {
+			fmt.Println("Error: --project is required (the id in the project's URL)")
+			return
+		}
+
+		listName, _ := cmd.Flags().GetString("list")
+		var err error
+		if listName == "" {
+			if listName, err = pkg.GetCurrentList(); err != nil {
+				fmt.Printf("Error getting current list: %v\n", err)
+				return
+			}
+		} else {
+			listName = pkg.ResolveListName(listName)
+		}
+
+		client, err := pkg.NewAsanaClient()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		tasks, err := pkg.AsanaProjectTasks(client, project)
+		if err != nil {
+			fmt.Printf("Failed to fetch Asana tasks: %v\n", err)
+			return
+		}
+
+		added, updated, err := pkg.ImportAsanaTasks(listName, tasks, pkg.Now())
+		if err != nil {
+			fmt.Printf("Failed to import Asana tasks: %v\n", err)
+			return
+		}
+		fmt.Printf("Imported %d new and updated %d task(s) from Asana project %s into list '%s'\n", added, updated, project, listName)
+	},
+}
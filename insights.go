@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+// insightsTop is how many commands and lists insights show
+const insightsTop = 5
+
+var insightsCmd = &cobra.Command{
+	Use:   "insights",
+	Short: "Show how you use todo and suggest workflow tweaks",
+	Long: `Look back over your own usage, from .todo/journal.jsonl and the lists,
+and show your most-used commands, busiest hours and most-changed lists, with
+suggestions such as lists with items that were never triaged.
+
+Everything stays on this machine: nothing is sent anywhere. Which commands
+you run is only recorded once you opt in with 'insights: true' in
+.todo/config.yaml, and then only their names, never their arguments.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		days, _ := cmd.Flags().GetInt("days")
+		if days < 1 {
+			fmt.Println("Error: --days must be at least 1")
+			return
+		}
+		cfg, err := pkg.LoadConfig()
+		if err != nil {
+			fmt.Printf("Error loading config: %v\n", err)
+			return
+		}
+		insights, err := pkg.GetInsights(cfg, pkg.Now(), days)
+		if err != nil {
+			fmt.Printf("Error reading the journal: %v\n", err)
+			return
+		}
+		if jsonOutput(cmd) {
+			printJSON(insights)
+			return
+		}
+
+		fmt.Printf("%sInsights for the last %d days, since %s\n", pkg.Emoji("🔎"), days, insights.Since.Format(pkg.DueDateFormat))
+		if len(insights.Commands) > 0 {
+			pkg.Blank()
+			fmt.Println("Most-used commands:")
+			printCounts(insights.Commands)
+			pkg.Blank()
+			fmt.Println("Busiest hours:")
+			printBusiestHours(insights.Hours)
+		}
+		if len(insights.Lists) > 0 {
+			pkg.Blank()
+			fmt.Println("Most-changed lists (times written):")
+			printCounts(insights.Lists)
+		}
+		if len(insights.Suggestions) > 0 {
+			pkg.Blank()
+			fmt.Println("Suggestions:")
+			for _, suggestion := range insights.Suggestions {
+				fmt.Printf("  - %s\n", suggestion)
+			}
+		}
+		if len(insights.Commands) == 0 && len(insights.Lists) == 0 && len(insights.Suggestions) == 0 {
+			pkg.Blank()
+			fmt.Println("Nothing to report yet.")
+		}
+		if !insights.Recording {
+			pkg.Blank()
+			pkg.Tip("Commands aren't being recorded; set 'insights: true' in .todo/config.yaml to see your most-used commands and busiest hours")
+		}
+	},
+}
+
+// printCounts prints the top counts with their names lined up
+func printCounts(counts []pkg.Count) {
+	counts = counts[:min(len(counts), insightsTop)]
+	width := 0
+	for _, count := range counts {
+		width = max(width, pkg.DisplayWidth(count.Name))
+	}
+	for _, count := range counts {
+		fmt.Printf("  %s  %d\n", pkg.PadRight(count.Name, width), count.Count)
+	}
+}
+
+// printBusiestHours prints the three hours of the day most commands were run
+// in, busiest first, with a bar for each
+func printBusiestHours(hours [24]int) {
+	order := make([]int, 24)
+	for hour := range order {
+		order[hour] = hour
+	}
+	sort.SliceStable(order, func(i, j int) bool { return hours[order[i]] > hours[order[j]] })
+
+	busiest := hours[order[0]]
+	for _, hour := range order[:3] {
+		if hours[hour] == 0 {
+			break
+		}
+		bar := strings.Repeat("█", max(1, hours[hour]*20/busiest))
+		fmt.Printf("  %02d:00-%02d:00  %s %d\n", hour, (hour+1)%24, bar, hours[hour])
+	}
+}
+
+// recordUsage journals the command that just ran for 'todo insights', if
+// insights are turned on
+func recordUsage(cmd *cobra.Command) {
+	cfg, err := pkg.LoadConfig()
+	if err != nil || cmd.Hidden {
+		return
+	}
+	command := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	if err := pkg.RecordCommand(cfg, command); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // setupIntegrationTest creates a temporary directory and builds the CLI binary
@@ -97,15 +101,10 @@ func TestListCommand(t *testing.T) {
 	}
 	
 	// Check that list was created and switched
-	if !strings.Contains(stdout, "Created and switched to list 'authentication'") {
+	if !strings.Contains(stdout, "Created todo list 'authentication'") {
 		t.Errorf("Expected list creation message, got: %s", stdout)
 	}
 	
-	// Check that todo file was created
-	if !strings.Contains(stdout, "Initialized todo file: .todo/authentication.md") {
-		t.Errorf("Expected todo file creation message, got: %s", stdout)
-	}
-	
 	// Verify the todo file exists
 	todoFile := ".todo/authentication.md"
 	if _, err := os.Stat(todoFile); os.IsNotExist(err) {
@@ -119,7 +118,7 @@ func TestListCommand(t *testing.T) {
 		t.Fatalf("list command failed for existing list with exit code %d, stderr: %s", exitCode, stderr)
 	}
 	
-	if !strings.Contains(stdout, "Switched to existing list 'authentication'") {
+	if !strings.Contains(stdout, "Switched to list 'authentication'") {
 		t.Errorf("Expected list switch message, got: %s", stdout)
 	}
 	
@@ -146,7 +145,7 @@ func TestAddCheckUncheckWorkflow(t *testing.T) {
 	if exitCode != 0 {
 		t.Fatalf("add command failed with exit code %d, stderr: %s", exitCode, stderr)
 	}
-	if !strings.Contains(stdout, "Added todo item to feature 'testing': First todo item") {
+	if !strings.Contains(stdout, "Added todo item to list 'testing': First todo item") {
 		t.Errorf("Expected add confirmation, got: %s", stdout)
 	}
 	
@@ -248,7 +247,7 @@ func TestVersionCommand(t *testing.T) {
 		t.Fatalf("version command failed with exit code %d, stderr: %s", exitCode, stderr)
 	}
 	
-	if !strings.Contains(stdout, "todo CLI v0.1.0") {
+	if !strings.Contains(stdout, "todo CLI v0.3.0") {
 		t.Errorf("Expected version string, got: %s", stdout)
 	}
 }
@@ -268,4 +267,2613 @@ func TestHelpCommand(t *testing.T) {
 			t.Errorf("Expected to find command %s in help output, got: %s", cmd, stdout)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestInitCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+	
+	// --yes skips the wizard and only creates the .todo directory
+	stdout, stderr, exitCode := runCLI(t, binaryPath, "init", "--yes")
+	if exitCode != 0 {
+		t.Fatalf("init --yes failed with exit code %d, stderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "Todo management initialized successfully") {
+		t.Errorf("Expected init confirmation, got: %s", stdout)
+	}
+	if _, err := os.Stat(".todo/config.yaml"); !os.IsNotExist(err) {
+		t.Error("init --yes should not write a config file")
+	}
+	
+	// The wizard reads answers from stdin: default list, visibility, branch tracking, hooks
+	stdout, stderr, exitCode = runCLIWithInput(t, binaryPath, "work\ncommitted\ny\ny\n", "init")
+	if exitCode != 0 {
+		t.Fatalf("init failed with exit code %d, stderr: %s", exitCode, stderr)
+	}
+	
+	config, err := os.ReadFile(".todo/config.yaml")
+	if err != nil {
+		t.Fatalf("Expected config file to be written: %v", err)
+	}
+	for _, expected := range []string{"default_list: work", "visibility: committed", "branch_tracking: true", "hooks: true"} {
+		if !strings.Contains(string(config), expected) {
+			t.Errorf("Expected %q in config, got: %s", expected, config)
+		}
+	}
+	
+	gitignore, _ := os.ReadFile(".gitignore")
+	if strings.Contains("\n"+string(gitignore), "\n.todo/\n") {
+		t.Errorf("Expected .todo/ not to be gitignored, got: %s", gitignore)
+	}
+	
+	if _, err := os.Stat(".git/hooks/post-checkout"); err != nil {
+		t.Errorf("Expected post-checkout hook to be installed: %v", err)
+	}
+}
+
+func TestQuietOutputContract(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	// The first line of each command's output is a stable, documented format
+	tests := []struct {
+		args      []string
+		firstLine string
+	}{
+		{[]string{"init", "--yes"}, "Todo management initialized successfully!"},
+		{[]string{"list", "auth"}, "Created todo list 'auth'"},
+		{[]string{"add", "Login form"}, "Added todo item to list 'auth': Login form"},
+		{[]string{"check", "1"}, "Marked item 1 as completed in list 'auth'"},
+		{[]string{"progress"}, "Todo list for branch 'auth':"},
+		{[]string{"list"}, "Lists:"},
+		{[]string{"history"}, "Completed Todo History:"},
+		{[]string{"uncheck", "1"}, "Marked item 1 as not completed in list 'auth'"},
+		// Errors older versions reported keep their wording
+		{[]string{"check", "abc"}, "Invalid item number: abc"},
+		{[]string{"uncheck", "abc"}, "Invalid item number: abc"},
+		{[]string{"progress", "missing"}, "List 'missing' does not exist"},
+		{[]string{"list", "--delete", "missing"}, "List 'missing' does not exist"},
+	}
+
+	for _, test := range tests {
+		args := append([]string{"--quiet"}, test.args...)
+		stdout, stderr, exitCode := runCLIWithInput(t, binaryPath, "y\n", args...)
+		if exitCode != 0 {
+			t.Fatalf("%v failed with exit code %d, stderr: %s", args, exitCode, stderr)
+		}
+
+		firstLine := strings.SplitN(stdout, "\n", 2)[0]
+		if firstLine != test.firstLine {
+			t.Errorf("%v: first line = %q, want %q", test.args, firstLine, test.firstLine)
+		}
+	}
+
+	// Quiet mode drops emoji and tips
+	runCLI(t, binaryPath, "check", "1")
+	stdout, _, _ := runCLI(t, binaryPath, "-q", "history")
+	if strings.Contains(stdout, "✅") || strings.Contains(stdout, "📅") {
+		t.Errorf("Expected no emoji in quiet output, got: %s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "init", "--yes")
+	if !strings.Contains(stdout, "✅") || !strings.Contains(stdout, "todo list <name>") {
+		t.Errorf("Expected decorations without --quiet, got: %s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "init", "--yes", "--quiet")
+	if strings.Contains(stdout, "todo list <name>") {
+		t.Errorf("Expected no tips with --quiet, got: %s", stdout)
+	}
+}
+
+func TestOutputDetail(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "ops")
+	runCLI(t, binaryPath, "add", "Rotate keys (due: 2030-01-02)")
+	runCLI(t, binaryPath, "add", "Patch hosts")
+	runCLI(t, binaryPath, "check", "2")
+
+	// Minimal output has no blank or decorative lines
+	for _, args := range [][]string{{"progress"}, {"list"}, {"history"}, {"agenda"}} {
+		stdout, _, _ := runCLI(t, binaryPath, append([]string{"--output-detail", "minimal"}, args...)...)
+		if strings.Contains(stdout, "\n\n") || strings.Contains(stdout, "📅") || strings.Contains(stdout, "✅") {
+			t.Errorf("%v: expected one line per fact, got:\n%s", args, stdout)
+		}
+	}
+	stdout, _, _ := runCLI(t, binaryPath, "--output-detail", "minimal", "progress")
+	if stdout != "Todo list for branch 'ops':\n1. [ ] Rotate keys\n2. [x] Patch hosts\nProgress: 1/2 completed\n" {
+		t.Errorf("minimal progress = %q", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "--output-detail", "rich", "progress")
+	for _, want := range []string{"1. [ ] Rotate keys (due 2030-01-02)", "[##########..........] 50%"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in rich output:\n%s", want, stdout)
+		}
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "--output-detail", "rich", "list")
+	if !strings.Contains(stdout, "ops - 1/2 completed (50%) [##########..........]") {
+		t.Errorf("Expected a progress bar in the rich overview:\n%s", stdout)
+	}
+
+	stdout, _, exitCode := runCLI(t, binaryPath, "--output-detail", "loud", "progress")
+	if exitCode == 0 || !strings.HasPrefix(stdout, "Error") {
+		t.Errorf("Expected an error for an unknown level, got %q (exit %d)", stdout, exitCode)
+	}
+}
+
+func TestListFormatJSON(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "ops")
+	runCLI(t, binaryPath, "add", "Rotate keys #security")
+	runCLI(t, binaryPath, "add", "Patch hosts")
+	runCLI(t, binaryPath, "check", "2")
+
+	stdout, stderr, exitCode := runCLI(t, binaryPath, "list", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("list --format json failed with exit code %d, stderr: %s", exitCode, stderr)
+	}
+
+	var overview struct {
+		Current string `json:"current"`
+		Lists   []struct {
+			Name      string   `json:"name"`
+			Current   bool     `json:"current"`
+			Pending   int      `json:"pending"`
+			Completed int      `json:"completed"`
+			Tags      []string `json:"tags"`
+		} `json:"lists"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &overview); err != nil {
+		t.Fatalf("Expected JSON output, got: %s (%v)", stdout, err)
+	}
+
+	if overview.Current != "ops" || len(overview.Lists) != 1 {
+		t.Fatalf("Unexpected overview: %+v", overview)
+	}
+	list := overview.Lists[0]
+	if !list.Current || list.Pending != 1 || list.Completed != 1 || len(list.Tags) != 1 || list.Tags[0] != "security" {
+		t.Errorf("Unexpected list overview: %+v", list)
+	}
+}
+
+func TestRendererFormats(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "ops")
+	runCLI(t, binaryPath, "add", "Rotate keys", "--priority", "p1")
+	runCLI(t, binaryPath, "add", "Patch hosts")
+	runCLI(t, binaryPath, "check", "2")
+
+	stdout, _, _ := runCLI(t, binaryPath, "progress", "--format", "table")
+	if !strings.Contains(stdout, "PRIORITY") || !strings.Contains(stdout, "Rotate keys") || strings.Contains(stdout, "DUE") {
+		t.Errorf("Expected a table of the items, got:\n%s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "ops", "--format", "plain")
+	if !strings.HasPrefix(stdout, "1. [ ] Rotate keys (p1)\n2. [x] Patch hosts\n") {
+		t.Errorf("Expected the plain list without a heading, got:\n%s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--all", "--format", "color")
+	if !strings.Contains(stdout, "ops - 1/2 completed (50%)") {
+		t.Errorf("Expected the overview, got:\n%s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--format", "json")
+	var list struct {
+		Name  string `json:"name"`
+		Items []struct {
+			Priority string `json:"priority"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &list); err != nil || list.Name != "ops" || len(list.Items) != 2 || list.Items[0].Priority != "p1" {
+		t.Errorf("Unexpected JSON list %q (%v)", stdout, err)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "list", "--format", "xml")
+	if !strings.Contains(stdout, "Error: unknown format 'xml' (expected text, color, json, plain, table)") {
+		t.Errorf("Expected an error for an unknown format, got %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "list", "ops", "--format", "table")
+	if !strings.Contains(stdout, "PRIORITY") || strings.Contains(stdout, "Switched to list") {
+		t.Errorf("Expected the list drawn alone as a table, got %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "list", "ops", "--format", "table", "--target", "2030-01-01")
+	if !strings.Contains(stdout, "Error: --format can't be combined with --target, --depends-on, --caldav, --owner or --reviewers") {
+		t.Errorf("Expected an error for a format with list settings, got %q", stdout)
+	}
+}
+
+func TestListNameCaseMatching(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "auth")
+	runCLI(t, binaryPath, "add", "Login form")
+
+	// A name that only differs in case switches to the existing list
+	stdout, _, _ := runCLI(t, binaryPath, "list", "Auth")
+	if !strings.Contains(stdout, "Switched to list 'auth'") || !strings.Contains(stdout, "Login form") {
+		t.Errorf("Expected to switch to 'auth', got: %s", stdout)
+	}
+
+	// Duplicates made outside the CLI are reported and can be merged
+	os.WriteFile(filepath.Join(tempDir, ".todo", "AUTH.md"), []byte("# Todo List for AUTH\n\n- [ ] Logout\n"), 0644)
+	stdout, _, _ = runCLI(t, binaryPath, "list")
+	if !strings.Contains(stdout, "merge-case-duplicates") {
+		t.Errorf("Expected a duplicate warning, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "list", "--merge-case-duplicates")
+	if !strings.Contains(stdout, "Merged 'AUTH' into 'auth'") {
+		t.Errorf("Unexpected merge output: %s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "auth")
+	if !strings.Contains(stdout, "Login form") || !strings.Contains(stdout, "Logout") {
+		t.Errorf("Expected merged items, got: %s", stdout)
+	}
+}
+
+func TestPorcelainOutput(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "ops")
+	runCLI(t, binaryPath, "add", "Rotate\tkeys")
+	runCLI(t, binaryPath, "add", "Patch hosts (due: 2024-07-01)")
+	runCLI(t, binaryPath, "check", "1")
+
+	expected := map[string]string{
+		"progress": "ops\t1\tcompleted\tRotate\\tkeys\nops\t2\tpending\tPatch hosts\n",
+		"list":     "ops\t1\tcompleted\tRotate\\tkeys\nops\t2\tpending\tPatch hosts\n",
+		"history":  "ops\t1\tcompleted\tRotate\\tkeys\n",
+		"agenda":   "ops\t2\tpending\tPatch hosts\n",
+	}
+	for command, want := range expected {
+		stdout, stderr, exitCode := runCLI(t, binaryPath, command, "--porcelain")
+		if exitCode != 0 {
+			t.Fatalf("%s --porcelain failed with exit code %d, stderr: %s", command, exitCode, stderr)
+		}
+		if stdout != want {
+			t.Errorf("%s --porcelain = %q, want %q", command, stdout, want)
+		}
+	}
+
+	stdout, _, _ := runCLI(t, binaryPath, "progress", "--porcelain=v1")
+	if stdout != expected["progress"] {
+		t.Errorf("--porcelain=v1 = %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--porcelain=v9")
+	if !strings.HasPrefix(stdout, "Error") {
+		t.Errorf("Expected an error for an unknown version, got %q", stdout)
+	}
+}
+
+func TestQuickfixOutput(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "ops")
+	runCLI(t, binaryPath, "add", "Rotate keys")
+	runCLI(t, binaryPath, "add", "Patch hosts")
+	runCLI(t, binaryPath, "check", "1")
+
+	stdout, _, _ := runCLI(t, binaryPath, "progress", "--format", "quickfix")
+	if stdout != ".todo/ops.md:3: [x] Rotate keys\n.todo/ops.md:4: [ ] Patch hosts\n" {
+		t.Errorf("progress --format quickfix = %q", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "search", "PATCH", "--format", "quickfix")
+	if stdout != ".todo/ops.md:4: [ ] Patch hosts\n" {
+		t.Errorf("search --format quickfix = %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "search", "keys")
+	if !strings.Contains(stdout, "[x] Rotate keys [ops #1]") {
+		t.Errorf("search = %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "search", "nothing")
+	if !strings.Contains(stdout, "No items match") {
+		t.Errorf("Expected no matches, got %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "search", "keys", "--format", "xml")
+	if !strings.HasPrefix(stdout, "Error") {
+		t.Errorf("Expected an error for an unknown format, got %q", stdout)
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "ops")
+	runCLI(t, binaryPath, "add", "Rotate keys")
+	runCLI(t, binaryPath, "add", "Patch hosts")
+	runCLI(t, binaryPath, "add", "Rotate certificates")
+	runCLI(t, binaryPath, "check", "1")
+
+	stdout, _, _ := runCLI(t, binaryPath, "search", "--regex", "^(rotate|patch) [kh]")
+	if !strings.Contains(stdout, "Rotate keys [ops #1]") || !strings.Contains(stdout, "Patch hosts [ops #2]") || strings.Contains(stdout, "certificates") {
+		t.Errorf("search --regex = %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "search", "rotate", "--pending")
+	if strings.Contains(stdout, "Rotate keys") || !strings.Contains(stdout, "[ ] Rotate certificates [ops #3]") {
+		t.Errorf("search --pending = %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "search", "rotate", "--completed")
+	if !strings.Contains(stdout, "[x] Rotate keys [ops #1]") || strings.Contains(stdout, "certificates") {
+		t.Errorf("search --completed = %q", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "search", "rotate", "--pending", "--completed")
+	if !strings.Contains(stdout, "Error: --pending can't be combined with --completed") {
+		t.Errorf("Expected an error for both filters, got %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "search", "--regex", "(")
+	if !strings.Contains(stdout, "Error: invalid regular expression") {
+		t.Errorf("Expected an error for a bad pattern, got %q", stdout)
+	}
+}
+
+func TestCountCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "ops")
+	runCLI(t, binaryPath, "add", "Rotate keys (due: 2000-01-01)")
+	runCLI(t, binaryPath, "add", "Patch hosts")
+	runCLI(t, binaryPath, "add", "Audit")
+	runCLI(t, binaryPath, "check", "3")
+	runCLIWithInput(t, binaryPath, "y\n", "list", "docs")
+	runCLI(t, binaryPath, "add", "Write guide")
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"count"}, "1\n"},
+		{[]string{"count", "--list", "ops"}, "2\n"},
+		{[]string{"count", "--list", "ops", "--completed"}, "1\n"},
+		{[]string{"count", "--all", "--overdue"}, "1\n"},
+		{[]string{"count", "--all", "--pending"}, "3\n"},
+	}
+	for _, tt := range tests {
+		stdout, stderr, exitCode := runCLI(t, binaryPath, tt.args...)
+		if exitCode != 0 || stdout != tt.want {
+			t.Errorf("todo %v = %q (exit %d, stderr %s), want %q", tt.args, stdout, exitCode, stderr, tt.want)
+		}
+	}
+}
+
+func TestWeightedProgress(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "release")
+	runCLI(t, binaryPath, "add", "--weight", "3", "Migrate database")
+	runCLI(t, binaryPath, "add", "Fix typo")
+	runCLI(t, binaryPath, "check", "1")
+
+	stdout, _, _ := runCLI(t, binaryPath, "progress")
+	if !strings.Contains(stdout, "Progress: 1/2 completed (75% by weight)") {
+		t.Errorf("Expected weighted progress, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--raw")
+	if !strings.Contains(stdout, "Progress: 1/2 completed\n") {
+		t.Errorf("Expected raw progress, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "list")
+	if !strings.Contains(stdout, "release - 1/2 completed (75% by weight)") {
+		t.Errorf("Expected weighted list progress, got: %s", stdout)
+	}
+}
+
+func TestListTarget(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "release")
+	runCLI(t, binaryPath, "add", "Tag the release")
+
+	target := time.Now().AddDate(0, 0, 10).Format("2006-01-02")
+	stdout, _, _ := runCLI(t, binaryPath, "list", "release", "--target", target)
+	if !strings.Contains(stdout, "Set the target date of list 'release' to "+target) {
+		t.Errorf("Unexpected output setting a target: %s", stdout)
+	}
+
+	// Nothing has been completed yet, so there is no pace to meet it
+	stdout, _, _ = runCLI(t, binaryPath, "progress")
+	if !strings.Contains(stdout, "Target: "+target+" (10 days left) - behind by 1 item") {
+		t.Errorf("Expected the target status, got: %s", stdout)
+	}
+
+	runCLI(t, binaryPath, "list", "release", "--target", "none")
+	if stdout, _, _ = runCLI(t, binaryPath, "progress"); strings.Contains(stdout, "Target:") {
+		t.Errorf("Expected the target to be cleared, got: %s", stdout)
+	}
+}
+
+func TestTour(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	// Without a terminal the first command doesn't offer the sample list
+	stdout, _, _ := runCLIWithInput(t, binaryPath, "y\n", "list", "main")
+	if strings.Contains(stdout, "Welcome") {
+		t.Errorf("Expected no first-run prompt without a terminal, got: %s", stdout)
+	}
+	runCLI(t, binaryPath, "add", "Existing item")
+
+	// Run the first step, skip the second, run the third and quit
+	stdout, _, _ = runCLIWithInput(t, binaryPath, "\ns\n\nq\n", "tour")
+	for _, want := range []string{"Created the sample list 'getting-started'", "Step 1 of 7", "Switched to list 'getting-started'", "Step 3 of 7", "Marked item 2 as completed in list 'getting-started'", "Switched back to list 'main'"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in tour output:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "Step 5 of 7") || strings.Contains(stdout, "Added todo item") {
+		t.Errorf("Expected the skipped step not to run and the tour to stop, got:\n%s", stdout)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(tempDir, ".todo", "getting-started.md"))
+	for _, want := range []string{"## Organising", "#words @home", "priority: p1", "parent: Ship the first release"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected %q in the sample list:\n%s", want, content)
+		}
+	}
+	if stdout, _, _ = runCLI(t, binaryPath, "list"); !strings.Contains(stdout, "getting-started") {
+		t.Errorf("Expected the sample list in the overview, got: %s", stdout)
+	}
+}
+
+func TestListOwnership(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "release")
+	runCLI(t, binaryPath, "list", "docs")
+
+	stdout, _, _ := runCLI(t, binaryPath, "list", "--mine")
+	if !strings.Contains(stdout, "No lists are owned by Test User <test@example.com>") {
+		t.Errorf("Expected no owned lists, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "list", "release", "--owner", "test@example.com", "--reviewers", "alex,kim")
+	if !strings.Contains(stdout, "List 'release' is now owned by test@example.com") || !strings.Contains(stdout, "List 'release' is now reviewed by alex, kim") {
+		t.Errorf("Unexpected output setting ownership: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "list", "--mine")
+	if !strings.Contains(stdout, "release") || strings.Contains(stdout, "docs") {
+		t.Errorf("Expected only the owned list, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "list", "--mine", "--health")
+	if !strings.Contains(stdout, "Error: --mine can't be combined with --health") {
+		t.Errorf("Expected an error combining --mine and --health, got: %s", stdout)
+	}
+}
+
+func TestListCalDAV(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "groceries")
+	stdout, _, _ := runCLI(t, binaryPath, "list", "groceries", "--caldav", "https://dav.example.com/tasks/")
+	if !strings.Contains(stdout, "List 'groceries' now syncs with https://dav.example.com/tasks/") {
+		t.Errorf("Unexpected output setting a collection: %s", stdout)
+	}
+	content, _ := os.ReadFile(filepath.Join(tempDir, ".todo", "groceries.md"))
+	if !strings.Contains(string(content), "caldav: https://dav.example.com/tasks/") {
+		t.Errorf("Expected the collection in the frontmatter, got: %s", content)
+	}
+
+	if stdout, _, _ = runCLI(t, binaryPath, "list", "groceries", "--caldav", "not a url"); !strings.Contains(stdout, "Error setting CalDAV collection") {
+		t.Errorf("Expected an invalid URL to be refused, got: %s", stdout)
+	}
+
+	runCLI(t, binaryPath, "list", "groceries", "--caldav", "none")
+	if content, _ = os.ReadFile(filepath.Join(tempDir, ".todo", "groceries.md")); strings.Contains(string(content), "caldav") {
+		t.Errorf("Expected the collection to be cleared, got: %s", content)
+	}
+}
+
+func TestRemindCommandErrors(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "main")
+	runCLI(t, binaryPath, "add", "Call Ann")
+
+	if stdout, _, _ := runCLI(t, binaryPath, "remind", "1"); !strings.Contains(stdout, "Error: --in or --at is required") {
+		t.Errorf("Expected --in or --at to be required, got: %s", stdout)
+	}
+	if stdout, _, _ := runCLI(t, binaryPath, "remind", "1", "--in", "soon"); !strings.Contains(stdout, "Error: invalid duration 'soon'") {
+		t.Errorf("Expected an invalid duration error, got: %s", stdout)
+	}
+	if stdout, _, _ := runCLI(t, binaryPath, "remind", "5", "--in", "2h"); !strings.Contains(stdout, "Error: item 5 not found") {
+		t.Errorf("Expected a missing item error, got: %s", stdout)
+	}
+}
+
+func TestServeStopsOnInterrupt(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "main")
+
+	serve := exec.Command(binaryPath, "serve", "--addr", "127.0.0.1:0")
+	serve.Dir = tempDir
+	stdout, err := serve.StdoutPipe()
+	if err != nil {
+		t.Fatalf("Failed to read serve output: %v", err)
+	}
+	if err := serve.Start(); err != nil {
+		t.Fatalf("Failed to start serve: %v", err)
+	}
+
+	lines := bufio.NewScanner(stdout)
+	if !lines.Scan() || !strings.HasPrefix(lines.Text(), "Serving todo feeds") {
+		t.Fatalf("Unexpected serve output: %q", lines.Text())
+	}
+	serve.Process.Signal(os.Interrupt)
+
+	var rest []string
+	for lines.Scan() {
+		rest = append(rest, lines.Text())
+	}
+	if err := serve.Wait(); err != nil {
+		t.Errorf("Expected serve to exit cleanly, got %v", err)
+	}
+	if len(rest) == 0 || rest[len(rest)-1] != "Stopped serving" {
+		t.Errorf("Expected serve to report stopping, got %q", rest)
+	}
+}
+
+func TestDaemonCommand(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "main")
+	runCLI(t, binaryPath, "add", "Write docs")
+	if stdout, _, _ := runCLI(t, binaryPath, "daemon", "status"); !strings.Contains(stdout, "No daemon is running.") {
+		t.Errorf("Unexpected status without a daemon: %s", stdout)
+	}
+
+	daemon := exec.Command(binaryPath, "daemon")
+	daemon.Dir = tempDir
+	if err := daemon.Start(); err != nil {
+		t.Fatalf("Failed to start the daemon: %v", err)
+	}
+	defer func() {
+		daemon.Process.Signal(os.Interrupt)
+		daemon.Wait()
+		if _, err := os.Stat(filepath.Join(tempDir, ".todo", "daemon.sock")); !os.IsNotExist(err) {
+			t.Error("Expected the daemon to remove its socket")
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(filepath.Join(tempDir, ".todo", "daemon.sock")); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if stdout, _, _ := runCLI(t, binaryPath, "daemon", "status"); !strings.Contains(stdout, "Lists indexed: 1") {
+		t.Errorf("Unexpected status with a daemon: %s", stdout)
+	}
+
+	// count is answered by the daemon and sees changes right away
+	runCLI(t, binaryPath, "add", "Ship it")
+	if stdout, _, _ := runCLI(t, binaryPath, "count"); strings.TrimSpace(stdout) != "2" {
+		t.Errorf("Expected 2 pending items, got: %s", stdout)
+	}
+
+	// Reminders at a given time are left to the daemon and kept with the item
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	stdout, _, _ := runCLI(t, binaryPath, "remind", "1", "--at", tomorrow.Format("2006-01-02")+" 09:00")
+	if !strings.Contains(stdout, "Will remind you about 'Write docs' at "+tomorrow.Format("Mon 2006-01-02")+" 09:00") {
+		t.Errorf("Unexpected remind output: %s", stdout)
+	}
+	content, _ := os.ReadFile(filepath.Join(tempDir, ".todo", "main.md"))
+	if !strings.Contains(string(content), "remind: "+tomorrow.Format("2006-01-02")+" 09:00 -->") {
+		t.Errorf("Expected the reminder time in the list file:\n%s", content)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "today", "--now", tomorrow.Format("2006-01-02")+" 08:00")
+	if !strings.Contains(stdout, "Reminders:\n  1. 09:00 [ ] Write docs [main #1]") {
+		t.Errorf("Expected the reminder in today's items, got: %s", stdout)
+	}
+}
+
+func TestBundleCommand(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "auth")
+	runCLI(t, binaryPath, "add", "Login form")
+
+	bundlePath := filepath.Join(tempDir, "auth.todo")
+	stdout, _, _ := runCLI(t, binaryPath, "bundle", "export", "auth", bundlePath)
+	if !strings.Contains(stdout, "Exported list 'auth'") {
+		t.Errorf("Unexpected export output: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "bundle", "import", bundlePath)
+	if !strings.Contains(stdout, "already exists") || !strings.Contains(stdout, "--as <name>") {
+		t.Errorf("Expected import to refuse an existing list, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "bundle", "import", bundlePath, "--as", "login")
+	if !strings.Contains(stdout, "Imported list 'login'") {
+		t.Errorf("Unexpected import output: %s", stdout)
+	}
+	if stdout, _, _ = runCLI(t, binaryPath, "progress", "login"); !strings.Contains(stdout, "Login form") {
+		t.Errorf("Expected the imported item, got: %s", stdout)
+	}
+}
+
+func TestRandomCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "home")
+	runCLI(t, binaryPath, "add", "Fix the sink #chores", "--estimate", "20m")
+	runCLI(t, binaryPath, "add", "Paint the fence #chores", "--estimate", "3h")
+	runCLI(t, binaryPath, "add", "Read a book")
+
+	stdout, _, _ := runCLI(t, binaryPath, "random", "--tag", "chores", "--max-estimate", "30m")
+	if !strings.Contains(stdout, "Fix the sink #chores [home #1] (estimate 20m)") {
+		t.Errorf("Expected the only matching item, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "random", "--context", "phone")
+	if !strings.Contains(stdout, "No pending items match.") {
+		t.Errorf("Expected no match, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "add", "Nap", "--estimate", "later")
+	if !strings.Contains(stdout, "invalid estimate") {
+		t.Errorf("Expected an invalid estimate error, got: %s", stdout)
+	}
+}
+
+func TestFitCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "home")
+	runCLI(t, binaryPath, "add", "Water the plants", "--estimate", "5m")
+	runCLI(t, binaryPath, "add", "Pay the bills", "--estimate", "15m", "--priority", "p1")
+	runCLI(t, binaryPath, "add", "Clean the garage", "--estimate", "2h", "--priority", "p1")
+	runCLI(t, binaryPath, "add", "Call mum")
+
+	stdout, _, _ := runCLI(t, binaryPath, "fit", "30m")
+	bills := strings.Index(stdout, "[p1] Pay the bills (15m) [home #2]")
+	plants := strings.Index(stdout, "Water the plants (5m) [home #1]")
+	if bills < 0 || plants < 0 || bills > plants {
+		t.Errorf("Expected the p1 item before the other fitting item, got: %s", stdout)
+	}
+	if strings.Contains(stdout, "garage") || strings.Contains(stdout, "Call mum") {
+		t.Errorf("Expected only items that fit, got: %s", stdout)
+	}
+
+	if stdout, _, _ = runCLI(t, binaryPath, "fit", "1m"); !strings.Contains(stdout, "No estimated items fit in 1m.") {
+		t.Errorf("Expected nothing to fit, got: %s", stdout)
+	}
+}
+
+func TestTriageCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "work")
+	runCLIWithInput(t, binaryPath, "y\n", "list", "inbox")
+	runCLI(t, binaryPath, "add", "Reply to email")
+	runCLI(t, binaryPath, "add", "Old idea")
+	runCLI(t, binaryPath, "add", "Quarterly report")
+	runCLI(t, binaryPath, "add", "Buy milk")
+
+	// An unknown key is asked again; the last item is never reached
+	stdout, _, _ := runCLIWithInput(t, binaryPath, "d\nz\nx\nm\nwork\nq\n", "triage")
+	if !strings.Contains(stdout, "Please press") || !strings.Contains(stdout, "Saved: 1 done, 1 deleted, 1 moved") {
+		t.Errorf("Unexpected triage output: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "inbox")
+	if !strings.Contains(stdout, "1. [x] Reply to email") || !strings.Contains(stdout, "2. [ ] Buy milk") || strings.Contains(stdout, "Old idea") {
+		t.Errorf("Unexpected inbox after triage: %s", stdout)
+	}
+	if stdout, _, _ = runCLI(t, binaryPath, "progress", "work"); !strings.Contains(stdout, "Quarterly report") {
+		t.Errorf("Expected the moved item in work: %s", stdout)
+	}
+}
+
+func TestAuditCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "add", "Before auditing")
+	os.WriteFile(".todo/config.yaml", []byte("audit: true\n"), 0644)
+
+	runCLI(t, binaryPath, "add", "Write docs")
+	runCLI(t, binaryPath, "check", "1")
+	runCLI(t, binaryPath, "progress")
+
+	stdout, _, _ := runCLI(t, binaryPath, "audit")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected two audit entries, got: %s", stdout)
+	}
+	if !strings.Contains(lines[0], "Test User <test@example.com>  todo add Write docs  (main)") || !strings.Contains(lines[1], "todo check 1") {
+		t.Errorf("Unexpected audit log: %s", stdout)
+	}
+
+	if stdout, _, _ = runCLI(t, binaryPath, "audit", "--list", "other"); !strings.Contains(stdout, "No audit entries") {
+		t.Errorf("Expected no entries for another list: %s", stdout)
+	}
+}
+
+func TestInsightsCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "add", "Before opting in")
+	stdout, _, _ := runCLI(t, binaryPath, "insights")
+	if !strings.Contains(stdout, "Most-changed lists (times written):\n  main  1") || strings.Contains(stdout, "Most-used commands") {
+		t.Errorf("Expected only list changes before opting in, got: %s", stdout)
+	}
+	if !strings.Contains(stdout, "set 'insights: true' in .todo/config.yaml") {
+		t.Errorf("Expected a tip on opting in, got: %s", stdout)
+	}
+
+	os.WriteFile(".todo/config.yaml", []byte("insights: true\n"), 0644)
+	runCLI(t, binaryPath, "add", "Write docs")
+	runCLI(t, binaryPath, "add", "Secret plans")
+	runCLI(t, binaryPath, "check", "1")
+
+	stdout, _, _ = runCLI(t, binaryPath, "insights")
+	if !strings.Contains(stdout, "Most-used commands:\n  add    2\n  check  1") || !strings.Contains(stdout, "Busiest hours:") {
+		t.Errorf("Expected the recorded commands, got: %s", stdout)
+	}
+	if strings.Contains(stdout, "set 'insights: true'") {
+		t.Errorf("Expected no opt-in tip once recording, got: %s", stdout)
+	}
+	// Only command names are recorded
+	journal, _ := os.ReadFile(".todo/journal.jsonl")
+	if strings.Contains(string(journal), "Secret plans") {
+		t.Errorf("Expected no arguments in the journal, got: %s", journal)
+	}
+}
+
+func TestDueCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "add", "File taxes", "--due", "2024-07-01", "--now", "2024-07-03")
+	runCLI(t, binaryPath, "add", "Book flights", "--due", "2024-07-04", "--now", "2024-07-03")
+	runCLI(t, binaryPath, "add", "Renew passport", "--due", "2024-08-01", "--now", "2024-07-03")
+
+	stdout, _, _ := runCLI(t, binaryPath, "due", "--now", "2024-07-03")
+	if !strings.Contains(stdout, "Tomorrow (Thu Jul 4):\n  1. [ ] Book flights [main #2]") {
+		t.Errorf("Expected the item due tomorrow, got: %s", stdout)
+	}
+	if strings.Contains(stdout, "File taxes") || strings.Contains(stdout, "Renew passport") {
+		t.Errorf("Expected only the next week's items, got: %s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "due", "--days", "30", "--now", "2024-07-03")
+	if !strings.Contains(stdout, "In 29 days (Thu Aug 1):") {
+		t.Errorf("Expected --days to look further ahead, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--now", "2024-07-03")
+	if !strings.Contains(stdout, "1. [ ] File taxes (overdue)") || !strings.Contains(stdout, "2. [ ] Book flights (due tomorrow)") || !strings.Contains(stdout, "3. [ ] Renew passport\n") {
+		t.Errorf("Expected overdue and due-soon items to be marked, got: %s", stdout)
+	}
+}
+
+func TestPrioritizeAndSort(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "add", "Write docs")
+	runCLI(t, binaryPath, "add", "Fix login")
+	runCLI(t, binaryPath, "add", "Plan sprint", "--priority", "p2")
+
+	stdout, _, _ := runCLI(t, binaryPath, "prioritize", "2", "p1")
+	if !strings.Contains(stdout, "Set the priority of 'Fix login' to p1") {
+		t.Errorf("Expected the priority to be set, got: %s", stdout)
+	}
+	content, _ := os.ReadFile(".todo/main.md")
+	if !strings.Contains(string(content), "priority: p1") {
+		t.Errorf("Expected the priority to be stored in the markdown: %s", content)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "progress")
+	if !strings.Contains(stdout, "1. [ ] Write docs\n2. [ ] Fix login (p1)\n3. [ ] Plan sprint (p2)") {
+		t.Errorf("Expected priorities in list order, got: %s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--sort", "priority")
+	if !strings.Contains(stdout, "2. [ ] Fix login (p1)\n3. [ ] Plan sprint (p2)\n1. [ ] Write docs") {
+		t.Errorf("Expected the most urgent items first, got: %s", stdout)
+	}
+
+	runCLI(t, binaryPath, "prioritize", "2", "none")
+	stdout, _, _ = runCLI(t, binaryPath, "progress")
+	if strings.Contains(stdout, "(p1)") {
+		t.Errorf("Expected the priority to be cleared, got: %s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "prioritize", "2", "urgent")
+	if !strings.Contains(stdout, "Error: invalid priority") {
+		t.Errorf("Expected an error for an unknown priority, got: %s", stdout)
+	}
+}
+
+func TestTagFilters(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "add", "Fix login #backend")
+	runCLI(t, binaryPath, "add", "Restyle header #frontend")
+	runCLI(t, binaryPath, "add", "Document the API #backend #docs")
+	runCLI(t, binaryPath, "check", "1")
+
+	stdout, _, _ := runCLI(t, binaryPath, "tags")
+	if !strings.Contains(stdout, "Tags:\n  #backend   2 items, 1 pending\n  #docs      1 item, 1 pending\n  #frontend  1 item, 1 pending") {
+		t.Errorf("Expected tag counts, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--tag", "backend")
+	if !strings.Contains(stdout, "1. [x] Fix login #backend\n3. [ ] Document the API #backend #docs") || strings.Contains(stdout, "Restyle") {
+		t.Errorf("Expected only #backend items, numbered as in the list, got: %s", stdout)
+	}
+	if !strings.Contains(stdout, "Progress: 1/2 completed") {
+		t.Errorf("Expected progress of the #backend items, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "history", "--tag", "frontend")
+	if !strings.Contains(stdout, "No completed todos found.") {
+		t.Errorf("Expected no completed #frontend items, got: %s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "history", "--tag", "backend")
+	if !strings.Contains(stdout, "Fix login #backend [main]") {
+		t.Errorf("Expected the completed #backend item, got: %s", stdout)
+	}
+}
+
+func TestRemoveCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	for _, text := range []string{"One", "Two", "Three", "Four", "Five"} {
+		runCLI(t, binaryPath, "add", text)
+	}
+
+	stdout, _, _ := runCLI(t, binaryPath, "remove", "2-3", "5")
+	for _, text := range []string{"Two", "Three", "Five"} {
+		if !strings.Contains(stdout, "Removed item from list 'main': "+text) {
+			t.Errorf("Expected %s to be removed, got: %s", text, stdout)
+		}
+	}
+	content, _ := os.ReadFile(".todo/main.md")
+	if strings.Contains(string(content), "Two") || !strings.Contains(string(content), "- [ ] One") || !strings.Contains(string(content), "- [ ] Four") {
+		t.Errorf("Unexpected list after remove: %s", content)
+	}
+
+	// The rest were renumbered
+	runCLI(t, binaryPath, "rm", "2")
+	content, _ = os.ReadFile(".todo/main.md")
+	if strings.Contains(string(content), "Four") {
+		t.Errorf("Expected item 2 to be Four after renumbering: %s", content)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "remove", "3")
+	if !strings.Contains(stdout, "invalid item ID: 3") {
+		t.Errorf("Expected an error for a missing item, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "remove", "1", "--json")
+	var changes []struct {
+		Action string `json:"action"`
+		List   string `json:"list"`
+		Item   struct {
+			Label string `json:"label"`
+			Text  string `json:"text"`
+		} `json:"item"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &changes); err != nil || len(changes) != 1 || changes[0].Action != "remove" || changes[0].Item.Text != "One" || changes[0].Item.Label != "" {
+		t.Errorf("Expected the removed item in a JSON array, got %q (%v)", stdout, err)
+	}
+}
+
+func TestArchiveCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	stdout, _, _ := runCLI(t, binaryPath, "archive")
+	if !strings.Contains(stdout, "No completed items to archive in list 'main'") {
+		t.Errorf("Expected nothing to archive, got: %s", stdout)
+	}
+
+	for _, text := range []string{"One", "Two", "Three"} {
+		runCLI(t, binaryPath, "add", text)
+	}
+	runCLI(t, binaryPath, "check", "1")
+	runCLI(t, binaryPath, "check", "3")
+
+	stdout, _, _ = runCLI(t, binaryPath, "archive")
+	if !strings.Contains(stdout, "Archived 2 completed item(s) from list 'main' to .todo/archive/main.md") {
+		t.Errorf("Unexpected archive output: %s", stdout)
+	}
+	content, _ := os.ReadFile(".todo/main.md")
+	if strings.Contains(string(content), "One") || !strings.Contains(string(content), "- [ ] Two") {
+		t.Errorf("Expected only the pending item to stay: %s", content)
+	}
+	archive, _ := os.ReadFile(".todo/archive/main.md")
+	if !strings.Contains(string(archive), "- [x] One <!-- completed: ") || !strings.Contains(string(archive), "- [x] Three") {
+		t.Errorf("Expected the completed items in the archive: %s", archive)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "history")
+	if !strings.Contains(stdout, "One [main, archived]") {
+		t.Errorf("Expected history to show archived items, got: %s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "history", "--porcelain")
+	if stdout != "" {
+		t.Errorf("Expected porcelain history to leave archived items out, got: %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "history", "--json")
+	if !strings.Contains(stdout, `"archived": true`) {
+		t.Errorf("Expected archived items in the JSON history, got: %s", stdout)
+	}
+}
+
+func TestSubtasks(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "add", "Ship the release")
+	runCLI(t, binaryPath, "add", "Announce it")
+	runCLI(t, binaryPath, "add", "--under", "1", "Write the changelog")
+	runCLI(t, binaryPath, "add", "--under", "1", "Tag the release")
+
+	content, _ := os.ReadFile(".todo/main.md")
+	if !strings.Contains(string(content), "- [ ] Ship the release\n") || !strings.Contains(string(content), "\n  - [ ] Write the changelog") || !strings.Contains(string(content), "\n  - [ ] Tag the release") {
+		t.Errorf("Expected the subtasks indented under their parent: %s", content)
+	}
+	stdout, _, _ := runCLI(t, binaryPath, "list", "main")
+	if !strings.Contains(stdout, "  2. [ ] Write the changelog") || !strings.Contains(stdout, "4. [ ] Announce it") {
+		t.Errorf("Expected the subtasks listed under their parent, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "add", "--under", "9", "Nothing")
+	if !strings.Contains(stdout, "invalid item ID: 9") {
+		t.Errorf("Expected an error for a missing parent, got: %s", stdout)
+	}
+
+	// Without --cascade only the item itself is checked
+	runCLI(t, binaryPath, "check", "2")
+	runCLI(t, binaryPath, "uncheck", "2")
+	stdout, _, _ = runCLI(t, binaryPath, "check", "1", "--cascade")
+	if !strings.Contains(stdout, "Also completed its 2 pending subtasks") {
+		t.Errorf("Expected the subtasks to be checked too, got: %s", stdout)
+	}
+	content, _ = os.ReadFile(".todo/main.md")
+	if !strings.Contains(string(content), "  - [x] Tag the release") || !strings.Contains(string(content), "- [ ] Announce it") {
+		t.Errorf("Expected only the subtree to be checked: %s", content)
+	}
+}
+
+func TestJSONFlag(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	stdout, _, _ := runCLI(t, binaryPath, "add", "Write docs", "--priority", "p1", "--json")
+	var change struct {
+		Action string `json:"action"`
+		List   string `json:"list"`
+		Item   struct {
+			ID        int    `json:"id"`
+			Text      string `json:"text"`
+			Completed bool   `json:"completed"`
+			Priority  string `json:"priority"`
+		} `json:"item"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &change); err != nil {
+		t.Fatalf("Expected JSON from add, got %q: %v", stdout, err)
+	}
+	if change.Action != "add" || change.List != "main" || change.Item.Text != "Write docs" || change.Item.Priority != "p1" {
+		t.Errorf("Unexpected add output %+v", change)
+	}
+
+	stdout, _, _ = runCLIWithInput(t, binaryPath, "Read docs\n", "add", "-", "--json")
+	var added []json.RawMessage
+	if err := json.Unmarshal([]byte(stdout), &added); err != nil || len(added) != 1 {
+		t.Errorf("Expected the items added from stdin in a JSON array, got %q", stdout)
+	}
+	runCLI(t, binaryPath, "remove", "2")
+
+	// Commands that take several items print an array even for one
+	stdout, _, _ = runCLI(t, binaryPath, "check", "1", "--json")
+	var checked []struct {
+		Action string `json:"action"`
+		Item   struct {
+			Completed bool `json:"completed"`
+		} `json:"item"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &checked); err != nil || len(checked) != 1 || checked[0].Action != "check" || !checked[0].Item.Completed {
+		t.Errorf("Expected the checked item in a JSON array, got %q", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "history", "--json")
+	var history []struct {
+		List string `json:"list"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &history); err != nil || len(history) != 1 || history[0].Text != "Write docs" {
+		t.Errorf("Expected the history as JSON, got %q", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--json")
+	var progress struct {
+		Name      string `json:"name"`
+		Completed int    `json:"completed"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &progress); err != nil || progress.Name != "main" || progress.Completed != 1 {
+		t.Errorf("Expected progress as JSON, got %q", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "list", "--json")
+	var overview struct {
+		Current string `json:"current"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &overview); err != nil || overview.Current != "main" {
+		t.Errorf("Expected the list overview as JSON, got %q", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--json", "--format", "quickfix")
+	if !strings.Contains(stdout, "Error: --json can't be combined with --format quickfix") {
+		t.Errorf("Expected conflicting formats to be refused, got %q", stdout)
+	}
+	stdout, _, code := runCLI(t, binaryPath, "tour", "--json")
+	if code == 0 || !strings.Contains(stdout, "Error: 'todo tour' doesn't support --json") {
+		t.Errorf("Expected --json to be refused by text-only commands, got %q", stdout)
+	}
+}
+
+func TestJSONReadCommands(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+	todo := func(args ...string) string {
+		stdout, _, _ := runCLI(t, binaryPath, append([]string{"--now", "2026-03-10 09:00"}, args...)...)
+		return stdout
+	}
+	decode := func(value interface{}, args ...string) {
+		t.Helper()
+		stdout := todo(append(args, "--json")...)
+		if err := json.Unmarshal([]byte(stdout), value); err != nil {
+			t.Errorf("Expected JSON from %v, got %q: %v", args, stdout, err)
+		}
+	}
+
+	todo("init", "--yes")
+	config, _ := os.ReadFile(".todo/config.yaml")
+	os.WriteFile(".todo/config.yaml", append(config, "insights: true\n"...), 0644)
+	todo("add", "Fix login #backend", "--due", "2026-03-08", "--estimate", "30m", "--priority", "p1")
+	todo("add", "Write docs", "--due", "2026-03-10")
+	todo("add", "Plan release", "--due", "2026-03-12")
+	todo("check", "2")
+
+	type item struct {
+		List        string `json:"list"`
+		ID          int    `json:"id"`
+		Label       string `json:"label"`
+		Text        string `json:"text"`
+		Completed   bool   `json:"completed"`
+		CompletedAt string `json:"completed_at"`
+		Due         string `json:"due"`
+		DaysLate    int    `json:"days_late"`
+	}
+
+	var items []item
+	decode(&items, "agenda")
+	if len(items) != 2 || items[0].List != "main" || items[0].Label != "1" || items[1].Text != "Plan release" {
+		t.Errorf("Unexpected agenda %+v", items)
+	}
+	decode(&items, "due")
+	if len(items) != 1 || items[0].Due != "2026-03-12" {
+		t.Errorf("Unexpected due items %+v", items)
+	}
+	decode(&items, "search", "login")
+	if len(items) != 1 || items[0].ID != 1 {
+		t.Errorf("Unexpected search results %+v", items)
+	}
+	decode(&items, "fit", "1h")
+	if len(items) != 1 || items[0].Text != "Fix login #backend" {
+		t.Errorf("Unexpected fitting items %+v", items)
+	}
+	decode(&items, "history")
+	if len(items) != 1 {
+		t.Fatalf("Unexpected history %+v", items)
+	}
+	if completedAt, err := time.Parse(time.RFC3339, items[0].CompletedAt); err != nil || completedAt.Format("2006-01-02") != "2026-03-10" {
+		t.Errorf("Expected completed_at in RFC 3339, got %q", items[0].CompletedAt)
+	}
+
+	var picked item
+	decode(&picked, "random", "--tag", "backend")
+	if picked.ID != 1 {
+		t.Errorf("Unexpected random pick %+v", picked)
+	}
+
+	var groups []struct {
+		Label string `json:"label"`
+		Items []item `json:"items"`
+	}
+	decode(&groups, "overdue")
+	if len(groups) != 1 || groups[0].Label == "" || len(groups[0].Items) != 1 || groups[0].Items[0].DaysLate != 2 {
+		t.Errorf("Unexpected overdue groups %+v", groups)
+	}
+
+	var today struct {
+		Reminders []item `json:"reminders"`
+		Overdue   []item `json:"overdue"`
+		Due       []item `json:"due"`
+	}
+	decode(&today, "today")
+	if today.Reminders == nil || len(today.Overdue) != 1 || today.Due == nil {
+		t.Errorf("Unexpected today %+v", today)
+	}
+
+	var standup struct {
+		Done  []item `json:"done"`
+		Today []item `json:"today"`
+	}
+	decode(&standup, "standup")
+	if standup.Done == nil || len(standup.Today) != 2 {
+		t.Errorf("Unexpected standup %+v", standup)
+	}
+
+	var retro struct {
+		From      string `json:"from"`
+		Completed []item `json:"completed"`
+		Added     []item `json:"added"`
+		CarryOver []item `json:"carry_over"`
+	}
+	decode(&retro, "retro")
+	if retro.From != "2026-02-25" || len(retro.Completed) != 1 || len(retro.Added) != 3 || len(retro.CarryOver) != 2 {
+		t.Errorf("Unexpected retro %+v", retro)
+	}
+
+	var shown struct {
+		item
+		Metadata map[string]string `json:"metadata"`
+	}
+	decode(&shown, "show", "1")
+	if shown.List != "main" || shown.Text != "Fix login #backend" || shown.Metadata["added"] != "2026-03-10" {
+		t.Errorf("Unexpected item details %+v", shown)
+	}
+
+	var counts struct {
+		Pending   int `json:"pending"`
+		Completed int `json:"completed"`
+		Overdue   int `json:"overdue"`
+	}
+	decode(&counts, "count")
+	if counts.Pending != 2 || counts.Completed != 1 || counts.Overdue != 1 {
+		t.Errorf("Unexpected counts %+v", counts)
+	}
+
+	var tags []struct {
+		Tag     string `json:"tag"`
+		Total   int    `json:"total"`
+		Pending int    `json:"pending"`
+	}
+	decode(&tags, "tags")
+	if len(tags) != 1 || tags[0].Tag != "backend" || tags[0].Pending != 1 {
+		t.Errorf("Unexpected tags %+v", tags)
+	}
+
+	var health []struct {
+		Name  string `json:"name"`
+		Score int    `json:"score"`
+	}
+	decode(&health, "list", "--health")
+	if len(health) != 1 || health[0].Name != "main" {
+		t.Errorf("Unexpected list health %+v", health)
+	}
+
+	var list struct {
+		Name  string `json:"name"`
+		Items []item `json:"items"`
+	}
+	decode(&list, "list", "ops")
+	if list.Name != "ops" || list.Items == nil {
+		t.Errorf("Expected the new list as JSON, got %+v", list)
+	}
+	var overview struct {
+		Current string `json:"current"`
+	}
+	decode(&overview, "list")
+	if overview.Current != "ops" {
+		t.Errorf("Expected the new list to be current, got %+v", overview)
+	}
+	if stdout := todo("list", "main", "--json", "--owner", "me"); !strings.Contains(stdout, "Error: --json can't be combined with --target") {
+		t.Errorf("Expected --json to refuse list settings, got %q", stdout)
+	}
+
+	var empty []interface{}
+	for _, args := range [][]string{{"holidays"}, {"timesheet"}, {"audit"}, {"workspace", "list"}, {"auth", "status"}} {
+		decode(&empty, args...)
+		if empty == nil || len(empty) != 0 {
+			t.Errorf("Expected an empty array from %v, got %v", args, empty)
+		}
+	}
+
+	var insights struct {
+		Recording bool  `json:"recording"`
+		Hours     []int `json:"hours"`
+	}
+	decode(&insights, "insights")
+	if !insights.Recording || len(insights.Hours) != 24 {
+		t.Errorf("Unexpected insights %+v", insights)
+	}
+
+	var daemon struct {
+		Running bool `json:"running"`
+	}
+	decode(&daemon, "daemon", "status")
+	if daemon.Running {
+		t.Errorf("Expected no daemon, got %+v", daemon)
+	}
+
+	var version struct {
+		Version string `json:"version"`
+	}
+	decode(&version, "version")
+	if version.Version != "0.3.0" {
+		t.Errorf("Unexpected version %+v", version)
+	}
+
+	exec.Command("git", "add", "-f", ".todo").Run()
+	exec.Command("git", "commit", "-q", "-m", "Add lists").Run()
+	var peeked struct {
+		Lists []struct {
+			Name    string `json:"name"`
+			Pending int    `json:"pending"`
+		} `json:"lists"`
+	}
+	decode(&peeked, "peek", ".")
+	if len(peeked.Lists) != 2 || peeked.Lists[0].Name != "main" || peeked.Lists[0].Pending != 2 {
+		t.Errorf("Unexpected peeked lists %+v", peeked)
+	}
+	decode(&list, "peek", ".", "main")
+	if list.Name != "main" || len(list.Items) != 3 {
+		t.Errorf("Unexpected peeked list %+v", list)
+	}
+}
+
+func TestAnchorCommand(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+	os.WriteFile(filepath.Join(tempDir, "auth.go"), []byte("package auth\n"), 0644)
+
+	runCLI(t, binaryPath, "add", "Handle expired tokens (due: 2030-01-02)")
+	stdout, _, _ := runCLI(t, binaryPath, "open", "1")
+	if !strings.Contains(stdout, "isn't anchored") {
+		t.Errorf("Expected an error without an anchor, got %q", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "anchor", "1", "auth.go:42")
+	if !strings.Contains(stdout, "Anchored item 1 to auth.go:42") {
+		t.Fatalf("anchor = %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "anchor", "1", "missing.go:1")
+	if !strings.HasPrefix(stdout, "Error") {
+		t.Errorf("Expected an error for a missing file, got %q", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "show", "1")
+	for _, want := range []string{"Handle expired tokens", "Status:    pending", "Due:       2030-01-02", "Anchor:    auth.go:42"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in show output:\n%s", want, stdout)
+		}
+	}
+
+	t.Setenv("EDITOR", "echo")
+	stdout, _, _ = runCLI(t, binaryPath, "open", "1")
+	if stdout != "+42 auth.go\n" {
+		t.Errorf("open = %q", stdout)
+	}
+
+	runCLI(t, binaryPath, "anchor", "1", "--remove")
+	stdout, _, _ = runCLI(t, binaryPath, "show", "1")
+	if strings.Contains(stdout, "Anchor:") {
+		t.Errorf("Expected the anchor to be removed:\n%s", stdout)
+	}
+}
+
+func TestEditMergesConcurrentWrites(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "main")
+	runCLI(t, binaryPath, "add", "Write docs")
+	runCLI(t, binaryPath, "add", "Fix login")
+
+	// A sync running in this test process while the editor is open
+	os.MkdirAll(filepath.Join(tempDir, ".todo", "locks"), 0755)
+	lock := fmt.Sprintf(`{"pid": %d, "command": "sync", "started": %q}`, os.Getpid(), time.Now().Format(time.RFC3339))
+	os.WriteFile(filepath.Join(tempDir, ".todo", "locks", "sync.json"), []byte(lock), 0644)
+
+	// The editor renames the first item while the second is checked
+	editor := filepath.Join(tempDir, "editor.sh")
+	script := fmt.Sprintf("#!/bin/sh\nsed -i.bak 's/Write docs/Write the docs/' \"$1\"\n%s check 2 >/dev/null\n", binaryPath)
+	os.WriteFile(editor, []byte(script), 0755)
+	t.Setenv("EDITOR", editor)
+
+	stdout, _, _ := runCLI(t, binaryPath, "edit")
+	if !strings.Contains(stdout, "Warning: 'todo sync' (pid ") || !strings.Contains(stdout, "will be merged when you close the editor") {
+		t.Errorf("Expected a warning about the sync, got: %s", stdout)
+	}
+	if !strings.Contains(stdout, "List 'main' changed while you were editing it; merged 1 change(s) into your edit") {
+		t.Errorf("Expected the merge to be reported, got: %s", stdout)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(tempDir, ".todo", "main.md"))
+	if !strings.Contains(string(content), "- [ ] Write the docs") || !strings.Contains(string(content), "- [x] Fix login") {
+		t.Errorf("Expected both changes in the list, got:\n%s", content)
+	}
+}
+
+func TestListOverviewAlignsWideNames(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "日本語")
+	runCLI(t, binaryPath, "add", "最初")
+	runCLIWithInput(t, binaryPath, "y\n", "list", "ops")
+	runCLI(t, binaryPath, "add", "Deploy")
+
+	stdout, _, _ := runCLI(t, binaryPath, "list")
+	for _, want := range []string{"  ops    - 0/1 completed", "  日本語 - 0/1 completed"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestPasteCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	markdown := "## Backend\n- [ ] Migrate\n  - [x] Backfill\n## Docs\n- [ ] Changelog\n"
+	stdout, _, _ := runCLIWithInput(t, binaryPath, markdown, "paste", "--list", "release")
+	if !strings.Contains(stdout, "Added 3 item(s) in 2 section(s) to list 'release'") {
+		t.Fatalf("paste = %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "release")
+	for _, want := range []string{"Backend:\n1. [ ] Migrate\n  2. [x] Backfill", "Docs:\n3. [ ] Changelog"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in:\n%s", want, stdout)
+		}
+	}
+
+	stdout, _, _ = runCLIWithInput(t, binaryPath, "just some notes\n", "paste")
+	if !strings.Contains(stdout, "No task list items found") {
+		t.Errorf("Expected no items, got %q", stdout)
+	}
+}
+
+func TestExportIssueBody(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "release")
+	runCLI(t, binaryPath, "add", "Tag v2 (due: 2030-01-02)")
+	runCLI(t, binaryPath, "add", "Announce")
+	runCLI(t, binaryPath, "check", "2")
+
+	stdout, _, _ := runCLI(t, binaryPath, "export", "issue-body")
+	if stdout != "- [ ] Tag v2 (due: 2030-01-02)\n- [x] Announce\n" {
+		t.Errorf("export issue-body = %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "export", "issue-body", "missing")
+	if !strings.HasPrefix(stdout, "Error") {
+		t.Errorf("Expected an error for a missing list, got %q", stdout)
+	}
+}
+
+func TestExportTimeseries(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "release")
+	os.WriteFile(".todo/config.yaml", []byte("insights: true\n"), 0644)
+	runCLI(t, binaryPath, "--now", "2024-07-01 09:00", "add", "Tag v2")
+	runCLI(t, binaryPath, "--now", "2024-07-02 09:00", "add", "Announce")
+	runCLI(t, binaryPath, "--now", "2024-07-03 09:00", "check", "1")
+
+	stdout, _, _ := runCLI(t, binaryPath, "--now", "2024-07-03 18:00", "export", "timeseries", "--out", "progress.csv")
+	if !strings.Contains(stdout, "Wrote 3 rows to progress.csv") {
+		t.Errorf("Unexpected output: %s", stdout)
+	}
+	content, _ := os.ReadFile(filepath.Join(tempDir, "progress.csv"))
+	want := "date,list,pending,completed,total,percent,recorded_percent\n2024-07-01,release,1,0,1,0,\n2024-07-02,release,2,0,2,0,\n2024-07-03,release,1,1,2,50,\n"
+	if string(content) != want {
+		t.Errorf("Unexpected time series:\n%s", content)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "--now", "2024-07-03 18:00", "export", "timeseries", "--since", "2024-07-03")
+	if !strings.HasSuffix(stdout, "recorded_percent\n2024-07-03,release,1,1,2,50,\n") {
+		t.Errorf("Expected --since to start the series, got %q", stdout)
+	}
+}
+
+func TestSyncIssueCommandErrors(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "release")
+
+	stdout, _, _ := runCLI(t, binaryPath, "sync", "issue", "abc")
+	if !strings.Contains(stdout, "Error: invalid issue number: abc") {
+		t.Errorf("Expected an invalid number error, got %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "sync", "issue", "7", "--list", "missing")
+	if !strings.Contains(stdout, "Error: list 'missing' does not exist") {
+		t.Errorf("Expected a missing list error, got %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "sync", "issue", "7", "--repo", "nope")
+	if !strings.Contains(stdout, "--repo must be owner/name") {
+		t.Errorf("Expected a bad repo error, got %q", stdout)
+	}
+}
+
+func TestOverdueCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "ops")
+	stdout, _, _ := runCLI(t, binaryPath, "overdue")
+	if !strings.Contains(stdout, "No overdue items.") {
+		t.Errorf("Expected no overdue items, got %q", stdout)
+	}
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	runCLI(t, binaryPath, "add", "Patch hosts (due: "+yesterday+")")
+	runCLI(t, binaryPath, "add", "Rotate keys (due: 2000-01-01)")
+	runCLI(t, binaryPath, "add", "Plan Q3 (due: 2999-01-01)")
+
+	stdout, _, _ = runCLI(t, binaryPath, "overdue")
+	for _, want := range []string{
+		"2 overdue items: 1 1-3 days late, 1 older",
+		"1-3 days late (1):\n  1. [ ] Patch hosts [ops #1] (1 day late)",
+		"Older (1):\n  2. [ ] Rotate keys [ops #2]",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "Plan Q3") {
+		t.Errorf("Items due later should not be overdue:\n%s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "overdue", "--porcelain")
+	if stdout != "ops\t1\tpending\tPatch hosts\nops\t2\tpending\tRotate keys\n" {
+		t.Errorf("Unexpected porcelain output %q", stdout)
+	}
+}
+
+func TestListSparklines(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "ops")
+	runCLI(t, binaryPath, "add", "Patch hosts")
+	runCLI(t, binaryPath, "add", "Rotate keys")
+
+	// Without earlier days there is nothing to draw yet
+	stdout, _, _ := runCLI(t, binaryPath, "list")
+	if !strings.Contains(stdout, "ops - 0/2 completed (0%)") {
+		t.Errorf("Expected no sparkline yet, got:\n%s", stdout)
+	}
+
+	runCLI(t, binaryPath, "check", "1")
+	twoDaysAgo := time.Now().AddDate(0, 0, -2).Format("2006-01-02")
+	snapshots := `{"ops":{"` + twoDaysAgo + `":0}}`
+	if err := os.WriteFile(filepath.Join(tempDir, ".todo", "snapshots.json"), []byte(snapshots), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "list")
+	want := "ops " + strings.Repeat(" ", 11) + "▁▁▄ - 1/2 completed (50%)"
+	if !strings.Contains(stdout, want) {
+		t.Errorf("Expected %q in:\n%s", want, stdout)
+	}
+}
+
+func TestRetroCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "web")
+	os.WriteFile(".todo/config.yaml", []byte("insights: true\n"), 0644)
+	runCLI(t, binaryPath, "add", "Ship login")
+	runCLI(t, binaryPath, "add", "Polish styles")
+	runCLI(t, binaryPath, "check", "1")
+
+	stdout, _, _ := runCLI(t, binaryPath, "retro", "--sprint", "1w")
+	for _, want := range []string{
+		"# Retro ",
+		"- Completed: 1\n- Added: 2\n- Net backlog change: +1\n- Carried over: 1\n",
+		"- [x] Ship login (web)",
+		"- Ship login (web): open 0 days",
+		"- [ ] Polish styles (web): open since " + time.Now().Format("2006-01-02"),
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in:\n%s", want, stdout)
+		}
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "retro", "--sprint", "two weeks")
+	if !strings.Contains(stdout, "Error: invalid sprint length") {
+		t.Errorf("Expected an error for a bad sprint, got %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "retro", "missing")
+	if !strings.Contains(stdout, "Error: list 'missing' does not exist") {
+		t.Errorf("Expected an error for a missing list, got %q", stdout)
+	}
+}
+
+func TestListHealth(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "done")
+	runCLI(t, binaryPath, "add", "Ship")
+	runCLI(t, binaryPath, "check", "1")
+	runCLIWithInput(t, binaryPath, "y\n", "list", "late")
+	runCLI(t, binaryPath, "add", "Patch hosts (due: 2000-01-01)")
+	runCLI(t, binaryPath, "add", "Rotate keys")
+
+	stdout, _, _ := runCLI(t, binaryPath, "list", "--health")
+	for _, want := range []string{"List health:", "late", "87 good - 1 overdue", "done", "100 good - finished"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in:\n%s", want, stdout)
+		}
+	}
+	if strings.Index(stdout, "late") > strings.Index(stdout, "done") {
+		t.Errorf("Expected the least healthy list first:\n%s", stdout)
+	}
+
+	config := "health:\n  weights: {overdue: 1, staleness: 0, velocity: 0, wip: 0}\n"
+	os.WriteFile(filepath.Join(tempDir, ".todo", "config.yaml"), []byte(config), 0644)
+	stdout, _, _ = runCLI(t, binaryPath, "list", "--health")
+	if !strings.Contains(stdout, "50 fair - 1 overdue") {
+		t.Errorf("Expected the overdue weight alone to count:\n%s", stdout)
+	}
+
+	os.WriteFile(filepath.Join(tempDir, ".todo", "config.yaml"), []byte("health:\n  weights: {bugs: 1}\n"), 0644)
+	stdout, _, _ = runCLI(t, binaryPath, "list", "--health")
+	if !strings.Contains(stdout, `unknown health factor "bugs"`) {
+		t.Errorf("Expected an unknown factor error, got %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "list", "late", "--health")
+	if !strings.Contains(stdout, "Error: --health only applies to the list overview") {
+		t.Errorf("Expected an error with a list name, got %q", stdout)
+	}
+}
+
+func TestBreakdownCommand(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "release")
+	runCLI(t, binaryPath, "add", "Ship 2.0")
+	runCLI(t, binaryPath, "add", "Write notes")
+
+	stdout, _, _ := runCLI(t, binaryPath, "breakdown", "1")
+	if !strings.Contains(stdout, "Error: breakdowns are not set up") {
+		t.Errorf("Expected breakdowns to be opt-in, got %q", stdout)
+	}
+
+	// A command is only run from the user's config, not the project's
+	config := "breakdown:\n  command: >-\n    printf '1. Tag %s\\n2. Build\\n' \"$TODO_BREAKDOWN_ITEM\"\n"
+	os.WriteFile(filepath.Join(tempDir, ".todo", "config.yaml"), []byte(config), 0644)
+	userDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	stdout, _, _ = runCLI(t, binaryPath, "breakdown", "1", "--dry-run")
+	if !strings.Contains(stdout, "Error: breakdown.command in .todo/config.yaml isn't run") || strings.Contains(stdout, "Tag") {
+		t.Errorf("Expected the project's command to be refused, got %q", stdout)
+	}
+	os.Remove(filepath.Join(tempDir, ".todo", "config.yaml"))
+	os.MkdirAll(filepath.Join(userDir, "todo"), 0755)
+	os.WriteFile(filepath.Join(userDir, "todo", "config.yaml"), []byte(config), 0644)
+
+	stdout, _, _ = runCLI(t, binaryPath, "breakdown", "1", "--dry-run")
+	if !strings.Contains(stdout, "[ ] Tag Ship 2.0") {
+		t.Errorf("Expected the suggestions, got %q", stdout)
+	}
+	content, _ := os.ReadFile(filepath.Join(tempDir, ".todo", "release.md"))
+	if strings.Contains(string(content), "Build") {
+		t.Error("Expected --dry-run to leave the list alone")
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "explain", "1")
+	if !strings.Contains(stdout, "2. [ ] Tag Ship 2.0") || !strings.Contains(stdout, "3. [ ] Build") {
+		t.Errorf("Expected the subtasks to be added, got %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "list")
+	if strings.Index(stdout, "Build") > strings.Index(stdout, "Write notes") {
+		t.Errorf("Expected the subtasks under their item:\n%s", stdout)
+	}
+}
+
+func TestRecoverCommand(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "work")
+	runCLI(t, binaryPath, "add", "Ship release")
+	runCLI(t, binaryPath, "add", "Write docs")
+	runCLI(t, binaryPath, "check", "1")
+
+	stdout, _, _ := runCLI(t, binaryPath, "recover", "work")
+	if !strings.Contains(stdout, "List 'work' doesn't look damaged") {
+		t.Errorf("Expected an intact list to be left alone, got %q", stdout)
+	}
+
+	// Cut the list short in the middle of its last item
+	listPath := filepath.Join(tempDir, ".todo", "work.md")
+	content, _ := os.ReadFile(listPath)
+	os.WriteFile(listPath, content[:len(content)-12], 0644)
+
+	cmd := exec.Command(binaryPath, "list")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("todo list failed: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "Warning: list 'work' looks damaged") {
+		t.Errorf("Expected a warning about the damaged list, got %q", stderr.String())
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "recover", "work")
+	if !strings.Contains(stdout, "Recovered list 'work' with 2 items") || !strings.Contains(stdout, "work.damaged.md") {
+		t.Errorf("Expected the list to be recovered, got %q", stdout)
+	}
+	if recovered, _ := os.ReadFile(listPath); string(recovered) != string(content) {
+		t.Errorf("Expected the list to be restored, got %q", recovered)
+	}
+}
+
+func TestSnoozeAndDueDates(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "work")
+	stdout, _, _ := runCLI(t, binaryPath, "add", "Ship", "--due", "+3d")
+	if !strings.Contains(stdout, "Added todo item") {
+		t.Fatalf("Expected the item to be added, got %q", stdout)
+	}
+	listPath := filepath.Join(tempDir, ".todo", "work.md")
+	content, _ := os.ReadFile(listPath)
+	if want := "due: " + time.Now().AddDate(0, 0, 3).Format("2006-01-02"); !strings.Contains(string(content), want) {
+		t.Errorf("Expected %q in:\n%s", want, content)
+	}
+
+	runCLI(t, binaryPath, "snooze", "1")
+	content, _ = os.ReadFile(listPath)
+	if want := "due: " + time.Now().AddDate(0, 0, 1).Format("2006-01-02"); !strings.Contains(string(content), want) {
+		t.Errorf("Expected the item snoozed until tomorrow:\n%s", content)
+	}
+
+	os.WriteFile(filepath.Join(tempDir, ".todo", "config.yaml"), []byte("dates:\n  locale: es\n"), 0644)
+	stdout, _, _ = runCLI(t, binaryPath, "snooze", "1", "pasado", "mañana")
+	if want := time.Now().AddDate(0, 0, 2).Format("2006-01-02"); !strings.Contains(stdout, "Snoozed 'Ship' until") || !strings.Contains(stdout, want) {
+		t.Errorf("Expected the item snoozed by two days, got %q", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "add", "Docs", "--due", "someday")
+	if !strings.Contains(stdout, `Error: cannot read "someday" as a date`) {
+		t.Errorf("Expected an error for an unreadable date, got %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "snooze", "5")
+	if !strings.Contains(stdout, "Error: invalid item ID: 5") {
+		t.Errorf("Expected an error for a missing item, got %q", stdout)
+	}
+}
+
+func TestWorkingDaysAndRecurringItems(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "work")
+	// Every day of the next week is a holiday but one
+	var calendar strings.Builder
+	calendar.WriteString("BEGIN:VCALENDAR\r\n")
+	for i := 1; i <= 7; i++ {
+		if i == 4 {
+			continue
+		}
+		calendar.WriteString("BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:" + time.Now().AddDate(0, 0, i).Format("20060102") + "\r\nSUMMARY:Off\r\nEND:VEVENT\r\n")
+	}
+	calendar.WriteString("END:VCALENDAR\r\n")
+	os.WriteFile(filepath.Join(tempDir, "off.ics"), []byte(calendar.String()), 0644)
+	os.WriteFile(filepath.Join(tempDir, ".todo", "config.yaml"), []byte("calendar:\n  working_days: [mon, tue, wed, thu, fri, sat, sun]\n"), 0644)
+
+	stdout, _, _ := runCLI(t, binaryPath, "holidays", "import", "off.ics")
+	if !strings.Contains(stdout, "Imported 6 holidays") {
+		t.Fatalf("Expected the holidays to be imported, got %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "holidays", "-n", "2")
+	if strings.Count(stdout, "Off") != 2 {
+		t.Errorf("Expected two upcoming holidays, got %q", stdout)
+	}
+
+	runCLI(t, binaryPath, "add", "Ship", "--due", "+1bd")
+	runCLI(t, binaryPath, "add", "Water plants", "--every", "1d", "--due", "+1d")
+	stdout, _, _ = runCLI(t, binaryPath, "check", "2")
+	if !strings.Contains(stdout, "Marked item 2 as completed") {
+		t.Fatalf("Expected the item to be checked, got %q", stdout)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(tempDir, ".todo", "work.md"))
+	var todoList []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, "- [") {
+			todoList = append(todoList, line)
+		}
+	}
+	workday := time.Now().AddDate(0, 0, 4).Format("2006-01-02")
+	if len(todoList) != 3 || !strings.Contains(todoList[0], "due: "+workday) {
+		t.Fatalf("Expected +1bd to skip the holidays:\n%s", strings.Join(todoList, "\n"))
+	}
+	if next := todoList[2]; !strings.HasPrefix(next, "- [ ] Water plants") || !strings.Contains(next, "due: "+workday) || !strings.Contains(next, "every: 1d") {
+		t.Errorf("Expected the next occurrence on the working day, got %q", next)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "add", "Docs", "--every", "fortnight")
+	if !strings.Contains(stdout, `Error: invalid recurrence "fortnight"`) {
+		t.Errorf("Expected an error for an unknown recurrence, got %q", stdout)
+	}
+	runCLI(t, binaryPath, "holidays", "clear")
+	if stdout, _, _ = runCLI(t, binaryPath, "holidays"); !strings.Contains(stdout, "No upcoming holidays") {
+		t.Errorf("Expected the holidays to be cleared, got %q", stdout)
+	}
+}
+
+func TestNowFlag(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "main")
+	// The days items are added are recorded once insights are on
+	os.WriteFile(".todo/config.yaml", []byte("insights: true\n"), 0644)
+	runCLI(t, binaryPath, "add", "Pay rent", "--due", "tomorrow", "--now", "2024-06-30")
+	runCLI(t, binaryPath, "add", "Water plants", "--every", "1w", "--now", "2024-06-30")
+	runCLI(t, binaryPath, "check", "2", "--now", "2024-07-02 09:15")
+
+	content, _ := os.ReadFile(filepath.Join(tempDir, ".todo", "main.md"))
+	for _, want := range []string{
+		"- [ ] Pay rent <!-- due: 2024-07-01; added: 2024-06-30 -->",
+		// The 30th is a Sunday, so the first one is due on Monday
+		"- [x] Water plants <!-- due: 2024-07-01; completed: 2024-07-02 09:15; added: 2024-06-30; every: 1w -->",
+		"- [ ] Water plants <!-- due: 2024-07-08; added: 2024-07-02; every: 1w -->",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected %q in:\n%s", want, content)
+		}
+	}
+
+	if stdout, _, _ := runCLI(t, binaryPath, "overdue", "--now", "2024-07-03"); !strings.Contains(stdout, "Pay rent") {
+		t.Errorf("Expected the item to be overdue on the given day, got %q", stdout)
+	}
+	if stdout, _, _ := runCLI(t, binaryPath, "add", "x", "--now", "someday"); !strings.Contains(stdout, "Error: invalid time 'someday'") {
+		t.Errorf("Expected an error for an invalid time, got %q", stdout)
+	}
+}
+
+func TestViewNumbers(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLIWithInput(t, binaryPath, "y\n", "list", "work")
+	runCLI(t, binaryPath, "add", "Review the login page")
+	runCLIWithInput(t, binaryPath, "y\n", "list", "home")
+	runCLI(t, binaryPath, "add", "Buy milk")
+	runCLI(t, binaryPath, "add", "Fix the login on the router")
+
+	stdout, _, _ := runCLI(t, binaryPath, "search", "login")
+	if !strings.Contains(stdout, "  1. [ ] Fix the login on the router [home #2]\n  2. [ ] Review the login page [work #1]") {
+		t.Fatalf("Expected numbered search results, got %q", stdout)
+	}
+
+	// The number refers to the view, even for an item of another list
+	stdout, _, _ = runCLI(t, binaryPath, "check", "2")
+	if !strings.Contains(stdout, "Marked item 2 as completed in list 'work'") {
+		t.Errorf("Expected the view's second item to be checked, got %q", stdout)
+	}
+	if stdout, _, _ = runCLI(t, binaryPath, "show", "1"); !strings.HasPrefix(stdout, "Fix the login on the router") {
+		t.Errorf("Expected the view's first item, got %q", stdout)
+	}
+	if stdout, _, _ = runCLI(t, binaryPath, "check", "3"); !strings.Contains(stdout, "Error: no item 3 in the last view") {
+		t.Errorf("Expected an error past the end of the view, got %q", stdout)
+	}
+
+	// Showing a list brings its own numbers back
+	runCLI(t, binaryPath, "list", "home")
+	if stdout, _, _ = runCLI(t, binaryPath, "check", "1"); !strings.Contains(stdout, "Marked item 1 as completed in list 'home'") {
+		t.Errorf("Expected the list's first item to be checked, got %q", stdout)
+	}
+	content, _ := os.ReadFile(filepath.Join(".todo", "home.md"))
+	if !strings.Contains(string(content), "- [x] Buy milk") {
+		t.Errorf("Expected Buy milk to be checked:\n%s", content)
+	}
+}
+
+func TestSQLiteStorageCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "add", "Kept from markdown")
+	os.WriteFile(".todo/config.yaml", []byte("storage: sqlite\n"), 0644)
+
+	runCLI(t, binaryPath, "add", "Stored in the database")
+	runCLI(t, binaryPath, "check", "1")
+	if _, err := os.Stat(".todo/todo.db"); err != nil {
+		t.Fatalf("Expected .todo/todo.db to be created: %v", err)
+	}
+	content, _ := os.ReadFile(".todo/main.md")
+	if strings.Contains(string(content), "Stored in the database") || strings.Contains(string(content), "[x]") {
+		t.Errorf("Expected the list file to be left alone: %s", content)
+	}
+	stdout, _, _ := runCLI(t, binaryPath, "progress")
+	if !strings.Contains(stdout, "[x] Kept from markdown") || !strings.Contains(stdout, "[ ] Stored in the database") {
+		t.Errorf("Expected both items from the database, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "export", "--markdown")
+	if !strings.Contains(stdout, "Wrote 1 list file(s) from .todo/todo.db") {
+		t.Errorf("Unexpected export output: %s", stdout)
+	}
+	content, _ = os.ReadFile(".todo/main.md")
+	if !strings.Contains(string(content), "- [x] Kept from markdown <!-- completed: ") || !strings.Contains(string(content), "- [ ] Stored in the database") {
+		t.Errorf("Expected the list file to be regenerated: %s", content)
+	}
+}
+
+// The released binaries are built without cgo, so SQLite storage must not
+// need it
+func TestSQLiteStorageWithoutCgo(t *testing.T) {
+	moduleDir, _ := os.Getwd()
+	tempDir, _ := setupIntegrationTest(t)
+
+	binaryPath := filepath.Join(tempDir, "todo-nocgo")
+	build := exec.Command("go", "build", "-o", binaryPath, ".")
+	build.Dir = moduleDir
+	build.Env = append(os.Environ(), "CGO_ENABLED=0")
+	if output, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build without cgo: %v\n%s", err, output)
+	}
+
+	runCLI(t, binaryPath, "init", "--yes")
+	os.WriteFile(".todo/config.yaml", []byte("storage: sqlite\n"), 0644)
+	stdout, _, _ := runCLI(t, binaryPath, "add", "Stored without cgo")
+	if strings.Contains(stdout, "Error") {
+		t.Fatalf("Expected the database to open without cgo, got: %s", stdout)
+	}
+	if _, err := os.Stat(".todo/todo.db"); err != nil {
+		t.Fatalf("Expected .todo/todo.db to be created: %v", err)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "progress")
+	if !strings.Contains(stdout, "[ ] Stored without cgo") {
+		t.Errorf("Expected the item from the database, got: %s", stdout)
+	}
+}
+
+func TestGlobalFlag(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "add", "Project task")
+	runCLI(t, binaryPath, "--global", "add", "Buy milk")
+
+	global, err := os.ReadFile(filepath.Join(home, ".todo", "main.md"))
+	if err != nil || !strings.Contains(string(global), "Buy milk") {
+		t.Fatalf("Expected the item in ~/.todo/main.md, got %q (%v)", global, err)
+	}
+	project, _ := os.ReadFile(".todo/main.md")
+	if strings.Contains(string(project), "Buy milk") {
+		t.Errorf("Expected the project list to be left alone: %s", project)
+	}
+
+	t.Setenv("TODO_GLOBAL", "1")
+	stdout, _, _ := runCLI(t, binaryPath, "progress")
+	if !strings.Contains(stdout, "Buy milk") || strings.Contains(stdout, "Project task") {
+		t.Errorf("Expected TODO_GLOBAL=1 to show the global list, got: %s", stdout)
+	}
+}
+
+func TestSubdirectoryUsesTodoRoot(t *testing.T) {
+	testDir, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "add", "From the root")
+	os.MkdirAll(filepath.Join("src", "api"), 0755)
+	os.WriteFile(filepath.Join("src", "api", "handler.go"), []byte("package api\n"), 0644)
+	os.Chdir(filepath.Join("src", "api"))
+	defer os.Chdir(testDir)
+
+	runCLI(t, binaryPath, "add", "From a subdirectory")
+	if _, err := os.Stat(".todo"); !os.IsNotExist(err) {
+		t.Error("Expected no .todo directory to be created in the subdirectory")
+	}
+	stdout, _, _ := runCLI(t, binaryPath, "progress")
+	if !strings.Contains(stdout, "From the root") || !strings.Contains(stdout, "From a subdirectory") {
+		t.Errorf("Expected the root's list, got: %s", stdout)
+	}
+
+	// Paths are still relative to where todo was run
+	stdout, _, _ = runCLI(t, binaryPath, "anchor", "2", "handler.go:3")
+	if !strings.Contains(stdout, "Anchored item 2 to handler.go:3") {
+		t.Errorf("Unexpected anchor output: %s", stdout)
+	}
+	content, _ := os.ReadFile(filepath.Join(testDir, ".todo", "main.md"))
+	if !strings.Contains(string(content), "src/api/handler.go:3") {
+		t.Errorf("Expected the anchor relative to the project root: %s", content)
+	}
+}
+
+func TestAddSection(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "add", "Design doc")
+	runCLI(t, binaryPath, "add", "--section", "Backend", "Rate limiting")
+	runCLI(t, binaryPath, "add", "--section", "Frontend", "Login form")
+	stdout, _, _ := runCLI(t, binaryPath, "add", "--section", "backend", "Caching")
+	if !strings.Contains(stdout, "Added todo item to list 'main': Caching") {
+		t.Errorf("Unexpected add output: %s", stdout)
+	}
+
+	content, _ := os.ReadFile(".todo/main.md")
+	if !strings.Contains(string(content), "## Backend\n\n- [ ] Rate limiting\n") || !strings.Contains(string(content), "- [ ] Caching\n") {
+		t.Errorf("Expected the items under their heading: %s", content)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "progress")
+	if !strings.Contains(stdout, "Backend:\n2. [ ] Rate limiting\n3. [ ] Caching\n\nFrontend:\n4. [ ] Login form") {
+		t.Errorf("Expected the list grouped by section, got: %s", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "add", "--section", "Backend", "--under", "2", "Nope")
+	if !strings.Contains(stdout, "Error: --under can't be combined with --section") {
+		t.Errorf("Expected --under and --section to conflict, got: %s", stdout)
+	}
+}
+
+func TestNoteCommand(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "add", "Ship the release")
+	stdout, _, _ := runCLI(t, binaryPath, "note", "1", "Blocked on the API review")
+	if !strings.Contains(stdout, "Added a note to item 1") {
+		t.Errorf("Unexpected note output: %s", stdout)
+	}
+	runCLI(t, binaryPath, "add", "--under", "1", "Write the changelog")
+	runCLI(t, binaryPath, "note", "1", "Ask Sam on Monday")
+
+	content, _ := os.ReadFile(".todo/main.md")
+	if !strings.Contains(string(content), "- [ ] Ship the release\n  Blocked on the API review\n  Ask Sam on Monday\n  - [ ] Write the changelog") {
+		t.Errorf("Expected the notes indented under the item: %s", content)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "show", "1")
+	if !strings.Contains(stdout, "\n  Blocked on the API review\n  Ask Sam on Monday\n") {
+		t.Errorf("Expected show to print the notes, got: %s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "note", "5", "Nothing there")
+	if !strings.Contains(stdout, "Error:") {
+		t.Errorf("Expected an error for a missing item, got: %s", stdout)
+	}
+}
+
+func TestBulkCheckUncheck(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	for _, text := range []string{"One", "Two", "Three", "Four", "Five", "Six"} {
+		runCLI(t, binaryPath, "add", text)
+	}
+
+	// A bad number checks nothing
+	stdout, _, _ := runCLI(t, binaryPath, "check", "1", "9")
+	if !strings.Contains(stdout, "invalid item ID: 9") {
+		t.Errorf("Expected an error for item 9, got: %s", stdout)
+	}
+	content, _ := os.ReadFile(".todo/main.md")
+	if strings.Contains(string(content), "[x]") {
+		t.Errorf("Expected nothing to be checked: %s", content)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "check", "1", "3-5", "4")
+	for _, ref := range []string{"1", "3", "4", "5"} {
+		if !strings.Contains(stdout, fmt.Sprintf("Marked item %s as completed in list 'main'", ref)) {
+			t.Errorf("Expected item %s to be checked, got: %s", ref, stdout)
+		}
+	}
+	if strings.Count(stdout, "Marked item") != 4 {
+		t.Errorf("Expected item 4 to be checked once, got: %s", stdout)
+	}
+	content, _ = os.ReadFile(".todo/main.md")
+	if strings.Count(string(content), "[x]") != 4 || !strings.Contains(string(content), "- [ ] Two") {
+		t.Errorf("Expected items 1 and 3-5 to be checked: %s", content)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "uncheck", "3-4", "--json")
+	var changes []struct {
+		Item struct {
+			Text      string `json:"text"`
+			Completed bool   `json:"completed"`
+		} `json:"item"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &changes); err != nil || len(changes) != 2 || changes[1].Item.Text != "Four" || changes[1].Item.Completed {
+		t.Errorf("Expected two unchecked items as JSON, got %s (%v)", stdout, err)
+	}
+	content, _ = os.ReadFile(".todo/main.md")
+	if strings.Count(string(content), "[x]") != 2 {
+		t.Errorf("Expected items 3 and 4 to be unchecked: %s", content)
+	}
+}
+
+func TestCheckPlainOutput(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "add", "Water plants", "--every", "1d")
+	for _, text := range []string{"Two", "Three", "Four", "Five", "Six", "Seven"} {
+		runCLI(t, binaryPath, "add", text)
+	}
+
+	// Without --json only the messages are printed
+	if stdout, _, _ := runCLI(t, binaryPath, "check", "2"); stdout != "Marked item 2 as completed in list 'main'\n" {
+		t.Errorf("Unexpected check output: %q", stdout)
+	}
+	if stdout, _, _ := runCLI(t, binaryPath, "uncheck", "2"); stdout != "Marked item 2 as not completed in list 'main'\n" {
+		t.Errorf("Unexpected uncheck output: %q", stdout)
+	}
+
+	// A range past the end names the first missing item and checks
+	// nothing, though checking the repeating item would add an eighth
+	if stdout, _, _ := runCLI(t, binaryPath, "check", "1-99"); stdout != "Error: invalid item ID: 8\n" {
+		t.Errorf("Unexpected output for a range past the end: %q", stdout)
+	}
+	content, _ := os.ReadFile(".todo/main.md")
+	if strings.Contains(string(content), "[x]") {
+		t.Errorf("Expected nothing to be checked: %s", content)
+	}
+}
+
+func TestAddFromStdin(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	stdout, _, _ := runCLIWithInput(t, binaryPath, "- Buy milk\n\n1. Call Sam\n", "add", "-", "--priority", "p2")
+	if !strings.Contains(stdout, "Added todo item to list 'main': Buy milk\nAdded todo item to list 'main': Call Sam") {
+		t.Errorf("Unexpected add output: %s", stdout)
+	}
+	runCLIWithInput(t, binaryPath, "Book flights\n", "add", "--stdin", "--section", "Travel")
+
+	content, _ := os.ReadFile(".todo/main.md")
+	for _, expected := range []string{"- [ ] Buy milk <!-- priority: p2 -->", "- [ ] Call Sam <!-- priority: p2 -->", "## Travel\n\n- [ ] Book flights"} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected %q in: %s", expected, content)
+		}
+	}
+	if strings.Count(string(content), "priority: p2") != 2 {
+		t.Errorf("Expected the priority on both piped items: %s", content)
+	}
+
+	stdout, _, _ = runCLIWithInput(t, binaryPath, "\n\n", "add", "-")
+	if !strings.Contains(stdout, "No items to add") {
+		t.Errorf("Expected nothing to be added from blank input, got: %s", stdout)
+	}
+	stdout, _, _ = runCLIWithInput(t, binaryPath, "", "add", "--stdin", "Extra")
+	if !strings.Contains(stdout, "Error: --stdin reads the items from stdin") {
+		t.Errorf("Expected --stdin with an item to fail, got: %s", stdout)
+	}
+}
+
+func TestImportMarkdownFile(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	os.WriteFile("TODO.md", []byte("# Launch\n\nSome notes about the launch.\n\n- [x] Write the announcement\n- [ ] Update the docs\n  - [X] Screenshots\n"), 0644)
+
+	stdout, _, _ := runCLI(t, binaryPath, "import", "TODO.md", "--list", "launch")
+	if !strings.Contains(stdout, "Imported 3 item(s) (2 completed) from TODO.md into list 'launch'") {
+		t.Errorf("Unexpected import output: %s", stdout)
+	}
+	content, _ := os.ReadFile(".todo/launch.md")
+	if !strings.Contains(string(content), "## Launch\n\n- [x] Write the announcement\n- [ ] Update the docs\n  - [x] Screenshots") {
+		t.Errorf("Expected the tasks with their checked state, got: %s", content)
+	}
+
+	os.WriteFile("prose.md", []byte("Nothing to do here.\n"), 0644)
+	stdout, _, _ = runCLI(t, binaryPath, "import", "prose.md")
+	if !strings.Contains(stdout, "No task list items found in prose.md") {
+		t.Errorf("Expected no items to be found, got: %s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "import", "missing.md")
+	if !strings.Contains(stdout, "Error reading missing.md") {
+		t.Errorf("Expected a missing file error, got: %s", stdout)
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "add", "Login form")
+	runCLI(t, binaryPath, "check", "1")
+	runCLI(t, binaryPath, "list", "billing")
+	runCLI(t, binaryPath, "add", "Invoices")
+
+	stdout, _, _ := runCLI(t, binaryPath, "export", "--format", "json", "--all")
+	var lists []struct {
+		Name  string `json:"name"`
+		Items []struct {
+			Text        string `json:"text"`
+			Completed   bool   `json:"completed"`
+			CompletedAt string `json:"completed_at"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &lists); err != nil {
+		t.Fatalf("Expected JSON, got %v: %s", err, stdout)
+	}
+	if len(lists) != 2 || lists[1].Name != "main" || !lists[1].Items[0].Completed || lists[1].Items[0].CompletedAt == "" {
+		t.Errorf("Unexpected export %+v", lists)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "export", "--format", "csv", "--list", "main", "--out", "main.csv")
+	if !strings.Contains(stdout, "Wrote 1 list(s) to main.csv") {
+		t.Errorf("Unexpected export output: %s", stdout)
+	}
+	content, _ := os.ReadFile("main.csv")
+	if !strings.HasPrefix(string(content), "list,id,label,text,completed,completed_at,") || !strings.Contains(string(content), "\nmain,1,1,Login form,true,") {
+		t.Errorf("Unexpected CSV: %s", content)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "export", "--format", "md")
+	if !strings.HasPrefix(stdout, "# Todo List for billing\n\n- [ ] Invoices") {
+		t.Errorf("Expected the current list as markdown, got: %s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "export", "--format", "md", "--all", "--list", "main")
+	if !strings.Contains(stdout, "Error: --all can't be combined with --list") {
+		t.Errorf("Expected --all with --list to fail, got: %s", stdout)
+	}
+}
+
+func TestTodoTxtImportExport(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	todoTxt := "(A) 2024-06-21 Book flights @phone +travel due:2024-07-01\nx 2024-06-27 2024-06-20 Write notes +release pri:B\n"
+	os.WriteFile("todo.txt", []byte(todoTxt), 0644)
+
+	stdout, _, _ := runCLI(t, binaryPath, "import", "todo.txt")
+	if !strings.Contains(stdout, "Imported 2 item(s) (1 completed) from todo.txt into list 'main'") {
+		t.Errorf("Unexpected import output: %s", stdout)
+	}
+	content, _ := os.ReadFile(".todo/main.md")
+	for _, expected := range []string{"- [ ] Book flights @phone +travel <!-- due: 2024-07-01; added: 2024-06-21; priority: p1 -->", "- [x] Write notes +release <!-- completed: 2024-06-27 00:00; added: 2024-06-20; priority: p2 -->"} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected %q in: %s", expected, content)
+		}
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "export", "--format", "todotxt")
+	if stdout != todoTxt {
+		t.Errorf("Expected the todo.txt file back, got: %q", stdout)
+	}
+
+	// --format reads a todo.txt file by another name
+	os.WriteFile("tasks", []byte("(B) Call Sam\n"), 0644)
+	runCLI(t, binaryPath, "import", "tasks", "--format", "todotxt", "--list", "calls")
+	content, _ = os.ReadFile(".todo/calls.md")
+	if !strings.Contains(string(content), "- [ ] Call Sam <!-- priority: p2 -->") {
+		t.Errorf("Expected the task in calls: %s", content)
+	}
+}
+
+func TestSyncTodoistBindsList(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "list", "groceries")
+	stdout, _, _ := runCLI(t, binaryPath, "sync", "todoist", "--project", "Groceries", "--dry-run")
+	if !strings.Contains(stdout, "List 'groceries' now syncs with the Todoist project 'Groceries'") {
+		t.Errorf("Unexpected output: %s", stdout)
+	}
+	content, _ := os.ReadFile(filepath.Join(tempDir, ".todo", "groceries.md"))
+	if !strings.Contains(string(content), "todoist: Groceries") {
+		t.Errorf("Expected the project in the frontmatter: %s", content)
+	}
+
+	stdout, _, _ = runCLI(t, binaryPath, "sync", "todoist", "--project", "none")
+	if !strings.Contains(stdout, "List 'groceries' is no longer synced with Todoist") {
+		t.Errorf("Unexpected output: %s", stdout)
+	}
+	if content, _ = os.ReadFile(filepath.Join(tempDir, ".todo", "groceries.md")); strings.Contains(string(content), "todoist") {
+		t.Errorf("Expected the project to be removed: %s", content)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "sync", "todoist")
+	if !strings.Contains(stdout, "Error: no lists are bound to Todoist") {
+		t.Errorf("Expected an error without bound lists, got: %s", stdout)
+	}
+}
+
+func TestSyncTodoDataBranch(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	remote := filepath.Join(tempDir, "remote.git")
+	git(tempDir, "init", "-q", "--bare", remote)
+	git(tempDir, "remote", "add", "origin", remote)
+	git(tempDir, "push", "-q", "origin", "HEAD")
+
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "list", "auth")
+	runCLI(t, binaryPath, "add", "Login form")
+	head := git(tempDir, "rev-parse", "HEAD")
+
+	stdout, _, _ := runCLI(t, binaryPath, "sync", "push")
+	if !strings.Contains(stdout, "auth.md") {
+		t.Errorf("Expected the pushed list, got: %s", stdout)
+	}
+
+	// The lists go to the todo-data branch, which shares no history with
+	// the working branch, and the working branch is left alone
+	if got := git(tempDir, "rev-parse", "HEAD"); got != head {
+		t.Errorf("Push moved the working branch from %s to %s", head, got)
+	}
+	if out := git(remote, "ls-tree", "-r", "--name-only", "todo-data"); !strings.Contains(out, "auth.md") {
+		t.Errorf("Expected auth.md on todo-data, got: %s", out)
+	}
+	if err := exec.Command("git", "-C", remote, "merge-base", "todo-data", "HEAD").Run(); err == nil {
+		t.Error("Expected todo-data to be an orphan branch")
+	}
+
+	// Another clone pulls the lists, and its changes come back
+	other := filepath.Join(tempDir, "other")
+	git(tempDir, "clone", "-q", remote, other)
+	git(other, "config", "user.name", "Test User")
+	git(other, "config", "user.email", "test@example.com")
+	os.Chdir(other)
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "sync", "pull")
+	content, _ := os.ReadFile(filepath.Join(other, ".todo", "auth.md"))
+	if !strings.Contains(string(content), "Login form") {
+		t.Fatalf("Expected the pulled list, got: %s", content)
+	}
+
+	runCLI(t, binaryPath, "list", "auth")
+	runCLI(t, binaryPath, "check", "1")
+	runCLI(t, binaryPath, "sync", "push")
+
+	os.Chdir(tempDir)
+	runCLI(t, binaryPath, "sync", "pull")
+	content, _ = os.ReadFile(filepath.Join(tempDir, ".todo", "auth.md"))
+	if !strings.Contains(string(content), "- [x] Login form") {
+		t.Errorf("Expected the item checked on the other clone, got: %s", content)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "sync", "status")
+	if !strings.Contains(stdout, "up to date") {
+		t.Errorf("Expected nothing left to sync, got: %s", stdout)
+	}
+
+	// A push while offline is queued, and only a push sends it
+	git(tempDir, "remote", "set-url", "origin", filepath.Join(tempDir, "missing.git"))
+	runCLI(t, binaryPath, "add", "Logout")
+	stdout, _, _ = runCLI(t, binaryPath, "sync", "push")
+	if !strings.Contains(stdout, "Offline:") {
+		t.Fatalf("Expected the push to be queued, got: %s", stdout)
+	}
+	git(tempDir, "remote", "set-url", "origin", remote)
+	stdout, _, _ = runCLI(t, binaryPath, "sync", "pull")
+	if !strings.Contains(stdout, "1 change(s) queued while offline will be sent by 'todo sync push'") {
+		t.Errorf("Expected the pull to leave the queue alone, got: %s", stdout)
+	}
+	if out := git(remote, "show", "todo-data:auth.md"); strings.Contains(out, "Logout") {
+		t.Errorf("Expected the pull not to send the queued change, got: %s", out)
+	}
+	runCLI(t, binaryPath, "sync", "push")
+	if out := git(remote, "show", "todo-data:auth.md"); !strings.Contains(out, "Logout") {
+		t.Errorf("Expected the push to send the queued change, got: %s", out)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "sync", "status")
+	if strings.Contains(stdout, "queued while offline") {
+		t.Errorf("Expected the queue to be flushed by the push, got: %s", stdout)
+	}
+}
+
+func TestBranchCommand(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+	
+	runCLI(t, binaryPath, "init", "--yes")
+	currentBranch := func() string {
+		output, _ := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+		return strings.TrimSpace(string(output))
+	}
+	
+	stdout, _, _ := runCLI(t, binaryPath, "branch", "feature/auth")
+	if !strings.Contains(stdout, "Created branch 'feature/auth'") || !strings.Contains(stdout, "Created todo list 'auth'") {
+		t.Errorf("Unexpected output: %s", stdout)
+	}
+	if branch := currentBranch(); branch != "feature/auth" {
+		t.Errorf("Expected feature/auth to be checked out, got %s", branch)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, ".todo", "auth.md")); err != nil {
+		t.Errorf("Expected the auth list to be created: %v", err)
+	}
+	
+	// An existing branch is checked out and gets a list named after it
+	exec.Command("git", "branch", "fix/login-bug").Run()
+	stdout, _, _ = runCLI(t, binaryPath, "branch", "fix/login-bug")
+	if !strings.Contains(stdout, "Switched to branch 'fix/login-bug'") || !strings.Contains(stdout, "Created todo list 'fix-login-bug'") {
+		t.Errorf("Unexpected output: %s", stdout)
+	}
+	
+	stdout, _, _ = runCLI(t, binaryPath, "branch", "feature/auth")
+	if !strings.Contains(stdout, "Switched to branch 'feature/auth'") || !strings.Contains(stdout, "Switched to list 'auth'") {
+		t.Errorf("Unexpected output: %s", stdout)
+	}
+	if content, _ := os.ReadFile(filepath.Join(tempDir, ".current-list")); string(content) != "auth" {
+		t.Errorf("Expected auth to be the current list, got %q", content)
+	}
+	
+	stdout, _, _ = runCLI(t, binaryPath, "branch", "bad..name")
+	if !strings.Contains(stdout, "Error creating branch") {
+		t.Errorf("Expected an invalid branch name to fail, got: %s", stdout)
+	}
+}
+
+func TestAutoCommit(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+	
+	runCLI(t, binaryPath, "init", "--yes", "--visibility", "committed")
+	lastCommit := func() string {
+		output, _ := exec.Command("git", "log", "-1", "--format=%s").Output()
+		return strings.TrimSpace(string(output))
+	}
+	
+	// Without --commit or auto_commit nothing is committed
+	runCLI(t, binaryPath, "add", "Implement login")
+	if got := lastCommit(); got != "Initial commit" {
+		t.Errorf("Expected no commit, got %q", got)
+	}
+	
+	stdout, _, _ := runCLI(t, binaryPath, "add", "Write tests", "--commit")
+	if got := lastCommit(); got != "todo: add 2 items in main" {
+		t.Errorf("Unexpected commit %q (output: %s)", got, stdout)
+	}
+	
+	configPath := filepath.Join(tempDir, ".todo", "config.yaml")
+	config, _ := os.ReadFile(configPath)
+	os.WriteFile(configPath, append(config, []byte("auto_commit: true\n")...), 0644)
+	stdout, _, _ = runCLI(t, binaryPath, "check", "1")
+	if got := lastCommit(); got != "todo: check 'Implement login' in main" {
+		t.Errorf("Unexpected commit %q (output: %s)", got, stdout)
+	}
+	if !strings.Contains(stdout, "Committed: todo: check 'Implement login' in main") {
+		t.Errorf("Expected the commit to be reported, got: %s", stdout)
+	}
+	
+	// Commands that change no lists commit nothing
+	runCLI(t, binaryPath, "list")
+	if output, _ := exec.Command("git", "rev-list", "--count", "HEAD").Output(); strings.TrimSpace(string(output)) != "3" {
+		t.Errorf("Expected 3 commits, got %s", output)
+	}
+}
+
+func TestMergetoolMergesBranches(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+	
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		// The driver runs 'todo mergetool', so the binary must be on PATH
+		cmd.Env = append(os.Environ(), "PATH="+tempDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+		return string(output)
+	}
+	
+	runCLI(t, binaryPath, "init", "--yes", "--visibility", "committed")
+	stdout, _, _ := runCLI(t, binaryPath, "mergetool", "install")
+	if !strings.Contains(stdout, "Registered the todo merge driver for .todo/*.md") {
+		t.Errorf("Unexpected output: %s", stdout)
+	}
+	// Only the list is merged here
+	f, _ := os.OpenFile(".gitignore", os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(".todo/journal.jsonl\n")
+	f.Close()
+	runCLI(t, binaryPath, "list", "auth")
+	runCLI(t, binaryPath, "add", "Login form")
+	runCLI(t, binaryPath, "add", "Logout")
+	git("add", ".gitignore", ".todo")
+	git("commit", "-q", "-m", "Add auth list")
+	base := strings.TrimSpace(git("rev-parse", "--abbrev-ref", "HEAD"))
+	
+	git("checkout", "-q", "-b", "other")
+	runCLI(t, binaryPath, "check", "1")
+	runCLI(t, binaryPath, "add", "Remember me")
+	git("commit", "-q", "-am", "Work on other")
+	
+	git("checkout", "-q", base)
+	runCLI(t, binaryPath, "remove", "2")
+	runCLI(t, binaryPath, "add", "Password reset")
+	git("commit", "-q", "-am", "Work on base")
+	
+	// Both sides appended an item at the end, which a text merge can't settle
+	git("merge", "-q", "--no-edit", "other")
+	content, _ := os.ReadFile(filepath.Join(tempDir, ".todo", "auth.md"))
+	for _, want := range []string{"- [x] Login form", "- [ ] Password reset", "- [ ] Remember me"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected %q in the merged list:\n%s", want, content)
+		}
+	}
+	if strings.Contains(string(content), "Logout") || strings.Contains(string(content), "<<<<<<<") {
+		t.Errorf("Unexpected merged list:\n%s", content)
+	}
+}
+
+func TestPruneFlags(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+	
+	runCLI(t, binaryPath, "init", "--yes")
+	base, _ := exec.Command("git", "branch", "--show-current").Output()
+	// feature/old was merged, feature/gone is deleted with work left in its
+	// list, and feature/new has no commits of its own yet
+	runCLI(t, binaryPath, "branch", "feature/old")
+	exec.Command("git", "commit", "-q", "--allow-empty", "-m", "Old work").Run()
+	exec.Command("git", "checkout", "-q", strings.TrimSpace(string(base))).Run()
+	exec.Command("git", "merge", "-q", "--no-ff", "--no-edit", "feature/old").Run()
+	runCLI(t, binaryPath, "branch", "feature/gone")
+	runCLI(t, binaryPath, "add", "Finish the migration")
+	runCLI(t, binaryPath, "branch", "feature/new")
+	exec.Command("git", "checkout", "-q", strings.TrimSpace(string(base))).Run()
+	exec.Command("git", "branch", "-D", "feature/gone").Run()
+	// Lists that never had a branch aren't stale
+	runCLI(t, binaryPath, "list", "notes")
+	runCLI(t, binaryPath, "list", "work")
+	
+	stdout, _, _ := runCLI(t, binaryPath, "prune", "--dry-run")
+	for _, want := range []string{"Would archive list 'gone' (branch feature/gone no longer exists)", "Would archive list 'old' (branch feature/old was merged into "} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q, got: %s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "'notes'") || strings.Contains(stdout, "'new'") {
+		t.Errorf("Expected the lists without a merged or deleted branch to be kept, got: %s", stdout)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, ".todo", "old.md")); err != nil {
+		t.Errorf("Expected a dry run to keep the list: %v", err)
+	}
+	
+	// --yes doesn't ask, except about a list with pending items, and
+	// --delete deletes instead of archiving
+	stdout, _, _ = runCLIWithInput(t, binaryPath, "n\n", "prune", "--delete", "--yes")
+	if !strings.Contains(stdout, "Delete list 'gone' (branch feature/gone no longer exists) with 1 pending item(s)?") || !strings.Contains(stdout, "Deleted 1 of 2 stale list(s)") {
+		t.Errorf("Unexpected output: %s", stdout)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, ".todo", "gone.md")); err != nil {
+		t.Errorf("Expected the list with pending items to be kept: %v", err)
+	}
+	stdout, _, _ = runCLIWithInput(t, binaryPath, "y\n", "prune", "--delete", "--yes")
+	if !strings.Contains(stdout, "Deleted 1 of 1 stale list(s)") {
+		t.Errorf("Unexpected output: %s", stdout)
+	}
+	for _, name := range []string{"old.md", "archive/old.md", "gone.md"} {
+		if _, err := os.Stat(filepath.Join(tempDir, ".todo", name)); !os.IsNotExist(err) {
+			t.Errorf("Expected no %s, got %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, ".todo", "work.md")); err != nil {
+		t.Errorf("Expected the current list to be kept: %v", err)
+	}
+}
+
+func TestMonorepoSubprojects(t *testing.T) {
+	tempDir, binaryPath := setupIntegrationTest(t)
+	
+	runCLI(t, binaryPath, "init", "--yes")
+	runCLI(t, binaryPath, "list", "release")
+	runCLI(t, binaryPath, "add", "Tag v2")
+	for _, dir := range []string{"packages/api", "packages/web"} {
+		os.MkdirAll(filepath.Join(tempDir, dir, ".todo"), 0755)
+	}
+	
+	// -C targets the subproject's own .todo
+	stdout, _, _ := runCLI(t, binaryPath, "-C", "packages/api", "list", "auth")
+	if !strings.Contains(stdout, "Created todo list 'auth'") {
+		t.Errorf("Unexpected output: %s", stdout)
+	}
+	runCLI(t, binaryPath, "-C", "packages/api", "add", "Login endpoint")
+	runCLI(t, binaryPath, "-C", "packages/api", "check", "1")
+	if _, err := os.Stat(filepath.Join(tempDir, "packages", "api", ".todo", "auth.md")); err != nil {
+		t.Errorf("Expected the list in packages/api: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, ".todo", "auth.md")); !os.IsNotExist(err) {
+		t.Errorf("Expected no auth list at the root, got %v", err)
+	}
+	
+	stdout, _, _ = runCLI(t, binaryPath, "list", "--recursive")
+	for _, want := range []string{"packages/api/auth - 1/1 completed (100%)", "release           - 0/1 completed (0%)"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q, got: %s", want, stdout)
+		}
+	}
+	
+	stdout, _, _ = runCLI(t, binaryPath, "-C", "missing", "list")
+	if !strings.Contains(stdout, "Error: chdir missing") {
+		t.Errorf("Expected an error for a missing directory, got: %s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "list", "--recursive", "--health")
+	if !strings.Contains(stdout, "Error: --recursive can't be combined with --health") {
+		t.Errorf("Unexpected output: %s", stdout)
+	}
+}
+
+func TestConfigLayers(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+	userDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TODO_DEFAULT_LIST", "")
+
+	runCLI(t, binaryPath, "init", "--yes")
+	// The user's defaults apply to every project, under its own settings
+	os.MkdirAll(filepath.Join(userDir, "todo"), 0755)
+	os.WriteFile(filepath.Join(userDir, "todo", "config.yaml"), []byte("display:\n  time_format: \"Jan 2, 2006 at 15:04\"\n  sort: priority\n"), 0644)
+	runCLI(t, binaryPath, "add", "Write docs")
+	runCLI(t, binaryPath, "add", "Fix login", "--priority", "p1")
+	runCLI(t, binaryPath, "check", "1", "--now", "2024-06-03 09:30")
+
+	stdout, _, _ := runCLI(t, binaryPath, "show", "1")
+	if !strings.Contains(stdout, "Status:    completed Jun 3, 2024 at 09:30") {
+		t.Errorf("Expected the user's time format, got:\n%s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--format", "plain")
+	if !strings.HasPrefix(stdout, "2. [ ] Fix login (p1)\n1. [x] Write docs\n") {
+		t.Errorf("Expected display.sort to order by priority, got:\n%s", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--format", "plain", "--sort", "position")
+	if !strings.HasPrefix(stdout, "1. [x] Write docs\n") {
+		t.Errorf("Expected --sort to override display.sort, got:\n%s", stdout)
+	}
+
+	// Colors only go to a terminal unless asked for
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--format", "color")
+	if strings.Contains(stdout, "\033[") {
+		t.Errorf("Expected no colors in a pipe, got %q", stdout)
+	}
+	os.WriteFile(".todo/config.yaml", []byte("display:\n  color: always\n"), 0644)
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--format", "color")
+	if !strings.Contains(stdout, "\033[32m1. [x] Write docs\033[0m") {
+		t.Errorf("Expected display.color to force colors, got %q", stdout)
+	}
+	t.Setenv("NO_COLOR", "1")
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--format", "color")
+	if strings.Contains(stdout, "\033[") {
+		t.Errorf("Expected NO_COLOR to override the config, got %q", stdout)
+	}
+	stdout, _, _ = runCLI(t, binaryPath, "progress", "--format", "color", "--color", "always")
+	if !strings.Contains(stdout, "\033[") {
+		t.Errorf("Expected --color to override NO_COLOR, got %q", stdout)
+	}
+
+	// The environment picks the default list
+	t.Setenv("TODO_DEFAULT_LIST", "inbox")
+	os.Remove(".todo/.current-list")
+	runCLI(t, binaryPath, "add", "Reply to email")
+	if content, err := os.ReadFile(".todo/inbox.md"); err != nil || !strings.Contains(string(content), "Reply to email") {
+		t.Errorf("Expected the item in the inbox list, got %q (%v)", content, err)
+	}
+}
+
+func TestHooksInstallRecordsConfig(t *testing.T) {
+	_, binaryPath := setupIntegrationTest(t)
+	
+	runCLI(t, binaryPath, "init", "--yes")
+	stdout, _, exitCode := runCLI(t, binaryPath, "hooks", "install")
+	if exitCode != 0 || !strings.HasPrefix(stdout, "Installed git hooks") {
+		t.Fatalf("Unexpected output (exit %d): %s", exitCode, stdout)
+	}
+	if content, _ := os.ReadFile(".todo/config.yaml"); !strings.Contains(string(content), "hooks: true") {
+		t.Errorf("Expected the hooks to be recorded, got %q", content)
+	}
+	
+	// A config that can't be written fails instead of going unrecorded
+	os.Remove(".todo/config.yaml")
+	os.Symlink(filepath.Join("missing", "config.yaml"), ".todo/config.yaml")
+	stdout, _, _ = runCLI(t, binaryPath, "hooks", "install")
+	if !strings.HasPrefix(stdout, "Error recording hooks in config: ") || strings.Contains(stdout, "Installed git hooks") {
+		t.Errorf("Expected an error: %s", stdout)
+	}
+}
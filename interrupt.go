@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// interruptible returns the command's context, cancelled when Ctrl-C is
+// pressed or the process is asked to stop, for long operations that stop
+// cleanly. Until stop is called those signals no longer kill the process,
+// so only wrap the long-running part.
+func interruptible(cmd *cobra.Command) (ctx context.Context, stop context.CancelFunc) {
+	return signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+}
+
+// printInterrupted reports whether err means the command was interrupted,
+// printing so if it was
+func printInterrupted(err error) bool {
+	if !errors.Is(err, context.Canceled) {
+		return false
+	}
+	if err == context.Canceled {
+		fmt.Println("Interrupted")
+	} else {
+		fmt.Printf("Interrupted: %v\n", err)
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+// jsonCommands are the commands that can print JSON for --json: every
+// command that reads lists or settings, and the item commands. Others refuse
+// the flag rather than print text a script would fail to parse.
+var jsonCommands = map[string]bool{
+	"todo list":           true,
+	"todo progress":       true,
+	"todo history":        true,
+	"todo count":          true,
+	"todo random":         true,
+	"todo fit":            true,
+	"todo agenda":         true,
+	"todo overdue":        true,
+	"todo due":            true,
+	"todo today":          true,
+	"todo search":         true,
+	"todo show":           true,
+	"todo standup":        true,
+	"todo retro":          true,
+	"todo tags":           true,
+	"todo holidays":       true,
+	"todo timesheet":      true,
+	"todo audit":          true,
+	"todo insights":       true,
+	"todo peek":           true,
+	"todo sync status":    true,
+	"todo workspace list": true,
+	"todo auth status":    true,
+	"todo daemon status":  true,
+	"todo version":        true,
+	"todo add":            true,
+	"todo check":          true,
+	"todo uncheck":        true,
+	"todo remove":         true,
+}
+
+// jsonOutput reports whether --json was given
+func jsonOutput(cmd *cobra.Command) bool {
+	value, _ := cmd.Flags().GetBool("json")
+	return value
+}
+
+// checkJSONFlag ends commands given --json that can't print it
+func checkJSONFlag(cmd *cobra.Command) {
+	if !jsonCommands[cmd.CommandPath()] {
+		fmt.Printf("Error: '%s' doesn't support --json\n", cmd.CommandPath())
+		os.Exit(1)
+	}
+}
+
+// outputFormat returns the --format a command was given, or json for
+// --json. ok is false, after printing an error, when both ask for
+// different formats.
+func outputFormat(cmd *cobra.Command) (format string, ok bool) {
+	format, _ = cmd.Flags().GetString("format")
+	if !jsonOutput(cmd) {
+		return format, true
+	}
+	if cmd.Flags().Changed("format") && format != "json" {
+		fmt.Printf("Error: --json can't be combined with --format %s\n", format)
+		return "", false
+	}
+	return "json", true
+}
+
+// printJSON prints value as indented JSON
+func printJSON(value interface{}) {
+	if err := pkg.WriteJSON(os.Stdout, value); err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+}
+
+// printItemChanges prints the items changed by a command that takes several
+// of them as a JSON array, however many were given
+func printItemChanges(changes []*pkg.ItemChange) {
+	printJSON(append([]*pkg.ItemChange{}, changes...))
+}
+
+// printItemChange prints the item a command changed as JSON
+func printItemChange(action, listName string, itemID int) {
+	change, err := pkg.NewItemChange(action, listName, itemID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	printJSON(change)
+}
+
+// printListItems prints items gathered from several lists as a JSON array
+func printListItems(entries []pkg.ListItem) {
+	items, err := pkg.ListItemsJSON(entries)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	printJSON(items)
+}
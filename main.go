@@ -2,17 +2,90 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand/v2"
 	"os"
-	"strconv"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/scttymn/todo-cli/pkg"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
+// terminalWidth returns the width of the terminal on stdout, or 0 when output
+// isn't going to a terminal so piped output is never wrapped
+func terminalWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		return width
+	}
+	return 0
+}
+
+// applyDisplaySettings sets how times and colors are shown from the config
+// and the environment, with --color taking precedence
+func applyDisplaySettings(cmd *cobra.Command) {
+	cfg, err := pkg.LoadConfig()
+	if err != nil {
+		// Commands that read the config report the error themselves
+		cfg = pkg.DefaultConfig()
+	}
+	pkg.TimeFormat = cfg.Display.TimeLayout()
+	color := cfg.Display.Color
+	if cmd.Flags().Changed("color") {
+		color, _ = cmd.Flags().GetString("color")
+		switch color {
+		case pkg.ColorAuto, pkg.ColorAlways, pkg.ColorNever:
+		default:
+			fmt.Printf("Error: invalid --color %q (expected %s, %s or %s)\n", color, pkg.ColorAuto, pkg.ColorAlways, pkg.ColorNever)
+			os.Exit(1)
+		}
+	}
+	pkg.Color = pkg.ColorEnabled(color, term.IsTerminal(int(os.Stdout.Fd())))
+}
+
+// enterTodoRoot switches to the nearest directory up the tree that uses
+// todo, so commands run in a subdirectory of a project work on its lists
+func enterTodoRoot() error {
+	if pkg.Global {
+		return nil
+	}
+	root, err := pkg.FindTodoRoot()
+	if err == pkg.ErrNoTodoRoot {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.Chdir(root)
+}
+
+// invocationDir is the directory todo was run in, before it switched to
+// the directory holding .todo
+var invocationDir string
+
+// argPath resolves a relative path given on the command line against the
+// directory todo was run in, once it has switched to another
+func argPath(path string) string {
+	if path == "" || filepath.IsAbs(path) || invocationDir == "" {
+		return path
+	}
+	if wd, err := os.Getwd(); err == nil && wd == invocationDir {
+		return path
+	}
+	return filepath.Join(invocationDir, path)
+}
+
 func requiresInit() bool {
-	// Just ensure .todo directory exists
+	if err := enterTodoRoot(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return true
+	}
+	// Only create .todo here when no parent directory has one
 	if err := pkg.EnsureTodoDirectory(); err != nil {
 		fmt.Printf("Failed to create .todo directory: %v\n", err)
 		return true
@@ -24,283 +97,1415 @@ var rootCmd = &cobra.Command{
 	Use:   "todo [command] [flags]",
 	Short: "A CLI tool for managing todo lists",
 	Long:  `todo is a CLI tool that manages todo lists in markdown files, helping you track tasks for different projects or features.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// -C runs todo as if it was started in another directory, such
+		// as a subproject with its own .todo, like git -C
+		if dir, _ := cmd.Flags().GetString("directory"); dir != "" {
+			if err := os.Chdir(dir); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		// Everything below reads .todo, so find it first: ~/.todo with
+		// --global, otherwise the nearest one up the tree
+		invocationDir, _ = os.Getwd()
+		if global, _ := cmd.Flags().GetBool("global"); global || pkg.GlobalFromEnv() {
+			if err := pkg.UseGlobalDirectory(); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		} else if cmd.Name() != "init" {
+			// init sets up the directory it's run in
+			if err := enterTodoRoot(); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		pkg.Quiet, _ = cmd.Flags().GetBool("quiet")
+		pkg.Truncate, _ = cmd.Flags().GetBool("truncate")
+		pkg.Width, _ = cmd.Flags().GetInt("width")
+		if !cmd.Flags().Changed("width") {
+			pkg.Width = terminalWidth()
+		}
+		detail, _ := cmd.Flags().GetString("output-detail")
+		if err := pkg.SetDetail(detail); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		// Wrapped items would take more than one line each
+		if pkg.Detail == pkg.DetailMinimal {
+			pkg.Width = 0
+		}
+		applyDisplaySettings(cmd)
+		if value, _ := cmd.Flags().GetString("now"); value != "" {
+			start, err := pkg.ParseNow(value)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			pkg.SetClock(pkg.ClockStartingAt(start))
+		}
+		// Nothing but the JSON may be printed, so no tips or prompts
+		if jsonOutput(cmd) {
+			checkJSONFlag(cmd)
+			pkg.Quiet = true
+			return
+		}
+		offerSampleList(cmd)
+		checkIdleTimer(cmd)
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		recordAudit(cmd, args)
+		autoCommit(cmd)
+		recordUsage(cmd)
+		printIntegrityWarnings(cmd)
+	},
 }
 
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize todo management in the current directory",
-	Long:  `Initialize the current directory for todo management by creating the .todo directory.`,
+	Long:  `Initialize the current directory for todo management by creating the .todo directory.
+
+By default init runs a short setup wizard that chooses where .todo lives, the
+default list name, whether .todo/ is gitignored, branch tracking and git hooks,
+and writes the answers to .todo/config.yaml. Use --yes to skip the wizard and
+only create the .todo directory; nothing outside .todo/ is touched.
+
+--bootstrap-git also runs git init, creates a README.md and makes an initial
+commit when the directory isn't a git repository with history yet.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		skipWizard, _ := cmd.Flags().GetBool("yes")
+		visibility, _ := cmd.Flags().GetString("visibility")
+		bootstrapGit, _ := cmd.Flags().GetBool("bootstrap-git")
+		
+		if err := pkg.ValidateVisibility(visibility); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		
+		if bootstrapGit {
+			if err := pkg.BootstrapGitRepository(); err != nil {
+				fmt.Printf("Failed to bootstrap git repository: %v\n", err)
+				return
+			}
+			fmt.Println("Git repository ready")
+		}
+		
+		if !skipWizard {
+			if err := runInitWizard(visibility); err != nil {
+				fmt.Printf("Failed to initialize todo directory: %v\n", err)
+				return
+			}
+		} else if visibility != "" {
+			if err := setVisibility(visibility); err != nil {
+				fmt.Printf("Failed to set visibility: %v\n", err)
+				return
+			}
+		}
+		
 		err := pkg.EnsureTodoDirectory()
 		if err != nil {
 			fmt.Printf("Failed to initialize todo directory: %v\n", err)
 			return
 		}
 		
-		fmt.Println("✅ Todo management initialized successfully!")
-		fmt.Println("You can now create todo lists with: todo list <name>")
+		fmt.Printf("%sTodo management initialized successfully!\n", pkg.Emoji("✅"))
+		pkg.Tip("You can now create todo lists with: todo list <name>")
+	},
+}
+
+
+var addCmd = &cobra.Command{
+	Use:   "add [todo-item]",
+	Short: "Add a todo item to the current list",
+	Long:  `Add an item to the current list. With - or --stdin, one item is added for each line of stdin, leaving out blank lines and any bullet, number or checkbox in front of an item, and the flags apply to every one of them:\n\n  todo add "Fix the login bug"\n  pbpaste | todo add -\n  todo add --stdin --section Ideas < brainstorm.txt`,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+		
+		fromStdin, _ := cmd.Flags().GetBool("stdin")
+		if fromStdin && len(args) == 1 {
+			fmt.Println("Error: --stdin reads the items from stdin, so it takes no item")
+			return
+		}
+		fromStdin = fromStdin || len(args) == 1 && args[0] == "-"
+		var todoItems []string
+		switch {
+		case fromStdin:
+			input, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Printf("Error reading stdin: %v\n", err)
+				return
+			}
+			if todoItems = pkg.ParseItemLines(string(input)); len(todoItems) == 0 {
+				fmt.Println("No items to add: stdin had no lines of text")
+				return
+			}
+		case len(args) == 1:
+			todoItems = args
+		default:
+			fmt.Println("Error: give the item to add, or - to read items from stdin")
+			return
+		}
+		
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		
+		var estimate time.Duration
+		if value, _ := cmd.Flags().GetString("estimate"); value != "" {
+			if estimate, err = pkg.ParseEstimate(value); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+		var priority int
+		if value, _ := cmd.Flags().GetString("priority"); value != "" {
+			if priority, err = pkg.ParsePriority(value); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+		var due *time.Time
+		if value, _ := cmd.Flags().GetString("due"); value != "" {
+			date, err := pkg.ParseDueDate(value, pkg.Now())
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			due = &date
+		}
+		var every pkg.Recurrence
+		if value, _ := cmd.Flags().GetString("every"); value != "" {
+			if every, err = pkg.ParseRecurrence(value); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			if due == nil {
+				// The first occurrence is due today, or on the next
+				// working day
+				date, err := pkg.WorkdayFrom(pkg.Now())
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return
+				}
+				due = &date
+			}
+		}
+		
+		under, _ := cmd.Flags().GetString("under")
+		section, _ := cmd.Flags().GetString("section")
+		if under != "" && cmd.Flags().Changed("section") {
+			fmt.Println("Error: --under can't be combined with --section; subtasks go in their parent's section")
+			return
+		}
+		
+		var parentID int
+		if under != "" {
+			if parentID, err = pkg.ResolveItemRef(currentList, under); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+		
+		// Every item is added before anything is written, so either all of
+		// them are or none are
+		store := pkg.NewStore()
+		itemIDs := make([]int, len(todoItems))
+		for i, todoItem := range todoItems {
+			var itemID int
+			if under != "" {
+				itemID, err = store.AddItemUnder(currentList, parentID, todoItem)
+			} else if cmd.Flags().Changed("section") {
+				itemID, err = store.AddItemToSection(currentList, section, todoItem)
+			} else {
+				itemID, err = store.AddItem(currentList, todoItem)
+			}
+			if err == nil && cmd.Flags().Changed("weight") {
+				weight, _ := cmd.Flags().GetInt("weight")
+				err = store.SetWeight(currentList, itemID, weight)
+			}
+			if err == nil && estimate > 0 {
+				err = store.SetEstimate(currentList, itemID, estimate)
+			}
+			if err == nil && priority > 0 {
+				err = store.SetPriority(currentList, itemID, priority)
+			}
+			if err == nil && due != nil {
+				err = store.SetDue(currentList, itemID, due)
+			}
+			if err == nil && every.N > 0 {
+				err = store.SetRecurrence(currentList, itemID, every)
+			}
+			if err != nil {
+				break
+			}
+			itemIDs[i] = itemID
+		}
+		if err == nil {
+			err = store.Flush()
+		}
+		if err != nil {
+			fmt.Printf("Error adding todo item: %v\n", err)
+			return
+		}
+		
+		if jsonOutput(cmd) && !fromStdin {
+			printItemChange("add", currentList, itemIDs[0])
+			return
+		}
+		if jsonOutput(cmd) {
+			var changes []*pkg.ItemChange
+			for _, itemID := range itemIDs {
+				change, err := pkg.NewItemChange("add", currentList, itemID)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return
+				}
+				changes = append(changes, change)
+			}
+			printItemChanges(changes)
+			return
+		}
+		for _, todoItem := range todoItems {
+			fmt.Printf("Added todo item to list '%s': %s\n", currentList, todoItem)
+		}
+	},
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check <item-number|section.item|id|from-to>...",
+	Short: "Mark todo items as completed",
+	Long:  `Mark items as completed. Several items, or ranges of them, are checked in one write:\n\n  todo check 3        Check item 3\n  todo check 1 3 5    Check several items\n  todo check 2-6      Check items 2 to 6`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+		
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		
+		targets, ok := resolveItemTargets(currentList, args)
+		if !ok {
+			return
+		}
+		
+		// Every item is checked before anything is written, so either all
+		// of them are or none are
+		cascade, _ := cmd.Flags().GetBool("cascade")
+		store := pkg.NewStore()
+		subtasks := make([][]int, len(targets))
+		for i, target := range targets {
+			if cascade {
+				subtasks[i], err = store.CheckSubtree(target.list, target.itemID)
+			} else {
+				err = store.CheckItem(target.list, target.itemID)
+			}
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = store.Flush()
+		}
+		if err != nil {
+			fmt.Printf("Error checking todo item: %v\n", err)
+			return
+		}
+		
+		var changes []*pkg.ItemChange
+		for i, target := range targets {
+			closedIssue, closeErr := closePromotedIssue(target.list, target.itemID)
+			if jsonOutput(cmd) {
+				change, err := pkg.NewItemChange("check", target.list, target.itemID)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return
+				}
+				if closeErr != nil {
+					fmt.Fprintf(os.Stderr, "Warning: could not close %s: %v\n", closedIssue, closeErr)
+				} else {
+					change.ClosedIssue = closedIssue
+				}
+				changes = append(changes, change)
+				continue
+			}
+			
+			fmt.Printf("Marked item %s as completed in list '%s'\n", target.ref, target.list)
+			if len(subtasks[i]) == 1 {
+				fmt.Println("Also completed its 1 pending subtask")
+			} else if len(subtasks[i]) > 1 {
+				fmt.Printf("Also completed its %d pending subtasks\n", len(subtasks[i]))
+			}
+			if closeErr != nil {
+				fmt.Printf("Warning: could not close %s: %v\n", closedIssue, closeErr)
+			} else if closedIssue != "" {
+				fmt.Printf("Closed %s\n", closedIssue)
+			}
+		}
+		if jsonOutput(cmd) {
+			printItemChanges(changes)
+		}
+	},
+}
+
+var uncheckCmd = &cobra.Command{
+	Use:   "uncheck <item-number|section.item|id|from-to>...",
+	Short: "Mark todo items as not completed",
+	Long:  `Mark items as not completed. Like check, it takes several items or ranges of them:\n\n  todo uncheck 3      Uncheck item 3\n  todo uncheck 2-4    Uncheck items 2, 3 and 4`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+		
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		
+		targets, ok := resolveItemTargets(currentList, args)
+		if !ok {
+			return
+		}
+		
+		store := pkg.NewStore()
+		for _, target := range targets {
+			if err = store.UncheckItem(target.list, target.itemID); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = store.Flush()
+		}
+		if err != nil {
+			fmt.Printf("Error unchecking todo item: %v\n", err)
+			return
+		}
+		
+		var changes []*pkg.ItemChange
+		for _, target := range targets {
+			if jsonOutput(cmd) {
+				change, err := pkg.NewItemChange("uncheck", target.list, target.itemID)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return
+				}
+				changes = append(changes, change)
+				continue
+			}
+			fmt.Printf("Marked item %s as not completed in list '%s'\n", target.ref, target.list)
+		}
+		if jsonOutput(cmd) {
+			printItemChanges(changes)
+		}
+	},
+}
+
+// itemTarget is an item named on the command line, by the ref it was given
+type itemTarget struct {
+	ref    string
+	list   string
+	itemID int
+}
+
+// resolveItemTargets expands ranges among refs and resolves every one to
+// its list and item before anything changes them, printing an error and
+// reporting false if any can't be, naming the first missing item. An item
+// named twice is kept once.
+func resolveItemTargets(currentList string, refs []string) ([]itemTarget, bool) {
+	refs, err := pkg.ExpandItemRefs(refs)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return nil, false
+	}
+	var targets []itemTarget
+	for _, ref := range refs {
+		listName, itemID, err := pkg.ResolveViewItemRef(currentList, ref)
+		if errors.Is(err, pkg.ErrInvalidItemNumber) {
+			// Kept from before references other than numbers were
+			// accepted, for scripts that look for it
+			fmt.Printf("Invalid item number: %s\n", ref)
+			return nil, false
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return nil, false
+		}
+		if !slices.ContainsFunc(targets, func(t itemTarget) bool { return t.list == listName && t.itemID == itemID }) {
+			targets = append(targets, itemTarget{ref: ref, list: listName, itemID: itemID})
+		}
+	}
+	
+	// Checked against the lists as they are now, since checking a
+	// repeating item adds its next occurrence at the end
+	sizes := make(map[string]int)
+	for _, target := range targets {
+		if _, ok := sizes[target.list]; !ok {
+			todoList, err := pkg.ParseTodoFile(target.list)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return nil, false
+			}
+			sizes[target.list] = len(todoList.Items)
+		}
+		if target.itemID < 1 || target.itemID > sizes[target.list] {
+			fmt.Printf("Error: invalid item ID: %d\n", target.itemID)
+			return nil, false
+		}
+	}
+	return targets, true
+}
+
+var removeCmd = &cobra.Command{
+	Use:     "remove <item-number|section.item|id|from-to>...",
+	Aliases: []string{"rm"},
+	Short:   "Delete todo items from the current list",
+	Long:    `Delete items and renumber the items after them:\n\n  todo remove 3        Remove item 3\n  todo remove 2 5 7    Remove several items\n  todo remove 2-4      Remove items 2, 3 and 4`,
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+		
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		
+		// Resolve every number before removing anything, since removing
+		// renumbers the items after it
+		targets, ok := resolveItemTargets(currentList, args)
+		if !ok {
+			return
+		}
+		var lists []string
+		itemIDs := make(map[string][]int)
+		for _, target := range targets {
+			if _, ok := itemIDs[target.list]; !ok {
+				lists = append(lists, target.list)
+			}
+			itemIDs[target.list] = append(itemIDs[target.list], target.itemID)
+		}
+		
+		if !confirm(bufio.NewReader(os.Stdin), pkg.ConfirmRemove, fmt.Sprintf("Remove %d item(s)?", len(targets))) {
+			fmt.Println("Remove cancelled.")
+			return
+		}
+		
+		store := pkg.NewStore()
+		removed := make(map[string][]pkg.TodoItem)
+		for _, listName := range lists {
+			if removed[listName], err = store.RemoveItems(listName, itemIDs[listName]); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = store.Flush()
+		}
+		if err != nil {
+			fmt.Printf("Error removing todo items: %v\n", err)
+			return
+		}
+		
+		if jsonOutput(cmd) {
+			// The removed items have no label, as their numbers now belong
+			// to the items after them
+			var changes []*pkg.ItemChange
+			for _, listName := range lists {
+				for _, item := range removed[listName] {
+					changes = append(changes, &pkg.ItemChange{Action: "remove", List: listName, Item: pkg.NewJSONItem(item, "")})
+				}
+			}
+			printItemChanges(changes)
+			return
+		}
+		for _, listName := range lists {
+			for _, item := range removed[listName] {
+				fmt.Printf("Removed item from list '%s': %s\n", listName, item.Text)
+			}
+		}
+		pkg.Tip("The items after them were renumbered")
 	},
 }
 
+var progressCmd = &cobra.Command{
+	Use:   "progress [list-name]",
+	Short: "Show progress for current list, specific list, or all lists\n                Available flags: --all",
+	Long:  `Show todo progress:\n\n  todo progress             Current list progress\n  todo progress <name>      Specific list progress\n  todo progress --all       All lists progress\n  todo progress --format quickfix  Items as file:line: text for Vim's :cexpr`,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+		
+		showAll, _ := cmd.Flags().GetBool("all")
+		pkg.RawProgress, _ = cmd.Flags().GetBool("raw")
+		order, _ := cmd.Flags().GetString("sort")
+		if !cmd.Flags().Changed("sort") {
+			if cfg, err := pkg.LoadConfig(); err == nil && cfg.Display.Sort != "" {
+				order = cfg.Display.Sort
+			}
+		}
+		sortOrder, err := pkg.ParseSortOrder(order)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		pkg.SortOrder = sortOrder
+		pkg.DisplayFilter.Tags, _ = cmd.Flags().GetStringSlice("tag")
+		
+		format, ok := outputFormat(cmd)
+		if !ok {
+			return
+		}
+		renderer, ok := formatRenderer(format, "quickfix")
+		if !ok {
+			return
+		}
+		
+		version, ok := porcelain(cmd)
+		if !ok {
+			return
+		}
+		if version != "" || format == "quickfix" {
+			var names []string
+			if showAll {
+				names, _ = pkg.GetAllLists()
+			} else if len(args) == 1 {
+				names = []string{pkg.ResolveListName(args[0])}
+			} else {
+				currentList, err := pkg.GetCurrentList()
+				if err != nil {
+					fmt.Printf("Error getting current list: %v\n", err)
+					return
+				}
+				names = []string{currentList}
+			}
+			if format == "quickfix" {
+				printQuickfixLists(names)
+			} else {
+				printPorcelainLists(names)
+			}
+			return
+		}
+		
+		if showAll && len(args) > 0 {
+			fmt.Println("Error: Cannot use --all flag with list name")
+			return
+		}
+		if renderer != nil {
+			var err error
+			if showAll {
+				err = pkg.RenderListOverviews(os.Stdout, renderer)
+			} else {
+				listName := ""
+				if len(args) == 1 {
+					listName = pkg.ResolveListName(args[0])
+				} else if listName, err = pkg.GetCurrentList(); err != nil {
+					fmt.Printf("Error getting current list: %v\n", err)
+					return
+				}
+				if !pkg.TodoFileExists(listName) {
+					fmt.Printf("List '%s' does not exist\n", listName)
+					return
+				}
+				err = pkg.RenderTodoList(os.Stdout, listName, renderer)
+			}
+			if err != nil {
+				fmt.Printf("Error showing progress: %v\n", err)
+			}
+			return
+		}
+		
+		if showAll {
+			err := pkg.ListAllFeatures()
+			if err != nil {
+				fmt.Printf("Error showing progress: %v\n", err)
+				return
+			}
+		} else if len(args) == 1 {
+			// Show progress for specific list
+			listName := pkg.ResolveListName(args[0])
+			
+			// Check if the list exists by checking if todo file exists
+			if !pkg.TodoFileExists(listName) {
+				fmt.Printf("List '%s' does not exist\n", listName)
+				return
+			}
+			
+			err := pkg.DisplayTodoList(listName)
+			if err != nil {
+				fmt.Printf("Error displaying todo list: %v\n", err)
+				return
+			}
+		} else {
+			// Show progress for current list
+			currentList, err := pkg.GetCurrentList()
+			if err != nil {
+				fmt.Printf("Error getting current list: %v\n", err)
+				return
+			}
+			
+			err = pkg.DisplayTodoList(currentList)
+			if err != nil {
+				fmt.Printf("Error displaying todo list: %v\n", err)
+				return
+			}
+		}
+	},
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list [list-name]",
+	Short: "Show all lists, switch to lists, or create new lists\n                Available flags: --delete, --adopt",
+	Long:  `Manage todo lists:\n\n  todo list                      Show all lists with progress\n  todo list <name>               Switch to or create list\n  todo list --delete <name>      Delete list (requires confirmation)\n  todo list --adopt <old> <new>  Move a list to a new name (e.g. after renaming its branch)\n  todo list --merge-case-duplicates  Merge lists whose names only differ in case or accents\n  todo list --format json        Per-list counts, overdue items, tags and last activity as JSON\n  todo list --health             Score each list's health, least healthy first\n  todo list <name> --target <date>  Set the date the list should be finished by\n  todo list <name> --depends-on <list>  Warn while <list> is incomplete\n  todo list <name> --caldav <url>  Sync the list with a CalDAV task collection\n  todo list <name> --owner <who> --reviewers <a,b>  Record who owns and reviews the list\n  todo list --mine               Show only the lists you own (matched against your git name or email)\n  todo list --recursive          Show the lists of every subproject with its own .todo`,
+	Args:  cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		// The subprojects are searched from here, which needn't have a
+		// .todo of its own
+		if recursive, _ := cmd.Flags().GetBool("recursive"); recursive {
+			if len(args) > 0 {
+				fmt.Println("Error: --recursive only applies to the list overview")
+				return
+			}
+			for _, name := range []string{"delete", "adopt", "merge-case-duplicates", "health", "mine", "format", "porcelain", "json"} {
+				if cmd.Flags().Changed(name) {
+					fmt.Printf("Error: --recursive can't be combined with --%s\n", name)
+					return
+				}
+			}
+			if err := pkg.ListRecursive(); err != nil {
+				fmt.Printf("Error showing lists: %v\n", err)
+			}
+			return
+		}
+		
+		if requiresInit() {
+			return
+		}
+		
+		deleteFlag, _ := cmd.Flags().GetBool("delete")
+		adoptFlag, _ := cmd.Flags().GetBool("adopt")
+		mergeFlag, _ := cmd.Flags().GetBool("merge-case-duplicates")
+		
+		if mergeFlag {
+			if len(args) > 0 {
+				fmt.Println("Error: --merge-case-duplicates doesn't take a list name")
+				return
+			}
+			
+			if collisions, err := pkg.ListNameCollisions(); err == nil && len(collisions) > 0 {
+				if !confirm(bufio.NewReader(os.Stdin), pkg.ConfirmMergeCase, fmt.Sprintf("Merge %d group(s) of lists whose names only differ in case or accents?", len(collisions))) {
+					fmt.Println("Merge cancelled.")
+					return
+				}
+			}
+			
+			merged, err := pkg.MergeCaseDuplicates()
+			if err != nil {
+				fmt.Printf("Error merging lists: %v\n", err)
+				return
+			}
+			if len(merged) == 0 {
+				fmt.Println("No duplicate list names found.")
+				return
+			}
+			for _, m := range merged {
+				fmt.Printf("Merged '%s' into '%s'\n", strings.Join(m.Merged, "', '"), m.Into)
+			}
+			return
+		}
+		
+		if adoptFlag {
+			if len(args) != 2 {
+				fmt.Println("Error: --adopt requires the old and new list names")
+				return
+			}
+			
+			oldName := pkg.ResolveListName(pkg.GetFeatureName(args[0]))
+			newName := pkg.GetFeatureName(args[1])
+			
+			if !confirm(bufio.NewReader(os.Stdin), pkg.ConfirmAdopt, fmt.Sprintf("Move the items of '%s' into '%s' and remove '%s'?", oldName, newName, oldName)) {
+				fmt.Println("Adopt cancelled.")
+				return
+			}
+			
+			err := pkg.AdoptList(oldName, newName)
+			if err != nil {
+				fmt.Printf("Error adopting list: %v\n", err)
+				return
+			}
+			
+			// The list now belongs to the renamed branch
+			if pkg.BranchExists(args[1]) {
+				pkg.LinkListToBranch(newName, args[1])
+			}
+			
+			fmt.Printf("Moved list '%s' to '%s'\n", oldName, newName)
+			return
+		}
+		
+		if len(args) > 1 {
+			fmt.Println("Error: only --adopt takes two list names")
+			return
+		}
+		
+		if deleteFlag {
+			if len(args) == 0 {
+				fmt.Println("Error: --delete requires a list name")
+				return
+			}
+			
+			listName := pkg.ResolveListName(args[0])
+			
+			// Check if we're currently on the list we're trying to delete
+			currentList, err := pkg.GetCurrentList()
+			if err != nil {
+				fmt.Printf("Error getting current list: %v\n", err)
+				return
+			}
+			
+			if currentList == listName {
+				fmt.Printf("Error: Cannot delete list '%s' because it is currently active.\n", listName)
+				pkg.Tip("Switch to another list first (e.g., 'todo list main')")
+				return
+			}
+			
+			// Check if list exists
+			if !pkg.ListExists(listName) {
+				fmt.Printf("List '%s' does not exist\n", listName)
+				return
+			}
+			
+			if !confirm(bufio.NewReader(os.Stdin), pkg.ConfirmDelete, fmt.Sprintf("Are you sure you want to delete list '%s'? This will remove the todo file.", listName)) {
+				fmt.Println("Delete cancelled.")
+				return
+			}
+			
+			// Delete the todo file
+			err = pkg.DeleteList(listName)
+			if err != nil {
+				fmt.Printf("Error deleting list: %v\n", err)
+				return
+			}
+			
+			fmt.Printf("Successfully deleted list '%s'\n", listName)
+			return
+		}
+		
+		if (cmd.Flags().Changed("target") || cmd.Flags().Changed("depends-on") || cmd.Flags().Changed("caldav") || cmd.Flags().Changed("owner") || cmd.Flags().Changed("reviewers")) && len(args) == 0 {
+			fmt.Println("Error: --target, --depends-on, --caldav, --owner and --reviewers require a list name")
+			return
+		}
+		
+		mine, _ := cmd.Flags().GetBool("mine")
+		if mine && len(args) > 0 {
+			fmt.Println("Error: --mine only applies to the list overview")
+			return
+		}
+		
+		format, ok := outputFormat(cmd)
+		if !ok {
+			return
+		}
+		renderer, ok := formatRenderer(format)
+		if !ok {
+			return
+		}
+		formatFlag := "--format"
+		if jsonOutput(cmd) {
+			formatFlag = "--json"
+		}
+		if renderer != nil && (cmd.Flags().Changed("target") || cmd.Flags().Changed("depends-on") || cmd.Flags().Changed("caldav") || cmd.Flags().Changed("owner") || cmd.Flags().Changed("reviewers")) {
+			fmt.Printf("Error: %s can't be combined with --target, --depends-on, --caldav, --owner or --reviewers\n", formatFlag)
+			return
+		}
+		
+		version, ok := porcelain(cmd)
+		if !ok {
+			return
+		}
+		if version != "" && len(args) > 0 {
+			fmt.Println("Error: --porcelain only applies to the list overview")
+			return
+		}
+		
+		health, _ := cmd.Flags().GetBool("health")
+		if health && len(args) > 0 {
+			fmt.Println("Error: --health only applies to the list overview")
+			return
+		}
+		if mine && health {
+			fmt.Println("Error: --mine can't be combined with --health")
+			return
+		}
+		if health && renderer != nil && format != "json" {
+			fmt.Printf("Error: --health can't be combined with --format %s\n", format)
+			return
+		}
+		
+		if mine {
+			owned, err := pkg.OwnedLists()
+			if err != nil {
+				fmt.Printf("Error showing lists: %v\n", err)
+				return
+			}
+			if version != "" {
+				printPorcelainLists(owned)
+			} else if renderer != nil {
+				printOwnedOverviews(owned, renderer)
+			} else if len(owned) == 0 {
+				who, email := pkg.GitIdentity()
+				if email != "" {
+					who = fmt.Sprintf("%s <%s>", who, email)
+				}
+				fmt.Printf("No lists are owned by %s\n", who)
+				pkg.Tip("Set an owner with 'todo list <name> --owner <git name or email>'")
+			} else if err := pkg.ListFeatures(owned); err != nil {
+				fmt.Printf("Error showing lists: %v\n", err)
+			}
+		} else if health {
+			printListHealth(renderer != nil)
+		} else if version != "" {
+			names, err := pkg.GetAllLists()
+			if err != nil {
+				fmt.Printf("Error showing lists: %v\n", err)
+				return
+			}
+			printPorcelainLists(names)
+		} else if renderer != nil && len(args) == 0 {
+			if err := pkg.RenderListOverviews(os.Stdout, renderer); err != nil {
+				fmt.Printf("Error showing lists: %v\n", err)
+			}
+		} else if len(args) == 0 {
+			// Show all lists
+			err := pkg.ListAllFeatures()
+			if err != nil {
+				fmt.Printf("Error showing lists: %v\n", err)
+				return
+			}
+			
+			if cfg, err := pkg.LoadConfig(); err == nil {
+				for _, warning := range pkg.VisibilityWarnings(cfg) {
+					pkg.Blank()
+					fmt.Printf("Warning: %s\n", warning)
+				}
+			}
+			for _, warning := range pkg.ListNameWarnings() {
+				pkg.Blank()
+				fmt.Printf("Warning: %s\n", warning)
+			}
+			currentList, _ := pkg.GetCurrentList()
+			if warnings, err := pkg.DependencyWarnings(currentList); err == nil {
+				for _, warning := range warnings {
+					pkg.Blank()
+					fmt.Printf("Warning: %s\n", warning)
+				}
+			}
+		} else {
+			// Switch to or create specific list, reusing a list whose name
+			// only differs in case or accents
+			listName := pkg.ResolveListName(args[0])
+			
+			// Set as current list
+			err := pkg.SetCurrentList(listName)
+			if err != nil {
+				fmt.Printf("Error setting current list: %v\n", err)
+				return
+			}
+			
+			if currentList, err := pkg.GetCurrentList(); err == nil && currentList != listName && renderer == nil {
+				pkg.Tip("Note: branch tracking is enabled, so the active list follows the git branch ('%s')", currentList)
+			}
+			
+			// Create todo file if it doesn't exist
+			if !pkg.TodoFileExists(listName) {
+				err = pkg.CreateTodoFile(listName)
+				if err != nil {
+					fmt.Printf("Error creating todo file: %v\n", err)
+					return
+				}
+				if renderer == nil {
+					fmt.Printf("Created todo list '%s'\n", listName)
+				}
+			} else if renderer == nil {
+				fmt.Printf("Switched to list '%s'\n", listName)
+			}
+			
+			// Other renderers draw the list alone, like 'todo progress'
+			if renderer != nil {
+				if err := pkg.RenderTodoList(os.Stdout, listName, renderer); err != nil {
+					fmt.Printf("Error displaying todo list: %v\n", err)
+				}
+				return
+			}
+			
+			if cmd.Flags().Changed("target") {
+				target, _ := cmd.Flags().GetString("target")
+				if target == "none" {
+					target = ""
+				}
+				if err := pkg.SetListTarget(listName, target); err != nil {
+					fmt.Printf("Error setting target: %v\n", err)
+					return
+				}
+				if target == "" {
+					fmt.Printf("Cleared the target date of list '%s'\n", listName)
+				} else {
+					fmt.Printf("Set the target date of list '%s' to %s\n", listName, target)
+				}
+			}
+			
+			if cmd.Flags().Changed("depends-on") {
+				dependsOn, _ := cmd.Flags().GetStringSlice("depends-on")
+				if len(dependsOn) == 1 && dependsOn[0] == "none" {
+					dependsOn = nil
+				}
+				if err := pkg.SetListDependencies(listName, dependsOn); err != nil {
+					fmt.Printf("Error setting dependencies: %v\n", err)
+					return
+				}
+				if len(dependsOn) == 0 {
+					fmt.Printf("List '%s' no longer depends on other lists\n", listName)
+				} else {
+					fmt.Printf("List '%s' now depends on '%s'\n", listName, strings.Join(dependsOn, "', '"))
+				}
+			}
+			
+			if cmd.Flags().Changed("caldav") {
+				collection, _ := cmd.Flags().GetString("caldav")
+				if collection == "none" {
+					collection = ""
+				}
+				if err := pkg.SetListCalDAV(listName, collection); err != nil {
+					fmt.Printf("Error setting CalDAV collection: %v\n", err)
+					return
+				}
+				if collection == "" {
+					fmt.Printf("List '%s' is no longer synced with CalDAV\n", listName)
+				} else {
+					fmt.Printf("List '%s' now syncs with %s; run 'todo sync --provider caldav'\n", listName, collection)
+				}
+			}
+			
+			if cmd.Flags().Changed("owner") {
+				owner, _ := cmd.Flags().GetString("owner")
+				if owner == "none" {
+					owner = ""
+				}
+				if err := pkg.SetListOwner(listName, owner); err != nil {
+					fmt.Printf("Error setting owner: %v\n", err)
+					return
+				}
+				if owner == "" {
+					fmt.Printf("List '%s' no longer has an owner\n", listName)
+				} else {
+					fmt.Printf("List '%s' is now owned by %s\n", listName, owner)
+				}
+			}
+			
+			if cmd.Flags().Changed("reviewers") {
+				reviewers, _ := cmd.Flags().GetStringSlice("reviewers")
+				if len(reviewers) == 1 && reviewers[0] == "none" {
+					reviewers = nil
+				}
+				if err := pkg.SetListReviewers(listName, reviewers); err != nil {
+					fmt.Printf("Error setting reviewers: %v\n", err)
+					return
+				}
+				if len(reviewers) == 0 {
+					fmt.Printf("List '%s' no longer has reviewers\n", listName)
+				} else {
+					fmt.Printf("List '%s' is now reviewed by %s\n", listName, strings.Join(reviewers, ", "))
+				}
+			}
+			
+			if warnings, err := pkg.ListDependencyWarnings(listName); err == nil {
+				for _, warning := range warnings {
+					fmt.Printf("Warning: %s\n", warning)
+				}
+			}
+			
+			// Display current todos
+			err = pkg.DisplayTodoList(listName)
+			if err != nil {
+				fmt.Printf("Error displaying todo list: %v\n", err)
+				return
+			}
+		}
+	},
+}
+
+// porcelain returns the --porcelain version requested, or "" for the usual
+// output. ok is false, after printing an error, for unknown versions.
+func porcelain(cmd *cobra.Command) (version string, ok bool) {
+	version, _ = cmd.Flags().GetString("porcelain")
+	if version == "" {
+		return "", true
+	}
+	if err := pkg.CheckPorcelainVersion(version); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return "", false
+	}
+	return version, true
+}
+
+// printOwnedOverviews draws the overview of the named lists with a renderer
+func printOwnedOverviews(names []string, renderer pkg.Renderer) {
+	overviews, err := pkg.GetListOverviews(pkg.Now())
+	if err != nil {
+		fmt.Printf("Error showing lists: %v\n", err)
+		return
+	}
+	overviews = slices.DeleteFunc(overviews, func(overview pkg.ListOverview) bool {
+		return !slices.Contains(names, overview.Name)
+	})
+	if err := renderer.RenderOverview(os.Stdout, overviews); err != nil {
+		fmt.Printf("Error showing lists: %v\n", err)
+	}
+}
+
+// printPorcelainLists prints every item of the named lists that passes
+// pkg.DisplayFilter in porcelain format
+func printPorcelainLists(names []string) {
+	for _, parsed := range pkg.ParseLists(names) {
+		if parsed.Err != nil {
+			fmt.Printf("Error reading list '%s': %v\n", parsed.Name, parsed.Err)
+			return
+		}
+		for _, item := range parsed.List.Items {
+			if pkg.DisplayFilter.Matches(item) {
+				pkg.WritePorcelainItem(os.Stdout, parsed.Name, item)
+			}
+		}
+	}
+}
+
+// printQuickfixLists prints every item of the named lists that passes
+// pkg.DisplayFilter in Vim's quickfix format
+func printQuickfixLists(names []string) {
+	for _, parsed := range pkg.ParseLists(names) {
+		if parsed.Err != nil {
+			fmt.Printf("Error reading list '%s': %v\n", parsed.Name, parsed.Err)
+			return
+		}
+		for _, item := range parsed.List.Items {
+			if pkg.DisplayFilter.Matches(item) {
+				pkg.WriteQuickfixItem(os.Stdout, parsed.Name, item)
+			}
+		}
+	}
+}
+
+// formatRenderer returns the renderer a --format flag names, or nil for
+// text and the other formats a command handles itself. ok is false, after
+// printing an error, for unknown formats.
+func formatRenderer(format string, others ...string) (renderer pkg.Renderer, ok bool) {
+	if format == "text" || slices.Contains(others, format) {
+		return nil, true
+	}
+	renderer, err := pkg.LookupRenderer(format)
+	if err != nil {
+		fmt.Printf("Error: unknown format '%s' (expected %s)\n", format, strings.Join(append(append([]string{"text"}, others...), pkg.Renderers()...), ", "))
+		return nil, false
+	}
+	return renderer, true
+}
 
-var addCmd = &cobra.Command{
-	Use:   "add [todo-item]",
-	Short: "Add a todo item to the current list",
-	Args:  cobra.ExactArgs(1),
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Move completed items out of the current list into its archive",
+	Long: `Move the completed items of the current list to .todo/archive/<list>.md,
+with their completion times, to keep the list short. The items left are
+renumbered. 'todo history' still shows the archived items.`,
+	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		if requiresInit() {
 			return
 		}
 		
-		todoItem := args[0]
-		
 		currentList, err := pkg.GetCurrentList()
 		if err != nil {
 			fmt.Printf("Error getting current list: %v\n", err)
 			return
 		}
 		
-		err = pkg.AddTodoItem(currentList, todoItem)
+		archived, err := pkg.ArchiveCompleted(currentList)
 		if err != nil {
-			fmt.Printf("Error adding todo item: %v\n", err)
+			fmt.Printf("Error archiving items: %v\n", err)
 			return
 		}
-		
-		fmt.Printf("Added todo item to list '%s': %s\n", currentList, todoItem)
+		if len(archived) == 0 {
+			fmt.Printf("No completed items to archive in list '%s'\n", currentList)
+			return
+		}
+		fmt.Printf("Archived %d completed item(s) from list '%s' to %s\n", len(archived), currentList, pkg.GetArchiveFilePath(currentList))
+		pkg.Tip("'todo history' still shows them.")
 	},
 }
 
-var checkCmd = &cobra.Command{
-	Use:   "check [item-number]",
-	Short: "Mark a todo item as completed",
-	Args:  cobra.ExactArgs(1),
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Archive lists whose git branches were merged or deleted",
+	Long:  `Find todo lists whose branch has been merged into the default branch or no longer exists, and offer to archive each one into .todo/archive/, or to delete it with --delete. --dry-run only shows the lists, and --yes prunes them without asking, except for lists that still have pending items, which are always asked about.`,
+	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		if requiresInit() {
 			return
 		}
 		
-		itemNumber := args[0]
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		yes, _ := cmd.Flags().GetBool("yes")
+		deleteLists, _ := cmd.Flags().GetBool("delete")
 		
-		currentList, err := pkg.GetCurrentList()
+		staleLists, err := pkg.FindStaleLists()
 		if err != nil {
-			fmt.Printf("Error getting current list: %v\n", err)
+			fmt.Printf("Error finding stale lists: %v\n", err)
 			return
 		}
 		
-		itemID, err := strconv.Atoi(itemNumber)
-		if err != nil {
-			fmt.Printf("Invalid item number: %s\n", itemNumber)
+		if len(staleLists) == 0 {
+			fmt.Println("No stale lists found.")
 			return
 		}
 		
-		err = pkg.CheckTodoItem(currentList, itemID)
-		if err != nil {
-			fmt.Printf("Error checking todo item: %v\n", err)
+		action, done := "Archive", "Archived"
+		if deleteLists {
+			action, done = "Delete", "Deleted"
+		}
+		if dryRun {
+			for _, stale := range staleLists {
+				fmt.Printf("Would %s list '%s' (%s)\n", strings.ToLower(action), stale.Name, stale.Reason)
+			}
 			return
 		}
 		
-		fmt.Printf("Marked item %d as completed in list '%s'\n", itemID, currentList)
+		reader := bufio.NewReader(os.Stdin)
+		pruned := 0
+		for _, stale := range staleLists {
+			if stale.Pending > 0 {
+				// Work left in a list is always asked about, whatever --yes
+				// or the confirm settings say
+				question := fmt.Sprintf("%s list '%s' (%s) with %d pending item(s)?", action, stale.Name, stale.Reason, stale.Pending)
+				if !promptYesNo(reader, question, false) {
+					continue
+				}
+			} else if !yes && !confirm(reader, pkg.ConfirmPrune, fmt.Sprintf("%s list '%s' (%s)?", action, stale.Name, stale.Reason)) {
+				continue
+			}
+			
+			if deleteLists {
+				err = pkg.DeleteList(stale.Name)
+			} else {
+				err = pkg.ArchiveList(stale.Name)
+			}
+			if err != nil {
+				fmt.Printf("Error pruning list '%s': %v\n", stale.Name, err)
+				continue
+			}
+			pruned++
+		}
+		
+		fmt.Printf("%s %d of %d stale list(s)\n", done, pruned, len(staleLists))
 	},
 }
 
-var uncheckCmd = &cobra.Command{
-	Use:   "uncheck [item-number]",
-	Short: "Mark a todo item as not completed",
-	Args:  cobra.ExactArgs(1),
+var randomCmd = &cobra.Command{
+	Use:   "random",
+	Short: "Pick a random pending item to work on",
+	Long: `Pick a pending item from the current list at random, for when choosing
+what to do next is the hard part.
+
+Narrow the choice with --tag (#tags in the item text), --context (@contexts)
+and --max-estimate (items estimated with 'todo add --estimate' to take at
+most that long). --all picks from every list.`,
+	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		if requiresInit() {
 			return
 		}
 		
-		itemNumber := args[0]
-		
-		currentList, err := pkg.GetCurrentList()
-		if err != nil {
-			fmt.Printf("Error getting current list: %v\n", err)
+		filter, ok := itemFilter(cmd)
+		if !ok {
 			return
 		}
 		
-		itemID, err := strconv.Atoi(itemNumber)
-		if err != nil {
-			fmt.Printf("Invalid item number: %s\n", itemNumber)
+		lists, ok := filterLists(cmd)
+		if !ok {
 			return
 		}
 		
-		err = pkg.UncheckTodoItem(currentList, itemID)
+		items, err := pkg.PendingItems(lists, filter)
 		if err != nil {
-			fmt.Printf("Error unchecking todo item: %v\n", err)
+			fmt.Printf("Error reading lists: %v\n", err)
+			return
+		}
+		if len(items) == 0 {
+			if jsonOutput(cmd) {
+				printJSON(nil)
+				return
+			}
+			fmt.Println("No pending items match.")
 			return
 		}
 		
-		fmt.Printf("Marked item %d as not completed in list '%s'\n", itemID, currentList)
+		pick := items[rand.IntN(len(items))]
+		if jsonOutput(cmd) {
+			picks, err := pkg.ListItemsJSON([]pkg.ListItem{pick})
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			printJSON(picks[0])
+			return
+		}
+		estimate := ""
+		if pick.Item.Estimate > 0 {
+			estimate = fmt.Sprintf(" (estimate %s)", pkg.FormatEstimate(pick.Item.Estimate))
+		}
+		fmt.Printf("%s%s [%s #%d]%s\n", pkg.Emoji("🎲"), pick.Item.Text, pick.List, pick.Item.ID, estimate)
 	},
 }
 
-var progressCmd = &cobra.Command{
-	Use:   "progress [list-name]",
-	Short: "Show progress for current list, specific list, or all lists\n                Available flags: --all",
-	Long:  `Show todo progress:\n\n  todo progress             Current list progress\n  todo progress <name>      Specific list progress\n  todo progress --all       All lists progress`,
-	Args:  cobra.MaximumNArgs(1),
+var fitCmd = &cobra.Command{
+	Use:   "fit <duration>",
+	Short: "List pending items that fit in the given time",
+	Long: `List the pending items of the current list whose estimates fit in the
+given time, e.g. 'todo fit 30m', most urgent priority first.
+
+Only items with an estimate ('todo add --estimate') are considered. Use --all
+to look in every list, and --tag or --context to narrow the choice.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		if requiresInit() {
 			return
 		}
 		
-		showAll, _ := cmd.Flags().GetBool("all")
+		window, err := pkg.ParseEstimate(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		filter, ok := itemFilter(cmd)
+		if !ok {
+			return
+		}
+		filter.MaxEstimate = window
 		
-		if showAll {
-			if len(args) > 0 {
-				fmt.Println("Error: Cannot use --all flag with list name")
-				return
-			}
-			err := pkg.ListAllFeatures()
-			if err != nil {
-				fmt.Printf("Error showing progress: %v\n", err)
-				return
-			}
-		} else if len(args) == 1 {
-			// Show progress for specific list
-			listName := args[0]
-			
-			// Check if the list exists by checking if todo file exists
-			if !pkg.TodoFileExists(listName) {
-				fmt.Printf("List '%s' does not exist\n", listName)
-				return
-			}
-			
-			err := pkg.DisplayTodoList(listName)
-			if err != nil {
-				fmt.Printf("Error displaying todo list: %v\n", err)
-				return
-			}
-		} else {
-			// Show progress for current list
-			currentList, err := pkg.GetCurrentList()
-			if err != nil {
-				fmt.Printf("Error getting current list: %v\n", err)
-				return
-			}
-			
-			err = pkg.DisplayTodoList(currentList)
-			if err != nil {
-				fmt.Printf("Error displaying todo list: %v\n", err)
-				return
+		lists, ok := filterLists(cmd)
+		if !ok {
+			return
+		}
+		items, err := pkg.PendingItems(lists, filter)
+		if err != nil {
+			fmt.Printf("Error reading lists: %v\n", err)
+			return
+		}
+		pkg.SortByPriority(items)
+		if jsonOutput(cmd) {
+			printListItems(items)
+			return
+		}
+		if len(items) == 0 {
+			fmt.Printf("No estimated items fit in %s.\n", pkg.FormatEstimate(window))
+			return
+		}
+		
+		fmt.Printf("Items that fit in %s:\n", pkg.FormatEstimate(window))
+		pkg.Blank()
+		for _, entry := range items {
+			priority := ""
+			if entry.Item.Priority > 0 {
+				priority = "[" + pkg.FormatPriority(entry.Item.Priority) + "] "
 			}
+			fmt.Printf("  %s%s (%s) [%s #%d]\n", priority, entry.Item.Text, pkg.FormatEstimate(entry.Item.Estimate), entry.List, entry.Item.ID)
 		}
 	},
 }
 
-var listCmd = &cobra.Command{
-	Use:   "list [list-name]",
-	Short: "Show all lists, switch to lists, or create new lists\n                Available flags: --delete",
-	Long:  `Manage todo lists:\n\n  todo list                 Show all lists with progress\n  todo list <name>          Switch to or create list\n  todo list --delete <name> Delete list (requires confirmation)`,
-	Args:  cobra.MaximumNArgs(1),
+// filterLists returns every list with --all, or else the current list
+func filterLists(cmd *cobra.Command) ([]string, bool) {
+	if all, _ := cmd.Flags().GetBool("all"); all {
+		lists, err := pkg.GetAllLists()
+		if err != nil {
+			fmt.Printf("Error reading lists: %v\n", err)
+			return nil, false
+		}
+		return lists, true
+	}
+	
+	currentList, err := pkg.GetCurrentList()
+	if err != nil {
+		fmt.Printf("Error getting current list: %v\n", err)
+		return nil, false
+	}
+	return []string{currentList}, true
+}
+
+// itemFilter reads the --tag, --context and --max-estimate flags
+func itemFilter(cmd *cobra.Command) (pkg.ItemFilter, bool) {
+	var filter pkg.ItemFilter
+	filter.Tags, _ = cmd.Flags().GetStringSlice("tag")
+	filter.Contexts, _ = cmd.Flags().GetStringSlice("context")
+	if value, _ := cmd.Flags().GetString("max-estimate"); value != "" {
+		estimate, err := pkg.ParseEstimate(value)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return filter, false
+		}
+		filter.MaxEstimate = estimate
+	}
+	return filter, true
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show history of completed todos across all lists",
+	Long:  `Display a chronological history of all completed todos with timestamps, organized by date.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if requiresInit() {
 			return
 		}
 		
-		deleteFlag, _ := cmd.Flags().GetBool("delete")
+		pkg.DisplayFilter.Tags, _ = cmd.Flags().GetStringSlice("tag")
 		
-		if deleteFlag {
-			if len(args) == 0 {
-				fmt.Println("Error: --delete requires a list name")
-				return
-			}
-			
-			listName := args[0]
-			
-			// Check if we're currently on the list we're trying to delete
-			currentList, err := pkg.GetCurrentList()
-			if err != nil {
-				fmt.Printf("Error getting current list: %v\n", err)
-				return
-			}
-			
-			if currentList == listName {
-				fmt.Printf("Error: Cannot delete list '%s' because it is currently active.\n", listName)
-				fmt.Println("Switch to another list first (e.g., 'todo list main')")
-				return
-			}
-			
-			// Check if list exists
-			if !pkg.ListExists(listName) {
-				fmt.Printf("List '%s' does not exist\n", listName)
-				return
-			}
-			
-			// Confirmation prompt
-			fmt.Printf("Are you sure you want to delete list '%s'? This will remove the todo file. (y/N): ", listName)
-			reader := bufio.NewReader(os.Stdin)
-			response, err := reader.ReadString('\n')
-			if err != nil {
-				fmt.Printf("Error reading input: %v\n", err)
-				return
-			}
-			
-			response = strings.TrimSpace(strings.ToLower(response))
-			if response != "y" && response != "yes" {
-				fmt.Println("Delete cancelled.")
-				return
-			}
-			
-			// Delete the todo file
-			err = pkg.DeleteList(listName)
-			if err != nil {
-				fmt.Printf("Error deleting list: %v\n", err)
-				return
-			}
-			
-			fmt.Printf("Successfully deleted list '%s'\n", listName)
+		version, ok := porcelain(cmd)
+		if !ok {
 			return
 		}
-		
-		if len(args) == 0 {
-			// Show all lists
-			err := pkg.ListAllFeatures()
+		if jsonOutput(cmd) {
+			history, err := pkg.HistoryJSON()
 			if err != nil {
-				fmt.Printf("Error showing lists: %v\n", err)
+				fmt.Printf("Failed to show history: %v\n", err)
 				return
 			}
-		} else {
-			// Switch to or create specific list
-			listName := args[0]
-			
-			// Set as current list
-			err := pkg.SetCurrentList(listName)
+			printJSON(history)
+			return
+		}
+		if version != "" {
+			history, err := pkg.CompletedHistory()
 			if err != nil {
-				fmt.Printf("Error setting current list: %v\n", err)
+				fmt.Printf("Failed to show history: %v\n", err)
 				return
 			}
-			
-			// Create todo file if it doesn't exist
-			if !pkg.TodoFileExists(listName) {
-				err = pkg.CreateTodoFile(listName)
-				if err != nil {
-					fmt.Printf("Error creating todo file: %v\n", err)
-					return
+			// The IDs of archived items don't refer to their list
+			for _, entry := range history {
+				if !entry.Archived {
+					pkg.WritePorcelainItem(os.Stdout, entry.List, entry.Item)
 				}
-				fmt.Printf("Created todo list '%s'\n", listName)
-			} else {
-				fmt.Printf("Switched to list '%s'\n", listName)
 			}
-			
-			// Display current todos
-			err = pkg.DisplayTodoList(listName)
-			if err != nil {
-				fmt.Printf("Error displaying todo list: %v\n", err)
-				return
-			}
-		}
-	},
-}
-
-var historyCmd = &cobra.Command{
-	Use:   "history",
-	Short: "Show history of completed todos across all lists",
-	Long:  `Display a chronological history of all completed todos with timestamps, organized by date.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if requiresInit() {
 			return
 		}
 		
@@ -333,6 +1538,7 @@ This is a CLI tool for managing todo lists. Each todo list is stored as a markdo
 Initialize todo management in the current directory.
 - Use when: Directory lacks .todo setup
 - Creates: .todo directory for storing todo files
+- Runs an interactive setup wizard; use 'todo init --yes' to skip it
 
 ### 2. todo list [list-name]
 Manage todo lists (create, switch, view, delete).
@@ -355,19 +1561,24 @@ Mark todo item as incomplete.
 - Takes: Item number (1-based indexing)
 - Example: todo uncheck 2
 
-### 6. todo progress [list-name]
+### 6. todo remove <number>...
+Delete todo items; the items after them are renumbered.
+- Takes: Item numbers or ranges like 2-4
+- Example: todo remove 2-4
+
+### 7. todo progress [list-name]
 Show progress for lists.
 - 'todo progress' - Current list progress
 - 'todo progress <name>' - Specific list progress
 - 'todo progress --all' - All lists progress
 
-### 7. todo history
+### 8. todo history
 Show chronological history of completed todos across all lists.
 
-### 8. todo edit
+### 9. todo edit
 Open current list in your configured editor ($EDITOR).
 
-### 9. todo version
+### 10. todo version
 Show CLI version.
 
 ## File Structure
@@ -382,12 +1593,13 @@ project/
 ` + "```" + `
 
 ## Todo File Format
-Standard markdown with checkboxes:
+Standard markdown with checkboxes. Due dates and completion times are kept
+in a trailing HTML comment, which markdown renderers hide:
 ` + "```" + `
 # Todo List for feature-auth
 
-- [ ] Incomplete task
-- [x] Completed task (completed: 2024-01-15 10:30)
+- [ ] Incomplete task <!-- due: 2024-01-20 -->
+- [x] Completed task <!-- completed: 2024-01-15 10:30 -->
 ` + "```" + `
 
 ## Common Workflows
@@ -405,6 +1617,7 @@ Standard markdown with checkboxes:
 
 ### Switching Between Lists
 - 'todo list other-feature' (switches to different list)
+- 'todo branch feature/other' (checks out the branch and switches to its list)
 
 ### Cleanup
 - 'todo list --delete completed-feature' (removes todo file)
@@ -436,7 +1649,12 @@ This tool is designed for developers who want flexible todo management.
 var editCmd = &cobra.Command{
 	Use:   "edit",
 	Short: "Open the current todo list in your configured editor",
-	Long:  `Open the current todo list file in your configured editor (set via $EDITOR environment variable).`,
+	Long: `Open the current todo list file in your configured editor (set via $EDITOR environment variable).
+
+The editor works on a copy. If a sync, the daemon or another command writes
+the list while it is open, their changes are merged with yours when the
+editor closes instead of being overwritten; where both changed the same
+item, your version is kept and reported.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if requiresInit() {
 			return
@@ -448,38 +1666,343 @@ var editCmd = &cobra.Command{
 			return
 		}
 		
-		err = pkg.EditTodoFile(currentList)
+		for _, writer := range pkg.ActiveWriters() {
+			fmt.Printf("Warning: %s is writing lists; changes it makes while you edit will be merged when you close the editor\n", writer)
+		}
+		
+		merge, err := pkg.EditTodoFile(currentList)
 		if err != nil {
 			fmt.Printf("Error opening editor: %v\n", err)
 			return
 		}
+		if merge != nil {
+			fmt.Printf("List '%s' changed while you were editing it; merged %d change(s) into your edit\n", currentList, merge.Merged)
+			for _, conflict := range merge.Conflicts {
+				fmt.Printf("Warning: %s; kept your version\n", conflict)
+			}
+		}
+	},
+}
+
+var countCmd = &cobra.Command{
+	Use:   "count",
+	Short: "Print the number of pending, completed or overdue items",
+	Long: `Print just the number of items, for status bars and shell conditionals:
+
+  todo count                 Pending items in the current list
+  todo count --completed     Completed items
+  todo count --overdue       Pending items past their due date
+  todo count --list <name>   Count another list
+  todo count --all           Count every list
+
+Counts come from an index in .todo/index.json, so lists are only parsed
+again after they change.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		completed, _ := cmd.Flags().GetBool("completed")
+		overdue, _ := cmd.Flags().GetBool("overdue")
+		listName, _ := cmd.Flags().GetString("list")
+		all, _ := cmd.Flags().GetBool("all")
+		
+		if completed && overdue {
+			fmt.Println("Error: use only one of --pending, --completed and --overdue")
+			return
+		}
+		if all && listName != "" {
+			fmt.Println("Error: use either --list or --all")
+			return
+		}
+		
+		var names []string
+		var err error
+		switch {
+		case all:
+			names, err = pkg.GetAllLists()
+		case listName != "":
+			names = []string{pkg.ResolveListName(listName)}
+		default:
+			var currentList string
+			currentList, err = pkg.GetCurrentList()
+			names = []string{currentList}
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		
+		// A running daemon has the lists parsed already
+		var counts pkg.ItemCounts
+		if err := pkg.AskDaemon(&counts, "count", names...); err != nil {
+			if counts, err = pkg.CountItems(names, pkg.Now()); err != nil {
+				fmt.Printf("Error counting items: %v\n", err)
+				return
+			}
+		}
+		
+		if jsonOutput(cmd) {
+			printJSON(counts)
+			return
+		}
+		switch {
+		case completed:
+			fmt.Println(counts.Completed)
+		case overdue:
+			fmt.Println(counts.Overdue)
+		default:
+			fmt.Println(counts.Pending)
+		}
 	},
 }
 
+// cliVersion is the release of todo CLI
+const cliVersion = "0.3.0"
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show the version of todo CLI",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("todo CLI v0.3.0")
+		if jsonOutput(cmd) {
+			printJSON(map[string]string{"version": cliVersion})
+			return
+		}
+		fmt.Println("todo CLI v" + cliVersion)
 	},
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringP("directory", "C", "", "Run as if todo was started in this directory, e.g. a subproject with its own .todo")
+	rootCmd.PersistentFlags().BoolP("global", "g", false, "Use the personal lists in ~/.todo instead of the project's (also TODO_GLOBAL=1)")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Suppress decorative output (emoji, banners, tips)")
+	rootCmd.PersistentFlags().Int("width", 0, "Wrap item text to this many columns (default: terminal width; 0 disables)")
+	rootCmd.PersistentFlags().Bool("truncate", false, "Cut long items to one line instead of wrapping them")
+	rootCmd.PersistentFlags().String("output-detail", pkg.DetailNormal, "How much to print: minimal (one line per fact, no decoration), normal or rich (progress bars and item details)")
+	rootCmd.PersistentFlags().String("color", pkg.ColorAuto, "Whether --format color writes colors: auto (only to a terminal), always or never (also display.color, TODO_COLOR, NO_COLOR)")
+	rootCmd.PersistentFlags().Bool("json", false, "Print JSON instead of text, from the commands that show lists, items or settings and from add, check, uncheck and remove")
+	rootCmd.PersistentFlags().String("now", "", "Run as if it were this time (YYYY-MM-DD [HH:MM]), for trying out date features")
+	rootCmd.PersistentFlags().MarkHidden("now")
+	
+	// Add the --yes flag to init command
+	initCmd.Flags().BoolP("yes", "y", false, "Skip the setup wizard and only create the .todo directory")
+	initCmd.Flags().Bool("bootstrap-git", false, "Initialize a git repository with a README and initial commit if needed")
+	initCmd.Flags().String("visibility", "", "Share .todo/ through git (committed) or keep it gitignored (local)")
+	
+	hooksCmd.AddCommand(hooksInstallCmd)
+	mergetoolCmd.AddCommand(mergetoolInstallCmd)
+	
+	// Add the sync subcommands and their flags
+	syncCmd.PersistentFlags().String("provider", "git", "Sync provider to use")
+	syncPullCmd.Flags().Bool("dry-run", false, "Show what would change without applying it")
+	syncPushCmd.Flags().Bool("dry-run", false, "Show what would change without applying it")
+	syncPullCmd.Flags().Bool("force", false, "Take the remote version of conflicting lists")
+	syncPushCmd.Flags().Bool("force", false, "Overwrite the remote with your lists")
+	syncCmd.AddCommand(syncStatusCmd)
+	syncCmd.AddCommand(syncPullCmd)
+	syncCmd.AddCommand(syncPushCmd)
+	syncIssueCmd.Flags().String("list", "", "List to sync (default: the current list)")
+	syncIssueCmd.Flags().String("repo", "", "GitHub repository as owner/name (default: from the origin remote)")
+	syncIssueCmd.Flags().Bool("dry-run", false, "Show what would change without applying it")
+	syncCmd.AddCommand(syncIssueCmd)
+	syncTodoistCmd.Flags().String("project", "", "Todoist project, by name or id, to bind the list to, or 'none' to stop syncing it")
+	syncTodoistCmd.Flags().String("list", "", "List to bind with --project (default: the current list)")
+	syncTodoistCmd.Flags().Bool("dry-run", false, "Show what would change without applying it")
+	syncCmd.AddCommand(syncTodoistCmd)
+	
+	// Add the agenda and serve flags
+	agendaCmd.Flags().Bool("ical-feed", false, "Print the calendar subscription URL served by 'todo serve'")
+	agendaCmd.Flags().String("addr", defaultServeAddr, "Address of the 'todo serve' server")
+	agendaCmd.Flags().BoolP("all", "a", false, "Include completed items")
+	overdueCmd.Flags().Bool("notify", false, "Also show the summary as a desktop notification")
+	dueCmd.Flags().Int("days", pkg.DefaultUpcomingDays, "How many days ahead to look")
+	anchorCmd.Flags().Bool("remove", false, "Remove the item's anchor")
+	pasteCmd.Flags().String("list", "", "List to import into (default: the current list)")
+	searchCmd.Flags().String("format", "text", "Output format: text, quickfix (file:line: text, for Vim's :cexpr) or json")
+	searchCmd.Flags().Bool("regex", false, "Read the search text as a regular expression")
+	searchCmd.Flags().Bool("pending", false, "Only show pending items")
+	searchCmd.Flags().Bool("completed", false, "Only show completed items")
+	serveCmd.Flags().String("addr", defaultServeAddr, "Address to listen on")
+	
+	tickCmd.Flags().Bool("dry-run", false, "Show the notifications that would be sent without sending them")
+	insightsCmd.Flags().Int("days", pkg.DefaultInsightsDays, "How many days back to look")
+	
+	bundleImportCmd.Flags().String("as", "", "Import the list under this name instead of its own")
+	bundleCmd.AddCommand(bundleExportCmd)
+	bundleCmd.AddCommand(bundleImportCmd)
+	exportCmd.Flags().Bool("markdown", false, "Write every list in the database to its markdown file")
+	exportCmd.Flags().String("format", "", "Format to export: json, csv, md or todotxt")
+	exportCmd.Flags().String("list", "", "List to export (default: the current list)")
+	exportCmd.Flags().BoolP("all", "a", false, "Export every list")
+	exportCmd.Flags().String("out", "", "File to write the export to (default: standard output)")
+	exportCmd.AddCommand(exportIssueBodyCmd)
+	exportTimeseriesCmd.Flags().String("out", "", "File to write the CSV to (default: standard output)")
+	exportTimeseriesCmd.Flags().String("since", "", "First day (YYYY-MM-DD) to write (default: the first day in the lists' history)")
+	exportCmd.AddCommand(exportTimeseriesCmd)
+	
+	timesheetCmd.Flags().String("week", "", "Week to show, as any date in it (default: this week)")
+	timesheetCmd.Flags().Lookup("week").NoOptDefVal = "this"
+	timesheetCmd.Flags().Bool("csv", false, "Print the timesheet as CSV")
+	
+	importPRCommentsCmd.Flags().String("list", "", "List to add the comments to (default: the current list)")
+	importPRCommentsCmd.Flags().String("repo", "", "GitHub repository as owner/name (default: from the origin remote)")
+	importCmd.Flags().String("list", "", "List to import into (default: the current list)")
+	importCmd.Flags().String("format", "", "Format of the file: md or todotxt (default: todotxt for .txt files, md otherwise)")
+	importCmd.AddCommand(importPRCommentsCmd)
+	importLinearCmd.Flags().Bool("dry-run", false, "Show what would change without applying it")
+	importLinearCmd.Flags().Bool("force", false, "Take Linear's version of items changed on both sides")
+	importCmd.AddCommand(importLinearCmd)
+	importAsanaCmd.Flags().String("project", "", "Id of the Asana project to import")
+	importAsanaCmd.Flags().String("list", "", "List to import the tasks into (default: the current list)")
+	importCmd.AddCommand(importAsanaCmd)
+	
+	promoteCmd.Flags().String("to", "", "Where to create the issue: github")
+	promoteCmd.Flags().Bool("close-on-check", false, "Close the issue when the item is checked")
+	promoteCmd.Flags().String("repo", "", "GitHub repository as owner/name (default: from the origin remote)")
+	remindCmd.Flags().String("in", "", "How long from now to be reminded, e.g. 2h or 30m")
+	remindCmd.Flags().String("at", "", "When to be reminded, e.g. \"2024-07-01 09:00\", \"friday 14:30\" or 17:00")
+	remindFireCmd.Flags().String("dir", "", "Project directory of the reminder")
+	remindFireCmd.Flags().String("list", "", "List of the item")
+	remindFireCmd.Flags().String("name", "", "Name of the scheduled job")
+	remindCmd.AddCommand(remindFireCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+	
+	auditCmd.Flags().String("list", "", "Only show changes to this list")
+	auditCmd.Flags().IntP("limit", "n", 20, "Show at most this many of the latest entries (0 for all)")
+	
+	workspaceCmd.AddCommand(workspaceAddCmd)
+	workspaceCmd.AddCommand(workspaceRemoveCmd)
+	workspaceCmd.AddCommand(workspaceListCmd)
+	standupCmd.Flags().Bool("all-workspaces", false, "Gather the standup of every registered workspace")
+	recoverCmd.Flags().Bool("force", false, "Rebuild the list even if it doesn't look damaged")
+	breakdownCmd.Flags().Bool("dry-run", false, "Show the suggested subtasks without adding them")
+	retroCmd.Flags().String("sprint", "2w", "Length of the sprint, ending today, in days (10d) or weeks (2w)")
+	
+	// Add the auth subcommands
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(authStatusCmd)
+	authCmd.AddCommand(authLogoutCmd)
+	pkg.CredentialPassphrase = promptCredentialPassphrase
+	
 	// Add the --all flag to progress command
 	progressCmd.Flags().BoolP("all", "a", false, "Show progress for all features")
+	progressCmd.Flags().Bool("raw", false, "Count every item the same, ignoring weights")
+	progressCmd.Flags().StringSlice("tag", nil, "Only show and count items with this #tag")
+	progressCmd.Flags().String("sort", pkg.SortPosition, "Order items by position or priority (most urgent first within each section); defaults to display.sort")
+	progressCmd.Flags().String("format", "text", "Output format: text, quickfix (file:line: text, for Vim's :cexpr), or a renderer: plain, color, json or table")
+	
+	addCmd.Flags().Int("weight", 1, "How much the item counts toward progress compared to a plain item")
+	addCmd.Flags().String("estimate", "", "How long the item is expected to take, e.g. 30m or 1h30m")
+	addCmd.Flags().String("priority", "", "Priority of the item, p1 (most urgent) to p3")
+	addCmd.Flags().String("due", "", "When the item is due: YYYY-MM-DD, +3d, +3bd (working days), tomorrow, friday, next week...")
+	addCmd.Flags().String("every", "", "Repeat the item after it is checked: 1d, 2w, 1m, 1bd (working days), weekly...")
+	addCmd.Flags().String("under", "", "Add the item as a subtask of this item")
+	addCmd.Flags().Bool("stdin", false, "Add an item for each line of stdin (the same as giving - as the item)")
+	addCmd.Flags().String("section", "", "Add the item to the end of this section, starting it if the list has none by that name")
+	checkCmd.Flags().Bool("cascade", false, "Also complete the item's pending subtasks")
+	
+	historyCmd.Flags().StringSlice("tag", nil, "Only show items with this #tag")
+	randomCmd.Flags().StringSlice("tag", nil, "Only pick items with this #tag")
+	randomCmd.Flags().StringSlice("context", nil, "Only pick items with this @context")
+	randomCmd.Flags().String("max-estimate", "", "Only pick items estimated to take at most this long")
+	randomCmd.Flags().BoolP("all", "a", false, "Pick from every list")
+	
+	fitCmd.Flags().StringSlice("tag", nil, "Only list items with this #tag")
+	fitCmd.Flags().StringSlice("context", nil, "Only list items with this @context")
+	fitCmd.Flags().BoolP("all", "a", false, "Look in every list")
 	
 	// Add the --delete flag to list command
 	listCmd.Flags().BoolP("delete", "d", false, "Delete the specified list")
+	listCmd.Flags().Bool("adopt", false, "Move the items of <old> into <new> and remove <old>")
+	listCmd.Flags().Bool("merge-case-duplicates", false, "Merge lists whose names only differ in case or accents")
+	listCmd.Flags().String("format", "text", "Output format for the list overview: text, or a renderer: plain, color, json or table")
+	listCmd.Flags().Bool("health", false, "Score each list's health from staleness, overdue items, velocity and work in progress")
+	listCmd.Flags().String("target", "", "Date (YYYY-MM-DD) the list should be finished by, or 'none' to clear it")
+	listCmd.Flags().String("caldav", "", "URL of a CalDAV task collection to sync the list with, or 'none' to stop")
+	listCmd.Flags().StringSlice("depends-on", nil, "Lists that should be complete before this one is worked on, or 'none' to clear them")
+	listCmd.Flags().String("owner", "", "Who is responsible for the list (git user name or email), or 'none' to clear it")
+	listCmd.Flags().StringSlice("reviewers", nil, "Who to notify when the list is complete, or 'none' to clear them")
+	listCmd.Flags().BoolP("recursive", "r", false, "Show the lists of every subproject with its own .todo, named after its directory")
+	listCmd.Flags().Bool("mine", false, "Only show the lists owned by you (your git user name or email)")
+	
+	pruneCmd.Flags().Bool("dry-run", false, "Show the stale lists without pruning them")
+	pruneCmd.Flags().BoolP("yes", "y", false, "Prune every stale list without pending items without asking")
+	pruneCmd.Flags().Bool("delete", false, "Delete stale lists instead of archiving them")
+	
+	countCmd.Flags().Bool("pending", false, "Count pending items (the default)")
+	countCmd.Flags().Bool("completed", false, "Count completed items")
+	countCmd.Flags().Bool("overdue", false, "Count pending items past their due date")
+	countCmd.Flags().String("list", "", "Count this list instead of the current one")
+	countCmd.Flags().BoolP("all", "a", false, "Count every list")
+	
+	// Add --commit to the commands that change lists
+	for _, cmd := range []*cobra.Command{addCmd, checkCmd, uncheckCmd, removeCmd, listCmd, branchCmd, archiveCmd, pruneCmd, editCmd, noteCmd, anchorCmd, breakdownCmd, prioritizeCmd, snoozeCmd, remindCmd, triageCmd, uiCmd, importCmd, pasteCmd, promoteCmd, recoverCmd, syncPullCmd, syncPushCmd, syncIssueCmd, syncTodoistCmd} {
+		cmd.Flags().Bool("commit", false, "Commit the changes to .todo with a message describing them")
+	}
+	
+	// Add --porcelain to the display commands
+	for _, cmd := range []*cobra.Command{progressCmd, listCmd, historyCmd, agendaCmd, searchCmd, overdueCmd, dueCmd} {
+		cmd.Flags().String("porcelain", "", "Print one item per line as list<TAB>id<TAB>status<TAB>text (format version, default "+pkg.PorcelainV1+")")
+		cmd.Flags().Lookup("porcelain").NoOptDefVal = pkg.PorcelainV1
+	}
 	
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(checkCmd)
 	rootCmd.AddCommand(uncheckCmd)
+	rootCmd.AddCommand(removeCmd)
 	rootCmd.AddCommand(progressCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(branchCmd)
+	rootCmd.AddCommand(mergetoolCmd)
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(pruneCmd)
 	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(countCmd)
+	rootCmd.AddCommand(randomCmd)
+	rootCmd.AddCommand(fitCmd)
+	rootCmd.AddCommand(triageCmd)
+	rootCmd.AddCommand(uiCmd)
+	rootCmd.AddCommand(agendaCmd)
+	rootCmd.AddCommand(overdueCmd)
+	rootCmd.AddCommand(dueCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(noteCmd)
+	rootCmd.AddCommand(anchorCmd)
+	rootCmd.AddCommand(openCmd)
+	rootCmd.AddCommand(pasteCmd)
+	rootCmd.AddCommand(standupCmd)
+	rootCmd.AddCommand(todayCmd)
+	rootCmd.AddCommand(retroCmd)
+	rootCmd.AddCommand(breakdownCmd)
+	rootCmd.AddCommand(recoverCmd)
+	rootCmd.AddCommand(snoozeCmd)
+	rootCmd.AddCommand(prioritizeCmd)
+	rootCmd.AddCommand(tagsCmd)
+	holidaysCmd.Flags().IntP("limit", "n", 10, "Show at most this many holidays (0 for all)")
+	holidaysCmd.AddCommand(holidaysImportCmd)
+	holidaysCmd.AddCommand(holidaysClearCmd)
+	rootCmd.AddCommand(holidaysCmd)
+	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(timesheetCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(tickCmd)
 	rootCmd.AddCommand(infoCmd)
 	rootCmd.AddCommand(editCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(bundleCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(tourCmd)
+	rootCmd.AddCommand(insightsCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(promoteCmd)
+	rootCmd.AddCommand(remindCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(peekCmd)
+	rootCmd.AddCommand(workspaceCmd)
+	rootCmd.AddCommand(authCmd)
+	rootCmd.AddCommand(hooksCmd)
+	rootCmd.AddCommand(hookCmd)
 	rootCmd.AddCommand(versionCmd)
 }
 
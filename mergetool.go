@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var mergetoolCmd = &cobra.Command{
+	Use:   "mergetool <base> <ours> <theirs>",
+	Short: "Merge two versions of a list file, as a git merge driver",
+	Long: `Merge the changes made to a list file on two branches, writing the result
+over <ours>. This is the merge driver git runs for .todo/*.md once
+'todo mergetool install' has registered it.
+
+Items are matched by their text. Items added on either side are kept, and
+an item is only removed when one side removed it and the other left it
+alone. When both sides changed an item, a checked item wins over an
+unchecked one; other differences keep our version and are reported.`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		merge, err := pkg.MergeListFiles(argPath(args[0]), argPath(args[1]), argPath(args[2]))
+		if err != nil {
+			// git leaves the file conflicted when the driver fails
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, conflict := range merge.Conflicts {
+			fmt.Printf("Warning: %s; kept our version\n", conflict)
+		}
+	},
+}
+
+var mergetoolInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Register todo mergetool as the git merge driver for list files",
+	Long: `Define the 'todo' merge driver in the repository's git config and apply it
+to the list files in .todo/.gitattributes. Commit .todo/.gitattributes to
+share it; each clone registers the driver itself by running this command,
+and merges lists as text until it does.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		if err := pkg.InstallMergeDriver(); err != nil {
+			fmt.Printf("Error installing merge driver: %v\n", err)
+			return
+		}
+		fmt.Println("Registered the todo merge driver for .todo/*.md")
+	},
+}
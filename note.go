@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var noteCmd = &cobra.Command{
+	Use:   "note [item-number|section.item|id] <text>",
+	Short: "Add a note to an item",
+	Long: `Add a line of notes to an item of the current list, for the details that
+don't fit in its one-line text. Notes are written as indented lines under the
+item in the list file, where they can also be typed by hand, and shown by
+'todo show':
+
+  todo note 3 "Blocked on the API review; ask Sam on Monday"`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		currentList, itemID, err := pkg.ResolveViewItemRef(currentList, args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		store := pkg.NewStore()
+		if err := store.AddNote(currentList, itemID, args[1]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if err := store.Flush(); err != nil {
+			fmt.Printf("Error saving note: %v\n", err)
+			return
+		}
+		fmt.Printf("Added a note to item %s\n", args[0])
+	},
+}
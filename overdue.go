@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/scttymn/todo-cli/pkg/notify"
+	"github.com/spf13/cobra"
+)
+
+var overdueCmd = &cobra.Command{
+	Use:   "overdue",
+	Short: "Show overdue items across all lists, grouped by how late they are",
+	Long: `Show every pending item past its due date across all lists, grouped by how
+late it is: 1-3 days, this week (4-7 days) and older. A summary line counts
+the items of each group.
+
+Use --notify to also show the summary as a desktop notification, for example
+from a cron job or a login script. Nothing is sent when no item is overdue.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		agenda, err := loadAgenda(false)
+		if err != nil {
+			fmt.Printf("Failed to load overdue items: %v\n", err)
+			return
+		}
+		groups := pkg.GroupOverdue(agenda, pkg.Now())
+
+		version, ok := porcelain(cmd)
+		if !ok {
+			return
+		}
+		if version != "" {
+			for _, group := range groups {
+				for _, entry := range group.Items {
+					pkg.WritePorcelainItem(os.Stdout, entry.List, entry.Item)
+				}
+			}
+			return
+		}
+		if jsonOutput(cmd) {
+			overdue, err := pkg.OverdueJSON(groups)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			printJSON(overdue)
+			return
+		}
+
+		if len(groups) == 0 {
+			fmt.Println("No overdue items.")
+			return
+		}
+
+		summary := pkg.OverdueSummary(groups)
+		fmt.Printf("%s%s\n", pkg.Emoji("⏰"), summary)
+		var shown []pkg.ListItem
+		for _, group := range groups {
+			pkg.Blank()
+			fmt.Printf("%s (%d):\n", strings.ToUpper(group.Label[:1])+group.Label[1:], len(group.Items))
+			for _, entry := range group.Items {
+				late := "1 day late"
+				if entry.DaysLate > 1 {
+					late = fmt.Sprintf("%d days late", entry.DaysLate)
+				}
+				shown = append(shown, entry.ListItem)
+				fmt.Printf("  %d. [ ] %s [%s #%d] (%s)\n", len(shown), entry.Item.Text, entry.List, entry.Item.ID, late)
+			}
+		}
+		pkg.SaveView(shown)
+
+		if notifyDesktop, _ := cmd.Flags().GetBool("notify"); notifyDesktop {
+			if err := notify.Desktop("todo: overdue items", summary); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}
+	},
+}
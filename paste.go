@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var pasteCmd = &cobra.Command{
+	Use:   "paste",
+	Short: "Import a markdown checklist from the clipboard or stdin",
+	Long: `Import the task list of a markdown fragment into a list (default: the
+current list), e.g. a checklist copied from an issue or a notes app:
+
+  todo paste                  Read the clipboard
+  pbpaste | todo paste        Read stdin
+  todo paste --list release   Import into another list
+
+Every '- [ ]' and '- [x]' item is imported. Headings, and plain bullets with
+tasks nested under them, become sections (nested ones joined with " / "), and
+items join a section of the same name if the list already has one. Tasks
+nested under other tasks are kept in order after their parent.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		listName, _ := cmd.Flags().GetString("list")
+		if listName == "" {
+			var err error
+			if listName, err = pkg.GetCurrentList(); err != nil {
+				fmt.Printf("Error getting current list: %v\n", err)
+				return
+			}
+		} else {
+			listName = pkg.ResolveListName(listName)
+		}
+
+		// Piped input wins; otherwise read the clipboard
+		var markdown string
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			text, err := pkg.ReadClipboard()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			markdown = text
+		} else {
+			input, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Printf("Error reading stdin: %v\n", err)
+				return
+			}
+			markdown = string(input)
+		}
+
+		items := pkg.ParsePastedMarkdown(markdown)
+		if len(items) == 0 {
+			fmt.Println("No task list items found (expected lines such as '- [ ] Task').")
+			return
+		}
+		if err := pkg.PasteItems(listName, items); err != nil {
+			fmt.Printf("Error importing items: %v\n", err)
+			return
+		}
+
+		sections := make(map[string]bool)
+		for _, item := range items {
+			if item.Section != "" {
+				sections[item.Section] = true
+			}
+		}
+		if len(sections) > 0 {
+			fmt.Printf("Added %d item(s) in %d section(s) to list '%s'\n", len(items), len(sections), listName)
+		} else {
+			fmt.Printf("Added %d item(s) to list '%s'\n", len(items), listName)
+		}
+	},
+}
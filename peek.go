@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var peekCmd = &cobra.Command{
+	Use:   "peek <path|git-url> [list]",
+	Short: "Show the lists committed to another repository",
+	Long: `Read the .todo directory committed to another repository and show its
+lists and their progress, or the items of one list. A git URL is cloned
+shallowly into a temporary directory first.
+
+Nothing is read from or written to your own lists.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		listName := ""
+		if len(args) == 2 {
+			listName = args[1]
+		}
+
+		// A path is relative to where todo was run; anything else is a
+		// git URL
+		source := args[0]
+		if _, err := os.Stat(argPath(source)); err == nil {
+			source = argPath(source)
+		}
+		var err error
+		if jsonOutput(cmd) {
+			err = pkg.RenderPeek(os.Stdout, source, listName, pkg.JSONRenderer{})
+		} else {
+			err = pkg.DisplayPeek(source, listName)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	},
+}
@@ -0,0 +1,113 @@
+package pkg
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// GetAgenda returns every item with a due date across all lists, soonest
+// first. Completed items are included only when includeCompleted is set.
+func GetAgenda(includeCompleted bool) ([]ListItem, error) {
+	lists, err := GetAllLists()
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := ParseLists(lists)
+	for _, result := range parsed {
+		if result.Err != nil {
+			return nil, result.Err
+		}
+	}
+	return agendaOf(parsed, includeCompleted), nil
+}
+
+// agendaOf returns the due-dated items of parsed lists, soonest first
+func agendaOf(lists []ParsedList, includeCompleted bool) []ListItem {
+	var agenda []ListItem
+	for _, parsed := range lists {
+		for _, item := range parsed.List.Items {
+			if item.DueDate == nil || (item.Completed && !includeCompleted) {
+				continue
+			}
+			agenda = append(agenda, ListItem{List: parsed.Name, Item: item})
+		}
+	}
+
+	sort.SliceStable(agenda, func(i, j int) bool {
+		if !agenda[i].Item.DueDate.Equal(*agenda[j].Item.DueDate) {
+			return agenda[i].Item.DueDate.Before(*agenda[j].Item.DueDate)
+		}
+		return agenda[i].List < agenda[j].List
+	})
+	return agenda
+}
+
+// WriteICalendar writes agenda items as an iCalendar feed of all-day events,
+// one per item on its due date, for calendar apps to subscribe to
+func WriteICalendar(w io.Writer, name string, agenda []ListItem) error {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\n")
+	b.WriteString("VERSION:2.0\r\n")
+	b.WriteString("PRODID:-//todo-cli//agenda//EN\r\n")
+	b.WriteString("CALSCALE:GREGORIAN\r\n")
+	b.WriteString("METHOD:PUBLISH\r\n")
+	writeICalLine(&b, "X-WR-CALNAME:"+escapeICalText(name))
+
+	stamp := Now().UTC().Format("20060102T150405Z")
+	for _, entry := range agenda {
+		due := *entry.Item.DueDate
+		summary := entry.Item.Text
+		if entry.Item.Completed {
+			summary = "✓ " + summary
+		}
+
+		b.WriteString("BEGIN:VEVENT\r\n")
+		writeICalLine(&b, "UID:"+agendaUID(entry))
+		b.WriteString("DTSTAMP:" + stamp + "\r\n")
+		b.WriteString("DTSTART;VALUE=DATE:" + due.Format("20060102") + "\r\n")
+		b.WriteString("DTEND;VALUE=DATE:" + due.AddDate(0, 0, 1).Format("20060102") + "\r\n")
+		writeICalLine(&b, "SUMMARY:"+escapeICalText(summary))
+		writeICalLine(&b, "CATEGORIES:"+escapeICalText(entry.List))
+		writeICalLine(&b, "DESCRIPTION:"+escapeICalText(fmt.Sprintf("Todo list: %s", entry.List)))
+		b.WriteString("TRANSP:TRANSPARENT\r\n")
+		b.WriteString("END:VEVENT\r\n")
+	}
+
+	b.WriteString("END:VCALENDAR\r\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// agendaUID identifies an item across feed refreshes so calendar apps update
+// events instead of duplicating them. Item numbers shift as lists change, so
+// the list and text are used instead.
+func agendaUID(entry ListItem) string {
+	sum := sha1.Sum([]byte(entry.List + "\x00" + entry.Item.Text))
+	return fmt.Sprintf("%x@todo-cli", sum[:10])
+}
+
+// escapeICalText escapes a TEXT value as required by RFC 5545
+func escapeICalText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
+
+// writeICalLine writes a content line, folding it at 75 octets without
+// splitting UTF-8 sequences
+func writeICalLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// Continuation lines start with the folding space
+		limit = 74
+	}
+	b.WriteString(line + "\r\n")
+}
@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGetAgenda(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+
+	os.WriteFile(GetTodoFilePath("release"), []byte("# Todo List for release\n\n- [ ] Ship it (due: 2024-07-03)\n- [x] Notes (due: 2024-07-01) (completed: 2024-06-30 10:00)\n- [ ] Someday\n"), 0644)
+	os.WriteFile(GetTodoFilePath("ops"), []byte("# Todo List for ops\n\n- [ ] Rotate keys (due: 2024-07-02)\n"), 0644)
+
+	agenda, err := GetAgenda(false)
+	if err != nil {
+		t.Fatalf("GetAgenda failed: %v", err)
+	}
+	if len(agenda) != 2 || agenda[0].Item.Text != "Rotate keys" || agenda[1].Item.Text != "Ship it" {
+		t.Fatalf("Unexpected agenda: %+v", agenda)
+	}
+
+	agenda, _ = GetAgenda(true)
+	if len(agenda) != 3 || agenda[0].Item.Text != "Notes" {
+		t.Fatalf("Expected completed items first by due date, got %+v", agenda)
+	}
+}
+
+func TestWriteICalendar(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	os.WriteFile(GetTodoFilePath("ops"), []byte("# Todo List for ops\n\n- [ ] Rotate keys, certs; tokens (due: 2024-07-02)\n"), 0644)
+
+	agenda, _ := GetAgenda(false)
+	var b strings.Builder
+	if err := WriteICalendar(&b, "todo", agenda); err != nil {
+		t.Fatalf("WriteICalendar failed: %v", err)
+	}
+	feed := b.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTART;VALUE=DATE:20240702\r\n",
+		"DTEND;VALUE=DATE:20240703\r\n",
+		`SUMMARY:Rotate keys\, certs\; tokens` + "\r\n",
+		"CATEGORIES:ops\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("Feed missing %q:\n%s", want, feed)
+		}
+	}
+
+	// The UID is stable across refreshes
+	var again strings.Builder
+	WriteICalendar(&again, "todo", agenda)
+	if agendaUID(agenda[0]) == "" || !strings.Contains(again.String(), "UID:"+agendaUID(agenda[0])) {
+		t.Error("Expected a stable UID in the feed")
+	}
+}
+
+func TestWriteICalLineFolds(t *testing.T) {
+	var b strings.Builder
+	writeICalLine(&b, "SUMMARY:"+strings.Repeat("é", 80))
+
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Line longer than 75 octets: %q", line)
+		}
+	}
+	if unfolded := strings.ReplaceAll(b.String(), "\r\n ", ""); unfolded != "SUMMARY:"+strings.Repeat("é", 80)+"\r\n" {
+		t.Errorf("Unfolding changed the line: %q", unfolded)
+	}
+}
@@ -0,0 +1,132 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// metaAnchor is the metadata key holding the code location an item is
+// about, as path:line
+const metaAnchor = "anchor"
+
+// Anchor is a location in the project's files that an item is about
+type Anchor struct {
+	Path string
+	// Line is 1-based, or 0 for the file as a whole
+	Line int
+}
+
+func (a Anchor) String() string {
+	if a.Line > 0 {
+		return fmt.Sprintf("%s:%d", a.Path, a.Line)
+	}
+	return a.Path
+}
+
+// ParseAnchor reads a location such as src/auth.go:42, or src/auth.go for a
+// whole file
+func ParseAnchor(s string) (Anchor, error) {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndex(s, ":"); i > 0 {
+		if line, err := strconv.Atoi(s[i+1:]); err == nil {
+			if line < 1 {
+				return Anchor{}, fmt.Errorf("invalid line number in '%s'", s)
+			}
+			return Anchor{Path: s[:i], Line: line}, nil
+		}
+	}
+	if s == "" {
+		return Anchor{}, fmt.Errorf("empty location")
+	}
+	return Anchor{Path: s}, nil
+}
+
+// ItemAnchor returns the location an item is anchored to, if any
+func ItemAnchor(item TodoItem) (Anchor, bool) {
+	value := item.Metadata[metaAnchor]
+	if value == "" {
+		return Anchor{}, false
+	}
+	anchor, err := ParseAnchor(value)
+	return anchor, err == nil
+}
+
+// SetItemAnchor anchors an item to a location in the project. The file must
+// exist; absolute paths inside the project are stored relative to it. A zero
+// Anchor removes the item's anchor.
+func SetItemAnchor(listName string, itemID int, anchor Anchor) error {
+	if anchor.Path != "" {
+		info, err := os.Stat(anchor.Path)
+		if err != nil {
+			return fmt.Errorf("cannot anchor to '%s': %w", anchor.Path, err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("cannot anchor to '%s': it is a directory", anchor.Path)
+		}
+		if filepath.IsAbs(anchor.Path) {
+			if wd, err := os.Getwd(); err == nil {
+				if rel, err := filepath.Rel(wd, anchor.Path); err == nil && !strings.HasPrefix(rel, "..") {
+					anchor.Path = rel
+				}
+			}
+		}
+		anchor.Path = filepath.ToSlash(anchor.Path)
+	}
+
+	store := NewStore()
+	item, err := store.item(listName, itemID)
+	if err != nil {
+		return err
+	}
+	if anchor.Path == "" {
+		delete(item.Metadata, metaAnchor)
+	} else {
+		if item.Metadata == nil {
+			item.Metadata = make(map[string]string)
+		}
+		item.Metadata[metaAnchor] = anchor.String()
+	}
+	store.MarkDirty(listName)
+	return store.Flush()
+}
+
+// OpenAnchor opens $EDITOR at an anchored location
+func OpenAnchor(anchor Anchor) error {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		return fmt.Errorf("EDITOR environment variable is not set. Please set it to your preferred editor (e.g., export EDITOR=nvim)")
+	}
+	if _, err := os.Stat(anchor.Path); err != nil {
+		return fmt.Errorf("cannot open '%s': %w", anchor.Path, err)
+	}
+
+	cmd := exec.Command(editor, editorArgs(editor, anchor)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run editor %s: %w", editor, err)
+	}
+	return nil
+}
+
+// editorArgs returns the arguments that open an editor at an anchor. Most
+// terminal editors take +line before the file; VS Code and its forks take
+// --goto file:line, and a few others take file:line directly.
+func editorArgs(editor string, anchor Anchor) []string {
+	if anchor.Line == 0 {
+		return []string{anchor.Path}
+	}
+	name := strings.TrimSuffix(filepath.Base(editor), ".exe")
+	switch name {
+	case "code", "code-insiders", "codium", "cursor":
+		return []string{"--goto", anchor.String()}
+	case "subl", "hx", "helix", "zed":
+		return []string{anchor.String()}
+	}
+	return []string{"+" + strconv.Itoa(anchor.Line), anchor.Path}
+}
@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseAnchor(t *testing.T) {
+	tests := map[string]Anchor{
+		"src/auth.go:42":    {Path: "src/auth.go", Line: 42},
+		"src/auth.go":       {Path: "src/auth.go"},
+		" README.md:1 ":     {Path: "README.md", Line: 1},
+		`C:\work\main.go:7`: {Path: `C:\work\main.go`, Line: 7},
+		"notes:draft.md":    {Path: "notes:draft.md"},
+	}
+	for input, want := range tests {
+		got, err := ParseAnchor(input)
+		if err != nil || got != want {
+			t.Errorf("ParseAnchor(%q) = %+v, %v; want %+v", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "main.go:0"} {
+		if _, err := ParseAnchor(input); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestSetItemAnchor(t *testing.T) {
+	setupTestDir(t)
+	wd, _ := os.Getwd()
+	AddTodoItem("main", "Handle expired tokens")
+	os.MkdirAll("src", 0755)
+	os.WriteFile(filepath.Join("src", "auth.go"), []byte("package src\n"), 0644)
+
+	if err := SetItemAnchor("main", 1, Anchor{Path: filepath.Join(wd, "src", "auth.go"), Line: 42}); err != nil {
+		t.Fatalf("SetItemAnchor failed: %v", err)
+	}
+	todoList, _ := ParseTodoFile("main")
+	if anchor, ok := ItemAnchor(todoList.Items[0]); !ok || anchor != (Anchor{Path: "src/auth.go", Line: 42}) {
+		t.Errorf("Expected a project-relative anchor, got %v", todoList.Items[0].Metadata)
+	}
+
+	if err := SetItemAnchor("main", 1, Anchor{Path: "src/missing.go"}); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+	if err := SetItemAnchor("main", 1, Anchor{Path: "src"}); err == nil {
+		t.Error("Expected an error for a directory")
+	}
+
+	if err := SetItemAnchor("main", 1, Anchor{}); err != nil {
+		t.Fatalf("Removing the anchor failed: %v", err)
+	}
+	todoList, _ = ParseTodoFile("main")
+	if _, ok := ItemAnchor(todoList.Items[0]); ok {
+		t.Error("Expected the anchor to be removed")
+	}
+}
+
+func TestEditorArgs(t *testing.T) {
+	anchor := Anchor{Path: "src/auth.go", Line: 42}
+	tests := map[string][]string{
+		"nvim":           {"+42", "src/auth.go"},
+		"/usr/bin/emacs": {"+42", "src/auth.go"},
+		"code":           {"--goto", "src/auth.go:42"},
+		"codium.exe":     {"--goto", "src/auth.go:42"},
+		"hx":             {"src/auth.go:42"},
+	}
+	for editor, want := range tests {
+		if got := editorArgs(editor, anchor); !reflect.DeepEqual(got, want) {
+			t.Errorf("editorArgs(%q) = %q, want %q", editor, got, want)
+		}
+	}
+	if got := editorArgs("nvim", Anchor{Path: "README.md"}); !reflect.DeepEqual(got, []string{"README.md"}) {
+		t.Errorf("Expected just the file without a line, got %q", got)
+	}
+}
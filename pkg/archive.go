@@ -0,0 +1,164 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func GetArchiveFilePath(listName string) string {
+	return filepath.Join(".todo", "archive", listName+".md")
+}
+
+// appendToArchive adds items to the end of a list's archive file
+func appendToArchive(listName string, items []TodoItem) error {
+	if err := os.MkdirAll(filepath.Join(".todo", "archive"), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	archivePath := GetArchiveFilePath(listName)
+	archive, err := parseTodoFileAt(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to parse archive file: %w", err)
+	}
+
+	for _, item := range items {
+		item.ID = len(archive.Items) + 1
+		archive.Items = append(archive.Items, item)
+	}
+
+	return writeTodoFileAt(archivePath, fmt.Sprintf("Archive for %s", listName), archive)
+}
+
+// ArchiveList moves every item of a list into .todo/archive/<list>.md and
+// removes the list file
+func ArchiveList(listName string) error {
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return fmt.Errorf("failed to parse todo file: %w", err)
+	}
+
+	if err := appendToArchive(listName, todoList.Items); err != nil {
+		return err
+	}
+
+	return DeleteList(listName)
+}
+
+// ArchiveCompleted moves the completed items of a list to the end of its
+// archive file, keeping their completion times, and returns them. The items
+// left are renumbered.
+func ArchiveCompleted(listName string) ([]TodoItem, error) {
+	store := NewStore()
+	todoList, err := store.Get(listName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Subtasks stay nested in the archive when their parent goes there too
+	var ids []int
+	depths := make(map[int]int)
+	for _, item := range todoList.Items {
+		if !item.Completed {
+			continue
+		}
+		ids = append(ids, item.ID)
+		if parent := todoList.Parent(item.ID); parent != 0 && todoList.Items[parent-1].Completed {
+			depths[item.ID] = depths[parent] + 1
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	archived, err := store.RemoveItems(listName, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range archived {
+		archived[i].Depth = depths[ids[i]]
+	}
+
+	// The archive is written first, so a failure leaves the items in both
+	// files rather than in neither
+	if err := appendToArchive(listName, archived); err != nil {
+		return nil, err
+	}
+	if err := store.Flush(); err != nil {
+		return nil, err
+	}
+	return archived, nil
+}
+
+// archivedLists returns the names of the lists with an archive file
+func archivedLists() ([]string, error) {
+	files, err := os.ReadDir(filepath.Join(".todo", "archive"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read archive directory: %w", err)
+	}
+
+	var lists []string
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".md") {
+			lists = append(lists, strings.TrimSuffix(file.Name(), ".md"))
+		}
+	}
+	return lists, nil
+}
+
+// AdoptList moves the items of oldName into newName, for example after the
+// branch behind a list was renamed. If newName already exists the items are
+// appended to it. The current list selection follows the move.
+func AdoptList(oldName, newName string) error {
+	if oldName == newName {
+		return fmt.Errorf("cannot adopt list '%s' into itself", oldName)
+	}
+	if !TodoFileExists(oldName) {
+		return fmt.Errorf("list '%s' does not exist", oldName)
+	}
+
+	// On a case-insensitive filesystem "Auth" and "auth" are the same file,
+	// so the move is a rename
+	oldInfo, _ := os.Stat(GetTodoFilePath(oldName))
+	if newInfo, err := os.Stat(GetTodoFilePath(newName)); err == nil && os.SameFile(oldInfo, newInfo) {
+		if err := os.Rename(GetTodoFilePath(oldName), GetTodoFilePath(newName)); err != nil {
+			return fmt.Errorf("failed to rename list: %w", err)
+		}
+		return followAdoptedList(oldName, newName)
+	}
+
+	oldList, err := ParseTodoFile(oldName)
+	if err != nil {
+		return fmt.Errorf("failed to parse todo file: %w", err)
+	}
+	newList, err := ParseTodoFile(newName)
+	if err != nil {
+		return fmt.Errorf("failed to parse todo file: %w", err)
+	}
+
+	for _, item := range oldList.Items {
+		item.ID = len(newList.Items) + 1
+		newList.Items = append(newList.Items, item)
+	}
+
+	if err := WriteTodoFile(newName, newList); err != nil {
+		return err
+	}
+	if err := DeleteList(oldName); err != nil {
+		return err
+	}
+
+	return followAdoptedList(oldName, newName)
+}
+
+// followAdoptedList moves the current list selection to a list's new name
+func followAdoptedList(oldName, newName string) error {
+	if content, err := os.ReadFile(GetCurrentListPath()); err == nil && strings.TrimSpace(string(content)) == oldName {
+		return SetCurrentList(newName)
+	}
+	return nil
+}
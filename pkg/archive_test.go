@@ -0,0 +1,128 @@
+package pkg
+
+import (
+	"testing"
+)
+
+func TestArchiveList(t *testing.T) {
+	setupTestDir(t)
+
+	CreateTodoFile("auth")
+	AddTodoItem("auth", "Login form")
+	AddTodoItem("auth", "Logout route")
+	CheckTodoItem("auth", 1)
+
+	if err := ArchiveList("auth"); err != nil {
+		t.Fatalf("ArchiveList failed: %v", err)
+	}
+
+	if TodoFileExists("auth") {
+		t.Error("List file should be removed after archiving")
+	}
+
+	archive, err := parseTodoFileAt(GetArchiveFilePath("auth"))
+	if err != nil {
+		t.Fatalf("Failed to parse archive: %v", err)
+	}
+	if len(archive.Items) != 2 {
+		t.Fatalf("Expected 2 archived items, got %d", len(archive.Items))
+	}
+	if !archive.Items[0].Completed || archive.Items[0].CompletedTime == nil {
+		t.Error("Archived item should keep its completion timestamp")
+	}
+
+	// Archiving again appends rather than overwriting
+	CreateTodoFile("auth")
+	AddTodoItem("auth", "Password reset")
+	if err := ArchiveList("auth"); err != nil {
+		t.Fatalf("ArchiveList failed: %v", err)
+	}
+	archive, _ = parseTodoFileAt(GetArchiveFilePath("auth"))
+	if len(archive.Items) != 3 {
+		t.Errorf("Expected 3 archived items, got %d", len(archive.Items))
+	}
+}
+
+func TestArchiveCompleted(t *testing.T) {
+	setupTestDir(t)
+
+	store := NewStore()
+	for _, text := range []string{"Login form", "Logout route", "Password reset"} {
+		store.AddItem("auth", text)
+	}
+	store.AddItemUnder("auth", 2, "Clear the session")
+	store.AddItemUnder("auth", 4, "Email the link")
+	store.Flush()
+	for _, id := range []int{1, 2, 3, 4} {
+		CheckTodoItem("auth", id)
+	}
+
+	archived, err := ArchiveCompleted("auth")
+	if err != nil {
+		t.Fatalf("ArchiveCompleted failed: %v", err)
+	}
+	if len(archived) != 4 {
+		t.Fatalf("Expected 4 archived items, got %+v", archived)
+	}
+
+	// The pending subtask is all that is left, renumbered
+	todoList, _ := ParseTodoFile("auth")
+	if len(todoList.Items) != 1 || todoList.Items[0].Text != "Email the link" || todoList.Items[0].ID != 1 || todoList.Items[0].Depth != 0 {
+		t.Errorf("Expected only the pending item to stay, got %+v", todoList.Items)
+	}
+
+	archive, _ := parseTodoFileAt(GetArchiveFilePath("auth"))
+	if len(archive.Items) != 4 || archive.Items[0].CompletedTime == nil {
+		t.Fatalf("Expected the completed items in the archive, got %+v", archive.Items)
+	}
+	if archive.Parent(3) != 2 || archive.Items[3].Depth != 0 {
+		t.Errorf("Expected only the subtask archived with its parent to stay nested, got %+v", archive.Items)
+	}
+
+	history, err := CompletedHistory()
+	if err != nil {
+		t.Fatalf("CompletedHistory failed: %v", err)
+	}
+	if len(history) != 4 || !history[0].Archived {
+		t.Errorf("Expected the history to include the archived items, got %+v", history)
+	}
+
+	if archived, err := ArchiveCompleted("auth"); err != nil || len(archived) != 0 {
+		t.Errorf("Expected nothing more to archive, got %+v, %v", archived, err)
+	}
+}
+
+func TestAdoptList(t *testing.T) {
+	setupTestDir(t)
+
+	CreateTodoFile("old-name")
+	AddTodoItem("old-name", "First")
+	CreateTodoFile("new-name")
+	AddTodoItem("new-name", "Existing")
+	SetCurrentList("old-name")
+
+	if err := AdoptList("old-name", "new-name"); err != nil {
+		t.Fatalf("AdoptList failed: %v", err)
+	}
+
+	if TodoFileExists("old-name") {
+		t.Error("Old list should be removed")
+	}
+
+	todoList, err := ParseTodoFile("new-name")
+	if err != nil {
+		t.Fatalf("ParseTodoFile failed: %v", err)
+	}
+	if len(todoList.Items) != 2 || todoList.Items[1].Text != "First" {
+		t.Errorf("Expected adopted items appended to new list, got %+v", todoList.Items)
+	}
+
+	currentList, _ := GetCurrentList()
+	if currentList != "new-name" {
+		t.Errorf("Current list = %q, want %q", currentList, "new-name")
+	}
+
+	if err := AdoptList("missing", "new-name"); err == nil {
+		t.Error("AdoptList should fail for a missing list")
+	}
+}
@@ -0,0 +1,231 @@
+package pkg
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AsanaAPIURL is the base URL of the Asana API
+var AsanaAPIURL = "https://app.asana.com/api/1.0"
+
+// Metadata keys for items imported from Asana
+const (
+	// metaAsana holds the gid of the task an item was imported from
+	metaAsana = "asana"
+	// metaAssignee holds the name of the person the task is assigned to
+	metaAssignee = "assignee"
+)
+
+const asanaTaskFields = "name,completed,completed_at,due_on,tags.name,assignee.name,memberships.project.gid,memberships.section.name"
+
+// AsanaTask is a task of an Asana project
+type AsanaTask struct {
+	GID         string
+	Name        string
+	Completed   bool
+	CompletedAt *time.Time
+	// DueOn is the due date as YYYY-MM-DD, if any
+	DueOn    string
+	Tags     []string
+	Assignee string
+	// Section is the name of the task's section in the project
+	Section string
+}
+
+// ItemText is the task name followed by its tags as #tags
+func (t AsanaTask) ItemText() string {
+	text := strings.TrimSpace(t.Name)
+	for _, tag := range t.Tags {
+		tag = strings.ReplaceAll(strings.TrimSpace(tag), " ", "-")
+		if ValidTagName(tag) && !slices.Contains(ExtractTags(text), strings.ToLower(tag)) {
+			text += " #" + tag
+		}
+	}
+	return text
+}
+
+// NewAsanaClient returns an API client authenticated with the stored asana
+// credential
+func NewAsanaClient() (*APIClient, error) {
+	token, err := GetCredential("asana")
+	if err != nil {
+		return nil, err
+	}
+	client := NewAPIClient(AsanaAPIURL, 5)
+	client.Header.Set("Authorization", "Bearer "+token)
+	return client, nil
+}
+
+// AsanaProjectTasks returns the tasks of a project in project order
+func AsanaProjectTasks(client *APIClient, projectGID string) ([]AsanaTask, error) {
+	var tasks []AsanaTask
+	offset := ""
+	for {
+		query := url.Values{"opt_fields": {asanaTaskFields}, "limit": {"100"}}
+		if offset != "" {
+			query.Set("offset", offset)
+		}
+
+		var page struct {
+			Data []struct {
+				GID         string     `json:"gid"`
+				Name        string     `json:"name"`
+				Completed   bool       `json:"completed"`
+				CompletedAt *time.Time `json:"completed_at"`
+				DueOn       string     `json:"due_on"`
+				Tags        []struct {
+					Name string `json:"name"`
+				} `json:"tags"`
+				Assignee *struct {
+					Name string `json:"name"`
+				} `json:"assignee"`
+				Memberships []struct {
+					Project struct {
+						GID string `json:"gid"`
+					} `json:"project"`
+					Section *struct {
+						Name string `json:"name"`
+					} `json:"section"`
+				} `json:"memberships"`
+			} `json:"data"`
+			NextPage *struct {
+				Offset string `json:"offset"`
+			} `json:"next_page"`
+		}
+		path := fmt.Sprintf("/projects/%s/tasks?%s", url.PathEscape(projectGID), query.Encode())
+		if err := client.Do("GET", path, nil, &page); err != nil {
+			return nil, err
+		}
+
+		for _, data := range page.Data {
+			task := AsanaTask{GID: data.GID, Name: data.Name, Completed: data.Completed, CompletedAt: data.CompletedAt, DueOn: data.DueOn}
+			for _, tag := range data.Tags {
+				task.Tags = append(task.Tags, tag.Name)
+			}
+			if data.Assignee != nil {
+				task.Assignee = data.Assignee.Name
+			}
+			// A task can be in several projects; only its section in
+			// this one matters
+			for _, membership := range data.Memberships {
+				if membership.Project.GID == projectGID && membership.Section != nil {
+					task.Section = membership.Section.Name
+				}
+			}
+			tasks = append(tasks, task)
+		}
+
+		if page.NextPage == nil || page.NextPage.Offset == "" {
+			return tasks, nil
+		}
+		offset = page.NextPage.Offset
+	}
+}
+
+// ImportAsanaTasks adds tasks to a list, creating the list if needed, with
+// each task under a section named after its Asana section. Tasks imported
+// before are updated in place instead, so importing again refreshes the
+// list. It returns how many items were added and updated.
+func ImportAsanaTasks(listName string, tasks []AsanaTask, now time.Time) (int, int, error) {
+	if err := CreateTodoFile(listName); err != nil {
+		return 0, 0, err
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return 0, 0, err
+	}
+	store := NewStore()
+	todoList, err := store.Get(listName)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	imported := make(map[string]int)
+	for i, item := range todoList.Items {
+		if gid := item.Metadata[metaAsana]; gid != "" {
+			imported[gid] = i
+		}
+	}
+
+	added, updated := 0, 0
+	for _, task := range tasks {
+		index, ok := imported[task.GID]
+		if !ok {
+			item := TodoItem{Metadata: map[string]string{metaAsana: task.GID}}
+			if cfg.Display.Numbering == NumberingID {
+				item.ShortID = newShortID(todoList)
+			}
+			todoList.Items = append(todoList.Items, item)
+			index = len(todoList.Items) - 1
+			imported[task.GID] = index
+			added++
+		}
+
+		item := &todoList.Items[index]
+		before := formatItemLine(*item) + item.Section
+		applyAsanaTask(item, task, now)
+		if ok && formatItemLine(*item)+item.Section != before {
+			updated++
+		}
+	}
+	if added == 0 && updated == 0 {
+		return 0, 0, nil
+	}
+
+	groupSections(todoList)
+	store.MarkDirty(listName)
+	return added, updated, store.Flush()
+}
+
+// applyAsanaTask makes an item match a task
+func applyAsanaTask(item *TodoItem, task AsanaTask, now time.Time) {
+	item.Text = task.ItemText()
+	item.Section = task.Section
+
+	if task.Completed && !item.Completed {
+		completed := now
+		if task.CompletedAt != nil {
+			completed = task.CompletedAt.Local()
+		}
+		item.Completed = true
+		item.CompletedTime = &completed
+	} else if !task.Completed {
+		item.Completed = false
+		item.CompletedTime = nil
+	}
+
+	item.DueDate = nil
+	if due, err := time.ParseInLocation(DueDateFormat, task.DueOn, time.Local); err == nil {
+		item.DueDate = &due
+	}
+
+	if task.Assignee != "" {
+		item.Metadata[metaAssignee] = task.Assignee
+	} else {
+		delete(item.Metadata, metaAssignee)
+	}
+}
+
+// groupSections brings the items of each section together, keeping the
+// order sections first appear in and the order of items within a section,
+// and renumbers the items. Items outside any section come first, since they
+// can only be written before the first heading.
+func groupSections(todoList *TodoList) {
+	order := map[string]int{"": -1}
+	for _, item := range todoList.Items {
+		if _, ok := order[item.Section]; !ok {
+			order[item.Section] = len(order)
+		}
+	}
+	sort.SliceStable(todoList.Items, func(i, j int) bool {
+		return order[todoList.Items[i].Section] < order[todoList.Items[j].Section]
+	})
+	for i := range todoList.Items {
+		todoList.Items[i].ID = i + 1
+	}
+}
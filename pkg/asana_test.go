@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAsanaTaskItemText(t *testing.T) {
+	task := AsanaTask{Name: "Ship the #release notes", Tags: []string{"Release", "needs review", "bad tag!"}}
+	if got, want := task.ItemText(), "Ship the #release notes #needs-review"; got != want {
+		t.Errorf("ItemText() = %q, want %q", got, want)
+	}
+}
+
+func TestAsanaProjectTasks(t *testing.T) {
+	pages := map[string]string{
+		"": `{"data":[
+			{"gid":"1","name":"Design","completed":true,"completed_at":"2024-06-01T10:00:00Z","tags":[{"name":"ux"}],"assignee":{"name":"Alice"},
+			 "memberships":[{"project":{"gid":"other"},"section":{"name":"Elsewhere"}},{"project":{"gid":"42"},"section":{"name":"Doing"}}]}
+		],"next_page":{"offset":"page2"}}`,
+		"page2": `{"data":[
+			{"gid":"2","name":"Build","completed":false,"due_on":"2024-07-01","assignee":null,"memberships":[{"project":{"gid":"42"},"section":{"name":"To do"}}]}
+		],"next_page":null}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects/42/tasks" || r.URL.Query().Get("opt_fields") == "" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, pages[r.URL.Query().Get("offset")])
+	}))
+	defer server.Close()
+
+	tasks, err := AsanaProjectTasks(NewAPIClient(server.URL, 0), "42")
+	if err != nil {
+		t.Fatalf("AsanaProjectTasks failed: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("Expected 2 tasks, got %+v", tasks)
+	}
+	if tasks[0].Section != "Doing" || tasks[0].Assignee != "Alice" || !tasks[0].Completed || tasks[0].CompletedAt == nil || tasks[0].Tags[0] != "ux" {
+		t.Errorf("Unexpected first task: %+v", tasks[0])
+	}
+	if tasks[1].Section != "To do" || tasks[1].DueOn != "2024-07-01" || tasks[1].Assignee != "" {
+		t.Errorf("Unexpected second task: %+v", tasks[1])
+	}
+}
+
+func TestImportAsanaTasks(t *testing.T) {
+	setupTestDir(t)
+	AddTodoItem("migrated", "Local note")
+	now := time.Date(2024, 6, 2, 9, 0, 0, 0, time.Local)
+
+	tasks := []AsanaTask{
+		{GID: "1", Name: "Design", Section: "Doing", Assignee: "Alice"},
+		{GID: "2", Name: "Build", Section: "To do", DueOn: "2024-07-01"},
+	}
+	added, updated, err := ImportAsanaTasks("migrated", tasks, now)
+	if err != nil || added != 2 || updated != 0 {
+		t.Fatalf("ImportAsanaTasks = %d, %d, %v; want 2 added", added, updated, err)
+	}
+
+	// Refreshing updates imported items and groups new ones by section
+	tasks[0].Completed = true
+	tasks = append(tasks, AsanaTask{GID: "3", Name: "Review", Section: "Doing"})
+	added, updated, err = ImportAsanaTasks("migrated", tasks, now)
+	if err != nil || added != 1 || updated != 1 {
+		t.Fatalf("ImportAsanaTasks = %d, %d, %v; want 1 added, 1 updated", added, updated, err)
+	}
+	if added, updated, _ := ImportAsanaTasks("migrated", tasks, now); added != 0 || updated != 0 {
+		t.Errorf("Importing unchanged tasks = %d, %d; want nothing", added, updated)
+	}
+
+	todoList, err := ParseTodoFile("migrated")
+	if err != nil {
+		t.Fatalf("ParseTodoFile failed: %v", err)
+	}
+	var got []string
+	for _, item := range todoList.Items {
+		got = append(got, fmt.Sprintf("%d %s/%s", item.ID, item.Section, item.Text))
+	}
+	want := []string{"1 /Local note", "2 Doing/Design", "3 Doing/Review", "4 To do/Build"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Items = %v, want %v", got, want)
+	}
+
+	design := todoList.Items[1]
+	if !design.Completed || design.CompletedTime == nil || design.Metadata[metaAssignee] != "Alice" || design.Metadata[metaAsana] != "1" {
+		t.Errorf("Unexpected imported item: %+v", design)
+	}
+	if build := todoList.Items[3]; build.DueDate == nil || build.DueDate.Format(DueDateFormat) != "2024-07-01" {
+		t.Errorf("Expected the due date to be imported, got %+v", build)
+	}
+}
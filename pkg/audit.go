@@ -0,0 +1,123 @@
+package pkg
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AuditEntry is one line of the audit log: who ran a command that changed
+// lists, and which lists it changed
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	Name    string    `json:"name"`
+	Email   string    `json:"email,omitempty"`
+	Command string    `json:"command"`
+	Lists   []string  `json:"lists"`
+}
+
+// Lists written or deleted by this process, for the audit log
+var (
+	changedMu    sync.Mutex
+	changedLists = make(map[string]bool)
+)
+
+func noteChanged(listName string) {
+	changedMu.Lock()
+	defer changedMu.Unlock()
+
+	changedLists[listName] = true
+}
+
+// ChangedLists returns the lists written or deleted so far, sorted by name
+func ChangedLists() []string {
+	changedMu.Lock()
+	defer changedMu.Unlock()
+
+	var names []string
+	for name := range changedLists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func GetAuditPath() string {
+	return filepath.Join(".todo", "audit.jsonl")
+}
+
+// GitIdentity returns the git user.name and user.email, falling back to the
+// login name when git has no identity configured
+func GitIdentity() (string, string) {
+	name, _ := runGit("config", "user.name")
+	email, _ := runGit("config", "user.email")
+	if name == "" {
+		if current, err := user.Current(); err == nil {
+			name = current.Username
+		}
+	}
+	return name, email
+}
+
+// RecordAudit appends an entry for command to the audit log if auditing is
+// enabled and the command changed any lists
+func RecordAudit(cfg *Config, command string, now time.Time) error {
+	if !cfg.Audit {
+		return nil
+	}
+	lists := ChangedLists()
+	if len(lists) == 0 {
+		return nil
+	}
+
+	name, email := GitIdentity()
+	line, err := json.Marshal(AuditEntry{Time: now, Name: name, Email: email, Command: command, Lists: lists})
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	file, err := os.OpenFile(GetAuditPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// ReadAudit returns every entry in the audit log, oldest first. Lines that
+// can't be parsed are skipped.
+func ReadAudit() ([]AuditEntry, error) {
+	file, err := os.Open(GetAuditPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading audit log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordAudit(t *testing.T) {
+	setupTestDir(t)
+	exec.Command("git", "init").Run()
+	exec.Command("git", "config", "user.name", "Test User").Run()
+	exec.Command("git", "config", "user.email", "test@example.com").Run()
+	changedLists = make(map[string]bool)
+	t.Cleanup(func() { changedLists = make(map[string]bool) })
+
+	now := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+
+	// Nothing is recorded while auditing is off, or when no list changed
+	if err := RecordAudit(&Config{Audit: true}, "todo list", now); err != nil {
+		t.Fatalf("RecordAudit failed: %v", err)
+	}
+	CreateTodoFile("auth")
+	AddTodoItem("main", "Write tests")
+	if err := RecordAudit(&Config{}, "todo add Write tests", now); err != nil {
+		t.Fatalf("RecordAudit failed: %v", err)
+	}
+	if entries, _ := ReadAudit(); len(entries) != 0 {
+		t.Fatalf("Expected no audit entries, got %+v", entries)
+	}
+
+	if err := RecordAudit(&Config{Audit: true}, "todo add Write tests", now); err != nil {
+		t.Fatalf("RecordAudit failed: %v", err)
+	}
+	entries, err := ReadAudit()
+	if err != nil {
+		t.Fatalf("ReadAudit failed: %v", err)
+	}
+	want := AuditEntry{Time: now, Name: "Test User", Email: "test@example.com", Command: "todo add Write tests", Lists: []string{"auth", "main"}}
+	if len(entries) != 1 || !reflect.DeepEqual(entries[0], want) {
+		t.Errorf("ReadAudit() = %+v, want [%+v]", entries, want)
+	}
+}
+
+func TestChangedListsIncludesDeletes(t *testing.T) {
+	setupTestDir(t)
+	changedLists = make(map[string]bool)
+	t.Cleanup(func() { changedLists = make(map[string]bool) })
+
+	EnsureTodoDirectory()
+	WriteTodoFile("old", &TodoList{})
+	DeleteList("old")
+
+	if got := ChangedLists(); !reflect.DeepEqual(got, []string{"old"}) {
+		t.Errorf("ChangedLists() = %v, want [old]", got)
+	}
+}
@@ -0,0 +1,157 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// commitSubjectColumns is how much of an item's text a commit message quotes
+const commitSubjectColumns = 50
+
+// AutoCommitEnabled reports whether the changes a command makes to .todo
+// should be committed, because the config asks for it or the command was
+// run with --commit
+func AutoCommitEnabled(cfg *Config, flag bool) bool {
+	return (cfg.AutoCommit || flag) && GitEnabled(cfg)
+}
+
+// CommitChanges stages the files of the lists the command changed and commits
+// them, and only them, with a message saying what command changed which
+// items, such as "todo: check 'Implement login' in auth". Everything else
+// under .todo, such as the journal and the sync state, stays out. It returns
+// the message, or "" when the command changed no lists or nothing was left
+// to commit.
+func CommitChanges(command string) (string, error) {
+	lists := ChangedLists()
+	if len(lists) == 0 {
+		return "", nil
+	}
+	if IsIgnored(".todo/") {
+		return "", fmt.Errorf(".todo/ is gitignored, so changes can't be committed; run 'todo init --yes --visibility committed' to share it")
+	}
+
+	paths := listPaths(lists)
+	if len(paths) == 0 {
+		return "", nil
+	}
+
+	message := commitMessage(command, lists)
+	if _, err := runGit(append([]string{"add", "-A", "--"}, paths...)...); err != nil {
+		return "", err
+	}
+	if _, err := runGit(append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...); err == nil {
+		return "", nil
+	}
+	if _, err := runGit(append([]string{"commit", "-q", "-m", message, "--"}, paths...)...); err != nil {
+		return "", err
+	}
+	return message, nil
+}
+
+// listPaths returns the files that hold the given lists and their archives,
+// or the database when lists are kept in SQLite. Files that neither exist
+// nor are tracked, such as the archive of a list never archived, are left
+// out, as git refuses a path that matches nothing.
+func listPaths(lists []string) []string {
+	var candidates []string
+	if UsingSQLite() {
+		candidates = append(candidates, GetDatabasePath())
+	} else {
+		for _, name := range lists {
+			candidates = append(candidates, GetTodoFilePath(name), GetArchiveFilePath(name))
+		}
+	}
+
+	var paths []string
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err != nil {
+			if tracked, _ := TrackedFiles(path); len(tracked) == 0 {
+				continue
+			}
+		}
+		paths = append(paths, filepath.ToSlash(path))
+	}
+	return paths
+}
+
+// commitMessage describes a command's changes to lists. The item is named
+// when exactly one changed compared to the last commit; otherwise the
+// message counts the items, or just names the lists.
+func commitMessage(command string, lists []string) string {
+	texts := make(map[string]bool)
+	for _, name := range lists {
+		for _, text := range changedItemTexts(name) {
+			texts[text] = true
+		}
+	}
+
+	subject := command
+	switch len(texts) {
+	case 0:
+	case 1:
+		for text := range texts {
+			if utf8.RuneCountInString(text) > commitSubjectColumns {
+				text = truncateText(text, commitSubjectColumns)
+			}
+			subject += fmt.Sprintf(" '%s'", text)
+		}
+	default:
+		subject += fmt.Sprintf(" %d items", len(texts))
+	}
+	return fmt.Sprintf("todo: %s in %s", subject, strings.Join(lists, ", "))
+}
+
+// changedItemTexts returns the texts of the items added, removed or changed
+// in a list file since the last commit. Items are matched by content, as
+// removing one renumbers the rest. Lists kept in SQLite have no file to
+// compare, so nothing is returned for them.
+func changedItemTexts(name string) []string {
+	if UsingSQLite() {
+		return nil
+	}
+
+	before := &TodoList{}
+	if content, err := runGitWithInput(nil, "show", "HEAD:./"+filepath.ToSlash(GetTodoFilePath(name))); err == nil {
+		if list, err := parseTodoList(bytes.NewReader(content)); err == nil {
+			before = list
+		}
+	}
+	after, err := parseTodoFileAt(GetTodoFilePath(name))
+	if err != nil {
+		return nil
+	}
+
+	// Cancel out the items that are the same on both sides
+	unchanged := func(item TodoItem) TodoItem {
+		item.ID, item.Line = 0, 0
+		return item
+	}
+	remaining := make([]TodoItem, 0, len(before.Items))
+	for _, item := range before.Items {
+		remaining = append(remaining, unchanged(item))
+	}
+	var texts []string
+	for _, item := range after.Items {
+		item = unchanged(item)
+		matched := false
+		for i := range remaining {
+			if reflect.DeepEqual(remaining[i], item) {
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			texts = append(texts, item.Text)
+		}
+	}
+	for _, item := range remaining {
+		texts = append(texts, item.Text)
+	}
+	return texts
+}
@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCommitChanges(t *testing.T) {
+	setupGitTestDir(t)
+	changedLists = make(map[string]bool)
+	t.Cleanup(func() { changedLists = make(map[string]bool) })
+	lastCommit := func() string {
+		output, _ := exec.Command("git", "log", "-1", "--format=%s").Output()
+		return strings.TrimSpace(string(output))
+	}
+
+	// Nothing is committed when no list changed
+	if message, err := CommitChanges("list"); err != nil || message != "" {
+		t.Fatalf("CommitChanges() = %q, %v; want nothing committed", message, err)
+	}
+
+	AddTodoItem("auth", "Implement login")
+	AddTodoItem("auth", "Write tests")
+	if message, err := CommitChanges("add"); err != nil || message != "todo: add 2 items in auth" {
+		t.Fatalf("CommitChanges() = %q, %v", message, err)
+	}
+
+	// Other staged files are left out of the commit
+	os.WriteFile("notes.txt", []byte("notes"), 0644)
+	exec.Command("git", "add", "notes.txt").Run()
+	changedLists = make(map[string]bool)
+	CheckTodoItem("auth", 1)
+	if message, err := CommitChanges("check"); err != nil || message != "todo: check 'Implement login' in auth" {
+		t.Fatalf("CommitChanges() = %q, %v", message, err)
+	}
+	if got := lastCommit(); got != "todo: check 'Implement login' in auth" {
+		t.Errorf("Last commit = %q", got)
+	}
+	if output, _ := exec.Command("git", "diff", "--cached", "--name-only").Output(); strings.TrimSpace(string(output)) != "notes.txt" {
+		t.Errorf("Expected notes.txt to stay staged, got %q", output)
+	}
+
+	// Other files under .todo are left out of the commit
+	changedLists = make(map[string]bool)
+	os.MkdirAll(".todo/sync", 0755)
+	os.WriteFile(".todo/sync/linear.json", []byte("{}"), 0644)
+	AddTodoItem("auth", "Review login")
+	if message, err := CommitChanges("add"); err != nil || message != "todo: add 'Review login' in auth" {
+		t.Fatalf("CommitChanges() = %q, %v", message, err)
+	}
+	if tracked, _ := TrackedFiles(".todo/sync"); len(tracked) != 0 {
+		t.Errorf("Expected the sync state to stay untracked, got %v", tracked)
+	}
+
+	// A removed item is named, though the items after it are renumbered
+	changedLists = make(map[string]bool)
+	store := NewStore()
+	store.RemoveItems("auth", []int{1})
+	store.Flush()
+	if message, _ := CommitChanges("remove"); message != "todo: remove 'Implement login' in auth" {
+		t.Errorf("CommitChanges() = %q", message)
+	}
+
+	// An ignored .todo can't be committed
+	changedLists = make(map[string]bool)
+	os.WriteFile(".gitignore", []byte(".todo/\n"), 0644)
+	AddTodoItem("auth", "Deploy")
+	if _, err := CommitChanges("add"); err == nil || !strings.Contains(err.Error(), "gitignored") {
+		t.Errorf("Expected an error for an ignored .todo, got %v", err)
+	}
+}
+
+func TestAutoCommitEnabled(t *testing.T) {
+	setupGitTestDir(t)
+
+	if AutoCommitEnabled(&Config{}, false) {
+		t.Error("Expected auto-commit to be off by default")
+	}
+	if !AutoCommitEnabled(&Config{AutoCommit: true}, false) || !AutoCommitEnabled(&Config{}, true) {
+		t.Error("Expected auto_commit or --commit to turn auto-commit on")
+	}
+	if AutoCommitEnabled(&Config{AutoCommit: true, Git: GitOff}, true) {
+		t.Error("Expected auto-commit to be off with git: off")
+	}
+}
@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// setupBenchStore creates a store of lists*items synthetic items, a third of
+// them completed, and silences stdout for commands that print
+func setupBenchStore(b *testing.B, lists, items int) {
+	b.Helper()
+
+	dir := b.TempDir()
+	originalDir, _ := os.Getwd()
+	os.Chdir(dir)
+
+	stdout := os.Stdout
+	devNull, _ := os.Open(os.DevNull)
+	os.Stdout = devNull
+	b.Cleanup(func() {
+		os.Stdout = stdout
+		devNull.Close()
+		os.Chdir(originalDir)
+	})
+
+	EnsureTodoDirectory()
+	base := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	for l := 0; l < lists; l++ {
+		todoList := &TodoList{}
+		for i := 0; i < items; i++ {
+			item := TodoItem{ID: i + 1, Text: fmt.Sprintf("Item %d of list %d #tag%d", i, l, i%7)}
+			if i%3 == 0 {
+				completed := base.Add(time.Duration(l*items+i) * time.Minute)
+				item.Completed = true
+				item.CompletedTime = &completed
+			}
+			todoList.Items = append(todoList.Items, item)
+		}
+		if err := WriteTodoFile(fmt.Sprintf("list-%04d", l), todoList); err != nil {
+			b.Fatalf("Failed to write list: %v", err)
+		}
+	}
+}
+
+var benchSizes = []struct{ lists, items int }{
+	{10, 100},
+	{200, 50},
+}
+
+func BenchmarkShowHistory(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("%dx%d", size.lists, size.items), func(b *testing.B) {
+			setupBenchStore(b, size.lists, size.items)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := ShowHistory(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkListAllFeatures(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("%dx%d", size.lists, size.items), func(b *testing.B) {
+			setupBenchStore(b, size.lists, size.items)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := ListAllFeatures(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetListOverviews(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("%dx%d", size.lists, size.items), func(b *testing.B) {
+			setupBenchStore(b, size.lists, size.items)
+			now := time.Now()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := GetListOverviews(now); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// BreakdownConfig says where 'todo breakdown' sends items to be split into
+// subtasks. Breakdowns are off until one of Command or Endpoint is set.
+type BreakdownConfig struct {
+	// Command is a shell command that reads the prompt on stdin and prints
+	// one subtask per line
+	Command string `yaml:"command,omitempty"`
+	// Endpoint is the base URL of an OpenAI-compatible API, such as
+	// https://api.openai.com/v1 or http://localhost:11434/v1
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// Model is the model the endpoint is asked to use
+	Model string `yaml:"model,omitempty"`
+}
+
+// metaParent records the item a subtask was broken down from
+const metaParent = "parent"
+
+// breakdownCredential names the stored API key sent to breakdown endpoints
+const breakdownCredential = "llm"
+
+// ErrBreakdownNotConfigured means neither a breakdown command nor endpoint
+// is set up
+var ErrBreakdownNotConfigured = errors.New("breakdowns are not configured; set breakdown.command or breakdown.endpoint in .todo/config.yaml")
+
+// BreakdownRequest is the item to break down and the list around it
+type BreakdownRequest struct {
+	List string
+	Item string
+	// Context holds the text of the list's other pending items
+	Context []string
+}
+
+// BreakdownProvider suggests subtasks for an item
+type BreakdownProvider interface {
+	// Name describes the provider in messages
+	Name() string
+	Breakdown(req BreakdownRequest) ([]string, error)
+}
+
+// NewBreakdownProvider returns the provider set up in the config
+func NewBreakdownProvider(cfg *Config) (BreakdownProvider, error) {
+	breakdown := cfg.Breakdown
+	switch {
+	case breakdown.Command != "" && breakdown.Endpoint != "":
+		return nil, fmt.Errorf("set only one of breakdown.command and breakdown.endpoint")
+	case breakdown.Command != "":
+		return commandBreakdown{command: breakdown.Command}, nil
+	case breakdown.Endpoint != "":
+		if breakdown.Model == "" {
+			return nil, fmt.Errorf("breakdown.endpoint needs a breakdown.model")
+		}
+		// Local servers usually don't need a key, so one is only sent when
+		// it has been stored
+		key, err := GetCredential(breakdownCredential)
+		if err != nil && !errors.Is(err, ErrCredentialNotFound) {
+			return nil, err
+		}
+		return newChatBreakdown(breakdown.Endpoint, breakdown.Model, key), nil
+	}
+	if err := cfg.ProjectCommandError("breakdown.command"); err != nil {
+		return nil, err
+	}
+	return nil, ErrBreakdownNotConfigured
+}
+
+// BreakdownPrompt is the prompt sent to providers for a request
+func BreakdownPrompt(req BreakdownRequest) string {
+	var b strings.Builder
+	b.WriteString("Break the following task down into a few concrete subtasks. ")
+	b.WriteString("Reply with one subtask per line and nothing else.\n\n")
+	fmt.Fprintf(&b, "Task: %s\n", req.Item)
+	fmt.Fprintf(&b, "List: %s\n", req.List)
+	if len(req.Context) > 0 {
+		b.WriteString("Other items in the list:\n")
+		for _, text := range req.Context {
+			fmt.Fprintf(&b, "- %s\n", text)
+		}
+	}
+	return b.String()
+}
+
+// subtaskPrefix matches the bullets, checkboxes and numbers providers tend
+// to put in front of each subtask
+var subtaskPrefix = regexp.MustCompile(`^(?:[-*+•]\s+)?(?:\[[ xX]\]\s+)?(?:\d+[.)]\s+)?`)
+
+// parseSubtasks reads one subtask per line of a provider's reply, dropping
+// blank lines, code fences and list markers
+func parseSubtasks(output string) []string {
+	var subtasks []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") {
+			continue
+		}
+		line = strings.TrimSpace(subtaskPrefix.ReplaceAllString(line, ""))
+		if line != "" {
+			subtasks = append(subtasks, line)
+		}
+	}
+	return subtasks
+}
+
+// commandBreakdown runs a shell command with the prompt on stdin
+type commandBreakdown struct {
+	command string
+}
+
+func (c commandBreakdown) Name() string {
+	return "breakdown command"
+}
+
+func (c commandBreakdown) Breakdown(req BreakdownRequest) ([]string, error) {
+	cmd := exec.Command("sh", "-c", c.command)
+	cmd.Stdin = strings.NewReader(BreakdownPrompt(req))
+	cmd.Env = append(os.Environ(), "TODO_BREAKDOWN_LIST="+req.List, "TODO_BREAKDOWN_ITEM="+req.Item)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("breakdown command failed: %w", err)
+	}
+	return parseSubtasks(string(output)), nil
+}
+
+// chatBreakdown asks an OpenAI-compatible chat completions endpoint
+type chatBreakdown struct {
+	client *APIClient
+	model  string
+}
+
+func newChatBreakdown(endpoint, model, key string) chatBreakdown {
+	client := NewAPIClient(endpoint, 0)
+	// Models can take a while to answer
+	client.HTTP.Timeout = 2 * time.Minute
+	client.MaxRetries = 2
+	if key != "" {
+		client.Header.Set("Authorization", "Bearer "+key)
+	}
+	return chatBreakdown{client: client, model: model}
+}
+
+func (c chatBreakdown) Name() string {
+	return c.model
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+func (c chatBreakdown) Breakdown(req BreakdownRequest) ([]string, error) {
+	body := map[string]interface{}{
+		"model": c.model,
+		"messages": []chatMessage{
+			{Role: "system", Content: "You break tasks down into short, actionable subtasks."},
+			{Role: "user", Content: BreakdownPrompt(req)},
+		},
+	}
+	var resp struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := c.client.Do("POST", "/chat/completions", body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("the endpoint returned no reply")
+	}
+	return parseSubtasks(resp.Choices[0].Message.Content), nil
+}
+
+// BreakdownItem asks the provider to break an item down and returns the
+// suggested subtasks without changing the list
+func BreakdownItem(provider BreakdownProvider, listName string, itemID int) ([]string, error) {
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return nil, err
+	}
+	if itemID < 1 || itemID > len(todoList.Items) {
+		return nil, fmt.Errorf("item %d does not exist", itemID)
+	}
+
+	req := BreakdownRequest{List: listName, Item: todoList.Items[itemID-1].Text}
+	for i, item := range todoList.Items {
+		if i != itemID-1 && !item.Completed {
+			req.Context = append(req.Context, item.Text)
+		}
+	}
+	subtasks, err := provider.Breakdown(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(subtasks) == 0 {
+		return nil, fmt.Errorf("%s suggested no subtasks", provider.Name())
+	}
+	return subtasks, nil
+}
+
+// AddSubtasks nests items under an item, after any subtasks it already
+// has. Each subtask names its parent in its metadata.
+// It returns the new items' numbers.
+func AddSubtasks(listName string, parentID int, subtasks []string) ([]int, error) {
+	store := NewStore()
+	todoList, err := store.Get(listName)
+	if err != nil {
+		return nil, err
+	}
+	if parentID < 1 || parentID > len(todoList.Items) {
+		return nil, fmt.Errorf("item %d does not exist", parentID)
+	}
+	parent := todoList.Items[parentID-1]
+
+	var ids []int
+	err = store.Batch(func() error {
+		for _, text := range subtasks {
+			id, err := store.AddItemUnder(listName, parentID, text)
+			if err != nil {
+				return err
+			}
+			todoList.Items[id-1].Metadata[metaParent] = parent.Text
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
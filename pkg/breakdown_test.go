@@ -0,0 +1,131 @@
+package pkg
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeBreakdown struct {
+	subtasks []string
+	got      BreakdownRequest
+}
+
+func (f *fakeBreakdown) Name() string { return "fake" }
+
+func (f *fakeBreakdown) Breakdown(req BreakdownRequest) ([]string, error) {
+	f.got = req
+	return f.subtasks, nil
+}
+
+func TestParseSubtasks(t *testing.T) {
+	output := "```\n1. Write the migration\n- [ ] Backfill rows\n* Drop the old column\n\n2) Tell support\n```\n"
+	want := []string{"Write the migration", "Backfill rows", "Drop the old column", "Tell support"}
+	if got := parseSubtasks(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSubtasks = %q, want %q", got, want)
+	}
+}
+
+func TestNewBreakdownProvider(t *testing.T) {
+	if _, err := NewBreakdownProvider(&Config{}); err != ErrBreakdownNotConfigured {
+		t.Errorf("Expected ErrBreakdownNotConfigured, got %v", err)
+	}
+	both := &Config{Breakdown: BreakdownConfig{Command: "cat", Endpoint: "http://localhost"}}
+	if _, err := NewBreakdownProvider(both); err == nil {
+		t.Error("Expected an error with both a command and an endpoint")
+	}
+	noModel := &Config{Breakdown: BreakdownConfig{Endpoint: "http://localhost"}}
+	if _, err := NewBreakdownProvider(noModel); err == nil {
+		t.Error("Expected an error for an endpoint without a model")
+	}
+}
+
+func TestCommandBreakdown(t *testing.T) {
+	provider := commandBreakdown{command: `grep -q "Task: Ship" && printf -- "- $TODO_BREAKDOWN_LIST\n- Tag\n"`}
+	subtasks, err := provider.Breakdown(BreakdownRequest{List: "release", Item: "Ship"})
+	if err != nil {
+		t.Fatalf("Breakdown failed: %v", err)
+	}
+	if want := []string{"release", "Tag"}; !reflect.DeepEqual(subtasks, want) {
+		t.Errorf("subtasks = %q, want %q", subtasks, want)
+	}
+}
+
+func TestChatBreakdown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var body struct {
+			Model    string        `json:"model"`
+			Messages []chatMessage `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Model != "small" || !strings.Contains(body.Messages[len(body.Messages)-1].Content, "Task: Ship") {
+			http.Error(w, "unexpected body", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"1. Tag\n2. Build"}}]}`))
+	}))
+	defer server.Close()
+
+	provider := newChatBreakdown(server.URL+"/v1", "small", "secret")
+	subtasks, err := provider.Breakdown(BreakdownRequest{List: "release", Item: "Ship"})
+	if err != nil {
+		t.Fatalf("Breakdown failed: %v", err)
+	}
+	if want := []string{"Tag", "Build"}; !reflect.DeepEqual(subtasks, want) {
+		t.Errorf("subtasks = %q, want %q", subtasks, want)
+	}
+}
+
+func TestBreakdownAndAddSubtasks(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	os.WriteFile(GetTodoFilePath("work"), []byte("# Todo List for work\n\n## Now\n\n- [ ] Ship\n- [x] Plan\n\n## Later\n\n- [ ] Docs\n"), 0644)
+
+	provider := &fakeBreakdown{subtasks: []string{"Tag", "Build"}}
+	subtasks, err := BreakdownItem(provider, "work", 1)
+	if err != nil {
+		t.Fatalf("BreakdownItem failed: %v", err)
+	}
+	if provider.got.Item != "Ship" || !reflect.DeepEqual(provider.got.Context, []string{"Docs"}) {
+		t.Errorf("Unexpected request %+v", provider.got)
+	}
+
+	ids, err := AddSubtasks("work", 1, subtasks)
+	if err != nil {
+		t.Fatalf("AddSubtasks failed: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int{2, 3}) {
+		t.Errorf("ids = %v", ids)
+	}
+	// More subtasks go after the ones already there
+	if ids, _ = AddSubtasks("work", 1, []string{"Announce"}); !reflect.DeepEqual(ids, []int{4}) {
+		t.Errorf("ids = %v", ids)
+	}
+
+	todoList, _ := ParseTodoFile("work")
+	var texts []string
+	for i, item := range todoList.Items {
+		texts = append(texts, item.Text)
+		if item.ID != i+1 {
+			t.Errorf("Item %q numbered %d", item.Text, item.ID)
+		}
+		if i >= 1 && i <= 3 && (item.Section != "Now" || item.Metadata[metaParent] != "Ship") {
+			t.Errorf("Subtask %+v not under its parent", item)
+		}
+	}
+	if want := []string{"Ship", "Tag", "Build", "Announce", "Plan", "Docs"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("items = %q, want %q", texts, want)
+	}
+
+	if _, err := BreakdownItem(&fakeBreakdown{}, "work", 1); err == nil {
+		t.Error("Expected an error when no subtasks are suggested")
+	}
+}
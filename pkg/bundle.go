@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// A bundle packages one list and its archive into a single file, so the list
+// can be handed to someone who doesn't share the repository. The files are
+// stored verbatim, keeping frontmatter, item metadata and anything else in
+// them intact. Lists kept in SQLite are bundled as the file they would be.
+
+const (
+	bundleFormat  = "todo-bundle"
+	bundleVersion = 1
+)
+
+// Bundle is the content of a bundle file
+type Bundle struct {
+	Format   string    `json:"format"`
+	Version  int       `json:"version"`
+	List     string    `json:"list"`
+	Exported time.Time `json:"exported"`
+	Content  string    `json:"content"`
+	Archive  string    `json:"archive,omitempty"`
+}
+
+// ExportBundle writes a list and its archive to a bundle file
+func ExportBundle(listName, path string) error {
+	if !TodoFileExists(listName) {
+		return fmt.Errorf("list '%s' does not exist", listName)
+	}
+
+	content, err := readListContent(listName)
+	if err != nil {
+		return fmt.Errorf("failed to read todo file: %w", err)
+	}
+	archive, err := os.ReadFile(GetArchiveFilePath(listName))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read archive file: %w", err)
+	}
+
+	bundle := Bundle{
+		Format:   bundleFormat,
+		Version:  bundleVersion,
+		List:     listName,
+		Exported: Now().UTC().Truncate(time.Second),
+		Content:  string(content),
+		Archive:  string(archive),
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// ReadBundle reads and checks a bundle file
+func ReadBundle(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil || bundle.Format != bundleFormat {
+		return nil, fmt.Errorf("%s is not a todo bundle", path)
+	}
+	if bundle.Version != bundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d (this version of todo reads version %d)", bundle.Version, bundleVersion)
+	}
+	if bundle.List == "" {
+		return nil, fmt.Errorf("bundle does not name a list")
+	}
+	return &bundle, nil
+}
+
+// ImportBundle creates a list from a bundle, named listName or the bundled
+// list's own name when listName is empty. An existing list is never
+// overwritten; archived items are added to an existing archive.
+func ImportBundle(bundle *Bundle, listName string) (string, error) {
+	if listName == "" {
+		listName = bundle.List
+	}
+	// Bundles come from elsewhere, so don't let a name reach outside .todo
+	if listName == "." || listName == ".." || strings.ContainsAny(listName, `/\`) {
+		return "", fmt.Errorf("invalid list name '%s'", listName)
+	}
+	if TodoFileExists(listName) {
+		return "", fmt.Errorf("list '%s' already exists", listName)
+	}
+
+	// Check both files parse before writing either of them
+	if _, err := parseTodoList(strings.NewReader(bundle.Content)); err != nil {
+		return "", fmt.Errorf("bundled list is invalid: %w", err)
+	}
+	archive, err := parseTodoList(strings.NewReader(bundle.Archive))
+	if err != nil {
+		return "", fmt.Errorf("bundled archive is invalid: %w", err)
+	}
+
+	if err := EnsureTodoDirectory(); err != nil {
+		return "", fmt.Errorf("failed to create .todo directory: %w", err)
+	}
+	content := retitleList(bundle.Content, fmt.Sprintf("Todo List for %s", bundle.List), fmt.Sprintf("Todo List for %s", listName))
+	if err := writeListContent(listName, []byte(content)); err != nil {
+		return "", err
+	}
+
+	if len(archive.Items) > 0 {
+		if err := appendToArchive(listName, archive.Items); err != nil {
+			return "", err
+		}
+	}
+
+	return listName, nil
+}
+
+// retitleList replaces the first "# " heading of a list file when it is the
+// given title
+func retitleList(content, oldTitle, newTitle string) string {
+	if oldTitle == newTitle {
+		return content
+	}
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "# ") {
+			if strings.TrimSpace(line) == "# "+oldTitle {
+				lines[i] = strings.Replace(line, oldTitle, newTitle, 1)
+			}
+			break
+		}
+	}
+	return strings.Join(lines, "")
+}
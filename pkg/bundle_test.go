@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	dir := setupTestDir(t)
+
+	CreateTodoFile("auth")
+	AddTodoItem("auth", "Old item")
+	CheckTodoItem("auth", 1)
+	ArchiveList("auth")
+	CreateTodoFile("auth")
+	AddWeightedTodoItem("auth", "Login form", 3)
+	SetListTarget("auth", "2024-08-01")
+
+	path := filepath.Join(dir, "auth.todo")
+	if err := ExportBundle("auth", path); err != nil {
+		t.Fatalf("ExportBundle failed: %v", err)
+	}
+	original, _ := os.ReadFile(GetTodoFilePath("auth"))
+
+	bundle, err := ReadBundle(path)
+	if err != nil {
+		t.Fatalf("ReadBundle failed: %v", err)
+	}
+	if bundle.List != "auth" {
+		t.Errorf("Expected list 'auth', got %q", bundle.List)
+	}
+
+	// The list already exists here
+	if _, err := ImportBundle(bundle, ""); err == nil {
+		t.Error("Import should not overwrite an existing list")
+	}
+
+	name, err := ImportBundle(bundle, "login")
+	if err != nil {
+		t.Fatalf("ImportBundle failed: %v", err)
+	}
+	if name != "login" {
+		t.Errorf("Expected list 'login', got %q", name)
+	}
+	imported, _ := os.ReadFile(GetTodoFilePath("login"))
+	if want := strings.Replace(string(original), "# Todo List for auth", "# Todo List for login", 1); string(imported) != want {
+		t.Errorf("Imported list differs:\n%s\nwant:\n%s", imported, want)
+	}
+	archive, _ := parseTodoFileAt(GetArchiveFilePath("login"))
+	if len(archive.Items) != 1 || archive.Items[0].Text != "Old item" {
+		t.Errorf("Expected the archive to be imported, got %+v", archive.Items)
+	}
+
+	// Importing into a fresh directory keeps the original name
+	os.Chdir(t.TempDir())
+	if name, err := ImportBundle(bundle, ""); err != nil || name != "auth" {
+		t.Fatalf("ImportBundle failed: %q %v", name, err)
+	}
+	imported, _ = os.ReadFile(GetTodoFilePath("auth"))
+	if string(imported) != string(original) {
+		t.Errorf("Imported list differs:\n%s\nwant:\n%s", imported, original)
+	}
+}
+
+func TestReadBundleErrors(t *testing.T) {
+	dir := setupTestDir(t)
+
+	write := func(content string) string {
+		path := filepath.Join(dir, "test.todo")
+		os.WriteFile(path, []byte(content), 0644)
+		return path
+	}
+
+	for _, content := range []string{
+		"# Todo List for auth\n",
+		`{"format": "other", "version": 1, "list": "auth"}`,
+		`{"format": "todo-bundle", "version": 2, "list": "auth"}`,
+		`{"format": "todo-bundle", "version": 1}`,
+	} {
+		if _, err := ReadBundle(write(content)); err == nil {
+			t.Errorf("Expected an error reading %s", content)
+		}
+	}
+
+	bundle, err := ReadBundle(write(`{"format": "todo-bundle", "version": 1, "list": "../escape"}`))
+	if err != nil {
+		t.Fatalf("ReadBundle failed: %v", err)
+	}
+	if _, err := ImportBundle(bundle, ""); err == nil {
+		t.Error("Expected an error for a list name outside .todo")
+	}
+}
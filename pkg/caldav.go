@@ -0,0 +1,464 @@
+package pkg
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// metaCalDAV is the metadata key holding the UID of the CalDAV task an item
+// is linked to
+const metaCalDAV = "caldav"
+
+// caldavSyncName names the CalDAV provider's sync state and offline queue
+const caldavSyncName = "caldav"
+
+// Lists are mapped to a CalDAV task collection by the caldav key of their
+// frontmatter. Items are linked to the collection's VTODOs by UID and synced
+// as described in syncitems.go, with titles, completion and due dates
+// following on both sides. Since UIDs may contain slashes, they are escaped
+// in snapshot keys. The state's IDs map snapshot keys to the task's URL.
+
+type caldavSyncProvider struct {
+	*itemSync
+	client *APIClient
+	// lists maps list names to their collection URL
+	lists map[string]string
+}
+
+// caldavTodo is a VTODO of a collection
+type caldavTodo struct {
+	UID  string
+	Href string
+	Item syncedItem
+}
+
+func newCalDAVSyncProvider(cfg *Config) (SyncProvider, error) {
+	lists, err := CalDAVLists()
+	if err != nil {
+		return nil, err
+	}
+	if len(lists) == 0 {
+		return nil, fmt.Errorf("no lists are mapped to CalDAV; run 'todo list <name> --caldav <collection-url>'")
+	}
+
+	credential, err := GetCredential("caldav")
+	if err != nil {
+		return nil, err
+	}
+	client := NewAPIClient("", 5)
+	// Servers take the account's user name and an app password; a bare
+	// token is sent as a bearer token
+	if strings.Contains(credential, ":") {
+		client.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credential)))
+	} else {
+		client.Header.Set("Authorization", "Bearer "+credential)
+	}
+	p := &caldavSyncProvider{client: client, lists: lists}
+	p.itemSync = &itemSync{
+		name:      caldavSyncName,
+		service:   "the CalDAV server",
+		metaKey:   metaCalDAV,
+		withDue:   true,
+		lists:     slices.Sorted(maps.Keys(lists)),
+		key:       caldavSyncKey,
+		splitKey:  splitCalDAVSyncKey,
+		fetch:     p.fetch,
+		operation: caldavOperation,
+		send:      p.send,
+	}
+	return p, nil
+}
+
+// CalDAVLists returns the lists mapped to a CalDAV collection, with their
+// collection URL
+func CalDAVLists() (map[string]string, error) {
+	names, err := GetAllLists()
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make(map[string]string)
+	for _, listName := range names {
+		todoList, err := ParseTodoFile(listName)
+		if err != nil {
+			return nil, err
+		}
+		if todoList.Meta.CalDAV != "" {
+			lists[listName] = todoList.Meta.CalDAV
+		}
+	}
+	return lists, nil
+}
+
+func caldavSyncKey(listName, uid string) string {
+	return itemSyncKey(listName, url.PathEscape(uid))
+}
+
+func splitCalDAVSyncKey(key string) (string, string) {
+	listName, escaped := splitItemSyncKey(key)
+	uid, err := url.PathUnescape(escaped)
+	if err != nil {
+		uid = escaped
+	}
+	return listName, uid
+}
+
+// caldavPayload is the data of a queued operation
+type caldavPayload struct {
+	UID     string `json:"uid"`
+	Content string `json:"content"`
+}
+
+func caldavOperation(action, listName, uid, content, remote string) SyncOperation {
+	// The UID of a new task is chosen here, so it is kept while the create
+	// is queued
+	if action == SyncAdd && uid == "" {
+		uid = newCalDAVUID()
+	}
+	payload, _ := json.Marshal(caldavPayload{UID: uid, Content: content})
+	id := action + ":" + caldavSyncKey(listName, uid)
+	if action == SyncAdd {
+		id = action + ":" + itemSyncKey(listName, content)
+	}
+	return SyncOperation{ID: id, Kind: action, List: listName, Payload: payload}
+}
+
+// newCalDAVUID returns a UID for a new task
+func newCalDAVUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b) + "@todo-cli"
+}
+
+// send carries out a queued operation against the list's collection
+func (p *caldavSyncProvider) send(ctx context.Context, state *SyncState, op SyncOperation) error {
+	var payload caldavPayload
+	if err := json.Unmarshal(op.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid queued operation %s: %w", op.ID, err)
+	}
+	collection, ok := p.lists[op.List]
+	if !ok {
+		return fmt.Errorf("list '%s' is no longer mapped to CalDAV", op.List)
+	}
+	synced := decodeSyncedItem(payload.Content)
+	key := caldavSyncKey(op.List, payload.UID)
+	now := time.Now()
+
+	switch op.Kind {
+	case SyncAdd:
+		href, err := resolveCalDAVHref(collection, url.PathEscape(payload.UID)+".ics")
+		if err != nil {
+			return err
+		}
+		// If-None-Match keeps a create from overwriting a task; one that
+		// already exists was created by an earlier attempt
+		header := http.Header{"Content-Type": {"text/calendar; charset=utf-8"}, "If-None-Match": {"*"}}
+		if _, err := p.client.SendContext(ctx, "PUT", href, header, []byte(newVTODO(payload.UID, synced, now))); err != nil && !isHTTPStatus(err, http.StatusPreconditionFailed) {
+			return err
+		}
+		state.IDs[key] = href
+		state.Base[key] = payload.Content
+		return linkSyncedItem(op.List, metaCalDAV, synced.Title, payload.UID)
+	case SyncUpdate:
+		href := state.IDs[key]
+		if href == "" {
+			return fmt.Errorf("no CalDAV task is known for %s", key)
+		}
+		data, err := p.client.SendContext(ctx, "GET", href, http.Header{"Accept": {"text/calendar"}}, nil)
+		if err != nil {
+			return err
+		}
+		header := http.Header{"Content-Type": {"text/calendar; charset=utf-8"}}
+		if _, err := p.client.SendContext(ctx, "PUT", href, header, []byte(patchVTODO(string(data), synced, now))); err != nil {
+			return err
+		}
+		state.Base[key] = payload.Content
+	case SyncDelete:
+		if href := state.IDs[key]; href != "" {
+			if _, err := p.client.SendContext(ctx, "DELETE", href, nil, nil); err != nil && !isHTTPStatus(err, http.StatusNotFound) {
+				return err
+			}
+		}
+		delete(state.Base, key)
+		delete(state.IDs, key)
+	default:
+		return fmt.Errorf("unknown operation %q", op.Kind)
+	}
+	return nil
+}
+
+// isHTTPStatus reports whether err is an API response with the given status
+func isHTTPStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// fetch adds the tasks of a list's collection to the remote snapshot,
+// keeping their URLs
+func (p *caldavSyncProvider) fetch(ctx context.Context, listName string, snap *itemSnapshot) error {
+	todos, err := p.todos(ctx, p.lists[listName])
+	if err != nil {
+		return err
+	}
+	for _, todo := range todos {
+		key := caldavSyncKey(listName, todo.UID)
+		snap.remote[key] = todo.Item.encode()
+		snap.ids[key] = todo.Href
+	}
+	return nil
+}
+
+const caldavTodoQuery = `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <d:getetag/>
+    <c:calendar-data/>
+  </d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="VTODO"/>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`
+
+// todos returns the VTODOs of a collection
+func (p *caldavSyncProvider) todos(ctx context.Context, collection string) ([]caldavTodo, error) {
+	header := http.Header{"Depth": {"1"}, "Content-Type": {"application/xml; charset=utf-8"}}
+	body, err := p.client.SendContext(ctx, "REPORT", collection, header, []byte(caldavTodoQuery))
+	if err != nil {
+		return nil, err
+	}
+
+	var multistatus struct {
+		Responses []struct {
+			Href     string `xml:"href"`
+			Propstat []struct {
+				Status string `xml:"status"`
+				Prop   struct {
+					CalendarData string `xml:"calendar-data"`
+				} `xml:"prop"`
+			} `xml:"propstat"`
+		} `xml:"response"`
+	}
+	if err := xml.Unmarshal(body, &multistatus); err != nil {
+		return nil, fmt.Errorf("failed to decode CalDAV response: %w", err)
+	}
+
+	var todos []caldavTodo
+	for _, response := range multistatus.Responses {
+		for _, propstat := range response.Propstat {
+			if propstat.Prop.CalendarData == "" {
+				continue
+			}
+			uid, item, ok := parseVTODO(propstat.Prop.CalendarData)
+			if !ok {
+				continue
+			}
+			href, err := resolveCalDAVHref(collection, response.Href)
+			if err != nil {
+				return nil, err
+			}
+			todos = append(todos, caldavTodo{UID: uid, Href: href, Item: item})
+		}
+	}
+	return todos, nil
+}
+
+// resolveCalDAVHref turns an href from the server, usually an absolute
+// path, into a URL
+func resolveCalDAVHref(collection, href string) (string, error) {
+	base, err := url.Parse(strings.TrimSuffix(collection, "/") + "/")
+	if err != nil {
+		return "", fmt.Errorf("invalid CalDAV collection URL %q: %w", collection, err)
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("invalid href %q from the CalDAV server: %w", href, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// icalProperty is the name and value of a content line
+type icalProperty struct {
+	Name  string
+	Value string
+}
+
+// icalLines unfolds an iCalendar object into its content lines
+func icalLines(data string) []string {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\n ", "")
+	data = strings.ReplaceAll(data, "\n\t", "")
+	var lines []string
+	for _, line := range strings.Split(data, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseICalProperty splits a content line into its name and value, dropping
+// any parameters. The value starts at the first colon outside quoted
+// parameters.
+func parseICalProperty(line string) icalProperty {
+	quoted := false
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ':' && !quoted:
+			name, _, _ := strings.Cut(line[:i], ";")
+			return icalProperty{Name: strings.ToUpper(name), Value: line[i+1:]}
+		}
+	}
+	return icalProperty{Name: strings.ToUpper(line)}
+}
+
+// unescapeICalText reverses escapeICalText
+func unescapeICalText(text string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(text)
+}
+
+// parseICalDate returns the date of a DATE or DATE-TIME value as
+// YYYY-MM-DD. UTC times are read in local time.
+func parseICalDate(value string) string {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t.Local().Format(DueDateFormat)
+	}
+	if len(value) >= 8 {
+		if t, err := time.Parse("20060102", value[:8]); err == nil {
+			return t.Format(DueDateFormat)
+		}
+	}
+	return ""
+}
+
+// parseVTODO reads the UID and the synced fields of the first VTODO of an
+// iCalendar object
+func parseVTODO(data string) (string, syncedItem, bool) {
+	var uid string
+	var item syncedItem
+	depth, found := 0, false
+	for _, line := range icalLines(data) {
+		prop := parseICalProperty(line)
+		switch {
+		case prop.Name == "BEGIN" && strings.EqualFold(prop.Value, "VTODO") && !found:
+			depth, found = 1, true
+			continue
+		case prop.Name == "BEGIN" && depth > 0:
+			depth++
+			continue
+		case prop.Name == "END" && depth > 0:
+			depth--
+			continue
+		}
+		// Only properties of the VTODO itself, not of its alarms
+		if depth != 1 {
+			continue
+		}
+
+		switch prop.Name {
+		case "UID":
+			uid = prop.Value
+		case "SUMMARY":
+			item.Title = unescapeICalText(prop.Value)
+		case "STATUS":
+			item.Done = strings.EqualFold(prop.Value, "COMPLETED")
+		case "COMPLETED":
+			item.Done = true
+		case "DUE":
+			item.Due = parseICalDate(prop.Value)
+		}
+	}
+	return uid, item, found && uid != ""
+}
+
+// newVTODO returns an iCalendar object holding a task for an item
+func newVTODO(uid string, item syncedItem, now time.Time) string {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\n")
+	b.WriteString("VERSION:2.0\r\n")
+	b.WriteString("PRODID:-//todo-cli//caldav//EN\r\n")
+	b.WriteString("BEGIN:VTODO\r\n")
+	writeICalLine(&b, "UID:"+uid)
+	b.WriteString("CREATED:" + now.UTC().Format("20060102T150405Z") + "\r\n")
+	for _, line := range vtodoLines(item, now, true, true, true) {
+		writeICalLine(&b, line)
+	}
+	b.WriteString("END:VTODO\r\n")
+	b.WriteString("END:VCALENDAR\r\n")
+	return b.String()
+}
+
+// vtodoLines returns the content lines for the synced fields of a task,
+// leaving out completion and due date unless they are to be written
+func vtodoLines(item syncedItem, now time.Time, withDone, withDue, withSummary bool) []string {
+	stamp := now.UTC().Format("20060102T150405Z")
+	lines := []string{"DTSTAMP:" + stamp, "LAST-MODIFIED:" + stamp}
+	if withSummary {
+		lines = append(lines, "SUMMARY:"+escapeICalText(item.Title))
+	}
+	if withDone {
+		if item.Done {
+			lines = append(lines, "STATUS:COMPLETED", "COMPLETED:"+stamp, "PERCENT-COMPLETE:100")
+		} else {
+			lines = append(lines, "STATUS:NEEDS-ACTION")
+		}
+	}
+	if withDue {
+		if due, err := time.Parse(DueDateFormat, item.Due); err == nil {
+			lines = append(lines, "DUE;VALUE=DATE:"+due.Format("20060102"))
+		}
+	}
+	return lines
+}
+
+// patchVTODO updates the task of an iCalendar object to match an item.
+// Fields that already match are kept as they are, so details the CLI doesn't
+// know about, such as an in-process status or a due time, survive.
+func patchVTODO(data string, item syncedItem, now time.Time) string {
+	_, current, _ := parseVTODO(data)
+	withSummary := current.Title != item.Title
+	withDone := current.Done != item.Done
+	withDue := current.Due != item.Due
+
+	replaced := map[string]bool{"DTSTAMP": true, "LAST-MODIFIED": true, "SUMMARY": withSummary, "STATUS": withDone, "COMPLETED": withDone, "PERCENT-COMPLETE": withDone, "DUE": withDue}
+
+	var b strings.Builder
+	depth, patched := 0, false
+	for _, line := range icalLines(data) {
+		prop := parseICalProperty(line)
+		switch {
+		case prop.Name == "BEGIN" && strings.EqualFold(prop.Value, "VTODO") && !patched:
+			depth = 1
+			writeICalLine(&b, line)
+			continue
+		case prop.Name == "BEGIN" && depth > 0:
+			depth++
+		case prop.Name == "END" && depth > 1:
+			depth--
+		case prop.Name == "END" && depth == 1:
+			for _, line := range vtodoLines(item, now, withDone, withDue, withSummary) {
+				writeICalLine(&b, line)
+			}
+			depth, patched = 0, true
+		case depth == 1 && replaced[prop.Name]:
+			continue
+		}
+		writeICalLine(&b, line)
+	}
+	return b.String()
+}
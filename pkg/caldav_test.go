@@ -0,0 +1,231 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeCalDAV serves one task collection at /tasks/, keeping each task's
+// iCalendar data by path
+type fakeCalDAV struct {
+	tasks map[string]string
+}
+
+func (f *fakeCalDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, password, ok := r.BasicAuth(); !ok || user != "me" || password != "secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case "REPORT":
+		if r.URL.Path != "/tasks/" || r.Header.Get("Depth") != "1" {
+			http.Error(w, "unexpected report", http.StatusBadRequest)
+			return
+		}
+		var paths []string
+		for path := range f.tasks {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		var b strings.Builder
+		b.WriteString(`<?xml version="1.0"?><d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">`)
+		for _, path := range paths {
+			fmt.Fprintf(&b, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:getetag>"1"</d:getetag><cal:calendar-data>%s</cal:calendar-data></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, path, strings.ReplaceAll(f.tasks[path], "&", "&amp;"))
+		}
+		b.WriteString(`</d:multistatus>`)
+		w.WriteHeader(http.StatusMultiStatus)
+		io.WriteString(w, b.String())
+	case "GET":
+		data, ok := f.tasks[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, data)
+	case "PUT":
+		if _, ok := f.tasks[r.URL.Path]; ok && r.Header.Get("If-None-Match") == "*" {
+			http.Error(w, "exists", http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.tasks[r.URL.Path] = string(body)
+		w.WriteHeader(http.StatusCreated)
+	case "DELETE":
+		delete(f.tasks, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unexpected method", http.StatusMethodNotAllowed)
+	}
+}
+
+func caldavTask(uid, summary, status, due string) string {
+	lines := []string{"BEGIN:VCALENDAR", "VERSION:2.0", "BEGIN:VTODO", "UID:" + uid, "SUMMARY:" + summary, "STATUS:" + status}
+	if due != "" {
+		lines = append(lines, "DUE;VALUE=DATE:"+due)
+	}
+	lines = append(lines, "BEGIN:VALARM", "ACTION:DISPLAY", "SUMMARY:Reminder", "END:VALARM", "END:VTODO", "END:VCALENDAR")
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func setupCalDAV(t *testing.T) *fakeCalDAV {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	t.Setenv("TODO_CALDAV_TOKEN", "me:secret")
+
+	fake := &fakeCalDAV{tasks: make(map[string]string)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	CreateTodoFile("groceries")
+	if err := SetListCalDAV("groceries", server.URL+"/tasks/"); err != nil {
+		t.Fatalf("SetListCalDAV failed: %v", err)
+	}
+	return fake
+}
+
+func TestCalDAVSync(t *testing.T) {
+	fake := setupCalDAV(t)
+	fake.tasks["/tasks/a.ics"] = caldavTask("a", `Milk\, oat`, "NEEDS-ACTION", "20240901")
+	fake.tasks["/tasks/b.ics"] = caldavTask("b", "Bread", "COMPLETED", "")
+	AddTodoItem("groceries", "Eggs")
+
+	provider, err := GetSyncProvider("caldav")
+	if err != nil {
+		t.Fatalf("GetSyncProvider failed: %v", err)
+	}
+
+	// Pulling adds the tasks as items linked to them
+	changes, err := provider.Pull(context.Background(), SyncOptions{})
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if len(changes) != 2 || changes[0].Action != SyncAdd || changes[0].Detail != "Milk, oat" {
+		t.Fatalf("Unexpected pulled changes: %+v", changes)
+	}
+	todoList, _ := ParseTodoFile("groceries")
+	if len(todoList.Items) != 3 || todoList.Items[1].Metadata[metaCalDAV] != "a" || !todoList.Items[2].Completed {
+		t.Fatalf("Unexpected list after pull: %+v", todoList.Items)
+	}
+	if due := todoList.Items[1].DueDate; due == nil || due.Format(DueDateFormat) != "2024-09-01" {
+		t.Errorf("Expected the task's due date, got %v", due)
+	}
+
+	// Pushing creates tasks for unlinked items and links them
+	if _, err := provider.Push(context.Background(), SyncOptions{}); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if len(fake.tasks) != 3 {
+		t.Fatalf("Expected a task for the new item, got %v", fake.tasks)
+	}
+	todoList, _ = ParseTodoFile("groceries")
+	uid := todoList.Items[0].Metadata[metaCalDAV]
+	created := fake.tasks["/tasks/"+uid+".ics"]
+	if uid == "" || !strings.Contains(created, "SUMMARY:Eggs\r\n") || !strings.Contains(created, "STATUS:NEEDS-ACTION\r\n") {
+		t.Fatalf("Expected the item linked to a new task, got %q in %v", uid, fake.tasks)
+	}
+
+	// Checking an item completes its task, keeping what the CLI doesn't sync
+	CheckTodoItem("groceries", 2)
+	if _, err := provider.Push(context.Background(), SyncOptions{}); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	task := fake.tasks["/tasks/a.ics"]
+	if !strings.Contains(task, "STATUS:COMPLETED\r\n") || strings.Contains(task, "NEEDS-ACTION") || !strings.Contains(task, "SUMMARY:Reminder\r\n") || !strings.Contains(task, "DUE;VALUE=DATE:20240901\r\n") {
+		t.Errorf("Unexpected updated task:\n%s", task)
+	}
+
+	// Reopening a task on the server reopens its item; deleting one
+	// removes its item
+	fake.tasks["/tasks/b.ics"] = caldavTask("b", "Bread", "NEEDS-ACTION", "20241001")
+	delete(fake.tasks, "/tasks/a.ics")
+	if _, err := provider.Pull(context.Background(), SyncOptions{}); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	todoList, _ = ParseTodoFile("groceries")
+	if len(todoList.Items) != 2 || todoList.Items[1].Text != "Bread" || todoList.Items[1].Completed || todoList.Items[1].DueDate == nil {
+		t.Errorf("Unexpected list after pull: %+v", todoList.Items)
+	}
+
+	// Removing an item deletes its task
+	todoList.Items = todoList.Items[1:]
+	WriteTodoFile("groceries", todoList)
+	if _, err := provider.Push(context.Background(), SyncOptions{}); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if _, ok := fake.tasks["/tasks/"+uid+".ics"]; ok || len(fake.tasks) != 1 {
+		t.Errorf("Expected the removed item's task to be deleted, got %v", fake.tasks)
+	}
+
+	status, err := provider.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(status.Incoming) != 0 || len(status.Outgoing) != 0 {
+		t.Errorf("Expected everything up to date, got %+v", status)
+	}
+}
+
+func TestCalDAVSyncNoLists(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	CreateTodoFile("groceries")
+
+	if _, err := GetSyncProvider("caldav"); err == nil || !strings.Contains(err.Error(), "--caldav") {
+		t.Errorf("Expected an error about unmapped lists, got %v", err)
+	}
+	if err := SetListCalDAV("groceries", "ftp://example.com/tasks"); err == nil {
+		t.Error("Expected an error for a non-HTTP collection URL")
+	}
+}
+
+func TestParseVTODO(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:x/1\r\nSUMMARY;LANGUAGE=en:Call \"Ann\\; Bob\" abo\r\n ut the trip\r\nDUE;TZID=Europe/Paris:20240315T090000\r\nCOMPLETED:20240310T120000Z\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	uid, item, ok := parseVTODO(data)
+	if !ok || uid != "x/1" {
+		t.Fatalf("parseVTODO() = %q, %v", uid, ok)
+	}
+	want := syncedItem{Title: `Call "Ann; Bob" about the trip`, Done: true, Due: "2024-03-15"}
+	if item != want {
+		t.Errorf("parseVTODO() = %+v, want %+v", item, want)
+	}
+
+	if _, _, ok := parseVTODO("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:e\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"); ok {
+		t.Error("An object without a VTODO should not parse")
+	}
+
+	// Keys escape UIDs so they survive splitting
+	listName, got := splitCalDAVSyncKey(caldavSyncKey("work/auth", "x/1"))
+	if listName != "work/auth" || got != "x/1" {
+		t.Errorf("splitCalDAVSyncKey() = %q, %q", listName, got)
+	}
+}
+
+func TestPatchVTODO(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	data := caldavTask("a", "Milk", "IN-PROCESS", "20240901")
+
+	// Unchanged fields are kept as they were
+	patched := patchVTODO(data, syncedItem{Title: "Oat milk", Due: "2024-09-01"}, now)
+	for _, want := range []string{"SUMMARY:Oat milk\r\n", "STATUS:IN-PROCESS\r\n", "DUE;VALUE=DATE:20240901\r\n", "SUMMARY:Reminder\r\n", "DTSTAMP:20240301T120000Z\r\n"} {
+		if !strings.Contains(patched, want) {
+			t.Errorf("Expected %q in the patched task:\n%s", want, patched)
+		}
+	}
+	if _, item, _ := parseVTODO(patched); item != (syncedItem{Title: "Oat milk", Due: "2024-09-01"}) {
+		t.Errorf("Patched task parses as %+v", item)
+	}
+
+	patched = patchVTODO(data, syncedItem{Title: "Milk", Done: true}, now)
+	if _, item, _ := parseVTODO(patched); item != (syncedItem{Title: "Milk", Done: true}) || strings.Contains(patched, "IN-PROCESS") {
+		t.Errorf("Expected a completed task without a due date:\n%s", patched)
+	}
+}
@@ -0,0 +1,119 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The clock is where everything the user sees as a date comes from: check
+// timestamps, the day items are added, due dates relative to today, snoozes,
+// recurrences, timers and statistics. Tests swap it for a SimulatedClock, and
+// the --now flag starts it at another time. Timeouts, rate limits and sync
+// bookkeeping use the real time.
+
+// Clock tells the time
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// offsetClock runs at the real rate from a time other than now
+type offsetClock struct {
+	offset time.Duration
+}
+
+func (c offsetClock) Now() time.Time {
+	return time.Now().Add(c.offset)
+}
+
+// ClockStartingAt returns a clock that reads start now and keeps running
+// from there
+func ClockStartingAt(start time.Time) Clock {
+	return offsetClock{offset: time.Until(start)}
+}
+
+// SimulatedClock only moves when told to
+type SimulatedClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewSimulatedClock(now time.Time) *SimulatedClock {
+	return &SimulatedClock{now: now}
+}
+
+func (c *SimulatedClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set moves the clock to a time
+func (c *SimulatedClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance moves the clock on by d
+func (c *SimulatedClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+var (
+	clockMu sync.RWMutex
+	clock   Clock = systemClock{}
+)
+
+// Now returns the current time on the clock
+func Now() time.Time {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return clock.Now()
+}
+
+// SetClock replaces the clock, returning a function that puts the previous
+// one back
+func SetClock(c Clock) (restore func()) {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	previous := clock
+	clock = c
+	return func() {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+		clock = previous
+	}
+}
+
+// realClock reports whether the clock is the system's own
+func realClock() bool {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	_, ok := clock.(systemClock)
+	return ok
+}
+
+// nowLayouts are the forms --now accepts
+var nowLayouts = []string{time.RFC3339, "2006-01-02T15:04", completedTimeFormat, DueDateFormat}
+
+// ParseNow reads the time given to --now: a date, a date and time, or an
+// RFC 3339 timestamp. Times without a zone are local.
+func ParseNow(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range nowLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s' (use YYYY-MM-DD or YYYY-MM-DD HH:MM)", value)
+}
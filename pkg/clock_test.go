@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimulatedClock(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("chores")
+	cfg := DefaultConfig()
+	cfg.Insights = true
+	SaveConfig(cfg)
+
+	start := time.Date(2024, 7, 1, 9, 0, 0, 0, time.Local)
+	simulated := NewSimulatedClock(start)
+	restore := SetClock(simulated)
+	defer restore()
+
+	store := NewStore()
+	id, _ := store.AddItem("chores", "Water plants")
+	store.SetRecurrence("chores", id, Recurrence{N: 1, Unit: "w"})
+	due := start
+	store.SetDue("chores", id, &due)
+
+	simulated.Advance(26*time.Hour + 30*time.Minute)
+	store.CheckItem("chores", id)
+	store.Flush()
+
+	todoList, _ := ParseTodoFile("chores")
+	if len(todoList.Items) != 2 {
+		t.Fatalf("Expected the next occurrence, got %+v", todoList.Items)
+	}
+	checked := todoList.Items[0]
+	added, _ := ItemAdded(checked)
+	if checked.CompletedTime == nil || checked.CompletedTime.Format(completedTimeFormat) != "2024-07-02 11:30" || !added.Equal(day("2024-07-01")) {
+		t.Errorf("Expected the clock's times, got %+v", checked)
+	}
+	if next := todoList.Items[1]; next.DueDate == nil || next.DueDate.Format(DueDateFormat) != "2024-07-08" {
+		t.Errorf("Expected the next occurrence due a week later, got %+v", next)
+	}
+
+	restore()
+	if time.Since(Now()) > time.Minute || !realClock() {
+		t.Error("Expected restore to bring back the real clock")
+	}
+}
+
+func TestClockStartingAt(t *testing.T) {
+	start := time.Date(2024, 7, 1, 9, 0, 0, 0, time.Local)
+	clock := ClockStartingAt(start)
+	if now := clock.Now(); now.Before(start) || now.Sub(start) > time.Minute {
+		t.Errorf("Expected the clock to start at %v, got %v", start, now)
+	}
+}
+
+func TestParseNow(t *testing.T) {
+	tests := map[string]time.Time{
+		"2024-07-01":           time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local),
+		"2024-07-01 09:30":     time.Date(2024, 7, 1, 9, 30, 0, 0, time.Local),
+		"2024-07-01T09:30":     time.Date(2024, 7, 1, 9, 30, 0, 0, time.Local),
+		"2024-07-01T09:30:00Z": time.Date(2024, 7, 1, 9, 30, 0, 0, time.UTC),
+	}
+	for input, want := range tests {
+		if got, err := ParseNow(input); err != nil || !got.Equal(want) {
+			t.Errorf("ParseNow(%q) = %v, %v, want %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseNow("tomorrow"); err == nil {
+		t.Error("Expected an error for an unsupported time")
+	}
+}
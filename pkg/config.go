@@ -0,0 +1,374 @@
+package pkg
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the settings stored in .todo/config.yaml. LoadConfig layers
+// them over the user's defaults in ~/.config/todo/config.yaml, and
+// environment variables override both; flags given to a command take
+// precedence over all three.
+type Config struct {
+	DefaultList    string          `yaml:"default_list,omitempty"`
+	Visibility     string          `yaml:"visibility,omitempty"`
+	BranchTracking bool            `yaml:"branch_tracking,omitempty"`
+	Hooks          bool            `yaml:"hooks,omitempty"`
+	Git            string          `yaml:"git,omitempty"`
+	Storage        string          `yaml:"storage,omitempty"`
+	ArchiveOnMerge string          `yaml:"archive_on_merge,omitempty"`
+	SyncRemote     string          `yaml:"sync_remote,omitempty"`
+	SyncBranch     string          `yaml:"sync_branch,omitempty"`
+	ListMatching   string          `yaml:"list_matching,omitempty"`
+	IdleThreshold  string          `yaml:"idle_threshold,omitempty"`
+	Audit          bool            `yaml:"audit,omitempty"`
+	AutoCommit     bool            `yaml:"auto_commit,omitempty"`
+	Insights       bool            `yaml:"insights,omitempty"`
+	Display        DisplayConfig   `yaml:"display,omitempty"`
+	Notify         NotifyConfig    `yaml:"notify,omitempty"`
+	Confirm        ConfirmConfig   `yaml:"confirm,omitempty"`
+	Linear         LinearConfig    `yaml:"linear,omitempty"`
+	Health         HealthConfig    `yaml:"health,omitempty"`
+	Breakdown      BreakdownConfig `yaml:"breakdown,omitempty"`
+	Dates          DatesConfig     `yaml:"dates,omitempty"`
+	Calendar       CalendarConfig  `yaml:"calendar,omitempty"`
+
+	// projectCommands names the shell commands set in .todo/config.yaml
+	// that LoadConfig left out, with none of the user's own to run instead
+	projectCommands []string
+}
+
+// DisplayConfig controls how lists are shown
+type DisplayConfig struct {
+	// Numbering is position (the default), section or id
+	Numbering string `yaml:"numbering,omitempty"`
+	// PadNumbers zero-pads item numbers so they line up
+	PadNumbers bool `yaml:"pad_numbers,omitempty"`
+	// Progress is weighted (the default) or raw
+	Progress string `yaml:"progress,omitempty"`
+	// SparklineDays is how many days of progress 'todo list' draws next to
+	// each list (default 14); a negative number turns sparklines off
+	SparklineDays int `yaml:"sparkline_days,omitempty"`
+	// TimeFormat is the Go time layout timestamps are shown in (default
+	// "2006-01-02 15:04"); lists always store them in the default layout
+	TimeFormat string `yaml:"time_format,omitempty"`
+	// Color is auto (the default), always or never: whether the color
+	// renderer writes ANSI escape codes, auto only to a terminal
+	Color string `yaml:"color,omitempty"`
+	// Sort is the order 'todo progress' shows items in without --sort:
+	// position (the default) or priority
+	Sort string `yaml:"sort,omitempty"`
+}
+
+// Color settings
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// TimeLayout returns the layout timestamps are shown in
+func (d DisplayConfig) TimeLayout() string {
+	if d.TimeFormat == "" {
+		return completedTimeFormat
+	}
+	return d.TimeFormat
+}
+
+// ColorEnabled reports whether a color setting turns colors on, auto
+// turning them on when output goes to a terminal
+func ColorEnabled(setting string, terminal bool) bool {
+	switch setting {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	return terminal
+}
+
+// ConfirmConfig controls which destructive operations ask before running
+type ConfirmConfig struct {
+	// Prompts is on (the default) or off. Off answers every confirmation
+	// with yes, for scripts and automation.
+	Prompts string `yaml:"prompts,omitempty"`
+	// Require overrides whether an operation asks for confirmation, keyed
+	// by the operation names in ConfirmDefaults
+	Require map[string]bool `yaml:"require,omitempty"`
+}
+
+// LinearConfig maps lists to the Linear teams and projects they sync with
+type LinearConfig struct {
+	Lists map[string]LinearMapping `yaml:"lists,omitempty"`
+}
+
+// LinearMapping is where a list's items live in Linear
+type LinearMapping struct {
+	// Team is the team key, such as ENG
+	Team string `yaml:"team"`
+	// Project is the name of a project in the team; without one the list
+	// holds the team's issues that have no project
+	Project string `yaml:"project,omitempty"`
+}
+
+// NotifyConfig holds the notification channels and the rules that use them
+type NotifyConfig struct {
+	Channels map[string]NotifyChannel `yaml:"channels,omitempty"`
+	Rules    []NotifyRule             `yaml:"rules,omitempty"`
+}
+
+// NotifyChannel is somewhere notifications are delivered. Secrets such as a
+// Slack webhook URL or SMTP password may be left out and stored with
+// 'todo auth login <channel name>' instead.
+type NotifyChannel struct {
+	// Type is slack, webhook, email or command
+	Type string `yaml:"type"`
+	// URL is the Slack or webhook endpoint
+	URL string `yaml:"url,omitempty"`
+	// To, From, SMTP (host:port) and Username configure email
+	To       string `yaml:"to,omitempty"`
+	From     string `yaml:"from,omitempty"`
+	SMTP     string `yaml:"smtp,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	// Command is run through the shell with the message on stdin
+	Command string `yaml:"command,omitempty"`
+}
+
+// NotifyRule sends a notification to a channel when a list meets a condition
+type NotifyRule struct {
+	// List is the list the rule watches, or "*" for every list
+	List string `yaml:"list"`
+	// When is the condition: complete, overdue or "progress >= N"
+	When string `yaml:"when"`
+	// Notify names the channel to send to
+	Notify string `yaml:"notify"`
+	// To is "reviewers" to address the notification to the reviewers in
+	// the list's frontmatter, skipping lists without any
+	To string `yaml:"to,omitempty"`
+}
+
+// DefaultConfig returns the settings used when no config file exists
+func DefaultConfig() *Config {
+	return &Config{
+		DefaultList:    "main",
+		ArchiveOnMerge: ArchiveOnMergePrompt,
+		SyncRemote:     "origin",
+		SyncBranch:     "todo-data",
+	}
+}
+
+func GetConfigPath() string {
+	return filepath.Join(".todo", "config.yaml")
+}
+
+// UserConfigPath is where the defaults for every project are kept
+func UserConfigPath() (string, error) {
+	dir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// configEnv lists the environment variables that override settings, in the
+// order they are applied
+var configEnv = []struct {
+	name  string
+	apply func(cfg *Config, value string)
+}{
+	{"TODO_DEFAULT_LIST", func(cfg *Config, value string) { cfg.DefaultList = value }},
+	{"TODO_TIME_FORMAT", func(cfg *Config, value string) { cfg.Display.TimeFormat = value }},
+	// NO_COLOR (https://no-color.org) turns colors off unless TODO_COLOR
+	// asks for them
+	{"NO_COLOR", func(cfg *Config, value string) { cfg.Display.Color = ColorNever }},
+	{"TODO_COLOR", func(cfg *Config, value string) { cfg.Display.Color = value }},
+	{"TODO_SORT", func(cfg *Config, value string) { cfg.Display.Sort = value }},
+	{"TODO_CONFIRM", func(cfg *Config, value string) { cfg.Confirm.Prompts = value }},
+}
+
+// LoadConfig returns the settings in effect: the defaults, overridden by
+// the user's config file, then .todo/config.yaml, then the environment.
+// Missing files are skipped.
+func LoadConfig() (*Config, error) {
+	cfg := DefaultConfig()
+
+	userPath, err := UserConfigPath()
+	if err == nil {
+		err = readConfigFile(userPath, cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	breakdownCommand, channels := cfg.Breakdown.Command, maps.Clone(cfg.Notify.Channels)
+	if err := readConfigFile(GetConfigPath(), cfg); err != nil {
+		return nil, err
+	}
+	cfg.keepUserCommands(breakdownCommand, channels)
+	for _, env := range configEnv {
+		if value := os.Getenv(env.name); value != "" {
+			env.apply(cfg, value)
+		}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// keepUserCommands puts back the shell commands of the user's config over
+// those .todo/config.yaml set. A project's config comes with its code, so
+// running its commands would let anyone who can commit to a repository run
+// anything on the machines of those who clone it.
+func (c *Config) keepUserCommands(breakdownCommand string, channels map[string]NotifyChannel) {
+	keep := func(setting string, project *string, user string) {
+		if *project == user {
+			return
+		}
+		*project = user
+		if user == "" {
+			c.projectCommands = append(c.projectCommands, setting)
+		}
+	}
+
+	keep("breakdown.command", &c.Breakdown.Command, breakdownCommand)
+	for _, name := range slices.Sorted(maps.Keys(c.Notify.Channels)) {
+		channel := c.Notify.Channels[name]
+		keep(channelCommandSetting(name), &channel.Command, channels[name].Command)
+		c.Notify.Channels[name] = channel
+	}
+}
+
+// channelCommandSetting names the command setting of a notify channel
+func channelCommandSetting(name string) string {
+	return "notify.channels." + name + ".command"
+}
+
+// ProjectCommandError explains that a command setting is empty because only
+// .todo/config.yaml set it, or returns nil when it didn't
+func (c *Config) ProjectCommandError(setting string) error {
+	if !slices.Contains(c.projectCommands, setting) {
+		return nil
+	}
+	path, err := UserConfigPath()
+	if err != nil {
+		path = "your user config"
+	}
+	return fmt.Errorf("%s in %s isn't run, as commands are only taken from your own config; set it in %s to use it", setting, GetConfigPath(), path)
+}
+
+// ChannelCommandError is ProjectCommandError for a notify channel's command
+func (c *Config) ChannelCommandError(name string) error {
+	return c.ProjectCommandError(channelCommandSetting(name))
+}
+
+// LoadProjectConfig reads .todo/config.yaml alone, over the defaults, for
+// commands that change and save it, so the user's defaults and the
+// environment aren't written into the project's settings
+func LoadProjectConfig() (*Config, error) {
+	cfg := DefaultConfig()
+	if err := readConfigFile(GetConfigPath(), cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// readConfigFile reads the settings in a config file over cfg, leaving
+// cfg alone if the file is missing
+func readConfigFile(path string, cfg *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// validate fills in the settings a config file may have cleared and checks
+// the rest
+func (cfg *Config) validate() error {
+	if cfg.DefaultList == "" {
+		cfg.DefaultList = "main"
+	}
+	if cfg.ArchiveOnMerge == "" {
+		cfg.ArchiveOnMerge = ArchiveOnMergePrompt
+	}
+	switch cfg.ArchiveOnMerge {
+	case ArchiveOnMergePrompt, ArchiveOnMergeAuto, ArchiveOnMergeOff:
+	default:
+		return fmt.Errorf("invalid archive_on_merge setting %q (expected %s, %s or %s)", cfg.ArchiveOnMerge, ArchiveOnMergePrompt, ArchiveOnMergeAuto, ArchiveOnMergeOff)
+	}
+	if cfg.Git != "" && cfg.Git != GitAuto && cfg.Git != GitOff {
+		return fmt.Errorf("invalid git setting %q (expected %s or %s)", cfg.Git, GitAuto, GitOff)
+	}
+	if cfg.Storage != "" && cfg.Storage != StorageMarkdown && cfg.Storage != StorageSQLite {
+		return fmt.Errorf("invalid storage setting %q (expected %s or %s)", cfg.Storage, StorageMarkdown, StorageSQLite)
+	}
+	switch cfg.Display.Numbering {
+	case "", NumberingPosition, NumberingSection, NumberingID:
+	default:
+		return fmt.Errorf("invalid display.numbering setting %q (expected %s, %s or %s)", cfg.Display.Numbering, NumberingPosition, NumberingSection, NumberingID)
+	}
+	if cfg.Display.Progress != "" && cfg.Display.Progress != ProgressWeighted && cfg.Display.Progress != ProgressRaw {
+		return fmt.Errorf("invalid display.progress setting %q (expected %s or %s)", cfg.Display.Progress, ProgressWeighted, ProgressRaw)
+	}
+	// A layout without any of the reference time's fields prints itself
+	if layout := cfg.Display.TimeLayout(); time.Date(2001, 12, 31, 23, 59, 58, 0, time.UTC).Format(layout) == layout {
+		return fmt.Errorf("invalid display.time_format setting %q (expected a Go time layout such as %q)", cfg.Display.TimeFormat, completedTimeFormat)
+	}
+	switch cfg.Display.Color {
+	case "", ColorAuto, ColorAlways, ColorNever:
+	default:
+		return fmt.Errorf("invalid display.color setting %q (expected %s, %s or %s)", cfg.Display.Color, ColorAuto, ColorAlways, ColorNever)
+	}
+	if cfg.Display.Sort != "" {
+		if _, err := ParseSortOrder(cfg.Display.Sort); err != nil {
+			return fmt.Errorf("invalid display.sort setting %q (expected %s or %s)", cfg.Display.Sort, SortPosition, SortPriority)
+		}
+	}
+	if cfg.ListMatching != "" && cfg.ListMatching != ListMatchingFold && cfg.ListMatching != ListMatchingExact {
+		return fmt.Errorf("invalid list_matching setting %q (expected %s or %s)", cfg.ListMatching, ListMatchingFold, ListMatchingExact)
+	}
+	if _, err := cfg.IdleTimeout(); err != nil {
+		return err
+	}
+	if err := cfg.Confirm.validate(); err != nil {
+		return err
+	}
+	for list, mapping := range cfg.Linear.Lists {
+		if mapping.Team == "" {
+			return fmt.Errorf("linear.lists.%s needs a team", list)
+		}
+	}
+
+	return nil
+}
+
+// SaveConfig writes the settings to .todo/config.yaml
+func SaveConfig(cfg *Config) error {
+	if err := EnsureTodoDirectory(); err != nil {
+		return fmt.Errorf("failed to create .todo directory: %w", err)
+	}
+
+	content, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	return os.WriteFile(GetConfigPath(), content, 0644)
+}
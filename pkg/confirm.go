@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Confirmation prompt settings
+const (
+	PromptsOn  = "on"
+	PromptsOff = "off"
+)
+
+// Operations that can ask for confirmation
+const (
+	ConfirmDelete    = "delete"
+	ConfirmPrune     = "prune"
+	ConfirmAdopt     = "adopt"
+	ConfirmMergeCase = "merge-case-duplicates"
+	ConfirmTriage    = "triage"
+	ConfirmRemove    = "remove"
+)
+
+// ConfirmDefaults says whether each operation asks for confirmation when the
+// config doesn't say otherwise
+var ConfirmDefaults = map[string]bool{
+	ConfirmDelete:    true,
+	ConfirmPrune:     true,
+	ConfirmAdopt:     false,
+	ConfirmMergeCase: false,
+	ConfirmTriage:    false,
+	ConfirmRemove:    false,
+}
+
+func (c ConfirmConfig) validate() error {
+	if c.Prompts != "" && c.Prompts != PromptsOn && c.Prompts != PromptsOff {
+		return fmt.Errorf("invalid confirm.prompts setting %q (expected %s or %s)", c.Prompts, PromptsOn, PromptsOff)
+	}
+	for operation := range c.Require {
+		if _, ok := ConfirmDefaults[operation]; !ok {
+			var known []string
+			for name := range ConfirmDefaults {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			return fmt.Errorf("unknown operation %q in confirm.require (expected one of %s)", operation, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// NeedsConfirmation reports whether an operation should ask before running
+func (c *Config) NeedsConfirmation(operation string) bool {
+	if c.Confirm.Prompts == PromptsOff {
+		return false
+	}
+	if require, ok := c.Confirm.Require[operation]; ok {
+		return require
+	}
+	return ConfirmDefaults[operation]
+}
@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"os"
+	"testing"
+)
+
+func TestNeedsConfirmation(t *testing.T) {
+	tests := []struct {
+		name      string
+		confirm   ConfirmConfig
+		operation string
+		want      bool
+	}{
+		{"delete asks by default", ConfirmConfig{}, ConfirmDelete, true},
+		{"prune asks by default", ConfirmConfig{}, ConfirmPrune, true},
+		{"triage doesn't ask by default", ConfirmConfig{}, ConfirmTriage, false},
+		{"require turns a prompt on", ConfirmConfig{Require: map[string]bool{ConfirmTriage: true}}, ConfirmTriage, true},
+		{"require turns a prompt off", ConfirmConfig{Require: map[string]bool{ConfirmDelete: false}}, ConfirmDelete, false},
+		{"prompts off wins over require", ConfirmConfig{Prompts: PromptsOff, Require: map[string]bool{ConfirmTriage: true}}, ConfirmTriage, false},
+		{"prompts off skips defaults", ConfirmConfig{Prompts: PromptsOff}, ConfirmDelete, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Confirm: tt.confirm}
+			if got := cfg.NeedsConfirmation(tt.operation); got != tt.want {
+				t.Errorf("NeedsConfirmation(%q) = %v, want %v", tt.operation, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigConfirm(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+
+	os.WriteFile(GetConfigPath(), []byte("confirm:\n  require:\n    triage: true\n    delete: false\n"), 0644)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !cfg.NeedsConfirmation(ConfirmTriage) || cfg.NeedsConfirmation(ConfirmDelete) {
+		t.Errorf("confirm.require not applied: %+v", cfg.Confirm)
+	}
+
+	invalid := []string{
+		"confirm:\n  prompts: sometimes\n",
+		"confirm:\n  require:\n    reset: true\n",
+	}
+	for _, content := range invalid {
+		os.WriteFile(GetConfigPath(), []byte(content), 0644)
+		if _, err := LoadConfig(); err == nil {
+			t.Errorf("LoadConfig should reject %q", content)
+		}
+	}
+}
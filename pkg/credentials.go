@@ -0,0 +1,376 @@
+package pkg
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// Credential backends
+const (
+	CredentialKeychain = "keychain"
+	CredentialFile     = "encrypted-file"
+	CredentialEnv      = "environment"
+)
+
+// keychainService is the service name credentials are stored under in the
+// OS keychain
+const keychainService = "todo-cli"
+
+// ErrCredentialNotFound is returned when no credential is stored for a provider
+var ErrCredentialNotFound = errors.New("no credential stored")
+
+// CredentialPassphrase supplies the passphrase for the encrypted credential
+// file. The default reads TODO_CREDENTIALS_PASSPHRASE; the CLI replaces it
+// with an interactive prompt.
+var CredentialPassphrase = func() (string, error) {
+	if passphrase := os.Getenv("TODO_CREDENTIALS_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	return "", fmt.Errorf("TODO_CREDENTIALS_PASSPHRASE is not set")
+}
+
+// CredentialInfo describes where a provider's credential is stored
+type CredentialInfo struct {
+	Provider string `json:"provider"`
+	Backend  string `json:"backend"`
+}
+
+// userConfigDir returns the per-user directory holding credential files and
+// the workspace registry
+func userConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "todo"), nil
+}
+
+// credentialEnvVar is the environment variable that overrides a provider's
+// stored credential, e.g. TODO_GITHUB_TOKEN
+func credentialEnvVar(provider string) string {
+	return "TODO_" + strings.ToUpper(strings.ReplaceAll(provider, "-", "_")) + "_TOKEN"
+}
+
+// SaveCredential stores a provider's secret in the OS keychain, falling back
+// to the encrypted credential file when no keychain is available. It returns
+// the backend that was used.
+func SaveCredential(provider, secret string) (string, error) {
+	index, err := loadCredentialIndex()
+	if err != nil {
+		return "", err
+	}
+
+	backend := CredentialKeychain
+	if err := keyring.Set(keychainService, provider, secret); err != nil {
+		backend = CredentialFile
+		if err := setFileCredential(provider, secret); err != nil {
+			return "", err
+		}
+	} else if index[provider] == CredentialFile {
+		// Don't leave an older copy behind in the file
+		if err := deleteFileCredential(provider); err != nil {
+			return "", err
+		}
+	}
+
+	index[provider] = backend
+	return backend, saveCredentialIndex(index)
+}
+
+// GetCredential returns a provider's secret from the environment, the OS
+// keychain or the encrypted credential file, in that order
+func GetCredential(provider string) (string, error) {
+	if secret := os.Getenv(credentialEnvVar(provider)); secret != "" {
+		return secret, nil
+	}
+
+	index, err := loadCredentialIndex()
+	if err != nil {
+		return "", err
+	}
+
+	switch index[provider] {
+	case CredentialKeychain:
+		secret, err := keyring.Get(keychainService, provider)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s credential from keychain: %w", provider, err)
+		}
+		return secret, nil
+	case CredentialFile:
+		return getFileCredential(provider)
+	}
+
+	return "", fmt.Errorf("%w for %s; run 'todo auth login %s' or set %s", ErrCredentialNotFound, provider, provider, credentialEnvVar(provider))
+}
+
+// DeleteCredential removes a provider's secret from every backend
+func DeleteCredential(provider string) error {
+	index, err := loadCredentialIndex()
+	if err != nil {
+		return err
+	}
+	if _, ok := index[provider]; !ok {
+		return fmt.Errorf("%w for %s", ErrCredentialNotFound, provider)
+	}
+
+	if index[provider] == CredentialKeychain {
+		if err := keyring.Delete(keychainService, provider); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+			return fmt.Errorf("failed to remove %s credential from keychain: %w", provider, err)
+		}
+	} else if err := deleteFileCredential(provider); err != nil {
+		return err
+	}
+
+	delete(index, provider)
+	return saveCredentialIndex(index)
+}
+
+// CredentialStatus lists the providers with a stored or environment credential
+func CredentialStatus() ([]CredentialInfo, error) {
+	index, err := loadCredentialIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []CredentialInfo
+	for provider, backend := range index {
+		if os.Getenv(credentialEnvVar(provider)) != "" {
+			backend = CredentialEnv
+		}
+		infos = append(infos, CredentialInfo{Provider: provider, Backend: backend})
+	}
+
+	for _, env := range os.Environ() {
+		name := strings.SplitN(env, "=", 2)[0]
+		if !strings.HasPrefix(name, "TODO_") || !strings.HasSuffix(name, "_TOKEN") {
+			continue
+		}
+		provider := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(name, "TODO_"), "_TOKEN"))
+		provider = strings.ReplaceAll(provider, "_", "-")
+		if _, ok := index[provider]; !ok && provider != "" {
+			infos = append(infos, CredentialInfo{Provider: provider, Backend: CredentialEnv})
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Provider < infos[j].Provider })
+	return infos, nil
+}
+
+// The credential index records which backend holds each provider's secret.
+// It contains no secrets itself.
+func credentialIndexPath() (string, error) {
+	dir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials.json"), nil
+}
+
+func loadCredentialIndex() (map[string]string, error) {
+	index := make(map[string]string)
+
+	path, err := credentialIndexPath()
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, nil
+		}
+		return nil, fmt.Errorf("failed to read credential index: %w", err)
+	}
+
+	if err := json.Unmarshal(content, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse credential index: %w", err)
+	}
+	return index, nil
+}
+
+func saveCredentialIndex(index map[string]string) error {
+	path, err := credentialIndexPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	content, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0600)
+}
+
+// encryptedCredentials is the on-disk form of the credential file: a map of
+// provider secrets sealed with AES-GCM under a key derived from the
+// passphrase with PBKDF2
+type encryptedCredentials struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+const pbkdf2Iterations = 600000
+
+func credentialFilePath() (string, error) {
+	dir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials.enc"), nil
+}
+
+func credentialKey(passphrase string, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, 32)
+}
+
+func loadFileCredentials() (map[string]string, string, error) {
+	secrets := make(map[string]string)
+
+	path, err := credentialFilePath()
+	if err != nil {
+		return nil, "", err
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return secrets, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read credential file: %w", err)
+	}
+
+	var sealed encryptedCredentials
+	if err := json.Unmarshal(content, &sealed); err != nil {
+		return nil, "", fmt.Errorf("failed to parse credential file: %w", err)
+	}
+
+	passphrase, err := CredentialPassphrase()
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := credentialKey(passphrase, sealed.Salt)
+	if err != nil {
+		return nil, "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, "", err
+	}
+
+	plaintext, err := gcm.Open(nil, sealed.Nonce, sealed.Data, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decrypt credential file (wrong passphrase?)")
+	}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, "", fmt.Errorf("failed to parse credential file: %w", err)
+	}
+	return secrets, passphrase, nil
+}
+
+func saveFileCredentials(secrets map[string]string, passphrase string) error {
+	path, err := credentialFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	if passphrase == "" {
+		if passphrase, err = CredentialPassphrase(); err != nil {
+			return err
+		}
+	}
+
+	sealed := encryptedCredentials{Salt: make([]byte, 16)}
+	if _, err := rand.Read(sealed.Salt); err != nil {
+		return err
+	}
+	key, err := credentialKey(passphrase, sealed.Salt)
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	sealed.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(sealed.Nonce); err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	sealed.Data = gcm.Seal(nil, sealed.Nonce, plaintext, nil)
+
+	content, err := json.Marshal(sealed)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0600)
+}
+
+func getFileCredential(provider string) (string, error) {
+	secrets, _, err := loadFileCredentials()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[provider]
+	if !ok {
+		return "", fmt.Errorf("%w for %s", ErrCredentialNotFound, provider)
+	}
+	return secret, nil
+}
+
+func setFileCredential(provider, secret string) error {
+	secrets, passphrase, err := loadFileCredentials()
+	if err != nil {
+		return err
+	}
+	secrets[provider] = secret
+	return saveFileCredentials(secrets, passphrase)
+}
+
+func deleteFileCredential(provider string) error {
+	path, err := credentialFilePath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	secrets, passphrase, err := loadFileCredentials()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[provider]; !ok {
+		return nil
+	}
+	delete(secrets, provider)
+	return saveFileCredentials(secrets, passphrase)
+}
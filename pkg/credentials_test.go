@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func setupCredentialsTest(t *testing.T) {
+	home := setupTestDir(t)
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("TODO_CREDENTIALS_PASSPHRASE", "correct horse battery staple")
+}
+
+func TestCredentialsInKeychain(t *testing.T) {
+	setupCredentialsTest(t)
+	keyring.MockInit()
+
+	backend, err := SaveCredential("github", "ghp_secret")
+	if err != nil {
+		t.Fatalf("SaveCredential failed: %v", err)
+	}
+	if backend != CredentialKeychain {
+		t.Errorf("backend = %q, want %q", backend, CredentialKeychain)
+	}
+
+	secret, err := GetCredential("github")
+	if err != nil || secret != "ghp_secret" {
+		t.Errorf("GetCredential = %q, %v; want %q", secret, err, "ghp_secret")
+	}
+
+	infos, _ := CredentialStatus()
+	if len(infos) != 1 || infos[0].Provider != "github" || infos[0].Backend != CredentialKeychain {
+		t.Errorf("Unexpected status: %+v", infos)
+	}
+
+	if err := DeleteCredential("github"); err != nil {
+		t.Fatalf("DeleteCredential failed: %v", err)
+	}
+	if _, err := GetCredential("github"); !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("Expected ErrCredentialNotFound after delete, got %v", err)
+	}
+}
+
+func TestCredentialsFallBackToEncryptedFile(t *testing.T) {
+	setupCredentialsTest(t)
+	keyring.MockInitWithError(errors.New("no secret service"))
+
+	backend, err := SaveCredential("jira", "jira-token")
+	if err != nil {
+		t.Fatalf("SaveCredential failed: %v", err)
+	}
+	if backend != CredentialFile {
+		t.Errorf("backend = %q, want %q", backend, CredentialFile)
+	}
+
+	// The secret never appears in plaintext on disk
+	path, _ := credentialFilePath()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read credential file: %v", err)
+	}
+	if strings.Contains(string(content), "jira-token") {
+		t.Error("Credential file contains the plaintext secret")
+	}
+
+	secret, err := GetCredential("jira")
+	if err != nil || secret != "jira-token" {
+		t.Errorf("GetCredential = %q, %v; want %q", secret, err, "jira-token")
+	}
+
+	t.Setenv("TODO_CREDENTIALS_PASSPHRASE", "wrong")
+	if _, err := GetCredential("jira"); err == nil {
+		t.Error("GetCredential should fail with the wrong passphrase")
+	}
+}
+
+func TestCredentialFromEnvironment(t *testing.T) {
+	setupCredentialsTest(t)
+	keyring.MockInit()
+	t.Setenv("TODO_GITHUB_TOKEN", "from-env")
+
+	secret, err := GetCredential("github")
+	if err != nil || secret != "from-env" {
+		t.Errorf("GetCredential = %q, %v; want %q", secret, err, "from-env")
+	}
+
+	infos, _ := CredentialStatus()
+	if len(infos) != 1 || infos[0].Backend != CredentialEnv {
+		t.Errorf("Unexpected status: %+v", infos)
+	}
+}
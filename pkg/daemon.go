@@ -0,0 +1,615 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 'todo daemon' keeps every list parsed in memory and answers queries over
+// a unix socket in .todo, so commands that would parse every list can ask it
+// instead. Before answering it checks when each list was last written,
+// much as the index does, so answers are never staler than the lists. It also checks the lists and fires reminders once a second.
+//
+// Requests and responses are one line each. A request is a command followed
+// by its arguments, separated by spaces; arguments with spaces or quotes are
+// written as Go quoted strings. The response is a JSON object with either a
+// "result" or an "error". A connection that subscribes also receives event
+// objects, with an "event" instead, whenever a list changes. The commands
+// meant for editors (list, items, toggle, subscribe) are documented in the
+// README; the others are used by the CLI and may change.
+
+// ErrDaemonUnavailable is returned when no daemon is listening
+var ErrDaemonUnavailable = errors.New("the todo daemon is not running")
+
+// daemonTick is how often the daemon checks the lists and reminders
+const daemonTick = time.Second
+
+// daemonDialTimeout keeps commands from waiting on a daemon that hangs
+const daemonDialTimeout = 500 * time.Millisecond
+
+// daemonWriteTimeout is how long the daemon waits on a client that isn't
+// reading
+const daemonWriteTimeout = 5 * time.Second
+
+func GetDaemonSocketPath() string {
+	return filepath.Join(".todo", "daemon.sock")
+}
+
+func GetDaemonRemindersPath() string {
+	return filepath.Join(".todo", "reminders.json")
+}
+
+// DaemonStatus describes a running daemon
+type DaemonStatus struct {
+	PID       int       `json:"pid"`
+	Started   time.Time `json:"started"`
+	Lists     int       `json:"lists"`
+	Reminders int       `json:"reminders"`
+}
+
+// DaemonList is a list as the daemon describes it to editors
+type DaemonList struct {
+	Name      string `json:"name"`
+	Pending   int    `json:"pending"`
+	Completed int    `json:"completed"`
+	// Current is set for the list commands add items to
+	Current bool `json:"current"`
+}
+
+// DaemonItem is an item as the daemon describes it to editors
+type DaemonItem struct {
+	// ID is the item's number in its list, which changes as items are
+	// added and removed
+	ID        int    `json:"id"`
+	Text      string `json:"text"`
+	Completed bool   `json:"completed"`
+	Section   string `json:"section,omitempty"`
+	// Due is the due date as YYYY-MM-DD
+	Due string `json:"due,omitempty"`
+}
+
+// DaemonEvent is pushed to subscribed connections. Event is "changed" for a
+// list that was created or changed, or "removed".
+type DaemonEvent struct {
+	Event string `json:"event"`
+	List  string `json:"list"`
+}
+
+type daemonResponse struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// cachedList is a parsed list and the state it was parsed from
+type cachedList struct {
+	modTime time.Time
+	sum     [sha256.Size]byte
+	list    *TodoList
+}
+
+// daemonConn is a client connection. Responses and events are written from
+// different goroutines, so writes are serialized.
+type daemonConn struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	encoder *json.Encoder
+	// lists are the lists the connection subscribed to; nil means none
+	// and empty means all
+	lists map[string]bool
+}
+
+// send writes a response or event. A client that stops reading is given up
+// on rather than holding up the daemon.
+func (c *daemonConn) send(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(daemonWriteTimeout))
+	return c.encoder.Encode(v)
+}
+
+// Daemon serves queries about the lists of the current directory
+type Daemon struct {
+	// OnReminder is called when a reminder is due and its item is still
+	// pending
+	OnReminder func(Reminder)
+
+	mu          sync.Mutex
+	lists       map[string]*cachedList
+	reminders   []Reminder
+	subscribers map[*daemonConn]bool
+	started     time.Time
+	listener    net.Listener
+	// activated is set when systemd owns the socket
+	activated bool
+	// snapshotDay is the day progress snapshots were last recorded, and
+	// snapshotsStale is set when a list changed since
+	snapshotDay    string
+	snapshotsStale bool
+}
+
+func NewDaemon() *Daemon {
+	return &Daemon{lists: make(map[string]*cachedList), subscribers: make(map[*daemonConn]bool)}
+}
+
+// Listen opens the daemon's socket, replacing one left behind by a daemon
+// that didn't shut down cleanly. Under systemd socket activation the socket
+// systemd passes in is used instead, so the daemon starts on the first
+// connection.
+func (d *Daemon) Listen() error {
+	if os.Getenv("LISTEN_PID") == strconv.Itoa(os.Getpid()) && os.Getenv("LISTEN_FDS") == "1" {
+		// Passed sockets start at file descriptor 3
+		listener, err := net.FileListener(os.NewFile(3, "systemd-socket"))
+		if err != nil {
+			return fmt.Errorf("failed to use the socket passed by systemd: %w", err)
+		}
+		d.listener, d.activated = listener, true
+		return nil
+	}
+
+	path := GetDaemonSocketPath()
+	if conn, err := net.DialTimeout("unix", path, daemonDialTimeout); err == nil {
+		conn.Close()
+		return fmt.Errorf("a todo daemon is already running for this directory")
+	}
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	d.listener = listener
+	return nil
+}
+
+// Serve answers requests until stop is closed, then removes the socket
+func (d *Daemon) Serve(stop <-chan struct{}) error {
+	d.started = time.Now()
+	reminders, err := loadDaemonReminders()
+	if err != nil {
+		return err
+	}
+	d.reminders = reminders
+	if err := d.refresh(); err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			conn, err := d.listener.Accept()
+			if err != nil {
+				return
+			}
+			go d.handle(conn)
+		}
+	}()
+
+	ticker := time.NewTicker(daemonTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			d.listener.Close()
+			if !d.activated {
+				os.Remove(GetDaemonSocketPath())
+			}
+			return nil
+		case now := <-ticker.C:
+			d.refresh()
+			d.fireReminders(now)
+			d.recordSnapshots(now)
+		}
+	}
+}
+
+// handle answers the requests of one connection, one line at a time
+func (d *Daemon) handle(netConn net.Conn) {
+	conn := &daemonConn{conn: netConn, encoder: json.NewEncoder(netConn)}
+	defer func() {
+		d.mu.Lock()
+		delete(d.subscribers, conn)
+		d.mu.Unlock()
+		netConn.Close()
+	}()
+
+	scanner := bufio.NewScanner(netConn)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var response daemonResponse
+		result, err := d.dispatch(conn, scanner.Text())
+		if err == nil {
+			response.Result, err = json.Marshal(result)
+		}
+		if err != nil {
+			response.Error = err.Error()
+		}
+		if conn.send(response) != nil {
+			return
+		}
+	}
+}
+
+// dispatch runs one request
+func (d *Daemon) dispatch(conn *daemonConn, line string) (interface{}, error) {
+	args, err := splitDaemonLine(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty request")
+	}
+	if err := d.refresh(); err != nil {
+		return nil, err
+	}
+
+	// toggle writes a list, so it can't hold the lock refresh takes
+	if args[0] == "toggle" {
+		return d.toggle(args[1:])
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch args[0] {
+	case "ping":
+		return DaemonStatus{PID: os.Getpid(), Started: d.started, Lists: len(d.lists), Reminders: len(d.reminders)}, nil
+	case "list":
+		return d.listSummaries(), nil
+	case "items":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("usage: items <list>")
+		}
+		return d.items(ResolveListName(args[1]))
+	case "subscribe":
+		conn.lists = make(map[string]bool)
+		lists := []string{}
+		for _, name := range args[1:] {
+			name = ResolveListName(name)
+			conn.lists[name] = true
+			lists = append(lists, name)
+		}
+		d.subscribers[conn] = true
+		return map[string][]string{"lists": lists}, nil
+	case "count":
+		return d.count(args[1:], Now()), nil
+	case "agenda":
+		return agendaOf(d.parsedLists(), len(args) > 1 && args[1] == "completed"), nil
+	case "remind":
+		if len(args) != 5 {
+			return nil, fmt.Errorf("usage: remind <name> <list> <text> <time>")
+		}
+		at, err := time.Parse(time.RFC3339, args[4])
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q", args[4])
+		}
+		d.reminders = append(d.reminders, Reminder{Name: args[1], List: args[2], Text: args[3], At: at})
+		return len(d.reminders), saveDaemonReminders(d.reminders)
+	}
+	return nil, fmt.Errorf("unknown command %q", args[0])
+}
+
+// listSummaries describes every list
+func (d *Daemon) listSummaries() []DaemonList {
+	current, _ := GetCurrentList()
+	summaries := []DaemonList{}
+	for _, parsed := range d.parsedLists() {
+		counts := d.count([]string{parsed.Name}, Now())
+		summaries = append(summaries, DaemonList{Name: parsed.Name, Pending: counts.Pending, Completed: counts.Completed, Current: parsed.Name == current})
+	}
+	return summaries
+}
+
+// items describes the items of a list
+func (d *Daemon) items(listName string) ([]DaemonItem, error) {
+	cached, ok := d.lists[listName]
+	if !ok {
+		return nil, fmt.Errorf("list '%s' not found", listName)
+	}
+	items := []DaemonItem{}
+	for _, item := range cached.list.Items {
+		items = append(items, daemonItemOf(item))
+	}
+	return items, nil
+}
+
+func daemonItemOf(item TodoItem) DaemonItem {
+	described := DaemonItem{ID: item.ID, Text: item.Text, Completed: item.Completed, Section: item.Section}
+	if item.DueDate != nil {
+		described.Due = item.DueDate.Format(DueDateFormat)
+	}
+	return described
+}
+
+// toggle checks or unchecks an item and returns it as it now is
+func (d *Daemon) toggle(args []string) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("usage: toggle <list> <item>")
+	}
+	listName := ResolveListName(args[0])
+	if !TodoFileExists(listName) {
+		return nil, fmt.Errorf("list '%s' not found", listName)
+	}
+	itemID, err := ResolveItemRef(listName, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return nil, err
+	}
+	if itemID < 1 || itemID > len(todoList.Items) {
+		return nil, fmt.Errorf("item %d not found", itemID)
+	}
+	if todoList.Items[itemID-1].Completed {
+		err = UncheckTodoItem(listName, itemID)
+	} else {
+		err = CheckTodoItem(listName, itemID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Refreshing now tells subscribers without waiting for the next tick
+	if err := d.refresh(); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return daemonItemOf(d.lists[listName].list.Items[itemID-1]), nil
+}
+
+// refresh parses lists that changed since they were last read, forgets
+// deleted ones and tells subscribers about both
+func (d *Daemon) refresh() error {
+	names, err := GetAllLists()
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	var events []DaemonEvent
+	seen := make(map[string]bool)
+	for _, name := range names {
+		seen[name] = true
+		modTime := ListModTime(name)
+		cached, ok := d.lists[name]
+		if ok && !modTime.IsZero() && cached.modTime.Equal(modTime) && time.Since(modTime) >= racyWindow {
+			continue
+		}
+
+		// Lists written within the racy window are read again on every
+		// refresh, but only count as changed when their content does
+		content, err := readListContent(name)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(content)
+		if ok && cached.sum == sum {
+			cached.modTime = modTime
+			continue
+		}
+		todoList, err := parseTodoList(bytes.NewReader(content))
+		if err != nil {
+			d.mu.Unlock()
+			return err
+		}
+		d.lists[name] = &cachedList{modTime: modTime, sum: sum, list: todoList}
+		d.snapshotsStale = true
+		events = append(events, DaemonEvent{Event: "changed", List: name})
+	}
+	for name := range d.lists {
+		if !seen[name] {
+			delete(d.lists, name)
+			events = append(events, DaemonEvent{Event: "removed", List: name})
+		}
+	}
+
+	var subscribers []*daemonConn
+	for conn := range d.subscribers {
+		subscribers = append(subscribers, conn)
+	}
+	d.mu.Unlock()
+
+	for _, event := range events {
+		for _, conn := range subscribers {
+			if len(conn.lists) == 0 || conn.lists[event.List] {
+				conn.send(event)
+			}
+		}
+	}
+	return nil
+}
+
+// recordSnapshots records the progress of the cached lists when one of them
+// changed or a new day started
+func (d *Daemon) recordSnapshots(now time.Time) {
+	day := now.Format(DueDateFormat)
+	d.mu.Lock()
+	if !d.snapshotsStale && d.snapshotDay == day {
+		d.mu.Unlock()
+		return
+	}
+	lists := d.parsedLists()
+	d.snapshotDay, d.snapshotsStale = day, false
+	d.mu.Unlock()
+
+	if cfg, err := LoadConfig(); err == nil {
+		RecordSnapshots(lists, cfg, now)
+	}
+}
+
+// parsedLists returns the cached lists in name order
+func (d *Daemon) parsedLists() []ParsedList {
+	var names []string
+	for name := range d.lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parsed := make([]ParsedList, 0, len(names))
+	for _, name := range names {
+		parsed = append(parsed, ParsedList{Name: name, List: d.lists[name].list})
+	}
+	return parsed
+}
+
+// count works out ItemCounts like CountItems does, from the cached lists
+func (d *Daemon) count(names []string, now time.Time) ItemCounts {
+	today := now.Format(DueDateFormat)
+	var counts ItemCounts
+	for _, name := range names {
+		cached, ok := d.lists[name]
+		if !ok {
+			continue
+		}
+		for _, item := range cached.list.Items {
+			if item.Completed {
+				counts.Completed++
+				continue
+			}
+			counts.Pending++
+			if item.DueDate != nil && item.DueDate.Format(DueDateFormat) < today {
+				counts.Overdue++
+			}
+		}
+	}
+	return counts
+}
+
+// fireReminders calls OnReminder for due reminders and drops them
+func (d *Daemon) fireReminders(now time.Time) {
+	d.mu.Lock()
+	var due, kept []Reminder
+	for _, reminder := range d.reminders {
+		if reminder.At.After(now) {
+			kept = append(kept, reminder)
+		} else {
+			due = append(due, reminder)
+		}
+	}
+	if len(due) > 0 {
+		d.reminders = kept
+		saveDaemonReminders(kept)
+	}
+	d.mu.Unlock()
+
+	for _, reminder := range due {
+		if d.OnReminder != nil && ReminderDue(reminder.List, reminder.Text) {
+			d.OnReminder(reminder)
+		}
+	}
+}
+
+func loadDaemonReminders() ([]Reminder, error) {
+	content, err := os.ReadFile(GetDaemonRemindersPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reminders: %w", err)
+	}
+	var reminders []Reminder
+	if err := json.Unmarshal(content, &reminders); err != nil {
+		return nil, fmt.Errorf("failed to parse reminders: %w", err)
+	}
+	return reminders, nil
+}
+
+func saveDaemonReminders(reminders []Reminder) error {
+	if len(reminders) == 0 {
+		if err := os.Remove(GetDaemonRemindersPath()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	content, err := json.MarshalIndent(reminders, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GetDaemonRemindersPath(), content, 0644)
+}
+
+// AskDaemon sends a request to the daemon of the current directory and
+// decodes its result into out, which may be nil. It returns
+// ErrDaemonUnavailable when no daemon is running, so callers can fall back
+// to reading the lists themselves, and also when the clock isn't the real
+// one, since the daemon answers by its own.
+func AskDaemon(out interface{}, command string, args ...string) error {
+	if !realClock() {
+		return ErrDaemonUnavailable
+	}
+	conn, err := net.DialTimeout("unix", GetDaemonSocketPath(), daemonDialTimeout)
+	if err != nil {
+		return ErrDaemonUnavailable
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := fmt.Fprintln(conn, formatDaemonLine(append([]string{command}, args...))); err != nil {
+		return ErrDaemonUnavailable
+	}
+	var response daemonResponse
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		return ErrDaemonUnavailable
+	}
+	if response.Error != "" {
+		return fmt.Errorf("daemon: %s", response.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Result, out)
+}
+
+// formatDaemonLine writes a request line, quoting arguments that need it
+func formatDaemonLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"\\") || !strconv.CanBackquote(arg) {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// splitDaemonLine reads the arguments of a request line
+func splitDaemonLine(line string) ([]string, error) {
+	var args []string
+	s := strings.TrimSpace(line)
+	for s != "" {
+		if s[0] == '"' {
+			end := quotedEnd(s)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted argument")
+			}
+			arg, err := strconv.Unquote(s[:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted argument %s", s[:end])
+			}
+			args = append(args, arg)
+			s = s[end:]
+		} else {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				end = len(s)
+			}
+			args = append(args, s[:end])
+			s = s[end:]
+		}
+		s = strings.TrimLeft(s, " \t")
+	}
+	return args, nil
+}
@@ -0,0 +1,221 @@
+package pkg
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startDaemon runs a daemon for the test directory until the test ends
+func startDaemon(t *testing.T) *Daemon {
+	daemon := NewDaemon()
+	if err := daemon.Listen(); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- daemon.Serve(stop) }()
+	t.Cleanup(func() {
+		close(stop)
+		<-done
+	})
+
+	// Serve reads the lists before answering anything
+	for i := 0; i < 100; i++ {
+		if AskDaemon(nil, "ping") == nil {
+			return daemon
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("The daemon did not answer")
+	return nil
+}
+
+func TestDaemonQueries(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+
+	if err := AskDaemon(nil, "ping"); !errors.Is(err, ErrDaemonUnavailable) {
+		t.Fatalf("Expected ErrDaemonUnavailable without a daemon, got %v", err)
+	}
+
+	AddTodoItem("main", "Write docs (due: 2000-01-01)")
+	AddTodoItem("main", "Ship it")
+	CheckTodoItem("main", 2)
+	AddTodoItem("my list", "Plan (due: 2999-01-01)")
+	startDaemon(t)
+
+	if err := NewDaemon().Listen(); err == nil {
+		t.Error("A second daemon should not start")
+	}
+
+	var status DaemonStatus
+	if err := AskDaemon(&status, "ping"); err != nil || status.PID != os.Getpid() || status.Lists != 2 {
+		t.Errorf("ping = %+v, %v", status, err)
+	}
+
+	var counts ItemCounts
+	if err := AskDaemon(&counts, "count", "main", "my list"); err != nil {
+		t.Fatalf("count failed: %v", err)
+	}
+	if counts != (ItemCounts{Pending: 2, Completed: 1, Overdue: 1}) {
+		t.Errorf("count = %+v", counts)
+	}
+
+	// Answers follow changes to the files
+	AddTodoItem("my list", "Review")
+	if err := AskDaemon(&counts, "count", "my list"); err != nil || counts.Pending != 2 {
+		t.Errorf("count after a change = %+v, %v", counts, err)
+	}
+
+	var agenda []ListItem
+	if err := AskDaemon(&agenda, "agenda"); err != nil {
+		t.Fatalf("agenda failed: %v", err)
+	}
+	want, _ := GetAgenda(false)
+	if len(agenda) != 2 || agenda[0].Item.Text != want[0].Item.Text || agenda[1].List != "my list" {
+		t.Errorf("agenda = %+v, want %+v", agenda, want)
+	}
+
+	if err := AskDaemon(nil, "bogus"); err == nil || errors.Is(err, ErrDaemonUnavailable) {
+		t.Errorf("Expected an error for an unknown command, got %v", err)
+	}
+}
+
+func TestDaemonReminders(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	AddTodoItem("main", "Call Ann")
+	AddTodoItem("main", "Done already")
+	CheckTodoItem("main", 2)
+
+	daemon := NewDaemon()
+	var fired []string
+	daemon.OnReminder = func(reminder Reminder) {
+		fired = append(fired, reminder.Text)
+	}
+	now := time.Now()
+	daemon.reminders = []Reminder{
+		{Name: "a", List: "main", Text: "Call Ann", At: now.Add(-time.Second)},
+		{Name: "b", List: "main", Text: "Done already", At: now.Add(-time.Second)},
+		{Name: "c", List: "main", Text: "Call Ann", At: now.Add(time.Hour)},
+	}
+
+	daemon.fireReminders(now)
+	if !reflect.DeepEqual(fired, []string{"Call Ann"}) {
+		t.Errorf("Expected only the pending item's reminder to fire, got %v", fired)
+	}
+	saved, err := loadDaemonReminders()
+	if err != nil || len(saved) != 1 || saved[0].Name != "c" {
+		t.Errorf("Expected the later reminder to be kept, got %+v (%v)", saved, err)
+	}
+}
+
+func TestDaemonLine(t *testing.T) {
+	args := []string{"remind", "r1", "my list", `say "hi"`, ""}
+	line := formatDaemonLine(args)
+	if line != `remind r1 "my list" "say \"hi\"" ""` {
+		t.Errorf("formatDaemonLine() = %s", line)
+	}
+	got, err := splitDaemonLine(line)
+	if err != nil || !reflect.DeepEqual(got, args) {
+		t.Errorf("splitDaemonLine() = %q, %v", got, err)
+	}
+	if _, err := splitDaemonLine(`count "main`); err == nil {
+		t.Error("Expected an error for an unterminated argument")
+	}
+}
+
+// daemonClient talks the daemon's protocol over a raw connection, as an
+// editor would
+type daemonClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialDaemon(t *testing.T) *daemonClient {
+	conn, err := net.Dial("unix", GetDaemonSocketPath())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &daemonClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// next reads the next line the daemon sends
+func (c *daemonClient) next() map[string]json.RawMessage {
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		c.t.Fatalf("Failed to read from the daemon: %v", err)
+	}
+	var message map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(line), &message); err != nil {
+		c.t.Fatalf("Invalid line %q: %v", line, err)
+	}
+	return message
+}
+
+// request sends a request and returns the raw result, skipping events
+func (c *daemonClient) request(line string) string {
+	fmt.Fprintln(c.conn, line)
+	for {
+		message := c.next()
+		if _, ok := message["event"]; ok {
+			continue
+		}
+		if errMessage, ok := message["error"]; ok {
+			return "error: " + string(errMessage)
+		}
+		return string(message["result"])
+	}
+}
+
+func TestDaemonEditorProtocol(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	AddTodoItem("main", "Write docs (due: 2030-01-02)")
+	AddTodoItem("other", "Unrelated")
+	startDaemon(t)
+
+	client := dialDaemon(t)
+	if got := client.request("list"); !strings.Contains(got, `{"name":"main","pending":1,"completed":0,"current":true}`) || !strings.Contains(got, `"name":"other"`) {
+		t.Errorf("list = %s", got)
+	}
+	if got := client.request("items main"); got != `[{"id":1,"text":"Write docs","completed":false,"due":"2030-01-02"}]` {
+		t.Errorf("items = %s", got)
+	}
+	if got := client.request("items nope"); !strings.HasPrefix(got, "error: ") {
+		t.Errorf("Expected an error for a missing list, got %s", got)
+	}
+
+	// A subscriber hears about changes to its lists only
+	subscriber := dialDaemon(t)
+	if got := subscriber.request("subscribe main"); got != `{"lists":["main"]}` {
+		t.Errorf("subscribe = %s", got)
+	}
+	AddTodoItem("other", "Ignored")
+	if got := client.request("toggle main 1"); got != `{"id":1,"text":"Write docs","completed":true,"due":"2030-01-02"}` {
+		t.Errorf("toggle = %s", got)
+	}
+	if todoList, _ := ParseTodoFile("main"); !todoList.Items[0].Completed {
+		t.Error("Expected toggle to check the item")
+	}
+	if event := subscriber.next(); string(event["event"]) != `"changed"` || string(event["list"]) != `"main"` {
+		t.Errorf("Unexpected event: %v", event)
+	}
+
+	DeleteList("main")
+	client.request("ping")
+	if event := subscriber.next(); string(event["event"]) != `"removed"` {
+		t.Errorf("Expected a removed event, got %v", event)
+	}
+}
@@ -0,0 +1,339 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DatesConfig controls how dates typed in words, such as "next friday", are
+// read
+type DatesConfig struct {
+	// Locale is the language dates are typed in, such as es or fr_CA. By
+	// default it comes from LC_ALL, LC_TIME or LANG. English is always
+	// understood as well.
+	Locale string `yaml:"locale,omitempty"`
+	// FirstDayOfWeek is the day weeks start on (default monday). It decides
+	// what "next week" and "next friday" mean.
+	FirstDayOfWeek string `yaml:"first_day_of_week,omitempty"`
+}
+
+// DateParser reads a date typed by the user, relative to now
+type DateParser interface {
+	ParseDate(input string, now time.Time) (time.Time, error)
+}
+
+// DateLocale holds the words one language uses for dates. Words are
+// matched ignoring case and accents, so "proximo" matches "próximo".
+type DateLocale struct {
+	// Name is the language code, such as es
+	Name     string
+	Today    []string
+	Tomorrow []string
+	// DayAfterTomorrow may be several words, as in "pasado mañana"
+	DayAfterTomorrow []string
+	// Next marks the following week, before or after the day or week it
+	// qualifies: "next friday", "vendredi prochain"
+	Next []string
+	Week []string
+	// Weekdays holds the names and abbreviations of each day, indexed by
+	// time.Weekday
+	Weekdays [7][]string
+	// Filler words are ignored, like "on" in "on friday"
+	Filler []string
+}
+
+var (
+	dateLocalesMu sync.Mutex
+	dateLocales   = make(map[string]DateLocale)
+)
+
+// RegisterDateLocale adds or replaces a language dates can be typed in
+func RegisterDateLocale(locale DateLocale) {
+	dateLocalesMu.Lock()
+	defer dateLocalesMu.Unlock()
+
+	dateLocales[locale.Name] = locale
+}
+
+// DateLocales returns the names of the languages dates can be typed in
+func DateLocales() []string {
+	dateLocalesMu.Lock()
+	defer dateLocalesMu.Unlock()
+
+	var names []string
+	for name := range dateLocales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupDateLocale(name string) (DateLocale, bool) {
+	dateLocalesMu.Lock()
+	defer dateLocalesMu.Unlock()
+
+	locale, ok := dateLocales[name]
+	return locale, ok
+}
+
+func init() {
+	RegisterDateLocale(DateLocale{
+		Name:             "en",
+		Today:            []string{"today"},
+		Tomorrow:         []string{"tomorrow"},
+		DayAfterTomorrow: []string{"day after tomorrow"},
+		Next:             []string{"next"},
+		Week:             []string{"week"},
+		Weekdays: [7][]string{
+			{"sunday", "sun"}, {"monday", "mon"}, {"tuesday", "tue", "tues"}, {"wednesday", "wed"},
+			{"thursday", "thu", "thurs"}, {"friday", "fri"}, {"saturday", "sat"},
+		},
+		Filler: []string{"on", "this", "the"},
+	})
+	RegisterDateLocale(DateLocale{
+		Name:             "es",
+		Today:            []string{"hoy"},
+		Tomorrow:         []string{"mañana"},
+		DayAfterTomorrow: []string{"pasado mañana"},
+		Next:             []string{"próximo", "próxima", "siguiente"},
+		Week:             []string{"semana"},
+		Weekdays: [7][]string{
+			{"domingo", "dom"}, {"lunes", "lun"}, {"martes", "mar"}, {"miércoles", "mié"},
+			{"jueves", "jue"}, {"viernes", "vie"}, {"sábado", "sáb"},
+		},
+		Filler: []string{"el", "la", "este", "esta"},
+	})
+	RegisterDateLocale(DateLocale{
+		Name:             "fr",
+		Today:            []string{"aujourd'hui"},
+		Tomorrow:         []string{"demain"},
+		DayAfterTomorrow: []string{"après-demain", "après demain"},
+		Next:             []string{"prochain", "prochaine"},
+		Week:             []string{"semaine"},
+		Weekdays: [7][]string{
+			{"dimanche", "dim"}, {"lundi", "lun"}, {"mardi", "mar"}, {"mercredi", "mer"},
+			{"jeudi", "jeu"}, {"vendredi", "ven"}, {"samedi", "sam"},
+		},
+		Filler: []string{"le", "la", "ce", "cette"},
+	})
+	RegisterDateLocale(DateLocale{
+		Name:             "de",
+		Today:            []string{"heute"},
+		Tomorrow:         []string{"morgen"},
+		DayAfterTomorrow: []string{"übermorgen"},
+		Next:             []string{"nächste", "nächsten", "nächster", "kommende", "kommenden", "kommender"},
+		Week:             []string{"woche"},
+		Weekdays: [7][]string{
+			{"sonntag", "so"}, {"montag", "mo"}, {"dienstag", "di"}, {"mittwoch", "mi"},
+			{"donnerstag", "do"}, {"freitag", "fr"}, {"samstag", "sa"},
+		},
+		Filler: []string{"am", "diese", "diesen"},
+	})
+}
+
+// NewDateParser returns the date parser for the configured locale and
+// first day of the week
+func NewDateParser(cfg *Config) (DateParser, error) {
+	parser := &naturalDateParser{firstDay: time.Monday}
+
+	name := cfg.Dates.Locale
+	if name == "" {
+		name = environmentLocale()
+	}
+	if name = localeLanguage(name); name != "" && name != "en" {
+		locale, ok := lookupDateLocale(name)
+		switch {
+		case ok:
+			parser.locales = append(parser.locales, locale)
+		case cfg.Dates.Locale != "":
+			return nil, fmt.Errorf("unknown date locale %q (expected one of %s)", cfg.Dates.Locale, strings.Join(DateLocales(), ", "))
+		}
+	}
+	english, _ := lookupDateLocale("en")
+	parser.locales = append(parser.locales, english)
+
+	if cfg.Dates.FirstDayOfWeek != "" {
+		day, ok := parser.weekday(foldDateWord(cfg.Dates.FirstDayOfWeek))
+		if !ok {
+			return nil, fmt.Errorf("invalid first_day_of_week %q (expected a day such as monday or sunday)", cfg.Dates.FirstDayOfWeek)
+		}
+		parser.firstDay = day
+	}
+
+	calendar, err := LoadWorkCalendar(cfg)
+	if err != nil {
+		return nil, err
+	}
+	parser.calendar = calendar
+	return parser, nil
+}
+
+// ParseDueDate reads a due date typed by the user with the configured
+// date parser
+func ParseDueDate(input string, now time.Time) (time.Time, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return time.Time{}, err
+	}
+	parser, err := NewDateParser(cfg)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parser.ParseDate(input, now)
+}
+
+// environmentLocale returns the locale the environment asks for messages
+// and dates in
+func environmentLocale() string {
+	for _, variable := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if value := os.Getenv(variable); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// localeLanguage returns the language of a locale such as fr_CA.UTF-8
+func localeLanguage(locale string) string {
+	language, _, _ := strings.Cut(locale, ".")
+	language, _, _ = strings.Cut(language, "_")
+	language, _, _ = strings.Cut(language, "-")
+	if language == "C" || language == "POSIX" {
+		return ""
+	}
+	return strings.ToLower(language)
+}
+
+// foldDateWord lowercases a word and strips its accents, so words match
+// however they were typed
+func foldDateWord(word string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(cases.Fold().String(strings.TrimSpace(word))) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// naturalDateParser reads ISO dates, offsets such as +3d, +2w and +5bd
+// (working days), and the words of its locales
+type naturalDateParser struct {
+	locales  []DateLocale
+	firstDay time.Weekday
+	calendar *WorkCalendar
+}
+
+var dateOffsetRegex = regexp.MustCompile(`^\+(\d+)(bd|d|w)$`)
+
+func (p *naturalDateParser) ParseDate(input string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	input = strings.TrimSpace(input)
+
+	if date, err := time.ParseInLocation(DueDateFormat, input, time.Local); err == nil {
+		return date, nil
+	}
+	if match := dateOffsetRegex.FindStringSubmatch(input); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		switch match[2] {
+		case "bd":
+			return p.calendar.AddWorkdays(today, n), nil
+		case "w":
+			n *= 7
+		}
+		return today.AddDate(0, 0, n), nil
+	}
+
+	phrase := strings.Join(strings.Fields(foldDateWord(input)), " ")
+	for _, locale := range p.locales {
+		if date, ok := p.parseWords(locale, phrase, today); ok {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot read %q as a date (try YYYY-MM-DD, +3d, +3bd, tomorrow or a day of the week)", input)
+}
+
+// parseWords reads a date written in the words of one locale
+func (p *naturalDateParser) parseWords(locale DateLocale, phrase string, today time.Time) (time.Time, bool) {
+	switch {
+	case matchesWord(locale.Today, phrase):
+		return today, true
+	case matchesWord(locale.Tomorrow, phrase):
+		return today.AddDate(0, 0, 1), true
+	case matchesWord(locale.DayAfterTomorrow, phrase):
+		return today.AddDate(0, 0, 2), true
+	}
+
+	var rest []string
+	next := false
+	for _, word := range strings.Fields(phrase) {
+		switch {
+		case matchesWord(locale.Filler, word):
+		case matchesWord(locale.Next, word) && !next:
+			next = true
+		default:
+			rest = append(rest, word)
+		}
+	}
+	if len(rest) != 1 {
+		return time.Time{}, false
+	}
+
+	// The following week starts on the next first day of the week
+	untilNextWeek := (int(p.firstDay) - int(today.Weekday()) + 7) % 7
+	if untilNextWeek == 0 {
+		untilNextWeek = 7
+	}
+	nextWeek := today.AddDate(0, 0, untilNextWeek)
+
+	if matchesWord(locale.Week, rest[0]) {
+		if !next {
+			return time.Time{}, false
+		}
+		return nextWeek, true
+	}
+	for day, names := range locale.Weekdays {
+		if !matchesWord(names, rest[0]) {
+			continue
+		}
+		if next {
+			return nextWeek.AddDate(0, 0, (day-int(p.firstDay)+7)%7), true
+		}
+		// A bare day is the next one to come, a week away on that day
+		days := (day - int(today.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, days), true
+	}
+	return time.Time{}, false
+}
+
+// weekday finds a day by name in any of the parser's locales
+func (p *naturalDateParser) weekday(word string) (time.Weekday, bool) {
+	for _, locale := range p.locales {
+		for day, names := range locale.Weekdays {
+			if matchesWord(names, word) {
+				return time.Weekday(day), true
+			}
+		}
+	}
+	return 0, false
+}
+
+func matchesWord(words []string, folded string) bool {
+	return slices.ContainsFunc(words, func(word string) bool {
+		return foldDateWord(word) == folded
+	})
+}
@@ -0,0 +1,108 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+// clearLocale keeps the environment's locale out of a test
+func clearLocale(t *testing.T) {
+	for _, variable := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		t.Setenv(variable, "")
+	}
+}
+
+func TestNaturalDates(t *testing.T) {
+	clearLocale(t)
+	// A Wednesday
+	now := time.Date(2024, 7, 3, 15, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		locale, firstDay, input, want string
+	}{
+		{"", "", "2024-08-01", "2024-08-01"},
+		{"", "", "+3d", "2024-07-06"},
+		{"", "", "+2w", "2024-07-17"},
+		{"", "", "today", "2024-07-03"},
+		{"", "", "Tomorrow", "2024-07-04"},
+		{"", "", "friday", "2024-07-05"},
+		{"", "", "on wed", "2024-07-10"},
+		{"", "", "next friday", "2024-07-12"},
+		{"", "", "next week", "2024-07-08"},
+		{"es", "", "próximo lunes", "2024-07-08"},
+		{"es", "", "el lunes que viene", ""},
+		{"es", "", "proximo LUNES", "2024-07-08"},
+		{"es", "", "pasado mañana", "2024-07-05"},
+		{"es", "", "viernes", "2024-07-05"},
+		{"fr", "", "vendredi", "2024-07-05"},
+		{"fr_CA.UTF-8", "", "lundi prochain", "2024-07-08"},
+		{"de", "", "übermorgen", "2024-07-05"},
+		{"de", "", "nächsten Freitag", "2024-07-12"},
+		// English still works in another locale, other locales don't
+		{"de", "", "tomorrow", "2024-07-04"},
+		{"", "", "vendredi", ""},
+		// Weeks starting on Sunday
+		{"", "sunday", "next week", "2024-07-07"},
+		{"", "sunday", "next friday", "2024-07-12"},
+		{"es", "domingo", "próxima semana", "2024-07-07"},
+		{"", "", "someday", ""},
+	}
+	for _, tt := range tests {
+		parser, err := NewDateParser(&Config{Dates: DatesConfig{Locale: tt.locale, FirstDayOfWeek: tt.firstDay}})
+		if err != nil {
+			t.Fatalf("NewDateParser(%q, %q) failed: %v", tt.locale, tt.firstDay, err)
+		}
+		got, err := parser.ParseDate(tt.input, now)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s %q: expected an error, got %s", tt.locale, tt.input, got.Format(DueDateFormat))
+			}
+			continue
+		}
+		if err != nil || got.Format(DueDateFormat) != tt.want {
+			t.Errorf("%s %q = %s, %v; want %s", tt.locale, tt.input, got.Format(DueDateFormat), err, tt.want)
+		}
+	}
+}
+
+func TestDateParserConfig(t *testing.T) {
+	clearLocale(t)
+	t.Setenv("LANG", "es_ES.UTF-8")
+	parser, err := NewDateParser(&Config{})
+	if err != nil {
+		t.Fatalf("NewDateParser failed: %v", err)
+	}
+	if _, err := parser.ParseDate("próximo lunes", time.Now()); err != nil {
+		t.Errorf("Expected the locale to come from LANG: %v", err)
+	}
+
+	// An unknown locale in the environment falls back to English
+	t.Setenv("LANG", "xx_XX.UTF-8")
+	if _, err := NewDateParser(&Config{}); err != nil {
+		t.Errorf("Expected an unknown environment locale to be ignored: %v", err)
+	}
+
+	for _, bad := range []DatesConfig{{Locale: "xx"}, {FirstDayOfWeek: "someday"}} {
+		if _, err := NewDateParser(&Config{Dates: bad}); err == nil {
+			t.Errorf("Expected an error for %+v", bad)
+		}
+	}
+}
+
+func TestRegisterDateLocale(t *testing.T) {
+	RegisterDateLocale(DateLocale{Name: "nl", Tomorrow: []string{"morgen"}, Weekdays: [7][]string{5: {"vrijdag"}}})
+	t.Cleanup(func() {
+		dateLocalesMu.Lock()
+		delete(dateLocales, "nl")
+		dateLocalesMu.Unlock()
+	})
+
+	parser, err := NewDateParser(&Config{Dates: DatesConfig{Locale: "nl"}})
+	if err != nil {
+		t.Fatalf("NewDateParser failed: %v", err)
+	}
+	now := time.Date(2024, 7, 3, 0, 0, 0, 0, time.Local)
+	if got, err := parser.ParseDate("vrijdag", now); err != nil || got.Format(DueDateFormat) != "2024-07-05" {
+		t.Errorf("vrijdag = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,161 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetListDependencies replaces the lists a list depends on. Dependencies
+// that would make lists depend on each other in a cycle are refused.
+func SetListDependencies(listName string, dependsOn []string) error {
+	var resolved []string
+	for _, dep := range dependsOn {
+		dep = ResolveListName(dep)
+		if dep == listName {
+			return fmt.Errorf("list '%s' can't depend on itself", listName)
+		}
+		if !TodoFileExists(dep) {
+			return fmt.Errorf("list '%s' does not exist", dep)
+		}
+		resolved = append(resolved, dep)
+	}
+
+	graph, err := dependencyGraph()
+	if err != nil {
+		return err
+	}
+	graph[listName] = resolved
+	if cycle := findDependencyCycle(graph, listName); cycle != nil {
+		return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return fmt.Errorf("failed to parse todo file: %w", err)
+	}
+	todoList.Meta.DependsOn = resolved
+	return WriteTodoFile(listName, todoList)
+}
+
+// DependencyWarnings describes lists that are being worked on while a list
+// they depend on is incomplete or missing. A list is being worked on when it
+// is the current list, or has some but not all of its items completed.
+func DependencyWarnings(currentList string) ([]string, error) {
+	lists, parsedLists, err := parseAllLists()
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	for _, name := range lists {
+		todoList, ok := parsedLists[name]
+		if !ok {
+			continue
+		}
+		progress := todoList.Progress()
+		if name == currentList || (progress.Completed > 0 && progress.Completed < progress.Total) {
+			warnings = append(warnings, unmetDependencies(name, todoList, parsedLists)...)
+		}
+	}
+	return warnings, nil
+}
+
+// ListDependencyWarnings describes the incomplete or missing lists one list
+// depends on
+func ListDependencyWarnings(listName string) ([]string, error) {
+	_, parsedLists, err := parseAllLists()
+	if err != nil {
+		return nil, err
+	}
+	todoList, ok := parsedLists[listName]
+	if !ok {
+		return nil, nil
+	}
+	return unmetDependencies(listName, todoList, parsedLists), nil
+}
+
+func unmetDependencies(name string, todoList *TodoList, parsedLists map[string]*TodoList) []string {
+	var warnings []string
+	for _, dep := range todoList.Meta.DependsOn {
+		depList, ok := parsedLists[dep]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("list '%s' depends on '%s', which does not exist", name, dep))
+			continue
+		}
+		if progress := depList.Progress(); progress.Completed < progress.Total {
+			warnings = append(warnings, fmt.Sprintf("list '%s' depends on '%s', which is not complete (%d/%d done)", name, dep, progress.Completed, progress.Total))
+		}
+	}
+	return warnings
+}
+
+// parseAllLists returns the list names and every list that could be parsed
+func parseAllLists() ([]string, map[string]*TodoList, error) {
+	lists, err := GetAllLists()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parsedLists := make(map[string]*TodoList)
+	for _, parsed := range ParseLists(lists) {
+		if parsed.Err == nil {
+			parsedLists[parsed.Name] = parsed.List
+		}
+	}
+	return lists, parsedLists, nil
+}
+
+// dependencyGraph maps each list to the lists it depends on
+func dependencyGraph() (map[string][]string, error) {
+	lists, err := GetAllLists()
+	if err != nil {
+		return nil, err
+	}
+
+	graph := make(map[string][]string)
+	for _, parsed := range ParseLists(lists) {
+		if parsed.Err != nil {
+			return nil, parsed.Err
+		}
+		graph[parsed.Name] = parsed.List.Meta.DependsOn
+	}
+	return graph, nil
+}
+
+// findDependencyCycle returns a cycle reachable from start, as the path of
+// list names ending where it began, or nil if there is none
+func findDependencyCycle(graph map[string][]string, start string) []string {
+	var path []string
+	onPath := make(map[string]bool)
+	done := make(map[string]bool)
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		if onPath[name] {
+			for i, n := range path {
+				if n == name {
+					return append(append([]string{}, path[i:]...), name)
+				}
+			}
+		}
+		if done[name] {
+			return nil
+		}
+
+		onPath[name] = true
+		path = append(path, name)
+		deps := append([]string{}, graph[name]...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		onPath[name] = false
+		done[name] = true
+		return nil
+	}
+	return visit(start)
+}
@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetListDependencies(t *testing.T) {
+	setupTestDir(t)
+
+	for _, name := range []string{"design", "build", "ship"} {
+		CreateTodoFile(name)
+	}
+
+	if err := SetListDependencies("build", []string{"design"}); err != nil {
+		t.Fatalf("SetListDependencies failed: %v", err)
+	}
+	if err := SetListDependencies("ship", []string{"build"}); err != nil {
+		t.Fatalf("SetListDependencies failed: %v", err)
+	}
+	todoList, _ := ParseTodoFile("ship")
+	if len(todoList.Meta.DependsOn) != 1 || todoList.Meta.DependsOn[0] != "build" {
+		t.Errorf("Expected ship to depend on build, got %v", todoList.Meta.DependsOn)
+	}
+
+	err := SetListDependencies("design", []string{"ship"})
+	if err == nil || !strings.Contains(err.Error(), "design -> ship -> build -> design") {
+		t.Errorf("Expected a cycle error, got %v", err)
+	}
+	if err := SetListDependencies("design", []string{"design"}); err == nil {
+		t.Error("A list should not depend on itself")
+	}
+	if err := SetListDependencies("design", []string{"missing"}); err == nil {
+		t.Error("Expected an error for a missing list")
+	}
+
+	if err := SetListDependencies("ship", nil); err != nil {
+		t.Fatalf("Clearing dependencies failed: %v", err)
+	}
+	todoList, _ = ParseTodoFile("ship")
+	if len(todoList.Meta.DependsOn) != 0 || !todoList.Meta.IsZero() {
+		t.Errorf("Expected no dependencies, got %+v", todoList.Meta)
+	}
+}
+
+func TestDependencyWarnings(t *testing.T) {
+	setupTestDir(t)
+
+	CreateTodoFile("design")
+	AddTodoItem("design", "Mockups")
+	AddTodoItem("design", "Review")
+	CreateTodoFile("build")
+	AddTodoItem("build", "Backend")
+	AddTodoItem("build", "Frontend")
+	SetListDependencies("build", []string{"design"})
+
+	// Nothing is being worked on yet
+	warnings, err := DependencyWarnings("design")
+	if err != nil {
+		t.Fatalf("DependencyWarnings failed: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", warnings)
+	}
+
+	// Starting on build while design is incomplete
+	CheckTodoItem("build", 1)
+	CheckTodoItem("design", 1)
+	warnings, _ = DependencyWarnings("design")
+	if len(warnings) != 1 || warnings[0] != "list 'build' depends on 'design', which is not complete (1/2 done)" {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+
+	CheckTodoItem("design", 2)
+	if warnings, _ = DependencyWarnings("build"); len(warnings) != 0 {
+		t.Errorf("Expected no warnings once design is done, got %v", warnings)
+	}
+
+	DeleteList("design")
+	warnings, _ = ListDependencyWarnings("build")
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "which does not exist") {
+		t.Errorf("Expected a missing list warning, got %v", warnings)
+	}
+}
@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// EditMerge is the outcome of merging the changes made to a list while it
+// was open in an editor into the edited version
+type EditMerge struct {
+	List *TodoList
+	// Merged counts the changes made meanwhile that were kept
+	Merged int
+	// Conflicts describe things changed both in the editor and meanwhile,
+	// where the editor's version was kept
+	Conflicts []string
+}
+
+// MergeEdit merges the changes made between base, the list as it was when
+// the editor opened, and current, the list as it is now, into edited.
+// Items are matched by their text. Edits made in the editor win: changes
+// made meanwhile are applied to items the editor left alone, items added
+// meanwhile are added after the item they followed (or at the same position
+// when that one was renamed in the editor), and items removed
+// meanwhile are removed unless they were edited.
+func MergeEdit(base, edited, current *TodoList) *EditMerge {
+	merge := &EditMerge{List: &TodoList{Meta: edited.Meta, Headings: edited.Headings}}
+	baseItems, _ := keyItems(base.Items)
+	editedItems, editedKeys := keyItems(edited.Items)
+	currentItems, currentKeys := keyItems(current.Items)
+
+	if metaState(current.Meta) != metaState(base.Meta) {
+		switch metaState(edited.Meta) {
+		case metaState(base.Meta):
+			merge.List.Meta = current.Meta
+			merge.Merged++
+		case metaState(current.Meta):
+		default:
+			merge.Conflicts = append(merge.Conflicts, "the list's frontmatter was changed both in the editor and meanwhile")
+		}
+	}
+
+	// Start from the edited items and bring in what changed meanwhile
+	keys := append([]string(nil), editedKeys...)
+	items := make(map[string]TodoItem)
+	for key, item := range editedItems {
+		items[key] = item
+	}
+	for i, key := range currentKeys {
+		item := currentItems[key]
+		baseItem, inBase := baseItems[key]
+		editedItem, inEdited := editedItems[key]
+		switch {
+		case !inBase && !inEdited:
+			keys = insertAfter(keys, key, currentKeys[:i], i)
+			items[key] = item
+			merge.Merged++
+		case !inBase || itemState(item) == itemState(baseItem):
+		case !inEdited:
+			merge.Conflicts = append(merge.Conflicts, fmt.Sprintf("'%s' was changed while you deleted it", item.Text))
+		case itemState(editedItem) == itemState(baseItem):
+			items[key] = item
+			merge.Merged++
+		case itemState(editedItem) != itemState(item):
+			merge.Conflicts = append(merge.Conflicts, fmt.Sprintf("'%s' was changed both in the editor and meanwhile", item.Text))
+		}
+	}
+	for key, baseItem := range baseItems {
+		if _, ok := currentItems[key]; ok {
+			continue
+		}
+		editedItem, inEdited := editedItems[key]
+		if !inEdited {
+			continue
+		}
+		if itemState(editedItem) == itemState(baseItem) {
+			delete(items, key)
+			merge.Merged++
+		} else {
+			merge.Conflicts = append(merge.Conflicts, fmt.Sprintf("'%s' was deleted while you edited it", baseItem.Text))
+		}
+	}
+
+	for _, key := range keys {
+		if item, ok := items[key]; ok {
+			item.ID = len(merge.List.Items) + 1
+			merge.List.Items = append(merge.List.Items, item)
+		}
+	}
+	return merge
+}
+
+// keyItems identifies items by their text, numbering repeats of the same
+// text in order, and returns them with their keys in list order
+func keyItems(list []TodoItem) (map[string]TodoItem, []string) {
+	items := make(map[string]TodoItem)
+	var keys []string
+	seen := make(map[string]int)
+	for _, item := range list {
+		seen[item.Text]++
+		key := fmt.Sprintf("%s\x00%d", item.Text, seen[item.Text])
+		items[key] = item
+		keys = append(keys, key)
+	}
+	return items, keys
+}
+
+// insertAfter adds key after the last of before that is in keys, or at
+// position if none is
+func insertAfter(keys []string, key string, before []string, position int) []string {
+	for i := len(before) - 1; i >= 0; i-- {
+		if j := slices.Index(keys, before[i]); j >= 0 {
+			position = j + 1
+			break
+		}
+	}
+	position = min(position, len(keys))
+	return slices.Insert(keys, position, key)
+}
+
+// itemState is everything written for an item, to tell whether it changed
+func itemState(item TodoItem) string {
+	return item.Section + "\x00" + strings.Repeat(subtaskIndent, item.Depth) + formatItemLine(item) + "\x00" + strings.Join(item.Notes, "\n")
+}
+
+func metaState(meta ListMeta) string {
+	if meta.IsZero() {
+		return ""
+	}
+	content, _ := formatFrontmatter(meta)
+	return strings.TrimSpace(content)
+}
@@ -0,0 +1,83 @@
+package pkg
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func parseTestList(t *testing.T, content string) *TodoList {
+	t.Helper()
+	todoList, err := parseTodoList(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("parseTodoList failed: %v", err)
+	}
+	return todoList
+}
+
+func TestMergeEdit(t *testing.T) {
+	base := parseTestList(t, "# Todo List for main\n\n- [ ] Write docs\n- [ ] Fix login\n- [ ] Old task\n- [ ] Tag release\n")
+	// The editor renamed one item, checked another and added one
+	edited := parseTestList(t, "# Todo List for main\n\n- [ ] Write the docs\n- [x] Fix login\n- [ ] Old task\n- [ ] Tag release\n- [ ] Announce\n")
+	// Meanwhile the daemon checked the last item, a sync removed one and
+	// added another after the first item, and the checked item was edited
+	current := parseTestList(t, "---\ntarget: 2024-08-01\n---\n# Todo List for main\n\n- [ ] Write docs\n- [ ] Review PR\n- [ ] Fix login <!-- priority: p1 -->\n- [x] Tag release\n")
+
+	merge := MergeEdit(base, edited, current)
+	var lines []string
+	for i, item := range merge.List.Items {
+		if item.ID != i+1 {
+			t.Errorf("Expected item %d to be renumbered, got %d", i+1, item.ID)
+		}
+		lines = append(lines, formatItemLine(item))
+	}
+	want := []string{"- [ ] Write the docs", "- [ ] Review PR", "- [x] Fix login", "- [x] Tag release", "- [ ] Announce"}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected merge:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+	if merge.List.Meta.Target != "2024-08-01" {
+		t.Errorf("Expected the target set meanwhile, got %+v", merge.List.Meta)
+	}
+	// The frontmatter, the added item, the checked item and the removed one
+	if merge.Merged != 4 {
+		t.Errorf("Expected 4 merged changes, got %d", merge.Merged)
+	}
+	if len(merge.Conflicts) != 1 || !strings.Contains(merge.Conflicts[0], "'Fix login' was changed both") {
+		t.Errorf("Expected a conflict for 'Fix login', got %v", merge.Conflicts)
+	}
+}
+
+func TestEditTodoFileMergesConcurrentChanges(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("main")
+	AddTodoItem("main", "Write docs")
+
+	// The editor appends an item to its copy while the list itself gets
+	// another item
+	script := "#!/bin/sh\nprintf -- '- [ ] Added in the editor\\n' >> \"$1\"\nprintf -- '- [ ] Added meanwhile\\n' >> .todo/main.md\n"
+	os.WriteFile("editor.sh", []byte(script), 0755)
+	t.Setenv("EDITOR", "./editor.sh")
+
+	merge, err := EditTodoFile("main")
+	if err != nil {
+		t.Fatalf("EditTodoFile failed: %v", err)
+	}
+	if merge == nil || merge.Merged != 1 || len(merge.Conflicts) != 0 {
+		t.Fatalf("Expected one merged change, got %+v", merge)
+	}
+	todoList, _ := ParseTodoFile("main")
+	// The item added meanwhile goes after the item it followed
+	if len(todoList.Items) != 3 || todoList.Items[1].Text != "Added meanwhile" || todoList.Items[2].Text != "Added in the editor" {
+		t.Errorf("Expected both additions, got %+v", todoList.Items)
+	}
+
+	// Without changes meanwhile the edited file is written as it is
+	os.WriteFile("editor.sh", []byte("#!/bin/sh\nprintf -- '- [ ] Last\\n' >> \"$1\"\n"), 0755)
+	if merge, err := EditTodoFile("main"); err != nil || merge != nil {
+		t.Fatalf("EditTodoFile = %+v, %v", merge, err)
+	}
+	content, _ := os.ReadFile(GetTodoFilePath("main"))
+	if !strings.HasSuffix(string(content), "- [ ] Added in the editor\n- [ ] Last\n") {
+		t.Errorf("Unexpected file:\n%s", content)
+	}
+}
@@ -0,0 +1,29 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseEstimate reads an item estimate such as "30m" or "1h30m"
+func ParseEstimate(value string) (time.Duration, error) {
+	estimate, err := time.ParseDuration(value)
+	if err != nil || estimate <= 0 {
+		return 0, fmt.Errorf("invalid estimate %q (expected a duration such as 30m or 1h30m)", value)
+	}
+	return estimate, nil
+}
+
+// FormatEstimate writes an estimate without trailing zero units, e.g. "1h"
+// rather than "1h0m0s"
+func FormatEstimate(estimate time.Duration) string {
+	s := estimate.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
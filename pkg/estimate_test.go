@@ -0,0 +1,53 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatEstimate(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Minute:           "30m",
+		time.Hour:                  "1h",
+		90 * time.Minute:           "1h30m",
+		time.Hour + 30*time.Second: "1h0m30s",
+		45 * time.Second:           "45s",
+	}
+	for estimate, want := range tests {
+		got := FormatEstimate(estimate)
+		if got != want {
+			t.Errorf("FormatEstimate(%v) = %s, want %s", estimate, got, want)
+		}
+		if parsed, err := ParseEstimate(got); err != nil || parsed != estimate {
+			t.Errorf("ParseEstimate(%s) = %v, %v; want %v", got, parsed, err, estimate)
+		}
+	}
+
+	for _, value := range []string{"", "soon", "0m", "-5m"} {
+		if _, err := ParseEstimate(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestEstimateRoundTrip(t *testing.T) {
+	setupTestDir(t)
+
+	store := NewStore()
+	itemID, _ := store.AddItem("auth", "Login form")
+	if err := store.SetEstimate("auth", itemID, 90*time.Minute); err != nil {
+		t.Fatalf("SetEstimate failed: %v", err)
+	}
+	if err := store.SetEstimate("auth", itemID, 0); err == nil {
+		t.Error("Expected an error for a zero estimate")
+	}
+	store.Flush()
+
+	todoList, _ := ParseTodoFile("auth")
+	if todoList.Items[0].Estimate != 90*time.Minute {
+		t.Errorf("Expected a 90 minute estimate, got %v", todoList.Items[0].Estimate)
+	}
+	if got := formatItemLine(todoList.Items[0]); got != "- [ ] Login form <!-- estimate: 1h30m -->" {
+		t.Errorf("Unexpected line: %s", got)
+	}
+}
@@ -0,0 +1,113 @@
+package pkg
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormats are the formats 'todo export --format' writes
+var ExportFormats = []string{"json", "csv", "md", "todotxt"}
+
+// ExportedList is a list as 'todo export --format json' writes it
+type ExportedList struct {
+	Name  string         `json:"name"`
+	Items []ExportedItem `json:"items"`
+}
+
+// ExportedItem is an item with everything its list file keeps about it
+type ExportedItem struct {
+	JSONItem
+	ShortID     string `json:"short_id,omitempty"`
+	CompletedAt string `json:"completed_at,omitempty"`
+	// Metadata holds the item's other metadata keys, such as a linked issue
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+var exportCSVHeader = []string{"list", "id", "label", "text", "completed", "completed_at", "section", "depth", "due", "priority", "estimate", "weight", "short_id", "notes", "metadata"}
+
+// WriteExport writes the named lists to w in one of ExportFormats. JSON is
+// an array of lists, CSV a row per item, markdown each list as its file
+// reads, one after another, and todotxt a todo.txt line per item.
+// Completion times are written as RFC 3339, except in todo.txt.
+func WriteExport(w io.Writer, format string, names []string) error {
+	if !slices.Contains(ExportFormats, format) {
+		return fmt.Errorf("invalid export format '%s' (expected %s)", format, strings.Join(ExportFormats, ", "))
+	}
+
+	lists := make([]ExportedList, 0, len(names))
+	var files [][]byte
+	for _, name := range names {
+		labels, todoList, err := listLabels(name)
+		if err != nil {
+			return fmt.Errorf("failed to read list '%s': %w", name, err)
+		}
+		switch format {
+		case "md":
+			content, err := formatTodoFile(todoFileTitle(name), todoList)
+			if err != nil {
+				return err
+			}
+			files = append(files, content)
+			continue
+		case "todotxt":
+			if _, err := io.WriteString(w, FormatTodoTxt(todoList)); err != nil {
+				return err
+			}
+			continue
+		}
+		list := ExportedList{Name: name, Items: make([]ExportedItem, 0, len(todoList.Items))}
+		for i, item := range todoList.Items {
+			exported := ExportedItem{JSONItem: NewJSONItem(item, labels[i]), ShortID: item.ShortID, Metadata: item.Metadata}
+			if item.Completed && item.CompletedTime != nil {
+				exported.CompletedAt = item.CompletedTime.Format(time.RFC3339)
+			}
+			list.Items = append(list.Items, exported)
+		}
+		lists = append(lists, list)
+	}
+
+	switch format {
+	case "json":
+		return WriteJSON(w, lists)
+	case "csv":
+		return writeExportCSV(w, lists)
+	}
+	for i, content := range files {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if _, err := w.Write(content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeExportCSV writes a row per item. Notes are joined by newlines and
+// metadata as key=value pairs separated by "; ".
+func writeExportCSV(w io.Writer, lists []ExportedList) error {
+	out := csv.NewWriter(w)
+	out.Write(exportCSVHeader)
+	for _, list := range lists {
+		for _, item := range list.Items {
+			var metadata []string
+			for key, value := range item.Metadata {
+				metadata = append(metadata, key+"="+value)
+			}
+			sort.Strings(metadata)
+			out.Write([]string{
+				list.Name, strconv.Itoa(item.ID), item.Label, item.Text, strconv.FormatBool(item.Completed), item.CompletedAt,
+				item.Section, strconv.Itoa(item.Depth), item.Due, item.Priority, item.Estimate, strconv.Itoa(item.Weight),
+				item.ShortID, strings.Join(item.Notes, "\n"), strings.Join(metadata, "; "),
+			})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportFixture(t *testing.T) {
+	setupTestDir(t)
+	store := NewStore()
+	store.AddItem("auth", "Login, form")
+	store.AddItem("auth", "Logout")
+	store.AddNote("auth", 2, "Clear the session")
+	store.CheckItem("auth", 1)
+	todoList, _ := store.Get("auth")
+	todoList.Items[0].Metadata = map[string]string{"linear": "ENG-12"}
+	store.AddItem("billing", "Invoices")
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+}
+
+func TestWriteExportJSON(t *testing.T) {
+	exportFixture(t)
+	var out bytes.Buffer
+	if err := WriteExport(&out, "json", []string{"auth", "billing"}); err != nil {
+		t.Fatalf("WriteExport failed: %v", err)
+	}
+	var lists []ExportedList
+	if err := json.Unmarshal(out.Bytes(), &lists); err != nil {
+		t.Fatalf("Expected JSON, got %v:\n%s", err, out.String())
+	}
+	if len(lists) != 2 || lists[0].Name != "auth" || len(lists[0].Items) != 2 || lists[1].Name != "billing" {
+		t.Fatalf("Unexpected lists %+v", lists)
+	}
+	login := lists[0].Items[0]
+	if _, err := time.Parse(time.RFC3339, login.CompletedAt); err != nil || !login.Completed {
+		t.Errorf("Expected an RFC 3339 completion time, got %+v", login)
+	}
+	if login.Metadata["linear"] != "ENG-12" {
+		t.Errorf("Expected the item's metadata, got %v", login.Metadata)
+	}
+	if !reflect.DeepEqual(lists[0].Items[1].Notes, []string{"Clear the session"}) {
+		t.Errorf("Expected the item's notes, got %+v", lists[0].Items[1])
+	}
+}
+
+func TestWriteExportCSV(t *testing.T) {
+	exportFixture(t)
+	var out bytes.Buffer
+	if err := WriteExport(&out, "csv", []string{"auth"}); err != nil {
+		t.Fatalf("WriteExport failed: %v", err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected CSV, got %v", err)
+	}
+	if len(rows) != 3 || !reflect.DeepEqual(rows[0], exportCSVHeader) {
+		t.Fatalf("Expected a header and two rows, got %q", rows)
+	}
+	if rows[1][3] != "Login, form" || rows[1][4] != "true" || rows[1][5] == "" || !strings.Contains(rows[1][14], "linear=ENG-12") {
+		t.Errorf("Unexpected row %q", rows[1])
+	}
+	if rows[2][13] != "Clear the session" {
+		t.Errorf("Expected the notes column, got %q", rows[2])
+	}
+}
+
+func TestWriteExportMarkdown(t *testing.T) {
+	exportFixture(t)
+	var out bytes.Buffer
+	if err := WriteExport(&out, "md", []string{"auth", "billing"}); err != nil {
+		t.Fatalf("WriteExport failed: %v", err)
+	}
+	for _, want := range []string{"# Todo List for auth\n\n- [x] Login, form <!-- completed: ", "linear: ENG-12", "  Clear the session\n\n# Todo List for billing\n\n- [ ] Invoices"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, out.String())
+		}
+	}
+
+	if err := WriteExport(&out, "xml", []string{"auth"}); err == nil || !strings.Contains(err.Error(), "invalid export format") {
+		t.Errorf("Expected an invalid format error, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// ItemFilter selects items by their tags, contexts and estimates. Fields
+// left empty match every item.
+type ItemFilter struct {
+	// Tags and Contexts must all appear in the item text; a leading # or @
+	// is optional
+	Tags     []string
+	Contexts []string
+	// MaxEstimate keeps items estimated to take at most this long. Items
+	// without an estimate don't match.
+	MaxEstimate time.Duration
+}
+
+// DisplayFilter limits the items NewListView, list overviews and the
+// history show and count, set for one command by its --tag flag
+var DisplayFilter ItemFilter
+
+// IsZero reports whether the filter matches every item
+func (f ItemFilter) IsZero() bool {
+	return len(f.Tags) == 0 && len(f.Contexts) == 0 && f.MaxEstimate == 0
+}
+
+// Matches reports whether an item passes the filter
+func (f ItemFilter) Matches(item TodoItem) bool {
+	if len(f.Tags) > 0 {
+		tags := item.Tags()
+		for _, tag := range f.Tags {
+			if !slices.Contains(tags, strings.ToLower(strings.TrimPrefix(tag, "#"))) {
+				return false
+			}
+		}
+	}
+	if len(f.Contexts) > 0 {
+		contexts := ExtractContexts(item.Text)
+		for _, context := range f.Contexts {
+			if !slices.Contains(contexts, strings.ToLower(strings.TrimPrefix(context, "@"))) {
+				return false
+			}
+		}
+	}
+	if f.MaxEstimate > 0 && (item.Estimate == 0 || item.Estimate > f.MaxEstimate) {
+		return false
+	}
+	return true
+}
+
+// PendingItems returns the pending items of the named lists that pass the
+// filter, in list order
+func PendingItems(lists []string, filter ItemFilter) ([]ListItem, error) {
+	var items []ListItem
+	for _, parsed := range ParseLists(lists) {
+		if parsed.Err != nil {
+			return nil, parsed.Err
+		}
+		for _, item := range parsed.List.Items {
+			if !item.Completed && filter.Matches(item) {
+				items = append(items, ListItem{List: parsed.Name, Item: item})
+			}
+		}
+	}
+	return items, nil
+}
+
+// filterList returns a copy of a list holding only the items that pass the
+// filter, keeping their IDs
+func (f ItemFilter) filterList(todoList *TodoList) *TodoList {
+	filtered := *todoList
+	filtered.Items = nil
+	for _, item := range todoList.Items {
+		if f.Matches(item) {
+			filtered.Items = append(filtered.Items, item)
+		}
+	}
+	return &filtered
+}
+
+// filterView keeps the items of a view that pass the filter, with their
+// labels
+func filterView(view ListView, filter ItemFilter) ListView {
+	filtered := *view.List
+	filtered.Items = nil
+	var labels []string
+	for i, item := range view.List.Items {
+		if filter.Matches(item) {
+			filtered.Items = append(filtered.Items, item)
+			labels = append(labels, view.Labels[i])
+		}
+	}
+	view.List, view.Labels = &filtered, labels
+	return view
+}
@@ -0,0 +1,80 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestItemFilter(t *testing.T) {
+	items := []TodoItem{
+		{Text: "Call the bank @phone #money", Estimate: 10 * time.Minute},
+		{Text: "Fix the sink @home"},
+		{Text: "Review budget #money @home", Estimate: time.Hour},
+	}
+
+	tests := []struct {
+		name   string
+		filter ItemFilter
+		want   []bool
+	}{
+		{"empty", ItemFilter{}, []bool{true, true, true}},
+		{"tag", ItemFilter{Tags: []string{"#Money"}}, []bool{true, false, true}},
+		{"context", ItemFilter{Contexts: []string{"home"}}, []bool{false, true, true}},
+		{"both", ItemFilter{Tags: []string{"money"}, Contexts: []string{"@home"}}, []bool{false, false, true}},
+		{"estimate", ItemFilter{MaxEstimate: 30 * time.Minute}, []bool{true, false, false}},
+	}
+
+	for _, test := range tests {
+		for i, item := range items {
+			if got := test.filter.Matches(item); got != test.want[i] {
+				t.Errorf("%s: Matches(%q) = %v, want %v", test.name, item.Text, got, test.want[i])
+			}
+		}
+	}
+}
+
+func TestPendingItems(t *testing.T) {
+	setupTestDir(t)
+
+	CreateTodoFile("home")
+	AddTodoItem("home", "Fix the sink #chores")
+	AddTodoItem("home", "Mow the lawn #chores")
+	AddTodoItem("home", "Read a book")
+	CheckTodoItem("home", 2)
+	CreateTodoFile("work")
+	AddTodoItem("work", "Expense report #chores")
+
+	items, err := PendingItems([]string{"home", "work"}, ItemFilter{Tags: []string{"chores"}})
+	if err != nil {
+		t.Fatalf("PendingItems failed: %v", err)
+	}
+	if len(items) != 2 || items[0].Item.Text != "Fix the sink #chores" || items[1].List != "work" {
+		t.Errorf("Unexpected items: %+v", items)
+	}
+}
+
+func TestDisplayFilter(t *testing.T) {
+	DisplayFilter = ItemFilter{Tags: []string{"#chores"}}
+	defer func() { DisplayFilter = ItemFilter{} }()
+
+	list := &TodoList{Items: []TodoItem{
+		{ID: 1, Text: "Fix the sink #chores"},
+		{ID: 2, Text: "Read a book", Completed: true},
+		{ID: 3, Text: "Mow the lawn #chores", Completed: true},
+	}}
+	view := NewListView("home", list, &Config{}, time.Now())
+	if len(view.List.Items) != 2 || view.Labels[1] != "3" {
+		t.Errorf("Expected the tagged items with their numbers, got %+v %v", view.List.Items, view.Labels)
+	}
+	if progress := view.List.Progress(); progress.Total != 2 || progress.Completed != 1 {
+		t.Errorf("Expected progress of the tagged items, got %+v", progress)
+	}
+	if len(list.Items) != 3 {
+		t.Error("Filtering the view changed the list")
+	}
+
+	overview := summarizeList("home", list, &Config{}, time.Now())
+	if overview.Total != 2 || overview.Completed != 1 {
+		t.Errorf("Expected the overview to count the tagged items, got %+v", overview)
+	}
+}
@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ListMeta is the YAML frontmatter at the top of a list file, between two
+// "---" lines, holding settings that belong to the list as a whole:
+//
+//	---
+//	target: 2024-08-01
+//	---
+//	# Todo List for release
+type ListMeta struct {
+	// Target is the date, YYYY-MM-DD, the list should be finished by
+	Target string `yaml:"target,omitempty"`
+	// DependsOn names lists that should be complete before this one is
+	// worked on
+	DependsOn []string `yaml:"depends_on,omitempty"`
+	// CalDAV is the URL of the CalDAV task collection the list is synced
+	// with
+	CalDAV string `yaml:"caldav,omitempty"`
+	// Todoist is the name or id of the Todoist project the list is synced
+	// with
+	Todoist string `yaml:"todoist,omitempty"`
+	// Owner is who is responsible for the list: a git user name or email
+	Owner string `yaml:"owner,omitempty"`
+	// Reviewers are told when the list is complete, by notify rules with
+	// "to: reviewers"
+	Reviewers []string `yaml:"reviewers,omitempty"`
+	// Branch is the git branch the list was made for; 'todo prune' offers
+	// to archive the list once the branch is merged or deleted
+	Branch string `yaml:"branch,omitempty"`
+	// Extra keeps keys this version doesn't know about
+	Extra map[string]interface{} `yaml:",inline"`
+}
+
+// IsZero reports whether there is no frontmatter to write
+func (m ListMeta) IsZero() bool {
+	return m.Target == "" && len(m.DependsOn) == 0 && m.CalDAV == "" && m.Todoist == "" && m.Owner == "" && len(m.Reviewers) == 0 && m.Branch == "" && len(m.Extra) == 0
+}
+
+// TargetDate returns the parsed target date, or nil if there is none
+func (m ListMeta) TargetDate() (*time.Time, error) {
+	if m.Target == "" {
+		return nil, nil
+	}
+	target, err := time.ParseInLocation(DueDateFormat, m.Target, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target date %q (expected YYYY-MM-DD)", m.Target)
+	}
+	return &target, nil
+}
+
+// frontmatterDelimiter opens and closes the frontmatter block
+const frontmatterDelimiter = "---"
+
+func parseFrontmatter(lines []string) (ListMeta, error) {
+	var meta ListMeta
+	err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &meta)
+	return meta, err
+}
+
+func formatFrontmatter(meta ListMeta) (string, error) {
+	content, err := yaml.Marshal(meta)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode list frontmatter: %w", err)
+	}
+	return frontmatterDelimiter + "\n" + string(content) + frontmatterDelimiter + "\n", nil
+}
+
+// SetListTarget sets the date a list should be finished by, or clears it
+// when target is empty
+func SetListTarget(listName, target string) error {
+	meta := ListMeta{Target: target}
+	if _, err := meta.TargetDate(); err != nil {
+		return err
+	}
+
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return fmt.Errorf("failed to parse todo file: %w", err)
+	}
+	todoList.Meta.Target = target
+	return WriteTodoFile(listName, todoList)
+}
+
+// SetListCalDAV sets the CalDAV task collection a list is synced with, or
+// clears it when collection is empty
+func SetListCalDAV(listName, collection string) error {
+	if collection != "" {
+		parsed, err := url.Parse(collection)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid CalDAV collection URL %q (expected http:// or https://)", collection)
+		}
+	}
+
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return fmt.Errorf("failed to parse todo file: %w", err)
+	}
+	todoList.Meta.CalDAV = collection
+	return WriteTodoFile(listName, todoList)
+}
+
+// SetListTodoist sets the Todoist project a list is synced with, or clears
+// it when project is empty
+func SetListTodoist(listName, project string) error {
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return fmt.Errorf("failed to parse todo file: %w", err)
+	}
+	todoList.Meta.Todoist = strings.TrimSpace(project)
+	return WriteTodoFile(listName, todoList)
+}
+
+// LinkListToBranch records the git branch a list belongs to
+func LinkListToBranch(listName, branch string) error {
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return fmt.Errorf("failed to parse todo file: %w", err)
+	}
+	if todoList.Meta.Branch == branch {
+		return nil
+	}
+	todoList.Meta.Branch = branch
+	return WriteTodoFile(listName, todoList)
+}
@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// comparableItems strips what may legitimately differ between two parses of
+// equivalent files, leaving the item data that must round-trip
+func comparableItems(list *TodoList) []TodoItem {
+	items := make([]TodoItem, 0, len(list.Items))
+	for _, item := range list.Items {
+		if item.CompletedTime != nil {
+			completed := item.CompletedTime.UTC()
+			item.CompletedTime = &completed
+		}
+		if item.DueDate != nil {
+			due := item.DueDate.UTC()
+			item.DueDate = &due
+		}
+		// Where an item sits in the file depends on how it was written
+		item.Line = 0
+		items = append(items, item)
+	}
+	return items
+}
+
+func FuzzParseTodoFileRoundTrip(f *testing.F) {
+	seeds := []string{
+		"# Todo List for main\n\n- [ ] First\n- [x] Done (completed: 2024-01-15 10:30)\n",
+		"- [ ] Ship (due: 2024-07-01)\n- [X] Upper case\n",
+		"- [ ] a (due: 2024-13-45)\n- [x] b (completed: not a time)\n",
+		"- [x] a (completed: 2024-01-01 10:00) (completed: 2024-01-02 11:00)\n",
+		"- [ ] a (completed: 2024-01-01 10:00)\n",
+		"  - [ ] nested\n    - [x] deeper\n\t- [ ] tab\n",
+		"- [ ] émoji 🎉 and RTL שלום and combining é\n",
+		"- [ ] windows line endings\r\n- [x] two\r\n",
+		"- [ ] " + strings.Repeat("x", 100000) + "\n",
+		"- [ ] invalid \xff\xfe utf-8\n",
+		"- [ ] a\rb\n",
+		"not an item\n- [ ]\n- [ ]  \n- [y] no\n",
+		"---\ntarget: 2024-08-01\nowner: sam\n---\n# Todo List for x\n- [ ] a\n",
+		"---\ntarget: [\n---\n- [ ] a\n",
+		"---\n- [ ] unclosed\n",
+		"- [ ] top\n## Backend\n- [ ] a <!-- id: b7e2 -->\n##\tFrontend  \n- [x] b\n## Empty\n",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, content string) {
+		dir := t.TempDir()
+		original := filepath.Join(dir, "original.md")
+		written := filepath.Join(dir, "written.md")
+		os.WriteFile(original, []byte(content), 0644)
+
+		first, err := parseTodoFileAt(original)
+		if err != nil {
+			t.Fatalf("parse failed: %v", err)
+		}
+		if err := writeTodoFileAt(written, "Todo List for fuzz", first); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		second, err := parseTodoFileAt(written)
+		if err != nil {
+			t.Fatalf("reparse failed: %v", err)
+		}
+
+		if !reflect.DeepEqual(comparableItems(first), comparableItems(second)) {
+			t.Fatalf("round trip changed the items:\nfirst:  %+v\nsecond: %+v", first.Items, second.Items)
+		}
+		if first.Meta.Target != second.Meta.Target || len(first.Meta.Extra) != len(second.Meta.Extra) {
+			t.Fatalf("round trip changed the frontmatter:\nfirst:  %+v\nsecond: %+v", first.Meta, second.Meta)
+		}
+	})
+}
+
+func FuzzWriteTodoFileText(f *testing.F) {
+	for _, seed := range []string{"Login form", "multi\nline", "crlf\r\nline", "#tag (due: soon)", "🎉"} {
+		f.Add(seed, false)
+		f.Add(seed, true)
+	}
+
+	f.Fuzz(func(t *testing.T, text string, completed bool) {
+		path := filepath.Join(t.TempDir(), "list.md")
+		item := TodoItem{ID: 1, Text: text, Completed: completed}
+		if completed {
+			now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+			item.CompletedTime = &now
+		}
+
+		if err := writeTodoFileAt(path, "Todo List for fuzz", &TodoList{Items: []TodoItem{item}}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		list, err := parseTodoFileAt(path)
+		if err != nil {
+			t.Fatalf("parse failed: %v", err)
+		}
+
+		// Whatever the text, writing an item gives it back exactly
+		if len(list.Items) != 1 || list.Items[0].Text != text {
+			t.Fatalf("item %q was read back as %+v", text, list.Items)
+		}
+	})
+}
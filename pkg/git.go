@@ -0,0 +1,303 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// runGit runs a git command and returns its trimmed output
+func runGit(args ...string) (string, error) {
+	return runGitContext(context.Background(), args...)
+}
+
+// runGitContext is runGit, killing git if ctx is done first
+func runGitContext(ctx context.Context, args ...string) (string, error) {
+	output, err := runGitWithInputContext(ctx, nil, args...)
+	return strings.TrimSpace(string(output)), err
+}
+
+// runGitWithInput runs a git command with the given stdin and returns its
+// raw output
+func runGitWithInput(input []byte, args ...string) ([]byte, error) {
+	return runGitWithInputContext(context.Background(), input, args...)
+}
+
+func runGitWithInputContext(ctx context.Context, input []byte, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return output, nil
+}
+
+// IsGitRepo reports whether the current directory is inside a git work tree
+func IsGitRepo() bool {
+	output, err := runGit("rev-parse", "--is-inside-work-tree")
+	return err == nil && output == "true"
+}
+
+// Git settings for the git config key
+const (
+	GitAuto = "auto"
+	GitOff  = "off"
+)
+
+// GitEnabled reports whether git-derived features should be used: git
+// integration must not be turned off, we must be inside a repository and
+// not working on the global lists
+func GitEnabled(cfg *Config) bool {
+	return !Global && cfg.Git != GitOff && IsGitRepo()
+}
+
+// RequireGit returns a descriptive error when a git-only feature can't run
+func RequireGit() error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if Global {
+		return fmt.Errorf("git integration isn't used with the global lists in ~/.todo")
+	}
+	if cfg.Git == GitOff {
+		return fmt.Errorf("git integration is disabled (git: off in %s)", GetConfigPath())
+	}
+	if !IsGitRepo() {
+		return fmt.Errorf("not inside a git repository (set 'git: off' in %s to use todo without git)", GetConfigPath())
+	}
+	return nil
+}
+
+// GetRepoRoot returns the top-level directory of the current git repository
+func GetRepoRoot() (string, error) {
+	return runGit("rev-parse", "--show-toplevel")
+}
+
+// GetGitDir returns the path of the repository's .git directory
+func GetGitDir() (string, error) {
+	return runGit("rev-parse", "--git-dir")
+}
+
+// GetCurrentBranch returns the name of the checked out branch
+func GetCurrentBranch() (string, error) {
+	branch, err := runGit("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to get current branch: %w", err)
+	}
+	if branch == "HEAD" {
+		return "", fmt.Errorf("HEAD is detached")
+	}
+	return branch, nil
+}
+
+// GetFeatureName maps a branch name to its todo list name, so that
+// feature/auth uses the auth list and fix/login-bug uses fix-login-bug
+func GetFeatureName(branchName string) string {
+	name := strings.TrimPrefix(branchName, "feature/")
+	return strings.ReplaceAll(name, "/", "-")
+}
+
+// AddToGitignore appends an entry to the .gitignore in the current directory
+// unless it is already listed
+func AddToGitignore(entry string) error {
+	content, err := os.ReadFile(".gitignore")
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if gitignoreMatches(strings.TrimSpace(line), entry) {
+			return nil
+		}
+	}
+
+	file, err := os.OpenFile(".gitignore", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open .gitignore: %w", err)
+	}
+	defer file.Close()
+
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		entry = "\n" + entry
+	}
+	_, err = file.WriteString(entry + "\n")
+	return err
+}
+
+// gitignoreMatches reports whether a .gitignore line is the entry, written
+// with or without its trailing slash or a leading slash anchoring it to
+// the .gitignore's directory
+func gitignoreMatches(line, entry string) bool {
+	entry = strings.TrimSuffix(entry, "/")
+	line = strings.TrimSuffix(line, "/")
+	return line == entry || line == "/"+entry
+}
+
+// RemoveFromGitignore drops an entry from the .gitignore in the current directory
+func RemoveFromGitignore(entry string) error {
+	content, err := os.ReadFile(".gitignore")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+
+	var kept []string
+	removed := false
+	for _, line := range strings.Split(string(content), "\n") {
+		if gitignoreMatches(strings.TrimSpace(line), entry) {
+			removed = true
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	if !removed {
+		return nil
+	}
+	return os.WriteFile(".gitignore", []byte(strings.Join(kept, "\n")), 0644)
+}
+
+// IsIgnored reports whether the given path matches a gitignore rule, even if
+// it is already tracked
+func IsIgnored(path string) bool {
+	err := exec.Command("git", "check-ignore", "-q", "--no-index", path).Run()
+	return err == nil
+}
+
+// TrackedFiles returns the files under path that are tracked by git
+func TrackedFiles(path string) ([]string, error) {
+	output, err := runGit("ls-files", "--", path)
+	if err != nil {
+		return nil, err
+	}
+	if output == "" {
+		return nil, nil
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+// HasCommits reports whether the current repository has at least one commit
+func HasCommits() bool {
+	_, err := runGit("rev-parse", "--verify", "-q", "HEAD")
+	return err == nil
+}
+
+// BootstrapGitRepository prepares the current directory as a git repository:
+// it runs git init if needed, creates a README.md if there isn't one, and
+// makes an initial commit when the repository has no history yet
+func BootstrapGitRepository() error {
+	if !IsGitRepo() {
+		if _, err := runGit("init"); err != nil {
+			return err
+		}
+	}
+
+	if _, err := os.Stat("README.md"); os.IsNotExist(err) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		content := fmt.Sprintf("# %s\n", filepath.Base(cwd))
+		if err := os.WriteFile("README.md", []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to create README.md: %w", err)
+		}
+	}
+
+	if HasCommits() {
+		return nil
+	}
+
+	if _, err := runGit("add", "README.md"); err != nil {
+		return err
+	}
+	_, err := runGit("commit", "-m", "Initial commit")
+	return err
+}
+
+// ListBranches returns the names of all local branches
+func ListBranches() ([]string, error) {
+	output, err := runGit("for-each-ref", "--format=%(refname:short)", "refs/heads/")
+	if err != nil {
+		return nil, err
+	}
+	if output == "" {
+		return nil, nil
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+// BranchExists reports whether a local branch with the given name exists
+func BranchExists(branchName string) bool {
+	_, err := runGit("rev-parse", "--verify", "-q", "refs/heads/"+branchName)
+	return err == nil
+}
+
+// CreateBranch creates a branch from HEAD and checks it out
+func CreateBranch(branchName string) error {
+	_, err := runGit("checkout", "-b", branchName)
+	return err
+}
+
+// SwitchBranch checks out an existing branch. Git refuses when local changes
+// would be overwritten, and the error says which files are in the way.
+func SwitchBranch(branchName string) error {
+	_, err := runGit("checkout", branchName)
+	return err
+}
+
+// GetDefaultBranch returns the branch that feature branches are merged into:
+// the remote's HEAD if known, otherwise main or master
+func GetDefaultBranch() string {
+	if ref, err := runGit("symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimPrefix(ref, "origin/")
+	}
+	for _, candidate := range []string{"main", "master"} {
+		if BranchExists(candidate) {
+			return candidate
+		}
+	}
+	return "main"
+}
+
+// MergedBranches returns the local branches that are fully merged into base.
+// A branch whose tip is base's own tip is left out: it has no commits of its
+// own yet, such as a branch just made for new work, so there is nothing of it
+// to have merged.
+func MergedBranches(base string) ([]string, error) {
+	tip, err := runGit("rev-parse", "--verify", "-q", base)
+	if err != nil {
+		return nil, err
+	}
+	output, err := runGit("for-each-ref", "--format=%(objectname) %(refname:short)", "--merged", base, "refs/heads/")
+	if err != nil {
+		return nil, err
+	}
+	if output == "" {
+		return nil, nil
+	}
+
+	var branches []string
+	for _, line := range strings.Split(output, "\n") {
+		commit, branch, _ := strings.Cut(line, " ")
+		if commit != tip {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
@@ -0,0 +1,203 @@
+package pkg
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestGetFeatureName(t *testing.T) {
+	tests := []struct {
+		branchName string
+		expected   string
+	}{
+		{"main", "main"},
+		{"feature/auth", "auth"},
+		{"fix/login-bug", "fix-login-bug"},
+		{"feature/api/v2", "api-v2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branchName, func(t *testing.T) {
+			result := GetFeatureName(tt.branchName)
+			if result != tt.expected {
+				t.Errorf("GetFeatureName(%q) = %q, want %q", tt.branchName, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAddToGitignore(t *testing.T) {
+	setupTestDir(t)
+
+	os.WriteFile(".gitignore", []byte("build/"), 0644)
+
+	if err := AddToGitignore(".todo/"); err != nil {
+		t.Fatalf("AddToGitignore failed: %v", err)
+	}
+	// Adding the same entry twice should not duplicate it
+	if err := AddToGitignore(".todo/"); err != nil {
+		t.Fatalf("AddToGitignore failed: %v", err)
+	}
+
+	content, err := os.ReadFile(".gitignore")
+	if err != nil {
+		t.Fatalf("Failed to read .gitignore: %v", err)
+	}
+
+	expected := "build/\n.todo/\n"
+	if string(content) != expected {
+		t.Errorf(".gitignore = %q, want %q", string(content), expected)
+	}
+}
+
+func TestAddToGitignoreAnchored(t *testing.T) {
+	setupTestDir(t)
+
+	// An entry anchored with a leading slash already ignores it
+	for _, existing := range []string{"/.todo/\n", "/.todo\n"} {
+		os.WriteFile(".gitignore", []byte(existing), 0644)
+		if err := AddToGitignore(".todo/"); err != nil {
+			t.Fatalf("AddToGitignore failed: %v", err)
+		}
+		if content, _ := os.ReadFile(".gitignore"); string(content) != existing {
+			t.Errorf(".gitignore = %q, want %q", content, existing)
+		}
+	}
+}
+
+func TestRemoveFromGitignore(t *testing.T) {
+	setupTestDir(t)
+
+	// Every way of writing the entry is removed, anchored or not
+	os.WriteFile(".gitignore", []byte("build/\n/.todo/\n.todo\n/.todo\n.todo/\nnode_modules/\n"), 0644)
+	if err := RemoveFromGitignore(".todo/"); err != nil {
+		t.Fatalf("RemoveFromGitignore failed: %v", err)
+	}
+	if content, _ := os.ReadFile(".gitignore"); string(content) != "build/\nnode_modules/\n" {
+		t.Errorf(".gitignore = %q", content)
+	}
+}
+
+func TestBootstrapGitRepository(t *testing.T) {
+	setupTestDir(t)
+	t.Setenv("GIT_AUTHOR_NAME", "Test User")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test User")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	if err := BootstrapGitRepository(); err != nil {
+		t.Fatalf("BootstrapGitRepository failed: %v", err)
+	}
+
+	if !IsGitRepo() {
+		t.Error("Expected a git repository to be initialized")
+	}
+	if _, err := os.Stat("README.md"); err != nil {
+		t.Errorf("Expected README.md to be created: %v", err)
+	}
+	if !HasCommits() {
+		t.Error("Expected an initial commit")
+	}
+
+	// Running it again on a bootstrapped repository is a no-op
+	if err := BootstrapGitRepository(); err != nil {
+		t.Fatalf("BootstrapGitRepository failed on existing repository: %v", err)
+	}
+}
+
+func TestGitOffMode(t *testing.T) {
+	setupGitTestDir(t)
+
+	if err := RequireGit(); err != nil {
+		t.Fatalf("RequireGit should succeed inside a repository: %v", err)
+	}
+
+	SaveConfig(&Config{Git: GitOff, BranchTracking: true})
+	SetCurrentList("notes")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if GitEnabled(cfg) {
+		t.Error("GitEnabled should be false when git is turned off")
+	}
+	if err := RequireGit(); err == nil || !strings.Contains(err.Error(), "git: off") {
+		t.Errorf("RequireGit should explain that git is disabled, got %v", err)
+	}
+
+	// Branch tracking falls back to .current-list without git
+	currentList, err := GetCurrentList()
+	if err != nil {
+		t.Fatalf("GetCurrentList failed: %v", err)
+	}
+	if currentList != "notes" {
+		t.Errorf("GetCurrentList() = %q, want %q", currentList, "notes")
+	}
+}
+
+func TestRequireGitOutsideRepository(t *testing.T) {
+	setupTestDir(t)
+
+	if IsGitRepo() {
+		t.Skip("temp directory is inside a git repository")
+	}
+	if err := RequireGit(); err == nil || !strings.Contains(err.Error(), "not inside a git repository") {
+		t.Errorf("RequireGit should explain that no repository was found, got %v", err)
+	}
+}
+
+func TestBranchTracking(t *testing.T) {
+	setupGitTestDir(t)
+	git := func(args ...string) {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("commit", "-q", "--allow-empty", "-m", "Initial commit")
+	git("checkout", "-q", "-b", "feature/auth")
+	SetCurrentList("notes")
+
+	// Without branch tracking .current-list decides
+	if currentList, _ := GetCurrentList(); currentList != "notes" {
+		t.Errorf("GetCurrentList() = %q, want %q", currentList, "notes")
+	}
+
+	SaveConfig(&Config{BranchTracking: true})
+	for branch, want := range map[string]string{"feature/auth": "auth", "fix/login-bug": "fix-login-bug"} {
+		git("checkout", "-q", "-B", branch)
+		if currentList, err := GetCurrentList(); err != nil || currentList != want {
+			t.Errorf("GetCurrentList() on %s = %q, %v; want %q", branch, currentList, err, want)
+		}
+	}
+}
+
+func TestCreateAndSwitchBranch(t *testing.T) {
+	setupGitTestDir(t)
+	if out, err := exec.Command("git", "commit", "-q", "--allow-empty", "-m", "Initial commit").CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, out)
+	}
+	base, _ := GetCurrentBranch()
+
+	if err := CreateBranch("feature/auth"); err != nil {
+		t.Fatalf("CreateBranch failed: %v", err)
+	}
+	if branch, _ := GetCurrentBranch(); branch != "feature/auth" {
+		t.Errorf("Expected feature/auth to be checked out, got %q", branch)
+	}
+	if err := CreateBranch("feature/auth"); err == nil {
+		t.Error("Expected creating an existing branch to fail")
+	}
+
+	if err := SwitchBranch(base); err != nil {
+		t.Fatalf("SwitchBranch failed: %v", err)
+	}
+	if branch, _ := GetCurrentBranch(); branch != base {
+		t.Errorf("Expected %s to be checked out, got %q", base, branch)
+	}
+	if err := SwitchBranch("missing"); err == nil {
+		t.Error("Expected switching to a missing branch to fail")
+	}
+}
@@ -0,0 +1,301 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GitHubAPIURL is the base URL of the GitHub API
+var GitHubAPIURL = "https://api.github.com"
+
+// metaReviewComment is the metadata key holding the URL of the review
+// comment an item was imported from
+const metaReviewComment = "review_comment"
+
+var gitHubRemoteRegex = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// ReviewComment is the first comment of an unresolved review thread
+type ReviewComment struct {
+	Path   string
+	Line   int
+	Author string
+	Body   string
+	URL    string
+}
+
+// ItemText is the text of the item a review comment is imported as: the
+// first line of the comment followed by a link to the file and line
+func (c ReviewComment) ItemText() string {
+	text := strings.TrimSpace(c.Body)
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = strings.TrimSpace(text[:i]) + " …"
+	}
+	if c.Author != "" {
+		text += " (@" + c.Author + ")"
+	}
+
+	location := c.Path
+	if c.Line > 0 {
+		location = fmt.Sprintf("%s:%d", c.Path, c.Line)
+	}
+	if location == "" {
+		location = "comment"
+	}
+	return fmt.Sprintf("%s [%s](%s)", text, location, c.URL)
+}
+
+// GitHubRepoFromRemote returns the owner and name of a GitHub repository
+// from a remote URL such as git@github.com:owner/repo.git
+func GitHubRepoFromRemote(remote string) (string, string, error) {
+	match := gitHubRemoteRegex.FindStringSubmatch(strings.TrimSpace(remote))
+	if match == nil {
+		return "", "", fmt.Errorf("%q is not a GitHub repository URL", remote)
+	}
+	return match[1], match[2], nil
+}
+
+// CurrentGitHubRepo returns the owner and name of the GitHub repository the
+// origin remote points to
+func CurrentGitHubRepo() (string, string, error) {
+	remote, err := runGit("remote", "get-url", "origin")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read the origin remote: %w", err)
+	}
+	return GitHubRepoFromRemote(remote)
+}
+
+// NewGitHubClient returns an API client authenticated with the stored
+// github credential
+func NewGitHubClient() (*APIClient, error) {
+	token, err := GetCredential("github")
+	if err != nil {
+		return nil, err
+	}
+	client := NewAPIClient(GitHubAPIURL, 10)
+	client.Header.Set("Authorization", "Bearer "+token)
+	return client, nil
+}
+
+const reviewThreadsQuery = `query($owner: String!, $name: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          isResolved
+          path
+          line
+          originalLine
+          comments(first: 1) {
+            nodes { body url author { login } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type reviewThreadsResponse struct {
+	Data struct {
+		Repository *struct {
+			PullRequest *struct {
+				ReviewThreads struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []struct {
+						IsResolved   bool   `json:"isResolved"`
+						Path         string `json:"path"`
+						Line         int    `json:"line"`
+						OriginalLine int    `json:"originalLine"`
+						Comments     struct {
+							Nodes []struct {
+								Body   string `json:"body"`
+								URL    string `json:"url"`
+								Author *struct {
+									Login string `json:"login"`
+								} `json:"author"`
+							} `json:"nodes"`
+						} `json:"comments"`
+					} `json:"nodes"`
+				} `json:"reviewThreads"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// UnresolvedReviewComments returns the first comment of every unresolved
+// review thread on a pull request, in the order GitHub lists them
+func UnresolvedReviewComments(client *APIClient, owner, name string, number int) ([]ReviewComment, error) {
+	var comments []ReviewComment
+	var cursor *string
+	for {
+		request := map[string]interface{}{
+			"query": reviewThreadsQuery,
+			"variables": map[string]interface{}{
+				"owner": owner, "name": name, "number": number, "cursor": cursor,
+			},
+		}
+		var response reviewThreadsResponse
+		if err := client.Do("POST", "/graphql", request, &response); err != nil {
+			return nil, err
+		}
+		if len(response.Errors) > 0 {
+			return nil, fmt.Errorf("GitHub API error: %s", response.Errors[0].Message)
+		}
+		if response.Data.Repository == nil || response.Data.Repository.PullRequest == nil {
+			return nil, fmt.Errorf("pull request %s/%s#%d not found", owner, name, number)
+		}
+
+		threads := response.Data.Repository.PullRequest.ReviewThreads
+		for _, thread := range threads.Nodes {
+			if thread.IsResolved || len(thread.Comments.Nodes) == 0 {
+				continue
+			}
+			first := thread.Comments.Nodes[0]
+			comment := ReviewComment{Path: thread.Path, Line: thread.Line, Body: first.Body, URL: first.URL}
+			// Comments on lines that have since changed only have the
+			// line they were made on
+			if comment.Line == 0 {
+				comment.Line = thread.OriginalLine
+			}
+			if first.Author != nil {
+				comment.Author = first.Author.Login
+			}
+			comments = append(comments, comment)
+		}
+
+		if !threads.PageInfo.HasNextPage {
+			return comments, nil
+		}
+		endCursor := threads.PageInfo.EndCursor
+		cursor = &endCursor
+	}
+}
+
+// ImportReviewComments adds review comments to a list as pending items,
+// creating the list if needed. Comments imported before are skipped. It
+// returns how many items were added.
+func ImportReviewComments(listName string, comments []ReviewComment) (int, error) {
+	if err := CreateTodoFile(listName); err != nil {
+		return 0, err
+	}
+
+	store := NewStore()
+	todoList, err := store.Get(listName)
+	if err != nil {
+		return 0, err
+	}
+	imported := make(map[string]bool)
+	for _, item := range todoList.Items {
+		if url := item.Metadata[metaReviewComment]; url != "" {
+			imported[url] = true
+		}
+	}
+
+	added := 0
+	for _, comment := range comments {
+		if imported[comment.URL] {
+			continue
+		}
+		itemID, err := store.AddItem(listName, comment.ItemText())
+		if err != nil {
+			return added, err
+		}
+		todoList.Items[itemID-1].Metadata[metaReviewComment] = comment.URL
+		if comment.Path != "" {
+			todoList.Items[itemID-1].Metadata[metaAnchor] = Anchor{Path: comment.Path, Line: comment.Line}.String()
+		}
+		imported[comment.URL] = true
+		added++
+	}
+
+	if added == 0 {
+		return 0, nil
+	}
+	return added, store.Flush()
+}
+
+// Metadata keys for items promoted to GitHub issues
+const (
+	// metaIssue holds the URL of the issue an item was promoted to
+	metaIssue = "issue"
+	// metaCloseIssue is "true" when checking the item should close its issue
+	metaCloseIssue = "close_issue"
+)
+
+var gitHubIssueURLRegex = regexp.MustCompile(`^https://github\.com/([^/]+)/([^/]+)/issues/(\d+)$`)
+
+// maxIssueTitle is the longest title GitHub accepts
+const maxIssueTitle = 256
+
+// CreateGitHubIssue opens an issue and returns its URL
+func CreateGitHubIssue(client *APIClient, owner, name, title, body string) (string, error) {
+	if runes := []rune(title); len(runes) > maxIssueTitle {
+		title = string(runes[:maxIssueTitle-1]) + "…"
+	}
+
+	var issue struct {
+		HTMLURL string `json:"html_url"`
+	}
+	request := map[string]string{"title": title, "body": body}
+	if err := client.Do("POST", fmt.Sprintf("/repos/%s/%s/issues", owner, name), request, &issue); err != nil {
+		return "", err
+	}
+	return issue.HTMLURL, nil
+}
+
+// CloseGitHubIssue closes the issue at a URL such as
+// https://github.com/owner/repo/issues/12 as completed
+func CloseGitHubIssue(client *APIClient, issueURL string) error {
+	match := gitHubIssueURLRegex.FindStringSubmatch(issueURL)
+	if match == nil {
+		return fmt.Errorf("%q is not a GitHub issue URL", issueURL)
+	}
+	request := map[string]string{"state": "closed", "state_reason": "completed"}
+	return client.Do("PATCH", fmt.Sprintf("/repos/%s/%s/issues/%s", match[1], match[2], match[3]), request, nil)
+}
+
+// PromoteToGitHub creates an issue from an item and records the issue URL
+// in the item's metadata. When closeOnCheck is set, checking the item later
+// closes the issue.
+func PromoteToGitHub(client *APIClient, owner, name, listName string, itemID int, closeOnCheck bool) (string, error) {
+	store := NewStore()
+	item, err := store.item(listName, itemID)
+	if err != nil {
+		return "", err
+	}
+	if existing := item.Metadata[metaIssue]; existing != "" {
+		return "", fmt.Errorf("item %d was already promoted to %s", itemID, existing)
+	}
+
+	body := fmt.Sprintf("%s\n\n_Promoted from the todo list '%s'._", item.Text, listName)
+	issueURL, err := CreateGitHubIssue(client, owner, name, item.Text, body)
+	if err != nil {
+		return "", err
+	}
+
+	if item.Metadata == nil {
+		item.Metadata = make(map[string]string)
+	}
+	item.Metadata[metaIssue] = issueURL
+	if closeOnCheck {
+		item.Metadata[metaCloseIssue] = "true"
+	}
+	store.MarkDirty(listName)
+	return issueURL, store.Flush()
+}
+
+// IssueToClose returns the URL of the issue to close when an item is
+// checked, or "" if it has none or wasn't promoted with closeOnCheck
+func IssueToClose(item TodoItem) string {
+	if item.Metadata[metaCloseIssue] != "true" {
+		return ""
+	}
+	return item.Metadata[metaIssue]
+}
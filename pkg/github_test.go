@@ -0,0 +1,206 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGitHubRepoFromRemote(t *testing.T) {
+	tests := []struct {
+		remote, owner, name string
+	}{
+		{"git@github.com:scttymn/todo-cli.git", "scttymn", "todo-cli"},
+		{"https://github.com/scttymn/todo-cli", "scttymn", "todo-cli"},
+		{"https://github.com/scttymn/todo-cli.git\n", "scttymn", "todo-cli"},
+		{"ssh://git@github.com/scttymn/todo.cli/", "scttymn", "todo.cli"},
+	}
+	for _, tt := range tests {
+		owner, name, err := GitHubRepoFromRemote(tt.remote)
+		if err != nil || owner != tt.owner || name != tt.name {
+			t.Errorf("GitHubRepoFromRemote(%q) = %q, %q, %v", tt.remote, owner, name, err)
+		}
+	}
+
+	if _, _, err := GitHubRepoFromRemote("https://gitlab.com/scttymn/todo-cli.git"); err == nil {
+		t.Error("Expected an error for a non-GitHub remote")
+	}
+}
+
+func TestReviewCommentItemText(t *testing.T) {
+	comment := ReviewComment{Path: "pkg/todo.go", Line: 42, Author: "alice", Body: "Rename this\n\nIt shadows the package name", URL: "https://github.com/o/r/pull/1#discussion_r7"}
+	want := "Rename this … (@alice) [pkg/todo.go:42](https://github.com/o/r/pull/1#discussion_r7)"
+	if got := comment.ItemText(); got != want {
+		t.Errorf("ItemText() = %q, want %q", got, want)
+	}
+}
+
+func TestUnresolvedReviewComments(t *testing.T) {
+	pages := []string{
+		`{"data":{"repository":{"pullRequest":{"reviewThreads":{
+			"pageInfo":{"hasNextPage":true,"endCursor":"c1"},
+			"nodes":[
+				{"isResolved":true,"path":"a.go","line":1,"comments":{"nodes":[{"body":"Done already","url":"u1","author":{"login":"bob"}}]}},
+				{"isResolved":false,"path":"b.go","line":0,"originalLine":9,"comments":{"nodes":[{"body":"Outdated but open","url":"u2","author":null}]}}
+			]}}}}}`,
+		`{"data":{"repository":{"pullRequest":{"reviewThreads":{
+			"pageInfo":{"hasNextPage":false,"endCursor":"c2"},
+			"nodes":[
+				{"isResolved":false,"path":"c.go","line":3,"comments":{"nodes":[{"body":"Add a test","url":"u3","author":{"login":"alice"}}]}}
+			]}}}}}`,
+	}
+
+	var cursors []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var request struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		cursors = append(cursors, request.Variables["cursor"])
+		fmt.Fprint(w, pages[len(cursors)-1])
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, 0)
+	client.Header.Set("Authorization", "Bearer secret")
+	comments, err := UnresolvedReviewComments(client, "o", "r", 1)
+	if err != nil {
+		t.Fatalf("UnresolvedReviewComments failed: %v", err)
+	}
+
+	if len(cursors) != 2 || cursors[0] != nil || cursors[1] != "c1" {
+		t.Errorf("Unexpected page cursors: %v", cursors)
+	}
+	if len(comments) != 2 {
+		t.Fatalf("Expected 2 unresolved comments, got %+v", comments)
+	}
+	if comments[0] != (ReviewComment{Path: "b.go", Line: 9, Body: "Outdated but open", URL: "u2"}) {
+		t.Errorf("Unexpected first comment: %+v", comments[0])
+	}
+	if comments[1].Author != "alice" || comments[1].Line != 3 {
+		t.Errorf("Unexpected second comment: %+v", comments[1])
+	}
+}
+
+func TestUnresolvedReviewCommentsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"repository":{"pullRequest":null}},"errors":[{"message":"Could not resolve to a PullRequest with the number of 99."}]}`)
+	}))
+	defer server.Close()
+
+	_, err := UnresolvedReviewComments(NewAPIClient(server.URL, 0), "o", "r", 99)
+	if err == nil || !strings.Contains(err.Error(), "Could not resolve") {
+		t.Errorf("Expected the GraphQL error, got %v", err)
+	}
+}
+
+func TestImportReviewComments(t *testing.T) {
+	setupTestDir(t)
+
+	comments := []ReviewComment{
+		{Path: "main.go", Line: 10, Author: "alice", Body: "Handle the error", URL: "https://github.com/o/r/pull/1#discussion_r1"},
+		{Path: "pkg/todo.go", Line: 4, Body: "Typo", URL: "https://github.com/o/r/pull/1#discussion_r2"},
+	}
+	added, err := ImportReviewComments("review", comments)
+	if err != nil || added != 2 {
+		t.Fatalf("ImportReviewComments = %d, %v; want 2", added, err)
+	}
+
+	// Importing again only adds new comments
+	comments = append(comments, ReviewComment{Path: "README.md", Line: 1, Body: "Mention the flag", URL: "https://github.com/o/r/pull/1#discussion_r3"})
+	added, err = ImportReviewComments("review", comments)
+	if err != nil || added != 1 {
+		t.Fatalf("ImportReviewComments = %d, %v; want 1", added, err)
+	}
+
+	todoList, err := ParseTodoFile("review")
+	if err != nil {
+		t.Fatalf("ParseTodoFile failed: %v", err)
+	}
+	if len(todoList.Items) != 3 {
+		t.Fatalf("Expected 3 items, got %+v", todoList.Items)
+	}
+	if todoList.Items[0].Text != "Handle the error (@alice) [main.go:10](https://github.com/o/r/pull/1#discussion_r1)" {
+		t.Errorf("Unexpected item text %q", todoList.Items[0].Text)
+	}
+	if todoList.Items[2].Metadata[metaReviewComment] != comments[2].URL {
+		t.Errorf("Expected the comment URL in metadata, got %v", todoList.Items[2].Metadata)
+	}
+	if anchor, ok := ItemAnchor(todoList.Items[1]); !ok || anchor != (Anchor{Path: "pkg/todo.go", Line: 4}) {
+		t.Errorf("Expected the comment's location as the anchor, got %v", todoList.Items[1].Metadata)
+	}
+}
+
+func TestPromoteToGitHub(t *testing.T) {
+	setupTestDir(t)
+	AddTodoItem("main", "Support #tags in titles")
+
+	var requests []string
+	var created map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case "POST":
+			json.NewDecoder(r.Body).Decode(&created)
+			fmt.Fprint(w, `{"number":12,"html_url":"https://github.com/o/r/issues/12"}`)
+		case "PATCH":
+			var update map[string]string
+			json.NewDecoder(r.Body).Decode(&update)
+			if update["state"] != "closed" {
+				http.Error(w, "expected state closed", http.StatusBadRequest)
+			}
+		}
+	}))
+	defer server.Close()
+	client := NewAPIClient(server.URL, 0)
+
+	issueURL, err := PromoteToGitHub(client, "o", "r", "main", 1, true)
+	if err != nil {
+		t.Fatalf("PromoteToGitHub failed: %v", err)
+	}
+	if issueURL != "https://github.com/o/r/issues/12" {
+		t.Errorf("Unexpected issue URL %q", issueURL)
+	}
+	if created["title"] != "Support #tags in titles" || !strings.Contains(created["body"], "'main'") {
+		t.Errorf("Unexpected issue: %v", created)
+	}
+
+	todoList, _ := ParseTodoFile("main")
+	if IssueToClose(todoList.Items[0]) != issueURL {
+		t.Errorf("Expected the issue to be closed on check, metadata %v", todoList.Items[0].Metadata)
+	}
+
+	// An item is only promoted once
+	if _, err := PromoteToGitHub(client, "o", "r", "main", 1, false); err == nil {
+		t.Error("Expected an error promoting the item again")
+	}
+
+	if err := CloseGitHubIssue(client, issueURL); err != nil {
+		t.Fatalf("CloseGitHubIssue failed: %v", err)
+	}
+	want := []string{"POST /repos/o/r/issues", "PATCH /repos/o/r/issues/12"}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("Requests = %v, want %v", requests, want)
+	}
+	if err := CloseGitHubIssue(client, "https://example.com/issues/1"); err == nil {
+		t.Error("Expected an error for a non-GitHub issue URL")
+	}
+}
+
+func TestIssueToClose(t *testing.T) {
+	promoted := TodoItem{Metadata: map[string]string{metaIssue: "https://github.com/o/r/issues/1"}}
+	if IssueToClose(promoted) != "" {
+		t.Error("Issues should only be closed when promoted with close-on-check")
+	}
+	promoted.Metadata[metaCloseIssue] = "true"
+	if IssueToClose(promoted) != "https://github.com/o/r/issues/1" {
+		t.Error("Expected the issue URL")
+	}
+}
@@ -0,0 +1,43 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Global is set by --global or TODO_GLOBAL=1. Commands then work on the
+// .todo directory in the home directory, for personal lists that don't
+// belong to a project, and git features are turned off.
+var Global bool
+
+// GlobalFromEnv reports whether TODO_GLOBAL asks for the global lists
+func GlobalFromEnv() bool {
+	global, _ := strconv.ParseBool(os.Getenv("TODO_GLOBAL"))
+	return global
+}
+
+// UseGlobalDirectory switches to the home directory, so that .todo is
+// ~/.todo, and sets Global
+func UseGlobalDirectory() error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to find home directory: %w", err)
+	}
+	if err := os.Chdir(home); err != nil {
+		return fmt.Errorf("failed to switch to home directory: %w", err)
+	}
+	Global = true
+	return nil
+}
+
+// GetCurrentListPath returns the file recording the selected list. It sits
+// beside .todo in a project, and inside ~/.todo for the global lists so it
+// doesn't clutter the home directory.
+func GetCurrentListPath() string {
+	if Global {
+		return filepath.Join(".todo", ".current-list")
+	}
+	return ".current-list"
+}
@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUseGlobalDirectory(t *testing.T) {
+	setupTestDir(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Cleanup(func() { Global = false })
+
+	if err := UseGlobalDirectory(); err != nil {
+		t.Fatalf("UseGlobalDirectory failed: %v", err)
+	}
+	if err := SetCurrentList("personal"); err != nil {
+		t.Fatalf("SetCurrentList failed: %v", err)
+	}
+	store := NewStore()
+	store.AddItem("personal", "Renew passport")
+	store.Flush()
+
+	if _, err := os.Stat(filepath.Join(home, ".todo", "personal.md")); err != nil {
+		t.Errorf("Expected the list in ~/.todo: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".todo", ".current-list")); err != nil {
+		t.Errorf("Expected the current list to be recorded in ~/.todo: %v", err)
+	}
+	if current, _ := GetCurrentList(); current != "personal" {
+		t.Errorf("Expected current list personal, got %q", current)
+	}
+	if GitEnabled(DefaultConfig()) {
+		t.Error("Expected git features to be off for the global lists")
+	}
+}
+
+func TestGlobalFromEnv(t *testing.T) {
+	for value, want := range map[string]bool{"1": true, "true": true, "0": false, "": false, "yes": false} {
+		t.Setenv("TODO_GLOBAL", value)
+		if got := GlobalFromEnv(); got != want {
+			t.Errorf("TODO_GLOBAL=%q: got %v, want %v", value, got, want)
+		}
+	}
+}
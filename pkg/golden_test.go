@@ -0,0 +1,149 @@
+package pkg
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The golden files pin down exactly what each import and export format
+// writes, so a change to any of them shows up as a failing test rather than
+// as drift between releases. Every list in testdata/golden/lists is written
+// in every format and compared with testdata/golden/<format>/<list><ext>;
+// formats that can also be read must read their golden file back to the
+// same bytes. After an intended format change, rewrite the files with
+//
+//	go test ./pkg -run TestGoldenFormats -update
+//
+// and review the diff.
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// goldenNow is the time formats that depend on it are written at
+var goldenNow = time.Date(2024, 7, 3, 12, 0, 0, 0, time.Local)
+
+// goldenFormat is a format lists are imported from or exported to. Formats
+// that are only ever written leave decode nil.
+type goldenFormat struct {
+	name   string
+	ext    string
+	encode func(todoList *TodoList) ([]byte, error)
+	decode func(data []byte) (*TodoList, error)
+}
+
+var goldenFormats = []goldenFormat{
+	{
+		// The markdown of a list file
+		name: "todo",
+		ext:  ".md",
+		encode: func(todoList *TodoList) ([]byte, error) {
+			return formatTodoFile(todoFileTitle("golden"), todoList)
+		},
+		decode: func(data []byte) (*TodoList, error) {
+			return parseTodoList(bytes.NewReader(data))
+		},
+	},
+	{
+		// GitHub task list markdown, from todo export issue-body and read
+		// back by todo paste
+		name: "github",
+		ext:  ".md",
+		encode: func(todoList *TodoList) ([]byte, error) {
+			return []byte(FormatIssueBody(todoList)), nil
+		},
+		decode: func(data []byte) (*TodoList, error) {
+			return &TodoList{Items: ParsePastedMarkdown(string(data))}, nil
+		},
+	},
+	{
+		// todo list --format json
+		name: "json",
+		ext:  ".json",
+		encode: func(todoList *TodoList) ([]byte, error) {
+			var out bytes.Buffer
+			err := JSONRenderer{}.RenderList(&out, NewListView("golden", todoList, &Config{}, goldenNow))
+			return out.Bytes(), err
+		},
+	},
+	{
+		// todo export timeseries, over the week up to goldenNow
+		name: "timeseries",
+		ext:  ".csv",
+		encode: func(todoList *TodoList) ([]byte, error) {
+			lists := []ParsedList{{Name: "golden", List: todoList}}
+			points, err := ProgressTimeSeries(lists, Snapshots{}, goldenNow.AddDate(0, 0, -6), goldenNow)
+			if err != nil {
+				return nil, err
+			}
+			var out bytes.Buffer
+			err = WriteProgressCSV(&out, points)
+			return out.Bytes(), err
+		},
+	},
+}
+
+func TestGoldenFormats(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "golden", "lists", "*.md"))
+	if err != nil || len(fixtures) == 0 {
+		t.Fatalf("No golden lists found: %v", err)
+	}
+	// Completion times are written with the local offset, so pin it for
+	// the files to be the same on every machine
+	local := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = local })
+
+	for _, format := range goldenFormats {
+		for _, fixture := range fixtures {
+			name := strings.TrimSuffix(filepath.Base(fixture), ".md")
+			t.Run(format.name+"/"+name, func(t *testing.T) {
+				content, err := os.ReadFile(fixture)
+				if err != nil {
+					t.Fatal(err)
+				}
+				todoList, err := parseTodoList(bytes.NewReader(content))
+				if err != nil {
+					t.Fatalf("Failed to parse %s: %v", fixture, err)
+				}
+				got, err := format.encode(todoList)
+				if err != nil {
+					t.Fatalf("encode failed: %v", err)
+				}
+
+				golden := filepath.Join("testdata", "golden", format.name, name+format.ext)
+				if *updateGolden {
+					os.MkdirAll(filepath.Dir(golden), 0755)
+					if err := os.WriteFile(golden, got, 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("Missing golden file (run with -update to create it): %v", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("%s differs from its golden file:\ngot:\n%s\nwant:\n%s", golden, got, want)
+				}
+
+				if format.decode == nil {
+					return
+				}
+				decoded, err := format.decode(want)
+				if err != nil {
+					t.Fatalf("decode failed: %v", err)
+				}
+				again, err := format.encode(decoded)
+				if err != nil {
+					t.Fatalf("encode after decode failed: %v", err)
+				}
+				if !bytes.Equal(again, want) {
+					t.Fatalf("%s doesn't survive a round trip:\ngot:\n%s\nwant:\n%s", golden, again, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// A list's health score runs from 100 (nothing needs attention) down to 0.
+// Each factor scores a penalty from 0 to 1, and the score drops by the
+// weighted average of the penalties:
+//
+//   - staleness: days since the list last changed, fully stale after
+//     healthStaleDays
+//   - overdue: the share of pending items that are past their due date
+//   - velocity: how far completions in the last week fell behind the week
+//     before
+//   - wip: pending items with tracked time, beyond the WIP limit, fully
+//     penalized at twice the limit
+//
+// Finished lists are always healthy.
+
+// Health factors, as named in the health.weights setting
+const (
+	HealthStaleness = "staleness"
+	HealthOverdue   = "overdue"
+	HealthVelocity  = "velocity"
+	HealthWIP       = "wip"
+)
+
+// healthFactors lists the factors in the order their reasons are shown
+var healthFactors = []string{HealthStaleness, HealthOverdue, HealthVelocity, HealthWIP}
+
+const (
+	// healthStaleDays is how long without changes makes a list fully stale
+	healthStaleDays = 14
+	// DefaultWIPLimit is how many started items a list can have before it
+	// counts against its health
+	DefaultWIPLimit = 3
+)
+
+// HealthConfig tunes the list health score
+type HealthConfig struct {
+	// Weights sets how much each factor counts (default 1 each); 0 leaves a
+	// factor out
+	Weights map[string]float64 `yaml:"weights,omitempty"`
+	// WIPLimit is how many started items are fine (default 3)
+	WIPLimit int `yaml:"wip_limit,omitempty"`
+}
+
+// ListHealth is the health of one list and what it is made of
+type ListHealth struct {
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+	// Pending items left; a list without any is finished
+	Pending int `json:"pending"`
+	// StaleDays counts the days since the list last changed
+	StaleDays int `json:"stale_days"`
+	Overdue   int `json:"overdue"`
+	// Recent and Previous count the items completed in the last week and
+	// the week before
+	Recent   int `json:"recent"`
+	Previous int `json:"previous"`
+	// WIP counts pending items with tracked time
+	WIP int `json:"wip"`
+	// Reasons describe the factors that lowered the score
+	Reasons []string `json:"reasons"`
+}
+
+// healthWeights returns the weight of every factor, rejecting unknown ones
+func healthWeights(cfg *Config) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, factor := range healthFactors {
+		weights[factor] = 1
+	}
+	for factor, weight := range cfg.Health.Weights {
+		if _, ok := weights[factor]; !ok {
+			return nil, fmt.Errorf("unknown health factor %q in health.weights (expected staleness, overdue, velocity or wip)", factor)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("health weight of %s must not be negative", factor)
+		}
+		weights[factor] = weight
+	}
+	return weights, nil
+}
+
+// GetListHealth scores every list, least healthy first
+func GetListHealth(cfg *Config, now time.Time) ([]ListHealth, error) {
+	weights, err := healthWeights(cfg)
+	if err != nil {
+		return nil, err
+	}
+	wipLimit := cfg.Health.WIPLimit
+	if wipLimit <= 0 {
+		wipLimit = DefaultWIPLimit
+	}
+
+	names, err := GetAllLists()
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := Sessions()
+	if err != nil {
+		return nil, err
+	}
+	started := make(map[string]bool)
+	for _, session := range sessions {
+		started[session.List+"\x00"+session.Item] = true
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	weekAgo := now.AddDate(0, 0, -7)
+	twoWeeksAgo := now.AddDate(0, 0, -14)
+
+	var healths []ListHealth
+	for _, parsed := range ParseLists(names) {
+		if parsed.Err != nil {
+			return nil, parsed.Err
+		}
+
+		health := ListHealth{Name: parsed.Name}
+		var lastActivity *time.Time
+		for _, item := range parsed.List.Items {
+			if item.Completed {
+				lastActivity = laterTime(lastActivity, item.CompletedTime)
+				if item.CompletedTime == nil {
+					continue
+				}
+				if item.CompletedTime.After(weekAgo) {
+					health.Recent++
+				} else if item.CompletedTime.After(twoWeeksAgo) {
+					health.Previous++
+				}
+				continue
+			}
+			health.Pending++
+			if item.DueDate != nil && item.DueDate.Before(today) {
+				health.Overdue++
+			}
+			if started[parsed.Name+"\x00"+item.Text] {
+				health.WIP++
+			}
+		}
+		if modified := ListModTime(parsed.Name); !modified.IsZero() {
+			lastActivity = laterTime(lastActivity, &modified)
+		}
+		if lastActivity != nil {
+			health.StaleDays = DaysLate(*lastActivity, now)
+		}
+
+		scoreHealth(&health, weights, wipLimit)
+		healths = append(healths, health)
+	}
+
+	sort.SliceStable(healths, func(i, j int) bool {
+		return healths[i].Score < healths[j].Score
+	})
+	return healths, nil
+}
+
+// scoreHealth works out a list's score and the reasons for it
+func scoreHealth(health *ListHealth, weights map[string]float64, wipLimit int) {
+	health.Score = 100
+	if health.Pending == 0 {
+		return
+	}
+
+	penalties := map[string]float64{
+		HealthStaleness: min(float64(health.StaleDays)/healthStaleDays, 1),
+		HealthOverdue:   float64(health.Overdue) / float64(health.Pending),
+		HealthWIP:       min(max(float64(health.WIP-wipLimit), 0)/float64(wipLimit), 1),
+	}
+	if health.Previous > 0 && health.Recent < health.Previous {
+		penalties[HealthVelocity] = float64(health.Previous-health.Recent) / float64(health.Previous)
+	}
+
+	var total, penalty float64
+	for _, factor := range healthFactors {
+		total += weights[factor]
+		penalty += weights[factor] * penalties[factor]
+		if weights[factor] == 0 || penalties[factor] == 0 {
+			continue
+		}
+		switch factor {
+		case HealthStaleness:
+			health.Reasons = append(health.Reasons, "untouched for "+pluralDays(health.StaleDays))
+		case HealthOverdue:
+			health.Reasons = append(health.Reasons, fmt.Sprintf("%d overdue", health.Overdue))
+		case HealthVelocity:
+			health.Reasons = append(health.Reasons, fmt.Sprintf("%d done this week, %d the week before", health.Recent, health.Previous))
+		case HealthWIP:
+			health.Reasons = append(health.Reasons, fmt.Sprintf("%d in progress (limit %d)", health.WIP, wipLimit))
+		}
+	}
+	if total > 0 {
+		health.Score = 100 - int(100*penalty/total+0.5)
+	}
+}
+
+// HealthLabel names a health score: good, fair or poor
+func HealthLabel(score int) string {
+	switch {
+	case score >= 75:
+		return "good"
+	case score >= 50:
+		return "fair"
+	default:
+		return "poor"
+	}
+}
@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScoreHealth(t *testing.T) {
+	weights := map[string]float64{HealthStaleness: 1, HealthOverdue: 1, HealthVelocity: 1, HealthWIP: 1}
+
+	healthy := ListHealth{Pending: 4, StaleDays: 0, Recent: 3, Previous: 2, WIP: 2}
+	scoreHealth(&healthy, weights, 3)
+	if healthy.Score != 100 || len(healthy.Reasons) != 0 {
+		t.Errorf("Expected a healthy list, got %+v", healthy)
+	}
+
+	// Half stale, half overdue, velocity halved and WIP at twice the limit
+	sick := ListHealth{Pending: 4, StaleDays: 7, Overdue: 2, Recent: 1, Previous: 2, WIP: 6}
+	scoreHealth(&sick, weights, 3)
+	if sick.Score != 37 {
+		t.Errorf("Expected a score of 37, got %d", sick.Score)
+	}
+	want := []string{"untouched for 7 days", "2 overdue", "1 done this week, 2 the week before", "6 in progress (limit 3)"}
+	if !reflect.DeepEqual(sick.Reasons, want) {
+		t.Errorf("Reasons = %q", sick.Reasons)
+	}
+
+	// Only overdue items count
+	onlyOverdue := map[string]float64{HealthOverdue: 1}
+	sick.Reasons = nil
+	scoreHealth(&sick, onlyOverdue, 3)
+	if sick.Score != 50 || len(sick.Reasons) != 1 {
+		t.Errorf("Expected only overdue items to count, got %+v", sick)
+	}
+
+	finished := ListHealth{StaleDays: 90}
+	scoreHealth(&finished, weights, 3)
+	if finished.Score != 100 {
+		t.Errorf("Expected a finished list to be healthy, got %d", finished.Score)
+	}
+}
+
+func TestHealthWeights(t *testing.T) {
+	cfg := &Config{Health: HealthConfig{Weights: map[string]float64{HealthOverdue: 3, HealthWIP: 0}}}
+	weights, err := healthWeights(cfg)
+	if err != nil {
+		t.Fatalf("healthWeights failed: %v", err)
+	}
+	want := map[string]float64{HealthStaleness: 1, HealthOverdue: 3, HealthVelocity: 1, HealthWIP: 0}
+	if !reflect.DeepEqual(weights, want) {
+		t.Errorf("weights = %v", weights)
+	}
+
+	for _, bad := range []map[string]float64{{"bugs": 1}, {HealthOverdue: -1}} {
+		if _, err := healthWeights(&Config{Health: HealthConfig{Weights: bad}}); err == nil {
+			t.Errorf("Expected an error for %v", bad)
+		}
+	}
+}
+
+func TestGetListHealth(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	now := time.Now()
+	yesterday := now.AddDate(0, 0, -1).Format(DueDateFormat)
+	os.WriteFile(GetTodoFilePath("late"), []byte("# Todo List for late\n\n- [ ] Ship <!-- due: "+yesterday+" -->\n- [ ] Test\n"), 0644)
+	os.WriteFile(GetTodoFilePath("done"), []byte("# Todo List for done\n\n- [x] Ship\n"), 0644)
+	StartTimer("late", "Test", now)
+
+	healths, err := GetListHealth(&Config{}, now)
+	if err != nil {
+		t.Fatalf("GetListHealth failed: %v", err)
+	}
+	if len(healths) != 2 || healths[0].Name != "late" || healths[1].Name != "done" {
+		t.Fatalf("Expected the late list first, got %+v", healths)
+	}
+	late := healths[0]
+	if late.Pending != 2 || late.Overdue != 1 || late.WIP != 1 || late.Score != 87 {
+		t.Errorf("Unexpected health %+v", late)
+	}
+	if HealthLabel(late.Score) != "good" || HealthLabel(60) != "fair" || HealthLabel(10) != "poor" {
+		t.Errorf("Unexpected labels")
+	}
+}
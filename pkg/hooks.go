@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// HookNames lists the git hooks that todo installs
+var HookNames = []string{"post-checkout", "post-merge", "prepare-commit-msg"}
+
+// Settings for the archive_on_merge config key
+const (
+	ArchiveOnMergePrompt = "prompt"
+	ArchiveOnMergeAuto   = "auto"
+	ArchiveOnMergeOff    = "off"
+)
+
+// hookMarker identifies git hooks that were written by todo
+const hookMarker = "# Installed by todo CLI"
+
+// InstallHook writes a git hook that forwards to `todo hook <name>`. Hooks
+// that were not installed by todo are left untouched.
+func InstallHook(name string) error {
+	gitDir, err := GetGitDir()
+	if err != nil {
+		return fmt.Errorf("failed to locate git directory: %w", err)
+	}
+
+	hooksDir := filepath.Join(gitDir, "hooks")
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	hookPath := filepath.Join(hooksDir, name)
+	if content, err := os.ReadFile(hookPath); err == nil && !strings.Contains(string(content), hookMarker) {
+		return fmt.Errorf("%s hook already exists and was not installed by todo", name)
+	}
+
+	script := fmt.Sprintf("#!/bin/sh\n%s\ncommand -v todo >/dev/null 2>&1 && todo hook %s \"$@\"\nexit 0\n", hookMarker, name)
+	return os.WriteFile(hookPath, []byte(script), 0755)
+}
+
+// InstallHooks installs every hook in HookNames
+func InstallHooks() error {
+	if err := RequireGit(); err != nil {
+		return err
+	}
+	for _, name := range HookNames {
+		if err := InstallHook(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	mergeMessageRegex = regexp.MustCompile(`^Merge (?:remote-tracking )?branch '([^']+)'`)
+	mergeReflogRegex  = regexp.MustCompile(`^merge ([^:]+):`)
+)
+
+// MergedBranchFromMessage extracts the merged branch from a default merge
+// commit message such as "Merge branch 'feature/auth' into main"
+func MergedBranchFromMessage(message string) string {
+	firstLine := strings.SplitN(message, "\n", 2)[0]
+	if match := mergeMessageRegex.FindStringSubmatch(firstLine); match != nil {
+		return strings.TrimPrefix(match[1], "origin/")
+	}
+	return ""
+}
+
+// MergedBranchFromReflog returns the branch merged by the latest reflog
+// entry of HEAD, or an empty string if it wasn't a merge
+func MergedBranchFromReflog() string {
+	entry, err := runGit("reflog", "-1", "--format=%gs")
+	if err != nil {
+		return ""
+	}
+	if match := mergeReflogRegex.FindStringSubmatch(entry); match != nil {
+		return strings.TrimPrefix(match[1], "origin/")
+	}
+	return ""
+}
+
+// MergedList returns the list belonging to a branch that was just merged into
+// the default branch, or an empty string if there is no such list
+func MergedList(branch string) string {
+	if branch == "" {
+		return ""
+	}
+
+	current, err := GetCurrentBranch()
+	if err != nil || current != GetDefaultBranch() {
+		return ""
+	}
+
+	listName := GetFeatureName(branch)
+	if listName == GetFeatureName(current) || !TodoFileExists(listName) {
+		return ""
+	}
+	return listName
+}
+
+// ListSummary renders a short plain-text summary of a list, suitable for a
+// commit message
+func ListSummary(listName string) (string, error) {
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse todo file: %w", err)
+	}
+
+	completed := 0
+	var lines []string
+	for _, item := range todoList.Items {
+		checkbox := " "
+		if item.Completed {
+			checkbox = "x"
+			completed++
+		}
+		lines = append(lines, fmt.Sprintf("- [%s] %s", checkbox, item.Text))
+	}
+
+	header := fmt.Sprintf("Todo list '%s': %d/%d completed", listName, completed, len(todoList.Items))
+	if len(lines) == 0 {
+		return header + "\n", nil
+	}
+	return header + "\n\n" + strings.Join(lines, "\n") + "\n", nil
+}
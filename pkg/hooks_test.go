@@ -0,0 +1,104 @@
+package pkg
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergedBranchFromMessage(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{"Merge branch 'feature/auth'", "feature/auth"},
+		{"Merge branch 'feature/auth' into main\n\n# Conflicts:", "feature/auth"},
+		{"Merge remote-tracking branch 'origin/fix/login'", "fix/login"},
+		{"Add login form", ""},
+	}
+
+	for _, tt := range tests {
+		result := MergedBranchFromMessage(tt.message)
+		if result != tt.expected {
+			t.Errorf("MergedBranchFromMessage(%q) = %q, want %q", tt.message, result, tt.expected)
+		}
+	}
+}
+
+func TestListSummary(t *testing.T) {
+	setupTestDir(t)
+
+	CreateTodoFile("auth")
+	AddTodoItem("auth", "Login form")
+	AddTodoItem("auth", "Logout route")
+	CheckTodoItem("auth", 1)
+
+	summary, err := ListSummary("auth")
+	if err != nil {
+		t.Fatalf("ListSummary failed: %v", err)
+	}
+
+	expected := "Todo list 'auth': 1/2 completed\n\n- [x] Login form\n- [ ] Logout route\n"
+	if summary != expected {
+		t.Errorf("ListSummary = %q, want %q", summary, expected)
+	}
+}
+
+func TestMergedListAfterMerge(t *testing.T) {
+	setupGitTestDir(t)
+
+	git := func(args ...string) {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("checkout", "-q", "-b", "main")
+	git("commit", "-q", "--allow-empty", "-m", "Initial commit")
+	git("checkout", "-q", "-b", "feature/auth")
+	git("commit", "-q", "--allow-empty", "-m", "Auth work")
+	git("checkout", "-q", "main")
+	git("commit", "-q", "--allow-empty", "-m", "Main work")
+	git("merge", "-q", "--no-edit", "feature/auth")
+
+	CreateTodoFile("auth")
+
+	branch := MergedBranchFromReflog()
+	if branch != "feature/auth" {
+		t.Fatalf("MergedBranchFromReflog() = %q, want %q", branch, "feature/auth")
+	}
+	if list := MergedList(branch); list != "auth" {
+		t.Errorf("MergedList(%q) = %q, want %q", branch, list, "auth")
+	}
+
+	// Merges into anything but the default branch are ignored
+	git("checkout", "-q", "-b", "release")
+	if list := MergedList(branch); list != "" {
+		t.Errorf("MergedList on a non-default branch = %q, want empty", list)
+	}
+}
+
+func TestInstallHookKeepsForeignHooks(t *testing.T) {
+	setupGitTestDir(t)
+
+	hookPath := filepath.Join(".git", "hooks", "post-merge")
+	os.MkdirAll(filepath.Dir(hookPath), 0755)
+	os.WriteFile(hookPath, []byte("#!/bin/sh\necho custom\n"), 0755)
+
+	if err := InstallHook("post-merge"); err == nil {
+		t.Error("InstallHook should refuse to overwrite a hook it didn't install")
+	}
+
+	if err := InstallHook("post-checkout"); err != nil {
+		t.Fatalf("InstallHook failed: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(".git", "hooks", "post-checkout"))
+	if !strings.Contains(string(content), "todo hook post-checkout") {
+		t.Errorf("Unexpected hook content: %q", string(content))
+	}
+	// Reinstalling our own hook is fine
+	if err := InstallHook("post-checkout"); err != nil {
+		t.Errorf("InstallHook should overwrite its own hook: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultIdleThreshold is how long a timer may run without any CLI activity
+// before the next command asks what to do with the idle time
+const DefaultIdleThreshold = 30 * time.Minute
+
+// Ways of settling an idle period
+const (
+	// IdleKeep counts the idle time and leaves the timer running
+	IdleKeep = "keep"
+	// IdleTrim drops the idle time and leaves the timer running
+	IdleTrim = "trim"
+	// IdleDiscard drops the idle time and stops the timer where the
+	// activity stopped
+	IdleDiscard = "discard"
+)
+
+// IdleTimeout returns the idle_threshold setting, or 0 when idle detection
+// is off
+func (c *Config) IdleTimeout() (time.Duration, error) {
+	switch c.IdleThreshold {
+	case "":
+		return DefaultIdleThreshold, nil
+	case "off", "0":
+		return 0, nil
+	}
+	threshold, err := time.ParseDuration(c.IdleThreshold)
+	if err != nil || threshold < 0 {
+		return 0, fmt.Errorf("invalid idle_threshold setting %q (expected a duration such as 30m, or off)", c.IdleThreshold)
+	}
+	return threshold, nil
+}
+
+// GetActivityPath returns the file recording when a command was last run
+func GetActivityPath() string {
+	return filepath.Join(".todo", "activity")
+}
+
+// RecordActivity notes that a command ran. Nothing is written outside an
+// initialized directory.
+func RecordActivity(now time.Time) error {
+	if _, err := os.Stat(".todo"); err != nil {
+		return nil
+	}
+	return os.WriteFile(GetActivityPath(), []byte(now.Format(time.RFC3339)+"\n"), 0644)
+}
+
+// LastActivity returns when a command was last run, or the zero time
+func LastActivity() time.Time {
+	content, err := os.ReadFile(GetActivityPath())
+	if err != nil {
+		return time.Time{}
+	}
+	last, err := time.Parse(time.RFC3339, strings.TrimSpace(string(content)))
+	if err != nil {
+		return time.Time{}
+	}
+	return last
+}
+
+// IdleTimer returns the running timer and when activity stopped, if it has
+// run for longer than threshold since the last command or since it started
+func IdleTimer(now time.Time, threshold time.Duration) (*Session, time.Time, error) {
+	if threshold <= 0 {
+		return nil, time.Time{}, nil
+	}
+
+	session, err := RunningSession()
+	if err != nil || session == nil {
+		return nil, time.Time{}, err
+	}
+
+	idleSince := session.Start
+	if last := LastActivity(); last.After(idleSince) {
+		idleSince = last
+	}
+	if now.Sub(idleSince) <= threshold {
+		return nil, time.Time{}, nil
+	}
+	return session, idleSince, nil
+}
+
+// SettleIdle applies a choice about the idle period of a running timer
+func SettleIdle(choice string, session *Session, idleSince, now time.Time) error {
+	stop := JournalEntry{Time: idleSince, Kind: JournalTimerStop, List: session.List, Detail: session.Item}
+	switch choice {
+	case IdleKeep:
+		return nil
+	case IdleTrim:
+		restart := JournalEntry{Time: now, Kind: JournalTimerStart, List: session.List, Detail: session.Item}
+		return AppendJournal(stop, restart)
+	case IdleDiscard:
+		return AppendJournal(stop)
+	}
+	return fmt.Errorf("unknown idle choice %q", choice)
+}
@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestIdleTimeout(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":    DefaultIdleThreshold,
+		"off": 0,
+		"0":   0,
+		"2h":  2 * time.Hour,
+	}
+	for setting, want := range tests {
+		got, err := (&Config{IdleThreshold: setting}).IdleTimeout()
+		if err != nil || got != want {
+			t.Errorf("IdleTimeout(%q) = %v, %v; want %v", setting, got, err, want)
+		}
+	}
+
+	for _, setting := range []string{"soon", "-5m"} {
+		if _, err := (&Config{IdleThreshold: setting}).IdleTimeout(); err == nil {
+			t.Errorf("Expected an error for %q", setting)
+		}
+	}
+}
+
+func TestRecordActivity(t *testing.T) {
+	setupTestDir(t)
+
+	now := time.Date(2024, 7, 8, 9, 0, 0, 0, time.UTC)
+	if err := RecordActivity(now); err != nil {
+		t.Fatalf("RecordActivity failed: %v", err)
+	}
+	if _, err := os.Stat(".todo"); !os.IsNotExist(err) {
+		t.Error("RecordActivity should not create .todo")
+	}
+
+	EnsureTodoDirectory()
+	RecordActivity(now)
+	if got := LastActivity(); !got.Equal(now) {
+		t.Errorf("Expected last activity %v, got %v", now, got)
+	}
+}
+
+func TestIdleTimer(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+
+	start := time.Date(2024, 7, 8, 9, 0, 0, 0, time.Local)
+	threshold := 30 * time.Minute
+
+	if session, _, _ := IdleTimer(start.Add(time.Hour), threshold); session != nil {
+		t.Errorf("Expected no idle timer without a running one, got %+v", session)
+	}
+
+	StartTimer("auth", "Login form", start)
+	RecordActivity(start.Add(20 * time.Minute))
+
+	if session, _, _ := IdleTimer(start.Add(45*time.Minute), threshold); session != nil {
+		t.Errorf("Expected the timer not to be idle yet, got %+v", session)
+	}
+	if session, _, _ := IdleTimer(start.Add(9*time.Hour), 0); session != nil {
+		t.Error("A zero threshold should turn idle detection off")
+	}
+
+	now := start.Add(9 * time.Hour)
+	session, idleSince, err := IdleTimer(now, threshold)
+	if err != nil || session == nil {
+		t.Fatalf("Expected an idle timer, got %+v (%v)", session, err)
+	}
+	if !idleSince.Equal(start.Add(20 * time.Minute)) {
+		t.Errorf("Expected idle since the last activity, got %v", idleSince)
+	}
+
+	// Trimming drops the idle time but keeps the timer running
+	if err := SettleIdle(IdleTrim, session, idleSince, now); err != nil {
+		t.Fatalf("SettleIdle failed: %v", err)
+	}
+	sessions, _ := Sessions()
+	if len(sessions) != 2 || sessions[0].Duration(now) != 20*time.Minute || !sessions[1].Running() || !sessions[1].Start.Equal(now) {
+		t.Fatalf("Unexpected sessions after trimming: %+v", sessions)
+	}
+
+	// Discarding stops the timer where activity stopped
+	later := now.Add(2 * time.Hour)
+	RecordActivity(now.Add(10 * time.Minute))
+	session, idleSince, _ = IdleTimer(later, threshold)
+	if err := SettleIdle(IdleDiscard, session, idleSince, later); err != nil {
+		t.Fatalf("SettleIdle failed: %v", err)
+	}
+	if running, _ := RunningSession(); running != nil {
+		t.Errorf("Expected the timer to be stopped, got %+v", running)
+	}
+	sessions, _ = Sessions()
+	if got := sessions[1].Duration(later); got != 10*time.Minute {
+		t.Errorf("Expected 10 minutes counted, got %v", got)
+	}
+}
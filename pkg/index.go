@@ -0,0 +1,163 @@
+package pkg
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The index caches item counts per list in .todo/index.json so quick
+// queries such as 'todo count' don't parse every list. An entry is reused
+// while its file's size and modification time are unchanged.
+
+// IndexEntry is the cached summary of one list
+type IndexEntry struct {
+	ModTime   int64 `json:"mtime"`
+	Size      int64 `json:"size"`
+	Pending   int   `json:"pending"`
+	Completed int   `json:"completed"`
+	// Due holds the due dates of pending items, so overdue counts can be
+	// worked out for any day
+	Due []string `json:"due,omitempty"`
+}
+
+// racyWindow is how long after a write a file's modification time can't be
+// trusted to change again, on filesystems with coarse timestamps. Lists
+// modified more recently than this are counted but not cached.
+const racyWindow = 2 * time.Second
+
+// ItemCounts are the numbers of items in one or more lists
+type ItemCounts struct {
+	Pending   int `json:"pending"`
+	Completed int `json:"completed"`
+	Overdue   int `json:"overdue"`
+}
+
+func GetIndexPath() string {
+	return filepath.Join(".todo", "index.json")
+}
+
+// CountItems returns the item counts of the named lists, read from the
+// index where it is current. Overdue items are pending items due before now.
+func CountItems(names []string, now time.Time) (ItemCounts, error) {
+	index := loadIndex()
+	changed := false
+	today := now.Format(DueDateFormat)
+	// The index is keyed on the list files, which sqlite storage doesn't
+	// write, so the lists are counted from the database
+	sqlite := UsingSQLite()
+
+	var counts ItemCounts
+	for _, name := range names {
+		if sqlite {
+			todoList, err := ParseTodoFile(name)
+			if err != nil {
+				return ItemCounts{}, err
+			}
+			counts.add(countEntry(todoList), today)
+			continue
+		}
+
+		info, err := os.Stat(GetTodoFilePath(name))
+		if os.IsNotExist(err) {
+			if _, ok := index[name]; ok {
+				delete(index, name)
+				changed = true
+			}
+			continue
+		}
+		if err != nil {
+			return ItemCounts{}, err
+		}
+
+		entry, ok := index[name]
+		if !ok || entry.ModTime != info.ModTime().UnixNano() || entry.Size != info.Size() {
+			todoList, err := ParseTodoFile(name)
+			if err != nil {
+				return ItemCounts{}, err
+			}
+			entry = newIndexEntry(info, todoList)
+			if time.Since(info.ModTime()) >= racyWindow {
+				index[name] = entry
+				changed = true
+			}
+		}
+
+		counts.add(entry, today)
+	}
+
+	if changed {
+		// The index is only a cache, so failing to save it isn't an error
+		saveIndex(index)
+	}
+	return counts, nil
+}
+
+// add counts the items of an index entry, with those due before today as
+// overdue
+func (c *ItemCounts) add(entry IndexEntry, today string) {
+	c.Pending += entry.Pending
+	c.Completed += entry.Completed
+	for _, due := range entry.Due {
+		if due < today {
+			c.Overdue++
+		}
+	}
+}
+
+func newIndexEntry(info os.FileInfo, todoList *TodoList) IndexEntry {
+	entry := countEntry(todoList)
+	entry.ModTime, entry.Size = info.ModTime().UnixNano(), info.Size()
+	return entry
+}
+
+// countEntry counts the items of a list
+func countEntry(todoList *TodoList) IndexEntry {
+	var entry IndexEntry
+	for _, item := range todoList.Items {
+		if item.Completed {
+			entry.Completed++
+			continue
+		}
+		entry.Pending++
+		if item.DueDate != nil {
+			entry.Due = append(entry.Due, item.DueDate.Format(DueDateFormat))
+		}
+	}
+	return entry
+}
+
+func loadIndex() map[string]IndexEntry {
+	index := make(map[string]IndexEntry)
+	if content, err := os.ReadFile(GetIndexPath()); err == nil {
+		if json.Unmarshal(content, &index) != nil {
+			return make(map[string]IndexEntry)
+		}
+	}
+	return index
+}
+
+// saveIndex writes the index through a temporary file so concurrent
+// readers never see half of it
+func saveIndex(index map[string]IndexEntry) error {
+	content, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(".todo", ".index-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), GetIndexPath())
+}
@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCountItems(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	os.WriteFile(GetTodoFilePath("ops"), []byte(`# Todo List for ops
+
+- [ ] Rotate keys <!-- due: 2024-06-01 -->
+- [ ] Patch hosts <!-- due: 2024-07-01 -->
+- [x] Audit <!-- due: 2024-05-01 -->
+`), 0644)
+	os.WriteFile(GetTodoFilePath("docs"), []byte("- [ ] Write guide\n"), 0644)
+
+	// Lists written just now aren't cached, since their timestamps can't be
+	// trusted to change on the next write
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.Local)
+	counts, err := CountItems([]string{"ops", "docs", "missing"}, now)
+	if err != nil {
+		t.Fatalf("CountItems failed: %v", err)
+	}
+	if counts != (ItemCounts{Pending: 3, Completed: 1, Overdue: 1}) {
+		t.Errorf("Unexpected counts: %+v", counts)
+	}
+	if _, err := os.Stat(GetIndexPath()); !os.IsNotExist(err) {
+		t.Error("Freshly written lists should not be indexed")
+	}
+
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(GetTodoFilePath("ops"), old, old)
+	CountItems([]string{"ops"}, now)
+	if entry, ok := loadIndex()["ops"]; !ok || entry.Pending != 2 || len(entry.Due) != 2 {
+		t.Fatalf("Expected ops to be indexed, got %+v", loadIndex())
+	}
+
+	// A current entry is used without parsing the list
+	index := loadIndex()
+	entry := index["ops"]
+	entry.Pending = 42
+	index["ops"] = entry
+	saveIndex(index)
+	if counts, _ := CountItems([]string{"ops"}, now); counts.Pending != 42 {
+		t.Errorf("Expected the cached count, got %+v", counts)
+	}
+
+	// A changed list is parsed again
+	AddTodoItem("ops", "Renew certs")
+	os.Chtimes(GetTodoFilePath("ops"), old.Add(time.Minute), old.Add(time.Minute))
+	if counts, _ := CountItems([]string{"ops"}, now); counts.Pending != 3 {
+		t.Errorf("Expected a fresh count after a change, got %+v", counts)
+	}
+
+	// Deleted lists are dropped from the index
+	DeleteList("ops")
+	CountItems([]string{"ops"}, now)
+	if _, ok := loadIndex()["ops"]; ok {
+		t.Error("Deleted list is still indexed")
+	}
+}
@@ -0,0 +1,153 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Insights look back over the journal and the lists for patterns in how
+// they are used. Everything is read from .todo and nothing is sent
+// anywhere. Which commands are run, and when, is only recorded once
+// 'insights: true' is set in .todo/config.yaml; the lists written are
+// always journaled.
+
+// DefaultInsightsDays is how far back insights look by default
+const DefaultInsightsDays = 30
+
+// untriagedAge is how long a pending item can go without a due date,
+// priority, tag or context before it counts as never triaged
+const untriagedAge = 14 * 24 * time.Hour
+
+// suggestionMinimum is how many items, untriaged in a list or overdue, it
+// takes for insights to suggest doing something about them
+const suggestionMinimum = 5
+
+// Count is how often something happened
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Insights summarise how todo has been used since a day
+type Insights struct {
+	Since time.Time `json:"since"`
+	// Recording is whether commands are being recorded
+	Recording bool `json:"recording"`
+	// Commands are the commands run, most used first
+	Commands []Count `json:"commands"`
+	// Hours counts the commands run in each hour of the day
+	Hours [24]int `json:"hours"`
+	// Lists counts the writes to each list, most written first
+	Lists []Count `json:"lists"`
+	// Suggestions are workflow changes the usage points to
+	Suggestions []string `json:"suggestions"`
+}
+
+// RecordCommand journals that a command was run, when insights are on. Only
+// the command's name is kept, never its arguments.
+func RecordCommand(cfg *Config, command string) error {
+	if !cfg.Insights {
+		return nil
+	}
+	return AppendJournal(JournalEntry{Kind: JournalCommand, Action: command})
+}
+
+// GetInsights analyses the journal and the lists over the days up to now
+func GetInsights(cfg *Config, now time.Time, days int) (*Insights, error) {
+	entries, err := ReadJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	insights := &Insights{Since: now.AddDate(0, 0, -days), Recording: cfg.Insights}
+	commands := make(map[string]int)
+	lists := make(map[string]int)
+	for _, entry := range entries {
+		if entry.Time.Before(insights.Since) {
+			continue
+		}
+		switch entry.Kind {
+		case JournalCommand:
+			commands[entry.Action]++
+			insights.Hours[entry.Time.Local().Hour()]++
+		case JournalListWritten:
+			if ListExists(entry.List) {
+				lists[entry.List]++
+			}
+		}
+	}
+	insights.Commands = sortCounts(commands)
+	insights.Lists = sortCounts(lists)
+
+	names, err := GetAllLists()
+	if err != nil {
+		return nil, err
+	}
+	parsed := ParseLists(names)
+	for _, list := range parsed {
+		if list.Err != nil {
+			return nil, list.Err
+		}
+	}
+	insights.Suggestions = suggestions(commands, parsed, now, days)
+	return insights, nil
+}
+
+// suggestions looks for habits worth changing
+func suggestions(commands map[string]int, lists []ParsedList, now time.Time, days int) []string {
+	result := []string{}
+
+	var untriaged []Count
+	overdue := 0
+	today := now.Format(DueDateFormat)
+	for _, list := range lists {
+		count := 0
+		for _, item := range list.List.Items {
+			if item.Completed {
+				continue
+			}
+			if item.DueDate != nil && item.DueDate.Format(DueDateFormat) < today {
+				overdue++
+			}
+			added, ok := ItemAdded(item)
+			if !ok || now.Sub(added) < untriagedAge {
+				continue
+			}
+			if item.DueDate == nil && item.Priority == 0 && len(ExtractTags(item.Text)) == 0 && len(ExtractContexts(item.Text)) == 0 {
+				count++
+			}
+		}
+		if count >= suggestionMinimum {
+			untriaged = append(untriaged, Count{Name: list.Name, Count: count})
+		}
+	}
+	sort.SliceStable(untriaged, func(i, j int) bool { return untriaged[i].Count > untriaged[j].Count })
+	for _, list := range untriaged {
+		result = append(result, fmt.Sprintf("%d items added to %s over two weeks ago were never triaged (no due date, priority, tag or context); try 'todo triage %s'", list.Count, list.Name, list.Name))
+	}
+
+	if overdue >= suggestionMinimum {
+		result = append(result, fmt.Sprintf("%d items are overdue; 'todo overdue' lists them and 'todo snooze' moves them on", overdue))
+	}
+
+	added, checked := commands["add"], commands["check"]
+	if added >= 10 && checked*3 < added {
+		result = append(result, fmt.Sprintf("You added %d items but checked off %d in the last %d days; 'todo fit' or 'todo random' can pick something to finish", added, checked, days))
+	}
+	return result
+}
+
+func sortCounts(counts map[string]int) []Count {
+	sorted := make([]Count, 0, len(counts))
+	for name, count := range counts {
+		sorted = append(sorted, Count{Name: name, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
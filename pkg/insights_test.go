@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordCommand(t *testing.T) {
+	setupTestDir(t)
+
+	cfg := DefaultConfig()
+	RecordCommand(cfg, "add")
+	if entries, _ := ReadJournal(); len(entries) != 0 {
+		t.Fatalf("Expected nothing recorded until insights are on, got %+v", entries)
+	}
+	cfg.Insights = true
+	RecordCommand(cfg, "add")
+	if entries, _ := ReadJournal(); len(entries) != 1 || entries[0].Kind != JournalCommand || entries[0].Action != "add" {
+		t.Errorf("Expected the command to be recorded, got %+v", entries)
+	}
+}
+
+func TestGetInsights(t *testing.T) {
+	setupTestDir(t)
+	now := time.Date(2024, 7, 31, 18, 0, 0, 0, time.Local)
+	restore := SetClock(NewSimulatedClock(now))
+	defer restore()
+
+	// Twelve items added to the inbox a month ago and left alone, and one
+	// that was given a tag
+	store := NewStore()
+	for i := 0; i < 12; i++ {
+		store.AddItem("inbox", fmt.Sprintf("Idea %d", i+1))
+	}
+	store.AddItem("inbox", "Tagged idea #later")
+	list, _ := store.Get("inbox")
+	for i := range list.Items {
+		list.Items[i].Metadata[metaAdded] = "2024-07-01"
+	}
+	store.Flush()
+
+	cfg := DefaultConfig()
+	cfg.Insights = true
+	var entries []JournalEntry
+	for i := 0; i < 12; i++ {
+		entries = append(entries, JournalEntry{Time: now.Add(-time.Duration(i) * time.Hour), Kind: JournalCommand, Action: "add"})
+	}
+	entries = append(entries,
+		JournalEntry{Time: now.Add(-2 * time.Hour), Kind: JournalCommand, Action: "check"},
+		// Too old to count
+		JournalEntry{Time: now.AddDate(0, 0, -40), Kind: JournalCommand, Action: "check"},
+		JournalEntry{Time: now, Kind: JournalListWritten, List: "gone"},
+	)
+	AppendJournal(entries...)
+
+	insights, err := GetInsights(cfg, now, 30)
+	if err != nil {
+		t.Fatalf("GetInsights failed: %v", err)
+	}
+	if len(insights.Commands) != 2 || insights.Commands[0] != (Count{"add", 12}) || insights.Commands[1] != (Count{"check", 1}) {
+		t.Errorf("Unexpected commands: %+v", insights.Commands)
+	}
+	if insights.Hours[18] != 1 || insights.Hours[16] != 2 {
+		t.Errorf("Unexpected hours: %v", insights.Hours)
+	}
+	if len(insights.Lists) != 1 || insights.Lists[0].Name != "inbox" {
+		t.Errorf("Expected only the inbox's writes, got %+v", insights.Lists)
+	}
+
+	suggestions := strings.Join(insights.Suggestions, "\n")
+	for _, want := range []string{"12 items added to inbox over two weeks ago were never triaged", "You added 12 items but checked off 1 in the last 30 days"} {
+		if !strings.Contains(suggestions, want) {
+			t.Errorf("Expected %q in suggestions:\n%s", want, suggestions)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package pkg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Every time a list is written, a copy of it is kept in .todo/backups and
+// its checksum is recorded in the journal. Lists are checked as they are
+// parsed for signs of damage that todo's own writes never leave, such as a
+// file cut short partway through a line or two copies of the list mixed
+// together, and 'todo recover' rebuilds a damaged list from its backup.
+
+// GetBackupDir returns the directory holding the last written copy of each
+// list
+func GetBackupDir() string {
+	return filepath.Join(".todo", "backups")
+}
+
+// GetBackupPath returns where the last written copy of a list is kept
+func GetBackupPath(listName string) string {
+	return filepath.Join(GetBackupDir(), listName+".md")
+}
+
+// getDamagedPath returns where 'todo recover' keeps a damaged list file
+func getDamagedPath(listName string) string {
+	return filepath.Join(GetBackupDir(), listName+".damaged.md")
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// journalListWrites records the checksum of each list written. The lists
+// are already safely on disk, so a journal that can't be written only
+// costs 'todo recover' the chance to check the backup.
+func journalListWrites(contents map[string][]byte) {
+	var names []string
+	for name := range contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var entries []JournalEntry
+	for _, name := range names {
+		entries = append(entries, JournalEntry{Kind: JournalListWritten, List: name, Detail: checksum(contents[name])})
+	}
+	AppendJournal(entries...)
+}
+
+// Problems found in lists parsed by this process, keyed by list
+var (
+	integrityMu       sync.Mutex
+	integrityProblems = make(map[string][]string)
+)
+
+func noteIntegrity(listName string, content []byte) {
+	problems := CheckListIntegrity(listName, content)
+
+	integrityMu.Lock()
+	defer integrityMu.Unlock()
+
+	if len(problems) == 0 {
+		delete(integrityProblems, listName)
+		return
+	}
+	integrityProblems[listName] = problems
+}
+
+// IntegrityWarnings describes the damaged lists parsed so far, sorted by
+// list name
+func IntegrityWarnings() []string {
+	integrityMu.Lock()
+	defer integrityMu.Unlock()
+
+	var names []string
+	for name := range integrityProblems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var warnings []string
+	for _, name := range names {
+		warnings = append(warnings, fmt.Sprintf("list '%s' looks damaged (%s); run 'todo recover %s' to rebuild it from its backup",
+			name, strings.Join(integrityProblems[name], ", "), name))
+	}
+	return warnings
+}
+
+// CheckListIntegrity returns the signs of damage in the content of a list
+// file, or nil if it looks whole
+func CheckListIntegrity(listName string, content []byte) []string {
+	var problems []string
+	if bytes.IndexByte(content, 0) >= 0 {
+		problems = append(problems, "it contains NUL bytes")
+	}
+
+	heading := "# " + todoFileTitle(listName)
+	headings := 0
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == heading {
+			headings++
+		}
+	}
+	if headings > 1 {
+		problems = append(problems, "its heading appears more than once, as if two writes were mixed together")
+	}
+
+	// todo always ends a list with a newline, so a file that doesn't was
+	// either edited by hand or cut short
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		lastLine := content[bytes.LastIndexByte(content, '\n')+1:]
+		backup, err := os.ReadFile(GetBackupPath(listName))
+		switch {
+		case err == nil && len(backup) > len(content) && bytes.HasPrefix(backup, content):
+			problems = append(problems, "it stops partway through the last version written")
+		case bytes.Contains(lastLine, []byte("<!--")) && !bytes.Contains(lastLine, []byte("-->")):
+			problems = append(problems, "it ends in the middle of a line")
+		}
+	}
+	return problems
+}
+
+// ListProblems checks a list file for signs of damage
+func ListProblems(listName string) ([]string, error) {
+	content, err := readListContent(listName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read todo file: %w", err)
+	}
+	return CheckListIntegrity(listName, content), nil
+}
+
+// Recovery describes how a list was rebuilt
+type Recovery struct {
+	// Items counts the items of the rebuilt list
+	Items int
+	// Updated counts the items whose state was taken from the damaged file
+	Updated int
+	// Salvaged holds the items found only in the damaged file
+	Salvaged []string
+	// Verified is whether the backup matches the last write recorded in
+	// the journal, and Written is when that was
+	Verified bool
+	Written  time.Time
+	// DamagedPath is where the damaged file was kept
+	DamagedPath string
+}
+
+// RecoverList rebuilds a list from its backup. Items that were checked or
+// unchecked since, or added, are taken from whatever can still be read of
+// the damaged file, which is kept in .todo/backups.
+func RecoverList(listName string) (*Recovery, error) {
+	backup, err := os.ReadFile(GetBackupPath(listName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no backup of list '%s' to recover from", listName)
+		}
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	damaged, err := readListContent(listName)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read todo file: %w", err)
+	}
+
+	recovery := &Recovery{}
+	entries, err := ReadJournal()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Kind == JournalListWritten && entry.List == listName {
+			recovery.Verified = entry.Detail == checksum(backup)
+			recovery.Written = entry.Time
+		}
+	}
+
+	todoList, err := parseTodoList(bytes.NewReader(backup))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse backup: %w", err)
+	}
+	salvaged, err := readableItems(damaged)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range salvaged {
+		index := slices.IndexFunc(todoList.Items, func(existing TodoItem) bool { return existing.Text == item.Text })
+		if index >= 0 {
+			if todoList.Items[index].Completed != item.Completed {
+				todoList.Items[index].Completed = item.Completed
+				todoList.Items[index].CompletedTime = item.CompletedTime
+				recovery.Updated++
+			}
+			continue
+		}
+		insertInSection(todoList, item)
+		recovery.Salvaged = append(recovery.Salvaged, item.Text)
+	}
+	for i := range todoList.Items {
+		todoList.Items[i].ID = i + 1
+	}
+	recovery.Items = len(todoList.Items)
+
+	if len(damaged) > 0 {
+		recovery.DamagedPath = getDamagedPath(listName)
+		if err := writeFileAtomic(recovery.DamagedPath, damaged); err != nil {
+			return nil, fmt.Errorf("failed to keep the damaged file: %w", err)
+		}
+	}
+	if err := WriteTodoFile(listName, todoList); err != nil {
+		return nil, err
+	}
+	return recovery, nil
+}
+
+// readableItems returns the items that can still be read whole from a
+// damaged list file, each once
+func readableItems(content []byte) ([]TodoItem, error) {
+	content = bytes.ReplaceAll(content, []byte{0}, nil)
+	// A last line without a newline may be cut short
+	if end := bytes.LastIndexByte(content, '\n'); end < len(content)-1 {
+		content = content[:end+1]
+	}
+	parsed, err := parseTodoList(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse todo file: %w", err)
+	}
+
+	var items []TodoItem
+	seen := make(map[string]bool)
+	for _, item := range parsed.Items {
+		if !seen[item.Text] {
+			seen[item.Text] = true
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// insertInSection adds an item after the last item of its section, or at
+// the end of the list if the section is gone
+func insertInSection(todoList *TodoList, item TodoItem) {
+	position := len(todoList.Items)
+	for i, existing := range todoList.Items {
+		if existing.Section == item.Section {
+			position = i + 1
+		}
+	}
+	todoList.Items = slices.Insert(todoList.Items, position, item)
+}
@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckListIntegrity(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	os.MkdirAll(GetBackupDir(), 0755)
+	whole := "# Todo List for work\n\n- [ ] Ship\n- [ ] Docs <!-- due: 2024-07-01 -->\n"
+	os.WriteFile(GetBackupPath("work"), []byte(whole), 0644)
+
+	tests := []struct {
+		name    string
+		content string
+		damaged bool
+	}{
+		{"whole", whole, false},
+		{"edited by hand without a final newline", "# Todo List for work\n\n- [ ] Ship it", false},
+		{"cut short", whole[:len(whole)-10], true},
+		{"cut inside a comment", "# Todo List for work\n\n- [ ] Ship <!-- due: 20", true},
+		{"interleaved", whole + "# Todo List for work\n\n- [ ] Ship\n", true},
+		{"NUL bytes", whole + "\x00\x00\x00", true},
+	}
+	for _, tt := range tests {
+		problems := CheckListIntegrity("work", []byte(tt.content))
+		if (len(problems) > 0) != tt.damaged {
+			t.Errorf("%s: problems = %q", tt.name, problems)
+		}
+	}
+}
+
+func TestIntegrityWarnings(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	os.WriteFile(GetTodoFilePath("work"), []byte("# Todo List for work\n\n- [ ] Ship\n# Todo List for work\n"), 0644)
+
+	ParseTodoFile("work")
+	warnings := IntegrityWarnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "run 'todo recover work'") {
+		t.Fatalf("Expected a warning about work, got %q", warnings)
+	}
+
+	// A list that is fixed stops being reported
+	os.WriteFile(GetTodoFilePath("work"), []byte("# Todo List for work\n\n- [ ] Ship\n"), 0644)
+	ParseTodoFile("work")
+	if warnings := IntegrityWarnings(); len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %q", warnings)
+	}
+}
+
+func TestWritesKeepBackups(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("work")
+	AddTodoItem("work", "Ship")
+
+	onDisk, _ := os.ReadFile(GetTodoFilePath("work"))
+	backup, _ := os.ReadFile(GetBackupPath("work"))
+	if string(backup) != string(onDisk) {
+		t.Errorf("Expected the backup to match the list, got %q", backup)
+	}
+
+	entries, _ := ReadJournal()
+	last := entries[len(entries)-1]
+	if last.Kind != JournalListWritten || last.List != "work" || last.Detail != checksum(onDisk) {
+		t.Errorf("Expected the write in the journal, got %+v", last)
+	}
+}
+
+func TestRecoverList(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("work")
+	AddTodoItem("work", "Ship")
+	AddTodoItem("work", "Docs")
+	AddTodoItem("work", "Tag")
+
+	// The file was edited by another program, then cut short
+	backup, _ := os.ReadFile(GetBackupPath("work"))
+	damaged := strings.Replace(string(backup), "- [ ] Ship", "- [x] Ship", 1) + "- [ ] Announce\n- [ ] Blo"
+	os.WriteFile(GetTodoFilePath("work"), []byte(damaged), 0644)
+
+	recovery, err := RecoverList("work")
+	if err != nil {
+		t.Fatalf("RecoverList failed: %v", err)
+	}
+	if !recovery.Verified || recovery.Items != 4 || recovery.Updated != 1 || !reflect.DeepEqual(recovery.Salvaged, []string{"Announce"}) {
+		t.Errorf("Unexpected recovery %+v", recovery)
+	}
+	if kept, _ := os.ReadFile(recovery.DamagedPath); string(kept) != damaged {
+		t.Errorf("Expected the damaged file to be kept, got %q", kept)
+	}
+
+	todoList, _ := ParseTodoFile("work")
+	var texts []string
+	for _, item := range todoList.Items {
+		texts = append(texts, item.Text)
+	}
+	if !reflect.DeepEqual(texts, []string{"Ship", "Docs", "Tag", "Announce"}) || !todoList.Items[0].Completed {
+		t.Errorf("Unexpected items %+v", todoList.Items)
+	}
+
+	if _, err := RecoverList("other"); err == nil {
+		t.Error("Expected an error without a backup")
+	}
+}
@@ -0,0 +1,39 @@
+package pkg
+
+import (
+	"strings"
+)
+
+// FormatIssueBody writes a list as a GitHub-flavored markdown task list, to
+// paste into an issue or pull request description where GitHub shows the
+// progress of its checkboxes. Sections become "### " headings and due dates
+// stay visible as "(due: YYYY-MM-DD)", but the metadata comments the todo
+// file keeps are left out.
+func FormatIssueBody(todoList *TodoList) string {
+	var b strings.Builder
+	section := ""
+	for i, item := range todoList.Items {
+		if item.Section != section {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString("### " + item.Section + "\n\n")
+			section = item.Section
+		}
+		b.WriteString(issueBodyLine(item) + "\n")
+	}
+	return b.String()
+}
+
+// issueBodyLine writes an item as a task list line
+func issueBodyLine(item TodoItem) string {
+	mark := " "
+	if item.Completed {
+		mark = "x"
+	}
+	text := strings.Join(strings.Fields(item.Text), " ")
+	if item.DueDate != nil {
+		text += " (due: " + item.DueDate.Format(DueDateFormat) + ")"
+	}
+	return "- [" + mark + "] " + text
+}
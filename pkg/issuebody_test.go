@@ -0,0 +1,22 @@
+package pkg
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFormatIssueBody(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	content := "# Todo List for main\n\n- [x] Plan <!-- id: a1b2; completed: \"2024-06-01 10:00\"; priority: 1 -->\n\n## Backend\n\n- [ ] Migrate <!-- due: 2030-01-02; estimate: 2h -->\n- [ ] Multi <!-- text: \"Multi\\nline\" -->\n"
+	os.WriteFile(GetTodoFilePath("main"), []byte(content), 0644)
+
+	todoList, err := ParseTodoFile("main")
+	if err != nil {
+		t.Fatalf("ParseTodoFile failed: %v", err)
+	}
+	want := "- [x] Plan\n\n### Backend\n\n- [ ] Migrate (due: 2030-01-02)\n- [ ] Multi line\n"
+	if got := FormatIssueBody(todoList); got != want {
+		t.Errorf("FormatIssueBody() =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,341 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A list synced with a GitHub issue treats the task list in the issue's
+// body as its remote copy. Task lines have no ids, so items are matched by
+// their text and compared three ways with threeWayChanges: the state both
+// sides agreed on after the last sync, the list and the issue body. That
+// base is kept in .todo/sync/github-issue/<list>.json with the issue's URL
+// as its cursor, so syncing a list with another issue starts afresh.
+//
+// Outgoing changes are made to the body in place - a checkbox flipped, a
+// line removed, new items after the last task - so the text around the task
+// list, and the way it was laid out on GitHub, are left alone.
+
+// issueTaskRegex matches a task line of an issue body, capturing the bullet
+// before the checkbox, the mark and the text
+var issueTaskRegex = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)])\s+)\[([ xX])\]\s+(.*)$`)
+
+// issueTask is a task line of an issue body
+type issueTask struct {
+	line   int
+	bullet string
+	item   syncedItem
+}
+
+// IssueSyncResult reports what syncing a list with an issue did
+type IssueSyncResult struct {
+	// URL is the issue's web page
+	URL      string
+	Incoming []SyncChange
+	Outgoing []SyncChange
+}
+
+// issueSyncName names the sync state of a list synced with an issue
+func issueSyncName(listName string) string {
+	return "github-issue/" + listName
+}
+
+// issueTaskTitle is the text an item is matched by, with runs of whitespace,
+// which task lines can't keep, collapsed
+func issueTaskTitle(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// formatIssueTask writes a task line after the given bullet
+func formatIssueTask(bullet string, item syncedItem) string {
+	mark := " "
+	if item.Done {
+		mark = "x"
+	}
+	text := item.Title
+	if item.Due != "" {
+		text += " (due: " + item.Due + ")"
+	}
+	return bullet + "[" + mark + "] " + text
+}
+
+// parseIssueTasks returns the task lines of an issue body, skipping fenced
+// code blocks
+func parseIssueTasks(lines []string) []issueTask {
+	var tasks []issueTask
+	fenced := false
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		match := issueTaskRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if item, ok := parseItemLine("- [" + match[2] + "] " + match[3]); ok {
+			item.Text = issueTaskTitle(item.Text)
+			tasks = append(tasks, issueTask{line: i, bullet: match[1], item: syncedItemOf(item, true)})
+		}
+	}
+	return tasks
+}
+
+// SyncGitHubIssue syncs a list with the task list of a GitHub issue: checkbox
+// changes, new items and removed items made on GitHub are pulled into the
+// list, and those made locally are written into the issue body. Items
+// changed differently on both sides are reported as conflicts and left
+// alone. With dryRun, only the changes are reported.
+func SyncGitHubIssue(client *APIClient, owner, name string, number int, listName string, dryRun bool) (*IssueSyncResult, error) {
+	var issue struct {
+		Body    *string `json:"body"`
+		HTMLURL string  `json:"html_url"`
+	}
+	path := fmt.Sprintf("/repos/%s/%s/issues/%d", owner, name, number)
+	if err := client.Do("GET", path, nil, &issue); err != nil {
+		return nil, err
+	}
+	body := ""
+	if issue.Body != nil {
+		body = *issue.Body
+	}
+
+	state, err := LoadSyncState(issueSyncName(listName))
+	if err != nil {
+		return nil, err
+	}
+	if state.Cursor != issue.HTMLURL {
+		state.Cursor = issue.HTMLURL
+		state.Base = make(map[string]string)
+	}
+
+	store := NewStore()
+	todoList, err := store.Get(listName)
+	if err != nil {
+		return nil, err
+	}
+	local := make(map[string]string)
+	var localOrder []string
+	for _, item := range todoList.Items {
+		synced := syncedItemOf(item, true)
+		synced.Title = issueTaskTitle(synced.Title)
+		if _, ok := local[synced.Title]; !ok && synced.Title != "" {
+			local[synced.Title] = synced.encode()
+			localOrder = append(localOrder, synced.Title)
+		}
+	}
+
+	lines := strings.Split(body, "\n")
+	tasks := parseIssueTasks(lines)
+	remote := make(map[string]string)
+	for _, task := range tasks {
+		if _, ok := remote[task.item.Title]; !ok {
+			remote[task.item.Title] = task.item.encode()
+		}
+	}
+
+	incoming, outgoing := threeWayChanges(state.Base, local, remote)
+	result := &IssueSyncResult{
+		URL:      issue.HTMLURL,
+		Incoming: describeIssueChanges(listName, incoming),
+		Outgoing: describeIssueChanges(listName, outgoing),
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	// Titles both sides agree on become the new base
+	for title := range state.Base {
+		if _, ok := local[title]; !ok {
+			if _, ok := remote[title]; !ok {
+				delete(state.Base, title)
+			}
+		}
+	}
+	for title, content := range local {
+		if remote[title] == content {
+			state.Base[title] = content
+		}
+	}
+
+	// New tasks are added in the order of the issue, not of their titles
+	position := make(map[string]int)
+	for i, task := range tasks {
+		if _, ok := position[task.item.Title]; !ok {
+			position[task.item.Title] = i
+		}
+	}
+	slices.SortStableFunc(incoming, func(a, b SyncChange) int {
+		return position[a.List] - position[b.List]
+	})
+	for _, change := range incoming {
+		if change.Action == SyncConflict {
+			continue
+		}
+		if err := applyIssueTask(store, listName, change.Action, change.List, decodeSyncedItem(remote[change.List])); err != nil {
+			return nil, err
+		}
+		if change.Action == SyncDelete {
+			delete(state.Base, change.List)
+		} else {
+			state.Base[change.List] = remote[change.List]
+		}
+	}
+	if err := store.Flush(); err != nil {
+		return nil, err
+	}
+
+	if newBody := patchIssueBody(body, lines, tasks, outgoing, local, localOrder); newBody != body {
+		if err := client.Do("PATCH", path, map[string]string{"body": newBody}, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, change := range outgoing {
+		switch change.Action {
+		case SyncConflict:
+		case SyncDelete:
+			delete(state.Base, change.List)
+		default:
+			state.Base[change.List] = local[change.List]
+		}
+	}
+
+	now := time.Now()
+	state.LastRun = &now
+	return result, state.Save(issueSyncName(listName))
+}
+
+// describeIssueChanges turns changes keyed by item title into changes of
+// the list that name the item
+func describeIssueChanges(listName string, changes []SyncChange) []SyncChange {
+	described := make([]SyncChange, 0, len(changes))
+	for _, change := range changes {
+		detail := change.List
+		if change.Detail != "" {
+			detail += ": " + change.Detail
+		}
+		described = append(described, SyncChange{List: listName, Action: change.Action, Detail: detail})
+	}
+	return described
+}
+
+// applyIssueTask makes the item with a title match a task pulled from the
+// issue, adding or removing it as needed
+func applyIssueTask(store *Store, listName, action, title string, task syncedItem) error {
+	todoList, err := store.Get(listName)
+	if err != nil {
+		return err
+	}
+	index := slices.IndexFunc(todoList.Items, func(item TodoItem) bool {
+		return issueTaskTitle(item.Text) == title
+	})
+
+	if action == SyncDelete {
+		if index < 0 {
+			return nil
+		}
+		todoList.Items = slices.Delete(todoList.Items, index, index+1)
+		for i := range todoList.Items {
+			todoList.Items[i].ID = i + 1
+		}
+		store.MarkDirty(listName)
+		return nil
+	}
+
+	if index < 0 {
+		itemID, err := store.AddItem(listName, title)
+		if err != nil {
+			return err
+		}
+		index = itemID - 1
+		todoList.Items[index].Text = title
+	}
+
+	item := &todoList.Items[index]
+	if task.Done && !item.Completed {
+		completed := Now()
+		item.Completed = true
+		item.CompletedTime = &completed
+	} else if !task.Done {
+		item.Completed = false
+		item.CompletedTime = nil
+	}
+	item.DueDate = nil
+	if due, err := time.ParseInLocation(DueDateFormat, task.Due, time.Local); err == nil {
+		item.DueDate = &due
+	}
+	store.MarkDirty(listName)
+	return nil
+}
+
+// patchIssueBody applies outgoing changes to the lines of an issue body.
+// New items go after the last task, in the order of the list.
+func patchIssueBody(body string, lines []string, tasks []issueTask, outgoing []SyncChange, local map[string]string, localOrder []string) string {
+	crlf := strings.Contains(body, "\r\n")
+	lineEnd := ""
+	if crlf {
+		lineEnd = "\r"
+	}
+
+	byTitle := make(map[string]issueTask)
+	for _, task := range tasks {
+		if _, ok := byTitle[task.item.Title]; !ok {
+			byTitle[task.item.Title] = task
+		}
+	}
+
+	removed := make(map[int]bool)
+	added := make(map[string]bool)
+	for _, change := range outgoing {
+		switch change.Action {
+		case SyncUpdate:
+			task := byTitle[change.List]
+			lines[task.line] = formatIssueTask(task.bullet, decodeSyncedItem(local[change.List])) + lineEnd
+		case SyncDelete:
+			removed[byTitle[change.List].line] = true
+		case SyncAdd:
+			added[change.List] = true
+		}
+	}
+
+	var newLines []string
+	for _, title := range localOrder {
+		if added[title] {
+			newLines = append(newLines, formatIssueTask("- ", decodeSyncedItem(local[title]))+lineEnd)
+		}
+	}
+
+	insertAt := len(lines)
+	if len(tasks) > 0 {
+		insertAt = tasks[len(tasks)-1].line + 1
+	} else if len(newLines) > 0 && strings.TrimSpace(body) != "" {
+		// Keep the new task list apart from the text before it
+		for insertAt > 0 && strings.TrimSpace(lines[insertAt-1]) == "" {
+			insertAt--
+		}
+		newLines = append([]string{lineEnd}, newLines...)
+	} else if strings.TrimSpace(body) == "" {
+		lines, insertAt = nil, 0
+	}
+
+	var patched []string
+	for i, line := range lines {
+		if i == insertAt {
+			patched = append(patched, newLines...)
+		}
+		if !removed[i] {
+			patched = append(patched, line)
+		}
+	}
+	if insertAt >= len(lines) {
+		patched = append(patched, newLines...)
+	}
+	return strings.Join(patched, "\n")
+}
@@ -0,0 +1,157 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeIssueServer serves one issue whose body can be read and patched
+func fakeIssueServer(t *testing.T, body *string, patches *int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/o/r/issues/7" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == "PATCH" {
+			var update map[string]string
+			json.NewDecoder(r.Body).Decode(&update)
+			*body = update["body"]
+			*patches++
+		}
+		content, _ := json.Marshal(*body)
+		fmt.Fprintf(w, `{"html_url":"https://github.com/o/r/issues/7","body":%s}`, content)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSyncGitHubIssue(t *testing.T) {
+	setupTestDir(t)
+	AddTodoItem("release", "Tag the release")
+
+	body := "Release checklist\r\n\r\n- [ ] Write notes\r\n- [x] Bump version\r\n\r\n```\r\n- [ ] not a task\r\n```\r\nThanks!"
+	patches := 0
+	client := NewAPIClient(fakeIssueServer(t, &body, &patches).URL, 0)
+
+	// The first sync pulls the issue's tasks and pushes the list's
+	result, err := SyncGitHubIssue(client, "o", "r", 7, "release", false)
+	if err != nil {
+		t.Fatalf("SyncGitHubIssue failed: %v", err)
+	}
+	if result.URL != "https://github.com/o/r/issues/7" || len(result.Incoming) != 2 || len(result.Outgoing) != 1 {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	todoList, _ := ParseTodoFile("release")
+	if len(todoList.Items) != 3 || todoList.Items[1].Text != "Write notes" || !todoList.Items[2].Completed {
+		t.Fatalf("Expected the issue's tasks to be added, got %+v", todoList.Items)
+	}
+	want := "Release checklist\r\n\r\n- [ ] Write notes\r\n- [x] Bump version\r\n- [ ] Tag the release\r\n\r\n```\r\n- [ ] not a task\r\n```\r\nThanks!"
+	if body != want || patches != 1 {
+		t.Fatalf("Unexpected body after %d patches:\n%q", patches, body)
+	}
+
+	// Nothing changed, so nothing is written
+	result, err = SyncGitHubIssue(client, "o", "r", 7, "release", false)
+	if err != nil || len(result.Incoming)+len(result.Outgoing) != 0 || patches != 1 {
+		t.Fatalf("Expected no changes, got %+v (%v)", result, err)
+	}
+
+	// A task checked on GitHub and an item checked and removed locally
+	body = strings.Replace(body, "- [ ] Write notes", "- [x] Write notes", 1)
+	CheckTodoItem("release", 1)
+	store := NewStore()
+	todoList, _ = store.Get("release")
+	todoList.Items = slices.Delete(todoList.Items, 2, 3)
+	store.MarkDirty("release")
+	store.Flush()
+	result, err = SyncGitHubIssue(client, "o", "r", 7, "release", false)
+	if err != nil {
+		t.Fatalf("SyncGitHubIssue failed: %v", err)
+	}
+	if len(result.Incoming) != 1 || result.Incoming[0].Action != SyncUpdate {
+		t.Errorf("Expected the checked task to come in, got %+v", result.Incoming)
+	}
+	if len(result.Outgoing) != 2 {
+		t.Errorf("Expected the checked and removed items to go out, got %+v", result.Outgoing)
+	}
+	todoList, _ = ParseTodoFile("release")
+	if len(todoList.Items) != 2 || !todoList.Items[1].Completed {
+		t.Errorf("Expected Write notes to be checked, got %+v", todoList.Items)
+	}
+	want = "Release checklist\r\n\r\n- [x] Write notes\r\n- [x] Tag the release\r\n\r\n```\r\n- [ ] not a task\r\n```\r\nThanks!"
+	if body != want {
+		t.Errorf("Unexpected body:\n%q", body)
+	}
+}
+
+func TestSyncGitHubIssueConflict(t *testing.T) {
+	setupTestDir(t)
+	AddTodoItem("release", "Write notes")
+
+	body := "- [ ] Write notes"
+	patches := 0
+	client := NewAPIClient(fakeIssueServer(t, &body, &patches).URL, 0)
+	if _, err := SyncGitHubIssue(client, "o", "r", 7, "release", false); err != nil {
+		t.Fatalf("SyncGitHubIssue failed: %v", err)
+	}
+
+	// Changed differently on both sides: reported, and left alone
+	body = "- [ ] Write notes (due: 2026-01-02)"
+	CheckTodoItem("release", 1)
+	result, err := SyncGitHubIssue(client, "o", "r", 7, "release", false)
+	if err != nil {
+		t.Fatalf("SyncGitHubIssue failed: %v", err)
+	}
+	if len(result.Incoming) != 1 || result.Incoming[0].Action != SyncConflict {
+		t.Errorf("Expected a conflict, got %+v", result.Incoming)
+	}
+	if body != "- [ ] Write notes (due: 2026-01-02)" || patches != 0 {
+		t.Errorf("Expected the issue to be left alone, got %q", body)
+	}
+	todoList, _ := ParseTodoFile("release")
+	if !todoList.Items[0].Completed || todoList.Items[0].DueDate != nil {
+		t.Errorf("Expected the item to be left alone, got %+v", todoList.Items[0])
+	}
+}
+
+func TestSyncGitHubIssueDryRun(t *testing.T) {
+	setupTestDir(t)
+	AddTodoItem("release", "Tag the release")
+
+	body := ""
+	patches := 0
+	client := NewAPIClient(fakeIssueServer(t, &body, &patches).URL, 0)
+	result, err := SyncGitHubIssue(client, "o", "r", 7, "release", true)
+	if err != nil {
+		t.Fatalf("SyncGitHubIssue failed: %v", err)
+	}
+	if len(result.Outgoing) != 1 || result.Outgoing[0].Detail != "Tag the release" {
+		t.Errorf("Unexpected changes %+v", result.Outgoing)
+	}
+	if patches != 0 {
+		t.Errorf("Expected a dry run not to patch the issue")
+	}
+
+	// An empty body becomes just the task list
+	if _, err := SyncGitHubIssue(client, "o", "r", 7, "release", false); err != nil {
+		t.Fatalf("SyncGitHubIssue failed: %v", err)
+	}
+	if body != "- [ ] Tag the release" {
+		t.Errorf("Unexpected body %q", body)
+	}
+}
+
+func TestPatchIssueBodyAppendsTaskList(t *testing.T) {
+	body := "Some context\n\n"
+	local := map[string]string{"New": syncedItem{Title: "New"}.encode()}
+	got := patchIssueBody(body, strings.Split(body, "\n"), nil, []SyncChange{{List: "New", Action: SyncAdd}}, local, []string{"New"})
+	if got != "Some context\n\n- [ ] New\n\n" {
+		t.Errorf("Unexpected body %q", got)
+	}
+}
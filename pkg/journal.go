@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Journal entry kinds
+const (
+	JournalSyncQueued  = "sync-queued"
+	JournalSyncFlushed = "sync-flushed"
+	JournalNotified    = "notified"
+	JournalNotifyClear = "notify-cleared"
+	JournalTimerStart  = "timer-start"
+	JournalTimerStop   = "timer-stop"
+	JournalListWritten = "list-written"
+	JournalCommand     = "command"
+)
+
+// JournalEntry is one line of the journal, an append-only log of events in
+// .todo/journal.jsonl
+type JournalEntry struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Provider string    `json:"provider,omitempty"`
+	List     string    `json:"list,omitempty"`
+	Action   string    `json:"action,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+}
+
+func GetJournalPath() string {
+	return filepath.Join(".todo", "journal.jsonl")
+}
+
+// AppendJournal adds entries to the end of the journal, stamping any without
+// a time with the current time
+func AppendJournal(entries ...JournalEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := EnsureTodoDirectory(); err != nil {
+		return fmt.Errorf("failed to create .todo directory: %w", err)
+	}
+
+	file, err := os.OpenFile(GetJournalPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	now := Now()
+	for _, entry := range entries {
+		if entry.Time.IsZero() {
+			entry.Time = now
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode journal entry: %w", err)
+		}
+		if _, err := file.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write journal: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReadJournal returns every entry in the journal, oldest first. Lines that
+// can't be parsed, such as one cut short by a crash, are skipped.
+func ReadJournal() ([]JournalEntry, error) {
+	file, err := os.Open(GetJournalPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading journal: %w", err)
+	}
+	return entries, nil
+}
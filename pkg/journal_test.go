@@ -0,0 +1,38 @@
+package pkg
+
+import (
+	"os"
+	"testing"
+)
+
+func TestAppendAndReadJournal(t *testing.T) {
+	setupTestDir(t)
+
+	entries, err := ReadJournal()
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Expected an empty journal, got %+v (%v)", entries, err)
+	}
+
+	if err := AppendJournal(JournalEntry{Kind: JournalSyncQueued, List: "auth.md"}); err != nil {
+		t.Fatalf("AppendJournal failed: %v", err)
+	}
+	if err := AppendJournal(JournalEntry{Kind: JournalSyncFlushed}); err != nil {
+		t.Fatalf("AppendJournal failed: %v", err)
+	}
+
+	// A torn final line is ignored
+	file, _ := os.OpenFile(GetJournalPath(), os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString(`{"kind":"sync-`)
+	file.Close()
+
+	entries, err = ReadJournal()
+	if err != nil {
+		t.Fatalf("ReadJournal failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].List != "auth.md" || entries[1].Kind != JournalSyncFlushed {
+		t.Fatalf("Unexpected entries: %+v", entries)
+	}
+	if entries[0].Time.IsZero() {
+		t.Error("AppendJournal should stamp entries with the current time")
+	}
+}
@@ -0,0 +1,277 @@
+package pkg
+
+import (
+	"fmt"
+	"time"
+)
+
+// ItemChange is what --json prints for a command that changed an item
+type ItemChange struct {
+	// Action is the command that changed the item, such as check
+	Action string   `json:"action"`
+	List   string   `json:"list"`
+	Item   JSONItem `json:"item"`
+	// ClosedIssue is the GitHub issue closed along with the item
+	ClosedIssue string `json:"closed_issue,omitempty"`
+}
+
+// JSONListItem is an item of a view gathered from several lists, such as
+// the agenda or the history
+type JSONListItem struct {
+	List string `json:"list"`
+	// Archived items have no label, as their IDs no longer refer to the list
+	Archived bool `json:"archived,omitempty"`
+	JSONItem
+}
+
+// JSONOverdueItem is an overdue item with how late it is
+type JSONOverdueItem struct {
+	JSONListItem
+	DaysLate int `json:"days_late"`
+}
+
+// JSONOverdueGroup is a group of 'todo overdue', such as "1-3 days late"
+type JSONOverdueGroup struct {
+	Label string            `json:"label"`
+	Items []JSONOverdueItem `json:"items"`
+}
+
+// JSONReminder is an item with a reminder set for today
+type JSONReminder struct {
+	JSONListItem
+	At time.Time `json:"at"`
+}
+
+// JSONToday is what 'todo today --json' writes
+type JSONToday struct {
+	Reminders []JSONReminder `json:"reminders"`
+	Overdue   []JSONListItem `json:"overdue"`
+	Due       []JSONListItem `json:"due"`
+}
+
+// JSONStandup is what 'todo standup --json' writes
+type JSONStandup struct {
+	Done  []JSONListItem `json:"done"`
+	Today []JSONListItem `json:"today"`
+}
+
+// JSONWorkspaceStandup is the standup of a registered workspace, or the
+// error reading it
+type JSONWorkspaceStandup struct {
+	Path  string `json:"path"`
+	Error string `json:"error,omitempty"`
+	*JSONStandup
+}
+
+// JSONRetroItem is a completed item with how long it was open
+type JSONRetroItem struct {
+	JSONListItem
+	DaysOpen int `json:"days_open"`
+}
+
+// JSONRetro is what 'todo retro --json' writes
+type JSONRetro struct {
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	NetChange   int             `json:"net_change"`
+	Completed   []JSONListItem  `json:"completed"`
+	Added       []JSONListItem  `json:"added"`
+	LongestOpen []JSONRetroItem `json:"longest_open"`
+	CarryOver   []JSONListItem  `json:"carry_over"`
+}
+
+// JSONItemDetail is everything 'todo show --json' knows about an item
+type JSONItemDetail struct {
+	List string `json:"list"`
+	JSONItem
+	ShortID  string            `json:"short_id,omitempty"`
+	Anchor   string            `json:"anchor,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// NewItemChange describes the item with id in a list as it is now, labelled
+// the way the list shows it
+func NewItemChange(action, listName string, id int) (*ItemChange, error) {
+	labels, todoList, err := listLabels(listName)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range todoList.Items {
+		if item.ID == id {
+			return &ItemChange{Action: action, List: listName, Item: NewJSONItem(item, labels[i])}, nil
+		}
+	}
+	return nil, fmt.Errorf("item %d not found in list '%s'", id, listName)
+}
+
+// NewItemDetail describes an item of a list with its metadata
+func NewItemDetail(listName string, item TodoItem) (*JSONItemDetail, error) {
+	label, err := itemLabels{}.label(ListItem{List: listName, Item: item})
+	if err != nil {
+		return nil, err
+	}
+	detail := &JSONItemDetail{List: listName, JSONItem: NewJSONItem(item, label), ShortID: item.ShortID, Metadata: item.Metadata}
+	if anchor, ok := ItemAnchor(item); ok {
+		detail.Anchor = anchor.String()
+	}
+	return detail, nil
+}
+
+// HistoryJSON returns the completed items of every list, newest first
+func HistoryJSON() ([]JSONListItem, error) {
+	history, err := CompletedHistory()
+	if err != nil {
+		return nil, err
+	}
+	return ListItemsJSON(history)
+}
+
+// ListItemsJSON labels items gathered from several lists the way their lists
+// show them
+func ListItemsJSON(entries []ListItem) ([]JSONListItem, error) {
+	return itemLabels{}.items(entries)
+}
+
+// OverdueJSON labels the items of the overdue groups
+func OverdueJSON(groups []OverdueGroup) ([]JSONOverdueGroup, error) {
+	labels := itemLabels{}
+	out := make([]JSONOverdueGroup, 0, len(groups))
+	for _, group := range groups {
+		jsonGroup := JSONOverdueGroup{Label: group.Label, Items: make([]JSONOverdueItem, 0, len(group.Items))}
+		for _, entry := range group.Items {
+			item, err := labels.item(entry.ListItem)
+			if err != nil {
+				return nil, err
+			}
+			jsonGroup.Items = append(jsonGroup.Items, JSONOverdueItem{JSONListItem: item, DaysLate: entry.DaysLate})
+		}
+		out = append(out, jsonGroup)
+	}
+	return out, nil
+}
+
+// TodayJSON labels the items of today
+func TodayJSON(today *Today) (*JSONToday, error) {
+	labels := itemLabels{}
+	out := &JSONToday{Reminders: make([]JSONReminder, 0, len(today.Reminders))}
+	for _, entry := range today.Reminders {
+		item, err := labels.item(entry)
+		if err != nil {
+			return nil, err
+		}
+		at, _ := ItemReminder(entry.Item)
+		out.Reminders = append(out.Reminders, JSONReminder{JSONListItem: item, At: at})
+	}
+	var err error
+	if out.Overdue, err = labels.items(today.Overdue); err != nil {
+		return nil, err
+	}
+	if out.Due, err = labels.items(today.Due); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StandupJSON labels the items of a standup. The items of another
+// workspace's standup are left unlabelled, as its lists aren't the ones
+// here.
+func StandupJSON(standup *Standup, labelled bool) (*JSONStandup, error) {
+	var labels itemLabels
+	if labelled {
+		labels = itemLabels{}
+	}
+	done, err := labels.items(standup.Done)
+	if err != nil {
+		return nil, err
+	}
+	today, err := labels.items(standup.Today)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONStandup{Done: done, Today: today}, nil
+}
+
+// RetroJSON labels the items of a retro
+func RetroJSON(retro *Retro) (*JSONRetro, error) {
+	labels := itemLabels{}
+	out := &JSONRetro{
+		From:        retro.From.Format(DueDateFormat),
+		To:          retro.To.Format(DueDateFormat),
+		NetChange:   retro.NetChange(),
+		LongestOpen: make([]JSONRetroItem, 0, len(retro.LongestOpen)),
+	}
+	var err error
+	if out.Completed, err = labels.items(retro.Completed); err != nil {
+		return nil, err
+	}
+	if out.Added, err = labels.items(retro.Added); err != nil {
+		return nil, err
+	}
+	if out.CarryOver, err = labels.items(retro.CarryOver); err != nil {
+		return nil, err
+	}
+	for _, entry := range retro.LongestOpen {
+		item, err := labels.item(entry.ListItem)
+		if err != nil {
+			return nil, err
+		}
+		out.LongestOpen = append(out.LongestOpen, JSONRetroItem{JSONListItem: item, DaysOpen: entry.DaysOpen})
+	}
+	return out, nil
+}
+
+// itemLabels numbers the items of lists the way the lists show them, keyed
+// by list name and item ID, reading each list once. A nil itemLabels leaves
+// items unlabelled.
+type itemLabels map[string]map[int]string
+
+// label returns the label an item is shown with in its list
+func (l itemLabels) label(entry ListItem) (string, error) {
+	if l == nil || entry.Archived {
+		return "", nil
+	}
+	if _, ok := l[entry.List]; !ok {
+		labels, todoList, err := listLabels(entry.List)
+		if err != nil {
+			return "", err
+		}
+		l[entry.List] = make(map[int]string)
+		for i, item := range todoList.Items {
+			l[entry.List][item.ID] = labels[i]
+		}
+	}
+	return l[entry.List][entry.Item.ID], nil
+}
+
+func (l itemLabels) item(entry ListItem) (JSONListItem, error) {
+	label, err := l.label(entry)
+	if err != nil {
+		return JSONListItem{}, err
+	}
+	return JSONListItem{List: entry.List, Archived: entry.Archived, JSONItem: NewJSONItem(entry.Item, label)}, nil
+}
+
+func (l itemLabels) items(entries []ListItem) ([]JSONListItem, error) {
+	out := make([]JSONListItem, 0, len(entries))
+	for _, entry := range entries {
+		item, err := l.item(entry)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// listLabels parses a list and numbers its items following display.numbering
+func listLabels(listName string) ([]string, *TodoList, error) {
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse todo file: %w", err)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	return ItemLabels(todoList, cfg.Display), todoList, nil
+}
@@ -0,0 +1,53 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewItemChange(t *testing.T) {
+	setupTestDir(t)
+
+	store := NewStore()
+	store.AddItem("work", "Write docs")
+	id, _ := store.AddItem("work", "Ship it")
+	store.Flush()
+
+	change, err := NewItemChange("add", "work", id)
+	if err != nil {
+		t.Fatalf("NewItemChange failed: %v", err)
+	}
+	if change.Action != "add" || change.List != "work" || change.Item.Text != "Ship it" || change.Item.Label != "2" {
+		t.Errorf("Unexpected change %+v", change)
+	}
+	if _, err := NewItemChange("check", "work", 9); err == nil {
+		t.Error("Expected an error for a missing item")
+	}
+}
+
+func TestHistoryJSON(t *testing.T) {
+	setupTestDir(t)
+
+	store := NewStore()
+	store.AddItem("work", "Write docs")
+	store.AddItem("work", "Ship it")
+	store.AddItem("home", "Water plants")
+	store.Flush()
+	restore := SetClock(NewSimulatedClock(time.Date(2024, 7, 1, 9, 0, 0, 0, time.Local)))
+	CheckTodoItem("work", 2)
+	restore()
+	restore = SetClock(NewSimulatedClock(time.Date(2024, 7, 2, 9, 0, 0, 0, time.Local)))
+	CheckTodoItem("home", 1)
+	restore()
+
+	history, err := HistoryJSON()
+	if err != nil {
+		t.Fatalf("HistoryJSON failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 completed items, got %+v", history)
+	}
+	if history[0].List != "home" || history[0].Text != "Water plants" || history[1].Label != "2" || !history[1].Completed {
+		t.Errorf("Unexpected history %+v", history)
+	}
+}
@@ -0,0 +1,363 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+)
+
+// LinearAPIURL is the base URL of the Linear API
+var LinearAPIURL = "https://api.linear.app"
+
+// metaLinear is the metadata key holding the identifier, such as ENG-12, of
+// the Linear issue an item is linked to
+const metaLinear = "linear"
+
+// linearSyncName names the Linear provider's sync state and offline queue
+const linearSyncName = "linear"
+
+// Items are linked to issues by the issue identifier in their "linear"
+// metadata and synced as described in syncitems.go. Pending items that
+// aren't linked yet are created as issues on push.
+
+type linearSyncProvider struct {
+	*itemSync
+	client *APIClient
+	lists  map[string]LinearMapping
+	teams  map[string]*linearTeam
+}
+
+// linearTeam holds the ids needed to create and update a list's issues
+type linearTeam struct {
+	ID        string
+	ProjectID string
+	// OpenState and DoneState are the workflow states reopened and
+	// completed issues are moved to
+	OpenState string
+	DoneState string
+}
+
+type linearIssue struct {
+	ID         string
+	Identifier string
+	Title      string
+	Done       bool
+}
+
+func newLinearSyncProvider(cfg *Config) (SyncProvider, error) {
+	return newLinearProvider(cfg, cfg.Linear.Lists)
+}
+
+func newLinearProvider(cfg *Config, lists map[string]LinearMapping) (*linearSyncProvider, error) {
+	if len(lists) == 0 {
+		return nil, fmt.Errorf("no lists are mapped to Linear; add them under linear.lists in .todo/config.yaml")
+	}
+	key, err := GetCredential("linear")
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewAPIClient(LinearAPIURL, 10)
+	client.Header.Set("Authorization", key)
+	p := &linearSyncProvider{client: client, lists: lists, teams: make(map[string]*linearTeam)}
+	p.itemSync = &itemSync{
+		name:      linearSyncName,
+		service:   "Linear",
+		metaKey:   metaLinear,
+		lists:     slices.Sorted(maps.Keys(lists)),
+		key:       itemSyncKey,
+		splitKey:  splitItemSyncKey,
+		fetch:     p.fetch,
+		operation: linearOperation,
+		send:      p.send,
+	}
+	return p, nil
+}
+
+// ImportFromLinear pulls the issues of the lists mapped to Linear, or of one
+// of them when listName isn't empty
+func ImportFromLinear(ctx context.Context, listName string, opts SyncOptions) ([]SyncChange, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	lists := cfg.Linear.Lists
+	if listName != "" {
+		mapping, ok := lists[listName]
+		if !ok {
+			return nil, fmt.Errorf("list '%s' is not mapped to Linear; add it under linear.lists in .todo/config.yaml", listName)
+		}
+		lists = map[string]LinearMapping{listName: mapping}
+	}
+
+	provider, err := newLinearProvider(cfg, lists)
+	if err != nil {
+		return nil, err
+	}
+	return provider.Pull(ctx, opts)
+}
+
+// linearPayload is the data of a queued operation
+type linearPayload struct {
+	Identifier string `json:"identifier,omitempty"`
+	Content    string `json:"content"`
+}
+
+func linearOperation(action, listName, identifier, content, remote string) SyncOperation {
+	payload, _ := json.Marshal(linearPayload{Identifier: identifier, Content: content})
+	id := action + ":" + itemSyncKey(listName, identifier)
+	if identifier == "" {
+		id = action + ":" + itemSyncKey(listName, content)
+	}
+	return SyncOperation{ID: id, Kind: action, List: listName, Payload: payload}
+}
+
+// send carries out a queued operation against Linear
+func (p *linearSyncProvider) send(ctx context.Context, state *SyncState, op SyncOperation) error {
+	var payload linearPayload
+	if err := json.Unmarshal(op.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid queued operation %s: %w", op.ID, err)
+	}
+	team, err := p.team(ctx, op.List)
+	if err != nil {
+		return err
+	}
+	synced := decodeSyncedItem(payload.Content)
+	key := itemSyncKey(op.List, payload.Identifier)
+
+	id := state.IDs[payload.Identifier]
+	if id == "" {
+		id = payload.Identifier
+	}
+
+	switch op.Kind {
+	case SyncAdd:
+		issue, err := p.createIssue(ctx, team, synced.Title)
+		if err != nil {
+			return err
+		}
+		state.IDs[issue.Identifier] = issue.ID
+		state.Base[itemSyncKey(op.List, issue.Identifier)] = payload.Content
+		return linkSyncedItem(op.List, metaLinear, synced.Title, issue.Identifier)
+	case SyncUpdate:
+		stateID := team.OpenState
+		if synced.Done {
+			stateID = team.DoneState
+		}
+		variables := map[string]interface{}{"id": id, "input": map[string]string{"title": synced.Title, "stateId": stateID}}
+		if err := p.graphQL(ctx, linearUpdateMutation, variables, nil); err != nil {
+			return err
+		}
+		state.Base[key] = payload.Content
+	case SyncDelete:
+		if err := p.graphQL(ctx, linearArchiveMutation, map[string]interface{}{"id": id}, nil); err != nil {
+			return err
+		}
+		delete(state.Base, key)
+	default:
+		return fmt.Errorf("unknown operation %q", op.Kind)
+	}
+	return nil
+}
+
+// fetch adds the issues of a list's team and project to the remote
+// snapshot, keeping their ids
+func (p *linearSyncProvider) fetch(ctx context.Context, listName string, snap *itemSnapshot) error {
+	issues, err := p.issues(ctx, p.lists[listName])
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		snap.remote[itemSyncKey(listName, issue.Identifier)] = syncedItem{Title: issue.Title, Done: issue.Done}.encode()
+		snap.ids[issue.Identifier] = issue.ID
+	}
+	return nil
+}
+
+const linearTeamQuery = `query($key: String!) {
+  teams(filter: { key: { eq: $key } }) {
+    nodes { id states { nodes { id type position } } }
+  }
+}`
+
+const linearProjectQuery = `query($name: String!) {
+  projects(filter: { name: { eq: $name } }) { nodes { id } }
+}`
+
+const linearIssuesQuery = `query($filter: IssueFilter, $cursor: String) {
+  issues(filter: $filter, first: 100, after: $cursor) {
+    pageInfo { hasNextPage endCursor }
+    nodes { id identifier title state { type } }
+  }
+}`
+
+const linearCreateMutation = `mutation($input: IssueCreateInput!) {
+  issueCreate(input: $input) { success issue { id identifier } }
+}`
+
+const linearUpdateMutation = `mutation($id: String!, $input: IssueUpdateInput!) {
+  issueUpdate(id: $id, input: $input) { success }
+}`
+
+const linearArchiveMutation = `mutation($id: String!) {
+  issueArchive(id: $id) { success }
+}`
+
+// graphQL sends a query to Linear and decodes its data into out
+func (p *linearSyncProvider) graphQL(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	request := map[string]interface{}{"query": query, "variables": variables}
+	if err := p.client.DoContext(ctx, "POST", "/graphql", request, &response); err != nil {
+		return err
+	}
+	if len(response.Errors) > 0 {
+		return fmt.Errorf("Linear API error: %s", response.Errors[0].Message)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Data, out); err != nil {
+		return fmt.Errorf("failed to decode Linear response: %w", err)
+	}
+	return nil
+}
+
+// team looks up the Linear ids for a list's team and project
+func (p *linearSyncProvider) team(ctx context.Context, listName string) (*linearTeam, error) {
+	if team, ok := p.teams[listName]; ok {
+		return team, nil
+	}
+	mapping := p.lists[listName]
+
+	var teams struct {
+		Teams struct {
+			Nodes []struct {
+				ID     string `json:"id"`
+				States struct {
+					Nodes []struct {
+						ID       string  `json:"id"`
+						Type     string  `json:"type"`
+						Position float64 `json:"position"`
+					} `json:"nodes"`
+				} `json:"states"`
+			} `json:"nodes"`
+		} `json:"teams"`
+	}
+	if err := p.graphQL(ctx, linearTeamQuery, map[string]interface{}{"key": mapping.Team}, &teams); err != nil {
+		return nil, err
+	}
+	if len(teams.Teams.Nodes) == 0 {
+		return nil, fmt.Errorf("Linear team %q not found", mapping.Team)
+	}
+
+	node := teams.Teams.Nodes[0]
+	team := &linearTeam{ID: node.ID}
+	states := node.States.Nodes
+	sort.Slice(states, func(i, j int) bool { return states[i].Position < states[j].Position })
+	for _, state := range states {
+		if state.Type == "completed" && team.DoneState == "" {
+			team.DoneState = state.ID
+		}
+		if state.Type == "unstarted" && team.OpenState == "" {
+			team.OpenState = state.ID
+		}
+	}
+
+	if mapping.Project != "" {
+		var projects struct {
+			Projects struct {
+				Nodes []struct {
+					ID string `json:"id"`
+				} `json:"nodes"`
+			} `json:"projects"`
+		}
+		if err := p.graphQL(ctx, linearProjectQuery, map[string]interface{}{"name": mapping.Project}, &projects); err != nil {
+			return nil, err
+		}
+		if len(projects.Projects.Nodes) == 0 {
+			return nil, fmt.Errorf("Linear project %q not found", mapping.Project)
+		}
+		team.ProjectID = projects.Projects.Nodes[0].ID
+	}
+
+	p.teams[listName] = team
+	return team, nil
+}
+
+// issues returns the issues of a team and project
+func (p *linearSyncProvider) issues(ctx context.Context, mapping LinearMapping) ([]linearIssue, error) {
+	filter := map[string]interface{}{"team": map[string]interface{}{"key": map[string]string{"eq": mapping.Team}}}
+	if mapping.Project != "" {
+		filter["project"] = map[string]interface{}{"name": map[string]string{"eq": mapping.Project}}
+	} else {
+		filter["project"] = map[string]bool{"null": true}
+	}
+
+	var issues []linearIssue
+	var cursor *string
+	for {
+		var page struct {
+			Issues struct {
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []struct {
+					ID         string `json:"id"`
+					Identifier string `json:"identifier"`
+					Title      string `json:"title"`
+					State      struct {
+						Type string `json:"type"`
+					} `json:"state"`
+				} `json:"nodes"`
+			} `json:"issues"`
+		}
+		if err := p.graphQL(ctx, linearIssuesQuery, map[string]interface{}{"filter": filter, "cursor": cursor}, &page); err != nil {
+			return nil, err
+		}
+
+		for _, node := range page.Issues.Nodes {
+			done := node.State.Type == "completed" || node.State.Type == "canceled"
+			issues = append(issues, linearIssue{ID: node.ID, Identifier: node.Identifier, Title: node.Title, Done: done})
+		}
+		if !page.Issues.PageInfo.HasNextPage {
+			return issues, nil
+		}
+		endCursor := page.Issues.PageInfo.EndCursor
+		cursor = &endCursor
+	}
+}
+
+// createIssue opens an issue in a list's team and project
+func (p *linearSyncProvider) createIssue(ctx context.Context, team *linearTeam, title string) (*linearIssue, error) {
+	input := map[string]string{"teamId": team.ID, "title": title}
+	if team.ProjectID != "" {
+		input["projectId"] = team.ProjectID
+	}
+
+	var created struct {
+		IssueCreate struct {
+			Success bool `json:"success"`
+			Issue   struct {
+				ID         string `json:"id"`
+				Identifier string `json:"identifier"`
+			} `json:"issue"`
+		} `json:"issueCreate"`
+	}
+	if err := p.graphQL(ctx, linearCreateMutation, map[string]interface{}{"input": input}, &created); err != nil {
+		return nil, err
+	}
+	if !created.IssueCreate.Success || created.IssueCreate.Issue.Identifier == "" {
+		return nil, fmt.Errorf("Linear did not create the issue %q", title)
+	}
+	return &linearIssue{ID: created.IssueCreate.Issue.ID, Identifier: created.IssueCreate.Issue.Identifier, Title: title}, nil
+}
@@ -0,0 +1,237 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+type fakeLinearIssue struct {
+	ID, Identifier, Title, State string
+	Archived                     bool
+}
+
+// fakeLinear answers the GraphQL requests the Linear provider sends
+type fakeLinear struct {
+	issues  []*fakeLinearIssue
+	updates []string
+}
+
+func (f *fakeLinear) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "lin_key" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var request struct {
+		Query     string          `json:"query"`
+		Variables json.RawMessage `json:"variables"`
+	}
+	json.NewDecoder(r.Body).Decode(&request)
+	var variables struct {
+		ID    string            `json:"id"`
+		Input map[string]string `json:"input"`
+	}
+	json.Unmarshal(request.Variables, &variables)
+
+	var data interface{}
+	switch {
+	case strings.Contains(request.Query, "teams("):
+		data = map[string]interface{}{"teams": map[string]interface{}{"nodes": []interface{}{map[string]interface{}{
+			"id": "team-1",
+			"states": map[string]interface{}{"nodes": []interface{}{
+				map[string]interface{}{"id": "state-done", "type": "completed", "position": 3},
+				map[string]interface{}{"id": "state-todo", "type": "unstarted", "position": 1},
+				map[string]interface{}{"id": "state-backlog", "type": "backlog", "position": 0},
+			}},
+		}}}}
+	case strings.Contains(request.Query, "projects("):
+		data = map[string]interface{}{"projects": map[string]interface{}{"nodes": []interface{}{map[string]string{"id": "project-1"}}}}
+	case strings.Contains(request.Query, "issueCreate"):
+		issue := &fakeLinearIssue{ID: fmt.Sprintf("id-%d", len(f.issues)+1), Identifier: fmt.Sprintf("ENG-%d", len(f.issues)+1), Title: variables.Input["title"], State: "unstarted"}
+		f.issues = append(f.issues, issue)
+		data = map[string]interface{}{"issueCreate": map[string]interface{}{"success": true, "issue": map[string]string{"id": issue.ID, "identifier": issue.Identifier}}}
+	case strings.Contains(request.Query, "issueUpdate"):
+		issue := f.find(variables.ID)
+		issue.Title = variables.Input["title"]
+		issue.State = map[string]string{"state-done": "completed", "state-todo": "unstarted"}[variables.Input["stateId"]]
+		f.updates = append(f.updates, issue.Identifier+" "+issue.State)
+		data = map[string]interface{}{"issueUpdate": map[string]bool{"success": true}}
+	case strings.Contains(request.Query, "issueArchive"):
+		f.find(variables.ID).Archived = true
+		data = map[string]interface{}{"issueArchive": map[string]bool{"success": true}}
+	case strings.Contains(request.Query, "issues("):
+		var nodes []interface{}
+		for _, issue := range f.issues {
+			if !issue.Archived {
+				nodes = append(nodes, map[string]interface{}{"id": issue.ID, "identifier": issue.Identifier, "title": issue.Title, "state": map[string]string{"type": issue.State}})
+			}
+		}
+		data = map[string]interface{}{"issues": map[string]interface{}{"pageInfo": map[string]interface{}{"hasNextPage": false}, "nodes": nodes}}
+	default:
+		http.Error(w, "unexpected query", http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+func (f *fakeLinear) find(id string) *fakeLinearIssue {
+	for _, issue := range f.issues {
+		if issue.ID == id || issue.Identifier == id {
+			return issue
+		}
+	}
+	return &fakeLinearIssue{}
+}
+
+func setupLinear(t *testing.T) *fakeLinear {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	os.WriteFile(GetConfigPath(), []byte("linear:\n  lists:\n    auth:\n      team: ENG\n      project: Auth\n"), 0644)
+	t.Setenv("TODO_LINEAR_TOKEN", "lin_key")
+
+	fake := &fakeLinear{}
+	server := httptest.NewServer(fake)
+	original := LinearAPIURL
+	LinearAPIURL = server.URL
+	t.Cleanup(func() {
+		LinearAPIURL = original
+		server.Close()
+	})
+	return fake
+}
+
+func TestLinearSync(t *testing.T) {
+	fake := setupLinear(t)
+	fake.issues = []*fakeLinearIssue{
+		{ID: "id-1", Identifier: "ENG-1", Title: "Fix login", State: "unstarted"},
+		{ID: "id-2", Identifier: "ENG-2", Title: "Old bug", State: "completed"},
+	}
+	AddTodoItem("auth", "Write docs")
+
+	provider, err := GetSyncProvider("linear")
+	if err != nil {
+		t.Fatalf("GetSyncProvider failed: %v", err)
+	}
+
+	// Pulling adds the issues as items linked to them
+	changes, err := provider.Pull(context.Background(), SyncOptions{})
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if len(changes) != 2 || changes[0].Action != SyncAdd || changes[0].List != "auth/ENG-1" || changes[0].Detail != "Fix login" {
+		t.Fatalf("Unexpected pulled changes: %+v", changes)
+	}
+	todoList, _ := ParseTodoFile("auth")
+	if len(todoList.Items) != 3 || todoList.Items[1].Metadata[metaLinear] != "ENG-1" || !todoList.Items[2].Completed {
+		t.Fatalf("Unexpected list after pull: %+v", todoList.Items)
+	}
+
+	// Pushing creates issues for unlinked items and links them
+	changes, err = provider.Push(context.Background(), SyncOptions{})
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Action != SyncAdd || changes[0].Detail != "Write docs" {
+		t.Errorf("Unexpected pushed changes: %+v", changes)
+	}
+	if len(fake.issues) != 3 || fake.issues[2].Title != "Write docs" {
+		t.Fatalf("Expected an issue for the new item, got %+v", fake.issues)
+	}
+	todoList, _ = ParseTodoFile("auth")
+	if todoList.Items[0].Metadata[metaLinear] != "ENG-3" {
+		t.Errorf("Expected the item to be linked to ENG-3, got %v", todoList.Items[0].Metadata)
+	}
+
+	// Checking an item completes its issue
+	CheckTodoItem("auth", 2)
+	if _, err := provider.Push(context.Background(), SyncOptions{}); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if len(fake.updates) != 1 || fake.updates[0] != "ENG-1 completed" {
+		t.Errorf("Unexpected issue updates: %v", fake.updates)
+	}
+
+	// Renaming and reopening an issue updates its item
+	fake.issues[1].Title = "Old bug, again"
+	fake.issues[1].State = "unstarted"
+	if _, err := provider.Pull(context.Background(), SyncOptions{}); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	todoList, _ = ParseTodoFile("auth")
+	if todoList.Items[2].Text != "Old bug, again" || todoList.Items[2].Completed {
+		t.Errorf("Expected the reopened, renamed issue, got %+v", todoList.Items[2])
+	}
+
+	status, err := provider.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(status.Incoming) != 0 || len(status.Outgoing) != 0 {
+		t.Errorf("Expected everything up to date, got %+v", status)
+	}
+}
+
+func TestLinearSyncConflict(t *testing.T) {
+	fake := setupLinear(t)
+	fake.issues = []*fakeLinearIssue{{ID: "id-1", Identifier: "ENG-1", Title: "Fix login", State: "unstarted"}}
+
+	provider, _ := GetSyncProvider("linear")
+	if _, err := provider.Pull(context.Background(), SyncOptions{}); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+
+	fake.issues[0].Title = "Fix login on Safari"
+	CheckTodoItem("auth", 1)
+
+	status, err := provider.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(status.Incoming) != 1 || status.Incoming[0].Action != SyncConflict {
+		t.Fatalf("Expected a conflict, got %+v", status)
+	}
+
+	// Pulling leaves the conflict alone; --force takes Linear's version
+	provider.Pull(context.Background(), SyncOptions{})
+	if todoList, _ := ParseTodoFile("auth"); !todoList.Items[0].Completed {
+		t.Error("A conflicting item should not be changed by a plain pull")
+	}
+	provider.Pull(context.Background(), SyncOptions{Force: true})
+	todoList, _ := ParseTodoFile("auth")
+	if todoList.Items[0].Completed || todoList.Items[0].Text != "Fix login on Safari" {
+		t.Errorf("Expected Linear's version after a forced pull, got %+v", todoList.Items[0])
+	}
+
+	// LocalChanges works without reaching Linear
+	LinearAPIURL = "http://127.0.0.1:1"
+	CheckTodoItem("auth", 1)
+	changes, err := provider.LocalChanges()
+	if err != nil || len(changes) != 1 || changes[0].Action != SyncUpdate {
+		t.Errorf("LocalChanges() = %+v, %v", changes, err)
+	}
+}
+
+func TestImportFromLinear(t *testing.T) {
+	fake := setupLinear(t)
+	fake.issues = []*fakeLinearIssue{{ID: "id-1", Identifier: "ENG-1", Title: "Fix login", State: "unstarted"}}
+
+	if _, err := ImportFromLinear(context.Background(), "other", SyncOptions{}); err == nil {
+		t.Error("Expected an error importing into an unmapped list")
+	}
+
+	changes, err := ImportFromLinear(context.Background(), "auth", SyncOptions{DryRun: true})
+	if err != nil || len(changes) != 1 || TodoFileExists("auth") {
+		t.Fatalf("Dry run = %+v, %v; the list should not be created", changes, err)
+	}
+	if _, err := ImportFromLinear(context.Background(), "auth", SyncOptions{}); err != nil {
+		t.Fatalf("ImportFromLinear failed: %v", err)
+	}
+	if todoList, err := ParseTodoFile("auth"); err != nil || len(todoList.Items) != 1 {
+		t.Errorf("Expected the imported issue in auth, got %+v (%v)", todoList, err)
+	}
+}
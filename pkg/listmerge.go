@@ -0,0 +1,184 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MergeDriverName is the name todo's merge driver is registered under in
+// git config and .gitattributes
+const MergeDriverName = "todo"
+
+// ListMerge is the outcome of a three-way merge of two versions of a list
+type ListMerge struct {
+	List *TodoList
+	// Conflicts describe things changed differently on both sides, where
+	// our version was kept
+	Conflicts []string
+}
+
+// MergeLists merges the changes made from base in ours and theirs. Items
+// are matched by their text and kept if either side has them: items added
+// on either side are kept, as are items one side removed but the other
+// changed, and only items removed on one side and left alone on the other
+// are removed. When both sides changed an item, a completed item wins over
+// an open one; any other difference keeps ours.
+func MergeLists(base, ours, theirs *TodoList) *ListMerge {
+	merge := &ListMerge{List: &TodoList{Meta: ours.Meta, Headings: ours.Headings}}
+	baseItems, _ := keyItems(base.Items)
+	ourItems, ourKeys := keyItems(ours.Items)
+	theirItems, theirKeys := keyItems(theirs.Items)
+
+	if metaState(theirs.Meta) != metaState(base.Meta) {
+		switch metaState(ours.Meta) {
+		case metaState(base.Meta):
+			merge.List.Meta = theirs.Meta
+		case metaState(theirs.Meta):
+		default:
+			merge.Conflicts = append(merge.Conflicts, "the list's frontmatter was changed on both sides")
+		}
+	}
+
+	// Start from our items and bring in what changed on their side
+	keys := append([]string(nil), ourKeys...)
+	items := make(map[string]TodoItem)
+	for key, item := range ourItems {
+		items[key] = item
+	}
+	for i, key := range theirKeys {
+		item := theirItems[key]
+		baseItem, inBase := baseItems[key]
+		ourItem, inOurs := ourItems[key]
+		switch {
+		case !inOurs:
+			// Added on their side, or removed on ours but changed on theirs
+			if !inBase || itemState(item) != itemState(baseItem) {
+				keys = insertAfter(keys, key, theirKeys[:i], i)
+				items[key] = item
+			}
+		case inBase && itemState(item) == itemState(baseItem):
+		case inBase && itemState(ourItem) == itemState(baseItem):
+			items[key] = item
+		default:
+			// Changed on both sides, or added on both
+			merged, conflict := mergeItem(ourItem, item)
+			items[key] = merged
+			if conflict {
+				merge.Conflicts = append(merge.Conflicts, fmt.Sprintf("'%s' was changed on both sides", item.Text))
+			}
+		}
+	}
+	for key, baseItem := range baseItems {
+		if _, ok := theirItems[key]; ok {
+			continue
+		}
+		if ourItem, ok := ourItems[key]; ok && itemState(ourItem) == itemState(baseItem) {
+			delete(items, key)
+		}
+	}
+
+	for _, key := range keys {
+		if item, ok := items[key]; ok {
+			item.ID = len(merge.List.Items) + 1
+			merge.List.Items = append(merge.List.Items, item)
+		}
+	}
+	return merge
+}
+
+// mergeItem combines two versions of an item: ours, completed if theirs
+// is. It reports a conflict when they differ in more than whether they
+// are done.
+func mergeItem(ours, theirs TodoItem) (TodoItem, bool) {
+	merged := ours
+	if theirs.Completed && !ours.Completed {
+		merged.Completed, merged.CompletedTime = true, theirs.CompletedTime
+	}
+	open := func(item TodoItem) string {
+		item.Completed, item.CompletedTime = false, nil
+		return itemState(item)
+	}
+	return merged, open(ours) != open(theirs)
+}
+
+// MergeListFiles is the merge driver git runs for list files: it merges the
+// lists in the base, ours and theirs files with MergeLists and writes the
+// result over ours, keeping its heading.
+func MergeListFiles(basePath, oursPath, theirsPath string) (*ListMerge, error) {
+	var lists []*TodoList
+	var title string
+	for _, filePath := range []string{basePath, oursPath, theirsPath} {
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+		}
+		todoList, err := parseTodoList(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+		}
+		lists = append(lists, todoList)
+		if filePath == oursPath {
+			title = fileTitle(content)
+		}
+	}
+
+	merge := MergeLists(lists[0], lists[1], lists[2])
+	if err := writeTodoFileAt(oursPath, title, merge.List); err != nil {
+		return nil, err
+	}
+	return merge, nil
+}
+
+// fileTitle returns the "# " heading of a list file
+func fileTitle(content []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if title, ok := strings.CutPrefix(scanner.Text(), "# "); ok {
+			return strings.TrimSpace(title)
+		}
+	}
+	return "Todo List"
+}
+
+// InstallMergeDriver registers 'todo mergetool' as the git merge driver for
+// the list files. The driver is defined in the repository's git config, and
+// .todo/.gitattributes, which is shared along with the lists, applies it to
+// them; clones that haven't registered the driver merge lists as text.
+func InstallMergeDriver() error {
+	if err := RequireGit(); err != nil {
+		return err
+	}
+	if _, err := runGit("config", "merge."+MergeDriverName+".name", "todo list merge"); err != nil {
+		return err
+	}
+	if _, err := runGit("config", "merge."+MergeDriverName+".driver", "todo mergetool %O %A %B"); err != nil {
+		return err
+	}
+
+	if err := EnsureTodoDirectory(); err != nil {
+		return fmt.Errorf("failed to create .todo directory: %w", err)
+	}
+	attributesPath := filepath.Join(".todo", ".gitattributes")
+	content, err := os.ReadFile(attributesPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", attributesPath, err)
+	}
+	rule := "*.md merge=" + MergeDriverName
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == rule {
+			return nil
+		}
+	}
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+	content = append(content, rule+"\n"...)
+	if err := os.WriteFile(attributesPath, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", attributesPath, err)
+	}
+	return nil
+}
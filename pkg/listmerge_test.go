@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeLists(t *testing.T) {
+	base := parseTestList(t, "# Todo List for main\n\n- [ ] Write docs\n- [ ] Fix login\n- [ ] Old task\n- [ ] Stale task\n- [ ] Tag release\n")
+	// We checked one item, added one, removed one and reprioritized another
+	ours := parseTestList(t, "# Todo List for main\n\n- [x] Write docs\n- [ ] Fix login <!-- priority: p1 -->\n- [ ] Stale task\n- [ ] Tag release\n- [ ] Announce\n")
+	// They checked the reprioritized item, added one after the first, noted
+	// the item we removed, removed one we left alone and set a target
+	theirs := parseTestList(t, "---\ntarget: 2024-08-01\n---\n# Todo List for main\n\n- [ ] Write docs\n- [ ] Review PR\n- [x] Fix login\n- [ ] Old task\n  Still needed for the beta\n- [ ] Tag release\n")
+
+	merge := MergeLists(base, ours, theirs)
+	var lines []string
+	for i, item := range merge.List.Items {
+		if item.ID != i+1 {
+			t.Errorf("Expected item %d to be renumbered, got %d", i+1, item.ID)
+		}
+		lines = append(lines, formatItemLine(item))
+	}
+	want := []string{"- [x] Write docs", "- [ ] Review PR", "- [x] Fix login <!-- priority: p1 -->", "- [ ] Old task", "- [ ] Tag release", "- [ ] Announce"}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected merge:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+	if merge.List.Meta.Target != "2024-08-01" {
+		t.Errorf("Expected their target, got %+v", merge.List.Meta)
+	}
+	// Checking 'Fix login' on one side and reprioritizing it on the other
+	// differ in more than completion
+	if len(merge.Conflicts) != 1 || !strings.Contains(merge.Conflicts[0], "'Fix login' was changed on both sides") {
+		t.Errorf("Expected a conflict for 'Fix login', got %v", merge.Conflicts)
+	}
+}
+
+func TestMergeListsCompletionWins(t *testing.T) {
+	base := parseTestList(t, "# Todo List for main\n\n- [x] Deploy <!-- completed: 2024-06-01 10:00 -->\n")
+	ours := parseTestList(t, "# Todo List for main\n\n- [ ] Deploy\n- [ ] Ship\n")
+	theirs := parseTestList(t, "# Todo List for main\n\n- [x] Deploy <!-- completed: 2024-06-03 12:00 -->\n- [x] Ship <!-- completed: 2024-06-02 09:00 -->\n")
+
+	merge := MergeLists(base, ours, theirs)
+	if len(merge.List.Items) != 2 || !merge.List.Items[0].Completed || !merge.List.Items[1].Completed {
+		t.Fatalf("Expected both items checked, got %+v", merge.List.Items)
+	}
+	if got := merge.List.Items[0].CompletedTime.Format("2006-01-02"); got != "2024-06-03" {
+		t.Errorf("Expected their completion time, got %s", got)
+	}
+	if len(merge.Conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got %v", merge.Conflicts)
+	}
+}
+
+func TestMergeListFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		filePath := filepath.Join(dir, name)
+		os.WriteFile(filePath, []byte(content), 0644)
+		return filePath
+	}
+	base := write("base", "# Todo List for auth\n\n- [ ] Login form\n")
+	ours := write("ours", "# Todo List for auth\n\n- [x] Login form\n- [ ] Logout\n")
+	theirs := write("theirs", "# Todo List for auth\n\n- [ ] Login form\n- [ ] Remember me\n")
+
+	if _, err := MergeListFiles(base, ours, theirs); err != nil {
+		t.Fatalf("MergeListFiles failed: %v", err)
+	}
+	content, _ := os.ReadFile(ours)
+	if want := "# Todo List for auth\n\n- [x] Login form\n- [ ] Remember me\n- [ ] Logout\n"; string(content) != want {
+		t.Errorf("Unexpected merged file:\n%s\nwant:\n%s", content, want)
+	}
+	if _, err := MergeListFiles(filepath.Join(dir, "missing"), ours, theirs); err == nil {
+		t.Error("Expected a missing file to fail")
+	}
+}
+
+func TestInstallMergeDriver(t *testing.T) {
+	setupGitTestDir(t)
+
+	for range 2 {
+		if err := InstallMergeDriver(); err != nil {
+			t.Fatalf("InstallMergeDriver failed: %v", err)
+		}
+	}
+	if driver, _ := exec.Command("git", "config", "merge.todo.driver").Output(); strings.TrimSpace(string(driver)) != "todo mergetool %O %A %B" {
+		t.Errorf("Unexpected merge driver %q", driver)
+	}
+	content, _ := os.ReadFile(filepath.Join(".todo", ".gitattributes"))
+	if string(content) != "*.md merge=todo\n" {
+		t.Errorf("Expected the rule once, got %q", content)
+	}
+	if output, _ := exec.Command("git", "check-attr", "merge", ".todo/auth.md").Output(); !strings.Contains(string(output), "merge: todo") {
+		t.Errorf("Expected the driver to apply to list files, got %q", output)
+	}
+}
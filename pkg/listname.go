@@ -0,0 +1,159 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// List matching modes for the list_matching setting
+const (
+	// ListMatchingFold treats names that differ only in case or unicode
+	// normalization as the same list (the default)
+	ListMatchingFold = "fold"
+	// ListMatchingExact only treats identical names as the same list
+	ListMatchingExact = "exact"
+)
+
+// ListKey returns the form of a list name used to compare it with others.
+// Names are always NFC-normalized, so an "é" typed as one code point or as
+// "e" plus a combining accent is the same list; in fold mode case is
+// ignored too.
+func ListKey(name string, cfg *Config) string {
+	if cfg.ListMatching == ListMatchingExact {
+		return norm.NFC.String(name)
+	}
+	return norm.NFC.String(cases.Fold().String(name))
+}
+
+// ResolveListName returns the name of the existing list that name refers to.
+// An exact match wins; otherwise a single list with the same key is used. If
+// no list matches, name is returned NFC-normalized so new lists are created
+// under a canonical name.
+func ResolveListName(name string) string {
+	normalized := norm.NFC.String(name)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return normalized
+	}
+	lists, err := GetAllLists()
+	if err != nil {
+		return normalized
+	}
+
+	key := ListKey(name, cfg)
+	var matches []string
+	for _, list := range lists {
+		if list == name || list == normalized {
+			return list
+		}
+		if ListKey(list, cfg) == key {
+			matches = append(matches, list)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0]
+	}
+	return normalized
+}
+
+// ListNameCollisions returns the groups of existing lists whose names match
+// each other under the list_matching setting, such as "Auth" and "auth"
+func ListNameCollisions() ([][]string, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	lists, err := GetAllLists()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]string)
+	for _, list := range lists {
+		key := ListKey(list, cfg)
+		groups[key] = append(groups[key], list)
+	}
+
+	var collisions [][]string
+	for _, group := range groups {
+		if len(group) > 1 {
+			sort.Strings(group)
+			collisions = append(collisions, group)
+		}
+	}
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i][0] < collisions[j][0]
+	})
+	return collisions, nil
+}
+
+// MergedCollision describes lists that were merged into one
+type MergedCollision struct {
+	Into   string
+	Merged []string
+}
+
+// MergeCaseDuplicates merges each group of colliding lists into one. The
+// current list is kept if it is in the group, then a name already in its
+// canonical form, then the first name in order.
+func MergeCaseDuplicates() ([]MergedCollision, error) {
+	collisions, err := ListNameCollisions()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	currentList, _ := GetCurrentList()
+
+	var merged []MergedCollision
+	for _, group := range collisions {
+		into := mergeTarget(group, currentList, cfg)
+		result := MergedCollision{Into: into}
+		for _, list := range group {
+			if list == into {
+				continue
+			}
+			if err := AdoptList(list, into); err != nil {
+				return merged, fmt.Errorf("failed to merge '%s' into '%s': %w", list, into, err)
+			}
+			result.Merged = append(result.Merged, list)
+		}
+		merged = append(merged, result)
+	}
+	return merged, nil
+}
+
+func mergeTarget(group []string, currentList string, cfg *Config) string {
+	for _, list := range group {
+		if list == currentList {
+			return list
+		}
+	}
+	for _, list := range group {
+		if list == ListKey(list, cfg) {
+			return list
+		}
+	}
+	return group[0]
+}
+
+// ListNameWarnings describes each group of colliding list names
+func ListNameWarnings() []string {
+	collisions, err := ListNameCollisions()
+	if err != nil {
+		return nil
+	}
+
+	var warnings []string
+	for _, group := range collisions {
+		warnings = append(warnings, fmt.Sprintf("lists '%s' only differ in case or accents; merge them with 'todo list --merge-case-duplicates'", strings.Join(group, "', '")))
+	}
+	return warnings
+}
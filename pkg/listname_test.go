@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestResolveListName(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("auth")
+	CreateTodoFile("Caf\u00e9")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"auth", "auth"},
+		{"AUTH", "auth"},
+		{"caf\u00e9", "Caf\u00e9"},
+		{"CAFE\u0301", "Caf\u00e9"},
+		{"billing", "billing"},
+		{"Bille\u0301", "Bill\u00e9"},
+	}
+	for _, tt := range tests {
+		if got := ResolveListName(tt.name); got != tt.want {
+			t.Errorf("ResolveListName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// Exact matching still normalizes, but keeps case
+	SaveConfig(&Config{ListMatching: ListMatchingExact})
+	if got := ResolveListName("AUTH"); got != "AUTH" {
+		t.Errorf("ResolveListName(AUTH) with exact matching = %q", got)
+	}
+	if got := ResolveListName("Cafe\u0301"); got != "Caf\u00e9" {
+		t.Errorf("ResolveListName(Cafe\\u0301) with exact matching = %q", got)
+	}
+}
+
+func TestMergeCaseDuplicates(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("auth")
+	AddTodoItem("auth", "Login form")
+
+	// Write the duplicate directly, as a checkout on another machine might;
+	// on a case-insensitive filesystem it would be the same file
+	os.WriteFile(GetTodoFilePath("Auth"), []byte("# Todo List for Auth\n\n- [ ] Logout\n"), 0644)
+	if lists, _ := GetAllLists(); len(lists) != 2 {
+		t.Skip("filesystem is case-insensitive")
+	}
+
+	collisions, err := ListNameCollisions()
+	if err != nil {
+		t.Fatalf("ListNameCollisions failed: %v", err)
+	}
+	if !reflect.DeepEqual(collisions, [][]string{{"Auth", "auth"}}) {
+		t.Fatalf("Unexpected collisions: %v", collisions)
+	}
+	if warnings := ListNameWarnings(); len(warnings) != 1 {
+		t.Errorf("Expected one warning, got %v", warnings)
+	}
+
+	SetCurrentList("Auth")
+	merged, err := MergeCaseDuplicates()
+	if err != nil {
+		t.Fatalf("MergeCaseDuplicates failed: %v", err)
+	}
+	if len(merged) != 1 || merged[0].Into != "Auth" || !reflect.DeepEqual(merged[0].Merged, []string{"auth"}) {
+		t.Errorf("Unexpected merge: %+v", merged)
+	}
+
+	todoList, _ := ParseTodoFile("Auth")
+	if len(todoList.Items) != 2 || TodoFileExists("auth") {
+		t.Errorf("Expected one merged list, got %+v", todoList.Items)
+	}
+}
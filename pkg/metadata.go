@@ -0,0 +1,330 @@
+package pkg
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Item lines carry their metadata in a trailing HTML comment, which markdown
+// renderers hide:
+//
+//	- [x] Write notes <!-- due: 2024-06-28; completed: 2024-06-27 16:30 -->
+//
+// Values that aren't plain words are written as Go-quoted strings. When the text
+// itself can't be read back exactly from the line (it contains a newline,
+// surrounding whitespace, or something that looks like metadata), the exact
+// text is stored in the comment as well, under "text".
+//
+// Lines without a metadata comment are read in the older format, where the
+// due date and completion time are visible suffixes:
+//
+//	- [x] Write notes (due: 2024-06-28) (completed: 2024-06-27 16:30)
+
+// Metadata keys with a meaning of their own; any other key is kept in
+// TodoItem.Metadata
+const (
+	metaID        = "id"
+	metaWeight    = "weight"
+	metaEstimate  = "estimate"
+	metaPriority  = "priority"
+	metaDue       = "due"
+	metaCompleted = "completed"
+	metaText      = "text"
+)
+
+const completedTimeFormat = "2006-01-02 15:04"
+
+var (
+	itemRegex       = regexp.MustCompile(`^- \[([ xX])\] (.*)$`)
+	sectionRegex    = regexp.MustCompile(`^##\s+(.+)$`)
+	legacyItemRegex = regexp.MustCompile(`^(.+?)(\s+\(due:\s+(\d{4}-\d{2}-\d{2})\))?(\s+\(completed:\s+([^()]+?)\))?$`)
+	metaKeyRegex    = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+	bareValueRegex  = regexp.MustCompile(`^[A-Za-z0-9_.:+/#@=-]+(?: [A-Za-z0-9_.:+/#@=-]+)*$`)
+
+	angleEscaper = strings.NewReplacer("<", `\x3c`, ">", `\x3e`)
+)
+
+// parseItemLine reads an item from a trimmed line of a todo file
+func parseItemLine(line string) (TodoItem, bool) {
+	match := itemRegex.FindStringSubmatch(line)
+	if match == nil {
+		return TodoItem{}, false
+	}
+
+	item := TodoItem{Completed: match[1] != " "}
+	rest := match[2]
+
+	if text, meta, ok := splitMetadataComment(rest); ok {
+		item.Text = text
+		applyMetadata(&item, meta)
+		return item, true
+	}
+
+	if rest == "" {
+		return TodoItem{}, false
+	}
+	parseLegacySuffixes(&item, rest)
+	return item, true
+}
+
+// splitMetadataComment separates a trailing metadata comment from the text
+func splitMetadataComment(rest string) (string, map[string]string, bool) {
+	if !strings.HasSuffix(rest, "-->") {
+		return "", nil, false
+	}
+	start := strings.LastIndex(rest, "<!--")
+	if start < 0 || start+4 > len(rest)-3 {
+		return "", nil, false
+	}
+
+	meta, ok := parseMetadata(rest[start+4 : len(rest)-3])
+	if !ok {
+		return "", nil, false
+	}
+	return strings.TrimSuffix(rest[:start], " "), meta, true
+}
+
+// parseMetadata reads "key: value; key: value" pairs
+func parseMetadata(body string) (map[string]string, bool) {
+	s := strings.TrimSpace(body)
+	if s == "" {
+		return nil, false
+	}
+
+	meta := make(map[string]string)
+	for s != "" {
+		colon := strings.Index(s, ":")
+		if colon < 1 || !metaKeyRegex.MatchString(s[:colon]) {
+			return nil, false
+		}
+		key := s[:colon]
+		s = strings.TrimLeft(s[colon+1:], " ")
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := quotedEnd(s)
+			if end < 0 {
+				return nil, false
+			}
+			unquoted, err := strconv.Unquote(s[:end])
+			if err != nil {
+				return nil, false
+			}
+			value = unquoted
+			s = s[end:]
+		} else {
+			end := strings.Index(s, ";")
+			if end < 0 {
+				end = len(s)
+			}
+			value = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+
+		s = strings.TrimLeft(s, " ")
+		if s != "" {
+			if s[0] != ';' {
+				return nil, false
+			}
+			s = strings.TrimLeft(s[1:], " ")
+		}
+		meta[key] = value
+	}
+	return meta, true
+}
+
+// quotedEnd returns the index just past the quoted string at the start of s,
+// or -1 if it isn't terminated
+func quotedEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// applyMetadata sets the item fields a comment describes. Values that don't
+// parse are kept in Metadata so they are written back unchanged.
+func applyMetadata(item *TodoItem, meta map[string]string) {
+	if text, ok := meta[metaText]; ok {
+		item.Text = text
+		delete(meta, metaText)
+	}
+	if id, ok := meta[metaID]; ok && id != "" {
+		item.ShortID = id
+		delete(meta, metaID)
+	}
+	if weight, ok := meta[metaWeight]; ok {
+		if parsed, err := strconv.Atoi(weight); err == nil && parsed > 0 {
+			item.Weight = parsed
+			delete(meta, metaWeight)
+		}
+	}
+	if estimate, ok := meta[metaEstimate]; ok {
+		if parsed, err := time.ParseDuration(estimate); err == nil && parsed > 0 {
+			item.Estimate = parsed
+			delete(meta, metaEstimate)
+		}
+	}
+	if priority, ok := meta[metaPriority]; ok {
+		if parsed, err := ParsePriority(priority); err == nil {
+			item.Priority = parsed
+			delete(meta, metaPriority)
+		}
+	}
+	if due, ok := meta[metaDue]; ok {
+		if parsed, err := time.ParseInLocation(DueDateFormat, due, time.Local); err == nil {
+			item.DueDate = &parsed
+			delete(meta, metaDue)
+		}
+	}
+	if completed, ok := meta[metaCompleted]; ok && item.Completed {
+		if parsed, err := time.ParseInLocation(completedTimeFormat, completed, time.Local); err == nil {
+			item.CompletedTime = &parsed
+			delete(meta, metaCompleted)
+		}
+	}
+	if len(meta) > 0 {
+		item.Metadata = meta
+	}
+}
+
+// parseLegacySuffixes reads the older visible (due: ...) and (completed: ...)
+// suffixes. Suffixes that don't parse stay part of the text so they survive
+// being written back.
+func parseLegacySuffixes(item *TodoItem, rest string) {
+	match := legacyItemRegex.FindStringSubmatch(rest)
+	if match == nil {
+		item.Text = rest
+		return
+	}
+	item.Text = match[1]
+
+	if match[3] != "" {
+		if parsed, err := time.ParseInLocation(DueDateFormat, match[3], time.Local); err == nil {
+			item.DueDate = &parsed
+		} else {
+			item.Text += match[2]
+		}
+	}
+
+	if match[5] != "" {
+		if parsed, err := time.ParseInLocation(completedTimeFormat, match[5], time.Local); err == nil && item.Completed {
+			item.CompletedTime = &parsed
+		} else {
+			item.Text += match[4]
+		}
+	}
+}
+
+// formatItemLine writes an item as a line of a todo file
+func formatItemLine(item TodoItem) string {
+	checkbox := " "
+	if item.Completed {
+		checkbox = "x"
+	}
+
+	meta := itemMetadata(item)
+
+	// An item is a single line, so line breaks are shown as spaces
+	visible := strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(item.Text)
+
+	line := "- [" + checkbox + "] " + visible + formatMetadata(meta)
+	if parsed, ok := parseItemLine(strings.TrimSpace(line)); !ok || !sameItem(parsed, item) {
+		meta[metaText] = item.Text
+		line = "- [" + checkbox + "] " + visible + formatMetadata(meta)
+	}
+	return line
+}
+
+// itemMetadata returns the metadata written for an item: its Metadata along
+// with the fields that are written as metadata
+func itemMetadata(item TodoItem) map[string]string {
+	meta := make(map[string]string)
+	for key, value := range item.Metadata {
+		meta[key] = value
+	}
+	if item.ShortID != "" {
+		meta[metaID] = item.ShortID
+	}
+	if item.Weight > 0 {
+		meta[metaWeight] = strconv.Itoa(item.Weight)
+	}
+	if item.Estimate > 0 {
+		meta[metaEstimate] = FormatEstimate(item.Estimate)
+	}
+	if item.Priority > 0 {
+		meta[metaPriority] = FormatPriority(item.Priority)
+	}
+	if item.DueDate != nil {
+		meta[metaDue] = item.DueDate.Format(DueDateFormat)
+	}
+	if item.Completed && item.CompletedTime != nil {
+		meta[metaCompleted] = item.CompletedTime.Format(completedTimeFormat)
+	}
+	return meta
+}
+
+// formatMetadata writes a metadata comment, with the short ID, due date and
+// completion time first, then other keys in order, then the exact text
+func formatMetadata(meta map[string]string) string {
+	if len(meta) == 0 {
+		return ""
+	}
+
+	var keys []string
+	for key := range meta {
+		if key != metaID && key != metaDue && key != metaCompleted && key != metaText {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	keys = append([]string{metaID, metaDue, metaCompleted}, append(keys, metaText)...)
+
+	var pairs []string
+	for _, key := range keys {
+		value, ok := meta[key]
+		if !ok {
+			continue
+		}
+		if key == metaText || !bareValueRegex.MatchString(value) {
+			// Escaping < and > keeps a value from opening or closing a
+			// comment; strconv keeps invalid UTF-8 byte for byte
+			value = angleEscaper.Replace(strconv.Quote(value))
+		}
+		pairs = append(pairs, key+": "+value)
+	}
+	return " <!-- " + strings.Join(pairs, "; ") + " -->"
+}
+
+// sameItem reports whether parsing a written line gave back the item
+func sameItem(parsed, item TodoItem) bool {
+	if parsed.Text != item.Text || parsed.Completed != item.Completed || parsed.ShortID != item.ShortID || parsed.Weight != max(item.Weight, 0) || parsed.Estimate != max(item.Estimate, 0) || parsed.Priority != item.Priority {
+		return false
+	}
+	if formatTime(parsed.DueDate, DueDateFormat) != formatTime(item.DueDate, DueDateFormat) {
+		return false
+	}
+	if item.Completed && formatTime(parsed.CompletedTime, completedTimeFormat) != formatTime(item.CompletedTime, completedTimeFormat) {
+		return false
+	}
+	if len(parsed.Metadata) == 0 && len(item.Metadata) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(parsed.Metadata, item.Metadata)
+}
+
+func formatTime(t *time.Time, layout string) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(layout)
+}
@@ -0,0 +1,193 @@
+package pkg
+
+import (
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// adversarialChunks are pieces of text that look like metadata, end a line
+// or an HTML comment, or otherwise trip up a line-based format
+var adversarialChunks = []string{
+	"(completed: 2024-01-01 10:00)", "(due: 2024-07-01)", "(due: 2024-13-45)",
+	"(completed: yesterday)", "(", ")", "()", "<!--", "-->", "<!-- due: 2024-07-01 -->",
+	"<!-- text: \"x\" -->", "due:", "text:", ";", ":", "\"", "\\", "\\\"", "\n", "\r\n",
+	"\r", "\t", " ", "  ", "- [ ] ", "- [x] ", "#tag", "é", "🎉", "שלום", "&lt;", "\x00",
+	"word", "Ship it", "2024-07-01",
+}
+
+// adversarialText generates item texts built from adversarialChunks and
+// random runes
+type adversarialText string
+
+func (adversarialText) Generate(r *rand.Rand, size int) reflect.Value {
+	var b strings.Builder
+	for i := r.Intn(size + 1); i > 0; i-- {
+		if r.Intn(4) == 0 {
+			b.WriteRune(rune(r.Intn(0x2000)))
+		} else {
+			b.WriteString(adversarialChunks[r.Intn(len(adversarialChunks))])
+		}
+	}
+	text := strings.ToValidUTF8(b.String(), "?")
+	return reflect.ValueOf(adversarialText(text))
+}
+
+// metadataItem generates items with adversarial text and any combination of
+// due date, completion time and extra metadata
+type metadataItem TodoItem
+
+func (metadataItem) Generate(r *rand.Rand, size int) reflect.Value {
+	item := TodoItem{
+		ID:        1,
+		Text:      string(adversarialText("").Generate(r, size).Interface().(adversarialText)),
+		Completed: r.Intn(2) == 0,
+	}
+	if r.Intn(2) == 0 {
+		due := time.Date(2020+r.Intn(10), time.Month(1+r.Intn(12)), 1+r.Intn(28), 0, 0, 0, 0, time.Local)
+		item.DueDate = &due
+	}
+	if item.Completed && r.Intn(2) == 0 {
+		completed := time.Date(2020+r.Intn(10), time.Month(1+r.Intn(12)), 1+r.Intn(28), r.Intn(24), r.Intn(60), 0, 0, time.Local)
+		item.CompletedTime = &completed
+	}
+	if r.Intn(3) == 0 {
+		item.Metadata = map[string]string{
+			"owner": string(adversarialText("").Generate(r, 3).Interface().(adversarialText)),
+		}
+	}
+	return reflect.ValueOf(metadataItem(item))
+}
+
+func TestItemTextRoundTripProperty(t *testing.T) {
+	property := func(text adversarialText, completed bool) bool {
+		item := TodoItem{Text: string(text), Completed: completed}
+		parsed, ok := parseItemLine(strings.TrimSpace(formatItemLine(item)))
+		return ok && parsed.Text == item.Text && parsed.Completed == completed &&
+			parsed.DueDate == nil && parsed.CompletedTime == nil && parsed.Metadata == nil
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 5000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestItemMetadataRoundTripProperty(t *testing.T) {
+	property := func(generated metadataItem) bool {
+		item := TodoItem(generated)
+		parsed, ok := parseItemLine(strings.TrimSpace(formatItemLine(item)))
+		return ok && sameItem(parsed, item)
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 5000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTodoFileRoundTripProperty(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "list.md")
+
+	property := func(generated []metadataItem) bool {
+		list := &TodoList{}
+		for i, g := range generated {
+			item := TodoItem(g)
+			item.ID = i + 1
+			list.Items = append(list.Items, item)
+		}
+		if err := writeTodoFileAt(path, "Todo List for property", list); err != nil {
+			return false
+		}
+		parsed, err := parseTodoFileAt(path)
+		if err != nil || len(parsed.Items) != len(list.Items) {
+			return false
+		}
+		for i := range list.Items {
+			if parsed.Items[i].ID != list.Items[i].ID || !sameItem(parsed.Items[i], list.Items[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFormatItemLine(t *testing.T) {
+	due := time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local)
+	completed := time.Date(2024, 6, 27, 16, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		item TodoItem
+		want string
+	}{
+		{"plain", TodoItem{Text: "Ship it"}, "- [ ] Ship it"},
+		{"due", TodoItem{Text: "Ship it", DueDate: &due}, "- [ ] Ship it <!-- due: 2024-07-01 -->"},
+		{"completed", TodoItem{Text: "Ship it", Completed: true, CompletedTime: &completed}, "- [x] Ship it <!-- completed: 2024-06-27 16:30 -->"},
+		{"extra metadata", TodoItem{Text: "Ship it", Metadata: map[string]string{"owner": "sam; ops"}}, `- [ ] Ship it <!-- owner: "sam; ops" -->`},
+		{"timestamp-like text", TodoItem{Text: "Ship it (completed: 2024-01-01 10:00)", Completed: true}, `- [x] Ship it (completed: 2024-01-01 10:00) <!-- text: "Ship it (completed: 2024-01-01 10:00)" -->`},
+		{"trailing parentheses", TodoItem{Text: "Call (555) 123"}, "- [ ] Call (555) 123"},
+		{"comment in text", TodoItem{Text: "a <!-- due: 2024-07-01 -->"}, `- [ ] a <!-- due: 2024-07-01 --> <!-- text: "a \x3c!-- due: 2024-07-01 --\x3e" -->`},
+		{"newline", TodoItem{Text: "a\nb"}, `- [ ] a b <!-- text: "a\nb" -->`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatItemLine(tt.item); got != tt.want {
+				t.Errorf("formatItemLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompletedTimeIsLocal(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("No time zone data: %v", err)
+	}
+	local := time.Local
+	time.Local = newYork
+	t.Cleanup(func() { time.Local = local })
+
+	// Completion times are written in local time and read back the same,
+	// in both the comment and the legacy suffix
+	want := time.Date(2026, 10, 18, 1, 14, 0, 0, newYork)
+	for _, line := range []string{"- [x] Ship it <!-- completed: 2026-10-18 01:14 -->", "- [x] Ship it (completed: 2026-10-18 01:14)"} {
+		item, ok := parseItemLine(line)
+		if !ok || item.CompletedTime == nil || !item.CompletedTime.Equal(want) {
+			t.Errorf("parseItemLine(%q) completed at %v, want %v", line, item.CompletedTime, want)
+		}
+	}
+	item := TodoItem{Text: "Ship it", Completed: true, CompletedTime: &want}
+	if parsed, _ := parseItemLine(formatItemLine(item)); parsed.CompletedTime == nil || !parsed.CompletedTime.Equal(want) {
+		t.Errorf("Expected the completion time to round-trip, got %v", parsed.CompletedTime)
+	}
+}
+
+func TestParseItemLineMetadata(t *testing.T) {
+	item, ok := parseItemLine(`- [x] Ship it <!-- completed: 2024-06-27 16:30; owner: "sam; ops"; due: someday -->`)
+	if !ok {
+		t.Fatal("parseItemLine rejected a valid line")
+	}
+	if item.Text != "Ship it" || item.CompletedTime == nil {
+		t.Errorf("Unexpected item: %+v", item)
+	}
+
+	// Unknown keys and values that don't parse are kept for writing back
+	want := map[string]string{"owner": "sam; ops", "due": "someday"}
+	if !reflect.DeepEqual(item.Metadata, want) {
+		t.Errorf("Metadata = %v, want %v", item.Metadata, want)
+	}
+
+	// A comment that isn't metadata is part of the text
+	for _, line := range []string{"- [ ] a <!-- -->", "- [ ] a <!-- just a note -->", `- [ ] a <!-- due: "unterminated -->`} {
+		item, ok := parseItemLine(line)
+		if !ok || item.Text != strings.TrimPrefix(line, "- [ ] ") || item.Metadata != nil {
+			t.Errorf("parseItemLine(%q) = %+v", line, item)
+		}
+	}
+}
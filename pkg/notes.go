@@ -0,0 +1,37 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AddNote appends text to an item's notes, a line of notes for each line
+// of text. Blank lines are dropped, and a line that would read back as an
+// item or a section heading is refused.
+func (s *Store) AddNote(listName string, itemID int, text string) error {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if _, ok := parseItemLine(line); ok {
+			return fmt.Errorf("a note can't look like an item: %q", line)
+		}
+		if sectionRegex.MatchString(line) {
+			return fmt.Errorf("a note can't look like a section heading: %q", line)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("note can't be empty")
+	}
+
+	item, err := s.item(listName, itemID)
+	if err != nil {
+		return err
+	}
+	item.Notes = append(item.Notes, lines...)
+	s.MarkDirty(listName)
+	return nil
+}
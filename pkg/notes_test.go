@@ -0,0 +1,83 @@
+package pkg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNotes(t *testing.T) {
+	content := `# Todo List for app
+
+- [ ] Ship the release
+  Blocked on the API review
+  - [ ] Write the changelog
+      Draft is in docs/
+  Ask Sam on Monday
+Not indented, so not a note
+
+## Backend
+
+- [x] Rate limiting
+    Per token, not per IP
+`
+	list, err := parseTodoList(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("parseTodoList failed: %v", err)
+	}
+	want := [][]string{
+		{"Blocked on the API review", "Ask Sam on Monday"},
+		{"Draft is in docs/"},
+		{"Per token, not per IP"},
+	}
+	for i, notes := range want {
+		if !reflect.DeepEqual(list.Items[i].Notes, notes) {
+			t.Errorf("Item %d: expected notes %q, got %q", i+1, notes, list.Items[i].Notes)
+		}
+	}
+	if list.Parent(2) != 1 {
+		t.Errorf("Expected notes not to change nesting, got parent %d", list.Parent(2))
+	}
+
+	// Notes are written under their item, before its subtasks
+	formatted, _ := formatTodoFile("Todo List for app", list)
+	for _, expected := range []string{
+		"- [ ] Ship the release\n  Blocked on the API review\n  Ask Sam on Monday\n  - [ ] Write the changelog\n    Draft is in docs/\n",
+		"- [x] Rate limiting\n  Per token, not per IP\n",
+	} {
+		if !strings.Contains(string(formatted), expected) {
+			t.Errorf("Expected %q in:\n%s", expected, formatted)
+		}
+	}
+	reparsed, _ := parseTodoList(strings.NewReader(string(formatted)))
+	for i := range list.Items {
+		if !reflect.DeepEqual(reparsed.Items[i].Notes, list.Items[i].Notes) {
+			t.Errorf("Item %d: notes changed when written: %q", i+1, reparsed.Items[i].Notes)
+		}
+	}
+}
+
+func TestAddNote(t *testing.T) {
+	setupTestDir(t)
+	store := NewStore()
+	store.AddItem("app", "Ship the release")
+
+	for _, text := range []string{"", "  \n ", "- [ ] Not a note", "## Not a note either"} {
+		if err := store.AddNote("app", 1, text); err == nil {
+			t.Errorf("Expected %q to be refused", text)
+		}
+	}
+	if err := store.AddNote("app", 2, "No such item"); err == nil {
+		t.Error("Expected an invalid item ID to fail")
+	}
+	if err := store.AddNote("app", 1, "Blocked on review\n\n  see the PR  "); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	store.AddNote("app", 1, "Ask Sam")
+	store.Flush()
+
+	list, _ := ParseTodoFile("app")
+	if want := []string{"Blocked on review", "see the PR", "Ask Sam"}; !reflect.DeepEqual(list.Items[0].Notes, want) {
+		t.Errorf("Expected notes %q, got %q", want, list.Items[0].Notes)
+	}
+}
@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// windowsToast shows a balloon notification from the tray, reading the title
+// and message from the environment to avoid quoting them into the script
+const windowsToast = `Add-Type -AssemblyName System.Windows.Forms
+$icon = New-Object System.Windows.Forms.NotifyIcon
+$icon.Icon = [System.Drawing.SystemIcons]::Information
+$icon.Visible = $true
+$icon.ShowBalloonTip(10000, $env:TODO_NOTIFY_SUBJECT, $env:TODO_NOTIFY_MESSAGE, 'Info')
+Start-Sleep -Seconds 10
+$icon.Dispose()`
+
+// Desktop shows a notification on the user's desktop
+func Desktop(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.Command("notify-send", "--app-name=todo", title, message)
+	case "darwin":
+		cmd = exec.Command("osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, message)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToast)
+		cmd.Env = append(os.Environ(), "TODO_NOTIFY_SUBJECT="+title, "TODO_NOTIFY_MESSAGE="+message)
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to show notification: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+// Package notify evaluates the notification rules in .todo/config.yaml and
+// delivers the resulting notifications.
+package notify
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scttymn/todo-cli/pkg"
+)
+
+// Rule conditions
+const (
+	WhenComplete = "complete"
+	WhenOverdue  = "overdue"
+	WhenProgress = "progress"
+)
+
+// ToReviewers addresses a rule's notifications to the reviewers of the list
+const ToReviewers = "reviewers"
+
+var progressRegex = regexp.MustCompile(`^progress\s*>=\s*(\d+)%?$`)
+
+// condition is a parsed NotifyRule.When
+type condition struct {
+	kind      string
+	threshold int
+}
+
+func parseCondition(when string) (condition, error) {
+	when = strings.ToLower(strings.TrimSpace(when))
+	switch when {
+	case WhenComplete:
+		return condition{kind: WhenProgress, threshold: 100}, nil
+	case WhenOverdue:
+		return condition{kind: WhenOverdue}, nil
+	}
+
+	if match := progressRegex.FindStringSubmatch(when); match != nil {
+		threshold, _ := strconv.Atoi(match[1])
+		if threshold < 1 || threshold > 100 {
+			return condition{}, fmt.Errorf("progress threshold must be between 1 and 100, got %d", threshold)
+		}
+		return condition{kind: WhenProgress, threshold: threshold}, nil
+	}
+
+	return condition{}, fmt.Errorf("unknown condition %q (expected %s, %s or \"progress >= N\")", when, WhenComplete, WhenOverdue)
+}
+
+// Notification is a message for one channel, produced by a rule
+type Notification struct {
+	Channel string
+	List    string
+	Subject string
+	Message string
+	// Reviewers are who the notification is addressed to, for rules with
+	// "to: reviewers"
+	Reviewers []string
+
+	// keys identify the events behind the notification, so each is only
+	// reported once
+	keys []string
+}
+
+// Evaluation is the outcome of checking every rule against the lists
+type Evaluation struct {
+	Notifications []Notification
+
+	// cleared are previously notified events whose condition no longer
+	// holds, so they may fire again
+	cleared []string
+}
+
+// Evaluate checks the configured rules and returns the notifications that
+// are due, without sending anything. Events that were already notified are
+// not repeated until their condition stops holding and holds again.
+func Evaluate(cfg *pkg.Config, now time.Time) (*Evaluation, error) {
+	fired, err := firedKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	eval := &Evaluation{}
+	for i, rule := range cfg.Notify.Rules {
+		cond, err := parseCondition(rule.When)
+		if err != nil {
+			return nil, fmt.Errorf("notify rule %d: %w", i+1, err)
+		}
+		if _, ok := cfg.Notify.Channels[rule.Notify]; !ok {
+			return nil, fmt.Errorf("notify rule %d: unknown channel %q", i+1, rule.Notify)
+		}
+		if rule.To != "" && rule.To != ToReviewers {
+			return nil, fmt.Errorf("notify rule %d: unknown recipients %q (expected %s)", i+1, rule.To, ToReviewers)
+		}
+
+		lists, err := ruleLists(rule)
+		if err != nil {
+			return nil, err
+		}
+
+		prefix := ruleID(rule) + "|"
+		active := make(map[string]bool)
+		for _, list := range lists {
+			todoList, err := pkg.ParseTodoFile(list)
+			if err != nil {
+				return nil, err
+			}
+			if rule.To == ToReviewers && len(todoList.Meta.Reviewers) == 0 {
+				continue
+			}
+
+			var n *Notification
+			if cond.kind == WhenOverdue {
+				n = overdueNotification(prefix+list+"|", list, todoList, now, active, fired)
+			} else {
+				n = progressNotification(prefix+list, list, todoList, cond.threshold, pkg.WeightedProgress(cfg), active, fired)
+			}
+			if n != nil {
+				n.Channel = rule.Notify
+				if rule.To == ToReviewers {
+					n.Reviewers = todoList.Meta.Reviewers
+				}
+				eval.Notifications = append(eval.Notifications, *n)
+			}
+		}
+
+		for key := range fired {
+			if strings.HasPrefix(key, prefix) && !active[key] {
+				eval.cleared = append(eval.cleared, key)
+			}
+		}
+	}
+
+	return eval, nil
+}
+
+// Send delivers the notifications and records them in the journal. A
+// notification that fails to send is not recorded, so the next evaluation
+// tries it again.
+func (e *Evaluation) Send(cfg *pkg.Config) (int, []error) {
+	var errs []error
+
+	var entries []pkg.JournalEntry
+	for _, key := range e.cleared {
+		entries = append(entries, pkg.JournalEntry{Kind: pkg.JournalNotifyClear, Detail: key})
+	}
+	if err := pkg.AppendJournal(entries...); err != nil {
+		return 0, []error{err}
+	}
+
+	sent := 0
+	for _, n := range e.Notifications {
+		err := cfg.ChannelCommandError(n.Channel)
+		if err == nil {
+			err = send(n.Channel, cfg.Notify.Channels[n.Channel], n)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to notify %s: %w", n.Channel, err))
+			continue
+		}
+
+		entries = nil
+		for _, key := range n.keys {
+			entries = append(entries, pkg.JournalEntry{Kind: pkg.JournalNotified, List: n.List, Detail: key})
+		}
+		if err := pkg.AppendJournal(entries...); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, errs
+}
+
+func progressNotification(key, list string, todoList *pkg.TodoList, threshold int, weighted bool, active, fired map[string]bool) *Notification {
+	progress := todoList.Progress()
+	if progress.Total == 0 {
+		return nil
+	}
+	percentage := progress.Percent(weighted)
+	if percentage < threshold {
+		return nil
+	}
+
+	active[key] = true
+	if fired[key] {
+		return nil
+	}
+
+	subject := fmt.Sprintf("%s reached %d%%", list, threshold)
+	if threshold == 100 {
+		subject = fmt.Sprintf("%s is complete", list)
+	}
+	return &Notification{
+		List:    list,
+		Subject: subject,
+		Message: fmt.Sprintf("%s: %d/%d completed (%d%%)", list, progress.Completed, progress.Total, percentage),
+		keys:    []string{key},
+	}
+}
+
+func overdueNotification(prefix, list string, todoList *pkg.TodoList, now time.Time, active, fired map[string]bool) *Notification {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var keys, lines []string
+	for _, item := range todoList.Items {
+		if item.Completed || item.DueDate == nil || !item.DueDate.Before(today) {
+			continue
+		}
+
+		due := item.DueDate.Format(pkg.DueDateFormat)
+		key := prefix + item.Text + "|" + due
+		active[key] = true
+		if fired[key] {
+			continue
+		}
+		keys = append(keys, key)
+		lines = append(lines, fmt.Sprintf("- %s (due %s)", item.Text, due))
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	subject := fmt.Sprintf("1 overdue item in %s", list)
+	if len(keys) > 1 {
+		subject = fmt.Sprintf("%d overdue items in %s", len(keys), list)
+	}
+	return &Notification{
+		List:    list,
+		Subject: subject,
+		Message: strings.Join(lines, "\n"),
+		keys:    keys,
+	}
+}
+
+// ruleLists returns the lists a rule watches
+func ruleLists(rule pkg.NotifyRule) ([]string, error) {
+	if rule.List == "" || rule.List == "*" {
+		return pkg.GetAllLists()
+	}
+	if !pkg.ListExists(rule.List) {
+		return nil, nil
+	}
+	return []string{rule.List}, nil
+}
+
+// ruleID identifies a rule by its contents, so reordering rules doesn't
+// repeat notifications. The recipients go with the channel so the IDs of
+// rules that only differ in them aren't prefixes of each other.
+func ruleID(rule pkg.NotifyRule) string {
+	notify := rule.Notify
+	if rule.To != "" {
+		notify += ">" + rule.To
+	}
+	return strings.Join([]string{rule.List, rule.When, notify}, "|")
+}
+
+// firedKeys returns the events that have been notified and not cleared since
+func firedKeys() (map[string]bool, error) {
+	entries, err := pkg.ReadJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	fired := make(map[string]bool)
+	for _, entry := range entries {
+		switch entry.Kind {
+		case pkg.JournalNotified:
+			fired[entry.Detail] = true
+		case pkg.JournalNotifyClear:
+			delete(fired, entry.Detail)
+		}
+	}
+	return fired, nil
+}
@@ -0,0 +1,204 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/scttymn/todo-cli/pkg"
+)
+
+func setupTestDir(t *testing.T) {
+	testDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(testDir); err != nil {
+		t.Fatalf("Failed to change to test directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(originalDir) })
+
+	pkg.EnsureTodoDirectory()
+}
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		when    string
+		want    condition
+		wantErr bool
+	}{
+		{"complete", condition{kind: WhenProgress, threshold: 100}, false},
+		{"Overdue", condition{kind: WhenOverdue}, false},
+		{"progress >= 75", condition{kind: WhenProgress, threshold: 75}, false},
+		{"progress>=50%", condition{kind: WhenProgress, threshold: 50}, false},
+		{"progress >= 0", condition{}, true},
+		{"sometimes", condition{}, true},
+	}
+
+	for _, test := range tests {
+		got, err := parseCondition(test.when)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("parseCondition(%q) = %+v, %v; want %+v, error %v", test.when, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestEvaluateAndSend(t *testing.T) {
+	setupTestDir(t)
+
+	var received []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body)
+	}))
+	defer server.Close()
+
+	cfg := pkg.DefaultConfig()
+	cfg.Notify = pkg.NotifyConfig{
+		Channels: map[string]pkg.NotifyChannel{"hook": {Type: ChannelWebhook, URL: server.URL}},
+		Rules: []pkg.NotifyRule{
+			{List: "release", When: "complete", Notify: "hook"},
+			{List: "ops", When: "overdue", Notify: "hook"},
+		},
+	}
+
+	os.WriteFile(pkg.GetTodoFilePath("release"), []byte("# Todo List for release\n\n- [x] Tag\n- [ ] Announce\n"), 0644)
+	os.WriteFile(pkg.GetTodoFilePath("ops"), []byte("# Todo List for ops\n\n- [ ] Rotate keys (due: 2024-07-01)\n- [ ] Renew certs (due: 2024-07-10)\n"), 0644)
+	now := time.Date(2024, 7, 5, 9, 0, 0, 0, time.Local)
+
+	eval, err := Evaluate(cfg, now)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if len(eval.Notifications) != 1 || eval.Notifications[0].Subject != "1 overdue item in ops" {
+		t.Fatalf("Unexpected notifications: %+v", eval.Notifications)
+	}
+
+	if sent, errs := eval.Send(cfg); sent != 1 || len(errs) != 0 {
+		t.Fatalf("Send = %d, %v", sent, errs)
+	}
+	if len(received) != 1 || received[0]["list"] != "ops" {
+		t.Fatalf("Unexpected webhook calls: %+v", received)
+	}
+
+	// Nothing is repeated, but newly met conditions fire
+	pkg.CheckTodoItem("release", 2)
+	eval, _ = Evaluate(cfg, now)
+	if len(eval.Notifications) != 1 || eval.Notifications[0].Subject != "release is complete" {
+		t.Fatalf("Unexpected notifications: %+v", eval.Notifications)
+	}
+	eval.Send(cfg)
+
+	eval, _ = Evaluate(cfg, now)
+	if len(eval.Notifications) != 0 {
+		t.Fatalf("Expected no repeated notifications, got %+v", eval.Notifications)
+	}
+
+	// Reopening and finishing the list again notifies again
+	pkg.UncheckTodoItem("release", 2)
+	eval, _ = Evaluate(cfg, now)
+	eval.Send(cfg)
+	pkg.CheckTodoItem("release", 2)
+	eval, _ = Evaluate(cfg, now)
+	if len(eval.Notifications) != 1 {
+		t.Errorf("Expected the completion to fire again, got %+v", eval.Notifications)
+	}
+}
+
+func TestNotifyReviewers(t *testing.T) {
+	setupTestDir(t)
+
+	var received []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body)
+	}))
+	defer server.Close()
+
+	cfg := pkg.DefaultConfig()
+	cfg.Notify = pkg.NotifyConfig{
+		Channels: map[string]pkg.NotifyChannel{"hook": {Type: ChannelWebhook, URL: server.URL}},
+		Rules: []pkg.NotifyRule{
+			{List: "*", When: "complete", Notify: "hook", To: ToReviewers},
+			{List: "*", When: "complete", Notify: "hook"},
+		},
+	}
+	os.WriteFile(pkg.GetTodoFilePath("release"), []byte("---\nowner: sam\nreviewers:\n    - alex@example.com\n    - kim\n---\n# Todo List for release\n\n- [x] Tag\n"), 0644)
+	os.WriteFile(pkg.GetTodoFilePath("docs"), []byte("# Todo List for docs\n\n- [x] Write\n"), 0644)
+
+	eval, err := Evaluate(cfg, time.Now())
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	// The reviewers rule skips docs, which has no reviewers
+	if len(eval.Notifications) != 3 {
+		t.Fatalf("Expected three notifications, got %+v", eval.Notifications)
+	}
+	first := eval.Notifications[0]
+	if first.List != "release" || len(first.Reviewers) != 2 || first.Reviewers[0] != "alex@example.com" {
+		t.Errorf("Expected the release reviewers, got %+v", first)
+	}
+	if eval.Send(cfg); len(received) != 3 || received[0]["reviewers"] == nil || received[1]["reviewers"] != nil {
+		t.Fatalf("Unexpected webhook calls: %+v", received)
+	}
+
+	// Both rules keep their own record of what was sent
+	eval, _ = Evaluate(cfg, time.Now())
+	if len(eval.Notifications) != 0 || len(eval.cleared) != 0 {
+		t.Errorf("Expected nothing to repeat or clear, got %+v", eval)
+	}
+
+	cfg.Notify.Rules[0].To = "owner"
+	if _, err := Evaluate(cfg, time.Now()); err == nil {
+		t.Error("Expected an error for unknown recipients")
+	}
+}
+
+func TestReviewerAddresses(t *testing.T) {
+	if got := reviewerAddresses([]string{"alex@example.com", "kim", "lee@example.com"}); got != "alex@example.com,lee@example.com" {
+		t.Errorf("reviewerAddresses = %q", got)
+	}
+	if got := reviewerAddresses([]string{"kim"}); got != "" {
+		t.Errorf("Expected no addresses, got %q", got)
+	}
+}
+
+func TestSendFailureIsRetried(t *testing.T) {
+	setupTestDir(t)
+
+	cfg := pkg.DefaultConfig()
+	cfg.Notify = pkg.NotifyConfig{
+		Channels: map[string]pkg.NotifyChannel{"broken": {Type: ChannelCommand, Command: "exit 1"}},
+		Rules:    []pkg.NotifyRule{{List: "*", When: "progress >= 50", Notify: "broken"}},
+	}
+	os.WriteFile(pkg.GetTodoFilePath("main"), []byte("# Todo List for main\n\n- [x] Done\n"), 0644)
+
+	eval, err := Evaluate(cfg, time.Now())
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if sent, errs := eval.Send(cfg); sent != 0 || len(errs) != 1 {
+		t.Fatalf("Send = %d, %v; want a failure", sent, errs)
+	}
+
+	eval, _ = Evaluate(cfg, time.Now())
+	if len(eval.Notifications) != 1 {
+		t.Errorf("A failed notification should be retried, got %+v", eval.Notifications)
+	}
+}
+
+func TestEvaluateUnknownChannel(t *testing.T) {
+	setupTestDir(t)
+
+	cfg := pkg.DefaultConfig()
+	cfg.Notify.Rules = []pkg.NotifyRule{{List: "main", When: "complete", Notify: "nowhere"}}
+	if _, err := Evaluate(cfg, time.Now()); err == nil {
+		t.Error("Evaluate should reject a rule with an unknown channel")
+	}
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/scttymn/todo-cli/pkg"
+)
+
+// Channel types
+const (
+	ChannelSlack   = "slack"
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+	ChannelCommand = "command"
+)
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// send delivers a notification through the named channel
+func send(name string, channel pkg.NotifyChannel, n Notification) error {
+	switch channel.Type {
+	case ChannelSlack:
+		url, err := channelSecret(name, channel.URL)
+		if err != nil {
+			return err
+		}
+		text := fmt.Sprintf("*%s*\n%s", n.Subject, n.Message)
+		if len(n.Reviewers) > 0 {
+			text += "\nReviewers: " + strings.Join(n.Reviewers, ", ")
+		}
+		return postJSON(url, map[string]string{"text": text})
+	case ChannelWebhook:
+		url, err := channelSecret(name, channel.URL)
+		if err != nil {
+			return err
+		}
+		body := map[string]interface{}{"list": n.List, "subject": n.Subject, "message": n.Message}
+		if len(n.Reviewers) > 0 {
+			body["reviewers"] = n.Reviewers
+		}
+		return postJSON(url, body)
+	case ChannelEmail:
+		return sendEmail(name, channel, n)
+	case ChannelCommand:
+		return runCommand(channel.Command, n)
+	}
+	return fmt.Errorf("unknown channel type %q (expected %s, %s, %s or %s)", channel.Type, ChannelSlack, ChannelWebhook, ChannelEmail, ChannelCommand)
+}
+
+// channelSecret returns a value from the channel config, or the credential
+// stored for the channel when it was left out of the config
+func channelSecret(name, value string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+	return pkg.GetCredential(name)
+}
+
+func postJSON(url string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func sendEmail(name string, channel pkg.NotifyChannel, n Notification) error {
+	to := channel.To
+	if addresses := reviewerAddresses(n.Reviewers); addresses != "" {
+		to = addresses
+	}
+	if channel.SMTP == "" || to == "" {
+		return fmt.Errorf("email channels need smtp and to settings")
+	}
+
+	var auth smtp.Auth
+	if channel.Username != "" {
+		password, err := pkg.GetCredential(name)
+		if err != nil {
+			return err
+		}
+		host, _, err := net.SplitHostPort(channel.SMTP)
+		if err != nil {
+			return fmt.Errorf("invalid smtp address %q: %w", channel.SMTP, err)
+		}
+		auth = smtp.PlainAuth("", channel.Username, password, host)
+	}
+
+	from := channel.From
+	if from == "" {
+		from = channel.Username
+	}
+	if from == "" {
+		from = "todo@localhost"
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: [todo] %s\r\n\r\n%s\r\n", from, to, n.Subject, strings.ReplaceAll(n.Message, "\n", "\r\n"))
+	return smtp.SendMail(channel.SMTP, auth, from, strings.Split(to, ","), []byte(msg))
+}
+
+// reviewerAddresses returns the reviewers given as email addresses, comma
+// separated. Reviewers given by name can't be emailed, so the channel's own
+// recipients are used when none are addresses.
+func reviewerAddresses(reviewers []string) string {
+	var addresses []string
+	for _, reviewer := range reviewers {
+		if strings.Contains(reviewer, "@") {
+			addresses = append(addresses, reviewer)
+		}
+	}
+	return strings.Join(addresses, ",")
+}
+
+func runCommand(command string, n Notification) error {
+	if command == "" {
+		return fmt.Errorf("command channels need a command setting")
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = strings.NewReader(n.Message + "\n")
+	cmd.Env = append(os.Environ(), "TODO_NOTIFY_LIST="+n.List, "TODO_NOTIFY_SUBJECT="+n.Subject, "TODO_NOTIFY_REVIEWERS="+strings.Join(n.Reviewers, ","))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Item numbering schemes for the display.numbering setting
+const (
+	// NumberingPosition numbers items by their position in the list
+	NumberingPosition = "position"
+	// NumberingSection restarts numbering in each "## " section, shown as
+	// <section>.<item>
+	NumberingSection = "section"
+	// NumberingID shows each item's stable short ID
+	NumberingID = "id"
+)
+
+// shortIDBytes is the length of new short IDs, four hex digits
+const shortIDBytes = 2
+
+var sectionRefRegex = regexp.MustCompile(`^(\d+)\.(\d+)$`)
+
+var rangeRefRegex = regexp.MustCompile(`^(\d+)-(\d+)$`)
+
+// ItemLabels returns the label shown before each item of a list. Items
+// before the first section are numbered by position under section
+// numbering, so those labels stay valid item numbers.
+func ItemLabels(todoList *TodoList, display DisplayConfig) []string {
+	labels := make([]string, len(todoList.Items))
+	sectionIndex, sectionItem, largest := 0, 0, len(todoList.Items)
+
+	if display.Numbering == NumberingSection {
+		largest = 0
+		section := ""
+		for _, item := range todoList.Items {
+			if item.Section != section {
+				section, sectionItem = item.Section, 0
+			}
+			sectionItem++
+			if sectionItem > largest {
+				largest = sectionItem
+			}
+		}
+	}
+
+	pad := func(n int) string {
+		if display.PadNumbers {
+			return fmt.Sprintf("%0*d", len(strconv.Itoa(largest)), n)
+		}
+		return strconv.Itoa(n)
+	}
+
+	section := ""
+	sectionItem = 0
+	for i, item := range todoList.Items {
+		if item.Section != section {
+			section, sectionItem = item.Section, 0
+			sectionIndex++
+		}
+		sectionItem++
+
+		switch {
+		case display.Numbering == NumberingID && item.ShortID != "":
+			labels[i] = item.ShortID
+		case display.Numbering == NumberingSection && item.Section != "":
+			labels[i] = fmt.Sprintf("%d.%s", sectionIndex, pad(sectionItem))
+		default:
+			labels[i] = pad(item.ID)
+		}
+	}
+	return labels
+}
+
+// ErrInvalidItemNumber is returned for a reference that is neither a
+// number nor the short ID of an item
+var ErrInvalidItemNumber = errors.New("invalid item number")
+
+// ResolveItemRef returns the ID of the item a reference points to. A
+// reference is a position ("3" or "03"), a section number ("2.1") or a
+// short ID.
+func ResolveItemRef(listName, ref string) (int, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return id, nil
+	}
+
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse todo file: %w", err)
+	}
+
+	if match := sectionRefRegex.FindStringSubmatch(ref); match != nil {
+		labels := ItemLabels(todoList, DisplayConfig{Numbering: NumberingSection})
+		want := match[1] + "." + strings.TrimLeft(match[2], "0")
+		for i, label := range labels {
+			if label == want {
+				return todoList.Items[i].ID, nil
+			}
+		}
+		return 0, fmt.Errorf("no item %s in list '%s'", ref, listName)
+	}
+
+	for _, item := range todoList.Items {
+		if item.ShortID != "" && strings.EqualFold(item.ShortID, ref) {
+			return item.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrInvalidItemNumber, ref)
+}
+
+// ExpandItemRefs expands ranges of item numbers such as 2-4 among refs,
+// dropping repeats. Other refs are kept as they are.
+func ExpandItemRefs(refs []string) ([]string, error) {
+	var expanded []string
+	seen := make(map[string]bool)
+	add := func(ref string) {
+		if !seen[ref] {
+			seen[ref] = true
+			expanded = append(expanded, ref)
+		}
+	}
+	for _, ref := range refs {
+		match := rangeRefRegex.FindStringSubmatch(ref)
+		if match == nil {
+			add(ref)
+			continue
+		}
+		from, _ := strconv.Atoi(match[1])
+		to, _ := strconv.Atoi(match[2])
+		if from < 1 || to < from {
+			return nil, fmt.Errorf("invalid item range: %s", ref)
+		}
+		for n := from; n <= to; n++ {
+			add(strconv.Itoa(n))
+		}
+	}
+	return expanded, nil
+}
+
+// AssignShortIDs gives every item without a short ID a new one, reporting
+// whether any were added
+func AssignShortIDs(todoList *TodoList) bool {
+	assigned := false
+	for i := range todoList.Items {
+		if todoList.Items[i].ShortID == "" {
+			todoList.Items[i].ShortID = newShortID(todoList)
+			assigned = true
+		}
+	}
+	return assigned
+}
+
+// newShortID returns a random hex ID not used by any item in the list
+func newShortID(todoList *TodoList) string {
+	used := make(map[string]bool)
+	for _, item := range todoList.Items {
+		used[strings.ToLower(item.ShortID)] = true
+	}
+
+	for size := shortIDBytes; ; size++ {
+		// Try a few times at each length before making IDs longer
+		for attempt := 0; attempt < 8; attempt++ {
+			b := make([]byte, size)
+			rand.Read(b)
+			// An all-digit ID would read as a position
+			id := hex.EncodeToString(b)
+			if !used[id] && strings.Trim(id, "0123456789") != "" {
+				return id
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package pkg
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func sectionedList() *TodoList {
+	list := &TodoList{}
+	for i, section := range []string{"", "", "Backend", "Backend", "Backend", "Frontend"} {
+		list.Items = append(list.Items, TodoItem{ID: i + 1, Text: "item", Section: section})
+	}
+	for i := 0; i < 6; i++ {
+		list.Items = append(list.Items, TodoItem{ID: len(list.Items) + 1, Text: "item", Section: "Docs"})
+	}
+	return list
+}
+
+func TestItemLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		display DisplayConfig
+		want    []string
+	}{
+		{"position", DisplayConfig{}, []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"}},
+		{"padded", DisplayConfig{PadNumbers: true}, []string{"01", "02", "03", "04", "05", "06", "07", "08", "09", "10", "11", "12"}},
+		{"section", DisplayConfig{Numbering: NumberingSection}, []string{"1", "2", "1.1", "1.2", "1.3", "2.1", "3.1", "3.2", "3.3", "3.4", "3.5", "3.6"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ItemLabels(sectionedList(), tt.display); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ItemLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Padding follows the largest section
+	got := ItemLabels(sectionedList(), DisplayConfig{Numbering: NumberingSection, PadNumbers: true})
+	if got[0] != "1" || got[2] != "1.1" || got[6] != "3.1" {
+		t.Errorf("Unexpected padded section labels: %v", got)
+	}
+
+	list := &TodoList{Items: []TodoItem{{ID: 1, ShortID: "a3f0"}, {ID: 2}}}
+	if got := ItemLabels(list, DisplayConfig{Numbering: NumberingID}); !reflect.DeepEqual(got, []string{"a3f0", "2"}) {
+		t.Errorf("Unexpected ID labels: %v", got)
+	}
+}
+
+func TestResolveItemRef(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	os.WriteFile(GetTodoFilePath("work"), []byte(`# Todo List for work
+
+- [ ] Triage
+
+## Backend
+
+- [ ] Migrate <!-- id: b7e2 -->
+- [ ] Index
+
+## Frontend
+
+- [ ] Form
+`), 0644)
+
+	tests := []struct {
+		ref  string
+		want int
+	}{
+		{"1", 1},
+		{"03", 3},
+		{"1.1", 2},
+		{"1.02", 3},
+		{"2.1", 4},
+		{"b7e2", 2},
+		{"B7E2", 2},
+	}
+	for _, tt := range tests {
+		if got, err := ResolveItemRef("work", tt.ref); err != nil || got != tt.want {
+			t.Errorf("ResolveItemRef(%q) = %d, %v, want %d", tt.ref, got, err, tt.want)
+		}
+	}
+
+	for _, ref := range []string{"3.1", "1.3", "ffff", "one"} {
+		if _, err := ResolveItemRef("work", ref); err == nil {
+			t.Errorf("ResolveItemRef(%q) should fail", ref)
+		}
+	}
+}
+
+func TestExpandItemRefs(t *testing.T) {
+	refs, err := ExpandItemRefs([]string{"5", "2-4", "3", "1.2", "a1b2"})
+	if err != nil {
+		t.Fatalf("ExpandItemRefs failed: %v", err)
+	}
+	if got := strings.Join(refs, " "); got != "5 2 3 4 1.2 a1b2" {
+		t.Errorf("ExpandItemRefs = %q", got)
+	}
+	for _, ref := range []string{"4-2", "0-3"} {
+		if _, err := ExpandItemRefs([]string{ref}); err == nil {
+			t.Errorf("Expected an error for range %s", ref)
+		}
+	}
+}
+
+func TestShortIDs(t *testing.T) {
+	setupTestDir(t)
+	SaveConfig(&Config{Display: DisplayConfig{Numbering: NumberingID}})
+	CreateTodoFile("work")
+	AddTodoItem("work", "First")
+
+	todoList, _ := ParseTodoFile("work")
+	id := todoList.Items[0].ShortID
+	if len(id) != 2*shortIDBytes || strings.Trim(id, "0123456789") == "" {
+		t.Fatalf("Unexpected short ID %q", id)
+	}
+
+	// IDs don't change as the list changes
+	todoList.Items = append([]TodoItem{{ID: 1, Text: "Zeroth"}}, todoList.Items...)
+	WriteTodoFile("work", todoList)
+	DisplayTodoList("work")
+
+	todoList, _ = ParseTodoFile("work")
+	if todoList.Items[1].ShortID != id || todoList.Items[0].ShortID == "" || todoList.Items[0].ShortID == id {
+		t.Errorf("Unexpected IDs after adding an item: %+v", todoList.Items)
+	}
+
+	// A full list of four-digit IDs moves on to longer ones
+	full := &TodoList{}
+	for i := 0; i < 1<<16; i++ {
+		full.Items = append(full.Items, TodoItem{ShortID: strings.Repeat("0", 4-len(hexDigits(i))) + hexDigits(i)})
+	}
+	if id := newShortID(full); len(id) != 6 {
+		t.Errorf("Expected a longer ID when all short ones are taken, got %q", id)
+	}
+}
+
+func hexDigits(n int) string {
+	const digits = "0123456789abcdef"
+	if n == 0 {
+		return "0"
+	}
+	var s string
+	for ; n > 0; n /= 16 {
+		s = string(digits[n%16]) + s
+	}
+	return s
+}
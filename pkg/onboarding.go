@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// SampleListName is the list offered to new users to show what lists can
+// hold
+const SampleListName = "getting-started"
+
+// IsFirstRun reports whether todo has never been used in the current
+// directory
+func IsFirstRun() bool {
+	_, err := os.Stat(".todo")
+	return os.IsNotExist(err)
+}
+
+// sampleItem is an item of the sample list. Due dates are written as they
+// would be typed to --due.
+type sampleItem struct {
+	section  string
+	text     string
+	done     bool
+	priority int
+	due      string
+	parent   string
+}
+
+var sampleItems = []sampleItem{
+	{section: "Basics", text: "Open this list with 'todo list getting-started'", done: true},
+	{section: "Basics", text: "Check off an item with 'todo check 2'"},
+	{section: "Basics", text: "Add your own item with 'todo add \"Water the plants\"'"},
+	{section: "Organising", text: "Tag items with #words and contexts with @words, then 'todo search #words' #words @home"},
+	{section: "Organising", text: "Fix the most urgent thing first", priority: 1, due: "today"},
+	{section: "Organising", text: "Plan next week", priority: 3, due: "+7d"},
+	{section: "Organising", text: "Ship the first release", due: "+14d"},
+	{section: "Organising", text: "Write the changelog", parent: "Ship the first release"},
+	{section: "Organising", text: "Tag the release", parent: "Ship the first release"},
+}
+
+// CreateSampleList creates the getting-started list, with items showing
+// sections, tags and contexts, priorities, due dates and subtasks, dated
+// from now
+func CreateSampleList(now time.Time) error {
+	if TodoFileExists(SampleListName) {
+		return fmt.Errorf("list '%s' already exists", SampleListName)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	todoList := &TodoList{}
+	for i, sample := range sampleItems {
+		item := TodoItem{
+			ID:       i + 1,
+			Text:     sample.text,
+			Section:  sample.section,
+			Priority: sample.priority,
+			Metadata: map[string]string{},
+		}
+		if cfg.Insights {
+			item.Metadata[metaAdded] = now.Format(DueDateFormat)
+		}
+		if sample.done {
+			item.Completed = true
+			completed := now
+			item.CompletedTime = &completed
+		}
+		if sample.due != "" {
+			due, err := ParseDueDate(sample.due, now)
+			if err != nil {
+				return err
+			}
+			item.DueDate = &due
+		}
+		if sample.parent != "" {
+			item.Metadata[metaParent] = sample.parent
+			item.Depth = 1
+		}
+		todoList.Items = append(todoList.Items, item)
+	}
+	return WriteTodoFile(SampleListName, todoList)
+}
@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCreateSampleList(t *testing.T) {
+	setupTestDir(t)
+	if !IsFirstRun() {
+		t.Fatal("Expected a fresh directory to be a first run")
+	}
+
+	now := time.Date(2024, 7, 1, 9, 0, 0, 0, time.Local)
+	if err := CreateSampleList(now); err != nil {
+		t.Fatalf("CreateSampleList failed: %v", err)
+	}
+	if IsFirstRun() {
+		t.Error("Expected no first run once a list exists")
+	}
+	if err := CreateSampleList(now); err == nil {
+		t.Error("Expected an error when the sample list exists")
+	}
+
+	todoList, err := ParseTodoFile(SampleListName)
+	if err != nil || len(todoList.Items) != len(sampleItems) {
+		t.Fatalf("Expected the sample items, got %+v, %v", todoList, err)
+	}
+	var tagged, prioritized, due, subtasks, sections int
+	section := ""
+	for _, item := range todoList.Items {
+		if len(ExtractTags(item.Text)) > 0 {
+			tagged++
+		}
+		if item.Priority > 0 {
+			prioritized++
+		}
+		if item.DueDate != nil {
+			due++
+		}
+		if item.Metadata[metaParent] != "" {
+			subtasks++
+		}
+		if item.Section != section {
+			section = item.Section
+			sections++
+		}
+	}
+	if tagged == 0 || prioritized == 0 || due == 0 || subtasks == 0 || sections < 2 {
+		t.Errorf("Expected the sample list to show every feature, got %+v", todoList.Items)
+	}
+	if first := todoList.Items[4]; first.DueDate == nil || !first.DueDate.Equal(day("2024-07-01")) {
+		t.Errorf("Expected the urgent item to be due today, got %+v", first)
+	}
+}
@@ -0,0 +1,132 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Quiet suppresses decorative output: emoji, banners and tips. Commands print
+// the same facts either way, and the first line of their output keeps the
+// format documented in the README.
+var Quiet bool
+
+// Output detail levels for --output-detail
+const (
+	// DetailMinimal prints one line per fact: no decoration, no blank
+	// lines between groups and no wrapping, for screen readers and pipes
+	DetailMinimal = "minimal"
+	// DetailNormal is the usual output
+	DetailNormal = "normal"
+	// DetailRich adds progress bars and item details such as due dates
+	DetailRich = "rich"
+)
+
+// Detail is how much decoration and summary text commands print
+var Detail = DetailNormal
+
+// SetDetail sets the output detail level. Minimal detail implies quiet mode.
+func SetDetail(level string) error {
+	switch level {
+	case DetailMinimal, DetailNormal, DetailRich:
+	default:
+		return fmt.Errorf("unknown output detail '%s' (expected minimal, normal or rich)", level)
+	}
+	Detail = level
+	if level == DetailMinimal {
+		Quiet = true
+	}
+	return nil
+}
+
+// Emoji returns the emoji followed by a space, or nothing in quiet mode
+func Emoji(emoji string) string {
+	if Quiet {
+		return ""
+	}
+	return emoji + " "
+}
+
+// Tip prints a line of advice unless quiet mode is on
+func Tip(format string, args ...interface{}) {
+	if Quiet {
+		return
+	}
+	fmt.Printf(format+"\n", args...)
+}
+
+// Blank prints the empty line that separates groups of output, except at
+// minimal detail
+func Blank() {
+	blank(os.Stdout)
+}
+
+func blank(w io.Writer) {
+	if Detail != DetailMinimal {
+		fmt.Fprintln(w)
+	}
+}
+
+// TimeFormat is the layout FormatTimestamp shows times in, set from
+// display.time_format for one command
+var TimeFormat = completedTimeFormat
+
+// FormatTimestamp formats a time for display
+func FormatTimestamp(t time.Time) string {
+	return t.Format(TimeFormat)
+}
+
+// progressBarWidth is the number of cells in a progress bar
+const progressBarWidth = 20
+
+// ProgressBar draws a percentage as a bar such as [#####...............],
+// in plain ASCII so screen readers and fonts without block characters cope
+func ProgressBar(percent int) string {
+	filled := min(max(percent, 0), 100) * progressBarWidth / 100
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", progressBarWidth-filled) + "]"
+}
+
+// itemNotes returns the priority of an item and, when it is overdue or due
+// soon, the note on when it is due, shown after its text below rich detail,
+// e.g. " (p1, due tomorrow)"
+func itemNotes(item TodoItem, note string) string {
+	var notes []string
+	if item.Priority > 0 {
+		notes = append(notes, FormatPriority(item.Priority))
+	}
+	switch {
+	case note == "overdue":
+		notes = append(notes, note)
+	case note != "":
+		notes = append(notes, "due "+note)
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(notes, ", ") + ")"
+}
+
+// itemDetails returns the due date, priority and estimate of an item shown
+// after its text at rich detail, e.g. " (due 2024-07-01, tomorrow, p1, 30m)",
+// with the note on when it is due of an item overdue or due soon
+func itemDetails(item TodoItem, note string) string {
+	var details []string
+	if item.DueDate != nil {
+		details = append(details, "due "+item.DueDate.Format(DueDateFormat))
+	}
+	if note != "" {
+		details = append(details, note)
+	}
+	if item.Priority > 0 {
+		details = append(details, FormatPriority(item.Priority))
+	}
+	if item.Estimate > 0 {
+		details = append(details, FormatEstimate(item.Estimate))
+	}
+	if len(details) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(details, ", ") + ")"
+}
@@ -0,0 +1,44 @@
+package pkg
+
+import "testing"
+
+func TestEmojiQuiet(t *testing.T) {
+	t.Cleanup(func() { Quiet = false })
+
+	if got := Emoji("✅"); got != "✅ " {
+		t.Errorf("Emoji() = %q, want %q", got, "✅ ")
+	}
+
+	Quiet = true
+	if got := Emoji("✅"); got != "" {
+		t.Errorf("Emoji() in quiet mode = %q, want empty", got)
+	}
+}
+
+func TestSetDetail(t *testing.T) {
+	t.Cleanup(func() { Detail, Quiet = DetailNormal, false })
+
+	if err := SetDetail("verbose"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+	if err := SetDetail(DetailRich); err != nil || Detail != DetailRich || Quiet {
+		t.Errorf("SetDetail(rich) = %v; Detail %q, Quiet %v", err, Detail, Quiet)
+	}
+	if err := SetDetail(DetailMinimal); err != nil || !Quiet {
+		t.Errorf("Expected minimal detail to imply quiet mode, got %v", err)
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	tests := map[int]string{
+		0:   "[....................]",
+		25:  "[#####...............]",
+		100: "[####################]",
+		120: "[####################]",
+	}
+	for percent, want := range tests {
+		if got := ProgressBar(percent); got != want {
+			t.Errorf("ProgressBar(%d) = %q, want %q", percent, got, want)
+		}
+	}
+}
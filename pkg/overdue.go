@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Overdue items are grouped by how late they are, so the ones that slipped
+// long ago stand out from the ones that only just did
+const (
+	OverdueRecent = "1-3 days late"
+	OverdueWeek   = "this week"
+	OverdueOlder  = "older"
+)
+
+// OverdueItem is a pending item past its due date
+type OverdueItem struct {
+	ListItem
+	// DaysLate counts the days since the item was due, at least 1
+	DaysLate int
+}
+
+// OverdueGroup holds the overdue items of one escalation level
+type OverdueGroup struct {
+	Label string
+	Items []OverdueItem
+}
+
+// DaysLate returns how many calendar days before now a due date was, or 0
+// when it is today or later
+func DaysLate(due, now time.Time) int {
+	dueDay := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if !dueDay.Before(today) {
+		return 0
+	}
+	return int(today.Sub(dueDay).Hours() / 24)
+}
+
+// overdueLabel returns the escalation level of an item that many days late
+func overdueLabel(daysLate int) string {
+	switch {
+	case daysLate <= 3:
+		return OverdueRecent
+	case daysLate <= 7:
+		return OverdueWeek
+	default:
+		return OverdueOlder
+	}
+}
+
+// GroupOverdue returns the pending items of an agenda that are past due,
+// grouped from the least to the most late. Groups without items are left
+// out; items keep their agenda order within a group.
+func GroupOverdue(agenda []ListItem, now time.Time) []OverdueGroup {
+	groups := []OverdueGroup{{Label: OverdueRecent}, {Label: OverdueWeek}, {Label: OverdueOlder}}
+	for _, entry := range agenda {
+		if entry.Item.Completed || entry.Item.DueDate == nil {
+			continue
+		}
+		daysLate := DaysLate(*entry.Item.DueDate, now)
+		if daysLate == 0 {
+			continue
+		}
+		for i := range groups {
+			if groups[i].Label == overdueLabel(daysLate) {
+				groups[i].Items = append(groups[i].Items, OverdueItem{ListItem: entry, DaysLate: daysLate})
+			}
+		}
+	}
+
+	var nonEmpty []OverdueGroup
+	for _, group := range groups {
+		if len(group.Items) > 0 {
+			nonEmpty = append(nonEmpty, group)
+		}
+	}
+	return nonEmpty
+}
+
+// OverdueSummary sums up overdue groups in one line, such as
+// "5 overdue items: 2 1-3 days late, 3 older"
+func OverdueSummary(groups []OverdueGroup) string {
+	total := 0
+	var parts []string
+	for _, group := range groups {
+		total += len(group.Items)
+		parts = append(parts, fmt.Sprintf("%d %s", len(group.Items), group.Label))
+	}
+
+	if total == 1 {
+		return "1 overdue item: " + strings.Join(parts, ", ")
+	}
+	return fmt.Sprintf("%d overdue items: %s", total, strings.Join(parts, ", "))
+}
@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDaysLate(t *testing.T) {
+	now := time.Date(2026, 3, 30, 9, 0, 0, 0, time.Local)
+	tests := []struct {
+		due  time.Time
+		want int
+	}{
+		{time.Date(2026, 3, 30, 0, 0, 0, 0, time.Local), 0},
+		{time.Date(2026, 4, 2, 0, 0, 0, 0, time.Local), 0},
+		{time.Date(2026, 3, 29, 0, 0, 0, 0, time.Local), 1},
+		// Across a daylight saving change
+		{time.Date(2026, 3, 20, 0, 0, 0, 0, time.Local), 10},
+	}
+	for _, tt := range tests {
+		if got := DaysLate(tt.due, now); got != tt.want {
+			t.Errorf("DaysLate(%s) = %d, want %d", tt.due.Format(DueDateFormat), got, tt.want)
+		}
+	}
+}
+
+func TestGroupOverdue(t *testing.T) {
+	now := time.Date(2026, 3, 30, 9, 0, 0, 0, time.Local)
+	daysAgo := func(days int) *time.Time {
+		due := time.Date(2026, 3, 30-days, 0, 0, 0, 0, time.Local)
+		return &due
+	}
+	agenda := []ListItem{
+		{List: "ops", Item: TodoItem{ID: 1, Text: "Ancient", DueDate: daysAgo(30)}},
+		{List: "ops", Item: TodoItem{ID: 2, Text: "Done late", DueDate: daysAgo(10), Completed: true}},
+		{List: "web", Item: TodoItem{ID: 1, Text: "Last week", DueDate: daysAgo(7)}},
+		{List: "ops", Item: TodoItem{ID: 3, Text: "Three days", DueDate: daysAgo(3)}},
+		{List: "web", Item: TodoItem{ID: 2, Text: "Yesterday", DueDate: daysAgo(1)}},
+		{List: "web", Item: TodoItem{ID: 3, Text: "Today", DueDate: daysAgo(0)}},
+	}
+
+	groups := GroupOverdue(agenda, now)
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 groups, got %+v", groups)
+	}
+	if groups[0].Label != OverdueRecent || len(groups[0].Items) != 2 || groups[0].Items[0].Item.Text != "Three days" || groups[0].Items[1].DaysLate != 1 {
+		t.Errorf("Unexpected recent group %+v", groups[0])
+	}
+	if groups[1].Label != OverdueWeek || len(groups[1].Items) != 1 || groups[1].Items[0].DaysLate != 7 {
+		t.Errorf("Unexpected week group %+v", groups[1])
+	}
+	if groups[2].Label != OverdueOlder || len(groups[2].Items) != 1 || groups[2].Items[0].Item.Text != "Ancient" {
+		t.Errorf("Unexpected older group %+v", groups[2])
+	}
+
+	if got := OverdueSummary(groups); got != "4 overdue items: 2 1-3 days late, 1 this week, 1 older" {
+		t.Errorf("OverdueSummary = %q", got)
+	}
+	if got := OverdueSummary(groups[2:]); got != "1 overdue item: 1 older" {
+		t.Errorf("OverdueSummary = %q", got)
+	}
+	if groups := GroupOverdue(agenda[5:], now); len(groups) != 0 {
+		t.Errorf("Expected nothing overdue, got %+v", groups)
+	}
+}
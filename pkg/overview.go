@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"sort"
+	"time"
+)
+
+// ListOverview summarises one list for dashboards and scripts
+type ListOverview struct {
+	Name      string `json:"name"`
+	Current   bool   `json:"current"`
+	Total     int    `json:"total"`
+	Pending   int    `json:"pending"`
+	Completed int    `json:"completed"`
+	Overdue   int    `json:"overdue"`
+	// Percent is computed from the item weights unless display.progress
+	// is raw. Weighted is whether weights made a difference to it.
+	Percent         int        `json:"percent"`
+	Weighted        bool       `json:"weighted"`
+	Weight          int        `json:"weight"`
+	CompletedWeight int        `json:"completed_weight"`
+	Tags            []string   `json:"tags"`
+	LastActivity    *time.Time `json:"last_activity,omitempty"`
+}
+
+// GetListOverviews returns an overview of every list. Items are overdue when
+// they are pending and were due before the day of now. Last activity is the
+// later of the list file's modification time and its latest completion.
+func GetListOverviews(now time.Time) ([]ListOverview, error) {
+	lists, err := GetAllLists()
+	if err != nil {
+		return nil, err
+	}
+	currentList, err := GetCurrentList()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	overviews := make([]ListOverview, 0, len(lists))
+	for _, parsed := range ParseLists(lists) {
+		if parsed.Err != nil {
+			return nil, parsed.Err
+		}
+		list, todoList := parsed.Name, parsed.List
+
+		overview := summarizeList(list, todoList, cfg, today)
+		overview.Current = list == currentList
+
+		if modified := ListModTime(list); !modified.IsZero() {
+			overview.LastActivity = laterTime(overview.LastActivity, &modified)
+		}
+
+		overviews = append(overviews, overview)
+	}
+
+	return overviews, nil
+}
+
+// summarizeList counts the items of a list that pass DisplayFilter for its
+// overview
+func summarizeList(name string, todoList *TodoList, cfg *Config, today time.Time) ListOverview {
+	todoList = DisplayFilter.filterList(todoList)
+	overview := ListOverview{Name: name, Total: len(todoList.Items), Tags: []string{}}
+	tags := make(map[string]bool)
+	for _, item := range todoList.Items {
+		if item.Completed {
+			overview.Completed++
+			overview.LastActivity = laterTime(overview.LastActivity, item.CompletedTime)
+		} else {
+			overview.Pending++
+			if item.DueDate != nil && item.DueDate.Before(today) {
+				overview.Overdue++
+			}
+		}
+		for _, tag := range ExtractTags(item.Text) {
+			tags[tag] = true
+		}
+	}
+	progress := todoList.Progress()
+	overview.Weight, overview.CompletedWeight = progress.TotalWeight, progress.CompletedWeight
+	overview.Weighted = progress.HasWeights() && WeightedProgress(cfg)
+	overview.Percent = progress.Percent(WeightedProgress(cfg))
+	for tag := range tags {
+		overview.Tags = append(overview.Tags, tag)
+	}
+	sort.Strings(overview.Tags)
+	return overview
+}
+
+func laterTime(a, b *time.Time) *time.Time {
+	if a == nil || (b != nil && b.After(*a)) {
+		return b
+	}
+	return a
+}
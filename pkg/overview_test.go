@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetListOverviews(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+
+	os.WriteFile(GetTodoFilePath("ops"), []byte(`# Todo List for ops
+
+- [ ] Rotate keys #security (due: 2024-07-01)
+- [ ] Renew certs #security #infra (due: 2024-07-10)
+- [x] Patch hosts #infra (completed: 2024-07-02 10:00)
+`), 0644)
+	os.WriteFile(GetTodoFilePath("main"), []byte("# Todo List for main\n\n"), 0644)
+	SetCurrentList("ops")
+
+	overviews, err := GetListOverviews(time.Date(2024, 7, 5, 12, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("GetListOverviews failed: %v", err)
+	}
+	if len(overviews) != 2 {
+		t.Fatalf("Expected 2 overviews, got %+v", overviews)
+	}
+
+	empty, ops := overviews[0], overviews[1]
+	if empty.Name != "main" || empty.Current || empty.Total != 0 || len(empty.Tags) != 0 {
+		t.Errorf("Unexpected overview for main: %+v", empty)
+	}
+
+	if ops.Name != "ops" || !ops.Current || ops.Total != 3 || ops.Pending != 2 || ops.Completed != 1 || ops.Overdue != 1 || ops.Percent != 33 {
+		t.Errorf("Unexpected overview for ops: %+v", ops)
+	}
+	if !reflect.DeepEqual(ops.Tags, []string{"infra", "security"}) {
+		t.Errorf("Tags = %v, want [infra security]", ops.Tags)
+	}
+	if ops.LastActivity == nil {
+		t.Error("Expected a last activity time")
+	}
+}
@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetListOwner sets who is responsible for a list, or clears it when owner
+// is empty
+func SetListOwner(listName, owner string) error {
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return fmt.Errorf("failed to parse todo file: %w", err)
+	}
+	todoList.Meta.Owner = strings.TrimSpace(owner)
+	return WriteTodoFile(listName, todoList)
+}
+
+// SetListReviewers replaces the reviewers of a list. No reviewers clears
+// them.
+func SetListReviewers(listName string, reviewers []string) error {
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return fmt.Errorf("failed to parse todo file: %w", err)
+	}
+	var cleaned []string
+	for _, reviewer := range reviewers {
+		if reviewer = strings.TrimSpace(reviewer); reviewer != "" {
+			cleaned = append(cleaned, reviewer)
+		}
+	}
+	todoList.Meta.Reviewers = cleaned
+	return WriteTodoFile(listName, todoList)
+}
+
+// IsOwner reports whether owner names the person with the given git
+// identity: their name, their email, or "Name <email>", ignoring case
+func IsOwner(owner, name, email string) bool {
+	owner = strings.TrimSpace(owner)
+	if owner == "" {
+		return false
+	}
+	candidates := []string{name, email}
+	if name != "" && email != "" {
+		candidates = append(candidates, fmt.Sprintf("%s <%s>", name, email))
+	}
+	for _, candidate := range candidates {
+		if candidate != "" && strings.EqualFold(owner, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnedLists returns the names of the lists owned by the current git user
+func OwnedLists() ([]string, error) {
+	names, err := GetAllLists()
+	if err != nil {
+		return nil, err
+	}
+	name, email := GitIdentity()
+	var owned []string
+	for _, parsed := range ParseLists(names) {
+		if parsed.Err != nil {
+			return nil, parsed.Err
+		}
+		if IsOwner(parsed.List.Meta.Owner, name, email) {
+			owned = append(owned, parsed.Name)
+		}
+	}
+	return owned, nil
+}
@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestIsOwner(t *testing.T) {
+	tests := []struct {
+		owner string
+		want  bool
+	}{
+		{"Sam Taylor", true},
+		{"sam taylor", true},
+		{"SAM@example.com", true},
+		{"Sam Taylor <sam@example.com>", true},
+		{"alex", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := IsOwner(test.owner, "Sam Taylor", "sam@example.com"); got != test.want {
+			t.Errorf("IsOwner(%q) = %v, want %v", test.owner, got, test.want)
+		}
+	}
+	if IsOwner("", "", "") {
+		t.Error("Expected nobody to own a list without an owner")
+	}
+}
+
+func TestOwnedLists(t *testing.T) {
+	setupTestDir(t)
+	exec.Command("git", "init", "-q").Run()
+	exec.Command("git", "config", "user.name", "Sam Taylor").Run()
+	exec.Command("git", "config", "user.email", "sam@example.com").Run()
+
+	for _, name := range []string{"api", "docs", "web"} {
+		CreateTodoFile(name)
+	}
+	if err := SetListOwner("api", "sam@example.com"); err != nil {
+		t.Fatalf("SetListOwner failed: %v", err)
+	}
+	SetListOwner("docs", "alex")
+	if err := SetListReviewers("api", []string{" alex ", "", "kim"}); err != nil {
+		t.Fatalf("SetListReviewers failed: %v", err)
+	}
+
+	owned, err := OwnedLists()
+	if err != nil || strings.Join(owned, ",") != "api" {
+		t.Errorf("OwnedLists = %v, %v; want [api]", owned, err)
+	}
+
+	todoList, _ := ParseTodoFile("api")
+	if strings.Join(todoList.Meta.Reviewers, ",") != "alex,kim" {
+		t.Errorf("Expected trimmed reviewers, got %v", todoList.Meta.Reviewers)
+	}
+
+	SetListOwner("api", "")
+	SetListReviewers("api", nil)
+	todoList, _ = ParseTodoFile("api")
+	if !todoList.Meta.IsZero() {
+		t.Errorf("Expected clearing to remove the frontmatter, got %+v", todoList.Meta)
+	}
+}
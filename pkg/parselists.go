@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// ParsedList is the result of parsing one list with ParseLists
+type ParsedList struct {
+	Name string
+	List *TodoList
+	Err  error
+}
+
+// maxParseWorkers bounds how many list files are parsed at once
+var maxParseWorkers = runtime.NumCPU()
+
+// ParseLists parses the named lists concurrently with a bounded pool of
+// workers. The results are in the same order as names, so output built from
+// them is deterministic.
+func ParseLists(names []string) []ParsedList {
+	results, _ := ParseListsContext(context.Background(), names)
+	return results
+}
+
+// ParseListsContext is ParseLists, stopping as soon as ctx is done. Lists
+// not parsed by then are left out of the results, and ctx.Err() is
+// returned.
+func ParseListsContext(ctx context.Context, names []string) ([]ParsedList, error) {
+	results := make([]ParsedList, len(names))
+
+	workers := maxParseWorkers
+	if workers > len(names) {
+		workers = len(names)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				list, err := ParseTodoFile(names[i])
+				results[i] = ParsedList{Name: names[i], List: list, Err: err}
+			}
+		}()
+	}
+
+	parsed := 0
+send:
+	for i := range names {
+		select {
+		case indexes <- i:
+			parsed++
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return results[:parsed], err
+	}
+	return results, nil
+}
@@ -0,0 +1,53 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseListsKeepsOrder(t *testing.T) {
+	setupTestDir(t)
+
+	var names []string
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("list-%02d", i)
+		CreateTodoFile(name)
+		for j := 0; j <= i%5; j++ {
+			AddTodoItem(name, fmt.Sprintf("item %d", j))
+		}
+		names = append(names, name)
+	}
+	names = append(names, "missing")
+
+	results := ParseLists(names)
+	if len(results) != len(names) {
+		t.Fatalf("Expected %d results, got %d", len(names), len(results))
+	}
+	for i, result := range results[:50] {
+		if result.Name != names[i] || result.Err != nil || len(result.List.Items) != i%5+1 {
+			t.Errorf("Result %d: %+v", i, result)
+		}
+	}
+
+	// A missing list parses as empty, just like ParseTodoFile
+	if missing := results[50]; missing.Err != nil || len(missing.List.Items) != 0 {
+		t.Errorf("Unexpected result for missing list: %+v", missing)
+	}
+
+	if results := ParseLists(nil); len(results) != 0 {
+		t.Errorf("Expected no results for no lists, got %+v", results)
+	}
+}
+
+func TestParseListsContextCancelled(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("work")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ParseListsContext(ctx, []string{"work"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package pkg
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+var (
+	pastedHeadingRegex = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+#+)?$`)
+	pastedBulletRegex  = regexp.MustCompile(`^([ \t]*)(?:[-*+]|\d+[.)])\s+(.*)$`)
+	pastedTaskRegex    = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+)
+
+// ParsePastedMarkdown reads the task list items of a markdown fragment, such
+// as one copied from an issue, a pull request or a notes app. Headings, and
+// plain bullets with tasks nested under them, become sections, joined with
+// " / " when they are nested. Tasks nested under other tasks become their
+// subtasks.
+func ParsePastedMarkdown(markdown string) []TodoItem {
+	type level struct {
+		depth int
+		text  string
+		task  bool
+	}
+	var headings, bullets []level
+
+	section := func() string {
+		var path []string
+		for _, heading := range headings {
+			path = append(path, heading.text)
+		}
+		for _, bullet := range bullets {
+			if !bullet.task {
+				path = append(path, bullet.text)
+			}
+		}
+		return strings.Join(path, " / ")
+	}
+
+	var items []TodoItem
+	for _, line := range strings.Split(markdown, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+
+		if match := pastedHeadingRegex.FindStringSubmatch(line); match != nil {
+			depth := len(match[1])
+			for len(headings) > 0 && headings[len(headings)-1].depth >= depth {
+				headings = headings[:len(headings)-1]
+			}
+			headings = append(headings, level{depth: depth, text: match[2]})
+			bullets = nil
+			continue
+		}
+
+		match := pastedBulletRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		depth := len(strings.ReplaceAll(match[1], "\t", "    "))
+		for len(bullets) > 0 && bullets[len(bullets)-1].depth >= depth {
+			bullets = bullets[:len(bullets)-1]
+		}
+
+		task := pastedTaskRegex.FindStringSubmatch(match[2])
+		if task == nil {
+			bullets = append(bullets, level{depth: depth, text: match[2]})
+			continue
+		}
+		if item, ok := parseItemLine("- [" + task[1] + "] " + task[2]); ok {
+			item.Section = section()
+			for i := len(bullets) - 1; i >= 0 && bullets[i].task; i-- {
+				item.Depth++
+			}
+			items = append(items, item)
+		}
+		bullets = append(bullets, level{depth: depth, task: true})
+	}
+	return items
+}
+
+// ParseItemLines reads a plain list of items, one per line, such as a
+// brainstorm or a model's output. Blank lines are skipped, and the bullet,
+// number or checkbox in front of an item is dropped.
+func ParseItemLines(text string) []string {
+	var items []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if match := pastedBulletRegex.FindStringSubmatch(line); match != nil {
+			line = match[2]
+		}
+		if match := pastedTaskRegex.FindStringSubmatch(line); match != nil {
+			line = match[2]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			items = append(items, line)
+		}
+	}
+	return items
+}
+
+// PasteItems adds items parsed from pasted markdown to a list, creating it
+// if needed. Items in a section the list already has join it; items outside
+// any section join the last section, as added items do.
+func PasteItems(listName string, items []TodoItem) error {
+	if err := CreateTodoFile(listName); err != nil {
+		return err
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	store := NewStore()
+	todoList, err := store.Get(listName)
+	if err != nil {
+		return err
+	}
+	lastSection := ""
+	if len(todoList.Items) > 0 {
+		lastSection = todoList.Items[len(todoList.Items)-1].Section
+	}
+	for _, item := range items {
+		if item.Section == "" {
+			item.Section = lastSection
+		}
+		if cfg.Display.Numbering == NumberingID && item.ShortID == "" {
+			item.ShortID = newShortID(todoList)
+		}
+		todoList.Items = append(todoList.Items, item)
+	}
+
+	groupSections(todoList)
+	store.MarkDirty(listName)
+	return store.Flush()
+}
+
+// ReadClipboard returns the text on the system clipboard
+func ReadClipboard() (string, error) {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbpaste"}}
+	case "windows":
+		candidates = [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"}}
+	default:
+		candidates = [][]string{{"wl-paste", "--no-newline"}, {"xclip", "-o", "-selection", "clipboard"}, {"xsel", "--clipboard", "--output"}}
+	}
+
+	for _, candidate := range candidates {
+		if _, err := exec.LookPath(candidate[0]); err != nil {
+			continue
+		}
+		output, err := exec.Command(candidate[0], candidate[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to read the clipboard with %s: %w", candidate[0], err)
+		}
+		return string(output), nil
+	}
+	return "", fmt.Errorf("no clipboard tool found (install wl-clipboard, xclip or xsel, or pipe the markdown in)")
+}
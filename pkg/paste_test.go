@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParsePastedMarkdown(t *testing.T) {
+	markdown := `Notes from the planning call:
+
+- [ ] Draft the announcement (due: 2030-01-02)
+# Release
+## Backend ##
+- [x] Migrate the schema
+  - [ ] Backfill old rows
+* Frontend
+  * [ ] Update the banner
+    1. [X] Pick colours
+- [ ] Tag v2
+### Docs
++ [ ] Changelog
+not a task
+`
+	want := []struct {
+		text      string
+		completed bool
+		section   string
+		depth     int
+	}{
+		{"Draft the announcement", false, "", 0},
+		{"Migrate the schema", true, "Release / Backend", 0},
+		{"Backfill old rows", false, "Release / Backend", 1},
+		{"Update the banner", false, "Release / Backend / Frontend", 0},
+		{"Pick colours", true, "Release / Backend / Frontend", 1},
+		{"Tag v2", false, "Release / Backend", 0},
+		{"Changelog", false, "Release / Backend / Docs", 0},
+	}
+
+	items := ParsePastedMarkdown(markdown)
+	if len(items) != len(want) {
+		t.Fatalf("Expected %d items, got %+v", len(want), items)
+	}
+	for i, item := range items {
+		if item.Text != want[i].text || item.Completed != want[i].completed || item.Section != want[i].section || item.Depth != want[i].depth {
+			t.Errorf("Item %d = %q (completed %v, section %q, depth %d), want %+v", i+1, item.Text, item.Completed, item.Section, item.Depth, want[i])
+		}
+	}
+	if items[0].DueDate == nil || items[0].DueDate.Format(DueDateFormat) != "2030-01-02" {
+		t.Errorf("Expected the due date to be read, got %v", items[0].DueDate)
+	}
+}
+
+func TestPasteItems(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	content := "# Todo List for main\n\n- [ ] Intro\n\n## Backend\n\n- [ ] Existing\n\n## Later\n\n- [ ] Someday\n"
+	os.WriteFile(GetTodoFilePath("main"), []byte(content), 0644)
+
+	if err := PasteItems("main", ParsePastedMarkdown("- [ ] Loose\n## Backend\n- [ ] Pasted\n## New\n- [x] Fresh\n")); err != nil {
+		t.Fatalf("PasteItems failed: %v", err)
+	}
+
+	todoList, _ := ParseTodoFile("main")
+	var got []string
+	for i, item := range todoList.Items {
+		if item.ID != i+1 {
+			t.Errorf("Expected items to be renumbered, got ID %d at %d", item.ID, i+1)
+		}
+		got = append(got, item.Section+": "+item.Text)
+	}
+	want := []string{": Intro", "Backend: Existing", "Backend: Pasted", "Later: Someday", "Later: Loose", "New: Fresh"}
+	if len(got) != len(want) {
+		t.Fatalf("Items = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Items = %q, want %q", got, want)
+			break
+		}
+	}
+}
+
+func TestParseItemLines(t *testing.T) {
+	text := "Buy milk\n\n- Call Sam\n  * Book flights  \n3. Renew passport\n- [x] Pay rent\n-\n"
+	want := []string{"Buy milk", "Call Sam", "Book flights", "Renew passport", "Pay rent", "-"}
+	if got := ParseItemLines(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := ParseItemLines("\n  \n"); got != nil {
+		t.Errorf("Expected no items from blank lines, got %q", got)
+	}
+}
@@ -0,0 +1,155 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// scpURLRegex matches scp-like git URLs such as git@github.com:owner/repo.git
+var scpURLRegex = regexp.MustCompile(`^[\w.-]+@[\w.-]+:`)
+
+// isGitURL reports whether a peek source names a remote repository rather
+// than a local path
+func isGitURL(source string) bool {
+	return strings.Contains(source, "://") || scpURLRegex.MatchString(source)
+}
+
+// PeekLists reads the lists committed to another repository's .todo
+// directory, leaving the current directory's lists alone. source is the
+// path of a local repository or a git URL, which is cloned shallowly into a
+// temporary directory for the duration of the call.
+func PeekLists(source string) ([]ParsedList, error) {
+	repo := source
+	if _, err := os.Stat(source); err != nil {
+		if !isGitURL(source) {
+			return nil, fmt.Errorf("%s does not exist", source)
+		}
+
+		dir, err := os.MkdirTemp("", "todo-peek-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		if _, err := runGit("clone", "--quiet", "--depth", "1", "--no-checkout", source, dir); err != nil {
+			return nil, fmt.Errorf("failed to clone %s: %w", source, err)
+		}
+		repo = dir
+	}
+
+	if _, err := runGit("-C", repo, "rev-parse", "--git-dir"); err != nil {
+		return nil, fmt.Errorf("%s is not a git repository", source)
+	}
+	output, err := runGit("-C", repo, "ls-tree", "-z", "--name-only", "HEAD:.todo")
+	if err != nil {
+		return nil, fmt.Errorf("%s has no committed .todo directory", source)
+	}
+
+	var lists []ParsedList
+	for _, fileName := range strings.Split(output, "\x00") {
+		name, ok := strings.CutSuffix(fileName, ".md")
+		if !ok {
+			continue
+		}
+
+		parsed := ParsedList{Name: name}
+		content, err := runGitWithInput(nil, "-C", repo, "cat-file", "blob", "HEAD:.todo/"+fileName)
+		if err == nil {
+			parsed.List, err = parseTodoList(strings.NewReader(string(content)))
+		}
+		parsed.Err = err
+		lists = append(lists, parsed)
+	}
+	return lists, nil
+}
+
+// DisplayPeek prints the overview of another repository's lists, or the
+// items of one of them when listName is set
+func DisplayPeek(source, listName string) error {
+	lists, err := PeekLists(source)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if listName == "" {
+		if len(lists) == 0 {
+			fmt.Printf("No lists in %s\n", source)
+			return nil
+		}
+
+		fmt.Printf("Lists in %s:\n", source)
+		Blank()
+		printListSummaries(lists, cfg, nil)
+		return nil
+	}
+
+	todoList, err := peekList(lists, source, listName)
+	if err != nil {
+		return err
+	}
+	if len(todoList.Items) == 0 {
+		fmt.Printf("No todos in list '%s' of %s\n", listName, source)
+		return nil
+	}
+
+	fmt.Printf("Todo list '%s' in %s:\n", listName, source)
+	Blank()
+	printTodoList(todoList, cfg)
+	return nil
+}
+
+// RenderPeek draws the overview of another repository's lists with a
+// renderer, or the items of one of them when listName is set
+func RenderPeek(w io.Writer, source, listName string, renderer Renderer) error {
+	lists, err := PeekLists(source)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if listName == "" {
+		now := Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		overviews := make([]ListOverview, 0, len(lists))
+		for _, parsed := range lists {
+			if parsed.Err != nil {
+				return fmt.Errorf("failed to parse list '%s': %w", parsed.Name, parsed.Err)
+			}
+			overviews = append(overviews, summarizeList(parsed.Name, parsed.List, cfg, today))
+		}
+		return renderer.RenderOverview(w, overviews)
+	}
+
+	todoList, err := peekList(lists, source, listName)
+	if err != nil {
+		return err
+	}
+	return renderer.RenderList(w, NewListView(listName, todoList, cfg, Now()))
+}
+
+// peekList finds a list among those read from source
+func peekList(lists []ParsedList, source, listName string) (*TodoList, error) {
+	for _, parsed := range lists {
+		if parsed.Name != listName {
+			continue
+		}
+		if parsed.Err != nil {
+			return nil, fmt.Errorf("failed to parse todo file: %w", parsed.Err)
+		}
+		return parsed.List, nil
+	}
+	return nil, fmt.Errorf("list '%s' does not exist in %s", listName, source)
+}
@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestPeekLists(t *testing.T) {
+	root := setupTestDir(t)
+
+	git := func(dir string, args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test User", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	other := filepath.Join(root, "other")
+	os.Mkdir(other, 0755)
+	git(other, "init", "-q")
+	os.Chdir(other)
+	CreateTodoFile("auth")
+	AddTodoItem("auth", "Login form")
+	AddTodoItem("auth", "Logout")
+	CheckTodoItem("auth", 1)
+	CreateTodoFile("billing")
+	ArchiveList("billing")
+	git(other, "add", ".todo")
+	git(other, "commit", "-q", "-m", "Add lists")
+
+	// Uncommitted changes aren't part of what peek shows
+	AddTodoItem("auth", "Not committed")
+
+	mine := filepath.Join(root, "mine")
+	os.Mkdir(mine, 0755)
+	os.Chdir(mine)
+
+	for _, source := range []string{other, "file://" + other} {
+		lists, err := PeekLists(source)
+		if err != nil {
+			t.Fatalf("PeekLists(%s) failed: %v", source, err)
+		}
+		if len(lists) != 1 || lists[0].Name != "auth" || lists[0].Err != nil {
+			t.Fatalf("Expected the committed auth list, got %+v", lists)
+		}
+		if progress := lists[0].List.Progress(); progress.Completed != 1 || progress.Total != 2 {
+			t.Errorf("Unexpected progress: %+v", progress)
+		}
+	}
+
+	if _, err := os.Stat(".todo"); !os.IsNotExist(err) {
+		t.Error("Peeking should not create a .todo directory")
+	}
+
+	if _, err := PeekLists(filepath.Join(root, "missing")); err == nil {
+		t.Error("Expected an error for a missing path")
+	}
+	if _, err := PeekLists(mine); err == nil {
+		t.Error("Expected an error for a directory that isn't a repository")
+	}
+}
@@ -0,0 +1,37 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PorcelainV1 is the current porcelain format: one item per line as
+//
+//	list<TAB>id<TAB>status<TAB>text
+//
+// where id is the item number, status is "pending" or "completed", and
+// backslashes, tabs and line breaks in the text are escaped as \\, \t, \n
+// and \r. Fields are only ever added at the end of the line; anything else
+// gets a new version.
+const PorcelainV1 = "v1"
+
+// CheckPorcelainVersion reports an error for porcelain versions this build
+// can't produce
+func CheckPorcelainVersion(version string) error {
+	if version != PorcelainV1 {
+		return fmt.Errorf("unknown porcelain version '%s' (supported: %s)", version, PorcelainV1)
+	}
+	return nil
+}
+
+var porcelainEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// WritePorcelainItem writes an item as a porcelain line
+func WritePorcelainItem(w io.Writer, list string, item TodoItem) {
+	status := "pending"
+	if item.Completed {
+		status = "completed"
+	}
+	fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", porcelainEscaper.Replace(list), item.ID, status, porcelainEscaper.Replace(item.Text))
+}
@@ -0,0 +1,34 @@
+package pkg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWritePorcelainItem(t *testing.T) {
+	var buf bytes.Buffer
+	WritePorcelainItem(&buf, "ops", TodoItem{ID: 1, Text: "Rotate keys"})
+	WritePorcelainItem(&buf, "ops", TodoItem{ID: 2, Text: "a\tb\nc\\d", Completed: true})
+
+	want := "ops\t1\tpending\tRotate keys\n" +
+		"ops\t2\tcompleted\ta\\tb\\nc\\\\d\n"
+	if buf.String() != want {
+		t.Errorf("Unexpected porcelain output:\n%q\nwant\n%q", buf.String(), want)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if fields := strings.Split(line, "\t"); len(fields) != 4 {
+			t.Errorf("Line %q has %d fields", line, len(fields))
+		}
+	}
+}
+
+func TestCheckPorcelainVersion(t *testing.T) {
+	if err := CheckPorcelainVersion(PorcelainV1); err != nil {
+		t.Errorf("v1 should be supported: %v", err)
+	}
+	if err := CheckPorcelainVersion("v2"); err == nil {
+		t.Error("v2 should not be supported")
+	}
+}
@@ -0,0 +1,110 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Priorities run from p1 (most urgent) to MaxPriority; 0 means an item has
+// no priority and sorts after every item that has one
+const MaxPriority = 3
+
+// Orders items can be shown in, for 'todo progress --sort'
+const (
+	// SortPosition shows items in list order (the default)
+	SortPosition = "position"
+	// SortPriority shows the most urgent items of each section first
+	SortPriority = "priority"
+)
+
+// SortOrder is the order NewListView puts items in, set for one command
+var SortOrder = SortPosition
+
+// ParseSortOrder checks the value of a --sort flag
+func ParseSortOrder(value string) (string, error) {
+	if value != SortPosition && value != SortPriority {
+		return "", fmt.Errorf("invalid sort order %q (expected %s or %s)", value, SortPosition, SortPriority)
+	}
+	return value, nil
+}
+
+// ParsePriority reads a priority level such as "p1"
+func ParsePriority(value string) (int, error) {
+	level, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(value), "p"))
+	if err != nil || !strings.HasPrefix(strings.ToLower(value), "p") || level < 1 || level > MaxPriority {
+		return 0, fmt.Errorf("invalid priority %q (expected p1 to p%d)", value, MaxPriority)
+	}
+	return level, nil
+}
+
+// FormatPriority writes a priority level as "p1", or "" for none
+func FormatPriority(priority int) string {
+	if priority < 1 {
+		return ""
+	}
+	return "p" + strconv.Itoa(priority)
+}
+
+// SortByPriority orders items from most to least urgent, keeping the order
+// of items with the same priority
+func SortByPriority(items []ListItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return priorityRank(items[i].Item.Priority) < priorityRank(items[j].Item.Priority)
+	})
+}
+
+// sortViewByPriority orders the items of each section of a view from most
+// to least urgent, keeping their labels, without changing the list itself
+func sortViewByPriority(view ListView) ListView {
+	sections := make(map[string]int)
+	for _, item := range view.List.Items {
+		if _, ok := sections[item.Section]; !ok {
+			sections[item.Section] = len(sections)
+		}
+	}
+
+	order := make([]int, len(view.List.Items))
+	for i := range order {
+		order[i] = i
+	}
+	// Subtasks stay under their top-level item, which is sorted for them
+	items := view.List.Items
+	roots := make([]int, len(items))
+	for i, depth := range view.List.depths() {
+		if depth > 0 {
+			roots[i] = roots[i-1]
+		} else {
+			roots[i] = i
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := items[roots[order[i]]], items[roots[order[j]]]
+		if sections[a.Section] != sections[b.Section] {
+			return sections[a.Section] < sections[b.Section]
+		}
+		if priorityRank(a.Priority) != priorityRank(b.Priority) {
+			return priorityRank(a.Priority) < priorityRank(b.Priority)
+		}
+		return roots[order[i]] < roots[order[j]]
+	})
+
+	sorted := *view.List
+	sorted.Items = make([]TodoItem, len(order))
+	labels := make([]string, len(order))
+	for i, index := range order {
+		sorted.Items[i] = items[index]
+		labels[i] = view.Labels[index]
+	}
+	view.List, view.Labels = &sorted, labels
+	return view
+}
+
+// priorityRank puts items without a priority after every item with one
+func priorityRank(priority int) int {
+	if priority < 1 {
+		return MaxPriority + 1
+	}
+	return priority
+}
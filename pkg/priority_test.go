@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePriority(t *testing.T) {
+	for value, want := range map[string]int{"p1": 1, "P2": 2, "p3": 3} {
+		if got, err := ParsePriority(value); err != nil || got != want {
+			t.Errorf("ParsePriority(%q) = %d, %v; want %d", value, got, err, want)
+		}
+		if got := FormatPriority(want); got != "p"+value[1:] {
+			t.Errorf("FormatPriority(%d) = %s", want, got)
+		}
+	}
+
+	for _, value := range []string{"", "1", "p0", "p4", "high"} {
+		if _, err := ParsePriority(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestSortByPriority(t *testing.T) {
+	items := []ListItem{
+		{Item: TodoItem{Text: "none"}},
+		{Item: TodoItem{Text: "p3", Priority: 3}},
+		{Item: TodoItem{Text: "first p1", Priority: 1}},
+		{Item: TodoItem{Text: "second p1", Priority: 1}},
+	}
+	SortByPriority(items)
+
+	want := []string{"first p1", "second p1", "p3", "none"}
+	for i, text := range want {
+		if items[i].Item.Text != text {
+			t.Errorf("Position %d: got %s, want %s", i, items[i].Item.Text, text)
+		}
+	}
+}
+
+func TestSortOrder(t *testing.T) {
+	if _, err := ParseSortOrder("due"); err == nil {
+		t.Error("Expected an error for an unknown sort order")
+	}
+
+	list := &TodoList{Items: []TodoItem{
+		{ID: 1, Text: "Docs", Section: "Now"},
+		{ID: 2, Text: "Ship", Section: "Now", Priority: 1},
+		{ID: 3, Text: "Tidy", Section: "Later", Priority: 3},
+		{ID: 4, Text: "Plan", Section: "Later", Priority: 2},
+	}}
+	SortOrder = SortPriority
+	defer func() { SortOrder = SortPosition }()
+	view := NewListView("work", list, &Config{}, time.Now())
+
+	var got []string
+	for i, item := range view.List.Items {
+		got = append(got, view.Labels[i]+" "+item.Text)
+	}
+	if strings.Join(got, ", ") != "2 Ship, 1 Docs, 4 Plan, 3 Tidy" {
+		t.Errorf("Expected items sorted within sections, keeping their numbers, got %v", got)
+	}
+	if list.Items[0].Text != "Docs" {
+		t.Error("Sorting the view changed the list")
+	}
+}
+
+func TestPriorityRoundTrip(t *testing.T) {
+	setupTestDir(t)
+
+	store := NewStore()
+	itemID, _ := store.AddItem("auth", "Login form")
+	if err := store.SetPriority("auth", itemID, 2); err != nil {
+		t.Fatalf("SetPriority failed: %v", err)
+	}
+	if err := store.SetPriority("auth", itemID, 5); err == nil {
+		t.Error("Expected an error for priority 5")
+	}
+	store.Flush()
+
+	todoList, _ := ParseTodoFile("auth")
+	if todoList.Items[0].Priority != 2 {
+		t.Errorf("Expected priority 2, got %d", todoList.Items[0].Priority)
+	}
+	if got := formatItemLine(todoList.Items[0]); got != "- [ ] Login form <!-- priority: p2 -->" {
+		t.Errorf("Unexpected line: %s", got)
+	}
+}
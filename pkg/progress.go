@@ -0,0 +1,67 @@
+package pkg
+
+// Progress display modes for the display.progress setting
+const (
+	// ProgressWeighted computes percentages from item weights (the default)
+	ProgressWeighted = "weighted"
+	// ProgressRaw counts every item the same
+	ProgressRaw = "raw"
+)
+
+// RawProgress overrides the display.progress setting for one command
+var RawProgress bool
+
+// Progress counts a list's completed items, both by number and by weight
+type Progress struct {
+	Completed       int
+	Total           int
+	CompletedWeight int
+	TotalWeight     int
+}
+
+// EffectiveWeight returns how much an item counts toward progress
+func (item TodoItem) EffectiveWeight() int {
+	if item.Weight > 0 {
+		return item.Weight
+	}
+	return 1
+}
+
+// Progress counts the list's completed items
+func (l *TodoList) Progress() Progress {
+	var p Progress
+	for _, item := range l.Items {
+		p.Total++
+		p.TotalWeight += item.EffectiveWeight()
+		if item.Completed {
+			p.Completed++
+			p.CompletedWeight += item.EffectiveWeight()
+		}
+	}
+	return p
+}
+
+// HasWeights reports whether any item has a weight other than 1, so weighted
+// and raw progress can differ
+func (p Progress) HasWeights() bool {
+	return p.TotalWeight != p.Total
+}
+
+// Percent returns the percentage completed, by weight or by item count
+func (p Progress) Percent(weighted bool) int {
+	if weighted {
+		if p.TotalWeight == 0 {
+			return 0
+		}
+		return p.CompletedWeight * 100 / p.TotalWeight
+	}
+	if p.Total == 0 {
+		return 0
+	}
+	return p.Completed * 100 / p.Total
+}
+
+// WeightedProgress reports whether progress should be shown by weight
+func WeightedProgress(cfg *Config) bool {
+	return cfg.Display.Progress != ProgressRaw && !RawProgress
+}
@@ -0,0 +1,64 @@
+package pkg
+
+import "testing"
+
+func TestProgress(t *testing.T) {
+	list := &TodoList{Items: []TodoItem{
+		{Text: "Design", Completed: true, Weight: 3},
+		{Text: "Typo"},
+		{Text: "Build", Weight: 4},
+	}}
+
+	progress := list.Progress()
+	if progress != (Progress{Completed: 1, Total: 3, CompletedWeight: 3, TotalWeight: 8}) {
+		t.Errorf("Unexpected progress: %+v", progress)
+	}
+	if !progress.HasWeights() {
+		t.Error("Expected the list to have weights")
+	}
+	if got := progress.Percent(true); got != 37 {
+		t.Errorf("Weighted percent = %d, want 37", got)
+	}
+	if got := progress.Percent(false); got != 33 {
+		t.Errorf("Raw percent = %d, want 33", got)
+	}
+
+	if (&TodoList{Items: []TodoItem{{Weight: 1}, {}}}).Progress().HasWeights() {
+		t.Error("Weights of 1 are the same as no weights")
+	}
+	if got := (&TodoList{}).Progress().Percent(true); got != 0 {
+		t.Errorf("Empty list percent = %d", got)
+	}
+}
+
+func TestWeightedProgress(t *testing.T) {
+	defer func() { RawProgress = false }()
+
+	if !WeightedProgress(&Config{}) {
+		t.Error("Progress should be weighted by default")
+	}
+	if WeightedProgress(&Config{Display: DisplayConfig{Progress: ProgressRaw}}) {
+		t.Error("display.progress: raw should turn weighting off")
+	}
+	RawProgress = true
+	if WeightedProgress(&Config{}) {
+		t.Error("RawProgress should turn weighting off")
+	}
+}
+
+func TestAddWeightedTodoItem(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("release")
+
+	if err := AddWeightedTodoItem("release", "Migrate database", 5); err != nil {
+		t.Fatalf("AddWeightedTodoItem failed: %v", err)
+	}
+	if err := AddWeightedTodoItem("release", "Nothing", 0); err == nil {
+		t.Error("A weight below 1 should be rejected")
+	}
+
+	todoList, _ := ParseTodoFile("release")
+	if len(todoList.Items) != 1 || todoList.Items[0].Weight != 5 || todoList.Items[0].Text != "Migrate database" {
+		t.Errorf("Unexpected items: %+v", todoList.Items)
+	}
+}
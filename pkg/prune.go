@@ -0,0 +1,87 @@
+package pkg
+
+import "fmt"
+
+// StaleList is a list whose branch has been merged or deleted
+type StaleList struct {
+	Name   string
+	Branch string
+	Reason string
+	// Pending is how many of the list's items are still to do
+	Pending int
+}
+
+// FindStaleLists returns the lists whose branch was merged into the default
+// branch or deleted, with how many items each still has to do. A list belongs to the branch recorded in its
+// frontmatter, or else to the branch it is named after if there is one;
+// lists that belong to no branch, such as those made with 'todo add', are
+// never reported, and neither are the default list and the current list.
+func FindStaleLists() ([]StaleList, error) {
+	if err := RequireGit(); err != nil {
+		return nil, err
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	currentList, err := GetCurrentList()
+	if err != nil {
+		return nil, err
+	}
+
+	lists, err := GetAllLists()
+	if err != nil {
+		return nil, err
+	}
+
+	branches, err := ListBranches()
+	if err != nil {
+		return nil, err
+	}
+	branchForList := make(map[string]string)
+	for _, branch := range branches {
+		branchForList[GetFeatureName(branch)] = branch
+	}
+
+	base := GetDefaultBranch()
+	merged, err := MergedBranches(base)
+	if err != nil {
+		return nil, err
+	}
+	isMerged := make(map[string]bool)
+	for _, branch := range merged {
+		isMerged[branch] = true
+	}
+
+	var stale []StaleList
+	for _, list := range lists {
+		if list == cfg.DefaultList || list == currentList || list == GetFeatureName(base) {
+			continue
+		}
+
+		todoList, err := ParseTodoFile(list)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse todo file: %w", err)
+		}
+		branch, ok := branchForList[list]
+		if linked := todoList.Meta.Branch; linked != "" {
+			branch, ok = linked, BranchExists(linked)
+		}
+		pending := 0
+		for _, item := range todoList.Items {
+			if !item.Completed {
+				pending++
+			}
+		}
+		switch {
+		case branch == "":
+		case !ok:
+			stale = append(stale, StaleList{Name: list, Branch: branch, Reason: fmt.Sprintf("branch %s no longer exists", branch), Pending: pending})
+		case isMerged[branch]:
+			stale = append(stale, StaleList{Name: list, Branch: branch, Reason: fmt.Sprintf("branch %s was merged into %s", branch, base), Pending: pending})
+		}
+	}
+
+	return stale, nil
+}
@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestFindStaleLists(t *testing.T) {
+	setupGitTestDir(t)
+
+	git := func(args ...string) {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("checkout", "-q", "-b", "main")
+	git("commit", "-q", "--allow-empty", "-m", "Initial commit")
+
+	// feature/merged is merged into main, feature/active has unmerged work
+	git("checkout", "-q", "-b", "feature/merged")
+	git("commit", "-q", "--allow-empty", "-m", "Merged work")
+	git("checkout", "-q", "main")
+	git("merge", "-q", "--no-ff", "--no-edit", "feature/merged")
+	git("checkout", "-q", "-b", "feature/active")
+	git("commit", "-q", "--allow-empty", "-m", "Active work")
+	git("checkout", "-q", "main")
+	// feature/new was just made and has no commits of its own
+	git("branch", "feature/new")
+
+	// plain was made with 'todo add' and never had a branch
+	for _, list := range []string{"main", "merged", "active", "new", "deleted", "plain"} {
+		CreateTodoFile(list)
+	}
+	LinkListToBranch("deleted", "feature/deleted")
+	AddTodoItem("deleted", "Unfinished work")
+
+	stale, err := FindStaleLists()
+	if err != nil {
+		t.Fatalf("FindStaleLists failed: %v", err)
+	}
+
+	found := make(map[string]StaleList)
+	for _, s := range stale {
+		found[s.Name] = s
+	}
+
+	if len(found) != 2 {
+		t.Errorf("Expected 2 stale lists, got %+v", stale)
+	}
+	if s, ok := found["merged"]; !ok || s.Branch != "feature/merged" {
+		t.Errorf("Expected 'merged' to be stale via feature/merged, got %+v", stale)
+	}
+	if s, ok := found["deleted"]; !ok || s.Reason != "branch feature/deleted no longer exists" || s.Pending != 1 {
+		t.Errorf("Expected 'deleted' to be stale, got %+v", stale)
+	}
+	if _, ok := found["new"]; ok {
+		t.Errorf("Expected a branch without commits of its own not to count as merged, got %+v", stale)
+	}
+	if _, ok := found["plain"]; ok {
+		t.Errorf("Expected a list without a branch not to be stale, got %+v", stale)
+	}
+}
@@ -0,0 +1,80 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// WriteQuickfixItem writes an item as a line in Vim's default quickfix
+// format, file:line: text, pointing at the item in its todo file
+func WriteQuickfixItem(w io.Writer, list string, item TodoItem) {
+	status := "[ ]"
+	if item.Completed {
+		status = "[x]"
+	}
+	line := item.Line
+	if line < 1 {
+		line = 1
+	}
+	fmt.Fprintf(w, "%s:%d: %s %s\n", GetTodoFilePath(list), line, status, item.Text)
+}
+
+// SearchQuery is what SearchItems looks for
+type SearchQuery struct {
+	// Text is looked for anywhere in an item's text, ignoring case
+	Text string
+	// Pattern, when set, is matched against item text instead of Text
+	Pattern *regexp.Regexp
+	// Pending and Completed keep only the items in that state
+	Pending, Completed bool
+}
+
+// NewSearchQuery looks for text, read as a regular expression when regex is
+// set. Regular expressions ignore case too, unless they turn that off with
+// (?-i).
+func NewSearchQuery(text string, regex bool) (SearchQuery, error) {
+	query := SearchQuery{Text: text}
+	if regex {
+		pattern, err := regexp.Compile("(?i)" + text)
+		if err != nil {
+			return query, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		query.Pattern = pattern
+	}
+	return query, nil
+}
+
+// Matches reports whether an item is one the query looks for
+func (q SearchQuery) Matches(item TodoItem) bool {
+	if (q.Pending && item.Completed) || (q.Completed && !item.Completed) {
+		return false
+	}
+	if q.Pattern != nil {
+		return q.Pattern.MatchString(item.Text)
+	}
+	return strings.Contains(strings.ToLower(item.Text), strings.ToLower(q.Text))
+}
+
+// SearchItems returns the items of the named lists that match a query, in
+// list and item order. It stops with ctx.Err() as soon as ctx is done.
+func SearchItems(ctx context.Context, names []string, query SearchQuery) ([]ListItem, error) {
+	lists, err := ParseListsContext(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	var matches []ListItem
+	for _, parsed := range lists {
+		if parsed.Err != nil {
+			return nil, fmt.Errorf("error reading list '%s': %w", parsed.Name, parsed.Err)
+		}
+		for _, item := range parsed.List.Items {
+			if query.Matches(item) {
+				matches = append(matches, ListItem{List: parsed.Name, Item: item})
+			}
+		}
+	}
+	return matches, nil
+}
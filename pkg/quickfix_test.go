@@ -0,0 +1,63 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+)
+
+func TestSearchItemsQuickfix(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	content := "---\ntarget: 2030-01-01\n---\n# Todo List for main\n\n- [ ] Fix login\n\n## Later\n\n- [x] Login docs\n- [ ] Ship\n"
+	os.WriteFile(GetTodoFilePath("main"), []byte(content), 0644)
+	AddTodoItem("other", "Unrelated")
+
+	matches, err := SearchItems(context.Background(), []string{"main", "other"}, SearchQuery{Text: "LOGIN"})
+	if err != nil {
+		t.Fatalf("SearchItems failed: %v", err)
+	}
+	var buf bytes.Buffer
+	for _, match := range matches {
+		WriteQuickfixItem(&buf, match.List, match.Item)
+	}
+	want := ".todo/main.md:6: [ ] Fix login\n.todo/main.md:10: [x] Login docs\n"
+	if buf.String() != want {
+		t.Errorf("Unexpected quickfix output:\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	pending := TodoItem{Text: "Fix login redirect"}
+	done := TodoItem{Text: "Fix logout", Completed: true}
+
+	query, err := NewSearchQuery(`^fix (login|logout)\b`, true)
+	if err != nil {
+		t.Fatalf("NewSearchQuery failed: %v", err)
+	}
+	if !query.Matches(pending) || !query.Matches(done) {
+		t.Error("Expected the regular expression to match both items, ignoring case")
+	}
+	if query.Matches(TodoItem{Text: "Prefix login"}) {
+		t.Error("Expected the regular expression to be anchored")
+	}
+
+	query.Pending = true
+	if !query.Matches(pending) || query.Matches(done) {
+		t.Error("Expected Pending to keep only pending items")
+	}
+	query.Pending, query.Completed = false, true
+	if query.Matches(pending) || !query.Matches(done) {
+		t.Error("Expected Completed to keep only completed items")
+	}
+
+	// Without regex, special characters are plain text
+	query, _ = NewSearchQuery("login (", false)
+	if !query.Matches(TodoItem{Text: "Fix LOGIN (again)"}) {
+		t.Error("Expected plain text to match ignoring case")
+	}
+	if _, err := NewSearchQuery("login (", true); err == nil {
+		t.Error("Expected an invalid regular expression to fail")
+	}
+}
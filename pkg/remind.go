@@ -0,0 +1,263 @@
+package pkg
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reminders are one-shot jobs handed to the OS scheduler (a transient
+// systemd timer or an at job on Linux, a launchd agent on macOS, a scheduled
+// task on Windows), so nothing has to keep running until they are due. The
+// job runs the hidden 'todo remind fire' command, which shows a desktop
+// notification unless the item was completed in the meantime.
+
+// Reminder is a reminder about an item at a given time
+type Reminder struct {
+	// Name identifies the job to the scheduler
+	Name string    `json:"name"`
+	List string    `json:"list"`
+	Text string    `json:"text"`
+	At   time.Time `json:"at"`
+	// Dir is the project directory the reminder was set in
+	Dir string `json:"dir,omitempty"`
+}
+
+// metaRemind holds the time an item's reminder is set for, which has
+// nothing to do with when the item is due
+const metaRemind = "remind"
+
+var reminderClockRegex = regexp.MustCompile(`^([01]?\d|2[0-3]):([0-5]\d)$`)
+
+// ParseReminderTime reads the time given to 'todo remind --at': a time of
+// day, after a date in any form due dates take, such as "2024-07-01 09:00"
+// or "friday 14:30". A time of day on its own means the next time the clock
+// shows it.
+func ParseReminderTime(input string, now time.Time) (time.Time, error) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return time.Time{}, fmt.Errorf("no reminder time given")
+	}
+	clock := reminderClockRegex.FindStringSubmatch(fields[len(fields)-1])
+	if clock == nil {
+		return time.Time{}, fmt.Errorf("invalid reminder time '%s' (e.g. \"2024-07-01 09:00\", \"friday 14:30\" or \"17:00\")", input)
+	}
+	hour, _ := strconv.Atoi(clock[1])
+	minute, _ := strconv.Atoi(clock[2])
+
+	day := now
+	if len(fields) > 1 {
+		var err error
+		if day, err = ParseDueDate(strings.Join(fields[:len(fields)-1], " "), now); err != nil {
+			return time.Time{}, err
+		}
+	}
+	at := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, time.Local)
+	if len(fields) == 1 && !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at, nil
+}
+
+// ItemReminder returns the time an item's reminder is set for
+func ItemReminder(item TodoItem) (time.Time, bool) {
+	value, ok := item.Metadata[metaRemind]
+	if !ok {
+		return time.Time{}, false
+	}
+	at, err := time.ParseInLocation(completedTimeFormat, value, time.Local)
+	return at, err == nil
+}
+
+// reminderGOOS, lookPath and runScheduler are swapped out by tests
+var (
+	reminderGOOS = runtime.GOOS
+	lookPath     = exec.LookPath
+	runScheduler = func(stdin string, args ...string) error {
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = strings.NewReader(stdin)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s failed: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+)
+
+// NewReminder returns a reminder about a pending item of a list
+func NewReminder(listName string, itemID int, at time.Time) (*Reminder, error) {
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse todo file: %w", err)
+	}
+	if itemID < 1 || itemID > len(todoList.Items) {
+		return nil, fmt.Errorf("item %d not found", itemID)
+	}
+	item := todoList.Items[itemID-1]
+	if item.Completed {
+		return nil, fmt.Errorf("item %d is already completed", itemID)
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return &Reminder{
+		Name: fmt.Sprintf("todo-remind-%d", time.Now().UnixNano()),
+		List: listName,
+		Text: item.Text,
+		At:   at,
+		Dir:  dir,
+	}, nil
+}
+
+// fireArgs is the command line the scheduler runs when the reminder is due
+func (r Reminder) fireArgs(exe string) []string {
+	return []string{exe, "remind", "fire", "--dir", r.Dir, "--list", r.List, "--name", r.Name, "--", r.Text}
+}
+
+// ReminderDue reports whether the item a reminder is about is still pending
+func ReminderDue(listName, text string) bool {
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return false
+	}
+	for _, item := range todoList.Items {
+		if item.Text == text && !item.Completed {
+			return true
+		}
+	}
+	return false
+}
+
+// ScheduleReminder hands a reminder to the OS scheduler
+func ScheduleReminder(r Reminder, now time.Time) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the todo executable: %w", err)
+	}
+	args := r.fireArgs(exe)
+
+	switch reminderGOOS {
+	case "linux":
+		if _, err := lookPath("systemd-run"); err == nil {
+			delay := int(r.At.Sub(now).Round(time.Second).Seconds())
+			return runScheduler("", append([]string{"systemd-run", "--user", "--quiet",
+				"--unit=" + r.Name,
+				fmt.Sprintf("--on-active=%ds", max(delay, 1)),
+				"--timer-property=AccuracySec=1s",
+				"--description=todo reminder: " + r.Text,
+			}, args...)...)
+		}
+		if _, err := lookPath("at"); err == nil {
+			return runScheduler(atScript(args), "at", "-t", r.At.Format("200601021504.05"))
+		}
+		return fmt.Errorf("no scheduler found; reminders need systemd-run or at")
+	case "darwin":
+		path := launchAgentPath(r.Name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create LaunchAgents directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(launchAgentPlist(r.Name, args, schedulerMinute(r.At))), 0644); err != nil {
+			return fmt.Errorf("failed to write launch agent: %w", err)
+		}
+		return runScheduler("", "launchctl", "load", path)
+	case "windows":
+		// schtasks reads /SD in the system's date format; US month/day/year
+		// is the common case
+		at := schedulerMinute(r.At)
+		return runScheduler("", "schtasks", "/Create", "/F", "/TN", r.Name, "/SC", "ONCE",
+			"/SD", at.Format("01/02/2006"), "/ST", at.Format("15:04"), "/TR", windowsCommandLine(args))
+	}
+	return fmt.Errorf("reminders are not supported on %s", reminderGOOS)
+}
+
+// RemoveReminder deletes what the scheduler keeps of a fired reminder.
+// Transient systemd units and at jobs clean up after themselves.
+func RemoveReminder(name string) error {
+	switch reminderGOOS {
+	case "darwin":
+		path := launchAgentPath(name)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return runScheduler("", "launchctl", "remove", name)
+	case "windows":
+		return runScheduler("", "schtasks", "/Delete", "/F", "/TN", name)
+	}
+	return nil
+}
+
+// schedulerMinute rounds a time up to the minute, for schedulers that can't
+// be more precise
+func schedulerMinute(t time.Time) time.Time {
+	if rounded := t.Truncate(time.Minute); rounded.Before(t) {
+		return rounded.Add(time.Minute)
+	}
+	return t
+}
+
+// atScript is the job given to at. at jobs don't inherit the session, so
+// the variables notify-send needs to reach the desktop are passed along.
+func atScript(args []string) string {
+	var b strings.Builder
+	for _, name := range []string{"DISPLAY", "WAYLAND_DISPLAY", "DBUS_SESSION_BUS_ADDRESS", "XDG_RUNTIME_DIR"} {
+		if value := os.Getenv(name); value != "" {
+			fmt.Fprintf(&b, "export %s=%s\n", name, shellQuote(value))
+		}
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	b.WriteString(strings.Join(quoted, " ") + "\n")
+	return b.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// windowsCommandLine quotes arguments the way Windows programs split them
+func windowsCommandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+func launchAgentPath(name string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "Library", "LaunchAgents", name+".plist")
+}
+
+// launchAgentPlist describes a launchd job that runs once at the given
+// minute. launchd calendar intervals repeat every year, so the fired command
+// removes the job.
+func launchAgentPlist(label string, args []string, at time.Time) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t<string>%s</string>\n", html.EscapeString(label))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range args {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", html.EscapeString(arg))
+	}
+	b.WriteString("\t</array>\n\t<key>StartCalendarInterval</key>\n\t<dict>\n")
+	fmt.Fprintf(&b, "\t\t<key>Month</key>\n\t\t<integer>%d</integer>\n", at.Month())
+	fmt.Fprintf(&b, "\t\t<key>Day</key>\n\t\t<integer>%d</integer>\n", at.Day())
+	fmt.Fprintf(&b, "\t\t<key>Hour</key>\n\t\t<integer>%d</integer>\n", at.Hour())
+	fmt.Fprintf(&b, "\t\t<key>Minute</key>\n\t\t<integer>%d</integer>\n", at.Minute())
+	b.WriteString("\t</dict>\n</dict>\n</plist>\n")
+	return b.String()
+}
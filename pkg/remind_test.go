@@ -0,0 +1,166 @@
+package pkg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeScheduler records the scheduler commands run instead of running them
+func fakeScheduler(t *testing.T, goos string, available ...string) *[][]string {
+	var commands [][]string
+	originalGOOS, originalLookPath, originalRun := reminderGOOS, lookPath, runScheduler
+	reminderGOOS = goos
+	lookPath = func(name string) (string, error) {
+		for _, tool := range available {
+			if tool == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+	runScheduler = func(stdin string, args ...string) error {
+		commands = append(commands, append([]string{stdin}, args...))
+		return nil
+	}
+	t.Cleanup(func() {
+		reminderGOOS, lookPath, runScheduler = originalGOOS, originalLookPath, originalRun
+	})
+	return &commands
+}
+
+func TestNewReminder(t *testing.T) {
+	setupTestDir(t)
+	AddTodoItem("main", "Call Ann")
+	AddTodoItem("main", "Book flights")
+	CheckTodoItem("main", 2)
+
+	at := time.Now().Add(2 * time.Hour)
+	reminder, err := NewReminder("main", 1, at)
+	if err != nil {
+		t.Fatalf("NewReminder failed: %v", err)
+	}
+	if reminder.Text != "Call Ann" || reminder.List != "main" || !reminder.At.Equal(at) || !strings.HasPrefix(reminder.Name, "todo-remind-") {
+		t.Errorf("Unexpected reminder: %+v", reminder)
+	}
+
+	if _, err := NewReminder("main", 2, at); err == nil {
+		t.Error("Expected an error for a completed item")
+	}
+	if _, err := NewReminder("main", 5, at); err == nil {
+		t.Error("Expected an error for a missing item")
+	}
+
+	if !ReminderDue("main", "Call Ann") || ReminderDue("main", "Book flights") || ReminderDue("main", "Gone") {
+		t.Error("Only pending items should be due")
+	}
+}
+
+func TestParseReminderTime(t *testing.T) {
+	setupTestDir(t)
+	// A Wednesday afternoon
+	now := time.Date(2024, 7, 3, 15, 0, 0, 0, time.Local)
+
+	tests := map[string]string{
+		"2024-07-10 09:00": "2024-07-10 09:00",
+		"friday 14:30":     "2024-07-05 14:30",
+		"17:00":            "2024-07-03 17:00",
+		// Already past today
+		"9:05": "2024-07-04 09:05",
+	}
+	for input, want := range tests {
+		if got, err := ParseReminderTime(input, now); err != nil || got.Format(completedTimeFormat) != want {
+			t.Errorf("ParseReminderTime(%q) = %v, %v, want %s", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "tomorrow", "25:00", "someday 09:00"} {
+		if _, err := ParseReminderTime(input, now); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestScheduleReminderLinux(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	reminder := Reminder{Name: "todo-remind-1", List: "main", Text: "Call Ann's office", At: now.Add(2 * time.Hour), Dir: "/work"}
+
+	commands := fakeScheduler(t, "linux", "systemd-run", "at")
+	if err := ScheduleReminder(reminder, now); err != nil {
+		t.Fatalf("ScheduleReminder failed: %v", err)
+	}
+	got := strings.Join((*commands)[0][1:], " ")
+	for _, want := range []string{"systemd-run --user", "--unit=todo-remind-1", "--on-active=7200s", "remind fire --dir /work --list main --name todo-remind-1 -- Call Ann's office"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in %q", want, got)
+		}
+	}
+
+	// Without systemd, at runs a script with the quoted command
+	commands = fakeScheduler(t, "linux", "at")
+	t.Setenv("DISPLAY", ":0")
+	if err := ScheduleReminder(reminder, now); err != nil {
+		t.Fatalf("ScheduleReminder failed: %v", err)
+	}
+	command := (*commands)[0]
+	if strings.Join(command[1:], " ") != "at -t 202403011100.00" {
+		t.Errorf("Unexpected at command: %q", command[1:])
+	}
+	if !strings.Contains(command[0], "export DISPLAY=':0'\n") || !strings.Contains(command[0], `'Call Ann'\''s office'`) {
+		t.Errorf("Unexpected at script:\n%s", command[0])
+	}
+
+	fakeScheduler(t, "linux")
+	if err := ScheduleReminder(reminder, now); err == nil {
+		t.Error("Expected an error without a scheduler")
+	}
+}
+
+func TestScheduleReminderDarwin(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	reminder := Reminder{Name: "todo-remind-1", List: "main", Text: "Fix <b> & co", At: now.Add(90*time.Minute + 10*time.Second), Dir: "/work"}
+
+	commands := fakeScheduler(t, "darwin")
+	if err := ScheduleReminder(reminder, now); err != nil {
+		t.Fatalf("ScheduleReminder failed: %v", err)
+	}
+	path := filepath.Join(home, "Library", "LaunchAgents", "todo-remind-1.plist")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected a launch agent: %v", err)
+	}
+	// launchd only has minutes, so 10:30:10 rounds up to 10:31
+	for _, want := range []string{"<string>Fix &lt;b&gt; &amp; co</string>", "<key>Hour</key>\n\t\t<integer>10</integer>", "<key>Minute</key>\n\t\t<integer>31</integer>"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected %q in the launch agent:\n%s", want, content)
+		}
+	}
+	if strings.Join((*commands)[0][1:], " ") != "launchctl load "+path {
+		t.Errorf("Unexpected commands: %q", *commands)
+	}
+
+	if err := RemoveReminder("todo-remind-1"); err != nil {
+		t.Fatalf("RemoveReminder failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected the launch agent to be removed")
+	}
+}
+
+func TestScheduleReminderWindows(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	reminder := Reminder{Name: "todo-remind-1", List: "main", Text: `Say "hi"`, At: now.Add(time.Hour), Dir: `C:\work`}
+
+	commands := fakeScheduler(t, "windows")
+	if err := ScheduleReminder(reminder, now); err != nil {
+		t.Fatalf("ScheduleReminder failed: %v", err)
+	}
+	got := strings.Join((*commands)[0][1:], " ")
+	if !strings.Contains(got, "schtasks /Create /F /TN todo-remind-1 /SC ONCE /SD 03/01/2024 /ST 10:00 /TR ") || !strings.Contains(got, `"Say \"hi\""`) {
+		t.Errorf("Unexpected schtasks command: %q", got)
+	}
+}
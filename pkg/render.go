@@ -0,0 +1,453 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Renderer draws lists for a frontend. The CLI's own output comes from
+// the built-in renderers, and programs embedding this package can register
+// their own with RegisterRenderer to draw lists their way from the same
+// models.
+type Renderer interface {
+	// RenderList draws the items of one list and its progress
+	RenderList(w io.Writer, view ListView) error
+	// RenderOverview draws a summary of each list
+	RenderOverview(w io.Writer, overviews []ListOverview) error
+}
+
+// ListView is a list ready to be rendered
+type ListView struct {
+	Name string
+	List *TodoList
+	// Labels holds the number shown for each item, following
+	// display.numbering
+	Labels []string
+	// Weighted is whether progress is shown by weight
+	Weighted bool
+	// Now is the time overdue items and targets are judged against
+	Now time.Time
+}
+
+// NewListView prepares a list to be rendered with the display settings of
+// cfg, keeping the items that pass DisplayFilter in SortOrder
+func NewListView(name string, todoList *TodoList, cfg *Config, now time.Time) ListView {
+	view := ListView{
+		Name:     name,
+		List:     todoList,
+		Labels:   ItemLabels(todoList, cfg.Display),
+		Weighted: todoList.Progress().HasWeights() && WeightedProgress(cfg),
+		Now:      now,
+	}
+	if !DisplayFilter.IsZero() {
+		view = filterView(view, DisplayFilter)
+	}
+	if SortOrder == SortPriority {
+		view = sortViewByPriority(view)
+	}
+	return view
+}
+
+var (
+	renderersMu sync.Mutex
+	renderers   = make(map[string]Renderer)
+)
+
+// RegisterRenderer adds or replaces a renderer that --format can name
+func RegisterRenderer(name string, renderer Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+
+	renderers[name] = renderer
+}
+
+// Renderers returns the names of the registered renderers
+func Renderers() []string {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+
+	var names []string
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupRenderer returns the renderer registered under name
+func LookupRenderer(name string) (Renderer, error) {
+	renderersMu.Lock()
+	renderer, ok := renderers[name]
+	renderersMu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown renderer '%s' (expected one of %s)", name, strings.Join(Renderers(), ", "))
+	}
+	return renderer, nil
+}
+
+func init() {
+	RegisterRenderer("plain", PlainRenderer{})
+	RegisterRenderer("color", ColorRenderer{})
+	RegisterRenderer("json", JSONRenderer{})
+	RegisterRenderer("table", TableRenderer{})
+}
+
+// RenderTodoList draws a list with a renderer. Its own item numbers then
+// apply again instead of those of an aggregated view.
+func RenderTodoList(w io.Writer, listName string, renderer Renderer) error {
+	ClearView()
+	todoList, err := ParseTodoFile(listName)
+	if err != nil {
+		return fmt.Errorf("failed to parse todo file: %w", err)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.Display.Numbering == NumberingID && AssignShortIDs(todoList) {
+		if err := WriteTodoFile(listName, todoList); err != nil {
+			return err
+		}
+	}
+	return renderer.RenderList(w, NewListView(listName, todoList, cfg, Now()))
+}
+
+// RenderListOverviews draws the overview of every list with a renderer
+func RenderListOverviews(w io.Writer, renderer Renderer) error {
+	overviews, err := GetListOverviews(Now())
+	if err != nil {
+		return err
+	}
+	return renderer.RenderOverview(w, overviews)
+}
+
+// Kinds of line a styled renderer can set apart
+type lineKind int
+
+const (
+	lineSection lineKind = iota
+	linePending
+	lineCompleted
+	lineOverdue
+	lineDueSoon
+	lineSummary
+)
+
+// PlainRenderer draws lists as plain text, the CLI's usual output
+type PlainRenderer struct{}
+
+func (PlainRenderer) RenderList(w io.Writer, view ListView) error {
+	writeList(w, view, nil)
+	return nil
+}
+
+func (PlainRenderer) RenderOverview(w io.Writer, overviews []ListOverview) error {
+	writeOverviews(w, overviews, nil)
+	return nil
+}
+
+// ColorRenderer draws the plain text output with ANSI colors: sections in
+// bold, completed items in green, overdue ones in red and those due soon in
+// yellow. With Color off it draws the plain text.
+type ColorRenderer struct{}
+
+// Color is whether the color renderer writes ANSI escape codes, set from
+// display.color for one command
+var Color = true
+
+func (ColorRenderer) RenderList(w io.Writer, view ListView) error {
+	writeList(w, view, colorLine)
+	return nil
+}
+
+func (ColorRenderer) RenderOverview(w io.Writer, overviews []ListOverview) error {
+	writeOverviews(w, overviews, colorLine)
+	return nil
+}
+
+func colorLine(kind lineKind, line string) string {
+	if !Color {
+		return line
+	}
+	switch kind {
+	case lineSection:
+		return "\033[1m" + line + "\033[0m"
+	case lineCompleted:
+		return "\033[32m" + line + "\033[0m"
+	case lineOverdue:
+		return "\033[31m" + line + "\033[0m"
+	case lineDueSoon:
+		return "\033[33m" + line + "\033[0m"
+	case lineSummary:
+		return "\033[2m" + line + "\033[0m"
+	}
+	return line
+}
+
+// writeList writes the items of a list followed by its progress, styling
+// each line when style is set
+func writeList(w io.Writer, view ListView, style func(lineKind, string) string) {
+	if style == nil {
+		style = func(_ lineKind, line string) string { return line }
+	}
+	section := ""
+	depths := view.List.depths()
+	for i, item := range view.List.Items {
+		if item.Section != section {
+			if i > 0 {
+				blank(w)
+			}
+			fmt.Fprintln(w, style(lineSection, item.Section+":"))
+			section = item.Section
+		}
+
+		// Priorities, and whether items are overdue or due soon, are shown
+		// even when the rest of their details aren't
+		kind, status := linePending, "[ ]"
+		note := dueNote(item, view.Now)
+		switch {
+		case item.Completed:
+			kind, status = lineCompleted, "[x]"
+		case note == "overdue":
+			kind = lineOverdue
+		case note != "":
+			kind = lineDueSoon
+		}
+		text := item.Text
+		if Detail == DetailRich {
+			text += itemDetails(item, note)
+		} else {
+			text += itemNotes(item, note)
+		}
+		for _, line := range fitText(fmt.Sprintf("%s%s. %s ", strings.Repeat(subtaskIndent, depths[i]), view.Labels[i], status), text) {
+			fmt.Fprintln(w, style(kind, line))
+		}
+	}
+
+	progress := view.List.Progress()
+	blank(w)
+	if view.Weighted {
+		fmt.Fprintln(w, style(lineSummary, fmt.Sprintf("Progress: %d/%d completed (%d%% by weight)", progress.Completed, progress.Total, progress.Percent(true))))
+	} else {
+		fmt.Fprintln(w, style(lineSummary, fmt.Sprintf("Progress: %d/%d completed", progress.Completed, progress.Total)))
+	}
+	if Detail == DetailRich {
+		fmt.Fprintln(w, style(lineSummary, fmt.Sprintf("%s %d%%", ProgressBar(progress.Percent(view.Weighted)), progress.Percent(view.Weighted))))
+	}
+
+	if status, err := view.List.GetTargetStatus(view.Now); err != nil {
+		fmt.Fprintf(w, "Warning: %v\n", err)
+	} else if status != nil {
+		fmt.Fprintln(w, style(lineSummary, status.String()))
+	}
+}
+
+// writeOverviews writes the one-line progress of each list, with the names
+// padded so the progress lines up
+func writeOverviews(w io.Writer, overviews []ListOverview, style func(lineKind, string) string) {
+	nameWidth := 0
+	for _, overview := range overviews {
+		nameWidth = max(nameWidth, displayWidth(overview.Name))
+	}
+	for _, overview := range overviews {
+		line := overviewLine(PadRight(overview.Name, nameWidth), overview)
+		if style != nil {
+			switch {
+			case overview.Overdue > 0:
+				line = style(lineOverdue, line)
+			case overview.Total > 0 && overview.Pending == 0:
+				line = style(lineCompleted, line)
+			}
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// overviewLine returns the one-line progress of a list in an overview
+func overviewLine(name string, overview ListOverview) string {
+	bar := ""
+	if Detail == DetailRich {
+		bar = " " + ProgressBar(overview.Percent)
+	}
+	switch {
+	case overview.Total == 0:
+		return fmt.Sprintf("  %s - No todos", name)
+	case overview.Weighted:
+		return fmt.Sprintf("  %s - %d/%d completed (%d%% by weight)%s", name, overview.Completed, overview.Total, overview.Percent, bar)
+	default:
+		return fmt.Sprintf("  %s - %d/%d completed (%d%%)%s", name, overview.Completed, overview.Total, overview.Percent, bar)
+	}
+}
+
+// JSONRenderer draws lists as indented JSON for scripts
+type JSONRenderer struct{}
+
+// JSONItem is an item as JSONRenderer writes it
+type JSONItem struct {
+	ID          int      `json:"id"`
+	Label       string   `json:"label"`
+	Text        string   `json:"text"`
+	Completed   bool     `json:"completed"`
+	CompletedAt string   `json:"completed_at,omitempty"`
+	Section     string   `json:"section,omitempty"`
+	Depth       int      `json:"depth,omitempty"`
+	Notes       []string `json:"notes,omitempty"`
+	Due         string   `json:"due,omitempty"`
+	Priority    string   `json:"priority,omitempty"`
+	Estimate    string   `json:"estimate,omitempty"`
+	Weight      int      `json:"weight"`
+}
+
+// NewJSONItem prepares an item, shown with label, to be written as JSON
+func NewJSONItem(item TodoItem, label string) JSONItem {
+	out := JSONItem{
+		ID:        item.ID,
+		Label:     label,
+		Text:      item.Text,
+		Completed: item.Completed,
+		Section:   item.Section,
+		Depth:     item.Depth,
+		Notes:     item.Notes,
+		Priority:  FormatPriority(item.Priority),
+		Weight:    item.EffectiveWeight(),
+	}
+	if item.Completed && item.CompletedTime != nil {
+		out.CompletedAt = item.CompletedTime.Format(time.RFC3339)
+	}
+	if item.DueDate != nil {
+		out.Due = item.DueDate.Format(DueDateFormat)
+	}
+	if item.Estimate > 0 {
+		out.Estimate = FormatEstimate(item.Estimate)
+	}
+	return out
+}
+
+func (JSONRenderer) RenderList(w io.Writer, view ListView) error {
+	progress := view.List.Progress()
+	items := make([]JSONItem, 0, len(view.List.Items))
+	for i, item := range view.List.Items {
+		items = append(items, NewJSONItem(item, view.Labels[i]))
+	}
+	return WriteJSON(w, struct {
+		Name      string     `json:"name"`
+		Total     int        `json:"total"`
+		Completed int        `json:"completed"`
+		Percent   int        `json:"percent"`
+		Weighted  bool       `json:"weighted"`
+		Items     []JSONItem `json:"items"`
+	}{view.Name, progress.Total, progress.Completed, progress.Percent(view.Weighted), view.Weighted, items})
+}
+
+// RenderOverview writes the lists along with the name of the current one
+func (JSONRenderer) RenderOverview(w io.Writer, overviews []ListOverview) error {
+	current := ""
+	for _, overview := range overviews {
+		if overview.Current {
+			current = overview.Name
+		}
+	}
+	return WriteJSON(w, map[string]interface{}{
+		"current": current,
+		"lists":   overviews,
+	})
+}
+
+// WriteJSON writes value as indented JSON
+func WriteJSON(w io.Writer, value interface{}) error {
+	output, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(output))
+	return err
+}
+
+// TableRenderer draws lists as aligned columns, leaving out columns no row
+// has a value for
+type TableRenderer struct{}
+
+func (TableRenderer) RenderList(w io.Writer, view ListView) error {
+	today := time.Date(view.Now.Year(), view.Now.Month(), view.Now.Day(), 0, 0, 0, 0, view.Now.Location())
+	rows := [][]string{{"#", "DONE", "ITEM", "SECTION", "DUE", "PRIORITY", "ESTIMATE"}}
+	for i, item := range view.List.Items {
+		done, due, estimate := "", "", ""
+		if item.Completed {
+			done = "x"
+		}
+		if item.DueDate != nil {
+			due = item.DueDate.Format(DueDateFormat)
+			if !item.Completed && item.DueDate.Before(today) {
+				due += " (overdue)"
+			}
+		}
+		if item.Estimate > 0 {
+			estimate = FormatEstimate(item.Estimate)
+		}
+		rows = append(rows, []string{view.Labels[i], done, item.Text, item.Section, due, FormatPriority(item.Priority), estimate})
+	}
+	writeTable(w, rows, 3)
+	return nil
+}
+
+func (TableRenderer) RenderOverview(w io.Writer, overviews []ListOverview) error {
+	rows := [][]string{{"LIST", "DONE", "TOTAL", "PERCENT", "OVERDUE", "TAGS"}}
+	for _, overview := range overviews {
+		name := overview.Name
+		if overview.Current {
+			name += " (current)"
+		}
+		var tags []string
+		for _, tag := range overview.Tags {
+			tags = append(tags, "#"+tag)
+		}
+		rows = append(rows, []string{
+			name,
+			strconv.Itoa(overview.Completed),
+			strconv.Itoa(overview.Total),
+			strconv.Itoa(overview.Percent) + "%",
+			strconv.Itoa(overview.Overdue),
+			strings.Join(tags, " "),
+		})
+	}
+	writeTable(w, rows, 5)
+	return nil
+}
+
+// writeTable writes rows as columns two spaces apart. The first row is the
+// header. Past the first always columns, columns that are empty in every
+// other row are left out.
+func writeTable(w io.Writer, rows [][]string, always int) {
+	var columns []int
+	widths := make(map[int]int)
+	for column := range rows[0] {
+		for i, row := range rows {
+			if column < always || (i > 0 && row[column] != "") {
+				columns = append(columns, column)
+				break
+			}
+		}
+		for _, row := range rows {
+			widths[column] = max(widths[column], displayWidth(row[column]))
+		}
+	}
+
+	for _, row := range rows {
+		var cells []string
+		for i, column := range columns {
+			if i == len(columns)-1 {
+				cells = append(cells, row[column])
+			} else {
+				cells = append(cells, PadRight(row[column], widths[column]))
+			}
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+}
@@ -0,0 +1,146 @@
+package pkg
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countRenderer writes how many items and lists it was given
+type countRenderer struct{}
+
+func (countRenderer) RenderList(w io.Writer, view ListView) error {
+	_, err := io.WriteString(w, view.Name+": "+strings.Repeat("*", len(view.List.Items))+"\n")
+	return err
+}
+
+func (countRenderer) RenderOverview(w io.Writer, overviews []ListOverview) error {
+	for _, overview := range overviews {
+		io.WriteString(w, overview.Name+"\n")
+	}
+	return nil
+}
+
+func renderTestList() *TodoList {
+	due := time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local)
+	return &TodoList{Items: []TodoItem{
+		{ID: 1, Text: "Ship", Section: "Now", DueDate: &due, Priority: 1},
+		{ID: 2, Text: "Plan", Section: "Now", Completed: true, Estimate: 30 * time.Minute},
+		{ID: 3, Text: "Docs", Section: "Later"},
+	}}
+}
+
+func TestRegisterRenderer(t *testing.T) {
+	RegisterRenderer("count", countRenderer{})
+	defer func() {
+		renderersMu.Lock()
+		delete(renderers, "count")
+		renderersMu.Unlock()
+	}()
+
+	renderer, err := LookupRenderer("count")
+	if err != nil {
+		t.Fatalf("LookupRenderer failed: %v", err)
+	}
+	var out strings.Builder
+	renderer.RenderList(&out, NewListView("work", renderTestList(), &Config{}, time.Now()))
+	if out.String() != "work: ***\n" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+
+	if _, err := LookupRenderer("xml"); err == nil || !strings.Contains(err.Error(), "color, count, json, plain, table") {
+		t.Errorf("Expected an error naming the renderers, got %v", err)
+	}
+}
+
+func TestPlainAndColorRenderers(t *testing.T) {
+	now := time.Date(2024, 7, 3, 12, 0, 0, 0, time.Local)
+	view := NewListView("work", renderTestList(), &Config{}, now)
+
+	var plain strings.Builder
+	PlainRenderer{}.RenderList(&plain, view)
+	want := "Now:\n1. [ ] Ship (p1, overdue)\n2. [x] Plan\n\nLater:\n3. [ ] Docs\n\nProgress: 1/3 completed\n"
+	if plain.String() != want {
+		t.Errorf("plain = %q, want %q", plain.String(), want)
+	}
+
+	var color strings.Builder
+	ColorRenderer{}.RenderList(&color, view)
+	for _, line := range []string{"\033[1mNow:\033[0m", "\033[31m1. [ ] Ship (p1, overdue)\033[0m", "\033[32m2. [x] Plan\033[0m", "3. [ ] Docs\n"} {
+		if !strings.Contains(color.String(), line) {
+			t.Errorf("Expected %q in %q", line, color.String())
+		}
+	}
+
+	// Two days before it is due, the item is due soon
+	view.Now = time.Date(2024, 6, 29, 12, 0, 0, 0, time.Local)
+	color.Reset()
+	ColorRenderer{}.RenderList(&color, view)
+	if !strings.Contains(color.String(), "\033[33m1. [ ] Ship (p1, due in 2 days)\033[0m") {
+		t.Errorf("Expected the item to be due soon in %q", color.String())
+	}
+
+	// With colors off the color renderer draws the plain text
+	Color = false
+	t.Cleanup(func() { Color = true })
+	view.Now = now
+	color.Reset()
+	ColorRenderer{}.RenderList(&color, view)
+	if color.String() != want {
+		t.Errorf("Expected no colors, got %q", color.String())
+	}
+
+	overviews := []ListOverview{{Name: "work", Total: 3, Completed: 1, Percent: 33}, {Name: "ux", Weighted: true, Total: 2, Completed: 1, Percent: 75}, {Name: "empty"}}
+	plain.Reset()
+	PlainRenderer{}.RenderOverview(&plain, overviews)
+	want = "  work  - 1/3 completed (33%)\n  ux    - 1/2 completed (75% by weight)\n  empty - No todos\n"
+	if plain.String() != want {
+		t.Errorf("overview = %q, want %q", plain.String(), want)
+	}
+}
+
+func TestJSONRenderer(t *testing.T) {
+	var out strings.Builder
+	JSONRenderer{}.RenderList(&out, NewListView("work", renderTestList(), &Config{}, time.Now()))
+
+	var list struct {
+		Name      string     `json:"name"`
+		Completed int        `json:"completed"`
+		Items     []JSONItem `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &list); err != nil {
+		t.Fatalf("Expected JSON, got %q: %v", out.String(), err)
+	}
+	if list.Name != "work" || list.Completed != 1 || len(list.Items) != 3 {
+		t.Fatalf("Unexpected list %+v", list)
+	}
+	if item := list.Items[0]; item.Due != "2024-07-01" || item.Priority != "p1" || item.Section != "Now" {
+		t.Errorf("Unexpected item %+v", item)
+	}
+	if item := list.Items[1]; item.Estimate != "30m" || !item.Completed {
+		t.Errorf("Unexpected item %+v", item)
+	}
+}
+
+func TestTableRenderer(t *testing.T) {
+	now := time.Date(2024, 7, 3, 12, 0, 0, 0, time.Local)
+	var out strings.Builder
+	TableRenderer{}.RenderList(&out, NewListView("work", renderTestList(), &Config{}, now))
+	want := "#  DONE  ITEM  SECTION  DUE                   PRIORITY  ESTIMATE\n" +
+		"1        Ship  Now      2024-07-01 (overdue)  p1\n" +
+		"2  x     Plan  Now                                      30m\n" +
+		"3        Docs  Later\n"
+	if out.String() != want {
+		t.Errorf("table =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	// Columns nothing has a value for are left out
+	out.Reset()
+	TableRenderer{}.RenderOverview(&out, []ListOverview{{Name: "work", Current: true, Total: 2, Completed: 1, Percent: 50}})
+	want = "LIST            DONE  TOTAL  PERCENT  OVERDUE\nwork (current)  1     2      50%      0\n"
+	if out.String() != want {
+		t.Errorf("overview =\n%q\nwant\n%q", out.String(), want)
+	}
+}
@@ -0,0 +1,166 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metaAdded holds the day an item was added (YYYY-MM-DD). Items added before
+// lists recorded it have none, and count as added before any retro window.
+const metaAdded = "added"
+
+// longestOpenLimit is how many of the longest-open closed items a retro lists
+const longestOpenLimit = 5
+
+// ItemAdded returns the day an item was added, if it was recorded
+func ItemAdded(item TodoItem) (time.Time, bool) {
+	added, err := time.ParseInLocation(DueDateFormat, item.Metadata[metaAdded], time.Local)
+	return added, err == nil
+}
+
+// ParseSprint reads a sprint length such as "2w" or "10d" as a number of
+// days
+func ParseSprint(value string) (int, error) {
+	unit := 1
+	number := value
+	switch {
+	case strings.HasSuffix(value, "w"):
+		unit, number = 7, strings.TrimSuffix(value, "w")
+	case strings.HasSuffix(value, "d"):
+		number = strings.TrimSuffix(value, "d")
+	}
+	count, err := strconv.Atoi(number)
+	if err != nil || count < 1 {
+		return 0, fmt.Errorf("invalid sprint length %q (expected e.g. 2w or 10d)", value)
+	}
+	return count * unit, nil
+}
+
+// RetroItem is an item closed during a retro window, with how long it was
+// open
+type RetroItem struct {
+	ListItem
+	DaysOpen int
+}
+
+// Retro summarizes what happened to the lists during a window of days
+type Retro struct {
+	// From is midnight on the first day of the window, To its end
+	From, To time.Time
+	// Completed holds the items completed in the window, oldest first
+	Completed []ListItem
+	// Added holds the items added in the window, completed or not
+	Added []ListItem
+	// LongestOpen holds the completed items that had been open longest,
+	// for those whose adding day is known
+	LongestOpen []RetroItem
+	// CarryOver holds the items still pending at the end of the window,
+	// oldest first
+	CarryOver []ListItem
+}
+
+// NetChange is how much the backlog grew (or, when negative, shrank)
+func (r *Retro) NetChange() int {
+	return len(r.Added) - len(r.Completed)
+}
+
+// GetRetro collects the retro of the named lists for the days up to and
+// including now's
+func GetRetro(names []string, days int, now time.Time) (*Retro, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	retro := &Retro{From: today.AddDate(0, 0, -(days - 1)), To: now}
+
+	for _, parsed := range ParseLists(names) {
+		if parsed.Err != nil {
+			return nil, parsed.Err
+		}
+		for _, item := range parsed.List.Items {
+			entry := ListItem{List: parsed.Name, Item: item}
+			added, hasAdded := ItemAdded(item)
+			if hasAdded && added.Format(DueDateFormat) >= retro.From.Format(DueDateFormat) {
+				retro.Added = append(retro.Added, entry)
+			}
+
+			if !item.Completed {
+				retro.CarryOver = append(retro.CarryOver, entry)
+				continue
+			}
+			if item.CompletedTime == nil || item.CompletedTime.Before(retro.From) || item.CompletedTime.After(now) {
+				continue
+			}
+			retro.Completed = append(retro.Completed, entry)
+			if hasAdded {
+				retro.LongestOpen = append(retro.LongestOpen, RetroItem{ListItem: entry, DaysOpen: max(DaysLate(added, *item.CompletedTime), 0)})
+			}
+		}
+	}
+
+	sort.SliceStable(retro.Completed, func(i, j int) bool {
+		return retro.Completed[i].Item.CompletedTime.Before(*retro.Completed[j].Item.CompletedTime)
+	})
+	sort.SliceStable(retro.LongestOpen, func(i, j int) bool {
+		return retro.LongestOpen[i].DaysOpen > retro.LongestOpen[j].DaysOpen
+	})
+	if len(retro.LongestOpen) > longestOpenLimit {
+		retro.LongestOpen = retro.LongestOpen[:longestOpenLimit]
+	}
+	sort.SliceStable(retro.CarryOver, func(i, j int) bool {
+		return addedBefore(retro.CarryOver[i].Item, retro.CarryOver[j].Item)
+	})
+	return retro, nil
+}
+
+// addedBefore orders items by the day they were added, those without one
+// first
+func addedBefore(a, b TodoItem) bool {
+	addedA, okA := ItemAdded(a)
+	addedB, okB := ItemAdded(b)
+	if !okA || !okB {
+		return !okA && okB
+	}
+	return addedA.Before(addedB)
+}
+
+// FormatRetro writes a retro as markdown, ready to paste into retro notes
+func FormatRetro(retro *Retro) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Retro %s to %s\n", retro.From.Format(DueDateFormat), retro.To.Format(DueDateFormat))
+
+	b.WriteString("\n## Summary\n\n")
+	fmt.Fprintf(&b, "- Completed: %d\n", len(retro.Completed))
+	fmt.Fprintf(&b, "- Added: %d\n", len(retro.Added))
+	fmt.Fprintf(&b, "- Net backlog change: %+d\n", retro.NetChange())
+	fmt.Fprintf(&b, "- Carried over: %d\n", len(retro.CarryOver))
+
+	b.WriteString("\n## Completed\n\n")
+	for _, entry := range retro.Completed {
+		fmt.Fprintf(&b, "- [x] %s (%s)\n", issueTaskTitle(entry.Item.Text), entry.List)
+	}
+	if len(retro.Completed) == 0 {
+		b.WriteString("Nothing was completed.\n")
+	}
+
+	b.WriteString("\n## Longest open, now closed\n\n")
+	for _, entry := range retro.LongestOpen {
+		fmt.Fprintf(&b, "- %s (%s): open %s\n", issueTaskTitle(entry.Item.Text), entry.List, pluralDays(entry.DaysOpen))
+	}
+	if len(retro.LongestOpen) == 0 {
+		b.WriteString("Nothing to report.\n")
+	}
+
+	b.WriteString("\n## Carry-over\n\n")
+	for _, entry := range retro.CarryOver {
+		line := fmt.Sprintf("- [ ] %s (%s)", issueTaskTitle(entry.Item.Text), entry.List)
+		if added, ok := ItemAdded(entry.Item); ok {
+			line += ": open since " + added.Format(DueDateFormat)
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(retro.CarryOver) == 0 {
+		b.WriteString("Nothing carries over.\n")
+	}
+	return b.String()
+}
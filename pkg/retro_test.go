@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addedToday is the added stamp of items added by a test
+func addedToday() string {
+	return time.Now().Format(DueDateFormat)
+}
+
+func TestAddItemRecordsAddedDay(t *testing.T) {
+	setupTestDir(t)
+	AddTodoItem("main", "Write notes")
+
+	// The day is only recorded once insights are on
+	todoList, _ := ParseTodoFile("main")
+	if _, ok := ItemAdded(todoList.Items[0]); ok {
+		t.Errorf("Expected no added day with insights off, got %v", todoList.Items[0].Metadata)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Insights = true
+	SaveConfig(cfg)
+	AddTodoItem("main", "Read notes")
+
+	todoList, _ = ParseTodoFile("main")
+	added, ok := ItemAdded(todoList.Items[1])
+	if !ok || added.Format(DueDateFormat) != addedToday() {
+		t.Errorf("Expected the item to be added today, got %v (%v)", added, ok)
+	}
+}
+
+func TestParseSprint(t *testing.T) {
+	for value, want := range map[string]int{"2w": 14, "10d": 10, "5": 5} {
+		if got, err := ParseSprint(value); err != nil || got != want {
+			t.Errorf("ParseSprint(%q) = %d, %v; want %d", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "w", "0d", "-1w", "2 weeks"} {
+		if _, err := ParseSprint(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestGetRetro(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	content := `# Todo List for web
+
+- [x] Old and done <!-- added: 2026-01-01; completed: "2026-03-10 10:00" -->
+- [x] Quick fix <!-- added: 2026-03-25; completed: "2026-03-26 09:00" -->
+- [x] Long haul <!-- added: 2026-02-01; completed: "2026-03-28 17:00" -->
+- [x] Untracked <!-- completed: "2026-03-29 12:00" -->
+- [ ] Legacy item
+- [ ] New idea <!-- added: 2026-03-27 -->
+- [ ] Backlog item <!-- added: 2026-02-15 -->
+`
+	os.WriteFile(GetTodoFilePath("web"), []byte(content), 0644)
+
+	now := time.Date(2026, 3, 30, 18, 0, 0, 0, time.UTC)
+	retro, err := GetRetro([]string{"web"}, 14, now)
+	if err != nil {
+		t.Fatalf("GetRetro failed: %v", err)
+	}
+
+	if got := retro.From.Format(DueDateFormat); got != "2026-03-17" {
+		t.Errorf("Window starts %s", got)
+	}
+	if len(retro.Completed) != 3 || retro.Completed[0].Item.Text != "Quick fix" {
+		t.Errorf("Unexpected completed items %+v", retro.Completed)
+	}
+	if len(retro.Added) != 2 || retro.NetChange() != -1 {
+		t.Errorf("Unexpected added items %+v", retro.Added)
+	}
+	if len(retro.LongestOpen) != 2 || retro.LongestOpen[0].Item.Text != "Long haul" || retro.LongestOpen[0].DaysOpen != 55 {
+		t.Errorf("Unexpected longest open items %+v", retro.LongestOpen)
+	}
+	if len(retro.CarryOver) != 3 || retro.CarryOver[0].Item.Text != "Legacy item" || retro.CarryOver[2].Item.Text != "New idea" {
+		t.Errorf("Unexpected carry-over %+v", retro.CarryOver)
+	}
+
+	markdown := FormatRetro(retro)
+	for _, want := range []string{
+		"# Retro 2026-03-17 to 2026-03-30\n",
+		"- Completed: 3\n- Added: 2\n- Net backlog change: -1\n- Carried over: 3\n",
+		"## Completed\n\n- [x] Quick fix (web)\n- [x] Long haul (web)\n- [x] Untracked (web)\n",
+		"- Long haul (web): open 55 days\n- Quick fix (web): open 1 day\n",
+		"- [ ] Legacy item (web)\n- [ ] Backlog item (web): open since 2026-02-15\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Expected %q in:\n%s", want, markdown)
+		}
+	}
+}
+
+func TestFormatRetroEmpty(t *testing.T) {
+	now := time.Date(2026, 3, 30, 18, 0, 0, 0, time.UTC)
+	markdown := FormatRetro(&Retro{From: now, To: now})
+	for _, want := range []string{"Net backlog change: +0", "Nothing was completed.", "Nothing to report.", "Nothing carries over."} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Expected %q in:\n%s", want, markdown)
+		}
+	}
+}
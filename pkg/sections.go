@@ -0,0 +1,81 @@
+package pkg
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Sections returns the names of the list's sections, the `## ` headings of
+// its file, in the order they appear. A section with no items is included.
+func (l *TodoList) Sections() []string {
+	sections := slices.Clone(l.Headings)
+	for _, item := range l.Items {
+		if item.Section != "" && !slices.Contains(sections, item.Section) {
+			sections = append(sections, item.Section)
+		}
+	}
+	return sections
+}
+
+// section returns the name of the list's section matching name in any
+// case, or "" when there is none
+func (l *TodoList) section(name string) string {
+	for _, section := range l.Sections() {
+		if strings.EqualFold(section, name) {
+			return section
+		}
+	}
+	return ""
+}
+
+// AddItemToSection adds a pending item at the end of a section, starting
+// the section at the end of the list when it doesn't exist yet. An existing
+// section is matched in any case. It returns the item's ID.
+func (s *Store) AddItemToSection(listName, section, text string) (int, error) {
+	section = strings.TrimSpace(section)
+	if section == "" {
+		return 0, fmt.Errorf("section name can't be empty")
+	}
+	todoList, err := s.Get(listName)
+	if err != nil {
+		return 0, err
+	}
+	if existing := todoList.section(section); existing != "" {
+		section = existing
+	}
+	if !slices.Contains(todoList.Headings, section) {
+		todoList.Headings = append(todoList.Headings, section)
+	}
+
+	// The item goes after the last one of its section, or, for a section
+	// with no items yet, after those of the sections before it
+	position := len(todoList.Items)
+	before := todoList.Headings[:slices.Index(todoList.Headings, section)]
+	filled := false
+	for i, item := range todoList.Items {
+		if item.Section == section {
+			position, filled = i+1, true
+		}
+	}
+	if !filled {
+		position = 0
+		for i, item := range todoList.Items {
+			if item.Section == "" || slices.Contains(before, item.Section) {
+				position = i + 1
+			}
+		}
+	}
+
+	_, item, err := s.newItem(listName, text)
+	if err != nil {
+		return 0, err
+	}
+	item.Section = section
+	todoList.Items = slices.Insert(todoList.Items, position, item)
+	for i := range todoList.Items {
+		todoList.Items[i].ID = i + 1
+	}
+	s.MarkDirty(listName)
+	return position + 1, nil
+}
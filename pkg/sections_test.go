@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAddItemToSection(t *testing.T) {
+	setupTestDir(t)
+	store := NewStore()
+	store.AddItem("app", "Loose end")
+	if _, err := store.AddItemToSection("app", "", "Nowhere"); err == nil {
+		t.Error("Expected an empty section name to fail")
+	}
+	for _, add := range []struct{ section, text string }{
+		{"Backend", "API"},
+		{"Frontend", "Form"},
+		{"backend", "Database"},
+	} {
+		if _, err := store.AddItemToSection("app", add.section, add.text); err != nil {
+			t.Fatalf("AddItemToSection(%q) failed: %v", add.section, err)
+		}
+	}
+	itemID, err := store.AddItemUnder("app", 2, "Pagination")
+	if err != nil {
+		t.Fatalf("AddItemUnder failed: %v", err)
+	}
+	// The next item in the section goes after the subtask
+	if itemID, _ = store.AddItemToSection("app", "Backend", "Auth"); itemID != 5 {
+		t.Errorf("Expected Auth to be item 5, got %d", itemID)
+	}
+	store.Flush()
+
+	list, _ := ParseTodoFile("app")
+	var got []string
+	for _, item := range list.Items {
+		got = append(got, item.Section+": "+item.Text)
+	}
+	want := []string{": Loose end", "Backend: API", "Backend: Pagination", "Backend: Database", "Backend: Auth", "Frontend: Form"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if sections := list.Sections(); !reflect.DeepEqual(sections, []string{"Backend", "Frontend"}) {
+		t.Errorf("Expected sections Backend and Frontend, got %v", sections)
+	}
+	if list.Parent(3) != 2 {
+		t.Errorf("Expected Pagination to stay under API, got parent %d", list.Parent(3))
+	}
+
+	var out strings.Builder
+	PlainRenderer{}.RenderList(&out, NewListView("app", list, DefaultConfig(), Now()))
+	if !strings.Contains(out.String(), "Backend:\n2. [ ] API") {
+		t.Errorf("Expected items grouped under their section:\n%s", out.String())
+	}
+}
+
+func TestEmptySectionsSurvive(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+
+	content := `# Todo List for app
+
+## Backend
+
+- [ ] API
+
+## Design
+
+## Frontend
+
+- [ ] Form
+
+## Later
+
+`
+	os.WriteFile(GetTodoFilePath("app"), []byte(content), 0644)
+	list, _ := ParseTodoFile("app")
+	if sections := list.Sections(); !reflect.DeepEqual(sections, []string{"Backend", "Design", "Frontend", "Later"}) {
+		t.Errorf("Expected the empty sections too, got %v", sections)
+	}
+
+	// Writing the list keeps the empty headings where they were
+	WriteTodoFile("app", list)
+	if written, _ := os.ReadFile(GetTodoFilePath("app")); string(written) != content {
+		t.Errorf("Expected the empty headings to survive a write, got:\n%s", written)
+	}
+
+	// An empty section is filled where its heading stands
+	store := NewStore()
+	if itemID, _ := store.AddItemToSection("app", "design", "Mockups"); itemID != 2 {
+		t.Errorf("Expected Mockups to be item 2, got %d", itemID)
+	}
+	store.Flush()
+	list, _ = ParseTodoFile("app")
+	if item := list.Items[1]; item.Text != "Mockups" || item.Section != "Design" {
+		t.Errorf("Expected Mockups in Design, got %+v", item)
+	}
+}
@@ -0,0 +1,186 @@
+package pkg
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Snapshots record the completion percentage of each list once a day in
+// .todo/snapshots.json, so 'todo list' can show how a list got where it is.
+// They are taken whenever lists are looked at or watched: by the list
+// overview, 'todo tick' and the daemon. A day's snapshot is overwritten
+// until the day is over, so it ends up holding the last percentage seen.
+
+// snapshotRetention is how many days of snapshots are kept
+const snapshotRetention = 90
+
+// DefaultSparklineDays is how many days of progress 'todo list' shows
+const DefaultSparklineDays = 14
+
+// sparkLevels draw percentages from 0 to 100
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// Snapshots maps list names to their completion percentage by day
+// (YYYY-MM-DD)
+type Snapshots map[string]map[string]int
+
+func GetSnapshotsPath() string {
+	return filepath.Join(".todo", "snapshots.json")
+}
+
+// LoadSnapshots reads the recorded snapshots. Snapshots are only history,
+// so a missing or unreadable file gives an empty set.
+func LoadSnapshots() Snapshots {
+	snapshots := make(Snapshots)
+	if content, err := os.ReadFile(GetSnapshotsPath()); err == nil {
+		if json.Unmarshal(content, &snapshots) != nil {
+			return make(Snapshots)
+		}
+	}
+	return snapshots
+}
+
+// RecordSnapshots records today's completion percentage of every list, and
+// forgets lists that are gone and days past the retention period. The file
+// is only written when something changed.
+func RecordSnapshots(lists []ParsedList, cfg *Config, now time.Time) error {
+	snapshots := LoadSnapshots()
+	today := now.Format(DueDateFormat)
+	oldest := now.AddDate(0, 0, -snapshotRetention).Format(DueDateFormat)
+	changed := false
+
+	// Snapshots follow the configured progress mode, not one command's
+	// --raw override
+	weighted := cfg.Display.Progress != ProgressRaw
+	current := make(map[string]bool)
+	for _, parsed := range lists {
+		current[parsed.Name] = true
+		if parsed.Err != nil {
+			continue
+		}
+
+		percent := parsed.List.Progress().Percent(weighted)
+		days, ok := snapshots[parsed.Name]
+		if !ok {
+			days = make(map[string]int)
+			snapshots[parsed.Name] = days
+		}
+		if recorded, ok := days[today]; !ok || recorded != percent {
+			days[today] = percent
+			changed = true
+		}
+	}
+
+	for name, days := range snapshots {
+		if !current[name] {
+			delete(snapshots, name)
+			changed = true
+			continue
+		}
+		for day := range days {
+			if day < oldest {
+				delete(days, day)
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	content, err := json.Marshal(snapshots)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(GetSnapshotsPath(), content)
+}
+
+// RecordAllSnapshots reads every list and records today's snapshots
+func RecordAllSnapshots(cfg *Config, now time.Time) error {
+	names, err := GetAllLists()
+	if err != nil {
+		return err
+	}
+	return RecordSnapshots(ParseLists(names), cfg, now)
+}
+
+// Series returns a list's percentage on each of the last days days, oldest
+// first and ending today. Days without a snapshot take the one before them;
+// days before the first snapshot are -1.
+func (s Snapshots) Series(listName string, days int, now time.Time) []int {
+	recorded := s[listName]
+	series := make([]int, days)
+	last := -1
+	// Start from the newest snapshot older than the window, if any
+	start := now.AddDate(0, 0, -(days - 1)).Format(DueDateFormat)
+	lastDay := ""
+	for day, percent := range recorded {
+		if day < start && day > lastDay {
+			last, lastDay = percent, day
+		}
+	}
+
+	for i := range series {
+		day := now.AddDate(0, 0, i-(days-1)).Format(DueDateFormat)
+		if percent, ok := recorded[day]; ok {
+			last = percent
+		}
+		series[i] = last
+	}
+	return series
+}
+
+// Sparkline draws percentages as a line of block characters, one per value,
+// with a space for unknown (negative) values
+func Sparkline(values []int) string {
+	var b strings.Builder
+	for _, value := range values {
+		if value < 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		level := min(value, 100) * (len(sparkLevels) - 1) / 100
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
+
+// SparklineDays returns how many days of progress the list overview shows,
+// 0 when sparklines are turned off
+func SparklineDays(cfg *Config) int {
+	switch days := cfg.Display.SparklineDays; {
+	case days < 0:
+		return 0
+	case days == 0:
+		return DefaultSparklineDays
+	default:
+		return days
+	}
+}
+
+// listSparklines returns the sparkline of each list over the configured
+// number of days, or nil when no list has more than one day of history yet
+func listSparklines(lists []ParsedList, cfg *Config, now time.Time) map[string]string {
+	days := SparklineDays(cfg)
+	if days == 0 || Detail == DetailMinimal {
+		return nil
+	}
+
+	snapshots := LoadSnapshots()
+	sparklines := make(map[string]string)
+	history := false
+	for _, parsed := range lists {
+		series := snapshots.Series(parsed.Name, days, now)
+		if len(series) > 1 && series[len(series)-2] >= 0 {
+			history = true
+		}
+		sparklines[parsed.Name] = Sparkline(series)
+	}
+	if !history {
+		return nil
+	}
+	return sparklines
+}
@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordSnapshots(t *testing.T) {
+	setupTestDir(t)
+	AddTodoItem("ops", "Patch hosts")
+	AddTodoItem("ops", "Rotate keys")
+	CheckTodoItem("ops", 1)
+
+	// A day past the retention period and a list that was deleted
+	content := `{"ops":{"2025-12-01":10,"2026-03-01":20},"gone":{"2026-03-01":50}}`
+	if err := os.WriteFile(GetSnapshotsPath(), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 3, 30, 9, 0, 0, 0, time.Local)
+	cfg := &Config{}
+	if err := RecordAllSnapshots(cfg, now); err != nil {
+		t.Fatalf("RecordAllSnapshots failed: %v", err)
+	}
+
+	want := Snapshots{"ops": {"2026-03-01": 20, "2026-03-30": 50}}
+	if got := LoadSnapshots(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshots = %v, want %v", got, want)
+	}
+
+	// Later the same day, the day's snapshot is overwritten
+	CheckTodoItem("ops", 2)
+	RecordAllSnapshots(cfg, now.Add(time.Hour))
+	if got := LoadSnapshots()["ops"]["2026-03-30"]; got != 100 {
+		t.Errorf("Expected today's snapshot to be 100, got %d", got)
+	}
+}
+
+func TestSnapshotSeries(t *testing.T) {
+	now := time.Date(2026, 3, 30, 9, 0, 0, 0, time.Local)
+	snapshots := Snapshots{"ops": {"2026-03-20": 10, "2026-03-27": 40, "2026-03-29": 60}}
+
+	got := snapshots.Series("ops", 5, now)
+	want := []int{10, 40, 40, 60, 60}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Series = %v, want %v", got, want)
+	}
+	if got := snapshots.Series("new", 3, now); !reflect.DeepEqual(got, []int{-1, -1, -1}) {
+		t.Errorf("Series of a list without snapshots = %v", got)
+	}
+	snapshots["new"] = map[string]int{"2026-03-29": 0}
+	if got := snapshots.Series("new", 3, now); !reflect.DeepEqual(got, []int{-1, 0, 0}) {
+		t.Errorf("Series = %v", got)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := Sparkline([]int{-1, 0, 14, 50, 99, 100}); got != " ▁▁▄▇█" {
+		t.Errorf("Sparkline = %q", got)
+	}
+}
+
+func TestSparklineDays(t *testing.T) {
+	for days, want := range map[int]int{0: DefaultSparklineDays, 7: 7, -1: 0} {
+		cfg := &Config{Display: DisplayConfig{SparklineDays: days}}
+		if got := SparklineDays(cfg); got != want {
+			t.Errorf("SparklineDays(%d) = %d, want %d", days, got, want)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package pkg
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	// Registers the "sqlite" driver, written in Go so that builds without
+	// cgo, such as the released binaries, can use SQLite storage
+	_ "modernc.org/sqlite"
+)
+
+// Storage settings: where the lists are kept
+const (
+	// StorageMarkdown keeps each list in .todo/<list>.md (the default)
+	StorageMarkdown = "markdown"
+	// StorageSQLite keeps every list in .todo/todo.db
+	StorageSQLite = "sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS lists (
+	name     TEXT PRIMARY KEY,
+	meta     TEXT NOT NULL DEFAULT '',
+	modified TEXT NOT NULL DEFAULT '',
+	headings TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS items (
+	list         TEXT NOT NULL REFERENCES lists(name) ON DELETE CASCADE,
+	position     INTEGER NOT NULL,
+	text         TEXT NOT NULL,
+	completed    INTEGER NOT NULL DEFAULT 0,
+	completed_at TEXT,
+	due          TEXT,
+	section      TEXT NOT NULL DEFAULT '',
+	depth        INTEGER NOT NULL DEFAULT 0,
+	metadata     TEXT NOT NULL DEFAULT '{}',
+	notes        TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (list, position)
+);
+`
+
+func GetDatabasePath() string {
+	return filepath.Join(".todo", "todo.db")
+}
+
+// UsingSQLite reports whether the config keeps the lists in .todo/todo.db.
+// A config that can't be read counts as markdown; the commands that load
+// it report the error.
+func UsingSQLite() bool {
+	cfg, err := LoadConfig()
+	return err == nil && cfg.Storage == StorageSQLite
+}
+
+// openDatabase opens .todo/todo.db, creating it when it doesn't exist. A
+// new database starts with the lists already kept as markdown, so
+// switching storage doesn't lose them.
+func openDatabase() (*sql.DB, error) {
+	if err := EnsureTodoDirectory(); err != nil {
+		return nil, fmt.Errorf("failed to create .todo directory: %w", err)
+	}
+	_, statErr := os.Stat(GetDatabasePath())
+	db, err := sql.Open("sqlite", GetDatabasePath()+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := migrateDatabase(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if os.IsNotExist(statErr) {
+		if err := importMarkdownLists(db); err != nil {
+			db.Close()
+			os.Remove(GetDatabasePath())
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// migrateDatabase adds the columns a database created by an earlier
+// version lacks
+func migrateDatabase(db *sql.DB) error {
+	migrations := []struct{ table, column, definition string }{
+		{"items", "notes", `TEXT NOT NULL DEFAULT ''`},
+		{"lists", "modified", `TEXT NOT NULL DEFAULT ''`},
+		{"lists", "headings", `TEXT NOT NULL DEFAULT ''`},
+	}
+	for _, migration := range migrations {
+		columns, err := tableColumns(db, migration.table)
+		if err != nil {
+			return err
+		}
+		if columns[migration.column] {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE ` + migration.table + ` ADD COLUMN ` + migration.column + ` ` + migration.definition); err != nil {
+			return fmt.Errorf("failed to upgrade database: %w", err)
+		}
+	}
+	return nil
+}
+
+// tableColumns returns the names of the columns of a table
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database schema: %w", err)
+	}
+	defer rows.Close()
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to read database schema: %w", err)
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// importMarkdownLists copies the markdown list files into the database
+func importMarkdownLists(db *sql.DB) error {
+	names, err := markdownLists()
+	if err != nil {
+		return err
+	}
+	lists := make(map[string]*TodoList)
+	for _, name := range names {
+		todoList, err := parseTodoFileAt(GetTodoFilePath(name))
+		if err != nil {
+			return fmt.Errorf("failed to import list '%s': %w", name, err)
+		}
+		lists[name] = todoList
+	}
+	return writeListsSQLite(db, lists)
+}
+
+// writeListsSQLite replaces the items of each list in one transaction
+func writeListsSQLite(db *sql.DB, lists map[string]*TodoList) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to write database: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lists record when they were written, as a list file's modification
+	// time would
+	modified := time.Now().UTC().Format(time.RFC3339Nano)
+	for name, todoList := range lists {
+		meta := ""
+		if !todoList.Meta.IsZero() {
+			encoded, err := yaml.Marshal(todoList.Meta)
+			if err != nil {
+				return fmt.Errorf("failed to encode list metadata: %w", err)
+			}
+			meta = string(encoded)
+		}
+		if _, err := tx.Exec(`INSERT INTO lists (name, meta, modified, headings) VALUES (?, ?, ?, ?)
+			ON CONFLICT (name) DO UPDATE SET meta = excluded.meta, modified = excluded.modified, headings = excluded.headings`,
+			name, meta, modified, strings.Join(todoList.Headings, "\n")); err != nil {
+			return fmt.Errorf("failed to write list '%s': %w", name, err)
+		}
+		if _, err := tx.Exec(`DELETE FROM items WHERE list = ?`, name); err != nil {
+			return fmt.Errorf("failed to write list '%s': %w", name, err)
+		}
+
+		depths := todoList.depths()
+		for i, item := range todoList.Items {
+			// The completion time and due date get columns of their own, so
+			// the rest of the metadata is what the list file would hold
+			metadata := itemMetadata(item)
+			delete(metadata, metaCompleted)
+			delete(metadata, metaDue)
+			encoded, err := json.Marshal(metadata)
+			if err != nil {
+				return fmt.Errorf("failed to encode item metadata: %w", err)
+			}
+			var completedAt, due sql.NullString
+			if item.Completed && item.CompletedTime != nil {
+				completedAt = sql.NullString{String: item.CompletedTime.Format(completedTimeFormat), Valid: true}
+			}
+			if item.DueDate != nil {
+				due = sql.NullString{String: item.DueDate.Format(DueDateFormat), Valid: true}
+			}
+			if _, err := tx.Exec(`INSERT INTO items (list, position, text, completed, completed_at, due, section, depth, metadata, notes)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				name, i+1, item.Text, item.Completed, completedAt, due, item.Section, depths[i], string(encoded), strings.Join(item.Notes, "\n")); err != nil {
+				return fmt.Errorf("failed to write list '%s': %w", name, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to write database: %w", err)
+	}
+	return nil
+}
+
+// readListSQLite reads a list from the database. A list that doesn't exist
+// reads as empty, like a missing list file.
+func readListSQLite(name string) (*TodoList, error) {
+	db, err := openDatabase()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	todoList := &TodoList{Items: []TodoItem{}}
+	var meta, headings string
+	err = db.QueryRow(`SELECT meta, headings FROM lists WHERE name = ?`, name).Scan(&meta, &headings)
+	if err == sql.ErrNoRows {
+		return todoList, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read list '%s': %w", name, err)
+	}
+	if meta != "" {
+		if err := yaml.Unmarshal([]byte(meta), &todoList.Meta); err != nil {
+			return nil, fmt.Errorf("failed to parse metadata of list '%s': %w", name, err)
+		}
+	}
+	if headings != "" {
+		todoList.Headings = strings.Split(headings, "\n")
+	}
+
+	rows, err := db.Query(`SELECT text, completed, completed_at, due, section, depth, metadata, notes
+		FROM items WHERE list = ? ORDER BY position`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read list '%s': %w", name, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item TodoItem
+		var completedAt, due sql.NullString
+		var metadata, notes string
+		if err := rows.Scan(&item.Text, &item.Completed, &completedAt, &due, &item.Section, &item.Depth, &metadata, &notes); err != nil {
+			return nil, fmt.Errorf("failed to read list '%s': %w", name, err)
+		}
+		if notes != "" {
+			item.Notes = strings.Split(notes, "\n")
+		}
+		meta := make(map[string]string)
+		if err := json.Unmarshal([]byte(metadata), &meta); err != nil {
+			return nil, fmt.Errorf("failed to parse item metadata in list '%s': %w", name, err)
+		}
+		if completedAt.Valid {
+			meta[metaCompleted] = completedAt.String
+		}
+		if due.Valid {
+			meta[metaDue] = due.String
+		}
+		applyMetadata(&item, meta)
+		item.ID = len(todoList.Items) + 1
+		todoList.Items = append(todoList.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read list '%s': %w", name, err)
+	}
+	return todoList, nil
+}
+
+// flushSQLite writes changed lists to the database
+func flushSQLite(lists map[string]*TodoList) error {
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return writeListsSQLite(db, lists)
+}
+
+// sqliteLists returns the names of the lists in the database
+func sqliteLists() ([]string, error) {
+	db, err := openDatabase()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT name FROM lists ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lists: %w", err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to read lists: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// sqliteListExists reports whether the database has a list
+func sqliteListExists(name string) bool {
+	db, err := openDatabase()
+	if err != nil {
+		return false
+	}
+	defer db.Close()
+	var found int
+	return db.QueryRow(`SELECT 1 FROM lists WHERE name = ?`, name).Scan(&found) == nil
+}
+
+// sqliteListModified returns when a list was last written to the
+// database, or the zero time if it doesn't exist
+func sqliteListModified(name string) time.Time {
+	db, err := openDatabase()
+	if err != nil {
+		return time.Time{}
+	}
+	defer db.Close()
+	var modified string
+	if db.QueryRow(`SELECT modified FROM lists WHERE name = ?`, name).Scan(&modified) != nil {
+		return time.Time{}
+	}
+	when, _ := time.Parse(time.RFC3339Nano, modified)
+	return when
+}
+
+// createListSQLite adds an empty list to the database, leaving one that
+// already exists alone
+func createListSQLite(name string) error {
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	modified := time.Now().UTC().Format(time.RFC3339Nano)
+	if _, err := db.Exec(`INSERT OR IGNORE INTO lists (name, modified) VALUES (?, ?)`, name, modified); err != nil {
+		return fmt.Errorf("failed to create list '%s': %w", name, err)
+	}
+	return nil
+}
+
+// deleteListSQLite removes a list and its items from the database
+func deleteListSQLite(name string) error {
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	result, err := db.Exec(`DELETE FROM lists WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete list '%s': %w", name, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("list '%s' does not exist", name)
+	}
+	return nil
+}
+
+// ExportMarkdown writes every list in the database to its .todo/<list>.md
+// file, returning the names of the lists written
+func ExportMarkdown() ([]string, error) {
+	if !UsingSQLite() {
+		return nil, fmt.Errorf("lists are already kept as markdown (set storage: %s in .todo/config.yaml to use the database)", StorageSQLite)
+	}
+	names, err := sqliteLists()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		todoList, err := readListSQLite(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeTodoFileAt(GetTodoFilePath(name), todoFileTitle(name), todoList); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
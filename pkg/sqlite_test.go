@@ -0,0 +1,176 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStorage(t *testing.T) {
+	setupTestDir(t)
+
+	// A list kept as markdown before the switch is imported
+	store := NewStore()
+	store.AddItem("auth", "Login form")
+	store.Flush()
+	if err := SaveConfig(&Config{Storage: StorageSQLite}); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	if !TodoFileExists("auth") {
+		t.Fatal("Expected the markdown list to be imported into the database")
+	}
+	os.Remove(GetTodoFilePath("auth"))
+
+	due := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	completed := time.Date(2026, 2, 20, 9, 30, 0, 0, time.Local)
+	want := &TodoList{
+		Meta: ListMeta{Target: "2026-04-01", Owner: "sam"},
+		Items: []TodoItem{
+			{ID: 1, Text: "Login form", Notes: []string{"Use the design from the wiki", "Ask about SSO"}},
+			{ID: 2, Text: "Remember me", Depth: 1, Priority: 1, DueDate: &due, Metadata: map[string]string{"linear": "ENG-12"}},
+			{ID: 3, Text: "Invoices", Section: "Billing", Completed: true, CompletedTime: &completed, Weight: 3, Estimate: 2 * time.Hour},
+		},
+		Headings: []string{"Billing", "Later"},
+	}
+	if err := WriteTodoFile("auth", want); err != nil {
+		t.Fatalf("WriteTodoFile failed: %v", err)
+	}
+	if _, err := os.Stat(GetTodoFilePath("auth")); !os.IsNotExist(err) {
+		t.Error("Expected no list file to be written")
+	}
+
+	got, err := ParseTodoFile("auth")
+	if err != nil {
+		t.Fatalf("ParseTodoFile failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the list to round-trip:\n got %+v\nwant %+v", got, want)
+	}
+
+	if err := CreateTodoFile("billing"); err != nil {
+		t.Fatalf("CreateTodoFile failed: %v", err)
+	}
+	if lists, _ := GetAllLists(); !reflect.DeepEqual(lists, []string{"auth", "billing"}) {
+		t.Errorf("Expected lists auth and billing, got %v", lists)
+	}
+	counts, err := CountItems([]string{"auth", "billing"}, due.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("CountItems failed: %v", err)
+	}
+	if counts != (ItemCounts{Pending: 2, Completed: 1, Overdue: 1}) {
+		t.Errorf("Unexpected counts %+v", counts)
+	}
+
+	if err := DeleteList("billing"); err != nil {
+		t.Fatalf("DeleteList failed: %v", err)
+	}
+	if TodoFileExists("billing") {
+		t.Error("Expected billing to be deleted")
+	}
+	if _, err := EditTodoFile("auth"); err == nil {
+		t.Error("Expected editing a list to fail with sqlite storage")
+	}
+}
+
+func TestSQLiteStorageDaemonAndBundle(t *testing.T) {
+	dir := setupTestDir(t)
+	if err := SaveConfig(&Config{Storage: StorageSQLite}); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	AddTodoItem("auth", "Login form (due: 2000-01-01)")
+	AddTodoItem("billing", "Invoices")
+	AddTodoItem("docs", "Write the guide")
+
+	// The daemon reads the lists from the database
+	startDaemon(t)
+	var counts ItemCounts
+	if err := AskDaemon(&counts, "count", "auth", "billing", "docs"); err != nil || counts.Pending != 3 {
+		t.Errorf("count = %+v, %v", counts, err)
+	}
+	var agenda []ListItem
+	if err := AskDaemon(&agenda, "agenda"); err != nil || len(agenda) != 1 || agenda[0].Item.Text != "Login form" {
+		t.Errorf("agenda = %+v, %v", agenda, err)
+	}
+	AddTodoItem("docs", "Review")
+	if err := AskDaemon(&counts, "count", "docs"); err != nil || counts.Pending != 2 {
+		t.Errorf("count after a change = %+v, %v", counts, err)
+	}
+	if ListModTime("docs").IsZero() {
+		t.Error("Expected the database to record when docs was written")
+	}
+
+	// Bundles hold the list as its file would, and import into the database
+	path := filepath.Join(dir, "auth.todo")
+	if err := ExportBundle("auth", path); err != nil {
+		t.Fatalf("ExportBundle failed: %v", err)
+	}
+	bundle, err := ReadBundle(path)
+	if err != nil {
+		t.Fatalf("ReadBundle failed: %v", err)
+	}
+	if !strings.Contains(bundle.Content, "# Todo List for auth") || !strings.Contains(bundle.Content, "- [ ] Login form") {
+		t.Errorf("Unexpected bundled list:\n%s", bundle.Content)
+	}
+	if _, err := ImportBundle(bundle, "login"); err != nil {
+		t.Fatalf("ImportBundle failed: %v", err)
+	}
+	if _, err := os.Stat(GetTodoFilePath("login")); !os.IsNotExist(err) {
+		t.Error("Expected no list file to be written")
+	}
+	login, err := ParseTodoFile("login")
+	if err != nil || len(login.Items) != 1 || login.Items[0].Text != "Login form" || login.Items[0].DueDate == nil {
+		t.Errorf("Expected the bundled list in the database, got %+v (%v)", login, err)
+	}
+}
+
+func TestExportMarkdown(t *testing.T) {
+	setupTestDir(t)
+	if _, err := ExportMarkdown(); err == nil {
+		t.Error("Expected exporting to fail with markdown storage")
+	}
+
+	SaveConfig(&Config{Storage: StorageSQLite})
+	store := NewStore()
+	store.AddItem("auth", "Login form")
+	store.AddItemUnder("auth", 1, "Remember me")
+	store.CheckItem("auth", 2)
+	store.Flush()
+
+	names, err := ExportMarkdown()
+	if err != nil {
+		t.Fatalf("ExportMarkdown failed: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"auth"}) {
+		t.Errorf("Expected auth to be exported, got %v", names)
+	}
+	content, err := os.ReadFile(GetTodoFilePath("auth"))
+	if err != nil {
+		t.Fatalf("Expected a list file: %v", err)
+	}
+	if !strings.Contains(string(content), "- [ ] Login form\n") {
+		t.Errorf("Unexpected list file:\n%s", content)
+	}
+
+	// The exported file reads back as the same list, apart from the line
+	// numbers only a file has
+	exported, _ := parseTodoFileAt(GetTodoFilePath("auth"))
+	for i := range exported.Items {
+		exported.Items[i].Line = 0
+	}
+	stored, _ := ParseTodoFile("auth")
+	if !reflect.DeepEqual(exported, stored) {
+		t.Errorf("Expected the list file to match the database:\n got %+v\nwant %+v", exported, stored)
+	}
+}
+
+func TestStorageSetting(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	os.WriteFile(GetConfigPath(), []byte("storage: postgres\n"), 0644)
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "invalid storage setting") {
+		t.Errorf("Expected an invalid storage setting error, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Standup is what was done yesterday and what is up for today
+type Standup struct {
+	// Done holds the items completed yesterday, oldest first
+	Done []ListItem
+	// Today holds the pending items of the current list and pending items
+	// of other lists due today or earlier
+	Today []ListItem
+}
+
+// GetStandup collects the standup for the lists in the current directory
+func GetStandup(now time.Time) (*Standup, error) {
+	lists, err := GetAllLists()
+	if err != nil {
+		return nil, err
+	}
+	currentList, err := GetCurrentList()
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	yesterday := today.AddDate(0, 0, -1)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	standup := &Standup{}
+	for _, parsed := range ParseLists(lists) {
+		if parsed.Err != nil {
+			return nil, parsed.Err
+		}
+		for _, item := range parsed.List.Items {
+			entry := ListItem{List: parsed.Name, Item: item}
+			switch {
+			case item.Completed:
+				if item.CompletedTime != nil && !item.CompletedTime.Before(yesterday) && item.CompletedTime.Before(today) {
+					standup.Done = append(standup.Done, entry)
+				}
+			case parsed.Name == currentList || (item.DueDate != nil && item.DueDate.Before(tomorrow)):
+				standup.Today = append(standup.Today, entry)
+			}
+		}
+	}
+
+	sort.SliceStable(standup.Done, func(i, j int) bool {
+		return standup.Done[i].Item.CompletedTime.Before(*standup.Done[j].Item.CompletedTime)
+	})
+	return standup, nil
+}
+
+// WorkspaceStandup is the standup of one registered workspace
+type WorkspaceStandup struct {
+	Path    string
+	Standup *Standup
+	Err     error
+}
+
+// GetWorkspaceStandups collects the standup of every registered workspace.
+// A workspace that can't be read is reported in its Err rather than failing
+// the others.
+func GetWorkspaceStandups(now time.Time) ([]WorkspaceStandup, error) {
+	workspaces, err := LoadWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+
+	originalDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	defer os.Chdir(originalDir)
+
+	var standups []WorkspaceStandup
+	for _, workspace := range workspaces {
+		result := WorkspaceStandup{Path: workspace}
+		if _, err := os.Stat(workspace); err != nil {
+			result.Err = fmt.Errorf("workspace not found")
+		} else if err := os.Chdir(workspace); err != nil {
+			result.Err = err
+		} else {
+			result.Standup, result.Err = GetStandup(now)
+		}
+		standups = append(standups, result)
+	}
+	return standups, nil
+}
@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeStandupList writes a list with one item completed at each of the
+// given times and the given pending items
+func writeStandupList(t *testing.T, name string, completed []time.Time, pending []TodoItem) {
+	list := &TodoList{}
+	for i := range completed {
+		list.Items = append(list.Items, TodoItem{Text: "Done " + completed[i].Format(time.DateOnly), Completed: true, CompletedTime: &completed[i]})
+	}
+	list.Items = append(list.Items, pending...)
+	if err := WriteTodoFile(name, list); err != nil {
+		t.Fatalf("WriteTodoFile failed: %v", err)
+	}
+}
+
+func TestGetStandup(t *testing.T) {
+	setupTestDir(t)
+
+	now := time.Date(2024, 7, 10, 9, 0, 0, 0, time.Local)
+	yesterday := time.Date(2024, 7, 9, 16, 30, 0, 0, time.Local)
+	dueToday := time.Date(2024, 7, 10, 0, 0, 0, 0, time.Local)
+	dueLater := time.Date(2024, 7, 20, 0, 0, 0, 0, time.Local)
+
+	writeStandupList(t, "auth", []time.Time{yesterday, now.AddDate(0, 0, -3), now}, []TodoItem{{Text: "Logout"}})
+	writeStandupList(t, "billing", nil, []TodoItem{{Text: "Invoices", DueDate: &dueToday}, {Text: "Refunds", DueDate: &dueLater}})
+	SetCurrentList("auth")
+
+	standup, err := GetStandup(now)
+	if err != nil {
+		t.Fatalf("GetStandup failed: %v", err)
+	}
+	if len(standup.Done) != 1 || standup.Done[0].Item.Text != "Done 2024-07-09" {
+		t.Errorf("Expected yesterday's completion, got %+v", standup.Done)
+	}
+	if len(standup.Today) != 2 || standup.Today[0].Item.Text != "Logout" || standup.Today[1].Item.Text != "Invoices" {
+		t.Errorf("Expected the current list and items due today, got %+v", standup.Today)
+	}
+}
+
+func TestGetWorkspaceStandups(t *testing.T) {
+	root := setupTestDir(t)
+	t.Setenv("HOME", root)
+	t.Setenv("XDG_CONFIG_HOME", root)
+
+	now := time.Date(2024, 7, 10, 9, 0, 0, 0, time.Local)
+	yesterday := now.AddDate(0, 0, -1)
+
+	for _, name := range []string{"api", "web"} {
+		dir := filepath.Join(root, name)
+		os.Mkdir(dir, 0755)
+		os.Chdir(dir)
+		writeStandupList(t, name, []time.Time{yesterday}, nil)
+		if _, err := AddWorkspace(dir); err != nil {
+			t.Fatalf("AddWorkspace failed: %v", err)
+		}
+	}
+	os.RemoveAll(filepath.Join(root, "web"))
+	os.Chdir(root)
+
+	standups, err := GetWorkspaceStandups(now)
+	if err != nil {
+		t.Fatalf("GetWorkspaceStandups failed: %v", err)
+	}
+	if len(standups) != 2 {
+		t.Fatalf("Expected two workspaces, got %+v", standups)
+	}
+	if standups[0].Err != nil || len(standups[0].Standup.Done) != 1 || standups[0].Standup.Done[0].List != "api" {
+		t.Errorf("Unexpected standup for api: %+v", standups[0])
+	}
+	if standups[1].Err == nil {
+		t.Error("Expected an error for a workspace that no longer exists")
+	}
+
+	if dir, _ := os.Getwd(); dir != root {
+		t.Errorf("Expected to be back in %s, got %s", root, dir)
+	}
+}
@@ -0,0 +1,449 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store caches parsed lists for the length of one command, so a command that
+// touches the same list several times parses and writes each file at most
+// once. Reads go through to the file on first use; changes stay in memory
+// until Flush writes every changed list back.
+type Store struct {
+	mu    sync.Mutex
+	lists map[string]*TodoList
+	dirty map[string]bool
+
+	// reads and writes count file accesses, for tests
+	reads, writes int
+}
+
+func NewStore() *Store {
+	return &Store{lists: make(map[string]*TodoList), dirty: make(map[string]bool)}
+}
+
+// Get returns a list, parsing its file the first time it is asked for. The
+// returned list is shared: call MarkDirty after changing it.
+func (s *Store) Get(name string) (*TodoList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if list, ok := s.lists[name]; ok {
+		return list, nil
+	}
+
+	list, err := ParseTodoFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse todo file: %w", err)
+	}
+	s.reads++
+	s.lists[name] = list
+	return list, nil
+}
+
+// Put replaces a list and marks it to be written
+func (s *Store) Put(name string, list *TodoList) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lists[name] = list
+	s.dirty[name] = true
+}
+
+// MarkDirty records that a list returned by Get was changed
+func (s *Store) MarkDirty(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dirty[name] = true
+}
+
+// Flush writes every changed list back to its file, or to the database with
+// sqlite storage, along with its backup.
+// The lists are written in one transaction: if any of them can't be
+// written, none are.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	for name := range s.dirty {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return nil
+	}
+
+	if err := EnsureTodoDirectory(); err != nil {
+		return fmt.Errorf("failed to create .todo directory: %w", err)
+	}
+	done := MarkWriting(writerCommand())
+	defer done()
+	if err := os.MkdirAll(GetBackupDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	// With sqlite storage the lists go to the database, and only their
+	// backups are written as files
+	sqlite := UsingSQLite()
+	tx := NewTransaction()
+	contents := make(map[string][]byte)
+	for _, name := range names {
+		// Linked items remember when they last changed, compared to the
+		// list as it was written before
+		if slices.ContainsFunc(s.lists[name].Items, func(item TodoItem) bool { return itemLink(item) != "" }) {
+			if before, err := ParseTodoFile(name); err == nil {
+				stampModified(before, s.lists[name], Now())
+			}
+		}
+		content, err := formatTodoFile(todoFileTitle(name), s.lists[name])
+		if err != nil {
+			return err
+		}
+		if !sqlite {
+			tx.Stage(GetTodoFilePath(name), content)
+		}
+		tx.Stage(GetBackupPath(name), content)
+		contents[name] = content
+	}
+	if sqlite {
+		changed := make(map[string]*TodoList)
+		for _, name := range names {
+			changed[name] = s.lists[name]
+		}
+		if err := flushSQLite(changed); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	journalListWrites(contents)
+
+	for _, name := range names {
+		noteChanged(name)
+		s.writes++
+		delete(s.dirty, name)
+	}
+	return nil
+}
+
+// FlushContext writes the changed lists unless ctx is done, in which case
+// the changes are discarded so an interrupted command leaves the lists as
+// they were
+func (s *Store) FlushContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		s.Discard()
+		return err
+	}
+	return s.Flush()
+}
+
+// Discard drops the changes not yet flushed, so the next Get reads the
+// lists from their files again
+func (s *Store) Discard() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lists = make(map[string]*TodoList)
+	s.dirty = make(map[string]bool)
+}
+
+// Batch runs a change to several items or lists as one transaction: if fn
+// fails none of its changes are written, and otherwise they are all
+// flushed together
+func (s *Store) Batch(fn func() error) error {
+	if err := fn(); err != nil {
+		s.Discard()
+		return err
+	}
+	return s.Flush()
+}
+
+// AddItem adds a pending item to a list, returning its ID. It goes at the
+// end of the part of the list outside any section, before the first heading.
+func (s *Store) AddItem(listName, text string) (int, error) {
+	todoList, item, err := s.newItem(listName, text)
+	if err != nil {
+		return 0, err
+	}
+
+	position := len(todoList.Items)
+	for i, existing := range todoList.Items {
+		if existing.Section != "" {
+			position = i
+			break
+		}
+	}
+	todoList.Items = slices.Insert(todoList.Items, position, item)
+	for i := range todoList.Items {
+		todoList.Items[i].ID = i + 1
+	}
+	s.MarkDirty(listName)
+	return position + 1, nil
+}
+
+// newItem makes a pending item for a list without adding it. A
+// "(due: YYYY-MM-DD)" suffix sets the due date, and, when insights are on,
+// the item remembers the day it was added for 'todo retro'.
+func (s *Store) newItem(listName, text string) (*TodoList, TodoItem, error) {
+	todoList, err := s.Get(listName)
+	if err != nil {
+		return nil, TodoItem{}, err
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, TodoItem{}, err
+	}
+
+	item := TodoItem{Metadata: map[string]string{}}
+	if cfg.Insights {
+		item.Metadata[metaAdded] = Now().Format(DueDateFormat)
+	}
+	parseLegacySuffixes(&item, text)
+	if cfg.Display.Numbering == NumberingID {
+		item.ShortID = newShortID(todoList)
+	}
+	return todoList, item, nil
+}
+
+// CheckItem marks an item completed now. Checking a recurring item adds
+// its next occurrence to the end of the list.
+func (s *Store) CheckItem(listName string, itemID int) error {
+	item, err := s.item(listName, itemID)
+	if err != nil {
+		return err
+	}
+
+	repeat := !item.Completed && item.Metadata[metaEvery] != ""
+	now := Now()
+	item.Completed = true
+	item.CompletedTime = &now
+	s.MarkDirty(listName)
+	if repeat {
+		return s.repeatItem(listName, itemID, now)
+	}
+	return nil
+}
+
+// SetRecurrence makes an item come back after it is checked, or stops it
+// when every is the zero Recurrence
+func (s *Store) SetRecurrence(listName string, itemID int, every Recurrence) error {
+	item, err := s.item(listName, itemID)
+	if err != nil {
+		return err
+	}
+
+	if every.N == 0 {
+		delete(item.Metadata, metaEvery)
+	} else {
+		if item.Metadata == nil {
+			item.Metadata = make(map[string]string)
+		}
+		item.Metadata[metaEvery] = every.String()
+	}
+	s.MarkDirty(listName)
+	return nil
+}
+
+// repeatItem adds the next occurrence of a recurring item, due one interval
+// after the last one, or after today for items without a due date
+func (s *Store) repeatItem(listName string, itemID int, now time.Time) error {
+	todoList, err := s.Get(listName)
+	if err != nil {
+		return err
+	}
+	done := todoList.Items[itemID-1]
+	every, err := ParseRecurrence(done.Metadata[metaEvery])
+	if err != nil {
+		// Leave items whose recurrence was mangled by hand alone
+		return nil
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	calendar, err := LoadWorkCalendar(cfg)
+	if err != nil {
+		return err
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from := today
+	if done.DueDate != nil {
+		from = *done.DueDate
+	}
+	due := every.Next(from, today, calendar)
+
+	// The next occurrence goes at the very end, in the last section, so the
+	// IDs of the items after this one stay valid while several are checked
+	_, next, err := s.newItem(listName, done.Text)
+	if err != nil {
+		return err
+	}
+	next.ID = len(todoList.Items) + 1
+	next.Section = todoList.Items[len(todoList.Items)-1].Section
+	next.Text = done.Text
+	next.DueDate = &due
+	next.Weight = done.Weight
+	next.Estimate = done.Estimate
+	next.Priority = done.Priority
+	// The next occurrence has its own added day, and a reminder was only
+	// about this one
+	for key, value := range done.Metadata {
+		if key != metaAdded && key != metaRemind {
+			next.Metadata[key] = value
+		}
+	}
+	todoList.Items = append(todoList.Items, next)
+	return nil
+}
+
+// UncheckItem marks an item pending again
+func (s *Store) UncheckItem(listName string, itemID int) error {
+	item, err := s.item(listName, itemID)
+	if err != nil {
+		return err
+	}
+
+	item.Completed = false
+	item.CompletedTime = nil
+	s.MarkDirty(listName)
+	return nil
+}
+
+// RemoveItems deletes items from a list and renumbers the rest, returning
+// the items removed in list order
+func (s *Store) RemoveItems(listName string, itemIDs []int) ([]TodoItem, error) {
+	todoList, err := s.Get(listName)
+	if err != nil {
+		return nil, err
+	}
+
+	remove := make(map[int]bool)
+	for _, itemID := range itemIDs {
+		if itemID < 1 || itemID > len(todoList.Items) {
+			return nil, fmt.Errorf("invalid item ID: %d", itemID)
+		}
+		remove[itemID] = true
+	}
+
+	// The subtasks of a removed item move up a level to take its place
+	depths := todoList.depths()
+	for i := range todoList.Items {
+		todoList.Items[i].Depth = depths[i]
+	}
+	for _, itemID := range itemIDs {
+		for _, id := range todoList.Descendants(itemID) {
+			todoList.Items[id-1].Depth--
+		}
+	}
+
+	var removed, kept []TodoItem
+	for _, item := range todoList.Items {
+		if remove[item.ID] {
+			removed = append(removed, item)
+			continue
+		}
+		item.ID = len(kept) + 1
+		kept = append(kept, item)
+	}
+	todoList.Items = kept
+	s.MarkDirty(listName)
+	return removed, nil
+}
+
+// SetWeight sets how much an item counts toward its list's progress
+func (s *Store) SetWeight(listName string, itemID, weight int) error {
+	if weight < 1 {
+		return fmt.Errorf("invalid weight %d (must be at least 1)", weight)
+	}
+	item, err := s.item(listName, itemID)
+	if err != nil {
+		return err
+	}
+
+	item.Weight = weight
+	s.MarkDirty(listName)
+	return nil
+}
+
+// SetEstimate sets how long an item is expected to take
+func (s *Store) SetEstimate(listName string, itemID int, estimate time.Duration) error {
+	if estimate <= 0 {
+		return fmt.Errorf("invalid estimate %v (must be positive)", estimate)
+	}
+	item, err := s.item(listName, itemID)
+	if err != nil {
+		return err
+	}
+
+	item.Estimate = estimate
+	s.MarkDirty(listName)
+	return nil
+}
+
+// SetPriority sets an item's priority, 1 to MaxPriority, or 0 to clear it
+func (s *Store) SetPriority(listName string, itemID, priority int) error {
+	if priority < 0 || priority > MaxPriority {
+		return fmt.Errorf("invalid priority %d (must be 1 to %d, or 0 for none)", priority, MaxPriority)
+	}
+	item, err := s.item(listName, itemID)
+	if err != nil {
+		return err
+	}
+
+	item.Priority = priority
+	s.MarkDirty(listName)
+	return nil
+}
+
+// SetDue sets the day an item is due, or clears it when due is nil
+func (s *Store) SetDue(listName string, itemID int, due *time.Time) error {
+	item, err := s.item(listName, itemID)
+	if err != nil {
+		return err
+	}
+
+	item.DueDate = due
+	s.MarkDirty(listName)
+	return nil
+}
+
+// SetReminder records when an item's reminder is set for, or clears it
+// when at is nil
+func (s *Store) SetReminder(listName string, itemID int, at *time.Time) error {
+	item, err := s.item(listName, itemID)
+	if err != nil {
+		return err
+	}
+
+	if at == nil {
+		delete(item.Metadata, metaRemind)
+	} else {
+		if item.Metadata == nil {
+			item.Metadata = make(map[string]string)
+		}
+		item.Metadata[metaRemind] = at.Format(completedTimeFormat)
+	}
+	s.MarkDirty(listName)
+	return nil
+}
+
+func (s *Store) item(listName string, itemID int) (*TodoItem, error) {
+	todoList, err := s.Get(listName)
+	if err != nil {
+		return nil, err
+	}
+
+	if itemID < 1 || itemID > len(todoList.Items) {
+		return nil, fmt.Errorf("invalid item ID: %d", itemID)
+	}
+	return &todoList.Items[itemID-1], nil
+}
@@ -0,0 +1,161 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestStoreReadsAndWritesOnce(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("auth")
+	AddTodoItem("auth", "Login form")
+
+	store := NewStore()
+	store.AddItem("auth", "Logout button")
+	store.AddItem("auth", "Password reset")
+	store.CheckItem("auth", 1)
+	store.UncheckItem("auth", 2)
+	store.CheckItem("auth", 3)
+
+	if store.reads != 1 {
+		t.Errorf("Expected the list to be parsed once, got %d reads", store.reads)
+	}
+
+	// Nothing is written until Flush
+	onDisk, _ := ParseTodoFile("auth")
+	if len(onDisk.Items) != 1 {
+		t.Errorf("Expected changes to stay in memory before Flush, file has %+v", onDisk.Items)
+	}
+
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if store.writes != 1 {
+		t.Errorf("Expected one write, got %d", store.writes)
+	}
+
+	onDisk, _ = ParseTodoFile("auth")
+	if len(onDisk.Items) != 3 || !onDisk.Items[0].Completed || onDisk.Items[1].Completed || !onDisk.Items[2].Completed {
+		t.Errorf("Unexpected items after Flush: %+v", onDisk.Items)
+	}
+
+	// A second flush has nothing to write
+	store.Flush()
+	if store.writes != 1 {
+		t.Errorf("Expected no further writes, got %d", store.writes)
+	}
+}
+
+func TestStoreInvalidItem(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("auth")
+
+	store := NewStore()
+	if err := store.CheckItem("auth", 1); err == nil {
+		t.Error("CheckItem should fail for an item that doesn't exist")
+	}
+	if err := store.Flush(); err != nil || store.writes != 0 {
+		t.Errorf("A failed change should not be written: %v, %d writes", err, store.writes)
+	}
+}
+
+func TestStoreRemoveItems(t *testing.T) {
+	setupTestDir(t)
+
+	store := NewStore()
+	for _, text := range []string{"Login form", "Logout button", "Password reset", "Audit log"} {
+		store.AddItem("auth", text)
+	}
+	removed, err := store.RemoveItems("auth", []int{3, 1})
+	if err != nil {
+		t.Fatalf("RemoveItems failed: %v", err)
+	}
+	if len(removed) != 2 || removed[0].Text != "Login form" || removed[1].Text != "Password reset" {
+		t.Errorf("Expected the removed items in list order, got %+v", removed)
+	}
+	store.Flush()
+
+	onDisk, _ := ParseTodoFile("auth")
+	if len(onDisk.Items) != 2 || onDisk.Items[0].Text != "Logout button" || onDisk.Items[0].ID != 1 || onDisk.Items[1].ID != 2 {
+		t.Errorf("Expected the rest renumbered, got %+v", onDisk.Items)
+	}
+
+	if _, err := store.RemoveItems("auth", []int{1, 5}); err == nil {
+		t.Error("RemoveItems should fail for an item that doesn't exist")
+	}
+	if list, _ := store.Get("auth"); len(list.Items) != 2 {
+		t.Errorf("A failed remove should not change the list, got %+v", list.Items)
+	}
+}
+
+func TestStoreFlushIsAllOrNothing(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("auth")
+	CreateTodoFile("billing")
+
+	store := NewStore()
+	store.AddItem("auth", "Login form")
+	store.AddItem("billing", "Invoices")
+
+	// A directory where billing's file should be makes its write fail
+	os.Remove(GetTodoFilePath("billing"))
+	os.Mkdir(GetTodoFilePath("billing"), 0755)
+	if err := store.Flush(); err == nil {
+		t.Fatal("Expected Flush to fail")
+	}
+	if onDisk, _ := ParseTodoFile("auth"); len(onDisk.Items) != 0 {
+		t.Errorf("Expected auth to be left alone when billing can't be written, got %+v", onDisk.Items)
+	}
+}
+
+func TestStoreFlushContext(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("auth")
+
+	store := NewStore()
+	store.AddItem("auth", "Login form")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := store.FlushContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if onDisk, _ := ParseTodoFile("auth"); len(onDisk.Items) != 0 {
+		t.Errorf("Expected nothing written after cancelling, got %+v", onDisk.Items)
+	}
+	// The cancelled changes are dropped rather than written by a later flush
+	store.Flush()
+	if onDisk, _ := ParseTodoFile("auth"); len(onDisk.Items) != 0 {
+		t.Errorf("Expected the changes to be discarded, got %+v", onDisk.Items)
+	}
+}
+
+func TestStoreBatch(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("auth")
+
+	store := NewStore()
+	err := store.Batch(func() error {
+		store.AddItem("auth", "Login form")
+		return errors.New("import failed")
+	})
+	if err == nil || err.Error() != "import failed" {
+		t.Fatalf("Expected the batch error, got %v", err)
+	}
+	if onDisk, _ := ParseTodoFile("auth"); len(onDisk.Items) != 0 {
+		t.Errorf("Expected a failed batch to write nothing, got %+v", onDisk.Items)
+	}
+	if todoList, _ := store.Get("auth"); len(todoList.Items) != 0 {
+		t.Errorf("Expected a failed batch to be discarded, got %+v", todoList.Items)
+	}
+
+	err = store.Batch(func() error {
+		_, err := store.AddItem("auth", "Logout button")
+		return err
+	})
+	if onDisk, _ := ParseTodoFile("auth"); err != nil || len(onDisk.Items) != 1 {
+		t.Errorf("Expected the batch to be written: %v, %+v", err, onDisk.Items)
+	}
+}
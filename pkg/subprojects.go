@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Subprojects of a monorepo can each keep their own .todo. 'todo list
+// --recursive' shows the lists of all of them, named after their directory,
+// and 'todo -C <dir>' runs a command in one of them.
+
+// skippedSubprojectDirs are directories never searched for subprojects
+var skippedSubprojectDirs = map[string]bool{"node_modules": true, "vendor": true}
+
+// FindTodoRoots returns the directories under dir, dir itself included,
+// that contain a .todo directory, as slash-separated paths relative to dir
+// ("." for dir), sorted. Hidden directories, node_modules and vendor are
+// not searched.
+func FindTodoRoots(dir string) ([]string, error) {
+	var roots []string
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// An unreadable directory only hides its own subprojects
+			if entry != nil && entry.IsDir() && filePath != dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		name := entry.Name()
+		if filePath != dir && (strings.HasPrefix(name, ".") || skippedSubprojectDirs[name]) {
+			return filepath.SkipDir
+		}
+		if info, err := os.Stat(filepath.Join(filePath, ".todo")); err == nil && info.IsDir() {
+			rel, err := filepath.Rel(dir, filePath)
+			if err != nil {
+				return err
+			}
+			roots = append(roots, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for .todo directories: %w", err)
+	}
+	sort.Strings(roots)
+	return roots, nil
+}
+
+// SubprojectListName prefixes a list name with the subproject it belongs to
+func SubprojectListName(root, name string) string {
+	if root == "." {
+		return name
+	}
+	return path.Join(root, name)
+}
+
+// ListRecursive prints the overview of the lists in the current directory
+// and every subproject under it, each list named after its subproject
+func ListRecursive() error {
+	roots, err := FindTodoRoots(".")
+	if err != nil {
+		return err
+	}
+	if len(roots) == 0 {
+		fmt.Println("No .todo directories found")
+		return nil
+	}
+
+	originalDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	defer os.Chdir(originalDir)
+
+	// Each subproject is summarized with its own config, then all of them
+	// are printed together so the progress lines up
+	type summary struct {
+		name     string
+		overview ListOverview
+		err      error
+	}
+	var summaries []summary
+	now := Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, root := range roots {
+		if err := os.Chdir(filepath.Join(originalDir, filepath.FromSlash(root))); err != nil {
+			return err
+		}
+		cfg, err := LoadConfig()
+		if err != nil {
+			summaries = append(summaries, summary{name: root, err: err})
+			continue
+		}
+		names, err := GetAllLists()
+		if err != nil {
+			summaries = append(summaries, summary{name: root, err: err})
+			continue
+		}
+		for _, parsed := range ParseLists(names) {
+			entry := summary{name: SubprojectListName(root, parsed.Name), err: parsed.Err}
+			if parsed.Err == nil {
+				entry.overview = summarizeList(parsed.Name, parsed.List, cfg, today)
+			}
+			summaries = append(summaries, entry)
+		}
+	}
+
+	if len(summaries) == 0 {
+		fmt.Println("No features found")
+		return nil
+	}
+	nameWidth := 0
+	for _, entry := range summaries {
+		nameWidth = max(nameWidth, displayWidth(entry.name))
+	}
+	fmt.Println("Lists:")
+	Blank()
+	for _, entry := range summaries {
+		name := PadRight(entry.name, nameWidth)
+		if entry.err != nil {
+			fmt.Printf("  %s - Error: %v\n", name, entry.err)
+			continue
+		}
+		fmt.Println(overviewLine(name, entry.overview))
+	}
+	return nil
+}
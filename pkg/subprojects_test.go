@@ -0,0 +1,33 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindTodoRoots(t *testing.T) {
+	dir := t.TempDir()
+	for _, root := range []string{".", "packages/api", "packages/web", "packages/web/node_modules/lib", ".cache/tool", "vendor/dep"} {
+		os.MkdirAll(filepath.Join(dir, root, ".todo"), 0755)
+	}
+	os.MkdirAll(filepath.Join(dir, "docs"), 0755)
+
+	roots, err := FindTodoRoots(dir)
+	if err != nil {
+		t.Fatalf("FindTodoRoots failed: %v", err)
+	}
+	if want := []string{".", "packages/api", "packages/web"}; !reflect.DeepEqual(roots, want) {
+		t.Errorf("FindTodoRoots() = %v, want %v", roots, want)
+	}
+}
+
+func TestSubprojectListName(t *testing.T) {
+	if got := SubprojectListName(".", "auth"); got != "auth" {
+		t.Errorf("SubprojectListName(., auth) = %q", got)
+	}
+	if got := SubprojectListName("packages/api", "auth"); got != "packages/api/auth" {
+		t.Errorf("SubprojectListName(packages/api, auth) = %q", got)
+	}
+}
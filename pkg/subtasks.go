@@ -0,0 +1,142 @@
+package pkg
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Subtasks are items indented under the item before them, as nested task
+// lists are in markdown:
+//
+//	- [ ] Ship the release
+//	  - [ ] Write the changelog
+//	  - [ ] Tag the release
+//
+// Items stay in one flat list, numbered in order, and each one's Depth
+// says how deeply it is nested.
+
+// subtaskIndent is written before an item for each level it is nested
+const subtaskIndent = "  "
+
+// lineIndent measures the whitespace a line starts with, counting tabs as
+// four spaces
+func lineIndent(line string) int {
+	indent := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			indent++
+		case '\t':
+			indent += 4
+		default:
+			return indent
+		}
+	}
+	return indent
+}
+
+// depths returns how deeply each item is nested. An item can only be nested
+// one level deeper than the item before it, and not under an item of
+// another section, whatever its Depth says.
+func (l *TodoList) depths() []int {
+	depths := make([]int, len(l.Items))
+	for i, item := range l.Items {
+		limit := 0
+		if i > 0 && l.Items[i-1].Section == item.Section {
+			limit = depths[i-1] + 1
+		}
+		depths[i] = min(max(item.Depth, 0), limit)
+	}
+	return depths
+}
+
+// Parent returns the ID of the item an item is nested under, or 0 for
+// top-level items
+func (l *TodoList) Parent(itemID int) int {
+	if itemID < 1 || itemID > len(l.Items) {
+		return 0
+	}
+	depths := l.depths()
+	for i := itemID - 2; i >= 0; i-- {
+		if depths[i] < depths[itemID-1] {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Children returns the IDs of the items nested directly under an item
+func (l *TodoList) Children(itemID int) []int {
+	var children []int
+	depths := l.depths()
+	for _, id := range l.Descendants(itemID) {
+		if depths[id-1] == depths[itemID-1]+1 {
+			children = append(children, id)
+		}
+	}
+	return children
+}
+
+// Descendants returns the IDs of the items nested under an item at any
+// depth, in list order
+func (l *TodoList) Descendants(itemID int) []int {
+	if itemID < 1 || itemID > len(l.Items) {
+		return nil
+	}
+	var descendants []int
+	depths := l.depths()
+	for i := itemID; i < len(l.Items) && depths[i] > depths[itemID-1]; i++ {
+		descendants = append(descendants, i+1)
+	}
+	return descendants
+}
+
+// AddItemUnder adds a pending item as the last subtask of an item,
+// returning its ID. Items after it are renumbered.
+func (s *Store) AddItemUnder(listName string, parentID int, text string) (int, error) {
+	todoList, err := s.Get(listName)
+	if err != nil {
+		return 0, err
+	}
+	if parentID < 1 || parentID > len(todoList.Items) {
+		return 0, fmt.Errorf("invalid item ID: %d", parentID)
+	}
+	parent := todoList.Items[parentID-1]
+	position := parentID + len(todoList.Descendants(parentID))
+
+	_, item, err := s.newItem(listName, text)
+	if err != nil {
+		return 0, err
+	}
+	item.Section = parent.Section
+	item.Depth = todoList.depths()[parentID-1] + 1
+	todoList.Items = slices.Insert(todoList.Items, position, item)
+	for i := range todoList.Items {
+		todoList.Items[i].ID = i + 1
+	}
+	s.MarkDirty(listName)
+	return position + 1, nil
+}
+
+// CheckSubtree marks an item and every pending subtask under it completed,
+// returning the IDs of the subtasks it checked
+func (s *Store) CheckSubtree(listName string, itemID int) ([]int, error) {
+	todoList, err := s.Get(listName)
+	if err != nil {
+		return nil, err
+	}
+	var pending []int
+	for _, id := range todoList.Descendants(itemID) {
+		if !todoList.Items[id-1].Completed {
+			pending = append(pending, id)
+		}
+	}
+
+	// Repeating items are added again at the end, so the IDs stay valid
+	for _, id := range append([]int{itemID}, pending...) {
+		if err := s.CheckItem(listName, id); err != nil {
+			return nil, err
+		}
+	}
+	return pending, nil
+}
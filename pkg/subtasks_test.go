@@ -0,0 +1,125 @@
+package pkg
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSubtasksRoundTrip(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("release")
+
+	content := "# Todo List for release\n\n" +
+		"- [ ] Ship the release\n" +
+		"  - [ ] Write the changelog\n" +
+		"\t- [x] List the fixes\n" +
+		"  - [ ] Tag the release\n" +
+		"- [ ] Announce it\n"
+	os.WriteFile(GetTodoFilePath("release"), []byte(content), 0644)
+
+	todoList, err := ParseTodoFile("release")
+	if err != nil {
+		t.Fatalf("ParseTodoFile failed: %v", err)
+	}
+	var depths []int
+	for _, item := range todoList.Items {
+		depths = append(depths, item.Depth)
+	}
+	if !slices.Equal(depths, []int{0, 1, 2, 1, 0}) {
+		t.Errorf("Expected depths [0 1 2 1 0], got %v", depths)
+	}
+	if parent := todoList.Parent(3); parent != 2 {
+		t.Errorf("Expected item 3 to be under item 2, got %d", parent)
+	}
+	if parent := todoList.Parent(5); parent != 0 {
+		t.Errorf("Expected item 5 to be top-level, got %d", parent)
+	}
+	if children := todoList.Children(1); !slices.Equal(children, []int{2, 4}) {
+		t.Errorf("Expected item 1 to have children [2 4], got %v", children)
+	}
+	if descendants := todoList.Descendants(1); !slices.Equal(descendants, []int{2, 3, 4}) {
+		t.Errorf("Expected item 1 to have descendants [2 3 4], got %v", descendants)
+	}
+
+	if err := WriteTodoFile("release", todoList); err != nil {
+		t.Fatalf("WriteTodoFile failed: %v", err)
+	}
+	written, _ := os.ReadFile(GetTodoFilePath("release"))
+	for _, line := range []string{"\n  - [ ] Write the changelog", "\n    - [x] List the fixes", "\n  - [ ] Tag the release", "\n- [ ] Announce it"} {
+		if !strings.Contains(string(written), line) {
+			t.Errorf("Expected %q in the written list, got:\n%s", line, written)
+		}
+	}
+}
+
+func TestSubtasksDontCrossSections(t *testing.T) {
+	todoList := &TodoList{Items: []TodoItem{
+		{ID: 1, Text: "Login form", Section: "Auth"},
+		{ID: 2, Text: "Invoices", Section: "Billing", Depth: 1},
+		{ID: 3, Text: "Refunds", Section: "Billing", Depth: 3},
+	}}
+	if parent := todoList.Parent(2); parent != 0 {
+		t.Errorf("The first item of a section can't be a subtask, got parent %d", parent)
+	}
+	if parent := todoList.Parent(3); parent != 2 {
+		t.Errorf("Expected item 3 to be under item 2, got %d", parent)
+	}
+	if depths := todoList.depths(); !slices.Equal(depths, []int{0, 0, 1}) {
+		t.Errorf("Expected depths [0 0 1], got %v", depths)
+	}
+}
+
+func TestStoreAddItemUnder(t *testing.T) {
+	setupTestDir(t)
+
+	store := NewStore()
+	for _, text := range []string{"Ship the release", "Write the changelog", "Announce it"} {
+		store.AddItem("release", text)
+	}
+	store.RemoveItems("release", []int{2})
+	if _, err := store.AddItemUnder("release", 1, "Write the changelog"); err != nil {
+		t.Fatalf("AddItemUnder failed: %v", err)
+	}
+	id, err := store.AddItemUnder("release", 1, "Tag the release")
+	if err != nil {
+		t.Fatalf("AddItemUnder failed: %v", err)
+	}
+	if id != 3 {
+		t.Errorf("Expected the second subtask to be item 3, got %d", id)
+	}
+	if _, err := store.AddItemUnder("release", 9, "Nothing"); err == nil {
+		t.Error("AddItemUnder should fail for an item that doesn't exist")
+	}
+	store.Flush()
+
+	todoList, _ := ParseTodoFile("release")
+	var texts []string
+	for _, item := range todoList.Items {
+		texts = append(texts, item.Text)
+	}
+	if !slices.Equal(texts, []string{"Ship the release", "Write the changelog", "Tag the release", "Announce it"}) {
+		t.Errorf("Expected the subtasks after their parent, got %v", texts)
+	}
+	if children := todoList.Children(1); !slices.Equal(children, []int{2, 3}) {
+		t.Errorf("Expected item 1 to have children [2 3], got %v", children)
+	}
+
+	checked, err := store.CheckSubtree("release", 1)
+	if err != nil {
+		t.Fatalf("CheckSubtree failed: %v", err)
+	}
+	if !slices.Equal(checked, []int{2, 3}) {
+		t.Errorf("Expected subtasks [2 3] to be checked, got %v", checked)
+	}
+	if list, _ := store.Get("release"); list.Items[3].Completed {
+		t.Error("CheckSubtree should leave the items after the subtree alone")
+	}
+
+	// Removing a parent moves its subtasks up a level
+	store.RemoveItems("release", []int{1})
+	if list, _ := store.Get("release"); list.Items[0].Depth != 0 || list.Items[1].Depth != 0 {
+		t.Errorf("Expected the subtasks to be top-level, got %+v", list.Items)
+	}
+}
@@ -0,0 +1,209 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Sync change actions
+const (
+	SyncAdd      = "add"
+	SyncUpdate   = "update"
+	SyncDelete   = "delete"
+	SyncConflict = "conflict"
+)
+
+// SyncChange describes one list that differs between the local store and a
+// sync remote
+type SyncChange struct {
+	List   string `json:"list"`
+	Action string `json:"action"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// SyncStatus summarises the differences in both directions
+type SyncStatus struct {
+	Remote   string       `json:"remote"`
+	Incoming []SyncChange `json:"incoming"`
+	Outgoing []SyncChange `json:"outgoing"`
+}
+
+// SyncOptions control a pull or push
+type SyncOptions struct {
+	// DryRun only reports what would change
+	DryRun bool
+	// Force resolves conflicts in favour of the side being synced from
+	Force bool
+}
+
+// ErrSyncOffline is returned when a provider's remote can't be reached
+var ErrSyncOffline = errors.New("sync remote unreachable")
+
+// SyncProvider is a remote copy of the todo lists that can be pulled from
+// and pushed to. Status, Pull and Push stop when their context is done,
+// leaving the lists as they were and anything unsent queued for next time.
+type SyncProvider interface {
+	Name() string
+	Status(ctx context.Context) (*SyncStatus, error)
+	Pull(ctx context.Context, opts SyncOptions) ([]SyncChange, error)
+	Push(ctx context.Context, opts SyncOptions) ([]SyncChange, error)
+	// LocalChanges returns the changes made since the last sync without
+	// contacting the remote
+	LocalChanges() ([]SyncChange, error)
+}
+
+var syncProviders = map[string]func(cfg *Config) (SyncProvider, error){
+	"git":     newGitSyncProvider,
+	"caldav":  newCalDAVSyncProvider,
+	"linear":  newLinearSyncProvider,
+	"todoist": newTodoistSyncProvider,
+}
+
+// SyncProviderNames returns the names of the available sync providers
+func SyncProviderNames() []string {
+	var names []string
+	for name := range syncProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetSyncProvider returns the sync provider with the given name
+func GetSyncProvider(name string) (SyncProvider, error) {
+	newProvider, ok := syncProviders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown sync provider %q (available: %v)", name, SyncProviderNames())
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return newProvider(cfg)
+}
+
+// threeWayChanges compares base, local and remote snapshots of the store,
+// keyed by path, and returns the incoming and outgoing changes. A path that
+// changed on both sides differently is a conflict and appears in both.
+func threeWayChanges(base, local, remote map[string]string) (incoming, outgoing []SyncChange) {
+	paths := make(map[string]bool)
+	for _, snapshot := range []map[string]string{base, local, remote} {
+		for path := range snapshot {
+			paths[path] = true
+		}
+	}
+
+	var sorted []string
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	for _, path := range sorted {
+		baseContent, inBase := base[path]
+		localContent, inLocal := local[path]
+		remoteContent, inRemote := remote[path]
+
+		localChanged := inLocal != inBase || localContent != baseContent
+		remoteChanged := inRemote != inBase || remoteContent != baseContent
+		sameResult := inLocal == inRemote && localContent == remoteContent
+
+		switch {
+		case sameResult:
+			continue
+		case localChanged && remoteChanged:
+			conflict := SyncChange{List: path, Action: SyncConflict, Detail: "changed locally and remotely"}
+			incoming = append(incoming, conflict)
+			outgoing = append(outgoing, conflict)
+		case remoteChanged:
+			incoming = append(incoming, SyncChange{List: path, Action: changeAction(inLocal, inRemote)})
+		case localChanged:
+			outgoing = append(outgoing, SyncChange{List: path, Action: changeAction(inRemote, inLocal)})
+		}
+	}
+
+	return incoming, outgoing
+}
+
+// changeAction names the change that turns a path's old state into its new one
+func changeAction(existedBefore, existsAfter bool) string {
+	switch {
+	case !existedBefore:
+		return SyncAdd
+	case !existsAfter:
+		return SyncDelete
+	}
+	return SyncUpdate
+}
+
+// resolveConflicts turns conflicting changes into plain updates that take the
+// theirs side, for a forced sync
+func resolveConflicts(changes []SyncChange, ours, theirs map[string]string) []SyncChange {
+	resolved := make([]SyncChange, 0, len(changes))
+	for _, change := range changes {
+		if change.Action == SyncConflict {
+			_, inOurs := ours[change.List]
+			_, inTheirs := theirs[change.List]
+			change = SyncChange{List: change.List, Action: changeAction(inOurs, inTheirs), Detail: "conflict resolved by --force"}
+		}
+		resolved = append(resolved, change)
+	}
+	return resolved
+}
+
+// QueueSyncChanges records outgoing changes that couldn't be pushed because
+// the provider was offline, so they are reported and flushed on the next
+// successful sync
+func QueueSyncChanges(provider string, changes []SyncChange) error {
+	var entries []JournalEntry
+	for _, change := range changes {
+		entries = append(entries, JournalEntry{
+			Kind:     JournalSyncQueued,
+			Provider: provider,
+			List:     change.List,
+			Action:   change.Action,
+			Detail:   change.Detail,
+		})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return AppendJournal(entries...)
+}
+
+// QueuedSyncChanges returns the changes queued for a provider since it was
+// last flushed, one per list with the most recent action
+func QueuedSyncChanges(provider string) ([]SyncChange, error) {
+	entries, err := ReadJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	queued := make(map[string]SyncChange)
+	for _, entry := range entries {
+		if entry.Provider != provider {
+			continue
+		}
+		switch entry.Kind {
+		case JournalSyncQueued:
+			queued[entry.List] = SyncChange{List: entry.List, Action: entry.Action, Detail: entry.Detail}
+		case JournalSyncFlushed:
+			queued = make(map[string]SyncChange)
+		}
+	}
+
+	changes := make([]SyncChange, 0, len(queued))
+	for _, change := range queued {
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].List < changes[j].List })
+	return changes, nil
+}
+
+// FlushSyncQueue marks a provider's queued changes as sent
+func FlushSyncQueue(provider string) error {
+	return AppendJournal(JournalEntry{Kind: JournalSyncFlushed, Provider: provider})
+}
@@ -0,0 +1,385 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Refs used to remember the remote lists and the last synced state
+const (
+	gitSyncRemoteRef = "refs/todo/remote"
+	gitSyncBaseRef   = "refs/todo/base"
+)
+
+// gitSyncProvider stores the .todo lists on a dedicated branch of a git
+// remote, so they can follow you across machines without being committed to
+// the working branch
+type gitSyncProvider struct {
+	remote string
+	branch string
+}
+
+func newGitSyncProvider(cfg *Config) (SyncProvider, error) {
+	if err := RequireGit(); err != nil {
+		return nil, err
+	}
+	return &gitSyncProvider{remote: cfg.SyncRemote, branch: cfg.SyncBranch}, nil
+}
+
+func (p *gitSyncProvider) Name() string {
+	return fmt.Sprintf("git (%s/%s)", p.remote, p.branch)
+}
+
+func (p *gitSyncProvider) Status(ctx context.Context) (*SyncStatus, error) {
+	_, base, local, remote, err := p.snapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	incoming, outgoing := threeWayChanges(base, local, remote)
+	return &SyncStatus{Remote: p.Name(), Incoming: incoming, Outgoing: outgoing}, nil
+}
+
+func (p *gitSyncProvider) Pull(ctx context.Context, opts SyncOptions) ([]SyncChange, error) {
+	remoteCommit, base, local, remote, err := p.snapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	incoming, _ := threeWayChanges(base, local, remote)
+	if opts.Force {
+		incoming = resolveConflicts(incoming, local, remote)
+	}
+	if opts.DryRun || len(incoming) == 0 {
+		return incoming, nil
+	}
+	// Past this point the lists are written, which is quick, so an
+	// interruption either stops the pull before any of them or lets it
+	// finish
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	conflicts := false
+	sqlite := UsingSQLite()
+	for _, change := range incoming {
+		if change.Action == SyncConflict {
+			conflicts = true
+			continue
+		}
+		// Lists kept in the database are written there; archives are
+		// files either way
+		if name, ok := snapshotListName(change.List); ok && sqlite {
+			if err := applySnapshotListSQLite(name, change.Action, remote[change.List]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		filePath := filepath.Join(".todo", filepath.FromSlash(change.List))
+		if change.Action == SyncDelete {
+			if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to remove %s: %w", filePath, err)
+			}
+		} else {
+			if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+				return nil, fmt.Errorf("failed to create directory: %w", err)
+			}
+			if err := os.WriteFile(filePath, []byte(remote[change.List]), 0644); err != nil {
+				return nil, fmt.Errorf("failed to write %s: %w", filePath, err)
+			}
+		}
+		noteChanged(strings.TrimSuffix(change.List, ".md"))
+	}
+
+	// Conflicting lists keep the old base so they are reported until resolved
+	if !conflicts && remoteCommit != "" {
+		if _, err := runGit("update-ref", gitSyncBaseRef, remoteCommit); err != nil {
+			return nil, err
+		}
+	}
+
+	return incoming, nil
+}
+
+func (p *gitSyncProvider) Push(ctx context.Context, opts SyncOptions) ([]SyncChange, error) {
+	remoteCommit, base, local, remote, err := p.snapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	incoming, outgoing := threeWayChanges(base, local, remote)
+	if opts.Force {
+		// Pushing the local snapshot replaces the remote entirely
+		_, outgoing = threeWayChanges(remote, local, remote)
+	} else if len(incoming) > 0 {
+		return nil, fmt.Errorf("the remote has %d change(s) you don't have yet; run 'todo sync pull' first", len(incoming))
+	}
+	if opts.DryRun || len(outgoing) == 0 {
+		return outgoing, nil
+	}
+
+	commit, err := writeSnapshotCommit(local, remoteCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := runGitContext(ctx, "push", "-q", p.remote, commit+":refs/heads/"+p.branch); err != nil {
+		return nil, err
+	}
+	for _, ref := range []string{gitSyncRemoteRef, gitSyncBaseRef} {
+		if _, err := runGit("update-ref", ref, commit); err != nil {
+			return nil, err
+		}
+	}
+
+	return outgoing, nil
+}
+
+func (p *gitSyncProvider) LocalChanges() ([]SyncChange, error) {
+	base, err := readCommitSnapshot(resolveRef(gitSyncBaseRef))
+	if err != nil {
+		return nil, err
+	}
+	local, err := readLocalSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	_, outgoing := threeWayChanges(base, local, base)
+	return outgoing, nil
+}
+
+// snapshots fetches the remote branch and returns its commit together with
+// the base, local and remote contents of the store
+func (p *gitSyncProvider) snapshots(ctx context.Context) (remoteCommit string, base, local, remote map[string]string, err error) {
+	remoteCommit, err = p.fetch(ctx)
+	if err != nil {
+		return "", nil, nil, nil, err
+	}
+
+	if base, err = readCommitSnapshot(resolveRef(gitSyncBaseRef)); err != nil {
+		return "", nil, nil, nil, err
+	}
+	if local, err = readLocalSnapshot(); err != nil {
+		return "", nil, nil, nil, err
+	}
+
+	// A missing remote branch has nothing new to offer, rather than
+	// asking us to delete everything we synced before
+	remote = base
+	if remoteCommit != "" {
+		if remote, err = readCommitSnapshot(remoteCommit); err != nil {
+			return "", nil, nil, nil, err
+		}
+	}
+
+	return remoteCommit, base, local, remote, nil
+}
+
+// fetch updates the local copy of the remote branch and returns its commit,
+// or an empty string if the branch doesn't exist yet
+func (p *gitSyncProvider) fetch(ctx context.Context) (string, error) {
+	// A missing remote is a configuration problem, not a network one
+	if _, err := runGit("remote", "get-url", p.remote); err != nil {
+		return "", fmt.Errorf("sync remote '%s' is not configured; add it with 'git remote add' or set sync_remote in .todo/config.yaml", p.remote)
+	}
+
+	heads, err := runGitContext(ctx, "ls-remote", "--heads", p.remote, p.branch)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%w: failed to reach '%s': %v", ErrSyncOffline, p.remote, err)
+	}
+	if heads == "" {
+		return "", nil
+	}
+
+	if _, err := runGitContext(ctx, "fetch", "-q", p.remote, "+refs/heads/"+p.branch+":"+gitSyncRemoteRef); err != nil {
+		if ctx.Err() != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%w: failed to fetch from '%s': %v", ErrSyncOffline, p.remote, err)
+	}
+	return resolveRef(gitSyncRemoteRef), nil
+}
+
+// resolveRef returns the commit a ref points to, or an empty string
+func resolveRef(ref string) string {
+	commit, err := runGit("rev-parse", "--verify", "-q", ref)
+	if err != nil {
+		return ""
+	}
+	return commit
+}
+
+// readCommitSnapshot returns the files stored in a sync commit, keyed by
+// their slash-separated path
+func readCommitSnapshot(commit string) (map[string]string, error) {
+	snapshot := make(map[string]string)
+	if commit == "" {
+		return snapshot, nil
+	}
+
+	output, err := runGit("ls-tree", "-r", "--name-only", commit)
+	if err != nil {
+		return nil, err
+	}
+	if output == "" {
+		return snapshot, nil
+	}
+
+	for _, filePath := range strings.Split(output, "\n") {
+		content, err := runGitWithInput(nil, "cat-file", "blob", commit+":"+filePath)
+		if err != nil {
+			return nil, err
+		}
+		snapshot[filePath] = string(content)
+	}
+	return snapshot, nil
+}
+
+// readLocalSnapshot returns the list and archive files in .todo, keyed by
+// their slash-separated path relative to .todo. Lists kept in the database
+// are written as the files they would be, and any list files left beside it,
+// such as those 'todo export --markdown' writes, are skipped.
+func readLocalSnapshot() (map[string]string, error) {
+	snapshot := make(map[string]string)
+
+	sqlite := UsingSQLite()
+	if sqlite {
+		names, err := sqliteLists()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			todoList, err := readListSQLite(name)
+			if err != nil {
+				return nil, err
+			}
+			content, err := formatTodoFile(todoFileTitle(name), todoList)
+			if err != nil {
+				return nil, err
+			}
+			snapshot[name+".md"] = string(content)
+		}
+	}
+
+	for _, dir := range []string{"", "archive"} {
+		if dir == "" && sqlite {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(".todo", dir))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read .todo directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+				continue
+			}
+			content, err := os.ReadFile(filepath.Join(".todo", dir, entry.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+			}
+			snapshot[path.Join(dir, entry.Name())] = string(content)
+		}
+	}
+
+	return snapshot, nil
+}
+
+// snapshotListName returns the name of the list a snapshot path holds, if
+// it is a list rather than an archive
+func snapshotListName(filePath string) (string, bool) {
+	if strings.Contains(filePath, "/") || !strings.HasSuffix(filePath, ".md") {
+		return "", false
+	}
+	return strings.TrimSuffix(filePath, ".md"), true
+}
+
+// applySnapshotListSQLite writes a pulled list to the database, or deletes it
+func applySnapshotListSQLite(name, action, content string) error {
+	if action == SyncDelete {
+		if !TodoFileExists(name) {
+			return nil
+		}
+		return DeleteList(name)
+	}
+	todoList, err := parseTodoList(strings.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse list '%s': %w", name, err)
+	}
+	return WriteTodoFile(name, todoList)
+}
+
+// writeSnapshotCommit stores a snapshot as a commit on top of parent without
+// touching the index or working tree
+func writeSnapshotCommit(snapshot map[string]string, parent string) (string, error) {
+	tree, err := writeSnapshotTree(snapshot, "")
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"commit-tree", tree, "-m", "Update todo lists"}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	return runGit(args...)
+}
+
+// writeSnapshotTree writes the tree for the directory dir of a snapshot
+func writeSnapshotTree(snapshot map[string]string, dir string) (string, error) {
+	files := make(map[string]string)
+	subdirs := make(map[string]bool)
+	for filePath, content := range snapshot {
+		rel := filePath
+		if dir != "" {
+			if !strings.HasPrefix(filePath, dir+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(filePath, dir+"/")
+		}
+		if i := strings.Index(rel, "/"); i >= 0 {
+			subdirs[rel[:i]] = true
+		} else {
+			files[rel] = content
+		}
+	}
+
+	var entries []string
+	for name, content := range files {
+		blob, err := runGitWithInput([]byte(content), "hash-object", "-w", "--stdin")
+		if err != nil {
+			return "", err
+		}
+		entries = append(entries, fmt.Sprintf("100644 blob %s\t%s", strings.TrimSpace(string(blob)), name))
+	}
+	for name := range subdirs {
+		tree, err := writeSnapshotTree(snapshot, path.Join(dir, name))
+		if err != nil {
+			return "", err
+		}
+		entries = append(entries, fmt.Sprintf("040000 tree %s\t%s", tree, name))
+	}
+	sort.Strings(entries)
+
+	input := ""
+	if len(entries) > 0 {
+		input = strings.Join(entries, "\n") + "\n"
+	}
+	tree, err := runGitWithInput([]byte(input), "mktree")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(tree)), nil
+}
@@ -0,0 +1,190 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// setupSyncClones creates a bare remote and two clones of it, returning the
+// clone directories
+func setupSyncClones(t *testing.T) (string, string) {
+	root := setupTestDir(t)
+
+	git := func(dir string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	remote := filepath.Join(root, "remote.git")
+	git(root, "init", "-q", "--bare", remote)
+
+	var clones []string
+	for _, name := range []string{"laptop", "desktop"} {
+		dir := filepath.Join(root, name)
+		git(root, "clone", "-q", remote, dir)
+		git(dir, "config", "user.name", "Test User")
+		git(dir, "config", "user.email", "test@example.com")
+		clones = append(clones, dir)
+	}
+
+	return clones[0], clones[1]
+}
+
+func TestGitSyncPushAndPull(t *testing.T) {
+	laptop, desktop := setupSyncClones(t)
+
+	os.Chdir(laptop)
+	CreateTodoFile("auth")
+	AddTodoItem("auth", "Login form")
+
+	provider, err := GetSyncProvider("git")
+	if err != nil {
+		t.Fatalf("GetSyncProvider failed: %v", err)
+	}
+
+	// A dry run reports the change without pushing it
+	changes, err := provider.Push(context.Background(), SyncOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Push dry run failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].List != "auth.md" || changes[0].Action != SyncAdd {
+		t.Fatalf("Unexpected dry run changes: %+v", changes)
+	}
+	if status, _ := provider.Status(context.Background()); len(status.Outgoing) != 1 {
+		t.Errorf("Dry run should not push, status: %+v", status)
+	}
+
+	if _, err := provider.Push(context.Background(), SyncOptions{}); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if status, _ := provider.Status(context.Background()); len(status.Incoming)+len(status.Outgoing) != 0 {
+		t.Errorf("Expected nothing to sync after push, got %+v", status)
+	}
+
+	// The other machine sees the incoming list and pulls it
+	os.Chdir(desktop)
+	status, err := provider.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(status.Incoming) != 1 {
+		t.Fatalf("Expected one incoming change, got %+v", status)
+	}
+
+	if _, err := provider.Pull(context.Background(), SyncOptions{DryRun: true}); err != nil {
+		t.Fatalf("Pull dry run failed: %v", err)
+	}
+	if TodoFileExists("auth") {
+		t.Fatal("Dry run should not write lists")
+	}
+
+	if _, err := provider.Pull(context.Background(), SyncOptions{}); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	todoList, err := ParseTodoFile("auth")
+	if err != nil || len(todoList.Items) != 1 || todoList.Items[0].Text != "Login form" {
+		t.Fatalf("Expected pulled list, got %+v (%v)", todoList, err)
+	}
+
+	// Changing the same list on both machines is a conflict
+	AddTodoItem("auth", "Desktop item")
+	provider.Push(context.Background(), SyncOptions{})
+
+	os.Chdir(laptop)
+	AddTodoItem("auth", "Laptop item")
+	if _, err := provider.Push(context.Background(), SyncOptions{}); err == nil {
+		t.Error("Push should refuse while the remote has unpulled changes")
+	}
+
+	changes, err = provider.Pull(context.Background(), SyncOptions{})
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Action != SyncConflict {
+		t.Fatalf("Expected a conflict, got %+v", changes)
+	}
+
+	if _, err := provider.Push(context.Background(), SyncOptions{Force: true}); err != nil {
+		t.Fatalf("Forced push failed: %v", err)
+	}
+	if status, _ := provider.Status(context.Background()); len(status.Incoming)+len(status.Outgoing) != 0 {
+		t.Errorf("Expected nothing to sync after forced push, got %+v", status)
+	}
+}
+
+func TestGitSyncSQLite(t *testing.T) {
+	laptop, desktop := setupSyncClones(t)
+
+	os.Chdir(laptop)
+	AddTodoItem("auth", "Login form")
+	provider, _ := GetSyncProvider("git")
+	if _, err := provider.Push(context.Background(), SyncOptions{}); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	// A machine keeping its lists in the database pulls them into it
+	os.Chdir(desktop)
+	SaveConfig(&Config{Storage: StorageSQLite})
+	if _, err := provider.Pull(context.Background(), SyncOptions{}); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if _, err := os.Stat(GetTodoFilePath("auth")); !os.IsNotExist(err) {
+		t.Error("Expected no list file to be written")
+	}
+	todoList, _ := ParseTodoFile("auth")
+	if len(todoList.Items) != 1 || todoList.Items[0].Text != "Login form" {
+		t.Fatalf("Expected the pulled list in the database, got %+v", todoList)
+	}
+	if status, _ := provider.Status(context.Background()); len(status.Incoming)+len(status.Outgoing) != 0 {
+		t.Errorf("Expected nothing to sync after pull, got %+v", status)
+	}
+
+	// and pushes the lists from the database
+	AddTodoItem("auth", "Remember me")
+	if _, err := provider.Push(context.Background(), SyncOptions{}); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	os.Chdir(laptop)
+	provider.Pull(context.Background(), SyncOptions{})
+	if todoList, _ := ParseTodoFile("auth"); len(todoList.Items) != 2 {
+		t.Errorf("Expected the item added on the other machine, got %+v", todoList.Items)
+	}
+}
+
+func TestGitSyncOffline(t *testing.T) {
+	laptop, _ := setupSyncClones(t)
+
+	os.Chdir(laptop)
+	CreateTodoFile("auth")
+
+	provider, err := GetSyncProvider("git")
+	if err != nil {
+		t.Fatalf("GetSyncProvider failed: %v", err)
+	}
+
+	exec.Command("git", "remote", "set-url", "origin", filepath.Join(laptop, "unreachable.git")).Run()
+	if _, err := provider.Push(context.Background(), SyncOptions{}); !errors.Is(err, ErrSyncOffline) {
+		t.Fatalf("Expected ErrSyncOffline, got %v", err)
+	}
+
+	changes, err := provider.LocalChanges()
+	if err != nil {
+		t.Fatalf("LocalChanges failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].List != "auth.md" || changes[0].Action != SyncAdd {
+		t.Errorf("Unexpected local changes: %+v", changes)
+	}
+
+	// A remote that isn't configured at all is not treated as offline
+	exec.Command("git", "remote", "remove", "origin").Run()
+	if _, err := provider.Push(context.Background(), SyncOptions{}); err == nil || errors.Is(err, ErrSyncOffline) {
+		t.Errorf("Expected a configuration error, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestThreeWayChanges(t *testing.T) {
+	base := map[string]string{"same.md": "a", "local.md": "a", "remote.md": "a", "both.md": "a", "gone.md": "a"}
+	local := map[string]string{"same.md": "a", "local.md": "b", "remote.md": "a", "both.md": "b", "gone.md": "a", "new.md": "n"}
+	remote := map[string]string{"same.md": "a", "local.md": "a", "remote.md": "c", "both.md": "c"}
+
+	incoming, outgoing := threeWayChanges(base, local, remote)
+
+	expectedIncoming := []SyncChange{
+		{List: "both.md", Action: SyncConflict, Detail: "changed locally and remotely"},
+		{List: "gone.md", Action: SyncDelete},
+		{List: "remote.md", Action: SyncUpdate},
+	}
+	expectedOutgoing := []SyncChange{
+		{List: "both.md", Action: SyncConflict, Detail: "changed locally and remotely"},
+		{List: "local.md", Action: SyncUpdate},
+		{List: "new.md", Action: SyncAdd},
+	}
+
+	if !reflect.DeepEqual(incoming, expectedIncoming) {
+		t.Errorf("incoming = %+v, want %+v", incoming, expectedIncoming)
+	}
+	if !reflect.DeepEqual(outgoing, expectedOutgoing) {
+		t.Errorf("outgoing = %+v, want %+v", outgoing, expectedOutgoing)
+	}
+}
+
+func TestGetSyncProviderUnknown(t *testing.T) {
+	setupTestDir(t)
+
+	if _, err := GetSyncProvider("carrier-pigeon"); err == nil {
+		t.Error("GetSyncProvider should fail for an unknown provider")
+	}
+}
+
+func TestSyncQueue(t *testing.T) {
+	setupTestDir(t)
+
+	QueueSyncChanges("git", []SyncChange{{List: "auth.md", Action: SyncAdd}})
+	QueueSyncChanges("git", []SyncChange{{List: "auth.md", Action: SyncUpdate}, {List: "api.md", Action: SyncAdd}})
+	QueueSyncChanges("other", []SyncChange{{List: "ops.md", Action: SyncAdd}})
+
+	queued, err := QueuedSyncChanges("git")
+	if err != nil {
+		t.Fatalf("QueuedSyncChanges failed: %v", err)
+	}
+	expected := []SyncChange{{List: "api.md", Action: SyncAdd}, {List: "auth.md", Action: SyncUpdate}}
+	if !reflect.DeepEqual(queued, expected) {
+		t.Errorf("queued = %+v, want %+v", queued, expected)
+	}
+
+	if err := FlushSyncQueue("git"); err != nil {
+		t.Fatalf("FlushSyncQueue failed: %v", err)
+	}
+	if queued, _ := QueuedSyncChanges("git"); len(queued) != 0 {
+		t.Errorf("Expected an empty queue after flushing, got %+v", queued)
+	}
+	if queued, _ := QueuedSyncChanges("other"); len(queued) != 1 {
+		t.Errorf("Flushing one provider should not affect another, got %+v", queued)
+	}
+}
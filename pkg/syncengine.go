@@ -0,0 +1,450 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIError is a non-successful response from a remote API
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	body := strings.TrimSpace(e.Body)
+	if len(body) > 200 {
+		body = body[:200] + "..."
+	}
+	if body == "" {
+		return fmt.Sprintf("API request failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, body)
+}
+
+// Temporary reports whether retrying the request later may succeed
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// TransientError wraps failures such as network errors and exhausted
+// retries that should stop a sync run so it can be resumed later
+type TransientError struct {
+	Err error
+}
+
+func (e *TransientError) Error() string { return e.Err.Error() }
+func (e *TransientError) Unwrap() error { return e.Err }
+
+// IsTransientError reports whether err should pause a sync rather than fail
+// a single operation
+func IsTransientError(err error) bool {
+	var transient *TransientError
+	if errors.As(err, &transient) {
+		return true
+	}
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Temporary()
+}
+
+// RateLimiter spaces out requests so that no more than a fixed number are
+// sent per second
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+	sleep    func(context.Context, time.Duration) error
+}
+
+func NewRateLimiter(requestsPerSecond float64) *RateLimiter {
+	interval := time.Duration(0)
+	if requestsPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return &RateLimiter{interval: interval, sleep: sleepContext}
+}
+
+// sleepContext waits for d, or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Wait blocks until the next request may be sent, or until ctx is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	wait := l.next.Sub(now)
+	if wait < 0 {
+		wait = 0
+		l.next = now
+	}
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait > 0 {
+		return l.sleep(ctx, wait)
+	}
+	return ctx.Err()
+}
+
+// APIClient sends JSON requests to a remote API with rate limiting and
+// exponential backoff on rate-limit responses, server errors and network
+// failures
+type APIClient struct {
+	HTTP       *http.Client
+	BaseURL    string
+	Header     http.Header
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+
+	limiter *RateLimiter
+	sleep   func(context.Context, time.Duration) error
+}
+
+func NewAPIClient(baseURL string, requestsPerSecond float64) *APIClient {
+	return &APIClient{
+		HTTP:       &http.Client{Timeout: 30 * time.Second},
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Header:     make(http.Header),
+		MaxRetries: 5,
+		BaseDelay:  500 * time.Millisecond,
+		MaxDelay:   30 * time.Second,
+		limiter:    NewRateLimiter(requestsPerSecond),
+		sleep:      sleepContext,
+	}
+}
+
+// Do sends a request with an optional JSON body and decodes a JSON response
+// into out when it is non-nil. path may be relative to BaseURL or absolute.
+func (c *APIClient) Do(method, path string, body interface{}, out interface{}) error {
+	return c.DoContext(context.Background(), method, path, body, out)
+}
+
+// DoContext is Do, giving up as soon as ctx is done
+func (c *APIClient) DoContext(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	header := http.Header{"Accept": {"application/json"}}
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		header.Set("Content-Type", "application/json")
+	}
+
+	respBody, err := c.SendContext(ctx, method, path, header, payload)
+	if err != nil {
+		return err
+	}
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// Send sends a request with a raw body, for APIs that don't speak JSON, and
+// returns the response body. header is added to the client's headers.
+func (c *APIClient) Send(method, path string, header http.Header, payload []byte) ([]byte, error) {
+	return c.SendContext(context.Background(), method, path, header, payload)
+}
+
+// SendContext is Send, giving up as soon as ctx is done. A request
+// interrupted this way is not retried; its error wraps ctx.Err().
+func (c *APIClient) SendContext(ctx context.Context, method, path string, header http.Header, payload []byte) ([]byte, error) {
+	url := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		url = c.BaseURL + path
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := c.sleep(ctx, c.backoff(attempt, lastErr)); err != nil {
+				return nil, err
+			}
+		}
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		for key, values := range c.Header {
+			req.Header[key] = values
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode >= 400 {
+			apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+			if !apiErr.Temporary() {
+				return nil, apiErr
+			}
+			lastErr = &retryAfterError{APIError: apiErr, after: parseRetryAfter(resp.Header.Get("Retry-After"))}
+			continue
+		}
+		return respBody, nil
+	}
+
+	if retryErr, ok := lastErr.(*retryAfterError); ok {
+		lastErr = retryErr.APIError
+	}
+	return nil, &TransientError{Err: fmt.Errorf("giving up after %d attempts: %w", c.MaxRetries+1, lastErr)}
+}
+
+// retryAfterError remembers the server's Retry-After hint for the next attempt
+type retryAfterError struct {
+	*APIError
+	after time.Duration
+}
+
+// backoff returns how long to wait before the given retry attempt
+func (c *APIClient) backoff(attempt int, lastErr error) time.Duration {
+	if retryErr, ok := lastErr.(*retryAfterError); ok && retryErr.after > 0 {
+		return retryErr.after
+	}
+
+	delay := c.BaseDelay << (attempt - 1)
+	if delay > c.MaxDelay || delay <= 0 {
+		delay = c.MaxDelay
+	}
+	// Up to 10% jitter keeps parallel clients from retrying in lockstep
+	return delay + time.Duration(rand.Int63n(int64(delay)/10+1))
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		return time.Until(when)
+	}
+	return 0
+}
+
+// SyncOperation is one queued change to send to a remote service
+type SyncOperation struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	List      string          `json:"list,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Attempts  int             `json:"attempts,omitempty"`
+	LastError string          `json:"last_error,omitempty"`
+}
+
+// SyncState is the persistent state of a service sync, kept between runs in
+// .todo/sync/<name>.json
+type SyncState struct {
+	// Cursor marks how far incoming changes have been read, in whatever
+	// form the service uses (a timestamp, a page token, ...)
+	Cursor string `json:"cursor,omitempty"`
+	// IDs maps local item keys to the service's ids
+	IDs map[string]string `json:"ids,omitempty"`
+	// Base holds the last synced state of each item, keyed like IDs, for
+	// services whose changes are compared three ways
+	Base map[string]string `json:"base,omitempty"`
+	// Pending operations are retried, in order, on the next run
+	Pending []SyncOperation `json:"pending,omitempty"`
+	// Failed operations were rejected by the service and need attention
+	Failed  []SyncOperation `json:"failed,omitempty"`
+	LastRun *time.Time      `json:"last_run,omitempty"`
+}
+
+func GetSyncStatePath(name string) string {
+	return filepath.Join(".todo", "sync", name+".json")
+}
+
+// LoadSyncState reads the saved state of a sync, or returns an empty state
+func LoadSyncState(name string) (*SyncState, error) {
+	state := &SyncState{IDs: make(map[string]string), Base: make(map[string]string)}
+
+	content, err := os.ReadFile(GetSyncStatePath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state: %w", err)
+	}
+	if state.IDs == nil {
+		state.IDs = make(map[string]string)
+	}
+	if state.Base == nil {
+		state.Base = make(map[string]string)
+	}
+	return state, nil
+}
+
+// Save writes the state atomically so an interrupted run never leaves a
+// truncated state file behind
+func (s *SyncState) Save(name string) error {
+	path := GetSyncStatePath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create sync directory: %w", err)
+	}
+
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0644); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// SyncEngine runs queued operations against a service, saving progress after
+// every operation so a run that stops part-way resumes where it left off
+type SyncEngine struct {
+	Name  string
+	State *SyncState
+	// MaxAttempts is how many runs a rejected operation is retried for
+	// before it is moved to the failed list
+	MaxAttempts int
+}
+
+// SyncRunResult reports what a run of the engine did
+type SyncRunResult struct {
+	Completed int
+	Failed    int
+	Remaining int
+}
+
+func NewSyncEngine(name string) (*SyncEngine, error) {
+	state, err := LoadSyncState(name)
+	if err != nil {
+		return nil, err
+	}
+	return &SyncEngine{Name: name, State: state, MaxAttempts: 3}, nil
+}
+
+// Enqueue adds operations to the end of the queue, replacing any queued
+// operation with the same ID
+func (e *SyncEngine) Enqueue(ops ...SyncOperation) {
+	for _, op := range ops {
+		replaced := false
+		for i := range e.State.Pending {
+			if e.State.Pending[i].ID == op.ID {
+				e.State.Pending[i] = op
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			e.State.Pending = append(e.State.Pending, op)
+		}
+	}
+}
+
+// Run executes the pending operations in order. An operation the service
+// rejects is retried on later runs and eventually moved to State.Failed; a
+// transient failure (rate limits, server or network errors that outlast the
+// client's retries) or ctx being done stops the run, leaving the rest
+// queued for next time.
+func (e *SyncEngine) Run(ctx context.Context, handler func(op SyncOperation) error) (*SyncRunResult, error) {
+	result := &SyncRunResult{}
+	now := time.Now()
+	e.State.LastRun = &now
+
+	// Rejected operations go to the back of the queue; once we reach one
+	// again, everything left has already been tried this run
+	retried := make(map[string]bool)
+
+	for len(e.State.Pending) > 0 {
+		op := e.State.Pending[0]
+		if retried[op.ID] {
+			result.Remaining = len(e.State.Pending)
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return result, e.pause(result, err)
+		}
+
+		err := handler(op)
+		if err != nil && ctx.Err() != nil {
+			return result, e.pause(result, ctx.Err())
+		}
+		if err != nil && IsTransientError(err) {
+			return result, e.pause(result, err)
+		}
+
+		e.State.Pending = e.State.Pending[1:]
+		if err != nil {
+			op.Attempts++
+			op.LastError = err.Error()
+			if op.Attempts >= e.MaxAttempts {
+				e.State.Failed = append(e.State.Failed, op)
+				result.Failed++
+			} else {
+				e.State.Pending = append(e.State.Pending, op)
+				retried[op.ID] = true
+			}
+		} else {
+			result.Completed++
+		}
+
+		if err := e.State.Save(e.Name); err != nil {
+			return result, err
+		}
+	}
+
+	return result, e.State.Save(e.Name)
+}
+
+// pause saves the queue left when a run stops early
+func (e *SyncEngine) pause(result *SyncRunResult, err error) error {
+	result.Remaining = len(e.State.Pending)
+	if saveErr := e.State.Save(e.Name); saveErr != nil {
+		return saveErr
+	}
+	return fmt.Errorf("sync paused after %d operation(s), %d still queued; run sync again to resume: %w", result.Completed, result.Remaining, err)
+}
@@ -0,0 +1,249 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestAPIClient(url string) (*APIClient, *[]time.Duration) {
+	var sleeps []time.Duration
+	client := NewAPIClient(url, 0)
+	client.sleep = func(_ context.Context, d time.Duration) error { sleeps = append(sleeps, d); return nil }
+	client.MaxRetries = 3
+	return client, &sleeps
+}
+
+func TestAPIClientRetriesRateLimits(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if requests == 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"id": "42"}`))
+	}))
+	defer server.Close()
+
+	client, sleeps := newTestAPIClient(server.URL)
+
+	var out struct{ ID string }
+	if err := client.Do("POST", "/tasks", map[string]string{"content": "x"}, &out); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+
+	if out.ID != "42" {
+		t.Errorf("ID = %q, want %q", out.ID, "42")
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests)
+	}
+	// The first retry honours Retry-After, the second uses exponential backoff
+	if len(*sleeps) != 2 || (*sleeps)[0] != 7*time.Second {
+		t.Errorf("Unexpected backoff sleeps: %v", *sleeps)
+	}
+	if (*sleeps)[1] < client.BaseDelay*2 {
+		t.Errorf("Second backoff %v should be at least %v", (*sleeps)[1], client.BaseDelay*2)
+	}
+}
+
+func TestAPIClientGivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, _ := newTestAPIClient(server.URL)
+
+	err := client.Do("GET", "/tasks", nil, nil)
+	if !IsTransientError(err) {
+		t.Errorf("Expected a transient error after exhausting retries, got %v", err)
+	}
+}
+
+func TestAPIClientPermanentError(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, _ := newTestAPIClient(server.URL)
+
+	err := client.Do("GET", "/tasks/1", nil, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected a 404 APIError, got %v", err)
+	}
+	if IsTransientError(err) || requests != 1 {
+		t.Errorf("Client errors should not be retried (requests: %d)", requests)
+	}
+}
+
+func TestAPIClientStopsWhenCancelled(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, 0)
+	client.MaxRetries = 3
+	ctx, cancel := context.WithCancel(context.Background())
+	// Cancelled while waiting to retry
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return sleepContext(ctx, d)
+	}
+
+	err := client.DoContext(ctx, "GET", "/tasks", nil, nil)
+	if !errors.Is(err, context.Canceled) || IsTransientError(err) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected no retries after cancelling, got %d requests", requests)
+	}
+}
+
+func TestSyncEngineResumes(t *testing.T) {
+	setupTestDir(t)
+
+	engine, err := NewSyncEngine("test")
+	if err != nil {
+		t.Fatalf("NewSyncEngine failed: %v", err)
+	}
+	engine.Enqueue(
+		SyncOperation{ID: "1", Kind: "create"},
+		SyncOperation{ID: "2", Kind: "create"},
+		SyncOperation{ID: "3", Kind: "create"},
+	)
+
+	// The service goes down after the first operation
+	var sent []string
+	result, err := engine.Run(context.Background(), func(op SyncOperation) error {
+		if op.ID == "2" {
+			return &TransientError{Err: errors.New("connection reset")}
+		}
+		sent = append(sent, op.ID)
+		return nil
+	})
+	if err == nil {
+		t.Fatal("Run should report the interruption")
+	}
+	if result.Completed != 1 || result.Remaining != 2 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	// A fresh engine picks up the saved queue
+	engine, err = NewSyncEngine("test")
+	if err != nil {
+		t.Fatalf("NewSyncEngine failed: %v", err)
+	}
+	if len(engine.State.Pending) != 2 {
+		t.Fatalf("Expected 2 pending operations, got %+v", engine.State.Pending)
+	}
+
+	result, err = engine.Run(context.Background(), func(op SyncOperation) error {
+		sent = append(sent, op.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Completed != 2 || len(sent) != 3 || sent[1] != "2" || sent[2] != "3" {
+		t.Errorf("Expected operations to resume in order, sent %v, result %+v", sent, result)
+	}
+}
+
+func TestSyncEngineStopsWhenCancelled(t *testing.T) {
+	setupTestDir(t)
+
+	engine, err := NewSyncEngine("test")
+	if err != nil {
+		t.Fatalf("NewSyncEngine failed: %v", err)
+	}
+	engine.Enqueue(
+		SyncOperation{ID: "1", Kind: "create"},
+		SyncOperation{ID: "2", Kind: "create"},
+		SyncOperation{ID: "3", Kind: "create"},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result, err := engine.Run(ctx, func(op SyncOperation) error {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if result.Completed != 1 || result.Remaining != 2 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	// The rest stay queued for the next run
+	engine, _ = NewSyncEngine("test")
+	if len(engine.State.Pending) != 2 || engine.State.Pending[0].ID != "2" {
+		t.Errorf("Expected operations 2 and 3 to be pending, got %+v", engine.State.Pending)
+	}
+}
+
+func TestSyncEngineRejectedOperations(t *testing.T) {
+	setupTestDir(t)
+
+	engine, _ := NewSyncEngine("test")
+	engine.MaxAttempts = 2
+	engine.Enqueue(SyncOperation{ID: "bad"}, SyncOperation{ID: "good"})
+
+	reject := func(op SyncOperation) error {
+		if op.ID == "bad" {
+			return &APIError{StatusCode: http.StatusUnprocessableEntity}
+		}
+		return nil
+	}
+
+	// A rejected operation doesn't block the rest of the queue
+	result, err := engine.Run(context.Background(), reject)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Completed != 1 || result.Remaining != 1 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	// After MaxAttempts runs it is set aside as failed
+	result, _ = engine.Run(context.Background(), reject)
+	if result.Failed != 1 || len(engine.State.Pending) != 0 || len(engine.State.Failed) != 1 {
+		t.Errorf("Expected the operation to be marked failed, result %+v, state %+v", result, engine.State)
+	}
+}
+
+func TestRateLimiterSpacesRequests(t *testing.T) {
+	limiter := NewRateLimiter(10)
+	var waits []time.Duration
+	limiter.sleep = func(_ context.Context, d time.Duration) error { waits = append(waits, d); return nil }
+
+	for i := 0; i < 5; i++ {
+		limiter.Wait(context.Background())
+	}
+
+	// The first request goes straight out; at 10/s the fifth is scheduled
+	// 400ms after it
+	if len(waits) != 4 {
+		t.Fatalf("Expected 4 waits, got %v", waits)
+	}
+	last := waits[len(waits)-1]
+	if last < 350*time.Millisecond || last > 400*time.Millisecond {
+		t.Errorf("Expected the fifth request to wait about 400ms, got %v", last)
+	}
+}
@@ -0,0 +1,460 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Service syncs such as Linear and CalDAV link items to the service's
+// records through an item metadata key holding the record's id, and compare
+// three snapshots of the linked items with threeWayChanges: the state at the
+// last sync (SyncState.Base), the local lists and the service. Snapshots are
+// keyed by list and record id ("auth/ENG-12") and hold encoded syncedItems.
+// itemSync runs these steps for every service, which only provides how its
+// records are fetched and changed.
+
+// metaModified records when a linked item last changed here, so a service
+// that says when its records changed can tell which side changed last
+const metaModified = "modified"
+
+// linkedItemKeys are the metadata keys linking items to service records
+var linkedItemKeys = []string{metaLinear, metaCalDAV, metaTodoist}
+
+// syncedItem is the part of an item kept in step with a service
+type syncedItem struct {
+	Title string `json:"title"`
+	Done  bool   `json:"done,omitempty"`
+	// Due is the due date as YYYY-MM-DD, for services that have one
+	Due string `json:"due,omitempty"`
+}
+
+func (s syncedItem) encode() string {
+	content, _ := json.Marshal(s)
+	return string(content)
+}
+
+func decodeSyncedItem(content string) syncedItem {
+	var item syncedItem
+	json.Unmarshal([]byte(content), &item)
+	return item
+}
+
+// syncedItemOf returns the synced part of an item, with its due date when
+// the service has them
+func syncedItemOf(item TodoItem, withDue bool) syncedItem {
+	synced := syncedItem{Title: item.Text, Done: item.Completed}
+	if withDue && item.DueDate != nil {
+		synced.Due = item.DueDate.Format(DueDateFormat)
+	}
+	return synced
+}
+
+// itemSync implements SyncProvider for a service whose records are linked
+// to items
+type itemSync struct {
+	// name names the provider, its sync state and its offline queue, and
+	// service the service in messages
+	name    string
+	service string
+	metaKey string
+	// withDue is set for services whose records have a due date
+	withDue bool
+	// lists are the names of the synced lists, sorted
+	lists []string
+
+	// key and splitKey turn a list and record id into a snapshot key and
+	// back
+	key      func(listName, id string) string
+	splitKey func(key string) (string, string)
+	// fetch adds the records of a list to the remote snapshot
+	fetch func(ctx context.Context, listName string, snap *itemSnapshot) error
+	// operation queues a change to a record, with the item as it is here
+	// and the record as the service has it. Creates have no id yet; the
+	// service picks one if it needs to.
+	operation func(action, listName, id, local, remote string) SyncOperation
+	// send carries out a queued operation
+	send func(ctx context.Context, state *SyncState, op SyncOperation) error
+	// settle, when set, resolves the conflicts among incoming or outgoing
+	// changes that the service has a rule for
+	settle func(snap *itemSnapshot, changes []SyncChange, incoming bool) []SyncChange
+}
+
+// itemSnapshot is the state of the linked items on each side
+type itemSnapshot struct {
+	base, local, remote map[string]string
+	// ids holds the service's ids for records, as the service keys them,
+	// which are kept in SyncState.IDs
+	ids map[string]string
+	// updated holds when each record was last changed, for services that
+	// say, and modified when each linked item last changed here
+	updated  map[string]time.Time
+	modified map[string]time.Time
+	// unlinked are pending items that have no record yet
+	unlinked []ListItem
+}
+
+func (s *itemSync) Name() string {
+	return s.name
+}
+
+func (s *itemSync) Status(ctx context.Context) (*SyncStatus, error) {
+	state, err := LoadSyncState(s.name)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := s.snapshot(ctx, state, true)
+	if err != nil {
+		return nil, err
+	}
+
+	incoming, outgoing := s.changes(snap)
+	outgoing = append(snap.describe(outgoing), snap.creates()...)
+	return &SyncStatus{Remote: s.name, Incoming: snap.describe(incoming), Outgoing: outgoing}, nil
+}
+
+func (s *itemSync) Pull(ctx context.Context, opts SyncOptions) ([]SyncChange, error) {
+	state, err := LoadSyncState(s.name)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := s.snapshot(ctx, state, true)
+	if err != nil {
+		return nil, err
+	}
+
+	incoming, _ := s.changes(snap)
+	if opts.Force {
+		incoming = resolveConflicts(incoming, snap.local, snap.remote)
+	}
+	if opts.DryRun || len(incoming) == 0 {
+		return snap.describe(incoming), nil
+	}
+
+	store := NewStore()
+	for _, change := range incoming {
+		if change.Action == SyncConflict {
+			continue
+		}
+		listName, id := s.splitKey(change.List)
+		if err := applySyncedChange(store, listName, s.metaKey, id, change.Action, decodeSyncedItem(snap.remote[change.List]), s.withDue); err != nil {
+			return nil, err
+		}
+		if change.Action == SyncDelete {
+			delete(state.Base, change.List)
+			delete(state.IDs, change.List)
+		} else {
+			state.Base[change.List] = snap.remote[change.List]
+		}
+	}
+	for key, id := range snap.ids {
+		state.IDs[key] = id
+	}
+
+	if err := store.FlushContext(ctx); err != nil {
+		return nil, err
+	}
+	return snap.describe(incoming), state.Save(s.name)
+}
+
+func (s *itemSync) Push(ctx context.Context, opts SyncOptions) ([]SyncChange, error) {
+	engine, err := NewSyncEngine(s.name)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := s.snapshot(ctx, engine.State, true)
+	if err != nil {
+		return nil, err
+	}
+
+	_, outgoing := s.changes(snap)
+	if opts.Force {
+		outgoing = resolveConflicts(outgoing, snap.remote, snap.local)
+	}
+	changes := append(snap.describe(outgoing), snap.creates()...)
+	if opts.DryRun {
+		return changes, nil
+	}
+
+	for _, change := range outgoing {
+		// A linked item that the service and the last sync don't know
+		// about was linked by hand; it is left alone
+		if change.Action == SyncConflict || change.Action == SyncAdd {
+			continue
+		}
+		listName, id := s.splitKey(change.List)
+		engine.Enqueue(s.operation(change.Action, listName, id, snap.local[change.List], snap.remote[change.List]))
+	}
+	for _, unlinked := range snap.unlinked {
+		// An id the service picks for a create is kept while the create
+		// is queued, so retrying it can't add the record twice
+		op := s.operation(SyncAdd, unlinked.List, "", syncedItemOf(unlinked.Item, s.withDue).encode(), "")
+		for _, pending := range engine.State.Pending {
+			if pending.ID == op.ID {
+				op = pending
+			}
+		}
+		engine.Enqueue(op)
+	}
+	for key, id := range snap.ids {
+		engine.State.IDs[key] = id
+	}
+
+	result, err := engine.Run(ctx, func(op SyncOperation) error {
+		return s.send(ctx, engine.State, op)
+	})
+	if err != nil {
+		return changes, err
+	}
+	if result.Remaining > 0 || result.Failed > 0 {
+		return changes, fmt.Errorf("%s rejected %d change(s); %d will be retried on the next sync, see %s", s.service, result.Remaining+result.Failed, result.Remaining, GetSyncStatePath(s.name))
+	}
+	return changes, nil
+}
+
+func (s *itemSync) LocalChanges() ([]SyncChange, error) {
+	state, err := LoadSyncState(s.name)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := s.snapshot(context.Background(), state, false)
+	if err != nil {
+		return nil, err
+	}
+
+	_, outgoing := threeWayChanges(snap.base, snap.local, snap.base)
+	return append(snap.describe(outgoing), snap.creates()...), nil
+}
+
+// changes compares the snapshots, settling the conflicts the service has a
+// rule for
+func (s *itemSync) changes(snap *itemSnapshot) (incoming, outgoing []SyncChange) {
+	incoming, outgoing = threeWayChanges(snap.base, snap.local, snap.remote)
+	if s.settle != nil {
+		incoming, outgoing = s.settle(snap, incoming, true), s.settle(snap, outgoing, false)
+	}
+	return incoming, outgoing
+}
+
+// snapshot reads the base and local snapshots, and the remote one when
+// fetchRemote is set
+func (s *itemSync) snapshot(ctx context.Context, state *SyncState, fetchRemote bool) (*itemSnapshot, error) {
+	snap := &itemSnapshot{
+		base:     make(map[string]string),
+		local:    make(map[string]string),
+		remote:   make(map[string]string),
+		ids:      make(map[string]string),
+		updated:  make(map[string]time.Time),
+		modified: make(map[string]time.Time),
+	}
+
+	for key, content := range state.Base {
+		if listName, _ := splitItemSyncKey(key); slices.Contains(s.lists, listName) {
+			snap.base[key] = content
+		}
+	}
+
+	for _, listName := range s.lists {
+		if TodoFileExists(listName) {
+			todoList, err := ParseTodoFile(listName)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range todoList.Items {
+				if id := item.Metadata[s.metaKey]; id != "" {
+					key := s.key(listName, id)
+					snap.local[key] = syncedItemOf(item, s.withDue).encode()
+					if modified, err := time.Parse(time.RFC3339, item.Metadata[metaModified]); err == nil {
+						snap.modified[key] = modified
+					}
+				} else if !item.Completed {
+					snap.unlinked = append(snap.unlinked, ListItem{List: listName, Item: item})
+				}
+			}
+		}
+
+		if !fetchRemote {
+			continue
+		}
+		if err := s.fetch(ctx, listName, snap); err != nil {
+			if IsTransientError(err) {
+				return nil, fmt.Errorf("%w: failed to reach %s: %v", ErrSyncOffline, s.service, err)
+			}
+			return nil, err
+		}
+	}
+
+	return snap, nil
+}
+
+// describe names the record of each change
+func (s *itemSnapshot) describe(changes []SyncChange) []SyncChange {
+	return describeSyncedChanges(changes, s.remote, s.local, s.base)
+}
+
+// creates describes the records push would create for unlinked items
+func (s *itemSnapshot) creates() []SyncChange {
+	var changes []SyncChange
+	for _, unlinked := range s.unlinked {
+		changes = append(changes, SyncChange{List: unlinked.List, Action: SyncAdd, Detail: unlinked.Item.Text})
+	}
+	return changes
+}
+
+func itemSyncKey(listName, id string) string {
+	return listName + "/" + id
+}
+
+// splitItemSyncKey returns the list and record id of a snapshot key. Record
+// ids never contain a slash, though list names may.
+func splitItemSyncKey(key string) (string, string) {
+	i := strings.LastIndex(key, "/")
+	return key[:i], key[i+1:]
+}
+
+// describeSyncedChanges turns changes keyed by list and record id into
+// changes that also name the item
+func describeSyncedChanges(changes []SyncChange, snapshots ...map[string]string) []SyncChange {
+	described := make([]SyncChange, 0, len(changes))
+	for _, change := range changes {
+		title := ""
+		for _, snapshot := range snapshots {
+			if content, ok := snapshot[change.List]; ok {
+				title = decodeSyncedItem(content).Title
+				break
+			}
+		}
+
+		if change.Detail == "" {
+			change.Detail = title
+		} else {
+			change.Detail = title + ": " + change.Detail
+		}
+		described = append(described, change)
+	}
+	return described
+}
+
+// applySyncedChange makes the item of a list linked to a record match the
+// record pulled from a service, adding or removing the item as needed. Due
+// dates are only taken from services that have them.
+func applySyncedChange(store *Store, listName, metaKey, id, action string, remote syncedItem, withDue bool) error {
+	if err := CreateTodoFile(listName); err != nil {
+		return err
+	}
+	todoList, err := store.Get(listName)
+	if err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(todoList.Items, func(item TodoItem) bool {
+		return item.Metadata[metaKey] == id
+	})
+
+	if action == SyncDelete {
+		if index < 0 {
+			return nil
+		}
+		todoList.Items = slices.Delete(todoList.Items, index, index+1)
+		for i := range todoList.Items {
+			todoList.Items[i].ID = i + 1
+		}
+		store.MarkDirty(listName)
+		return nil
+	}
+
+	if index < 0 {
+		itemID, err := store.AddItem(listName, remote.Title)
+		if err != nil {
+			return err
+		}
+		index = itemID - 1
+		todoList.Items[index].Metadata[metaKey] = id
+	}
+
+	item := &todoList.Items[index]
+	item.Text = remote.Title
+	if remote.Done && !item.Completed {
+		completed := Now()
+		item.Completed = true
+		item.CompletedTime = &completed
+	} else if !remote.Done {
+		item.Completed = false
+		item.CompletedTime = nil
+	}
+	if withDue {
+		item.DueDate = nil
+		if due, err := time.ParseInLocation(DueDateFormat, remote.Due, time.Local); err == nil {
+			item.DueDate = &due
+		}
+	}
+	store.MarkDirty(listName)
+	return nil
+}
+
+// linkSyncedItem records the record a pending item was created as, for the
+// first unlinked pending item with the given text
+func linkSyncedItem(listName, metaKey, text, id string) error {
+	store := NewStore()
+	todoList, err := store.Get(listName)
+	if err != nil {
+		return err
+	}
+
+	for i, item := range todoList.Items {
+		if item.Metadata[metaKey] == "" && !item.Completed && item.Text == text {
+			if item.Metadata == nil {
+				todoList.Items[i].Metadata = make(map[string]string)
+			}
+			todoList.Items[i].Metadata[metaKey] = id
+			store.MarkDirty(listName)
+			return store.Flush()
+		}
+	}
+	return nil
+}
+
+// stampModified records now as the modified time of the linked items of a
+// list that are new or changed compared to before
+func stampModified(before, after *TodoList, now time.Time) {
+	states := make(map[string]string)
+	for _, item := range before.Items {
+		if link := itemLink(item); link != "" {
+			states[link] = modifiedState(item)
+		}
+	}
+	stamp := now.UTC().Format(time.RFC3339)
+	for i := range after.Items {
+		link := itemLink(after.Items[i])
+		if link == "" {
+			continue
+		}
+		if state, ok := states[link]; ok && state == modifiedState(after.Items[i]) {
+			continue
+		}
+		after.Items[i].Metadata[metaModified] = stamp
+	}
+}
+
+// itemLink names the service record an item is linked to, or "" for an
+// item that isn't linked
+func itemLink(item TodoItem) string {
+	for _, key := range linkedItemKeys {
+		if id := item.Metadata[key]; id != "" {
+			return key + ":" + id
+		}
+	}
+	return ""
+}
+
+// modifiedState is what a change to an item changes: everything written
+// for it but its position and the modified time itself
+func modifiedState(item TodoItem) string {
+	item.Metadata = maps.Clone(item.Metadata)
+	delete(item.Metadata, metaModified)
+	return item.Section + "\n" + formatItemLine(item) + "\n" + strings.Join(item.Notes, "\n")
+}
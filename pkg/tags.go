@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// tagRegex matches #tag tokens at the start of the text or after whitespace,
+// so anchors inside URLs and issue references like "#123" aren't tags
+var tagRegex = regexp.MustCompile(`(?:^|\s)#([A-Za-z][\w-]*)`)
+
+// tagNameRegex matches a tag name without its leading #
+var tagNameRegex = regexp.MustCompile(`^[A-Za-z][\w-]*$`)
+
+// contextRegex matches @context tokens the same way, so email addresses
+// aren't contexts
+var contextRegex = regexp.MustCompile(`(?:^|\s)@([A-Za-z][\w-]*)`)
+
+// ExtractTags returns the distinct #tags in an item's text, lowercased and
+// without the leading #
+func ExtractTags(text string) []string {
+	return extractTokens(tagRegex, text)
+}
+
+// Tags returns the distinct #tags in the item's text, lowercased and
+// without the leading #. Tags live in the text, so they follow edits.
+func (item TodoItem) Tags() []string {
+	return ExtractTags(item.Text)
+}
+
+// TagCount counts the items carrying a tag
+type TagCount struct {
+	Tag     string `json:"tag"`
+	Total   int    `json:"total"`
+	Pending int    `json:"pending"`
+}
+
+// Summary describes the count, e.g. "3 items, 1 pending"
+func (c TagCount) Summary() string {
+	return fmt.Sprintf("%s, %d pending", pluralItems(c.Total), c.Pending)
+}
+
+// CountTags counts the items of the lists carrying each tag, most used
+// first
+func CountTags(lists []ParsedList) []TagCount {
+	counts := make(map[string]*TagCount)
+	for _, list := range lists {
+		for _, item := range list.List.Items {
+			for _, tag := range item.Tags() {
+				if counts[tag] == nil {
+					counts[tag] = &TagCount{Tag: tag}
+				}
+				counts[tag].Total++
+				if !item.Completed {
+					counts[tag].Pending++
+				}
+			}
+		}
+	}
+
+	sorted := make([]TagCount, 0, len(counts))
+	for _, count := range counts {
+		sorted = append(sorted, *count)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Total != sorted[j].Total {
+			return sorted[i].Total > sorted[j].Total
+		}
+		return sorted[i].Tag < sorted[j].Tag
+	})
+	return sorted
+}
+
+// ValidTagName reports whether a tag, with or without its leading #, would
+// be found by ExtractTags
+func ValidTagName(tag string) bool {
+	return tagNameRegex.MatchString(strings.TrimPrefix(tag, "#"))
+}
+
+// ExtractContexts returns the distinct @contexts in an item's text, such as
+// @home or @phone, lowercased and without the leading @
+func ExtractContexts(text string) []string {
+	return extractTokens(contextRegex, text)
+}
+
+func extractTokens(re *regexp.Regexp, text string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, match := range re.FindAllStringSubmatch(text, -1) {
+		tag := strings.ToLower(match[1])
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractTags(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"Fix login #auth #Bug", []string{"auth", "bug"}},
+		{"#urgent rotate keys #urgent", []string{"urgent"}},
+		{"See issue #123 and https://example.com/#anchor", nil},
+		{"Deploy #front-end_v2", []string{"front-end_v2"}},
+		{"No tags here", nil},
+	}
+
+	for _, test := range tests {
+		if got := ExtractTags(test.text); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("ExtractTags(%q) = %v, want %v", test.text, got, test.expected)
+		}
+	}
+}
+
+func TestExtractContexts(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"Call the bank @phone @Errands", []string{"errands", "phone"}},
+		{"Email bob@example.com", nil},
+		{"@home fix the sink #chores", []string{"home"}},
+	}
+
+	for _, test := range tests {
+		if got := ExtractContexts(test.text); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("ExtractContexts(%q) = %v, want %v", test.text, got, test.expected)
+		}
+	}
+}
+
+func TestCountTags(t *testing.T) {
+	lists := []ParsedList{
+		{Name: "home", List: &TodoList{Items: []TodoItem{
+			{ID: 1, Text: "Fix the sink #chores #Urgent"},
+			{ID: 2, Text: "Mow the lawn #chores", Completed: true},
+		}}},
+		{Name: "work", List: &TodoList{Items: []TodoItem{
+			{ID: 1, Text: "Expense report #chores #urgent"},
+			{ID: 2, Text: "Issue #42"},
+		}}},
+	}
+
+	counts := CountTags(lists)
+	want := []TagCount{{Tag: "chores", Total: 3, Pending: 2}, {Tag: "urgent", Total: 2, Pending: 2}}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("CountTags = %+v, want %+v", counts, want)
+	}
+	if counts[0].Summary() != "3 items, 2 pending" {
+		t.Errorf("Unexpected summary %q", counts[0].Summary())
+	}
+}
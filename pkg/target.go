@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// velocityWindow is how far back completions are counted to estimate how
+// fast a list is being worked through
+const velocityWindow = 14 * 24 * time.Hour
+
+// TargetStatus compares a list's remaining work with its target date
+type TargetStatus struct {
+	Target    time.Time
+	DaysLeft  int
+	Remaining int
+	// Velocity is the number of items completed per day over the last two
+	// weeks
+	Velocity float64
+	// Margin is how many items ahead (positive) or behind (negative) the
+	// list will be at the target date if the current velocity holds
+	Margin int
+}
+
+// GetTargetStatus works out whether the list will meet its target date.
+// It returns nil if the list has no target.
+func (l *TodoList) GetTargetStatus(now time.Time) (*TargetStatus, error) {
+	target, err := l.Meta.TargetDate()
+	if err != nil || target == nil {
+		return nil, err
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	status := &TargetStatus{
+		Target:   *target,
+		DaysLeft: int(math.Round(target.Sub(today).Hours() / 24)),
+	}
+
+	recent := 0
+	for _, item := range l.Items {
+		if !item.Completed {
+			status.Remaining++
+			continue
+		}
+		if item.CompletedTime != nil && now.Sub(*item.CompletedTime) <= velocityWindow {
+			recent++
+		}
+	}
+	status.Velocity = float64(recent) / (velocityWindow.Hours() / 24)
+
+	// Items still expected to be done by the end of the target day
+	projected := status.Velocity * float64(max(status.DaysLeft+1, 0))
+	status.Margin = int(math.Floor(projected - float64(status.Remaining)))
+	return status, nil
+}
+
+// String describes the status in one line
+func (s *TargetStatus) String() string {
+	date := s.Target.Format(DueDateFormat)
+	switch {
+	case s.Remaining == 0:
+		return fmt.Sprintf("Target: %s - all items done", date)
+	case s.DaysLeft < 0:
+		return fmt.Sprintf("Target: %s - missed, %s left", date, pluralItems(s.Remaining))
+	case s.Margin >= 0:
+		return fmt.Sprintf("Target: %s (%s left) - on track, ahead by %s", date, pluralDays(s.DaysLeft), pluralItems(s.Margin))
+	default:
+		return fmt.Sprintf("Target: %s (%s left) - behind by %s at %.1f items/day", date, pluralDays(s.DaysLeft), pluralItems(-s.Margin), s.Velocity)
+	}
+}
+
+func pluralItems(n int) string {
+	if n == 1 {
+		return "1 item"
+	}
+	return fmt.Sprintf("%d items", n)
+}
+
+func pluralDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetTargetStatus(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.Local)
+	daysAgo := func(days int) *time.Time {
+		completed := now.AddDate(0, 0, -days)
+		return &completed
+	}
+
+	// Seven items done in the last two weeks is half an item a day
+	list := &TodoList{Meta: ListMeta{Target: "2024-07-10"}}
+	for i := 0; i < 7; i++ {
+		list.Items = append(list.Items, TodoItem{Completed: true, CompletedTime: daysAgo(i)})
+	}
+	list.Items = append(list.Items, TodoItem{Completed: true, CompletedTime: daysAgo(30)})
+	for i := 0; i < 3; i++ {
+		list.Items = append(list.Items, TodoItem{})
+	}
+
+	status, err := list.GetTargetStatus(now)
+	if err != nil {
+		t.Fatalf("GetTargetStatus failed: %v", err)
+	}
+	if status.DaysLeft != 9 || status.Remaining != 3 || status.Velocity != 0.5 || status.Margin != 2 {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+	if got := status.String(); got != "Target: 2024-07-10 (9 days left) - on track, ahead by 2 items" {
+		t.Errorf("Unexpected description: %s", got)
+	}
+
+	// The same pace falls behind an earlier target
+	list.Meta.Target = "2024-07-03"
+	status, _ = list.GetTargetStatus(now)
+	if status.Margin != -2 || !strings.Contains(status.String(), "behind by 2 items at 0.5 items/day") {
+		t.Errorf("Unexpected status for a close target: %+v (%s)", status, status)
+	}
+
+	list.Meta.Target = "2024-06-30"
+	if status, _ = list.GetTargetStatus(now); !strings.Contains(status.String(), "missed, 3 items left") {
+		t.Errorf("Unexpected status for a past target: %s", status)
+	}
+
+	list.Meta.Target = ""
+	if status, err := list.GetTargetStatus(now); status != nil || err != nil {
+		t.Errorf("Expected no status without a target, got %+v, %v", status, err)
+	}
+	list.Meta.Target = "August"
+	if _, err := list.GetTargetStatus(now); err == nil {
+		t.Error("Expected an error for an invalid target")
+	}
+}
+
+func TestSetListTarget(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("release")
+	AddTodoItem("release", "Tag the release")
+
+	if err := SetListTarget("release", "2024-08-01"); err != nil {
+		t.Fatalf("SetListTarget failed: %v", err)
+	}
+	if err := SetListTarget("release", "soon"); err == nil {
+		t.Error("SetListTarget should reject invalid dates")
+	}
+
+	content, _ := os.ReadFile(GetTodoFilePath("release"))
+	if !strings.HasPrefix(string(content), "---\ntarget: \"2024-08-01\"\n---\n# Todo List for release\n") {
+		t.Errorf("Unexpected file with frontmatter:\n%s", content)
+	}
+
+	// Unknown frontmatter keys are kept
+	os.WriteFile(GetTodoFilePath("release"), []byte("---\ntarget: 2024-08-01\nteam: platform\n---\n# Todo List for release\n\n- [ ] Tag the release\n"), 0644)
+	todoList, _ := ParseTodoFile("release")
+	if todoList.Meta.Target != "2024-08-01" || todoList.Meta.Extra["team"] != "platform" || len(todoList.Items) != 1 {
+		t.Fatalf("Unexpected list: %+v", todoList)
+	}
+
+	SetListTarget("release", "")
+	content, _ = os.ReadFile(GetTodoFilePath("release"))
+	if !strings.HasPrefix(string(content), "---\nteam: platform\n---\n") {
+		t.Errorf("Expected other frontmatter to survive clearing the target:\n%s", content)
+	}
+}
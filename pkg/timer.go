@@ -0,0 +1,122 @@
+package pkg
+
+import (
+	"fmt"
+	"time"
+)
+
+// Time tracking is recorded in the journal: starting a timer appends a
+// timer-start entry naming the list and item, and stopping it appends a
+// timer-stop entry. Each start and the stop after it make up a session.
+
+// Session is a stretch of time tracked against an item
+type Session struct {
+	List  string
+	Item  string
+	Start time.Time
+	// End is zero while the timer is still running
+	End time.Time
+}
+
+// Running reports whether the session's timer hasn't been stopped
+func (s Session) Running() bool {
+	return s.End.IsZero()
+}
+
+// Duration returns the length of the session, counting a running session
+// up to now
+func (s Session) Duration(now time.Time) time.Duration {
+	end := s.End
+	if s.Running() {
+		end = now
+	}
+	if end.Before(s.Start) {
+		return 0
+	}
+	return end.Sub(s.Start)
+}
+
+// Sessions returns every tracked session, oldest first. Only the last one
+// can still be running.
+func Sessions() ([]Session, error) {
+	entries, err := ReadJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []Session
+	running := false
+	for _, entry := range entries {
+		switch entry.Kind {
+		case JournalTimerStart:
+			// A start without a stop, from an interrupted write, ends
+			// when the next one begins
+			if running {
+				sessions[len(sessions)-1].End = entry.Time
+			}
+			sessions = append(sessions, Session{List: entry.List, Item: entry.Detail, Start: entry.Time})
+			running = true
+		case JournalTimerStop:
+			if running {
+				sessions[len(sessions)-1].End = entry.Time
+				running = false
+			}
+		}
+	}
+	return sessions, nil
+}
+
+// RunningSession returns the session whose timer is running, or nil
+func RunningSession() (*Session, error) {
+	sessions, err := Sessions()
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 || !sessions[len(sessions)-1].Running() {
+		return nil, nil
+	}
+	return &sessions[len(sessions)-1], nil
+}
+
+// StartTimer starts tracking time against an item. A timer that is already
+// running is stopped first and its session returned.
+func StartTimer(listName, itemText string, now time.Time) (*Session, error) {
+	stopped, err := RunningSession()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []JournalEntry
+	if stopped != nil {
+		stopped.End = now
+		entries = append(entries, JournalEntry{Time: now, Kind: JournalTimerStop, List: stopped.List, Detail: stopped.Item})
+	}
+	entries = append(entries, JournalEntry{Time: now, Kind: JournalTimerStart, List: listName, Detail: itemText})
+	if err := AppendJournal(entries...); err != nil {
+		return nil, err
+	}
+	return stopped, nil
+}
+
+// StopTimer stops the running timer and returns its session
+func StopTimer(now time.Time) (*Session, error) {
+	session, err := RunningSession()
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, fmt.Errorf("no timer is running")
+	}
+
+	session.End = now
+	if err := AppendJournal(JournalEntry{Time: now, Kind: JournalTimerStop, List: session.List, Detail: session.Item}); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// FormatDuration formats a duration as hours and minutes, e.g. "1h05m"
+func FormatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimer(t *testing.T) {
+	setupTestDir(t)
+
+	start := time.Date(2024, 7, 8, 9, 0, 0, 0, time.Local)
+
+	if _, err := StopTimer(start); err == nil {
+		t.Error("Expected an error stopping with no timer running")
+	}
+
+	stopped, err := StartTimer("auth", "Login form", start)
+	if err != nil || stopped != nil {
+		t.Fatalf("StartTimer failed: %v %+v", err, stopped)
+	}
+
+	// Starting another timer stops the first
+	stopped, err = StartTimer("billing", "Invoices", start.Add(90*time.Minute))
+	if err != nil {
+		t.Fatalf("StartTimer failed: %v", err)
+	}
+	if stopped == nil || stopped.Item != "Login form" || stopped.Duration(start) != 90*time.Minute {
+		t.Errorf("Expected the first timer to stop after 90 minutes, got %+v", stopped)
+	}
+
+	running, _ := RunningSession()
+	if running == nil || running.List != "billing" {
+		t.Fatalf("Expected the billing timer to run, got %+v", running)
+	}
+	if got := running.Duration(start.Add(2 * time.Hour)); got != 30*time.Minute {
+		t.Errorf("Expected 30 minutes so far, got %v", got)
+	}
+
+	session, err := StopTimer(start.Add(3 * time.Hour))
+	if err != nil {
+		t.Fatalf("StopTimer failed: %v", err)
+	}
+	if session.List != "billing" || session.Duration(time.Time{}) != 90*time.Minute {
+		t.Errorf("Unexpected stopped session: %+v", session)
+	}
+
+	sessions, err := Sessions()
+	if err != nil {
+		t.Fatalf("Sessions failed: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].Running() || sessions[1].Running() {
+		t.Errorf("Expected two finished sessions, got %+v", sessions)
+	}
+	if running, _ := RunningSession(); running != nil {
+		t.Errorf("Expected no running timer, got %+v", running)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                             "0h00m",
+		65 * time.Minute:              "1h05m",
+		10*time.Hour + 29*time.Second: "10h00m",
+		2*time.Hour + 59*time.Minute + 31*time.Second: "3h00m",
+	}
+	for duration, want := range tests {
+		if got := FormatDuration(duration); got != want {
+			t.Errorf("FormatDuration(%v) = %s, want %s", duration, got, want)
+		}
+	}
+}
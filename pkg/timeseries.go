@@ -0,0 +1,111 @@
+package pkg
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// A progress time series has a row per list per day with the pending and
+// completed counts of that day, for charting burnup in a spreadsheet. The
+// counts are worked out from when each item was added and completed, so
+// they reach back before snapshots were recorded. Items deleted since are
+// gone from the history, and items added before lists recorded the day they
+// were added count from the first day. The percentage 'todo list' recorded
+// that day, if any, is given alongside.
+
+// ProgressPoint is the state of a list at the end of a day
+type ProgressPoint struct {
+	Day       string
+	List      string
+	Pending   int
+	Completed int
+	// Recorded is the day's snapshot percentage, or -1 without one
+	Recorded int
+}
+
+// timeSeriesHeader names the CSV columns
+var timeSeriesHeader = []string{"date", "list", "pending", "completed", "total", "percent", "recorded_percent"}
+
+// ProgressTimeSeries returns a point per list per day from since to now,
+// ordered by day and then list. A zero since starts at the earliest day
+// anything was added, completed or recorded.
+func ProgressTimeSeries(lists []ParsedList, snapshots Snapshots, since, now time.Time) ([]ProgressPoint, error) {
+	for _, parsed := range lists {
+		if parsed.Err != nil {
+			return nil, parsed.Err
+		}
+	}
+
+	last := now.Format(DueDateFormat)
+	first := since.Format(DueDateFormat)
+	if since.IsZero() {
+		first = earliestProgressDay(lists, snapshots, last)
+	}
+
+	sorted := append([]ParsedList(nil), lists...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var points []ProgressPoint
+	start, _ := time.ParseInLocation(DueDateFormat, first, time.Local)
+	for day := start; day.Format(DueDateFormat) <= last; day = day.AddDate(0, 0, 1) {
+		date := day.Format(DueDateFormat)
+		for _, parsed := range sorted {
+			point := ProgressPoint{Day: date, List: parsed.Name, Recorded: -1}
+			for _, item := range parsed.List.Items {
+				if added, ok := ItemAdded(item); ok && added.Format(DueDateFormat) > date {
+					continue
+				}
+				if item.Completed && (item.CompletedTime == nil || item.CompletedTime.Format(DueDateFormat) <= date) {
+					point.Completed++
+				} else {
+					point.Pending++
+				}
+			}
+			if percent, ok := snapshots[parsed.Name][date]; ok {
+				point.Recorded = percent
+			}
+			points = append(points, point)
+		}
+	}
+	return points, nil
+}
+
+// earliestProgressDay returns the first day in the history of the lists,
+// and no later than last
+func earliestProgressDay(lists []ParsedList, snapshots Snapshots, last string) string {
+	first := last
+	for _, parsed := range lists {
+		for _, item := range parsed.List.Items {
+			if added, ok := ItemAdded(item); ok {
+				first = min(first, added.Format(DueDateFormat))
+			}
+			if item.CompletedTime != nil {
+				first = min(first, item.CompletedTime.Format(DueDateFormat))
+			}
+		}
+		for day := range snapshots[parsed.Name] {
+			first = min(first, day)
+		}
+	}
+	return first
+}
+
+// WriteProgressCSV writes a progress time series as CSV with a header row
+func WriteProgressCSV(w io.Writer, points []ProgressPoint) error {
+	out := csv.NewWriter(w)
+	out.Write(timeSeriesHeader)
+	for _, point := range points {
+		total := point.Pending + point.Completed
+		percent := Progress{Completed: point.Completed, Total: total}.Percent(false)
+		recorded := ""
+		if point.Recorded >= 0 {
+			recorded = strconv.Itoa(point.Recorded)
+		}
+		out.Write([]string{point.Day, point.List, strconv.Itoa(point.Pending), strconv.Itoa(point.Completed), strconv.Itoa(total), strconv.Itoa(percent), recorded})
+	}
+	out.Flush()
+	return out.Error()
+}
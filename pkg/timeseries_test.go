@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestProgressTimeSeries(t *testing.T) {
+	completed := day("2024-07-02").Add(15 * time.Hour)
+	api := &TodoList{Items: []TodoItem{
+		{ID: 1, Text: "Design", Completed: true, CompletedTime: &completed, Metadata: map[string]string{metaAdded: "2024-07-01"}},
+		{ID: 2, Text: "Build", Metadata: map[string]string{metaAdded: "2024-07-03"}},
+		{ID: 3, Text: "Legacy"},
+	}}
+	lists := []ParsedList{{Name: "docs", List: &TodoList{}}, {Name: "api", List: api}}
+	snapshots := Snapshots{"api": {"2024-07-02": 50}}
+
+	// Without --since the series starts at the first day in the history
+	points, err := ProgressTimeSeries(lists, snapshots, time.Time{}, day("2024-07-03").Add(9*time.Hour))
+	if err != nil {
+		t.Fatalf("ProgressTimeSeries failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := WriteProgressCSV(&out, points); err != nil {
+		t.Fatalf("WriteProgressCSV failed: %v", err)
+	}
+	want := `date,list,pending,completed,total,percent,recorded_percent
+2024-07-01,api,2,0,2,0,
+2024-07-01,docs,0,0,0,0,
+2024-07-02,api,1,1,2,50,50
+2024-07-02,docs,0,0,0,0,
+2024-07-03,api,2,1,3,33,
+2024-07-03,docs,0,0,0,0,
+`
+	if out.String() != want {
+		t.Errorf("Unexpected CSV:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	points, _ = ProgressTimeSeries(lists, snapshots, day("2024-07-03"), day("2024-07-03"))
+	if len(points) != 2 || points[0].Day != "2024-07-03" {
+		t.Errorf("Expected --since to start the series, got %+v", points)
+	}
+}
@@ -0,0 +1,88 @@
+package pkg
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// TimesheetRow is the time tracked against one list on one day
+type TimesheetRow struct {
+	Day      time.Time
+	List     string
+	Duration time.Duration
+}
+
+// WeekStart returns midnight on the Monday of the week containing t
+func WeekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// Timesheet totals the tracked sessions between from and to per day and per
+// list, sorted by day and then list. Sessions crossing midnight are split
+// between the days, and a running session counts up to now.
+func Timesheet(sessions []Session, from, to, now time.Time) []TimesheetRow {
+	type key struct {
+		day  time.Time
+		list string
+	}
+	totals := make(map[key]time.Duration)
+
+	for _, session := range sessions {
+		start, end := session.Start, session.End
+		if session.Running() {
+			end = now
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+
+		for start.Before(end) {
+			day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+			dayEnd := day.AddDate(0, 0, 1)
+			if dayEnd.After(end) {
+				dayEnd = end
+			}
+			totals[key{day, session.List}] += dayEnd.Sub(start)
+			start = dayEnd
+		}
+	}
+
+	var rows []TimesheetRow
+	for k, duration := range totals {
+		rows = append(rows, TimesheetRow{Day: k.day, List: k.list, Duration: duration})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].Day.Equal(rows[j].Day) {
+			return rows[i].Day.Before(rows[j].Day)
+		}
+		return rows[i].List < rows[j].List
+	})
+	return rows
+}
+
+// WriteTimesheetCSV writes timesheet rows as CSV with date, list, minutes
+// and decimal hours columns
+func WriteTimesheetCSV(w io.Writer, rows []TimesheetRow) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"date", "list", "minutes", "hours"})
+	for _, row := range rows {
+		minutes := int(row.Duration.Round(time.Minute) / time.Minute)
+		writer.Write([]string{
+			row.Day.Format(DueDateFormat),
+			row.List,
+			strconv.Itoa(minutes),
+			fmt.Sprintf("%.2f", float64(minutes)/60),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
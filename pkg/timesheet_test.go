@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWeekStart(t *testing.T) {
+	monday := time.Date(2024, 7, 8, 0, 0, 0, 0, time.Local)
+	for offset := 0; offset < 7; offset++ {
+		day := monday.AddDate(0, 0, offset).Add(15 * time.Hour)
+		if got := WeekStart(day); !got.Equal(monday) {
+			t.Errorf("WeekStart(%v) = %v, want %v", day, got, monday)
+		}
+	}
+}
+
+func TestTimesheet(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 7, day, hour, minute, 0, 0, time.Local)
+	}
+
+	sessions := []Session{
+		// Before the week starts, and so partly counted
+		{List: "auth", Start: at(7, 23, 0), End: at(8, 1, 0)},
+		{List: "billing", Start: at(8, 9, 0), End: at(8, 9, 45)},
+		{List: "auth", Start: at(8, 10, 0), End: at(8, 10, 30)},
+		// Across midnight
+		{List: "auth", Start: at(9, 23, 30), End: at(10, 0, 15)},
+		// Still running
+		{List: "billing", Start: at(11, 8, 0)},
+	}
+
+	from := WeekStart(at(10, 12, 0))
+	rows := Timesheet(sessions, from, from.AddDate(0, 0, 7), at(11, 9, 0))
+
+	want := []struct {
+		day      int
+		list     string
+		duration time.Duration
+	}{
+		{8, "auth", 90 * time.Minute},
+		{8, "billing", 45 * time.Minute},
+		{9, "auth", 30 * time.Minute},
+		{10, "auth", 15 * time.Minute},
+		{11, "billing", time.Hour},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("Expected %d rows, got %+v", len(want), rows)
+	}
+	for i, w := range want {
+		if rows[i].Day.Day() != w.day || rows[i].List != w.list || rows[i].Duration != w.duration {
+			t.Errorf("Row %d: got %+v, want %+v", i, rows[i], w)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTimesheetCSV(&buf, rows[:2]); err != nil {
+		t.Fatalf("WriteTimesheetCSV failed: %v", err)
+	}
+	wantCSV := "date,list,minutes,hours\n2024-07-08,auth,90,1.50\n2024-07-08,billing,45,0.75\n"
+	if buf.String() != wantCSV {
+		t.Errorf("Unexpected CSV:\n%s", buf.String())
+	}
+}
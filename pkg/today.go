@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"sort"
+	"time"
+)
+
+// Today is what needs attention on a day across all lists
+type Today struct {
+	// Reminders are the pending items with a reminder set for the day,
+	// earliest first
+	Reminders []ListItem
+	// Overdue are the pending items due before the day, most late first
+	Overdue []ListItem
+	// Due are the pending items due on the day
+	Due []ListItem
+}
+
+// IsEmpty reports whether nothing needs attention
+func (t *Today) IsEmpty() bool {
+	return len(t.Reminders) == 0 && len(t.Overdue) == 0 && len(t.Due) == 0
+}
+
+// GetToday gathers the pending items of every list that need attention on
+// the day of now
+func GetToday(now time.Time) (*Today, error) {
+	lists, err := GetAllLists()
+	if err != nil {
+		return nil, err
+	}
+
+	today := now.Format(DueDateFormat)
+	result := &Today{}
+	for _, parsed := range ParseLists(lists) {
+		if parsed.Err != nil {
+			return nil, parsed.Err
+		}
+		for _, item := range parsed.List.Items {
+			if item.Completed {
+				continue
+			}
+			entry := ListItem{List: parsed.Name, Item: item}
+			if at, ok := ItemReminder(item); ok && at.Format(DueDateFormat) == today {
+				result.Reminders = append(result.Reminders, entry)
+			}
+			if item.DueDate == nil {
+				continue
+			}
+			switch due := item.DueDate.Format(DueDateFormat); {
+			case due < today:
+				result.Overdue = append(result.Overdue, entry)
+			case due == today:
+				result.Due = append(result.Due, entry)
+			}
+		}
+	}
+
+	sort.SliceStable(result.Reminders, func(i, j int) bool {
+		a, _ := ItemReminder(result.Reminders[i].Item)
+		b, _ := ItemReminder(result.Reminders[j].Item)
+		return a.Before(b)
+	})
+	sort.SliceStable(result.Overdue, func(i, j int) bool {
+		return result.Overdue[i].Item.DueDate.Before(*result.Overdue[j].Item.DueDate)
+	})
+	return result, nil
+}
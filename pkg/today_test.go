@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetToday(t *testing.T) {
+	setupTestDir(t)
+	now := time.Date(2024, 7, 3, 8, 0, 0, 0, time.Local)
+	restore := SetClock(NewSimulatedClock(now))
+	defer restore()
+
+	store := NewStore()
+	add := func(listName, text, due, remind string) int {
+		id, _ := store.AddItem(listName, text)
+		if due != "" {
+			date := day(due)
+			store.SetDue(listName, id, &date)
+		}
+		if remind != "" {
+			at, _ := time.ParseInLocation(completedTimeFormat, remind, time.Local)
+			store.SetReminder(listName, id, &at)
+		}
+		return id
+	}
+	CreateTodoFile("work")
+	CreateTodoFile("home")
+	add("work", "Standup notes", "", "2024-07-03 16:00")
+	add("work", "Ship", "2024-07-03", "")
+	add("work", "Invoice", "2024-06-30", "")
+	add("home", "Call Ann", "2024-07-10", "2024-07-03 09:30")
+	add("home", "Tomorrow's call", "", "2024-07-04 09:00")
+	add("home", "Very late", "2024-06-01", "")
+	done := add("home", "Done", "2024-07-03", "2024-07-03 10:00")
+	store.CheckItem("home", done)
+	store.Flush()
+
+	today, err := GetToday(now)
+	if err != nil {
+		t.Fatalf("GetToday failed: %v", err)
+	}
+	texts := func(entries []ListItem) []string {
+		var out []string
+		for _, entry := range entries {
+			out = append(out, entry.Item.Text)
+		}
+		return out
+	}
+	for name, check := range map[string]struct{ got, want []string }{
+		"reminders": {texts(today.Reminders), []string{"Call Ann", "Standup notes"}},
+		"overdue":   {texts(today.Overdue), []string{"Very late", "Invoice"}},
+		"due":       {texts(today.Due), []string{"Ship"}},
+	} {
+		if len(check.got) != len(check.want) {
+			t.Errorf("%s = %v, want %v", name, check.got, check.want)
+			continue
+		}
+		for i := range check.want {
+			if check.got[i] != check.want[i] {
+				t.Errorf("%s = %v, want %v", name, check.got, check.want)
+				break
+			}
+		}
+	}
+
+	// Clearing a reminder takes it off the day
+	store.SetReminder("home", 1, nil)
+	store.Flush()
+	if today, _ := GetToday(now); len(today.Reminders) != 1 || today.IsEmpty() {
+		t.Errorf("Expected one reminder left, got %+v", today.Reminders)
+	}
+}
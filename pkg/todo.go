@@ -2,11 +2,17 @@ package pkg
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 )
@@ -16,10 +22,49 @@ type TodoItem struct {
 	Text          string
 	Completed     bool
 	CompletedTime *time.Time
+	DueDate       *time.Time
+	// ShortID is a stable identifier that, unlike ID, doesn't change when
+	// items are added or removed. Only lists numbered by ID have them.
+	ShortID string
+	// Weight is how much the item counts toward progress; 0 means the
+	// default of 1
+	Weight int
+	// Estimate is how long the item is expected to take, if known
+	Estimate time.Duration
+	// Priority is 1 (p1) to MaxPriority, or 0 for none
+	Priority int
+	// Section is the "## " heading the item is under, if any
+	Section string
+	// Depth is how deeply the item is nested as a subtask, written as
+	// indentation; 0 for top-level items
+	Depth int
+	// Notes are the plain-text lines written indented under the item
+	Notes []string
+	// Metadata holds metadata keys the item carries that have no field
+	Metadata map[string]string
+	// Line is the item's 1-based line in the todo file it was read from, or
+	// 0 for items that weren't read from a file
+	Line int
 }
 
+// DueDateFormat is the layout of due dates in todo files
+const DueDateFormat = "2006-01-02"
+
 type TodoList struct {
+	Meta  ListMeta
 	Items []TodoItem
+	// Headings are the list's "## " headings in the order they appear,
+	// including those with no items under them
+	Headings []string
+}
+
+// ListItem is an item together with the list it belongs to
+type ListItem struct {
+	List string
+	Item TodoItem
+	// Archived is whether the item was moved to the list's archive, where
+	// its ID no longer refers to the list
+	Archived bool
 }
 
 func GetTodoFilePath(branchName string) string {
@@ -27,6 +72,9 @@ func GetTodoFilePath(branchName string) string {
 }
 
 func TodoFileExists(featureName string) bool {
+	if UsingSQLite() {
+		return sqliteListExists(featureName)
+	}
 	filePath := GetTodoFilePath(featureName)
 	_, err := os.Stat(filePath)
 	return err == nil
@@ -36,10 +84,44 @@ func EnsureTodoDirectory() error {
 	return os.MkdirAll(".todo", 0755)
 }
 
+// ErrNoTodoRoot is returned by FindTodoRoot outside any directory using todo
+var ErrNoTodoRoot = errors.New("no .todo directory in this directory or any parent")
+
+// FindTodoRoot returns the nearest directory containing .todo, starting
+// from the current directory and walking up the tree the way git finds its
+// repository. The home directory is passed over: its .todo holds the
+// global lists, which only --global uses.
+func FindTodoRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	var homeInfo os.FileInfo
+	if home, err := os.UserHomeDir(); err == nil {
+		homeInfo, _ = os.Stat(home)
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ".todo")); err == nil && info.IsDir() {
+			dirInfo, err := os.Stat(dir)
+			if err == nil && (homeInfo == nil || !os.SameFile(dirInfo, homeInfo)) {
+				return dir, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ErrNoTodoRoot
+		}
+		dir = parent
+	}
+}
+
 func CreateTodoFile(branchName string) error {
 	if err := EnsureTodoDirectory(); err != nil {
 		return fmt.Errorf("failed to create .todo directory: %w", err)
 	}
+	if UsingSQLite() {
+		return createListSQLite(branchName)
+	}
 
 	filePath := GetTodoFilePath(branchName)
 	
@@ -53,6 +135,7 @@ func CreateTodoFile(branchName string) error {
 	}
 	defer file.Close()
 
+	noteChanged(branchName)
 	content := fmt.Sprintf("# Todo List for %s\n\n", branchName)
 	_, err = file.WriteString(content)
 	if err != nil {
@@ -62,9 +145,74 @@ func CreateTodoFile(branchName string) error {
 	return nil
 }
 
+// ParseTodoFile reads a list, noting any signs that its file is damaged
+// for IntegrityWarnings
 func ParseTodoFile(branchName string) (*TodoList, error) {
-	filePath := GetTodoFilePath(branchName)
-	
+	if UsingSQLite() {
+		return readListSQLite(branchName)
+	}
+	content, err := os.ReadFile(GetTodoFilePath(branchName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &TodoList{Items: []TodoItem{}}, nil
+		}
+		return nil, fmt.Errorf("failed to open todo file: %w", err)
+	}
+
+	noteIntegrity(branchName, content)
+	return parseTodoList(bytes.NewReader(content))
+}
+
+// ListModTime returns when a list was last written: its file's
+// modification time, or the time the database recorded with SQLite
+// storage. It is zero when that isn't known.
+func ListModTime(listName string) time.Time {
+	if UsingSQLite() {
+		return sqliteListModified(listName)
+	}
+	info, err := os.Stat(GetTodoFilePath(listName))
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// readListContent returns a list as the markdown of its file, formatting it
+// from the database with SQLite storage. A missing list is an error that
+// os.IsNotExist recognizes.
+func readListContent(listName string) ([]byte, error) {
+	if !UsingSQLite() {
+		return os.ReadFile(GetTodoFilePath(listName))
+	}
+	if !sqliteListExists(listName) {
+		return nil, &fs.PathError{Op: "open", Path: GetDatabasePath(), Err: fs.ErrNotExist}
+	}
+	todoList, err := readListSQLite(listName)
+	if err != nil {
+		return nil, err
+	}
+	return formatTodoFile(todoFileTitle(listName), todoList)
+}
+
+// writeListContent replaces a list with the markdown of a list file. List
+// files are written as they are; with SQLite storage the list is parsed and
+// written to the database.
+func writeListContent(listName string, content []byte) error {
+	if UsingSQLite() {
+		todoList, err := parseTodoList(bytes.NewReader(content))
+		if err != nil {
+			return err
+		}
+		return WriteTodoFile(listName, todoList)
+	}
+	if err := writeFileAtomic(GetTodoFilePath(listName), content); err != nil {
+		return fmt.Errorf("failed to write todo file: %w", err)
+	}
+	noteChanged(listName)
+	return nil
+}
+
+func parseTodoFileAt(filePath string) (*TodoList, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -74,126 +222,227 @@ func ParseTodoFile(branchName string) (*TodoList, error) {
 	}
 	defer file.Close()
 
+	return parseTodoList(file)
+}
+
+// parseTodoList reads a list in the markdown format of a list file
+func parseTodoList(r io.Reader) (*TodoList, error) {
 	var items []TodoItem
-	scanner := bufio.NewScanner(file)
+	var meta ListMeta
+	scanner := bufio.NewScanner(r)
+	// Don't fail on items longer than the default 64KB token size
+	scanner.Buffer(make([]byte, 0, 64*1024), math.MaxInt32)
 	itemID := 1
+	section := ""
+	var headings []string
+	// The indentation of the items the next one may be nested under, and
+	// their positions in items
+	var indents, owners []int
 	
-	// Updated regex to capture optional timestamp: - [x] task text (completed: 2024-01-15 10:30)
-	checkboxRegex := regexp.MustCompile(`^- \[([ x])\] (.+?)(?:\s+\(completed:\s+(.+?)\))?$`)
+	handleLine := func(line string, lineNo int) {
+		indent := lineIndent(line)
+		line = strings.TrimSpace(line)
+		
+		if match := sectionRegex.FindStringSubmatch(line); match != nil {
+			section = match[1]
+			if !slices.Contains(headings, section) {
+				headings = append(headings, section)
+			}
+			indents, owners = nil, nil
+			return
+		}
+		
+		if item, ok := parseItemLine(line); ok {
+			for len(indents) > 0 && indents[len(indents)-1] >= indent {
+				indents = indents[:len(indents)-1]
+				owners = owners[:len(owners)-1]
+			}
+			item.ID = itemID
+			item.Section = section
+			item.Depth = len(indents)
+			item.Line = lineNo
+			items = append(items, item)
+			indents = append(indents, indent)
+			owners = append(owners, len(items)-1)
+			itemID++
+			return
+		}
+		
+		// Other indented text is a note on the item it's indented under
+		if line == "" || indent == 0 {
+			return
+		}
+		for i := len(indents) - 1; i >= 0; i-- {
+			if indents[i] < indent {
+				items[owners[i]].Notes = append(items[owners[i]].Notes, line)
+				return
+			}
+		}
+	}
 	
+	// Frontmatter is only recognised on the first line, and is read as
+	// ordinary lines if it isn't closed or isn't valid YAML
+	var frontmatter []string
+	inFrontmatter := false
+	first := true
+	lineNo := 0
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		lineNo++
+		raw := strings.TrimRight(scanner.Text(), " \t\r")
 		
-		if match := checkboxRegex.FindStringSubmatch(line); match != nil {
-			completed := match[1] == "x"
-			text := match[2]
-			var completedTime *time.Time
-			
-			// Parse timestamp if present
-			if completed && len(match) > 3 && match[3] != "" {
-				if parsedTime, err := time.Parse("2006-01-02 15:04", match[3]); err == nil {
-					completedTime = &parsedTime
+		switch {
+		case first && raw == frontmatterDelimiter:
+			inFrontmatter = true
+		case inFrontmatter && raw == frontmatterDelimiter:
+			inFrontmatter = false
+			parsed, err := parseFrontmatter(frontmatter)
+			if err == nil {
+				meta = parsed
+			} else {
+				for i, line := range frontmatter {
+					handleLine(line, lineNo-len(frontmatter)+i)
 				}
 			}
-			
-			items = append(items, TodoItem{
-				ID:            itemID,
-				Text:          text,
-				Completed:     completed,
-				CompletedTime: completedTime,
-			})
-			itemID++
+			frontmatter = nil
+		case inFrontmatter:
+			frontmatter = append(frontmatter, scanner.Text())
+		default:
+			handleLine(scanner.Text(), lineNo)
 		}
+		first = false
+	}
+	for i, line := range frontmatter {
+		handleLine(line, lineNo-len(frontmatter)+i+1)
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("error reading todo file: %w", err)
 	}
 
-	return &TodoList{Items: items}, nil
+	return &TodoList{Meta: meta, Items: items, Headings: headings}, nil
 }
 
 func WriteTodoFile(branchName string, todoList *TodoList) error {
-	if err := EnsureTodoDirectory(); err != nil {
-		return fmt.Errorf("failed to create .todo directory: %w", err)
-	}
+	store := NewStore()
+	store.Put(branchName, todoList)
+	return store.Flush()
+}
 
-	filePath := GetTodoFilePath(branchName)
-	
-	file, err := os.Create(filePath)
+// todoFileTitle is the heading written at the top of a list's file
+func todoFileTitle(branchName string) string {
+	return fmt.Sprintf("Todo List for %s", branchName)
+}
+
+func writeTodoFileAt(filePath, title string, todoList *TodoList) error {
+	content, err := formatTodoFile(title, todoList)
 	if err != nil {
-		return fmt.Errorf("failed to create todo file: %w", err)
+		return err
 	}
-	defer file.Close()
+	if err := writeFileAtomic(filePath, content); err != nil {
+		return fmt.Errorf("failed to write todo file: %w", err)
+	}
+	return nil
+}
 
-	fmt.Fprintf(file, "# Todo List for %s\n\n", branchName)
+// formatTodoFile renders a list as the markdown written to its file
+func formatTodoFile(title string, todoList *TodoList) ([]byte, error) {
+	var file bytes.Buffer
+	if !todoList.Meta.IsZero() {
+		frontmatter, err := formatFrontmatter(todoList.Meta)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprint(&file, frontmatter)
+	}
+	fmt.Fprintf(&file, "# %s\n\n", title)
 	
+	// Headings with no items under them are written where they stood among
+	// the others, so an empty section isn't lost
+	filled := make(map[string]bool)
 	for _, item := range todoList.Items {
-		checkbox := " "
-		if item.Completed {
-			checkbox = "x"
-			if item.CompletedTime != nil {
-				fmt.Fprintf(file, "- [%s] %s (completed: %s)\n", checkbox, item.Text, item.CompletedTime.Format("2006-01-02 15:04"))
-			} else {
-				fmt.Fprintf(file, "- [%s] %s\n", checkbox, item.Text)
+		filled[item.Section] = true
+	}
+	afterItem := false
+	writeHeading := func(heading string) {
+		if afterItem {
+			fmt.Fprintln(&file)
+		}
+		fmt.Fprintf(&file, "## %s\n\n", heading)
+		afterItem = false
+	}
+	next := 0
+	writeEmptyHeadings := func(end int) {
+		for ; next < end; next++ {
+			if heading := todoList.Headings[next]; !filled[heading] {
+				writeHeading(heading)
 			}
-		} else {
-			fmt.Fprintf(file, "- [%s] %s\n", checkbox, item.Text)
 		}
 	}
+	
+	section := ""
+	depths := todoList.depths()
+	for i, item := range todoList.Items {
+		if item.Section != section {
+			if position := slices.Index(todoList.Headings, item.Section); position >= next {
+				writeEmptyHeadings(position)
+				next = position + 1
+			}
+			writeHeading(item.Section)
+			section = item.Section
+		}
+		fmt.Fprintln(&file, strings.Repeat(subtaskIndent, depths[i])+formatItemLine(item))
+		for _, note := range item.Notes {
+			fmt.Fprintln(&file, strings.Repeat(subtaskIndent, depths[i]+1)+note)
+		}
+		afterItem = true
+	}
+	writeEmptyHeadings(len(todoList.Headings))
 
-	return nil
+	return file.Bytes(), nil
 }
 
 func AddTodoItem(branchName, text string) error {
-	todoList, err := ParseTodoFile(branchName)
-	if err != nil {
-		return fmt.Errorf("failed to parse todo file: %w", err)
+	store := NewStore()
+	if _, err := store.AddItem(branchName, text); err != nil {
+		return err
 	}
-
-	newID := len(todoList.Items) + 1
-	todoList.Items = append(todoList.Items, TodoItem{
-		ID:            newID,
-		Text:          text,
-		Completed:     false,
-		CompletedTime: nil,
-	})
-
-	return WriteTodoFile(branchName, todoList)
+	return store.Flush()
 }
 
-func CheckTodoItem(branchName string, itemID int) error {
-	todoList, err := ParseTodoFile(branchName)
+// AddWeightedTodoItem adds an item that counts weight times as much as a
+// plain item toward the list's progress
+func AddWeightedTodoItem(branchName, text string, weight int) error {
+	store := NewStore()
+	itemID, err := store.AddItem(branchName, text)
 	if err != nil {
-		return fmt.Errorf("failed to parse todo file: %w", err)
+		return err
 	}
-
-	if itemID < 1 || itemID > len(todoList.Items) {
-		return fmt.Errorf("invalid item ID: %d", itemID)
+	if err := store.SetWeight(branchName, itemID, weight); err != nil {
+		return err
 	}
-
-	now := time.Now()
-	todoList.Items[itemID-1].Completed = true
-	todoList.Items[itemID-1].CompletedTime = &now
-	return WriteTodoFile(branchName, todoList)
+	return store.Flush()
 }
 
-func UncheckTodoItem(branchName string, itemID int) error {
-	todoList, err := ParseTodoFile(branchName)
-	if err != nil {
-		return fmt.Errorf("failed to parse todo file: %w", err)
+func CheckTodoItem(branchName string, itemID int) error {
+	store := NewStore()
+	if err := store.CheckItem(branchName, itemID); err != nil {
+		return err
 	}
+	return store.Flush()
+}
 
-	if itemID < 1 || itemID > len(todoList.Items) {
-		return fmt.Errorf("invalid item ID: %d", itemID)
+func UncheckTodoItem(branchName string, itemID int) error {
+	store := NewStore()
+	if err := store.UncheckItem(branchName, itemID); err != nil {
+		return err
 	}
-
-	todoList.Items[itemID-1].Completed = false
-	todoList.Items[itemID-1].CompletedTime = nil
-	return WriteTodoFile(branchName, todoList)
+	return store.Flush()
 }
 
+// DisplayTodoList prints a list, whose own item numbers then apply again
+// instead of those of an aggregated view
 func DisplayTodoList(branchName string) error {
+	ClearView()
 	todoList, err := ParseTodoFile(branchName)
 	if err != nil {
 		return fmt.Errorf("failed to parse todo file: %w", err)
@@ -204,197 +453,308 @@ func DisplayTodoList(branchName string) error {
 		return nil
 	}
 
-	fmt.Printf("Todo list for branch '%s':\n\n", branchName)
-	
-	completed := 0
-	for _, item := range todoList.Items {
-		status := "[ ]"
-		if item.Completed {
-			status = "[x]"
-			completed++
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.Display.Numbering == NumberingID && AssignShortIDs(todoList) {
+		if err := WriteTodoFile(branchName, todoList); err != nil {
+			return err
 		}
-		fmt.Printf("%d. %s %s\n", item.ID, status, item.Text)
 	}
-
-	fmt.Printf("\nProgress: %d/%d completed\n", completed, len(todoList.Items))
+	
+	fmt.Printf("Todo list for branch '%s':\n", branchName)
+	Blank()
+	printTodoList(todoList, cfg)
 	return nil
 }
 
+// printTodoList prints the items of a list followed by its progress
+func printTodoList(todoList *TodoList, cfg *Config) {
+	PlainRenderer{}.RenderList(os.Stdout, NewListView("", todoList, cfg, Now()))
+}
+
 func ListAllFeatures() error {
 	if err := EnsureTodoDirectory(); err != nil {
 		return fmt.Errorf("failed to ensure .todo directory: %w", err)
 	}
 
-	files, err := os.ReadDir(".todo")
+	features, err := GetAllLists()
 	if err != nil {
-		return fmt.Errorf("failed to read .todo directory: %w", err)
-	}
-
-	var features []string
-	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), ".md") {
-			featureName := strings.TrimSuffix(file.Name(), ".md")
-			features = append(features, featureName)
-		}
+		return err
 	}
 
 	if len(features) == 0 {
 		fmt.Println("No features found")
 		return nil
 	}
+	return ListFeatures(features)
+}
+
+// ListFeatures prints the overview of the named lists
+func ListFeatures(features []string) error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	lists := ParseLists(features)
+	now := Now()
+	// Snapshots are only history, so failing to record one isn't an error
+	RecordSnapshots(lists, cfg, now)
 
 	fmt.Println("Lists:")
-	fmt.Println()
+	Blank()
+	printListSummaries(lists, cfg, listSparklines(lists, cfg, now))
 
-	for _, feature := range features {
-		todoList, err := ParseTodoFile(feature)
-		if err != nil {
-			fmt.Printf("  %s - Error reading file: %v\n", feature, err)
-			continue
-		}
+	return nil
+}
 
-		completed := 0
-		for _, item := range todoList.Items {
-			if item.Completed {
-				completed++
-			}
+// printListSummaries prints the one-line progress of each list in an
+// overview, with the names padded so the progress lines up. Lists with a
+// sparkline get it between their name and progress.
+func printListSummaries(lists []ParsedList, cfg *Config, sparklines map[string]string) {
+	nameWidth := 0
+	for _, parsed := range lists {
+		nameWidth = max(nameWidth, displayWidth(parsed.Name))
+	}
+
+	now := Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, parsed := range lists {
+		name := PadRight(parsed.Name, nameWidth)
+		if sparklines != nil {
+			name += " " + sparklines[parsed.Name]
 		}
-
-		total := len(todoList.Items)
-		if total == 0 {
-			fmt.Printf("  %s - No todos\n", feature)
-		} else {
-			percentage := (completed * 100) / total
-			fmt.Printf("  %s - %d/%d completed (%d%%)\n", feature, completed, total, percentage)
+		if parsed.Err != nil {
+			fmt.Printf("  %s - Error reading file: %v\n", name, parsed.Err)
+			continue
 		}
+		fmt.Println(overviewLine(name, summarizeList(parsed.Name, parsed.List, cfg, today)))
 	}
-
-	return nil
 }
 
-func ShowHistory() error {
+// CompletedHistory returns the completed items of every list and archive
+// that pass DisplayFilter, newest first
+func CompletedHistory() ([]ListItem, error) {
 	if err := EnsureTodoDirectory(); err != nil {
-		return fmt.Errorf("failed to ensure .todo directory: %w", err)
+		return nil, fmt.Errorf("failed to ensure .todo directory: %w", err)
 	}
 
-	files, err := os.ReadDir(".todo")
+	lists, err := GetAllLists()
 	if err != nil {
-		return fmt.Errorf("failed to read .todo directory: %w", err)
-	}
-
-	type CompletedItem struct {
-		Text      string
-		List      string
-		Completed time.Time
+		return nil, err
 	}
 
-	var completedItems []CompletedItem
+	var history []ListItem
 
 	// Collect all completed items from all lists
-	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), ".md") {
-			listName := strings.TrimSuffix(file.Name(), ".md")
-			
-			todoList, err := ParseTodoFile(listName)
-			if err != nil {
-				continue // Skip files we can't parse
-			}
+	for _, parsed := range ParseLists(lists) {
+		if parsed.Err != nil {
+			continue // Skip files we can't parse
+		}
 
-			for _, item := range todoList.Items {
-				if item.Completed && item.CompletedTime != nil {
-					completedItems = append(completedItems, CompletedItem{
-						Text:      item.Text,
-						List:      listName,
-						Completed: *item.CompletedTime,
-					})
-				}
+		for _, item := range parsed.List.Items {
+			if item.Completed && item.CompletedTime != nil && DisplayFilter.Matches(item) {
+				history = append(history, ListItem{List: parsed.Name, Item: item})
 			}
 		}
 	}
 
-	if len(completedItems) == 0 {
-		fmt.Println("No completed todos found.")
-		return nil
+	// Along with the items moved to archives
+	archives, err := archivedLists()
+	if err != nil {
+		return nil, err
 	}
-
-	// Sort by completion time (newest first)
-	for i := 0; i < len(completedItems); i++ {
-		for j := i + 1; j < len(completedItems); j++ {
-			if completedItems[i].Completed.Before(completedItems[j].Completed) {
-				completedItems[i], completedItems[j] = completedItems[j], completedItems[i]
+	for _, name := range archives {
+		archive, err := parseTodoFileAt(GetArchiveFilePath(name))
+		if err != nil {
+			continue
+		}
+		for _, item := range archive.Items {
+			if item.Completed && item.CompletedTime != nil && DisplayFilter.Matches(item) {
+				history = append(history, ListItem{List: name, Item: item, Archived: true})
 			}
 		}
 	}
 
+	// Sort by completion time (newest first)
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Item.CompletedTime.After(*history[j].Item.CompletedTime)
+	})
+	return history, nil
+}
+
+func ShowHistory() error {
+	history, err := CompletedHistory()
+	if err != nil {
+		return err
+	}
+
+	if len(history) == 0 {
+		fmt.Println("No completed todos found.")
+		return nil
+	}
+
 	fmt.Println("Completed Todo History:")
-	fmt.Println()
+	Blank()
 
 	currentDate := ""
-	for _, item := range completedItems {
-		itemDate := item.Completed.Format("2006-01-02")
+	for _, entry := range history {
+		completed := *entry.Item.CompletedTime
+		itemDate := completed.Format("2006-01-02")
 		if itemDate != currentDate {
 			if currentDate != "" {
-				fmt.Println()
+				Blank()
 			}
-			fmt.Printf("📅 %s\n", item.Completed.Format("Monday, January 2, 2006"))
+			fmt.Printf("%s%s\n", Emoji("📅"), completed.Format("Monday, January 2, 2006"))
 			currentDate = itemDate
 		}
 		
-		timeStr := item.Completed.Format("15:04")
-		fmt.Printf("  ✅ %s [%s] (%s)\n", item.Text, item.List, timeStr)
+		timeStr := completed.Format("15:04")
+		list := entry.List
+		if entry.Archived {
+			list += ", archived"
+		}
+		fmt.Printf("  %s%s [%s] (%s)\n", Emoji("✅"), entry.Item.Text, list, timeStr)
 	}
 
 	return nil
 }
 
-func EditTodoFile(listName string) error {
+// EditTodoFile opens a list in the editor set in $EDITOR. The editor works
+// on a copy, so that when something else writes the list while it is open,
+// such as a sync or the daemon, saving doesn't silently overwrite it: the
+// changes made meanwhile are merged into the edited list instead. The merge
+// is returned, or nil when nothing else wrote the list.
+func EditTodoFile(listName string) (*EditMerge, error) {
+	if UsingSQLite() {
+		return nil, fmt.Errorf("lists are kept in %s, which can't be opened in an editor (run 'todo export --markdown' for list files)", GetDatabasePath())
+	}
+
 	// Get the editor from environment variable
 	editor := os.Getenv("EDITOR")
 	if editor == "" {
-		return fmt.Errorf("EDITOR environment variable is not set. Please set it to your preferred editor (e.g., export EDITOR=nvim)")
+		return nil, fmt.Errorf("EDITOR environment variable is not set. Please set it to your preferred editor (e.g., export EDITOR=nvim)")
 	}
 	
 	// Ensure the todo file exists
 	if !TodoFileExists(listName) {
 		err := CreateTodoFile(listName)
 		if err != nil {
-			return fmt.Errorf("failed to create todo file: %w", err)
+			return nil, fmt.Errorf("failed to create todo file: %w", err)
 		}
 	}
 	
-	// Get the file path
 	filePath := GetTodoFilePath(listName)
+	original, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read todo file: %w", err)
+	}
+	
+	// Edit a copy with the list's own file name, for the editor's title
+	// and syntax highlighting
+	editDir, err := os.MkdirTemp("", "todo-edit-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a copy to edit: %w", err)
+	}
+	editPath := filepath.Join(editDir, filepath.Base(filePath))
+	if err := os.WriteFile(editPath, original, 0644); err != nil {
+		os.RemoveAll(editDir)
+		return nil, fmt.Errorf("failed to create a copy to edit: %w", err)
+	}
 	
 	// Execute the editor command
-	cmd := exec.Command(editor, filePath)
+	cmd := exec.Command(editor, editPath)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("failed to run editor %s: %w", editor, err)
+	if err := cmd.Run(); err != nil {
+		os.RemoveAll(editDir)
+		return nil, fmt.Errorf("failed to run editor %s: %w", editor, err)
 	}
 	
-	return nil
+	edited, err := os.ReadFile(editPath)
+	if err == nil {
+		var merge *EditMerge
+		if merge, err = saveEdit(listName, original, edited); err == nil {
+			os.RemoveAll(editDir)
+			return merge, nil
+		}
+	}
+	return nil, fmt.Errorf("%w; your changes are kept in %s", err, editPath)
+}
+
+// saveEdit writes the edited content of a list opened in an editor as
+// original, merging in any changes written to the list since
+func saveEdit(listName string, original, edited []byte) (*EditMerge, error) {
+	done := MarkWriting("edit")
+	defer done()
+	
+	filePath := GetTodoFilePath(listName)
+	current, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read todo file: %w", err)
+	}
+	if bytes.Equal(edited, original) {
+		// Nothing to save, and whatever was written meanwhile stays
+		return nil, nil
+	}
+	if err != nil || bytes.Equal(current, original) {
+		if err := writeFileAtomic(filePath, edited); err != nil {
+			return nil, fmt.Errorf("failed to write todo file: %w", err)
+		}
+		noteChanged(listName)
+		return nil, nil
+	}
+	
+	lists := make([]*TodoList, 3)
+	for i, content := range [][]byte{original, edited, current} {
+		if lists[i], err = parseTodoList(bytes.NewReader(content)); err != nil {
+			return nil, err
+		}
+	}
+	merge := MergeEdit(lists[0], lists[1], lists[2])
+	if err := WriteTodoFile(listName, merge.List); err != nil {
+		return nil, err
+	}
+	return merge, nil
 }
 
 // GetCurrentList returns the currently active todo list name
 func GetCurrentList() (string, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	
+	// Follow the checked out branch when branch tracking is enabled
+	if cfg.BranchTracking && GitEnabled(cfg) {
+		if branch, err := GetCurrentBranch(); err == nil {
+			return ResolveListName(GetFeatureName(branch)), nil
+		}
+	}
+	
 	// Check if there's a .current-list file to track active list
-	currentListFile := ".current-list"
-	if content, err := os.ReadFile(currentListFile); err == nil {
-		return strings.TrimSpace(string(content)), nil
+	if content, err := os.ReadFile(GetCurrentListPath()); err == nil {
+		return ResolveListName(strings.TrimSpace(string(content))), nil
 	}
 	
-	// Default to "main" if no current list is set
-	return "main", nil
+	// Fall back to the configured default list
+	return ResolveListName(cfg.DefaultList), nil
 }
 
 // SetCurrentList sets the active todo list
 func SetCurrentList(listName string) error {
-	currentListFile := ".current-list"
-	return os.WriteFile(currentListFile, []byte(listName), 0644)
+	ClearView()
+	// The global lists keep the file inside .todo
+	if Global {
+		if err := EnsureTodoDirectory(); err != nil {
+			return fmt.Errorf("failed to create .todo directory: %w", err)
+		}
+	}
+	return os.WriteFile(GetCurrentListPath(), []byte(listName), 0644)
 }
 
 // ListExists checks if a todo list exists
@@ -402,8 +762,38 @@ func ListExists(listName string) bool {
 	return TodoFileExists(listName)
 }
 
+// GetAllLists returns the names of all todo lists in the .todo directory,
+// or in its database with sqlite storage
+func GetAllLists() ([]string, error) {
+	if UsingSQLite() {
+		return sqliteLists()
+	}
+	return markdownLists()
+}
+
+// markdownLists returns the names of the list files in the .todo directory
+func markdownLists() ([]string, error) {
+	files, err := os.ReadDir(".todo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .todo directory: %w", err)
+	}
+
+	var lists []string
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".md") {
+			lists = append(lists, strings.TrimSuffix(file.Name(), ".md"))
+		}
+	}
+	return lists, nil
+}
+
 // DeleteList removes a todo list file
 func DeleteList(listName string) error {
+	if UsingSQLite() {
+		noteChanged(listName)
+		return deleteListSQLite(listName)
+	}
 	filePath := GetTodoFilePath(listName)
+	noteChanged(listName)
 	return os.Remove(filePath)
 }
\ No newline at end of file
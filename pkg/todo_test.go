@@ -2,6 +2,8 @@ package pkg
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -282,4 +284,185 @@ func TestCheckTodoItemInvalidID(t *testing.T) {
 	if err == nil {
 		t.Error("CheckTodoItem should fail for ID 0")
 	}
-}
\ No newline at end of file
+}
+func TestDueDateRoundTrip(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+
+	testContent := `# Todo List for release
+
+- [ ] Tag the release (due: 2024-07-01)
+- [x] Write notes (due: 2024-06-28) (completed: 2024-06-27 16:30)
+- [ ] No deadline
+`
+	os.WriteFile(GetTodoFilePath("release"), []byte(testContent), 0644)
+
+	todoList, err := ParseTodoFile("release")
+	if err != nil {
+		t.Fatalf("ParseTodoFile failed: %v", err)
+	}
+
+	if todoList.Items[0].Text != "Tag the release" || todoList.Items[0].DueDate == nil || todoList.Items[0].DueDate.Format(DueDateFormat) != "2024-07-01" {
+		t.Errorf("Unexpected first item: %+v", todoList.Items[0])
+	}
+	if todoList.Items[1].Text != "Write notes" || todoList.Items[1].DueDate == nil || todoList.Items[1].CompletedTime == nil {
+		t.Errorf("Unexpected second item: %+v", todoList.Items[1])
+	}
+	if todoList.Items[2].DueDate != nil {
+		t.Errorf("Item without a due date got one: %+v", todoList.Items[2])
+	}
+
+	// Lists in the older suffix format are rewritten with a metadata comment
+	if err := WriteTodoFile("release", todoList); err != nil {
+		t.Fatalf("WriteTodoFile failed: %v", err)
+	}
+	expected := `# Todo List for release
+
+- [ ] Tag the release <!-- due: 2024-07-01 -->
+- [x] Write notes <!-- due: 2024-06-28; completed: 2024-06-27 16:30 -->
+- [ ] No deadline
+`
+	content, _ := os.ReadFile(GetTodoFilePath("release"))
+	if string(content) != expected {
+		t.Errorf("Unexpected file after writing:\n%s", content)
+	}
+
+	reparsed, _ := ParseTodoFile("release")
+	WriteTodoFile("release", reparsed)
+	if content, _ := os.ReadFile(GetTodoFilePath("release")); string(content) != expected {
+		t.Errorf("Round trip changed the file:\n%s", content)
+	}
+}
+
+func TestAddTodoItemDueDate(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("release")
+
+	AddTodoItem("release", "Tag the release (due: 2024-07-01)")
+	AddTodoItem("release", "Compare (completed: 2024-01-01 10:00)")
+
+	todoList, _ := ParseTodoFile("release")
+	if item := todoList.Items[0]; item.Text != "Tag the release" || item.DueDate == nil || item.DueDate.Format(DueDateFormat) != "2024-07-01" {
+		t.Errorf("Unexpected item with due date: %+v", item)
+	}
+	if item := todoList.Items[1]; item.Text != "Compare (completed: 2024-01-01 10:00)" || item.CompletedTime != nil {
+		t.Errorf("Unexpected item with a completed suffix: %+v", item)
+	}
+}
+
+func TestSectionsRoundTrip(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+
+	testContent := `# Todo List for work
+
+- [ ] Triage
+
+## Backend
+
+- [ ] Migrate
+- [x] Index
+
+## Frontend
+
+- [ ] Form
+`
+	os.WriteFile(GetTodoFilePath("work"), []byte(testContent), 0644)
+
+	todoList, err := ParseTodoFile("work")
+	if err != nil {
+		t.Fatalf("ParseTodoFile failed: %v", err)
+	}
+	var sections []string
+	for _, item := range todoList.Items {
+		sections = append(sections, item.Section)
+	}
+	if strings.Join(sections, ",") != ",Backend,Backend,Frontend" {
+		t.Errorf("Unexpected sections: %q", sections)
+	}
+
+	// New items go outside any section, before the first heading
+	AddTodoItem("work", "Styles")
+	todoList, _ = ParseTodoFile("work")
+	if item := todoList.Items[1]; item.Text != "Styles" || item.Section != "" {
+		t.Errorf("Added item is %q in section %q", item.Text, item.Section)
+	}
+
+	content, _ := os.ReadFile(GetTodoFilePath("work"))
+	want := strings.Replace(testContent, "- [ ] Triage\n", "- [ ] Triage\n- [ ] Styles\n", 1)
+	if string(content) != want {
+		t.Errorf("Round trip changed the file:\n%s", content)
+	}
+}
+
+func TestParseTodoFileHardening(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+
+	long := strings.Repeat("word ", 40000)
+	testContent := "# Todo List for edge\n\n" +
+		"- [X] Upper case check\n" +
+		"- [ ] " + long + "\n" +
+		"- [ ] Bad date (due: 2024-13-45)\n" +
+		"- [x] Bad time (completed: yesterday)\n" +
+		"- [ ] Pending (completed: 2024-01-01 10:00)\n"
+	os.WriteFile(GetTodoFilePath("edge"), []byte(testContent), 0644)
+
+	todoList, err := ParseTodoFile("edge")
+	if err != nil {
+		t.Fatalf("ParseTodoFile failed: %v", err)
+	}
+	if len(todoList.Items) != 5 {
+		t.Fatalf("Expected 5 items, got %d", len(todoList.Items))
+	}
+
+	if !todoList.Items[0].Completed {
+		t.Error("[X] should count as completed")
+	}
+	if todoList.Items[1].Text != strings.TrimSpace(long) {
+		t.Error("Long item text was not preserved")
+	}
+
+	// Suffixes that aren't valid metadata stay in the text
+	expected := []string{"Bad date (due: 2024-13-45)", "Bad time (completed: yesterday)", "Pending (completed: 2024-01-01 10:00)"}
+	for i, text := range expected {
+		if got := todoList.Items[i+2].Text; got != text {
+			t.Errorf("Item %d: Text = %q, want %q", i+3, got, text)
+		}
+	}
+
+	// A newline in item text can't split the item when written
+	todoList.Items[0].Text = "first line\nsecond line"
+	WriteTodoFile("edge", todoList)
+	reparsed, _ := ParseTodoFile("edge")
+	if len(reparsed.Items) != 5 || reparsed.Items[0].Text != "first line\nsecond line" {
+		t.Errorf("Unexpected items after writing a multi-line text: %+v", reparsed.Items[0])
+	}
+}
+
+func TestFindTodoRoot(t *testing.T) {
+	testDir := setupTestDir(t)
+	t.Setenv("HOME", t.TempDir())
+	root, _ := filepath.EvalSymlinks(testDir)
+
+	if _, err := FindTodoRoot(); err != ErrNoTodoRoot {
+		t.Errorf("Expected ErrNoTodoRoot without a .todo directory, got %v", err)
+	}
+
+	EnsureTodoDirectory()
+	os.MkdirAll(filepath.Join("src", "api"), 0755)
+	os.Chdir(filepath.Join("src", "api"))
+	found, err := FindTodoRoot()
+	if err != nil {
+		t.Fatalf("FindTodoRoot failed: %v", err)
+	}
+	if found != root {
+		t.Errorf("Expected %s, got %s", root, found)
+	}
+
+	// The global lists in the home directory aren't a project's
+	t.Setenv("HOME", root)
+	if _, err := FindTodoRoot(); err != ErrNoTodoRoot {
+		t.Errorf("Expected the home directory's .todo to be passed over, got %v", err)
+	}
+}
@@ -0,0 +1,385 @@
+package pkg
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TodoistAPIURL is the base URL of the Todoist API
+var TodoistAPIURL = "https://api.todoist.com/api/v1"
+
+// metaTodoist is the metadata key holding the id of the Todoist task an item
+// is linked to
+const metaTodoist = "todoist"
+
+// todoistSyncName names the Todoist provider's sync state and offline queue
+const todoistSyncName = "todoist"
+
+// Lists are bound to a Todoist project, by name or id, with the todoist key
+// of their frontmatter. Items are linked to the project's tasks by task id
+// and synced as described in syncitems.go, with titles, completion and due
+// dates following on both sides. Unlike the other services, an item changed
+// on both sides isn't left as a conflict: the side changed last wins, going
+// by the task's updated_at and the modified time the item keeps.
+
+type todoistSyncProvider struct {
+	*itemSync
+	client *APIClient
+	// lists maps list names to the project they are bound to
+	lists map[string]string
+	// projects caches the resolved project id of each list
+	projects map[string]string
+}
+
+// todoistTask is a task as the Todoist API returns it
+type todoistTask struct {
+	ID          string `json:"id"`
+	ProjectID   string `json:"project_id"`
+	Content     string `json:"content"`
+	Checked     bool   `json:"checked"`
+	IsDeleted   bool   `json:"is_deleted"`
+	UpdatedAt   string `json:"updated_at"`
+	CompletedAt string `json:"completed_at"`
+	Due         *struct {
+		Date string `json:"date"`
+	} `json:"due"`
+}
+
+// item returns the synced fields of a task. Due dates with a time keep
+// only the day.
+func (t todoistTask) item() syncedItem {
+	item := syncedItem{Title: t.Content, Done: t.Checked}
+	if t.Due != nil && len(t.Due.Date) >= len(DueDateFormat) {
+		item.Due = t.Due.Date[:len(DueDateFormat)]
+	}
+	return item
+}
+
+// updated returns when the task was last changed
+func (t todoistTask) updated() time.Time {
+	var latest time.Time
+	for _, value := range []string{t.UpdatedAt, t.CompletedAt} {
+		if when, err := time.Parse(time.RFC3339Nano, value); err == nil && when.After(latest) {
+			latest = when
+		}
+	}
+	return latest
+}
+
+func newTodoistSyncProvider(cfg *Config) (SyncProvider, error) {
+	lists, err := TodoistLists()
+	if err != nil {
+		return nil, err
+	}
+	if len(lists) == 0 {
+		return nil, fmt.Errorf("no lists are bound to Todoist; run 'todo sync todoist --project <name>'")
+	}
+
+	token, err := GetCredential("todoist")
+	if err != nil {
+		return nil, err
+	}
+	client := NewAPIClient(TodoistAPIURL, 5)
+	client.Header.Set("Authorization", "Bearer "+token)
+	p := &todoistSyncProvider{client: client, lists: lists, projects: make(map[string]string)}
+	p.itemSync = &itemSync{
+		name:      todoistSyncName,
+		service:   "Todoist",
+		metaKey:   metaTodoist,
+		withDue:   true,
+		lists:     slices.Sorted(maps.Keys(lists)),
+		key:       itemSyncKey,
+		splitKey:  splitItemSyncKey,
+		fetch:     p.fetch,
+		operation: todoistOperation,
+		send:      p.send,
+		settle:    settleTodoistConflicts,
+	}
+	return p, nil
+}
+
+// TodoistLists returns the lists bound to a Todoist project, with the
+// project's name or id
+func TodoistLists() (map[string]string, error) {
+	names, err := GetAllLists()
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make(map[string]string)
+	for _, listName := range names {
+		todoList, err := ParseTodoFile(listName)
+		if err != nil {
+			return nil, err
+		}
+		if todoList.Meta.Todoist != "" {
+			lists[listName] = todoList.Meta.Todoist
+		}
+	}
+	return lists, nil
+}
+
+// todoistPayload is the data of a queued operation. ID is the task's id, or
+// for a create the request id that makes retrying it safe. Remote is the
+// task as Todoist had it, so an update only sends what differs.
+type todoistPayload struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+	Remote  string `json:"remote,omitempty"`
+}
+
+func todoistOperation(action, listName, id, content, remote string) SyncOperation {
+	// The request id is chosen when the create is first queued, so
+	// Todoist ignores a retry that would add the task twice
+	if action == SyncAdd && id == "" {
+		id = newTodoistRequestID()
+	}
+	payload, _ := json.Marshal(todoistPayload{ID: id, Content: content, Remote: remote})
+	opID := action + ":" + itemSyncKey(listName, id)
+	if action == SyncAdd {
+		opID = action + ":" + itemSyncKey(listName, content)
+	}
+	return SyncOperation{ID: opID, Kind: action, List: listName, Payload: payload}
+}
+
+// newTodoistRequestID returns an id for a request Todoist should only carry
+// out once
+func newTodoistRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// send carries out a queued operation against Todoist
+func (p *todoistSyncProvider) send(ctx context.Context, state *SyncState, op SyncOperation) error {
+	var payload todoistPayload
+	if err := json.Unmarshal(op.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid queued operation %s: %w", op.ID, err)
+	}
+	if _, ok := p.lists[op.List]; !ok {
+		return fmt.Errorf("list '%s' is no longer bound to Todoist", op.List)
+	}
+	synced := decodeSyncedItem(payload.Content)
+	key := itemSyncKey(op.List, payload.ID)
+
+	switch op.Kind {
+	case SyncAdd:
+		projectID, err := p.project(ctx, op.List)
+		if err != nil {
+			return err
+		}
+		body := map[string]string{"content": synced.Title, "project_id": projectID}
+		if synced.Due != "" {
+			body["due_date"] = synced.Due
+		}
+		request, _ := json.Marshal(body)
+		header := http.Header{"Content-Type": {"application/json"}, "X-Request-Id": {payload.ID}}
+		response, err := p.client.SendContext(ctx, "POST", "/tasks", header, request)
+		if err != nil {
+			return err
+		}
+		var task todoistTask
+		if err := json.Unmarshal(response, &task); err != nil || task.ID == "" {
+			return fmt.Errorf("Todoist did not create the task %q", synced.Title)
+		}
+		state.Base[itemSyncKey(op.List, task.ID)] = payload.Content
+		return linkSyncedItem(op.List, metaTodoist, synced.Title, task.ID)
+	case SyncUpdate:
+		remote := decodeSyncedItem(payload.Remote)
+		if synced.Title != remote.Title || synced.Due != remote.Due {
+			body := map[string]string{"content": synced.Title, "due_date": synced.Due}
+			if synced.Due == "" {
+				body = map[string]string{"content": synced.Title, "due_string": "no date"}
+			}
+			if err := p.client.DoContext(ctx, "POST", "/tasks/"+url.PathEscape(payload.ID), body, nil); err != nil {
+				return err
+			}
+		}
+		if synced.Done != remote.Done {
+			action := "/reopen"
+			if synced.Done {
+				action = "/close"
+			}
+			if err := p.client.DoContext(ctx, "POST", "/tasks/"+url.PathEscape(payload.ID)+action, nil, nil); err != nil {
+				return err
+			}
+		}
+		state.Base[key] = payload.Content
+	case SyncDelete:
+		if err := p.client.DoContext(ctx, "DELETE", "/tasks/"+url.PathEscape(payload.ID), nil, nil); err != nil && !isHTTPStatus(err, http.StatusNotFound) {
+			return err
+		}
+		delete(state.Base, key)
+	default:
+		return fmt.Errorf("unknown operation %q", op.Kind)
+	}
+	return nil
+}
+
+// settleTodoistConflicts resolves the conflicts among incoming or outgoing
+// changes in favour of the side changed last, keeping those that side wins
+// and dropping the others. Conflicts whose order can't be told are kept.
+func settleTodoistConflicts(snap *itemSnapshot, changes []SyncChange, incoming bool) []SyncChange {
+	settled := make([]SyncChange, 0, len(changes))
+	for _, change := range changes {
+		if change.Action != SyncConflict {
+			settled = append(settled, change)
+			continue
+		}
+		remoteNewer, known := todoistRemoteNewer(snap, change.List)
+		if !known {
+			settled = append(settled, change)
+			continue
+		}
+		if remoteNewer != incoming {
+			continue
+		}
+		if incoming {
+			change = resolveConflicts([]SyncChange{change}, snap.local, snap.remote)[0]
+			change.Detail = "changed on both sides, Todoist's change is newer"
+		} else {
+			change = resolveConflicts([]SyncChange{change}, snap.remote, snap.local)[0]
+			change.Detail = "changed on both sides, the local change is newer"
+		}
+		settled = append(settled, change)
+	}
+	return settled
+}
+
+// todoistRemoteNewer reports whether the task of a snapshot key was changed
+// after its item, and whether that can be told. A task deleted in Todoist
+// has no time to go by, and its deletion always wins. Otherwise the order is
+// known when the task has an updated_at and the item a modified time.
+func todoistRemoteNewer(snap *itemSnapshot, key string) (newer, known bool) {
+	if _, ok := snap.remote[key]; !ok {
+		return true, true
+	}
+	modified, ok := snap.modified[key]
+	updated := snap.updated[key]
+	if !ok || updated.IsZero() {
+		return false, false
+	}
+	return updated.After(modified), true
+}
+
+// fetch adds the tasks of a list's project to the remote snapshot, with
+// when each was last changed
+func (p *todoistSyncProvider) fetch(ctx context.Context, listName string, snap *itemSnapshot) error {
+	tasks, err := p.tasks(ctx, listName, snap)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		key := itemSyncKey(listName, task.ID)
+		snap.remote[key] = task.item().encode()
+		snap.updated[key] = task.updated()
+	}
+	return nil
+}
+
+// project returns the id of the project a list is bound to, looking it up
+// by id or, in any case, by name
+func (p *todoistSyncProvider) project(ctx context.Context, listName string) (string, error) {
+	if id, ok := p.projects[listName]; ok {
+		return id, nil
+	}
+	wanted := p.lists[listName]
+
+	cursor := ""
+	for {
+		query := url.Values{"limit": {"200"}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		var page struct {
+			Results []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"results"`
+			NextCursor string `json:"next_cursor"`
+		}
+		if err := p.client.DoContext(ctx, "GET", "/projects?"+query.Encode(), nil, &page); err != nil {
+			return "", err
+		}
+		for _, project := range page.Results {
+			if project.ID == wanted || strings.EqualFold(project.Name, wanted) {
+				p.projects[listName] = project.ID
+				return project.ID, nil
+			}
+		}
+		if page.NextCursor == "" {
+			return "", fmt.Errorf("Todoist project %q not found", wanted)
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// tasks returns the tasks of the project a list is bound to. Todoist only
+// lists open tasks, so the tasks of linked items missing from that list
+// are fetched one by one: they were completed, deleted or moved away.
+func (p *todoistSyncProvider) tasks(ctx context.Context, listName string, snap *itemSnapshot) ([]todoistTask, error) {
+	projectID, err := p.project(ctx, listName)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []todoistTask
+	found := make(map[string]bool)
+	cursor := ""
+	for {
+		query := url.Values{"project_id": {projectID}, "limit": {"200"}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		var page struct {
+			Results    []todoistTask `json:"results"`
+			NextCursor string        `json:"next_cursor"`
+		}
+		if err := p.client.DoContext(ctx, "GET", "/tasks?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		for _, task := range page.Results {
+			tasks = append(tasks, task)
+			found[task.ID] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	var missing []string
+	for _, snapshot := range []map[string]string{snap.local, snap.base} {
+		for key := range snapshot {
+			if name, id := splitItemSyncKey(key); name == listName && !found[id] {
+				missing = append(missing, id)
+				found[id] = true
+			}
+		}
+	}
+	sort.Strings(missing)
+	for _, id := range missing {
+		var task todoistTask
+		err := p.client.DoContext(ctx, "GET", "/tasks/"+url.PathEscape(id), nil, &task)
+		if isHTTPStatus(err, http.StatusNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !task.IsDeleted && task.ProjectID == projectID {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
@@ -0,0 +1,319 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTodoist answers the REST requests the Todoist provider sends
+type fakeTodoist struct {
+	tasks []*todoistTask
+	// requests records the requests that change tasks
+	requests   []string
+	requestIDs map[string]bool
+}
+
+func (f *fakeTodoist) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer todoist_key" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1")
+	var body map[string]string
+	json.NewDecoder(r.Body).Decode(&body)
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if r.Method != "GET" {
+		f.requests = append(f.requests, r.Method+" "+path)
+	}
+
+	switch {
+	case r.Method == "GET" && path == "/projects":
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": []map[string]string{{"id": "p-inbox", "name": "Inbox"}, {"id": "p-1", "name": "Groceries"}}})
+	case r.Method == "GET" && path == "/tasks":
+		var open []*todoistTask
+		for _, task := range f.tasks {
+			if task.ProjectID == r.URL.Query().Get("project_id") && !task.Checked && !task.IsDeleted {
+				open = append(open, task)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": open})
+	case r.Method == "POST" && path == "/tasks":
+		if f.requestIDs[r.Header.Get("X-Request-Id")] {
+			http.Error(w, "duplicate request", http.StatusConflict)
+			return
+		}
+		f.requestIDs[r.Header.Get("X-Request-Id")] = true
+		task := &todoistTask{ID: fmt.Sprintf("t-%d", len(f.tasks)+1), ProjectID: body["project_id"], Content: body["content"], UpdatedAt: now}
+		f.tasks = append(f.tasks, task)
+		json.NewEncoder(w).Encode(task)
+	default:
+		parts := strings.Split(strings.TrimPrefix(path, "/tasks/"), "/")
+		task := f.find(parts[0])
+		if task == nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		switch {
+		case r.Method == "GET":
+			json.NewEncoder(w).Encode(task)
+			return
+		case r.Method == "DELETE":
+			task.IsDeleted = true
+		case len(parts) == 2 && parts[1] == "close":
+			task.Checked, task.CompletedAt = true, now
+		case len(parts) == 2 && parts[1] == "reopen":
+			task.Checked, task.CompletedAt = false, ""
+		default:
+			task.Content = body["content"]
+		}
+		task.UpdatedAt = now
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeTodoist) find(id string) *todoistTask {
+	for _, task := range f.tasks {
+		if task.ID == id {
+			return task
+		}
+	}
+	return nil
+}
+
+func setupTodoist(t *testing.T) *fakeTodoist {
+	setupTestDir(t)
+	t.Setenv("TODO_TODOIST_TOKEN", "todoist_key")
+	AddTodoItem("groceries", "Milk")
+	if err := SetListTodoist("groceries", "groceries"); err != nil {
+		t.Fatalf("SetListTodoist failed: %v", err)
+	}
+
+	fake := &fakeTodoist{requestIDs: make(map[string]bool)}
+	server := httptest.NewServer(fake)
+	original := TodoistAPIURL
+	TodoistAPIURL = server.URL + "/api/v1"
+	t.Cleanup(func() {
+		TodoistAPIURL = original
+		server.Close()
+	})
+	return fake
+}
+
+func TestTodoistSync(t *testing.T) {
+	fake := setupTodoist(t)
+	fake.tasks = []*todoistTask{
+		{ID: "t-1", ProjectID: "p-1", Content: "Bread", UpdatedAt: "2024-06-01T10:00:00Z"},
+		{ID: "t-2", ProjectID: "p-inbox", Content: "Elsewhere"},
+	}
+
+	provider, err := GetSyncProvider("todoist")
+	if err != nil {
+		t.Fatalf("GetSyncProvider failed: %v", err)
+	}
+
+	// Pulling adds the project's tasks as items linked to them
+	changes, err := provider.Pull(context.Background(), SyncOptions{})
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Action != SyncAdd || changes[0].List != "groceries/t-1" || changes[0].Detail != "Bread" {
+		t.Fatalf("Unexpected pulled changes: %+v", changes)
+	}
+
+	// Pushing creates tasks for unlinked items and links them
+	if _, err := provider.Push(context.Background(), SyncOptions{}); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if len(fake.tasks) != 3 || fake.tasks[2].Content != "Milk" || fake.tasks[2].ProjectID != "p-1" {
+		t.Fatalf("Expected a task for the new item, got %+v", fake.tasks)
+	}
+	todoList, _ := ParseTodoFile("groceries")
+	if todoList.Items[0].Metadata[metaTodoist] != "t-3" || todoList.Items[1].Metadata[metaTodoist] != "t-1" {
+		t.Fatalf("Expected the items to be linked, got %+v", todoList.Items)
+	}
+
+	// Checking an item closes its task, and reopening it reopens the task
+	CheckTodoItem("groceries", 1)
+	provider.Push(context.Background(), SyncOptions{})
+	UncheckTodoItem("groceries", 1)
+	provider.Push(context.Background(), SyncOptions{})
+	if want := []string{"POST /tasks", "POST /tasks/t-3/close", "POST /tasks/t-3/reopen"}; strings.Join(fake.requests, ", ") != strings.Join(want, ", ") {
+		t.Errorf("Requests = %v, want %v", fake.requests, want)
+	}
+
+	// A task completed in Todoist, which no longer lists it, checks its item
+	fake.tasks[0].Checked = true
+	if _, err := provider.Pull(context.Background(), SyncOptions{}); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if todoList, _ := ParseTodoFile("groceries"); !todoList.Items[1].Completed {
+		t.Errorf("Expected Bread to be checked, got %+v", todoList.Items[1])
+	}
+
+	// A task deleted in Todoist removes its item
+	fake.tasks = fake.tasks[1:]
+	provider.Pull(context.Background(), SyncOptions{})
+	if todoList, _ := ParseTodoFile("groceries"); len(todoList.Items) != 1 || todoList.Items[0].Text != "Milk" {
+		t.Errorf("Expected only Milk to be left, got %+v", todoList.Items)
+	}
+
+	status, err := provider.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(status.Incoming) != 0 || len(status.Outgoing) != 0 {
+		t.Errorf("Expected everything up to date, got %+v", status)
+	}
+}
+
+func TestTodoistSyncNewestWins(t *testing.T) {
+	fake := setupTodoist(t)
+	fake.tasks = []*todoistTask{{ID: "t-1", ProjectID: "p-1", Content: "Bread"}}
+	provider, _ := GetSyncProvider("todoist")
+	provider.Pull(context.Background(), SyncOptions{})
+	provider.Push(context.Background(), SyncOptions{})
+
+	// Renamed in Todoist after the item was checked here: Todoist wins
+	CheckTodoItem("groceries", 2)
+	fake.tasks[0].Content = "Sourdough"
+	fake.tasks[0].UpdatedAt = time.Now().Add(time.Hour).UTC().Format(time.RFC3339Nano)
+	changes, err := provider.Pull(context.Background(), SyncOptions{})
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Action != SyncUpdate || !strings.Contains(changes[0].Detail, "Todoist's change is newer") {
+		t.Fatalf("Unexpected pulled changes: %+v", changes)
+	}
+	todoList, _ := ParseTodoFile("groceries")
+	if todoList.Items[1].Text != "Sourdough" || todoList.Items[1].Completed {
+		t.Errorf("Expected Todoist's version, got %+v", todoList.Items[1])
+	}
+
+	// Renamed in Todoist before the item was checked here: ours wins
+	fake.tasks[0].Content = "Rye"
+	fake.tasks[0].UpdatedAt = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339Nano)
+	CheckTodoItem("groceries", 2)
+	if changes, _ := provider.Pull(context.Background(), SyncOptions{}); len(changes) != 0 {
+		t.Errorf("Expected nothing to pull, got %+v", changes)
+	}
+	changes, err = provider.Push(context.Background(), SyncOptions{})
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if len(changes) != 1 || !strings.Contains(changes[0].Detail, "the local change is newer") {
+		t.Fatalf("Unexpected pushed changes: %+v", changes)
+	}
+	if fake.tasks[0].Content != "Sourdough" || !fake.tasks[0].Checked {
+		t.Errorf("Expected the local version in Todoist, got %+v", fake.tasks[0])
+	}
+
+	// LocalChanges works without reaching Todoist
+	TodoistAPIURL = "http://127.0.0.1:1"
+	UncheckTodoItem("groceries", 2)
+	changes, err = provider.LocalChanges()
+	if err != nil || len(changes) != 1 || changes[0].Action != SyncUpdate {
+		t.Errorf("LocalChanges() = %+v, %v", changes, err)
+	}
+}
+
+func TestTodoistSyncConflict(t *testing.T) {
+	fake := setupTodoist(t)
+	fake.tasks = []*todoistTask{{ID: "t-1", ProjectID: "p-1", Content: "Bread"}}
+	provider, _ := GetSyncProvider("todoist")
+	provider.Pull(context.Background(), SyncOptions{})
+	provider.Push(context.Background(), SyncOptions{})
+	rename := func(text string) {
+		store := NewStore()
+		todoList, _ := store.Get("groceries")
+		todoList.Items[1].Text = text
+		store.MarkDirty("groceries")
+		store.Flush()
+	}
+
+	// Renamed on both sides, in Todoist before the rename here: ours wins
+	rename("Rye")
+	if todoList, _ := ParseTodoFile("groceries"); todoList.Items[1].Metadata[metaModified] == "" {
+		t.Fatalf("Expected the renamed item to record when it changed, got %+v", todoList.Items[1])
+	}
+	fake.tasks[0].Content = "Sourdough"
+	fake.tasks[0].UpdatedAt = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339Nano)
+	if changes, _ := provider.Pull(context.Background(), SyncOptions{}); len(changes) != 0 {
+		t.Errorf("Expected nothing to pull, got %+v", changes)
+	}
+	changes, err := provider.Push(context.Background(), SyncOptions{})
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if len(changes) != 1 || !strings.Contains(changes[0].Detail, "the local change is newer") || fake.tasks[0].Content != "Rye" {
+		t.Fatalf("Expected the local rename to win, got %+v and %+v", changes, fake.tasks[0])
+	}
+
+	// Reopened here while Todoist set a due date later: Todoist wins
+	CheckTodoItem("groceries", 2)
+	provider.Push(context.Background(), SyncOptions{})
+	UncheckTodoItem("groceries", 2)
+	fake.tasks[0].Due = &struct {
+		Date string `json:"date"`
+	}{Date: "2026-03-01"}
+	fake.tasks[0].UpdatedAt = time.Now().Add(time.Hour).UTC().Format(time.RFC3339Nano)
+	changes, err = provider.Pull(context.Background(), SyncOptions{})
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if len(changes) != 1 || !strings.Contains(changes[0].Detail, "Todoist's change is newer") {
+		t.Fatalf("Unexpected pulled changes: %+v", changes)
+	}
+	if todoList, _ := ParseTodoFile("groceries"); !todoList.Items[1].Completed || todoList.Items[1].DueDate == nil {
+		t.Errorf("Expected Todoist's version, got %+v", todoList.Items[1])
+	}
+
+	// Without an updated_at there is no time to go by, so the conflict is
+	// kept
+	rename("Spelt")
+	fake.tasks[0].Content = "Barley"
+	fake.tasks[0].UpdatedAt, fake.tasks[0].CompletedAt = "", ""
+	changes, err = provider.Pull(context.Background(), SyncOptions{})
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Action != SyncConflict {
+		t.Fatalf("Expected a conflict, got %+v", changes)
+	}
+	if todoList, _ := ParseTodoFile("groceries"); todoList.Items[1].Text != "Spelt" {
+		t.Errorf("Expected the conflict to be left alone, got %+v", todoList.Items[1])
+	}
+
+	// --force settles it in favour of the direction synced
+	if _, err := provider.Pull(context.Background(), SyncOptions{Force: true}); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if todoList, _ := ParseTodoFile("groceries"); todoList.Items[1].Text != "Barley" {
+		t.Errorf("Expected Todoist's version, got %+v", todoList.Items[1])
+	}
+}
+
+func TestTodoistSyncDeletedTaskWins(t *testing.T) {
+	fake := setupTodoist(t)
+	fake.tasks = []*todoistTask{{ID: "t-1", ProjectID: "p-1", Content: "Bread"}}
+	provider, _ := GetSyncProvider("todoist")
+	provider.Pull(context.Background(), SyncOptions{})
+
+	fake.tasks = nil
+	CheckTodoItem("groceries", 2)
+	changes, err := provider.Pull(context.Background(), SyncOptions{})
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Action != SyncDelete {
+		t.Fatalf("Expected the deletion to win, got %+v", changes)
+	}
+	if todoList, _ := ParseTodoFile("groceries"); len(todoList.Items) != 1 {
+		t.Errorf("Expected the item to be removed, got %+v", todoList.Items)
+	}
+}
@@ -0,0 +1,121 @@
+package pkg
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// todo.txt (http://todotxt.org) keeps a task per line:
+//
+//	x 2024-06-27 2024-06-20 Write notes +release @laptop due:2024-06-28 pri:A
+//	(B) 2024-06-21 Book flights @phone
+//
+// A leading "x" marks a task done, followed by the day it was completed and
+// the day it was created; an open task starts with its priority, (A) to
+// (Z), and the day it was created. +projects, @contexts and key:value tags
+// go anywhere in the text.
+
+var (
+	todoTxtDoneRegex     = regexp.MustCompile(`^x\s+(?:(\d{4}-\d{2}-\d{2})\s+(?:(\d{4}-\d{2}-\d{2})\s+)?)?(.*)$`)
+	todoTxtOpenRegex     = regexp.MustCompile(`^(?:\(([A-Z])\)\s+)?(?:(\d{4}-\d{2}-\d{2})\s+)?(.*)$`)
+	todoTxtDueRegex      = regexp.MustCompile(`(?:^|\s)due:(\d{4}-\d{2}-\d{2})(?:\s|$)`)
+	todoTxtPriorityRegex = regexp.MustCompile(`(?:^|\s)pri:([A-Z])(?:\s|$)`)
+	todoTxtProjectRegex  = regexp.MustCompile(`[^\w-]+`)
+)
+
+// todoTxtPriority maps a todo.txt priority letter to a priority level. (A)
+// to (C) are p1 to p3, and the letters after (C) count as p3.
+func todoTxtPriority(letter string) int {
+	return min(int(letter[0]-'A')+1, MaxPriority)
+}
+
+// ParseTodoTxt reads the tasks of a todo.txt file. Priorities, due: tags,
+// completion dates and creation dates become the item's priority, due date,
+// completion time and added day; +projects, @contexts and other tags stay in
+// the text, where @contexts work as they do in any item.
+func ParseTodoTxt(content string) []TodoItem {
+	var items []TodoItem
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		item := TodoItem{Metadata: make(map[string]string)}
+		var priority, completed, created, text string
+		if match := todoTxtDoneRegex.FindStringSubmatch(line); match != nil {
+			item.Completed = true
+			completed, created, text = match[1], match[2], match[3]
+			if pri := todoTxtPriorityRegex.FindStringSubmatch(text); pri != nil {
+				priority = pri[1]
+				text = todoTxtPriorityRegex.ReplaceAllString(text, " ")
+			}
+		} else {
+			match := todoTxtOpenRegex.FindStringSubmatch(line)
+			priority, created, text = match[1], match[2], match[3]
+		}
+
+		if due := todoTxtDueRegex.FindStringSubmatch(text); due != nil {
+			if day, err := time.ParseInLocation(DueDateFormat, due[1], time.Local); err == nil {
+				item.DueDate = &day
+				text = todoTxtDueRegex.ReplaceAllString(text, " ")
+			}
+		}
+		if priority != "" {
+			item.Priority = todoTxtPriority(priority)
+		}
+		if day, err := time.ParseInLocation(DueDateFormat, completed, time.Local); err == nil {
+			item.CompletedTime = &day
+		}
+		if _, err := time.ParseInLocation(DueDateFormat, created, time.Local); err == nil {
+			item.Metadata[metaAdded] = created
+		}
+		item.Text = strings.Join(strings.Fields(text), " ")
+		if item.Text == "" {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// FormatTodoTxt writes a list as todo.txt, a line per item. The section an
+// item is in becomes a +project, and a completed item keeps its priority as
+// a pri: tag, as todo.txt clients do. Notes, estimates and weights, which
+// todo.txt has no place for, are left out.
+func FormatTodoTxt(todoList *TodoList) string {
+	var out strings.Builder
+	for _, item := range todoList.Items {
+		var fields []string
+		if item.Completed {
+			fields = append(fields, "x")
+			if item.CompletedTime != nil {
+				fields = append(fields, item.CompletedTime.Format(DueDateFormat))
+				if added := item.Metadata[metaAdded]; added != "" {
+					fields = append(fields, added)
+				}
+			}
+		} else {
+			if item.Priority > 0 {
+				fields = append(fields, "("+string(rune('A'+item.Priority-1))+")")
+			}
+			if added := item.Metadata[metaAdded]; added != "" {
+				fields = append(fields, added)
+			}
+		}
+
+		fields = append(fields, strings.Join(strings.Fields(item.Text), " "))
+		if project := strings.Trim(todoTxtProjectRegex.ReplaceAllString(item.Section, "-"), "-"); project != "" && !strings.Contains(" "+item.Text+" ", " +"+project+" ") {
+			fields = append(fields, "+"+project)
+		}
+		if item.DueDate != nil {
+			fields = append(fields, "due:"+item.DueDate.Format(DueDateFormat))
+		}
+		if item.Completed && item.Priority > 0 {
+			fields = append(fields, "pri:"+string(rune('A'+item.Priority-1)))
+		}
+		out.WriteString(strings.Join(fields, " ") + "\n")
+	}
+	return out.String()
+}
@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTodoTxt(t *testing.T) {
+	items := ParseTodoTxt("(A) 2024-06-21 Book flights @phone +travel due:2024-07-01\n" +
+		"x 2024-06-27 2024-06-20 Write notes +release pri:B\n" +
+		"\n" +
+		"(E) Someday\n" +
+		"x Done long ago\n" +
+		"xylophone lessons\n")
+	if len(items) != 5 {
+		t.Fatalf("Expected 5 items, got %+v", items)
+	}
+
+	flights := items[0]
+	if flights.Text != "Book flights @phone +travel" || flights.Priority != 1 || flights.Completed {
+		t.Errorf("Unexpected open task %+v", flights)
+	}
+	if flights.DueDate == nil || flights.DueDate.Format(DueDateFormat) != "2024-07-01" || flights.Metadata[metaAdded] != "2024-06-21" {
+		t.Errorf("Expected the due date and creation date, got %+v", flights)
+	}
+
+	notes := items[1]
+	if !notes.Completed || notes.Text != "Write notes +release" || notes.Priority != 2 {
+		t.Errorf("Unexpected done task %+v", notes)
+	}
+	if notes.CompletedTime == nil || !notes.CompletedTime.Equal(time.Date(2024, 6, 27, 0, 0, 0, 0, time.Local)) || notes.Metadata[metaAdded] != "2024-06-20" {
+		t.Errorf("Expected the completion and creation dates, got %+v", notes)
+	}
+
+	if items[2].Priority != MaxPriority {
+		t.Errorf("Expected (E) to count as p%d, got %d", MaxPriority, items[2].Priority)
+	}
+	if !items[3].Completed || items[3].Text != "Done long ago" || items[3].CompletedTime != nil {
+		t.Errorf("Expected a done task without dates, got %+v", items[3])
+	}
+	if items[4].Completed || items[4].Text != "xylophone lessons" {
+		t.Errorf("Expected a word starting with x to be an open task, got %+v", items[4])
+	}
+}
+
+func TestFormatTodoTxt(t *testing.T) {
+	completed := time.Date(2024, 6, 27, 16, 30, 0, 0, time.Local)
+	due := time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local)
+	todoList := &TodoList{Items: []TodoItem{
+		{ID: 1, Text: "Book flights @phone", Priority: 1, DueDate: &due, Metadata: map[string]string{metaAdded: "2024-06-21"}},
+		{ID: 2, Text: "Write notes +release", Section: "release", Completed: true, CompletedTime: &completed, Priority: 2, Metadata: map[string]string{metaAdded: "2024-06-20"}},
+		{ID: 3, Text: "Deploy", Section: "Release / Web", Notes: []string{"left out"}},
+	}}
+	want := "(A) 2024-06-21 Book flights @phone due:2024-07-01\n" +
+		"x 2024-06-27 2024-06-20 Write notes +release pri:B\n" +
+		"Deploy +Release-Web\n"
+	if got := FormatTodoTxt(todoList); got != want {
+		t.Errorf("FormatTodoTxt:\n got %q\nwant %q", got, want)
+	}
+
+	// What todo.txt can hold reads back the same
+	items := ParseTodoTxt(want)
+	if items[0].Priority != 1 || items[0].DueDate == nil || !items[1].Completed || items[1].Priority != 2 {
+		t.Errorf("Expected the list to read back, got %+v", items)
+	}
+}
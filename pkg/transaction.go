@@ -0,0 +1,145 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// A Transaction writes several files so that either all of them change or
+// none do. Each file is first written in full to a temporary file beside
+// it; only once every one of them is on disk are they renamed over the
+// originals. If a write fails nothing has been touched, and if a rename
+// fails the files already replaced are put back the way they were.
+type Transaction struct {
+	files map[string][]byte
+}
+
+func NewTransaction() *Transaction {
+	return &Transaction{files: make(map[string][]byte)}
+}
+
+// Stage queues content to be written to path on Commit, replacing anything
+// staged for it before
+func (tx *Transaction) Stage(path string, content []byte) {
+	tx.files[path] = content
+}
+
+// Len returns the number of files staged
+func (tx *Transaction) Len() int {
+	return len(tx.files)
+}
+
+// stagedFile is a file written to its temporary path and what it replaces
+type stagedFile struct {
+	path, tmp string
+	// original holds the file's previous content, or nil if it is new
+	original []byte
+	mode     os.FileMode
+}
+
+// Commit writes every staged file, or none of them
+func (tx *Transaction) Commit() error {
+	var paths []string
+	for path := range tx.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var staged []stagedFile
+	cleanup := func() {
+		for _, file := range staged {
+			os.Remove(file.tmp)
+		}
+	}
+	for _, path := range paths {
+		file := stagedFile{path: path, mode: 0644}
+		if info, err := os.Stat(path); err == nil {
+			file.mode = info.Mode().Perm()
+			if file.original, err = os.ReadFile(path); err != nil {
+				cleanup()
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+		}
+		tmp, err := writeTempFile(path, tx.files[path], file.mode)
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		file.tmp = tmp
+		staged = append(staged, file)
+	}
+
+	for i, file := range staged {
+		if err := os.Rename(file.tmp, file.path); err != nil {
+			cleanup()
+			if rollbackErr := rollback(staged[:i]); rollbackErr != nil {
+				return fmt.Errorf("failed to write %s: %w (and restoring the other files failed: %v)", file.path, err, rollbackErr)
+			}
+			return fmt.Errorf("failed to write %s: %w", file.path, err)
+		}
+	}
+	tx.files = make(map[string][]byte)
+	return nil
+}
+
+// rollback puts back the files a failed commit already replaced
+func rollback(replaced []stagedFile) error {
+	var errs []error
+	for _, file := range replaced {
+		if file.original == nil {
+			if err := os.Remove(file.path); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err := writeFileAtomic(file.path, file.original); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeFileAtomic writes a file through a temporary file in the same
+// directory, so readers never see half of it
+func writeFileAtomic(path string, content []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := writeTempFile(path, content, mode)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// writeTempFile writes content to a new hidden file next to path and
+// flushes it to disk, returning its name
+func writeTempFile(path string, content []byte, mode os.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
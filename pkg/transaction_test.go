@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTransactionCommit(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "a.md")
+	os.WriteFile(existing, []byte("old"), 0600)
+
+	tx := NewTransaction()
+	tx.Stage(existing, []byte("new a"))
+	tx.Stage(filepath.Join(dir, "b.md"), []byte("new b"))
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	for name, want := range map[string]string{"a.md": "new a", "b.md": "new b"} {
+		if content, _ := os.ReadFile(filepath.Join(dir, name)); string(content) != want {
+			t.Errorf("%s = %q, want %q", name, content, want)
+		}
+	}
+	if info, _ := os.Stat(existing); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file mode to be kept, got %v", info.Mode())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("Expected no temporary files left, got %d entries", len(entries))
+	}
+	if tx.Len() != 0 {
+		t.Errorf("Expected a committed transaction to be empty")
+	}
+}
+
+func TestTransactionWritesNothingOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "a.md")
+	os.WriteFile(existing, []byte("old"), 0644)
+
+	tx := NewTransaction()
+	tx.Stage(existing, []byte("new"))
+	tx.Stage(filepath.Join(dir, "missing", "b.md"), []byte("new"))
+	if err := tx.Commit(); err == nil {
+		t.Fatal("Expected a write into a missing directory to fail")
+	}
+
+	if content, _ := os.ReadFile(existing); string(content) != "old" {
+		t.Errorf("Expected a.md to be untouched, got %q", content)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected no temporary files left, got %d entries", len(entries))
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	dir := t.TempDir()
+	changed := filepath.Join(dir, "a.md")
+	created := filepath.Join(dir, "b.md")
+	os.WriteFile(changed, []byte("new"), 0644)
+	os.WriteFile(created, []byte("new"), 0644)
+
+	err := rollback([]stagedFile{
+		{path: changed, original: []byte("old"), mode: 0644},
+		{path: created},
+	})
+	if err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+	if content, _ := os.ReadFile(changed); string(content) != "old" {
+		t.Errorf("Expected a.md to be restored, got %q", content)
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Errorf("Expected the new b.md to be removed")
+	}
+}
@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Triage actions
+const (
+	TriageDone   = "done"
+	TriageDelete = "delete"
+	// TriageSnooze makes the item due tomorrow
+	TriageSnooze = "snooze"
+	TriageMove   = "move"
+	TriageTag    = "tag"
+	TriageSkip   = "skip"
+)
+
+// TriageDecision is what to do with one item of a list being triaged
+type TriageDecision struct {
+	Action string
+	// Target is the list to move the item to, or the tag to add
+	Target string
+}
+
+// ApplyTriage carries out decisions about the items of a list, keyed by
+// item ID. Every decision is checked before anything is written, and all
+// changed lists are written together at the end.
+func ApplyTriage(listName string, decisions map[int]TriageDecision, now time.Time) error {
+	store := NewStore()
+	todoList, err := store.Get(listName)
+	if err != nil {
+		return err
+	}
+
+	for itemID, decision := range decisions {
+		if itemID < 1 || itemID > len(todoList.Items) {
+			return fmt.Errorf("invalid item ID: %d", itemID)
+		}
+		switch decision.Action {
+		case TriageDone, TriageDelete, TriageSnooze, TriageSkip:
+		case TriageMove:
+			if decision.Target == listName {
+				return fmt.Errorf("item %d is already in list '%s'", itemID, listName)
+			}
+			if !TodoFileExists(decision.Target) {
+				return fmt.Errorf("list '%s' does not exist", decision.Target)
+			}
+		case TriageTag:
+			if !ValidTagName(decision.Target) {
+				return fmt.Errorf("invalid tag %q", decision.Target)
+			}
+		default:
+			return fmt.Errorf("unknown triage action %q", decision.Action)
+		}
+	}
+
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.Local)
+
+	// Moved items keep the order they had here
+	var moves []int
+	for itemID, decision := range decisions {
+		if decision.Action == TriageMove {
+			moves = append(moves, itemID)
+		}
+	}
+	sort.Ints(moves)
+	for _, itemID := range moves {
+		target, err := store.Get(decisions[itemID].Target)
+		if err != nil {
+			return err
+		}
+		item := todoList.Items[itemID-1]
+		item.ID = len(target.Items) + 1
+		item.Section = ""
+		if len(target.Items) > 0 {
+			item.Section = target.Items[len(target.Items)-1].Section
+		}
+		target.Items = append(target.Items, item)
+		store.MarkDirty(decisions[itemID].Target)
+	}
+
+	var kept []TodoItem
+	for _, item := range todoList.Items {
+		decision := decisions[item.ID]
+		switch decision.Action {
+		case TriageDelete, TriageMove:
+			continue
+		case TriageDone:
+			completed := now
+			item.Completed = true
+			item.CompletedTime = &completed
+		case TriageSnooze:
+			due := tomorrow
+			item.DueDate = &due
+		case TriageTag:
+			tag := strings.TrimPrefix(decision.Target, "#")
+			if !slices.Contains(ExtractTags(item.Text), strings.ToLower(tag)) {
+				item.Text += " #" + tag
+			}
+		}
+		item.ID = len(kept) + 1
+		kept = append(kept, item)
+	}
+	todoList.Items = kept
+	store.MarkDirty(listName)
+
+	return store.Flush()
+}
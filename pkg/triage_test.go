@@ -0,0 +1,80 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyTriage(t *testing.T) {
+	setupTestDir(t)
+
+	CreateTodoFile("inbox")
+	for _, text := range []string{"Done soon", "Old idea", "Later", "Belongs elsewhere", "Needs a tag", "Untouched"} {
+		AddTodoItem("inbox", text)
+	}
+	CreateTodoFile("work")
+	AddTodoItem("work", "Existing")
+
+	now := time.Date(2024, 7, 8, 15, 0, 0, 0, time.Local)
+	decisions := map[int]TriageDecision{
+		1: {Action: TriageDone},
+		2: {Action: TriageDelete},
+		3: {Action: TriageSnooze},
+		4: {Action: TriageMove, Target: "work"},
+		5: {Action: TriageTag, Target: "#errands"},
+		6: {Action: TriageSkip},
+	}
+	if err := ApplyTriage("inbox", decisions, now); err != nil {
+		t.Fatalf("ApplyTriage failed: %v", err)
+	}
+
+	inbox, _ := ParseTodoFile("inbox")
+	if len(inbox.Items) != 4 {
+		t.Fatalf("Expected 4 items left, got %+v", inbox.Items)
+	}
+	if !inbox.Items[0].Completed {
+		t.Error("Expected the first item to be done")
+	}
+	if due := inbox.Items[1].DueDate; inbox.Items[1].Text != "Later" || due == nil || due.Format(DueDateFormat) != "2024-07-09" {
+		t.Errorf("Expected 'Later' to be due tomorrow, got %+v", inbox.Items[1])
+	}
+	if inbox.Items[2].Text != "Needs a tag #errands" || inbox.Items[3].Text != "Untouched" {
+		t.Errorf("Unexpected items: %+v", inbox.Items)
+	}
+	for i, item := range inbox.Items {
+		if item.ID != i+1 {
+			t.Errorf("Expected item %d to be renumbered, got ID %d", i+1, item.ID)
+		}
+	}
+
+	work, _ := ParseTodoFile("work")
+	if len(work.Items) != 2 || work.Items[1].Text != "Belongs elsewhere" {
+		t.Errorf("Expected the moved item in work, got %+v", work.Items)
+	}
+}
+
+func TestApplyTriageRejectsBadDecisions(t *testing.T) {
+	setupTestDir(t)
+
+	CreateTodoFile("inbox")
+	AddTodoItem("inbox", "Item")
+
+	for _, decision := range []TriageDecision{
+		{Action: TriageMove, Target: "missing"},
+		{Action: TriageMove, Target: "inbox"},
+		{Action: TriageTag, Target: "two words"},
+		{Action: "archive"},
+	} {
+		if err := ApplyTriage("inbox", map[int]TriageDecision{1: decision}, time.Now()); err == nil {
+			t.Errorf("Expected an error for %+v", decision)
+		}
+	}
+	if err := ApplyTriage("inbox", map[int]TriageDecision{2: {Action: TriageDone}}, time.Now()); err == nil {
+		t.Error("Expected an error for a missing item")
+	}
+
+	inbox, _ := ParseTodoFile("inbox")
+	if len(inbox.Items) != 1 || inbox.Items[0].Completed {
+		t.Errorf("Rejected decisions should not change the list, got %+v", inbox.Items)
+	}
+}
@@ -0,0 +1,448 @@
+package pkg
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// UIKey is a key pressed in 'todo ui': a character, or one of the named
+// keys below
+type UIKey rune
+
+// Named keys, which sort below every character
+const (
+	KeyUp UIKey = -1 - iota
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyEnter
+	KeyEscape
+	KeyBackspace
+	KeyTab
+	KeyInterrupt
+)
+
+// ReadUIKey reads one key press from a terminal in raw mode. Arrow keys
+// arrive as escape sequences, and an escape with nothing after it is the
+// escape key itself. Sequences for other keys read as 0, which does
+// nothing.
+func ReadUIKey(r *bufio.Reader) (UIKey, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	switch c {
+	case 3:
+		return KeyInterrupt, nil
+	case '\r', '\n':
+		return KeyEnter, nil
+	case 8, 127:
+		return KeyBackspace, nil
+	case '\t':
+		return KeyTab, nil
+	case 27:
+		seq, err := r.Peek(2)
+		if err != nil || r.Buffered() < 2 || (seq[0] != '[' && seq[0] != 'O') {
+			return KeyEscape, nil
+		}
+		final := seq[1]
+		r.Discard(2)
+		switch final {
+		case 'A':
+			return KeyUp, nil
+		case 'B':
+			return KeyDown, nil
+		case 'C':
+			return KeyRight, nil
+		case 'D':
+			return KeyLeft, nil
+		}
+		// Skip the rest of longer sequences, such as ESC [ 3 ~ for delete
+		for final >= '0' && final <= '9' || final == ';' {
+			if final, err = r.ReadByte(); err != nil {
+				break
+			}
+		}
+		return 0, nil
+	}
+	return UIKey(c), nil
+}
+
+type uiMode int
+
+const (
+	uiBrowse uiMode = iota
+	// uiAdd and uiAddUnder read the text of a new item
+	uiAdd
+	uiAddUnder
+	// uiMove picks the list to move an item to
+	uiMove
+	// uiDelete asks before deleting an item
+	uiDelete
+)
+
+// UIHelp lists the keys of 'todo ui'
+const UIHelp = "↑/↓ select  tab switch pane  space check  a add  A add subtask  m move  d delete  q quit"
+
+// UI is the state of the full-screen 'todo ui': the lists in a sidebar and
+// the items of the selected one in the main pane. It reads and writes the
+// list files as keys are pressed, so the terminal loop around it only has
+// to feed it keys and draw its View.
+type UI struct {
+	lists []string
+	list  int
+	view  ListView
+
+	cursor, offset int
+	sidebar        bool
+	mode           uiMode
+	input          []rune
+	target         int
+	message        string
+}
+
+// NewUI opens the UI on a list, or on the first one when it doesn't exist
+func NewUI(current string) (*UI, error) {
+	lists, err := GetAllLists()
+	if err != nil {
+		return nil, err
+	}
+	if len(lists) == 0 {
+		return nil, fmt.Errorf("no todo lists found")
+	}
+	u := &UI{lists: lists}
+	for i, name := range lists {
+		if name == current {
+			u.list = i
+		}
+	}
+	return u, u.load()
+}
+
+// load reads the selected list again, keeping the cursor in range
+func (u *UI) load() error {
+	name := u.lists[u.list]
+	todoList, err := ParseTodoFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to parse todo file: %w", err)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	u.view = NewListView(name, todoList, cfg, Now())
+	u.cursor = min(u.cursor, max(len(todoList.Items)-1, 0))
+	return nil
+}
+
+// selected returns the item under the cursor
+func (u *UI) selected() (TodoItem, bool) {
+	if u.cursor >= len(u.view.List.Items) {
+		return TodoItem{}, false
+	}
+	return u.view.List.Items[u.cursor], true
+}
+
+// selectItem puts the cursor on the item with an ID
+func (u *UI) selectItem(itemID int) {
+	for i, item := range u.view.List.Items {
+		if item.ID == itemID {
+			u.cursor = i
+		}
+	}
+}
+
+// HandleKey acts on a key press, reporting true when the UI should close
+func (u *UI) HandleKey(key UIKey) (quit bool) {
+	if key == KeyInterrupt {
+		return true
+	}
+	u.message = ""
+	switch u.mode {
+	case uiAdd, uiAddUnder:
+		u.handleInput(key)
+	case uiMove:
+		u.handleMove(key)
+	case uiDelete:
+		if key == 'y' || key == 'Y' {
+			u.deleteItem()
+		}
+		u.mode = uiBrowse
+	default:
+		return u.handleBrowse(key)
+	}
+	return false
+}
+
+func (u *UI) handleBrowse(key UIKey) (quit bool) {
+	switch key {
+	case 'q':
+		return true
+	case KeyTab, KeyLeft, KeyRight, 'h', 'l':
+		u.sidebar = !u.sidebar
+	case KeyUp, 'k':
+		u.moveCursor(-1)
+	case KeyDown, 'j':
+		u.moveCursor(1)
+	case ' ', 'x', KeyEnter:
+		if u.sidebar {
+			u.sidebar = false
+		} else {
+			u.toggle()
+		}
+	case 'a':
+		u.mode, u.input = uiAdd, nil
+	case 'A':
+		if _, ok := u.selected(); ok {
+			u.mode, u.input = uiAddUnder, nil
+		}
+	case 'm':
+		if _, ok := u.selected(); ok && len(u.lists) > 1 {
+			u.mode, u.target = uiMove, (u.list+1)%len(u.lists)
+		} else if ok {
+			u.message = "There is no other list to move it to."
+		}
+	case 'd':
+		if _, ok := u.selected(); ok {
+			u.mode = uiDelete
+		}
+	}
+	return false
+}
+
+// moveCursor selects the next or previous list or item
+func (u *UI) moveCursor(step int) {
+	if !u.sidebar {
+		u.cursor = min(max(u.cursor+step, 0), max(len(u.view.List.Items)-1, 0))
+		return
+	}
+	next := min(max(u.list+step, 0), len(u.lists)-1)
+	if next != u.list {
+		u.list, u.cursor, u.offset = next, 0, 0
+		u.report(u.load())
+	}
+}
+
+func (u *UI) handleInput(key UIKey) {
+	switch {
+	case key == KeyEscape:
+		u.mode = uiBrowse
+	case key == KeyBackspace:
+		if len(u.input) > 0 {
+			u.input = u.input[:len(u.input)-1]
+		}
+	case key == KeyEnter:
+		text := strings.TrimSpace(string(u.input))
+		if text != "" {
+			u.addItem(text)
+		}
+		u.mode = uiBrowse
+	case key >= ' ':
+		u.input = append(u.input, rune(key))
+	}
+}
+
+func (u *UI) handleMove(key UIKey) {
+	step := 0
+	switch key {
+	case KeyEscape, 'q':
+		u.mode = uiBrowse
+	case KeyUp, 'k':
+		step = -1
+	case KeyDown, 'j':
+		step = 1
+	case KeyEnter:
+		u.moveItem()
+		u.mode = uiBrowse
+	}
+	// The target skips over the list the item is in
+	for step != 0 {
+		next := u.target + step
+		if next < 0 || next >= len(u.lists) {
+			break
+		}
+		u.target = next
+		if next != u.list {
+			break
+		}
+	}
+}
+
+func (u *UI) toggle() {
+	item, ok := u.selected()
+	if !ok {
+		return
+	}
+	name := u.lists[u.list]
+	if item.Completed {
+		u.report(UncheckTodoItem(name, item.ID))
+	} else {
+		u.report(CheckTodoItem(name, item.ID))
+	}
+	u.report(u.load())
+}
+
+func (u *UI) addItem(text string) {
+	name := u.lists[u.list]
+	store := NewStore()
+	var itemID int
+	var err error
+	if item, ok := u.selected(); ok && u.mode == uiAddUnder {
+		itemID, err = store.AddItemUnder(name, item.ID, text)
+	} else {
+		itemID, err = store.AddItem(name, text)
+	}
+	if err == nil {
+		err = store.Flush()
+	}
+	if u.report(err) {
+		u.report(u.load())
+		u.selectItem(itemID)
+		u.message = fmt.Sprintf("Added '%s'", text)
+	}
+}
+
+func (u *UI) moveItem() {
+	item, ok := u.selected()
+	if !ok {
+		return
+	}
+	target := u.lists[u.target]
+	err := ApplyTriage(u.lists[u.list], map[int]TriageDecision{item.ID: {Action: TriageMove, Target: target}}, Now())
+	if u.report(err) {
+		u.report(u.load())
+		u.message = fmt.Sprintf("Moved '%s' to list '%s'", item.Text, target)
+	}
+}
+
+func (u *UI) deleteItem() {
+	item, ok := u.selected()
+	if !ok {
+		return
+	}
+	store := NewStore()
+	_, err := store.RemoveItems(u.lists[u.list], []int{item.ID})
+	if err == nil {
+		err = store.Flush()
+	}
+	if u.report(err) {
+		u.report(u.load())
+		u.message = fmt.Sprintf("Deleted '%s'", item.Text)
+	}
+}
+
+// report shows an error in the status line, reporting whether there was
+// none
+func (u *UI) report(err error) bool {
+	if err != nil {
+		u.message = fmt.Sprintf("Error: %v", err)
+		return false
+	}
+	return true
+}
+
+// ANSI styles for the UI
+const (
+	uiReverse = "\033[7m"
+	uiBold    = "\033[1m"
+	uiDim     = "\033[2m"
+	uiReset   = "\033[0m"
+)
+
+// View draws the UI as lines filling a terminal of the given size
+func (u *UI) View(width, height int) []string {
+	sidebarWidth := 12
+	for _, name := range u.lists {
+		sidebarWidth = max(sidebarWidth, displayWidth(name)+2)
+	}
+	sidebarWidth = min(sidebarWidth, width/3)
+	mainWidth := width - sidebarWidth - 1
+	rows := max(height-2, 1)
+
+	// Scroll just enough to keep the cursor in sight
+	if u.cursor < u.offset {
+		u.offset = u.cursor
+	} else if u.cursor >= u.offset+rows {
+		u.offset = u.cursor - rows + 1
+	}
+
+	progress := u.view.List.Progress()
+	lines := []string{uiBold + fitColumns(fmt.Sprintf(" %s — %d/%d completed", u.view.Name, progress.Completed, progress.Total), width) + uiReset}
+	for row := 0; row < rows; row++ {
+		lines = append(lines, u.sidebarRow(row, sidebarWidth)+"│"+u.itemRow(u.offset+row, mainWidth))
+	}
+	return append(lines, u.statusLine(width))
+}
+
+func (u *UI) sidebarRow(row, width int) string {
+	if row >= len(u.lists) {
+		return strings.Repeat(" ", width)
+	}
+	text := fitColumns(" "+u.lists[row], width)
+	switch {
+	case u.mode == uiMove && row == u.target:
+		return uiReverse + text + uiReset
+	case row == u.list && u.sidebar:
+		return uiReverse + text + uiReset
+	case row == u.list:
+		return uiBold + text + uiReset
+	}
+	return text
+}
+
+func (u *UI) itemRow(index, width int) string {
+	if index >= len(u.view.List.Items) {
+		if index == 0 {
+			return fitColumns(" No items yet; press a to add one.", width)
+		}
+		return strings.Repeat(" ", width)
+	}
+	item := u.view.List.Items[index]
+	status := "[ ]"
+	if item.Completed {
+		status = "[x]"
+	}
+	indent := strings.Repeat(subtaskIndent, u.view.List.depths()[index])
+	text := fitColumns(fmt.Sprintf(" %s%s. %s %s", indent, u.view.Labels[index], status, item.Text), width)
+	switch {
+	case index == u.cursor && !u.sidebar:
+		return uiReverse + text + uiReset
+	case item.Completed:
+		return uiDim + text + uiReset
+	}
+	return text
+}
+
+func (u *UI) statusLine(width int) string {
+	var status string
+	switch u.mode {
+	case uiAdd:
+		status = "Add item: " + string(u.input) + "█"
+	case uiAddUnder:
+		item, _ := u.selected()
+		status = fmt.Sprintf("Add subtask under '%s': %s█", item.Text, string(u.input))
+	case uiMove:
+		status = fmt.Sprintf("Move to list '%s'? ↑/↓ choose  enter move  esc cancel", u.lists[u.target])
+	case uiDelete:
+		item, _ := u.selected()
+		status = fmt.Sprintf("Delete '%s'? (y/N)", item.Text)
+	default:
+		status = u.message
+		if status == "" {
+			status = UIHelp
+		}
+	}
+	return uiReverse + fitColumns(" "+status, width) + uiReset
+}
+
+// fitColumns cuts or pads text to exactly the given number of columns
+func fitColumns(text string, columns int) string {
+	if columns <= 0 {
+		return ""
+	}
+	if displayWidth(text) > columns {
+		text = truncateText(text, columns)
+	}
+	return PadRight(text, columns)
+}
@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestReadUIKey(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("j\033[A\033[B\t\r\x7f\033[3~é\x03"))
+	want := []UIKey{'j', KeyUp, KeyDown, KeyTab, KeyEnter, KeyBackspace, 0, 'é', KeyInterrupt}
+	for i, expected := range want {
+		key, err := ReadUIKey(reader)
+		if err != nil {
+			t.Fatalf("ReadUIKey %d failed: %v", i, err)
+		}
+		if key != expected {
+			t.Errorf("Key %d = %d, want %d", i, key, expected)
+		}
+	}
+
+	// An escape on its own is the escape key
+	if key, _ := ReadUIKey(bufio.NewReader(strings.NewReader("\033"))); key != KeyEscape {
+		t.Errorf("Expected a lone escape to be KeyEscape, got %d", key)
+	}
+}
+
+// typeKeys presses keys in the UI, typing the characters of any strings
+func typeKeys(ui *UI, keys ...interface{}) {
+	for _, key := range keys {
+		switch key := key.(type) {
+		case UIKey:
+			ui.HandleKey(key)
+		case string:
+			for _, r := range key {
+				ui.HandleKey(UIKey(r))
+			}
+		}
+	}
+}
+
+func TestUIEditsLists(t *testing.T) {
+	setupTestDir(t)
+	store := NewStore()
+	for _, text := range []string{"Login form", "Logout button"} {
+		store.AddItem("auth", text)
+	}
+	store.AddItem("billing", "Invoices")
+	store.Flush()
+
+	ui, err := NewUI("auth")
+	if err != nil {
+		t.Fatalf("NewUI failed: %v", err)
+	}
+
+	// Check the second item
+	typeKeys(ui, KeyDown, UIKey(' '))
+	if list, _ := ParseTodoFile("auth"); !list.Items[1].Completed {
+		t.Errorf("Expected item 2 to be checked, got %+v", list.Items)
+	}
+
+	// Add an item, then a subtask under it
+	typeKeys(ui, "aSession timeout", KeyEnter, "ARemember me", KeyEnter)
+	list, _ := ParseTodoFile("auth")
+	if len(list.Items) != 4 || list.Items[2].Text != "Session timeout" || list.Parent(4) != 3 {
+		t.Errorf("Expected the item and its subtask to be added, got %+v", list.Items)
+	}
+
+	// Escape drops an item being typed
+	typeKeys(ui, "aNever mind", KeyEscape)
+	if list, _ := ParseTodoFile("auth"); len(list.Items) != 4 {
+		t.Errorf("Expected escape to cancel adding, got %+v", list.Items)
+	}
+
+	// Move the first item to billing
+	typeKeys(ui, KeyUp, KeyUp, KeyUp, UIKey('m'), KeyEnter)
+	billing, _ := ParseTodoFile("billing")
+	if len(billing.Items) != 2 || billing.Items[1].Text != "Login form" {
+		t.Errorf("Expected Login form to move to billing, got %+v", billing.Items)
+	}
+
+	// Deleting asks first
+	typeKeys(ui, UIKey('d'), UIKey('n'))
+	if list, _ := ParseTodoFile("auth"); len(list.Items) != 3 {
+		t.Errorf("Expected no to keep the item, got %+v", list.Items)
+	}
+	typeKeys(ui, UIKey('d'), UIKey('y'))
+	if list, _ := ParseTodoFile("auth"); len(list.Items) != 2 || list.Items[0].Text != "Session timeout" {
+		t.Errorf("Expected Logout button to be deleted, got %+v", list.Items)
+	}
+
+	// Selecting a list in the sidebar shows its items
+	typeKeys(ui, KeyTab, KeyDown, KeyTab)
+	if item, _ := ui.selected(); item.Text != "Invoices" {
+		t.Errorf("Expected billing's first item to be selected, got %+v", item)
+	}
+	if !ui.HandleKey(UIKey('q')) {
+		t.Error("Expected q to quit")
+	}
+}
+
+func TestUIView(t *testing.T) {
+	setupTestDir(t)
+	store := NewStore()
+	store.AddItem("auth", "Login form")
+	store.AddItemUnder("auth", 1, "Remember me")
+	store.Flush()
+
+	ui, err := NewUI("auth")
+	if err != nil {
+		t.Fatalf("NewUI failed: %v", err)
+	}
+	lines := ui.View(60, 6)
+	if len(lines) != 6 {
+		t.Fatalf("Expected 6 lines, got %d: %q", len(lines), lines)
+	}
+	var plain []string
+	for _, line := range lines {
+		for _, style := range []string{uiReverse, uiBold, uiDim, uiReset} {
+			line = strings.ReplaceAll(line, style, "")
+		}
+		if DisplayWidth(line) != 60 {
+			t.Errorf("Expected every line to fill 60 columns, got %d: %q", DisplayWidth(line), line)
+		}
+		plain = append(plain, line)
+	}
+	screen := strings.Join(plain, "\n")
+	for _, want := range []string{" auth — 0/2 completed", "│ 1. [ ] Login form", "│   2. [ ] Remember me", " ↑/↓ select"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("Expected %q in:\n%s", want, screen)
+		}
+	}
+}
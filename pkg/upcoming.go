@@ -0,0 +1,63 @@
+package pkg
+
+import (
+	"fmt"
+	"time"
+)
+
+// DueSoonDays is how many days ahead a pending item counts as due soon, and
+// is set apart when its list is shown
+const DueSoonDays = 3
+
+// DefaultUpcomingDays is how far ahead 'todo due' looks by default
+const DefaultUpcomingDays = 7
+
+// DaysUntil returns how many calendar days after now a due date is, which
+// is negative when it has passed
+func DaysUntil(due, now time.Time) int {
+	if late := DaysLate(due, now); late > 0 {
+		return -late
+	}
+	dueDay := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return int(dueDay.Sub(today).Hours() / 24)
+}
+
+// DueLabel says when a due date is relative to now: "overdue", "today",
+// "tomorrow" or "in 3 days"
+func DueLabel(due, now time.Time) string {
+	switch days := DaysUntil(due, now); {
+	case days < 0:
+		return "overdue"
+	case days == 0:
+		return "today"
+	case days == 1:
+		return "tomorrow"
+	default:
+		return fmt.Sprintf("in %d days", days)
+	}
+}
+
+// dueNote returns the DueLabel of a pending item that is overdue or due
+// soon, or "" for the others
+func dueNote(item TodoItem, now time.Time) string {
+	if item.Completed || item.DueDate == nil || DaysUntil(*item.DueDate, now) > DueSoonDays {
+		return ""
+	}
+	return DueLabel(*item.DueDate, now)
+}
+
+// UpcomingItems returns the pending items of an agenda due from today
+// through days ahead, keeping their agenda order
+func UpcomingItems(agenda []ListItem, now time.Time, days int) []ListItem {
+	var upcoming []ListItem
+	for _, entry := range agenda {
+		if entry.Item.Completed || entry.Item.DueDate == nil {
+			continue
+		}
+		if until := DaysUntil(*entry.Item.DueDate, now); until >= 0 && until <= days {
+			upcoming = append(upcoming, entry)
+		}
+	}
+	return upcoming
+}
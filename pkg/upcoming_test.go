@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDueLabel(t *testing.T) {
+	now := time.Date(2024, 7, 3, 18, 0, 0, 0, time.Local)
+	tests := []struct {
+		due  string
+		want string
+	}{
+		{"2024-07-02", "overdue"},
+		{"2024-07-03", "today"},
+		{"2024-07-04", "tomorrow"},
+		{"2024-07-10", "in 7 days"},
+	}
+	for _, tt := range tests {
+		due, _ := time.ParseInLocation(DueDateFormat, tt.due, time.Local)
+		if got := DueLabel(due, now); got != tt.want {
+			t.Errorf("DueLabel(%s) = %q, want %q", tt.due, got, tt.want)
+		}
+	}
+}
+
+func TestUpcomingItems(t *testing.T) {
+	now := time.Date(2024, 7, 3, 9, 0, 0, 0, time.Local)
+	date := func(value string) *time.Time {
+		due, _ := time.ParseInLocation(DueDateFormat, value, time.Local)
+		return &due
+	}
+	agenda := []ListItem{
+		{List: "work", Item: TodoItem{ID: 1, Text: "Late", DueDate: date("2024-07-01")}},
+		{List: "work", Item: TodoItem{ID: 2, Text: "Today", DueDate: date("2024-07-03")}},
+		{List: "home", Item: TodoItem{ID: 1, Text: "Done", DueDate: date("2024-07-04"), Completed: true}},
+		{List: "home", Item: TodoItem{ID: 2, Text: "Next week", DueDate: date("2024-07-10")}},
+		{List: "home", Item: TodoItem{ID: 3, Text: "Later", DueDate: date("2024-07-11")}},
+	}
+
+	upcoming := UpcomingItems(agenda, now, 7)
+	if len(upcoming) != 2 || upcoming[0].Item.Text != "Today" || upcoming[1].Item.Text != "Next week" {
+		t.Errorf("Unexpected upcoming items %+v", upcoming)
+	}
+}
@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Views that gather items from several lists (search results, the agenda,
+// overdue and today's items, standup) number the items they show. The
+// numbers are saved for the shell that showed them, so 'todo check 7' right
+// after a view acts on the view's seventh item, whichever list it is in.
+// They stop applying once a list is shown or the current list changes, or
+// after viewTTL.
+
+// viewTTL is how long a view's numbers keep applying
+const viewTTL = time.Hour
+
+// viewState is the last view shown, as saved in .todo/view.json
+type viewState struct {
+	// Session is the process id of the shell the view was shown in
+	Session int        `json:"session"`
+	Shown   time.Time  `json:"shown"`
+	Items   []viewItem `json:"items"`
+}
+
+// viewItem is where a numbered item of a view lives, and its text to tell
+// whether the list has changed since
+type viewItem struct {
+	List string `json:"list"`
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+func GetViewPath() string {
+	return filepath.Join(".todo", "view.json")
+}
+
+// viewSession identifies the shell running todo
+func viewSession() int {
+	return os.Getppid()
+}
+
+// SaveView numbers the items of an aggregated view in the order given, item
+// i being number i+1. The numbers are only a convenience, so callers needn't
+// treat failing to save them as an error.
+func SaveView(items []ListItem) error {
+	state := viewState{Session: viewSession(), Shown: time.Now()}
+	for _, entry := range items {
+		state.Items = append(state.Items, viewItem{List: entry.List, ID: entry.Item.ID, Text: entry.Item.Text})
+	}
+	content, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(GetViewPath(), content)
+}
+
+// ClearView stops the numbers of the last view from applying
+func ClearView() {
+	os.Remove(GetViewPath())
+}
+
+// loadView returns the last view shown in this shell, if its numbers still
+// apply
+func loadView() (*viewState, bool) {
+	content, err := os.ReadFile(GetViewPath())
+	if err != nil {
+		return nil, false
+	}
+	var state viewState
+	if json.Unmarshal(content, &state) != nil || state.Session != viewSession() || time.Since(state.Shown) > viewTTL {
+		return nil, false
+	}
+	return &state, true
+}
+
+// ResolveViewItemRef finds the item a reference typed by the user is about:
+// an item number of the last view shown in this shell, or otherwise any
+// reference ResolveItemRef takes to an item of currentList
+func ResolveViewItemRef(currentList, ref string) (string, int, error) {
+	number, err := strconv.Atoi(ref)
+	view, ok := loadView()
+	if err != nil || !ok {
+		itemID, err := ResolveItemRef(currentList, ref)
+		return currentList, itemID, err
+	}
+	if number < 1 || number > len(view.Items) {
+		return "", 0, fmt.Errorf("no item %d in the last view (it has %d)", number, len(view.Items))
+	}
+
+	want := view.Items[number-1]
+	todoList, err := ParseTodoFile(want.List)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to parse todo file: %w", err)
+	}
+	if want.ID <= len(todoList.Items) && todoList.Items[want.ID-1].Text == want.Text {
+		return want.List, want.ID, nil
+	}
+	// Items above it were added or removed since
+	found := 0
+	for _, item := range todoList.Items {
+		if item.Text == want.Text {
+			if found != 0 {
+				found = 0
+				break
+			}
+			found = item.ID
+		}
+	}
+	if found == 0 {
+		return "", 0, fmt.Errorf("item %d of the last view has changed since it was shown; show the view again", number)
+	}
+	return want.List, found, nil
+}
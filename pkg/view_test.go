@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestViewNumbers(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("work")
+	CreateTodoFile("home")
+	AddTodoItem("work", "Review the login page")
+	AddTodoItem("home", "Buy milk")
+	AddTodoItem("home", "Fix the login on the router")
+
+	// Without a view, numbers are items of the current list
+	if list, id, err := ResolveViewItemRef("home", "1"); err != nil || list != "home" || id != 1 {
+		t.Errorf("ResolveViewItemRef = %s, %d, %v", list, id, err)
+	}
+
+	home, _ := ParseTodoFile("home")
+	work, _ := ParseTodoFile("work")
+	if err := SaveView([]ListItem{{List: "home", Item: home.Items[1]}, {List: "work", Item: work.Items[0]}}); err != nil {
+		t.Fatalf("SaveView failed: %v", err)
+	}
+	if list, id, err := ResolveViewItemRef("home", "2"); err != nil || list != "work" || id != 1 {
+		t.Errorf("ResolveViewItemRef = %s, %d, %v", list, id, err)
+	}
+	if _, _, err := ResolveViewItemRef("home", "3"); err == nil || !strings.Contains(err.Error(), "no item 3 in the last view") {
+		t.Errorf("Expected an error past the end of the view, got %v", err)
+	}
+
+	// The item moved down when another was put above it
+	home.Items = append([]TodoItem{{Text: "Water plants"}}, home.Items...)
+	WriteTodoFile("home", home)
+	if list, id, err := ResolveViewItemRef("home", "1"); err != nil || list != "home" || id != 3 {
+		t.Errorf("Expected the moved item, got %s, %d, %v", list, id, err)
+	}
+	// The item is gone
+	home.Items = home.Items[:2]
+	WriteTodoFile("home", home)
+	if _, _, err := ResolveViewItemRef("home", "1"); err == nil || !strings.Contains(err.Error(), "has changed") {
+		t.Errorf("Expected an error for a changed item, got %v", err)
+	}
+
+	// Views shown in another shell, or long ago, don't apply
+	for _, state := range []viewState{
+		{Session: viewSession() + 1, Shown: time.Now(), Items: []viewItem{{List: "work", ID: 1, Text: "Review the login page"}}},
+		{Session: viewSession(), Shown: time.Now().Add(-2 * viewTTL), Items: []viewItem{{List: "work", ID: 1, Text: "Review the login page"}}},
+	} {
+		content, _ := json.Marshal(state)
+		os.WriteFile(GetViewPath(), content, 0644)
+		if list, _, _ := ResolveViewItemRef("home", "1"); list != "home" {
+			t.Errorf("Expected the view %+v not to apply", state)
+		}
+	}
+
+	SaveView([]ListItem{{List: "work", Item: work.Items[0]}})
+	SetCurrentList("work")
+	if _, err := os.Stat(GetViewPath()); !os.IsNotExist(err) {
+		t.Error("Expected switching lists to clear the view")
+	}
+}
@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Visibility values control whether .todo/ is shared through git
+const (
+	VisibilityLocal     = "local"
+	VisibilityCommitted = "committed"
+)
+
+// ValidateVisibility checks that a visibility setting is one we understand
+func ValidateVisibility(visibility string) error {
+	switch visibility {
+	case "", VisibilityLocal, VisibilityCommitted:
+		return nil
+	}
+	return fmt.Errorf("invalid visibility %q (expected %s or %s)", visibility, VisibilityLocal, VisibilityCommitted)
+}
+
+// ApplyVisibility updates .gitignore to match the visibility setting. Local
+// lists are ignored; committed lists are shared with the team, but the
+// .current-list selection, the count index, the activity time, the last
+// view's numbers, the list backups, the journal, the snapshots and the
+// daemon's reminders stay personal either way.
+func ApplyVisibility(visibility string) error {
+	if err := ValidateVisibility(visibility); err != nil {
+		return err
+	}
+
+	switch visibility {
+	case VisibilityLocal:
+		if err := AddToGitignore(".todo/"); err != nil {
+			return err
+		}
+	case VisibilityCommitted:
+		if err := RemoveFromGitignore(".todo/"); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range []string{GetIndexPath(), GetActivityPath(), GetViewPath(), GetWriteLockDir(), GetBackupDir(), GetJournalPath(), GetSnapshotsPath(), GetDaemonRemindersPath()} {
+		if err := AddToGitignore(filepath.ToSlash(path)); err != nil {
+			return err
+		}
+	}
+	return AddToGitignore(".current-list")
+}
+
+// VisibilityWarnings describes any disagreement between the configured
+// visibility and the state of the git repository
+func VisibilityWarnings(cfg *Config) []string {
+	if cfg.Visibility == "" || !GitEnabled(cfg) {
+		return nil
+	}
+
+	var warnings []string
+	switch cfg.Visibility {
+	case VisibilityLocal:
+		if tracked, err := TrackedFiles(".todo"); err == nil && len(tracked) > 0 {
+			warnings = append(warnings, fmt.Sprintf("visibility is 'local' but %d file(s) in .todo/ are tracked by git; run 'git rm -r --cached .todo' to stop sharing them", len(tracked)))
+		}
+		if !IsIgnored(".todo/") {
+			warnings = append(warnings, "visibility is 'local' but .todo/ is not gitignored; run 'todo init --yes --visibility local' to fix")
+		}
+	case VisibilityCommitted:
+		if IsIgnored(".todo/") {
+			warnings = append(warnings, "visibility is 'committed' but .todo/ is gitignored, so lists won't be shared; check your .gitignore")
+		}
+	}
+
+	return warnings
+}
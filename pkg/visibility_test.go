@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func setupGitTestDir(t *testing.T) string {
+	testDir := setupTestDir(t)
+
+	if err := exec.Command("git", "init", "-q").Run(); err != nil {
+		t.Skipf("git is not available: %v", err)
+	}
+	exec.Command("git", "config", "user.name", "Test User").Run()
+	exec.Command("git", "config", "user.email", "test@example.com").Run()
+
+	return testDir
+}
+
+func TestApplyVisibility(t *testing.T) {
+	setupGitTestDir(t)
+
+	if err := ApplyVisibility(VisibilityLocal); err != nil {
+		t.Fatalf("ApplyVisibility(local) failed: %v", err)
+	}
+	content, _ := os.ReadFile(".gitignore")
+	if !strings.Contains(string(content), ".todo/") || !strings.Contains(string(content), ".current-list") {
+		t.Errorf("Expected .todo/ and .current-list to be ignored, got %q", string(content))
+	}
+
+	if err := ApplyVisibility(VisibilityCommitted); err != nil {
+		t.Fatalf("ApplyVisibility(committed) failed: %v", err)
+	}
+	content, _ = os.ReadFile(".gitignore")
+	lines := strings.Split(string(content), "\n")
+	if slices.Contains(lines, ".todo/") {
+		t.Errorf("Expected .todo/ to be removed from .gitignore, got %q", string(content))
+	}
+	if !slices.Contains(lines, ".current-list") || !slices.Contains(lines, ".todo/index.json") || !slices.Contains(lines, ".todo/activity") || !slices.Contains(lines, ".todo/view.json") || !slices.Contains(lines, ".todo/locks") {
+		t.Errorf("Expected .current-list, the index and the activity time to stay ignored, got %q", string(content))
+	}
+	for _, path := range []string{".todo/journal.jsonl", ".todo/snapshots.json", ".todo/reminders.json"} {
+		if !slices.Contains(lines, path) {
+			t.Errorf("Expected %s to stay ignored, got %q", path, string(content))
+		}
+	}
+
+	if err := ApplyVisibility("public"); err == nil {
+		t.Error("ApplyVisibility should reject unknown values")
+	}
+}
+
+func TestVisibilityWarnings(t *testing.T) {
+	setupGitTestDir(t)
+
+	CreateTodoFile("main")
+	exec.Command("git", "add", ".todo").Run()
+
+	// Local lists that are tracked and not ignored disagree with the repo
+	warnings := VisibilityWarnings(&Config{Visibility: VisibilityLocal})
+	if len(warnings) != 2 {
+		t.Errorf("Expected 2 warnings for tracked, unignored local lists, got %v", warnings)
+	}
+
+	warnings = VisibilityWarnings(&Config{Visibility: VisibilityCommitted})
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings for committed lists, got %v", warnings)
+	}
+
+	ApplyVisibility(VisibilityLocal)
+	warnings = VisibilityWarnings(&Config{Visibility: VisibilityCommitted})
+	if len(warnings) != 1 {
+		t.Errorf("Expected 1 warning for ignored committed lists, got %v", warnings)
+	}
+}
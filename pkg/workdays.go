@@ -0,0 +1,326 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CalendarConfig sets which days are working days. Business-day offsets
+// such as +3bd count only working days, and recurring items that fall on a
+// day off move to the next working day. Holidays imported with 'todo
+// holidays import' are days off too.
+type CalendarConfig struct {
+	// WorkingDays names the days of the week people work (default monday
+	// to friday)
+	WorkingDays []string `yaml:"working_days,omitempty"`
+}
+
+// WorkCalendar knows which days are working days
+type WorkCalendar struct {
+	working  [7]bool
+	holidays map[string]string
+}
+
+func GetHolidaysPath() string {
+	return filepath.Join(".todo", "holidays.json")
+}
+
+// LoadWorkCalendar returns the configured working days and the imported
+// holidays
+func LoadWorkCalendar(cfg *Config) (*WorkCalendar, error) {
+	calendar := &WorkCalendar{}
+	if len(cfg.Calendar.WorkingDays) == 0 {
+		for day := time.Monday; day <= time.Friday; day++ {
+			calendar.working[day] = true
+		}
+	}
+	english, _ := lookupDateLocale("en")
+	parser := &naturalDateParser{locales: []DateLocale{english}}
+	for _, name := range cfg.Calendar.WorkingDays {
+		day, ok := parser.weekday(foldDateWord(name))
+		if !ok {
+			return nil, fmt.Errorf("invalid working day %q in calendar.working_days", name)
+		}
+		calendar.working[day] = true
+	}
+	if calendar.working == [7]bool{} {
+		return nil, fmt.Errorf("calendar.working_days needs at least one day")
+	}
+
+	holidays, err := LoadHolidays()
+	if err != nil {
+		return nil, err
+	}
+	calendar.holidays = holidays
+	return calendar, nil
+}
+
+// IsWorkday reports whether people work on a day
+func (c *WorkCalendar) IsWorkday(day time.Time) bool {
+	if _, ok := c.holidays[day.Format(DueDateFormat)]; ok {
+		return false
+	}
+	return c.working[day.Weekday()]
+}
+
+// NextWorkday returns day if it is a working day, or else the first working
+// day after it
+func (c *WorkCalendar) NextWorkday(day time.Time) time.Time {
+	for !c.IsWorkday(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// AddWorkdays returns the day n working days after day
+func (c *WorkCalendar) AddWorkdays(day time.Time, n int) time.Time {
+	for n > 0 {
+		day = day.AddDate(0, 0, 1)
+		if c.IsWorkday(day) {
+			n--
+		}
+	}
+	return day
+}
+
+// WorkdayFrom returns today if it is a working day, or else the next one
+func WorkdayFrom(now time.Time) (time.Time, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return time.Time{}, err
+	}
+	calendar, err := LoadWorkCalendar(cfg)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return calendar.NextWorkday(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)), nil
+}
+
+// Holiday is a day off imported from a calendar
+type Holiday struct {
+	Date time.Time
+	Name string
+}
+
+// LoadHolidays returns the imported holidays, keyed by date (YYYY-MM-DD)
+func LoadHolidays() (map[string]string, error) {
+	holidays := make(map[string]string)
+	content, err := os.ReadFile(GetHolidaysPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return holidays, nil
+		}
+		return nil, fmt.Errorf("failed to read holidays: %w", err)
+	}
+	if err := json.Unmarshal(content, &holidays); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", GetHolidaysPath(), err)
+	}
+	return holidays, nil
+}
+
+// UpcomingHolidays returns the holidays from today on, soonest first
+func UpcomingHolidays(now time.Time) ([]Holiday, error) {
+	holidays, err := LoadHolidays()
+	if err != nil {
+		return nil, err
+	}
+	today := now.Format(DueDateFormat)
+
+	var upcoming []Holiday
+	for date, name := range holidays {
+		if date < today {
+			continue
+		}
+		if parsed, err := time.ParseInLocation(DueDateFormat, date, time.Local); err == nil {
+			upcoming = append(upcoming, Holiday{Date: parsed, Name: name})
+		}
+	}
+	sort.Slice(upcoming, func(i, j int) bool {
+		return upcoming[i].Date.Before(upcoming[j].Date)
+	})
+	return upcoming, nil
+}
+
+// ClearHolidays forgets every imported holiday
+func ClearHolidays() error {
+	if err := os.Remove(GetHolidaysPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ImportHolidays adds the days of the events of an iCalendar file or URL to
+// the holidays, returning how many days were added. Yearly events are repeated
+// for the years up to holidayYears from now.
+func ImportHolidays(source string, now time.Time) (int, error) {
+	data, err := readCalendarSource(source)
+	if err != nil {
+		return 0, err
+	}
+
+	holidays, err := LoadHolidays()
+	if err != nil {
+		return 0, err
+	}
+	before := len(holidays)
+	maps.Copy(holidays, parseHolidayEvents(data, now.Year()+holidayYears))
+
+	content, err := json.MarshalIndent(holidays, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := EnsureTodoDirectory(); err != nil {
+		return 0, fmt.Errorf("failed to create .todo directory: %w", err)
+	}
+	if err := writeFileAtomic(GetHolidaysPath(), append(content, '\n')); err != nil {
+		return 0, fmt.Errorf("failed to write holidays: %w", err)
+	}
+	return len(holidays) - before, nil
+}
+
+// holidayYears is how many years ahead yearly holidays are repeated
+const holidayYears = 5
+
+func readCalendarSource(source string) (string, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return "", fmt.Errorf("failed to read calendar: %w", err)
+		}
+		return string(data), nil
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to download calendar: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download calendar: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to download calendar: %w", err)
+	}
+	return string(data), nil
+}
+
+var yearlyRule = regexp.MustCompile(`(?i)(?:^|;)FREQ=YEARLY(?:;|$)`)
+
+// parseHolidayEvents returns the days covered by the events of an
+// iCalendar object, keyed by date. Events last from DTSTART up to but not
+// including DTEND.
+func parseHolidayEvents(data string, untilYear int) map[string]string {
+	holidays := make(map[string]string)
+
+	var start, end, name, rule string
+	inEvent := false
+	for _, line := range icalLines(data) {
+		prop := parseICalProperty(line)
+		switch {
+		case prop.Name == "BEGIN" && strings.EqualFold(prop.Value, "VEVENT"):
+			inEvent = true
+			start, end, name, rule = "", "", "", ""
+		case prop.Name == "END" && strings.EqualFold(prop.Value, "VEVENT"):
+			inEvent = false
+			first, err := time.ParseInLocation(DueDateFormat, start, time.Local)
+			if err != nil {
+				continue
+			}
+			days := 1
+			if last, err := time.ParseInLocation(DueDateFormat, end, time.Local); err == nil && last.After(first) {
+				days = int(last.Sub(first).Hours()/24 + 0.5)
+			}
+			years := 0
+			if yearlyRule.MatchString(rule) {
+				years = untilYear - first.Year()
+			}
+			for year := 0; year <= years; year++ {
+				for day := 0; day < days; day++ {
+					holidays[first.AddDate(year, 0, day).Format(DueDateFormat)] = name
+				}
+			}
+		case !inEvent:
+		case prop.Name == "DTSTART":
+			start = parseICalDate(prop.Value)
+		case prop.Name == "DTEND":
+			end = parseICalDate(prop.Value)
+		case prop.Name == "SUMMARY":
+			name = unescapeICalText(prop.Value)
+		case prop.Name == "RRULE":
+			rule = prop.Value
+		}
+	}
+	return holidays
+}
+
+// Recurrence is how often a recurring item comes back
+type Recurrence struct {
+	N int
+	// Unit is d (days), w (weeks), m (months) or bd (working days)
+	Unit string
+}
+
+// metaEvery holds the recurrence of an item, such as 2w
+const metaEvery = "every"
+
+var recurrenceRegex = regexp.MustCompile(`^(\d*)\s*([a-z]+)$`)
+
+// recurrenceUnits maps the ways of writing a unit to the unit
+var recurrenceUnits = map[string]string{
+	"d": "d", "day": "d", "days": "d", "daily": "d",
+	"w": "w", "week": "w", "weeks": "w", "weekly": "w",
+	"m": "m", "month": "m", "months": "m", "monthly": "m",
+	"bd": "bd", "workday": "bd", "workdays": "bd", "weekday": "bd", "weekdays": "bd",
+}
+
+// ParseRecurrence reads how often an item repeats, such as 2w, month or
+// workday
+func ParseRecurrence(value string) (Recurrence, error) {
+	match := recurrenceRegex.FindStringSubmatch(strings.ToLower(strings.TrimSpace(value)))
+	if match == nil || recurrenceUnits[match[2]] == "" {
+		return Recurrence{}, fmt.Errorf("invalid recurrence %q (expected e.g. 1d, 2w, 1m, 1bd, weekly or workday)", value)
+	}
+	n := 1
+	if match[1] != "" {
+		n, _ = strconv.Atoi(match[1])
+	}
+	if n < 1 {
+		return Recurrence{}, fmt.Errorf("invalid recurrence %q (must repeat at least every 1)", value)
+	}
+	return Recurrence{N: n, Unit: recurrenceUnits[match[2]]}, nil
+}
+
+func (r Recurrence) String() string {
+	return strconv.Itoa(r.N) + r.Unit
+}
+
+// Next returns the first occurrence after from that is also after today,
+// moved to a working day
+func (r Recurrence) Next(from, today time.Time, calendar *WorkCalendar) time.Time {
+	next := from
+	for first := true; first || !next.After(today); first = false {
+		switch r.Unit {
+		case "d":
+			next = next.AddDate(0, 0, r.N)
+		case "w":
+			next = next.AddDate(0, 0, 7*r.N)
+		case "m":
+			next = next.AddDate(0, r.N, 0)
+		case "bd":
+			next = calendar.AddWorkdays(next, r.N)
+		}
+	}
+	return calendar.NextWorkday(next)
+}
@@ -0,0 +1,159 @@
+package pkg
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func day(value string) time.Time {
+	date, _ := time.ParseInLocation(DueDateFormat, value, time.Local)
+	return date
+}
+
+const holidayCalendar = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20240704\r\nDTEND;VALUE=DATE:20240706\r\nSUMMARY:Summer\\, break\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20201225\r\nRRULE:FREQ=YEARLY\r\nSUMMARY:Christmas\r\nEND:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseHolidayEvents(t *testing.T) {
+	holidays := parseHolidayEvents(holidayCalendar, 2025)
+
+	for date, want := range map[string]string{
+		"2024-07-04": "Summer, break",
+		"2024-07-05": "Summer, break",
+		"2020-12-25": "Christmas",
+		"2025-12-25": "Christmas",
+	} {
+		if holidays[date] != want {
+			t.Errorf("holidays[%s] = %q, want %q", date, holidays[date], want)
+		}
+	}
+	if _, ok := holidays["2024-07-06"]; ok {
+		t.Error("Expected DTEND to be left out")
+	}
+	if _, ok := holidays["2026-12-25"]; ok {
+		t.Error("Expected yearly holidays to stop at the last year")
+	}
+}
+
+func TestWorkCalendar(t *testing.T) {
+	setupTestDir(t)
+	EnsureTodoDirectory()
+	os.WriteFile("holidays.ics", []byte(holidayCalendar), 0644)
+	// A Wednesday
+	now := time.Date(2024, 7, 3, 15, 0, 0, 0, time.Local)
+
+	if added, err := ImportHolidays("holidays.ics", now); err != nil || added != 12 {
+		t.Fatalf("ImportHolidays = %d, %v", added, err)
+	}
+	if added, _ := ImportHolidays("holidays.ics", now); added != 0 {
+		t.Errorf("Expected importing twice to add nothing, got %d", added)
+	}
+	upcoming, _ := UpcomingHolidays(now)
+	if len(upcoming) != 8 || upcoming[0].Name != "Summer, break" {
+		t.Errorf("Unexpected upcoming holidays %+v", upcoming)
+	}
+
+	calendar, err := LoadWorkCalendar(&Config{})
+	if err != nil {
+		t.Fatalf("LoadWorkCalendar failed: %v", err)
+	}
+	// Thursday and Friday are holidays, then the weekend
+	if got := calendar.AddWorkdays(day("2024-07-03"), 1); !got.Equal(day("2024-07-08")) {
+		t.Errorf("AddWorkdays = %s", got.Format(DueDateFormat))
+	}
+	if got := calendar.NextWorkday(day("2024-07-04")); !got.Equal(day("2024-07-08")) {
+		t.Errorf("NextWorkday = %s", got.Format(DueDateFormat))
+	}
+
+	sundays, _ := LoadWorkCalendar(&Config{Calendar: CalendarConfig{WorkingDays: []string{"sunday", "Sat"}}})
+	if !sundays.IsWorkday(day("2024-07-07")) || sundays.IsWorkday(day("2024-07-08")) {
+		t.Error("Expected only the configured working days to be workdays")
+	}
+	if _, err := LoadWorkCalendar(&Config{Calendar: CalendarConfig{WorkingDays: []string{"someday"}}}); err == nil {
+		t.Error("Expected an error for an unknown working day")
+	}
+
+	parser, _ := NewDateParser(&Config{})
+	if got, _ := parser.ParseDate("+3bd", now); !got.Equal(day("2024-07-10")) {
+		t.Errorf("+3bd = %s", got.Format(DueDateFormat))
+	}
+
+	if err := ClearHolidays(); err != nil {
+		t.Fatalf("ClearHolidays failed: %v", err)
+	}
+	if holidays, _ := LoadHolidays(); len(holidays) != 0 {
+		t.Errorf("Expected no holidays after clearing, got %v", holidays)
+	}
+}
+
+func TestParseRecurrence(t *testing.T) {
+	tests := map[string]string{"2w": "2w", "weekly": "1w", "1 month": "1m", "Workday": "1bd", "3d": "3d"}
+	for input, want := range tests {
+		if every, err := ParseRecurrence(input); err != nil || every.String() != want {
+			t.Errorf("ParseRecurrence(%q) = %v, %v, want %s", input, every, err, want)
+		}
+	}
+	for _, input := range []string{"", "0d", "fortnight", "-1w"} {
+		if _, err := ParseRecurrence(input); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	calendar := &WorkCalendar{}
+	for weekday := time.Monday; weekday <= time.Friday; weekday++ {
+		calendar.working[weekday] = true
+	}
+	today := day("2024-07-03")
+
+	tests := []struct {
+		every, from, want string
+	}{
+		{"1w", "2024-07-01", "2024-07-08"},
+		// Missed occurrences are skipped
+		{"1d", "2024-06-20", "2024-07-04"},
+		// An item done early still moves on one interval
+		{"1w", "2024-07-10", "2024-07-17"},
+		// Saturday moves to Monday
+		{"1m", "2024-06-06", "2024-07-08"},
+		{"1bd", "2024-07-03", "2024-07-04"},
+	}
+	for _, test := range tests {
+		every, _ := ParseRecurrence(test.every)
+		if got := every.Next(day(test.from), today, calendar); !got.Equal(day(test.want)) {
+			t.Errorf("%s from %s = %s, want %s", test.every, test.from, got.Format(DueDateFormat), test.want)
+		}
+	}
+}
+
+func TestCheckRecurringItem(t *testing.T) {
+	setupTestDir(t)
+	CreateTodoFile("chores")
+
+	store := NewStore()
+	id, _ := store.AddItem("chores", "Water plants")
+	due := time.Now().AddDate(0, 0, 1)
+	store.SetDue("chores", id, &due)
+	store.SetRecurrence("chores", id, Recurrence{N: 2, Unit: "w"})
+	store.SetReminder("chores", id, &due)
+	store.AddItem("chores", "Vacuum")
+
+	if err := store.CheckItem("chores", id); err != nil {
+		t.Fatalf("CheckItem failed: %v", err)
+	}
+	// Checking it again doesn't add another
+	store.CheckItem("chores", id)
+	store.Flush()
+
+	todoList, _ := ParseTodoFile("chores")
+	if len(todoList.Items) != 3 {
+		t.Fatalf("Expected the next occurrence at the end, got %+v", todoList.Items)
+	}
+	next := todoList.Items[2]
+	if next.Text != "Water plants" || next.Completed || next.Metadata[metaEvery] != "2w" || next.Metadata[metaRemind] != "" || next.DueDate == nil || !next.DueDate.After(due) {
+		t.Errorf("Unexpected next occurrence %+v", next)
+	}
+}
@@ -0,0 +1,102 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// The workspace registry is a per-user list of project directories with
+// todo lists, so commands can look across every project instead of only the
+// current one.
+
+func workspacesPath() (string, error) {
+	dir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "workspaces.json"), nil
+}
+
+// LoadWorkspaces returns the registered workspace directories, sorted
+func LoadWorkspaces() ([]string, error) {
+	path, err := workspacesPath()
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read workspace registry: %w", err)
+	}
+
+	var workspaces []string
+	if err := json.Unmarshal(content, &workspaces); err != nil {
+		return nil, fmt.Errorf("failed to parse workspace registry: %w", err)
+	}
+	sort.Strings(workspaces)
+	return workspaces, nil
+}
+
+func saveWorkspaces(workspaces []string) error {
+	path, err := workspacesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	sort.Strings(workspaces)
+	content, err := json.MarshalIndent(workspaces, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0600)
+}
+
+// AddWorkspace registers a project directory and returns its absolute path.
+// The directory must contain a .todo directory.
+func AddWorkspace(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(filepath.Join(abs, ".todo")); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%s has no .todo directory (run 'todo init' there first)", abs)
+	}
+
+	workspaces, err := LoadWorkspaces()
+	if err != nil {
+		return "", err
+	}
+	for _, workspace := range workspaces {
+		if workspace == abs {
+			return abs, nil
+		}
+	}
+	return abs, saveWorkspaces(append(workspaces, abs))
+}
+
+// RemoveWorkspace unregisters a project directory
+func RemoveWorkspace(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	workspaces, err := LoadWorkspaces()
+	if err != nil {
+		return "", err
+	}
+	for i, workspace := range workspaces {
+		if workspace == abs {
+			return abs, saveWorkspaces(append(workspaces[:i], workspaces[i+1:]...))
+		}
+	}
+	return "", fmt.Errorf("%s is not a registered workspace", abs)
+}
@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkspaceRegistry(t *testing.T) {
+	root := setupTestDir(t)
+	t.Setenv("HOME", root)
+	t.Setenv("XDG_CONFIG_HOME", root)
+
+	project := filepath.Join(root, "project")
+	os.MkdirAll(filepath.Join(project, ".todo"), 0755)
+
+	if _, err := AddWorkspace(root); err == nil {
+		t.Error("Expected an error registering a directory without .todo")
+	}
+
+	path, err := AddWorkspace(project)
+	if err != nil {
+		t.Fatalf("AddWorkspace failed: %v", err)
+	}
+	if path != project {
+		t.Errorf("Expected %s, got %s", project, path)
+	}
+
+	// Registering the same directory twice keeps one entry
+	os.Chdir(project)
+	AddWorkspace(".")
+	workspaces, err := LoadWorkspaces()
+	if err != nil {
+		t.Fatalf("LoadWorkspaces failed: %v", err)
+	}
+	if len(workspaces) != 1 || workspaces[0] != project {
+		t.Errorf("Unexpected workspaces: %v", workspaces)
+	}
+
+	if _, err := RemoveWorkspace(project); err != nil {
+		t.Fatalf("RemoveWorkspace failed: %v", err)
+	}
+	if workspaces, _ = LoadWorkspaces(); len(workspaces) != 0 {
+		t.Errorf("Expected no workspaces, got %v", workspaces)
+	}
+	if _, err := RemoveWorkspace(project); err == nil {
+		t.Error("Expected an error removing an unregistered workspace")
+	}
+}
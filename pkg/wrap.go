@@ -0,0 +1,185 @@
+package pkg
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// Width is the number of terminal columns item text is fitted to, or 0 to
+// print items on one line whatever their length
+var Width int
+
+// Truncate cuts long items to a single line instead of wrapping them
+var Truncate bool
+
+// minTextWidth is the narrowest column worth wrapping into; below it items
+// are printed unwrapped
+const minTextWidth = 10
+
+// Characters that change how the character before them is drawn
+const (
+	zeroWidthJoiner = '\u200d'
+	emojiVariation  = '\ufe0f'
+)
+
+// displayWidth returns the number of terminal columns s takes up. Wide
+// characters such as CJK and most emoji take two columns and combining
+// marks take none.
+func displayWidth(s string) int {
+	total := 0
+	for s != "" {
+		size, columns := nextGlyph(s)
+		total += columns
+		s = s[size:]
+	}
+	return total
+}
+
+// DisplayWidth returns how many terminal columns s takes up
+func DisplayWidth(s string) int {
+	return displayWidth(s)
+}
+
+// PadRight pads s with spaces to fill the given number of terminal columns,
+// so columns line up whatever characters s contains
+func PadRight(s string, columns int) string {
+	if pad := columns - displayWidth(s); pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// nextGlyph returns the length in bytes of the first glyph of s and the
+// columns it takes up. A glyph is a character together with the combining
+// marks, variation selectors, skin tone modifiers and zero-width-joined
+// characters drawn with it, so an emoji sequence such as a family counts
+// once rather than once per person.
+func nextGlyph(s string) (size, columns int) {
+	r, size := utf8.DecodeRuneInString(s)
+	columns = runeWidth(r)
+	for size < len(s) {
+		next, n := utf8.DecodeRuneInString(s[size:])
+		switch {
+		case next == zeroWidthJoiner:
+			size += n
+			if size < len(s) {
+				_, joined := utf8.DecodeRuneInString(s[size:])
+				size += joined
+			}
+		case next == emojiVariation:
+			// Asks for the two-column emoji form of the character
+			size += n
+			columns = 2
+		case next >= 0x1f3fb && next <= 0x1f3ff:
+			// Skin tone modifiers recolour the emoji before them
+			size += n
+		case runeWidth(next) == 0:
+			size += n
+		default:
+			return size, columns
+		}
+	}
+	return size, columns
+}
+
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) || unicode.IsControl(r) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// fitText lays text out in a column of the given width after a prefix such
+// as "12. [ ] ", returning the lines to print. Continuation lines are
+// indented to line up under the start of the text.
+func fitText(prefix, text string) []string {
+	available := Width - displayWidth(prefix)
+	if Width <= 0 || available < minTextWidth || displayWidth(text) <= available {
+		return []string{prefix + text}
+	}
+
+	if Truncate {
+		return []string{prefix + truncateText(text, available)}
+	}
+
+	indent := strings.Repeat(" ", displayWidth(prefix))
+	lines := wrapText(text, available)
+	for i := range lines {
+		if i == 0 {
+			lines[i] = prefix + lines[i]
+		} else {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return lines
+}
+
+// truncateText cuts text to fit in the given number of columns, marking the
+// cut with an ellipsis
+func truncateText(text string, columns int) string {
+	end, used := 0, 0
+	for end < len(text) {
+		size, w := nextGlyph(text[end:])
+		if used+w > columns-1 {
+			break
+		}
+		end += size
+		used += w
+	}
+	return strings.TrimRight(text[:end], " ") + "…"
+}
+
+// wrapText breaks text into lines of at most the given number of columns,
+// between words where possible. Words longer than a line are split.
+func wrapText(text string, columns int) []string {
+	var lines []string
+	var line strings.Builder
+	used := 0
+
+	flush := func() {
+		lines = append(lines, line.String())
+		line.Reset()
+		used = 0
+	}
+
+	for _, word := range strings.Fields(text) {
+		w := displayWidth(word)
+		if used > 0 && used+1+w > columns {
+			flush()
+		}
+		if used > 0 {
+			line.WriteByte(' ')
+			used++
+		}
+
+		for w > columns-used {
+			// Split a word that can't fit on a line of its own
+			split := 0
+			for split < len(word) {
+				size, glyphWidth := nextGlyph(word[split:])
+				if used+glyphWidth > columns {
+					break
+				}
+				split += size
+				used += glyphWidth
+			}
+			line.WriteString(word[:split])
+			word = word[split:]
+			w = displayWidth(word)
+			flush()
+		}
+		line.WriteString(word)
+		used += w
+	}
+	if used > 0 || len(lines) == 0 {
+		flush()
+	}
+	return lines
+}
@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"hello", 5},
+		{"café", 4},
+		{"café", 4},
+		{"日本語", 6},
+		{"🎉 done", 7},
+		{"cafe\u0301", 4},
+		{"👍🏽 ok", 5},
+		{"👨\u200d👩\u200d👧 family", 9},
+		{"❤\ufe0f", 2},
+		{"🇯🇵", 2},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := displayWidth(tt.text); got != tt.want {
+			t.Errorf("displayWidth(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFitText(t *testing.T) {
+	defer func() { Width, Truncate = 0, false }()
+
+	tests := []struct {
+		name     string
+		width    int
+		truncate bool
+		text     string
+		want     []string
+	}{
+		{"no width", 0, false, "a long item that is not wrapped", []string{"1. [ ] a long item that is not wrapped"}},
+		{"fits", 40, false, "short item", []string{"1. [ ] short item"}},
+		{"wraps", 24, false, "write the release notes for the next version", []string{
+			"1. [ ] write the release",
+			"       notes for the",
+			"       next version",
+		}},
+		{"long word", 17, false, "see https://example.com/a/very/long/path", []string{
+			"1. [ ] see",
+			"       https://ex",
+			"       ample.com/",
+			"       a/very/lon",
+			"       g/path",
+		}},
+		{"wide characters", 17, false, "日本語のテキストを折り返す", []string{
+			"1. [ ] 日本語のテ",
+			"       キストを折",
+			"       り返す",
+		}},
+		{"truncate", 24, true, "write the release notes for the next version", []string{"1. [ ] write the releas…"}},
+		{"truncate wide", 18, true, "日本語のテキストを折り返す", []string{"1. [ ] 日本語のテ…"}},
+		{"truncate emoji", 18, true, "👨\u200d👩\u200d👧 family reunion", []string{"1. [ ] 👨\u200d👩\u200d👧 family…"}},
+		{"too narrow", 12, false, "write the release notes", []string{"1. [ ] write the release notes"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Width, Truncate = tt.width, tt.truncate
+			got := fitText("1. [ ] ", tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fitText() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			for _, line := range got {
+				if tt.width >= minTextWidth+7 && displayWidth(line) > tt.width {
+					t.Errorf("line %q is wider than %d columns", line, tt.width)
+				}
+			}
+		})
+	}
+}
+
+func TestPadRight(t *testing.T) {
+	tests := map[string]string{
+		"main":     "main    ",
+		"日本語":      "日本語  ",
+		"🎉 party":  "🎉 party",
+		"too long": "too long",
+	}
+	for text, want := range tests {
+		if got := PadRight(text, 8); got != want {
+			t.Errorf("PadRight(%q, 8) = %q, want %q", text, got, want)
+		}
+	}
+}
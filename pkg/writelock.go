@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Commands that write lists, such as a sync pulling changes or the daemon
+// checking an item from an editor, leave a marker in .todo/locks while they
+// do. The markers are advisory: nothing waits for them, but 'todo edit'
+// warns about them and merges whatever they wrote while the editor was
+// open. Each process has its own marker, so writers never block each other.
+
+// writeLockStale is how old a marker may get before it is ignored, in case
+// a process died without removing it and its id was reused
+const writeLockStale = time.Hour
+
+// WriteLock describes a process writing lists
+type WriteLock struct {
+	PID     int       `json:"pid"`
+	Command string    `json:"command"`
+	Started time.Time `json:"started"`
+}
+
+var (
+	writeLockMu    sync.Mutex
+	writeLockDepth int
+)
+
+func GetWriteLockDir() string {
+	return filepath.Join(".todo", "locks")
+}
+
+func writeLockPath(pid int) string {
+	return filepath.Join(GetWriteLockDir(), strconv.Itoa(pid)+".json")
+}
+
+// MarkWriting records that this process is writing lists until done is
+// called. Calls may nest; the marker goes once the outermost one is done.
+// Failing to leave a marker only loses the warning, so it isn't an error.
+func MarkWriting(command string) (done func()) {
+	writeLockMu.Lock()
+	defer writeLockMu.Unlock()
+
+	writeLockDepth++
+	if writeLockDepth == 1 {
+		content, _ := json.Marshal(WriteLock{PID: os.Getpid(), Command: command, Started: time.Now()})
+		if os.MkdirAll(GetWriteLockDir(), 0755) == nil {
+			os.WriteFile(writeLockPath(os.Getpid()), content, 0644)
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			writeLockMu.Lock()
+			defer writeLockMu.Unlock()
+
+			writeLockDepth--
+			if writeLockDepth == 0 {
+				os.Remove(writeLockPath(os.Getpid()))
+			}
+		})
+	}
+}
+
+// writerCommand names the command this process is running, for markers
+// left by code that doesn't know which command it serves
+func writerCommand() string {
+	for _, arg := range os.Args[1:] {
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// ActiveWriters returns the other processes writing lists right now, oldest
+// first. Markers left by processes that are gone are removed.
+func ActiveWriters() []WriteLock {
+	entries, err := os.ReadDir(GetWriteLockDir())
+	if err != nil {
+		return nil
+	}
+
+	var writers []WriteLock
+	for _, entry := range entries {
+		path := filepath.Join(GetWriteLockDir(), entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var lock WriteLock
+		if json.Unmarshal(content, &lock) != nil || !processAlive(lock.PID) || time.Since(lock.Started) > writeLockStale {
+			os.Remove(path)
+			continue
+		}
+		if lock.PID != os.Getpid() {
+			writers = append(writers, lock)
+		}
+	}
+	sort.Slice(writers, func(i, j int) bool { return writers[i].Started.Before(writers[j].Started) })
+	return writers
+}
+
+// String describes the writer for warnings
+func (l WriteLock) String() string {
+	return fmt.Sprintf("'todo %s' (pid %d)", l.Command, l.PID)
+}
+
+// processAlive reports whether a process is running. Where signals can't
+// tell, processes are taken to be gone.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,60 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMarkWriting(t *testing.T) {
+	setupTestDir(t)
+
+	outer := MarkWriting("sync")
+	inner := MarkWriting("sync")
+	inner()
+	inner()
+	if _, err := os.Stat(writeLockPath(os.Getpid())); err != nil {
+		t.Fatalf("Expected the marker to stay until the outer call is done: %v", err)
+	}
+	// A process's own marker isn't reported to it
+	if writers := ActiveWriters(); len(writers) != 0 {
+		t.Errorf("Expected no other writers, got %v", writers)
+	}
+	outer()
+	if _, err := os.Stat(writeLockPath(os.Getpid())); !os.IsNotExist(err) {
+		t.Errorf("Expected the marker to be removed, got %v", err)
+	}
+}
+
+func TestActiveWriters(t *testing.T) {
+	setupTestDir(t)
+	os.MkdirAll(GetWriteLockDir(), 0755)
+
+	write := func(lock WriteLock) string {
+		content, _ := json.Marshal(lock)
+		path := writeLockPath(lock.PID)
+		os.WriteFile(path, content, 0644)
+		return path
+	}
+	// The parent process is alive, while pid -1 is never a process
+	live := write(WriteLock{PID: os.Getppid(), Command: "daemon", Started: time.Now()})
+	dead := write(WriteLock{PID: -1, Command: "sync", Started: time.Now()})
+
+	writers := ActiveWriters()
+	if len(writers) != 1 || writers[0].Command != "daemon" || writers[0].String() != fmt.Sprintf("'todo daemon' (pid %d)", os.Getppid()) {
+		t.Fatalf("Expected the live writer, got %v", writers)
+	}
+	if _, err := os.Stat(dead); !os.IsNotExist(err) {
+		t.Error("Expected the marker of a dead process to be removed")
+	}
+
+	write(WriteLock{PID: os.Getppid(), Command: "daemon", Started: time.Now().Add(-2 * writeLockStale)})
+	if writers := ActiveWriters(); len(writers) != 0 {
+		t.Errorf("Expected a stale marker to be ignored, got %v", writers)
+	}
+	if _, err := os.Stat(live); !os.IsNotExist(err) {
+		t.Error("Expected the stale marker to be removed")
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var prioritizeCmd = &cobra.Command{
+	Use:   "prioritize [item-number|section.item|id] [p1|p2|p3|none]",
+	Short: "Set or clear an item's priority",
+	Long: `Set how urgent an item of the current list is, from p1 (most urgent) to p3,
+or clear it with none:
+
+  todo prioritize 3 p1
+  todo prioritize 3 none
+
+'todo progress --sort priority' shows the most urgent items first.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		priority := 0
+		if args[1] != "none" {
+			var err error
+			if priority, err = pkg.ParsePriority(args[1]); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		currentList, item, ok := currentItem(currentList, args[0])
+		if !ok {
+			return
+		}
+
+		store := pkg.NewStore()
+		if err := store.SetPriority(currentList, item.ID, priority); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if err := store.Flush(); err != nil {
+			fmt.Printf("Error setting priority: %v\n", err)
+			return
+		}
+		if priority == 0 {
+			fmt.Printf("Cleared the priority of '%s'\n", item.Text)
+		} else {
+			fmt.Printf("Set the priority of '%s' to %s\n", item.Text, pkg.FormatPriority(priority))
+		}
+	},
+}
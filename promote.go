@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote [item-number|section.item|id] --to github",
+	Short: "Create an issue from an item",
+	Long: `Create a GitHub issue from an item of the current list. The issue is
+titled with the item's text and its URL is recorded in the item's metadata.
+
+With --close-on-check, checking the item later also closes the issue.
+
+The repository is taken from the origin remote unless --repo is given. A
+GitHub token is read from TODO_GITHUB_TOKEN or 'todo auth login github'.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		to, _ := cmd.Flags().GetString("to")
+		if to == "" {
+			fmt.Println("Error: --to is required (e.g. --to github)")
+			return
+		}
+		if to != "github" {
+			fmt.Printf("Error: unsupported destination %q (expected github)\n", to)
+			return
+		}
+		closeOnCheck, _ := cmd.Flags().GetBool("close-on-check")
+
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		currentList, itemID, err := pkg.ResolveViewItemRef(currentList, args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		owner, name, ok := gitHubRepo(cmd)
+		if !ok {
+			return
+		}
+		client, err := pkg.NewGitHubClient()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		issueURL, err := pkg.PromoteToGitHub(client, owner, name, currentList, itemID, closeOnCheck)
+		if err != nil {
+			fmt.Printf("Failed to promote item: %v\n", err)
+			return
+		}
+		fmt.Printf("Promoted item %s to %s\n", args[0], issueURL)
+		if closeOnCheck {
+			pkg.Tip("The issue will be closed when the item is checked")
+		}
+	},
+}
+
+// closePromotedIssue closes the GitHub issue of an item that was promoted
+// with --close-on-check and returns its URL, or "" when there is none.
+// Failures are for the caller to report and don't undo the check.
+func closePromotedIssue(listName string, itemID int) (issueURL string, err error) {
+	todoList, err := pkg.ParseTodoFile(listName)
+	if err != nil || itemID < 1 || itemID > len(todoList.Items) {
+		return "", nil
+	}
+	issueURL = pkg.IssueToClose(todoList.Items[itemID-1])
+	if issueURL == "" {
+		return "", nil
+	}
+
+	client, err := pkg.NewGitHubClient()
+	if err == nil {
+		err = pkg.CloseGitHubIssue(client, issueURL)
+	}
+	return issueURL, err
+}
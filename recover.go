@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover <list-name>",
+	Short: "Rebuild a damaged list from its backup",
+	Long: `Rebuild a list whose file was damaged, for example cut short by a full disk
+or mixed up by two programs writing it at once.
+
+Every time todo writes a list it keeps a copy in .todo/backups and records
+its checksum in the journal. recover starts from that copy and brings back
+whatever can still be read of the damaged file: items checked or unchecked
+since, and items that were added. The damaged file is kept in .todo/backups
+as <list>.damaged.md.
+
+Lists are checked whenever they are read, and commands warn about damaged
+ones. Use --force to rebuild a list that doesn't look damaged.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		listName := pkg.ResolveListName(args[0])
+		force, _ := cmd.Flags().GetBool("force")
+		if pkg.TodoFileExists(listName) && !force {
+			problems, err := pkg.ListProblems(listName)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			if len(problems) == 0 {
+				fmt.Printf("List '%s' doesn't look damaged\n", listName)
+				pkg.Tip("Use --force to rebuild it from its backup anyway.")
+				return
+			}
+		}
+
+		recovery, err := pkg.RecoverList(listName)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("Recovered list '%s' with %d items\n", listName, recovery.Items)
+		if !recovery.Verified {
+			fmt.Println("Warning: the backup doesn't match the last write in the journal, so it may be out of date")
+		} else {
+			fmt.Printf("The backup was written %s\n", pkg.FormatTimestamp(recovery.Written.Local()))
+		}
+		if recovery.Updated > 0 {
+			fmt.Printf("Took the checked state of %d items from the damaged file\n", recovery.Updated)
+		}
+		if len(recovery.Salvaged) > 0 {
+			fmt.Println("Salvaged items found only in the damaged file:")
+			for _, text := range recovery.Salvaged {
+				fmt.Printf("  [ ] %s\n", text)
+			}
+		}
+		if recovery.DamagedPath != "" {
+			fmt.Printf("The damaged file was kept as %s\n", recovery.DamagedPath)
+		}
+	},
+}
+
+// printIntegrityWarnings warns about damaged lists the command read. They
+// go to stderr so they don't get mixed into output meant for scripts.
+func printIntegrityWarnings(cmd *cobra.Command) {
+	if cmd == recoverCmd {
+		return
+	}
+	for _, warning := range pkg.IntegrityWarnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/scttymn/todo-cli/pkg/notify"
+	"github.com/spf13/cobra"
+)
+
+var remindCmd = &cobra.Command{
+	Use:   "remind [item-number|section.item|id] (--in <duration> | --at <time>)",
+	Short: "Get a desktop notification about an item later",
+	Long: `Schedule a one-shot desktop notification about an item of the current list,
+after a while or at a given time, which needn't have anything to do with when
+the item is due:
+
+  todo remind 3 --in 2h
+  todo remind 1.2 --in 45m
+  todo remind 4 --at "2024-07-01 09:00"
+  todo remind 4 --at "friday 14:30"
+  todo remind 4 --at 17:00
+
+The time is kept with the item, and 'todo today' lists the reminders of the
+day. The reminder is handed to 'todo daemon' when one is running, or else to
+the OS scheduler (a systemd user timer or an at job on Linux, a launchd agent
+on macOS, a scheduled task on Windows), so nothing needs to keep running. If
+the item is completed before the reminder is due, it is skipped.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		in, _ := cmd.Flags().GetString("in")
+		atFlag, _ := cmd.Flags().GetString("at")
+		now := time.Now()
+		var at time.Time
+		switch {
+		case in != "" && atFlag != "":
+			fmt.Println("Error: use either --in or --at, not both")
+			return
+		case in != "":
+			delay, err := time.ParseDuration(in)
+			if err != nil || delay <= 0 {
+				fmt.Printf("Error: invalid duration '%s' (e.g. 2h, 30m, 1h30m)\n", in)
+				return
+			}
+			at = now.Add(delay)
+		case atFlag != "":
+			var err error
+			if at, err = pkg.ParseReminderTime(atFlag, now); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			if !at.After(now) {
+				fmt.Printf("Error: %s has already passed\n", at.Format("Mon 2006-01-02 15:04"))
+				return
+			}
+		default:
+			fmt.Println("Error: --in or --at is required (e.g. --in 2h or --at \"2024-07-01 09:00\")")
+			return
+		}
+
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		currentList, itemID, err := pkg.ResolveViewItemRef(currentList, args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		reminder, err := pkg.NewReminder(currentList, itemID, at)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		// A running daemon fires the reminder itself; otherwise the OS
+		// scheduler does
+		if err := pkg.AskDaemon(nil, "remind", reminder.Name, reminder.List, reminder.Text, reminder.At.Format(time.RFC3339)); err != nil {
+			if err := pkg.ScheduleReminder(*reminder, now); err != nil {
+				fmt.Printf("Error scheduling reminder: %v\n", err)
+				return
+			}
+		}
+
+		store := pkg.NewStore()
+		if err := store.SetReminder(currentList, itemID, &reminder.At); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if err := store.Flush(); err != nil {
+			fmt.Printf("Error saving reminder: %v\n", err)
+			return
+		}
+
+		when := reminder.At.Format("15:04")
+		if reminder.At.Format(pkg.DueDateFormat) != now.Format(pkg.DueDateFormat) {
+			when = reminder.At.Format("Mon 2006-01-02 15:04")
+		}
+		fmt.Printf("Will remind you about '%s' at %s\n", reminder.Text, when)
+	},
+}
+
+var remindFireCmd = &cobra.Command{
+	Use:    "fire <text>",
+	Short:  "Entry point for reminders scheduled by todo remind",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+		listName, _ := cmd.Flags().GetString("list")
+		name, _ := cmd.Flags().GetString("name")
+		defer pkg.RemoveReminder(name)
+
+		if err := os.Chdir(dir); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if !pkg.ReminderDue(listName, args[0]) {
+			return
+		}
+		if err := notify.Desktop("todo: "+listName, args[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	},
+}
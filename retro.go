@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var retroCmd = &cobra.Command{
+	Use:   "retro [list-name...]",
+	Short: "Summarize a sprint as markdown for a retrospective",
+	Long: `Summarize the last sprint across all lists, or only the lists named, as
+markdown to paste into retro notes: the items completed and added, the net
+change of the backlog, the longest-open items that were closed, and the items
+carried over into the next sprint.
+
+The sprint ends today and lasts --sprint days or weeks (default: 2w). Items
+record the day they are added; items from lists older than that count as
+added before any sprint.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		sprint, _ := cmd.Flags().GetString("sprint")
+		days, err := pkg.ParseSprint(sprint)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		var names []string
+		for _, name := range args {
+			listName := pkg.ResolveListName(name)
+			if !pkg.TodoFileExists(listName) {
+				fmt.Printf("Error: list '%s' does not exist\n", listName)
+				return
+			}
+			names = append(names, listName)
+		}
+		if len(names) == 0 {
+			if names, err = pkg.GetAllLists(); err != nil {
+				fmt.Printf("Error getting lists: %v\n", err)
+				return
+			}
+		}
+
+		retro, err := pkg.GetRetro(names, days, pkg.Now())
+		if err != nil {
+			fmt.Printf("Failed to build the retro: %v\n", err)
+			return
+		}
+		if jsonOutput(cmd) {
+			items, err := pkg.RetroJSON(retro)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			printJSON(items)
+			return
+		}
+		fmt.Print(pkg.FormatRetro(retro))
+	},
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <text> [list-name...]",
+	Short: "Find items containing some text",
+	Long: `Find items whose text contains <text>, ignoring case, across all lists or
+only the lists named. With --regex, <text> is a regular expression, such as
+'^fix (login|logout)'. --pending and --completed keep only the items in that
+state.
+
+With --format quickfix each match is printed as file:line: text, so Vim can
+jump straight to it in the todo file:
+
+  :cexpr system('todo search login --format quickfix')`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		format, ok := outputFormat(cmd)
+		if !ok {
+			return
+		}
+		if format != "text" && format != "quickfix" && format != "json" {
+			fmt.Printf("Error: unknown format '%s' (expected text, quickfix or json)\n", format)
+			return
+		}
+		version, ok := porcelain(cmd)
+		if !ok {
+			return
+		}
+
+		regex, _ := cmd.Flags().GetBool("regex")
+		query, err := pkg.NewSearchQuery(args[0], regex)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		query.Pending, _ = cmd.Flags().GetBool("pending")
+		query.Completed, _ = cmd.Flags().GetBool("completed")
+		if query.Pending && query.Completed {
+			fmt.Println("Error: --pending can't be combined with --completed")
+			return
+		}
+
+		var names []string
+		if len(args) > 1 {
+			for _, name := range args[1:] {
+				listName := pkg.ResolveListName(name)
+				if !pkg.TodoFileExists(listName) {
+					fmt.Printf("Error: list '%s' does not exist\n", listName)
+					return
+				}
+				names = append(names, listName)
+			}
+		} else {
+			if names, err = pkg.GetAllLists(); err != nil {
+				fmt.Printf("Error getting lists: %v\n", err)
+				return
+			}
+		}
+
+		ctx, stop := interruptible(cmd)
+		matches, err := pkg.SearchItems(ctx, names, query)
+		stop()
+		if printInterrupted(err) {
+			return
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		switch {
+		case version != "":
+			for _, match := range matches {
+				pkg.WritePorcelainItem(os.Stdout, match.List, match.Item)
+			}
+		case format == "quickfix":
+			for _, match := range matches {
+				pkg.WriteQuickfixItem(os.Stdout, match.List, match.Item)
+			}
+		case format == "json":
+			printListItems(matches)
+		case len(matches) == 0:
+			fmt.Printf("No items match '%s'.\n", args[0])
+		default:
+			for i, match := range matches {
+				status := "[ ]"
+				if match.Item.Completed {
+					status = "[x]"
+				}
+				fmt.Printf("  %d. %s %s [%s #%d]\n", i+1, status, match.Item.Text, match.List, match.Item.ID)
+			}
+			pkg.SaveView(matches)
+		}
+	},
+}
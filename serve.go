@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+// defaultServeAddr is where `todo serve` listens unless --addr is given
+const defaultServeAddr = "127.0.0.1:7420"
+
+// serveShutdownTimeout is how long `todo serve` waits for requests being
+// answered when it is stopped
+const serveShutdownTimeout = 5 * time.Second
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve read-only feeds of your todo lists over HTTP",
+	Long: `Start a local HTTP server with read-only feeds of your todo lists:
+
+  /agenda.ics   iCalendar feed of due-dated items, for calendar apps to
+                subscribe to (add ?completed=1 to include completed items)
+
+Lists are read on every request, so subscribed calendars pick up changes on
+their next refresh. The server listens on 127.0.0.1 by default; use --addr to
+change that.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		addr, _ := cmd.Flags().GetString("addr")
+		mux := http.NewServeMux()
+		mux.HandleFunc("/agenda.ics", serveAgendaFeed)
+
+		ctx, stop := interruptible(cmd)
+		defer stop()
+		server := &http.Server{Addr: addr, Handler: mux}
+		// Ctrl-C lets the requests being answered finish, for a few
+		// seconds at most, before the server stops
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+			defer cancel()
+			server.Shutdown(shutdownCtx)
+		}()
+
+		fmt.Printf("Serving todo feeds on http://%s\n", addr)
+		fmt.Printf("Calendar feed: %s\n", agendaFeedURL(addr))
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Println("Stopped serving")
+	},
+}
+
+func serveAgendaFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	agenda, err := pkg.GetAgenda(r.URL.Query().Get("completed") == "1")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	pkg.WriteICalendar(w, "todo: "+projectName(), agenda)
+}
+
+// agendaFeedURL is the URL calendar apps subscribe to for a server address
+func agendaFeedURL(addr string) string {
+	return fmt.Sprintf("http://%s/agenda.ics", addr)
+}
+
+// projectName names the feed after the directory being served
+func projectName() string {
+	if dir, err := os.Getwd(); err == nil {
+		return filepath.Base(dir)
+	}
+	return "todo"
+}
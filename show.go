@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show [item-number|section.item|id]",
+	Short: "Show everything known about an item",
+	Long:  `Show an item of the current list with its status, dates, section, anchor and any other metadata it carries, followed by its notes.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		currentList, item, ok := currentItem(currentList, args[0])
+		if !ok {
+			return
+		}
+		if jsonOutput(cmd) {
+			detail, err := pkg.NewItemDetail(currentList, item)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			printJSON(detail)
+			return
+		}
+
+		status := "pending"
+		if item.Completed {
+			status = "completed"
+			if item.CompletedTime != nil {
+				status += " " + pkg.FormatTimestamp(*item.CompletedTime)
+			}
+		}
+		fmt.Printf("%s\n\n", item.Text)
+		fmt.Printf("  List:      %s\n", currentList)
+		fmt.Printf("  Status:    %s\n", status)
+		if item.Section != "" {
+			fmt.Printf("  Section:   %s\n", item.Section)
+		}
+		if item.DueDate != nil {
+			fmt.Printf("  Due:       %s\n", item.DueDate.Format(pkg.DueDateFormat))
+		}
+		if item.Priority > 0 {
+			fmt.Printf("  Priority:  %s\n", pkg.FormatPriority(item.Priority))
+		}
+		if item.Estimate > 0 {
+			fmt.Printf("  Estimate:  %s\n", pkg.FormatEstimate(item.Estimate))
+		}
+		if item.Weight > 0 {
+			fmt.Printf("  Weight:    %d\n", item.Weight)
+		}
+		if item.ShortID != "" {
+			fmt.Printf("  ID:        %s\n", item.ShortID)
+		}
+		anchor, anchored := pkg.ItemAnchor(item)
+		if anchored {
+			fmt.Printf("  Anchor:    %s\n", anchor)
+		}
+
+		var keys []string
+		for key := range item.Metadata {
+			if key != "anchor" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("  %-10s %s\n", key+":", item.Metadata[key])
+		}
+		if len(item.Notes) > 0 {
+			fmt.Println()
+			for _, note := range item.Notes {
+				fmt.Printf("  %s\n", note)
+			}
+		}
+
+		if anchored {
+			pkg.Tip(fmt.Sprintf("\nOpen it with 'todo open %s'.", args[0]))
+		}
+	},
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var snoozeCmd = &cobra.Command{
+	Use:   "snooze [item-number|section.item|id] [when]",
+	Short: "Push an item's due date back",
+	Long: `Make an item of the current list due later, tomorrow unless told otherwise:
+
+  todo snooze 3
+  todo snooze 3 friday
+  todo snooze 3 next week
+  todo snooze 3 +3d
+
+Dates can be typed as YYYY-MM-DD, as an offset in days or weeks, or in words
+in English or the language set by dates.locale in .todo/config.yaml (by
+default the one in LANG), such as "próximo lunes" or "vendredi". Weeks
+start on dates.first_day_of_week (default monday).`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		currentList, item, ok := currentItem(currentList, args[0])
+		if !ok {
+			return
+		}
+
+		when := "tomorrow"
+		if len(args) > 1 {
+			when = strings.Join(args[1:], " ")
+		}
+		due, err := pkg.ParseDueDate(when, pkg.Now())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		store := pkg.NewStore()
+		if err := store.SetDue(currentList, item.ID, &due); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if err := store.Flush(); err != nil {
+			fmt.Printf("Error snoozing item: %v\n", err)
+			return
+		}
+		fmt.Printf("Snoozed '%s' until %s\n", item.Text, due.Format("Mon 2006-01-02"))
+	},
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var standupCmd = &cobra.Command{
+	Use:   "standup",
+	Short: "Show what was done yesterday and what is up today",
+	Long: `Show the items completed yesterday, and today's pending items: everything
+left in the current list plus anything due today or overdue in other lists.
+
+Use --all-workspaces to gather the standup of every project registered with
+'todo workspace add', grouped by project.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		now := pkg.Now()
+
+		if all, _ := cmd.Flags().GetBool("all-workspaces"); all {
+			standups, err := pkg.GetWorkspaceStandups(now)
+			if err != nil {
+				fmt.Printf("Failed to load workspaces: %v\n", err)
+				return
+			}
+			if jsonOutput(cmd) {
+				printWorkspaceStandups(standups)
+				return
+			}
+			if len(standups) == 0 {
+				fmt.Println("No workspaces registered. Run 'todo workspace add' in a project to add it.")
+				return
+			}
+
+			for i, result := range standups {
+				if i > 0 {
+					pkg.Blank()
+				}
+				fmt.Printf("%s%s\n", pkg.Emoji("📁"), result.Path)
+				if result.Err != nil {
+					fmt.Printf("  Error: %v\n", result.Err)
+					continue
+				}
+				printStandup(result.Standup, "  ", false)
+			}
+			return
+		}
+
+		if requiresInit() {
+			return
+		}
+		standup, err := pkg.GetStandup(now)
+		if err != nil {
+			fmt.Printf("Failed to load standup: %v\n", err)
+			return
+		}
+		if jsonOutput(cmd) {
+			items, err := pkg.StandupJSON(standup, true)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			printJSON(items)
+			return
+		}
+		printStandup(standup, "", true)
+	},
+}
+
+// printStandup prints the two halves of a standup, each line indented.
+// Numbered standups number their items as a view for item commands.
+func printStandup(standup *pkg.Standup, indent string, numbered bool) {
+	var shown []pkg.ListItem
+	number := func(entry pkg.ListItem) string {
+		if !numbered {
+			return ""
+		}
+		shown = append(shown, entry)
+		return fmt.Sprintf("%d. ", len(shown))
+	}
+
+	fmt.Printf("%sYesterday:\n", indent)
+	if len(standup.Done) == 0 {
+		fmt.Printf("%s  Nothing completed\n", indent)
+	}
+	for _, entry := range standup.Done {
+		fmt.Printf("%s  %s[x] %s [%s #%d]\n", indent, number(entry), entry.Item.Text, entry.List, entry.Item.ID)
+	}
+
+	fmt.Printf("%sToday:\n", indent)
+	if len(standup.Today) == 0 {
+		fmt.Printf("%s  Nothing pending\n", indent)
+	}
+	for _, entry := range standup.Today {
+		due := ""
+		if entry.Item.DueDate != nil {
+			due = fmt.Sprintf(" (due %s)", entry.Item.DueDate.Format(pkg.DueDateFormat))
+		}
+		fmt.Printf("%s  %s[ ] %s [%s #%d]%s\n", indent, number(entry), entry.Item.Text, entry.List, entry.Item.ID, due)
+	}
+
+	if numbered {
+		pkg.SaveView(shown)
+	}
+}
+
+// printWorkspaceStandups prints the standups of every workspace as JSON.
+// Their items are unlabelled, as the numbers belong to other projects' lists.
+func printWorkspaceStandups(standups []pkg.WorkspaceStandup) {
+	out := make([]pkg.JSONWorkspaceStandup, 0, len(standups))
+	for _, result := range standups {
+		entry := pkg.JSONWorkspaceStandup{Path: result.Path}
+		if result.Err != nil {
+			entry.Error = result.Err.Error()
+		} else {
+			entry.JSONStandup, _ = pkg.StandupJSON(result.Standup, false)
+		}
+		out = append(out, entry)
+	}
+	printJSON(out)
+}
@@ -0,0 +1,508 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Pull, push, or compare todo lists with a sync remote",
+	Long: `Synchronize todo lists with a remote copy:
+
+  todo sync                   Pull, then push
+  todo sync status            Show incoming and outgoing changes
+  todo sync pull [--dry-run]  Apply incoming changes to your lists
+  todo sync push [--dry-run]  Send your local changes to the remote
+
+Lists changed on both sides are reported as conflicts and left alone; add
+--force to pull or push to settle them in favour of that direction.
+
+When the remote can't be reached, pushed changes are queued in
+.todo/journal.jsonl and sent on the next successful sync.
+
+Use --provider to choose where lists are synced (default: git, which stores
+.todo on the sync_branch of the sync_remote from .todo/config.yaml, todo-data
+of origin unless set: an orphan branch that shares no history with your code
+and is never checked out, so your working branch is left alone). The
+linear provider syncs the lists mapped under linear.lists in the config with
+their Linear team and project: issues become items, pending items become
+issues, and titles and completion follow on both sides. The caldav provider
+does the same for lists mapped to a CalDAV task collection (Nextcloud Tasks,
+Fastmail, ...) with 'todo list <name> --caldav <url>', due dates included.
+The todoist provider syncs the lists bound to a Todoist project with
+'todo sync todoist --project <name>'.
+
+'todo sync issue <number>' syncs a list with the task list of a GitHub issue.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, provider := syncProvider(cmd)
+		if provider == nil {
+			return
+		}
+		ctx, stop := interruptible(cmd)
+		defer stop()
+		done := pkg.MarkWriting("sync")
+		defer done()
+
+		changes, err := provider.Pull(ctx, pkg.SyncOptions{})
+		if printInterrupted(err) {
+			return
+		}
+		if err != nil {
+			if errors.Is(err, pkg.ErrSyncOffline) {
+				queueOfflineChanges(name, provider, err)
+				return
+			}
+			fmt.Printf("Error pulling changes: %v\n", err)
+			return
+		}
+		reportSyncChanges(changes, false, "pulled", "pull")
+
+		changes, err = provider.Push(ctx, pkg.SyncOptions{})
+		if printInterrupted(err) {
+			return
+		}
+		if err != nil {
+			fmt.Printf("Error pushing changes: %v\n", err)
+			return
+		}
+		reportSyncChanges(changes, false, "pushed", "push")
+		flushSyncQueue(name)
+	},
+}
+
+var syncStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show incoming and outgoing changes",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, provider := syncProvider(cmd)
+		if provider == nil {
+			return
+		}
+
+		queued, err := pkg.QueuedSyncChanges(name)
+		if err != nil {
+			fmt.Printf("Error reading sync queue: %v\n", err)
+			return
+		}
+
+		ctx, stop := interruptible(cmd)
+		status, err := provider.Status(ctx)
+		stop()
+		if printInterrupted(err) {
+			return
+		}
+		if jsonOutput(cmd) && (err == nil || errors.Is(err, pkg.ErrSyncOffline)) {
+			printSyncStatus(provider.Name(), status, queued, err)
+			return
+		}
+		if errors.Is(err, pkg.ErrSyncOffline) {
+			fmt.Printf("Sync status for %s:\n", provider.Name())
+			pkg.Blank()
+			fmt.Printf("Offline: %v\n", err)
+			printSyncChanges(fmt.Sprintf("Queued while offline (%d)", len(queued)), queued)
+			return
+		}
+		if err != nil {
+			fmt.Printf("Error getting sync status: %v\n", err)
+			return
+		}
+
+		fmt.Printf("Sync status for %s:\n", status.Remote)
+		if len(queued) > 0 {
+			pkg.Blank()
+			fmt.Printf("%d change(s) queued while offline will be sent on the next sync.\n", len(queued))
+		}
+		if len(status.Incoming) == 0 && len(status.Outgoing) == 0 {
+			pkg.Blank()
+			fmt.Println("Everything is up to date.")
+			return
+		}
+		printSyncChanges("Incoming (todo sync pull)", status.Incoming)
+		printSyncChanges("Outgoing (todo sync push)", status.Outgoing)
+	},
+}
+
+var syncPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Apply incoming changes from the sync remote",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, provider := syncProvider(cmd)
+		if provider == nil {
+			return
+		}
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		force, _ := cmd.Flags().GetBool("force")
+		ctx, stop := interruptible(cmd)
+		defer stop()
+		if !dryRun {
+			done := pkg.MarkWriting("sync pull")
+			defer done()
+		}
+		changes, err := provider.Pull(ctx, pkg.SyncOptions{DryRun: dryRun, Force: force})
+		if printInterrupted(err) {
+			return
+		}
+		if err != nil {
+			fmt.Printf("Error pulling changes: %v\n", err)
+			return
+		}
+
+		reportSyncChanges(changes, dryRun, "pulled", "pull")
+
+		// A pull only reads the remote, so anything queued while offline
+		// waits for the next push
+		if queued, _ := pkg.QueuedSyncChanges(name); len(queued) > 0 {
+			pkg.Blank()
+			fmt.Printf("%d change(s) queued while offline will be sent by 'todo sync push'.\n", len(queued))
+		}
+	},
+}
+
+var syncPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Send local changes to the sync remote",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, provider := syncProvider(cmd)
+		if provider == nil {
+			return
+		}
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		force, _ := cmd.Flags().GetBool("force")
+		ctx, stop := interruptible(cmd)
+		defer stop()
+		changes, err := provider.Push(ctx, pkg.SyncOptions{DryRun: dryRun, Force: force})
+		if printInterrupted(err) {
+			return
+		}
+		if err != nil {
+			if errors.Is(err, pkg.ErrSyncOffline) && !dryRun {
+				queueOfflineChanges(name, provider, err)
+				return
+			}
+			fmt.Printf("Error pushing changes: %v\n", err)
+			return
+		}
+
+		reportSyncChanges(changes, dryRun, "pushed", "push")
+		if !dryRun {
+			flushSyncQueue(name)
+		}
+	},
+}
+
+var syncIssueCmd = &cobra.Command{
+	Use:   "issue <number>",
+	Short: "Sync a list with the task list of a GitHub issue",
+	Long: `Keep a list (default: the current list) and the task list in the body of a
+GitHub issue in step, in both directions. Boxes checked on GitHub are checked
+here, items checked here are checked on GitHub, and tasks added or removed
+on either side are added or removed on the other. Due dates travel as a
+"(due: YYYY-MM-DD)" suffix.
+
+Items are matched by their text, so an item renamed on one side shows up as
+one removed and one added. Items changed differently on both sides since the
+last sync are reported as conflicts and left alone. Only the task lines of
+the issue body are edited; the rest of it is kept as written.
+
+The repository is taken from the origin remote unless --repo is given. A
+GitHub token is read from TODO_GITHUB_TOKEN or 'todo auth login github'.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil || number < 1 {
+			fmt.Printf("Error: invalid issue number: %s\n", args[0])
+			return
+		}
+
+		listName, _ := cmd.Flags().GetString("list")
+		if listName == "" {
+			if listName, err = pkg.GetCurrentList(); err != nil {
+				fmt.Printf("Error getting current list: %v\n", err)
+				return
+			}
+		} else {
+			listName = pkg.ResolveListName(listName)
+		}
+		if !pkg.ListExists(listName) {
+			fmt.Printf("Error: list '%s' does not exist\n", listName)
+			return
+		}
+
+		owner, name, ok := gitHubRepo(cmd)
+		if !ok {
+			return
+		}
+
+		client, err := pkg.NewGitHubClient()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		result, err := pkg.SyncGitHubIssue(client, owner, name, number, listName, dryRun)
+		if err != nil {
+			fmt.Printf("Error syncing with %s/%s#%d: %v\n", owner, name, number, err)
+			return
+		}
+
+		fmt.Printf("Syncing list '%s' with %s\n", listName, result.URL)
+		if len(result.Incoming) == 0 && len(result.Outgoing) == 0 {
+			pkg.Blank()
+			fmt.Println("Everything is up to date.")
+			return
+		}
+		pulled, pushed := "Pulled", "Pushed"
+		if dryRun {
+			pulled, pushed = "Would pull", "Would push"
+		}
+		printSyncChanges(pulled, filterConflicts(result.Incoming, false))
+		printSyncChanges(pushed, filterConflicts(result.Outgoing, false))
+		if conflicts := filterConflicts(result.Incoming, true); len(conflicts) > 0 {
+			printSyncChanges("Conflicts", conflicts)
+			pkg.Blank()
+			fmt.Println("Conflicting items were left untouched.")
+			pkg.Tip("Make the item match on both sides, then run 'todo sync issue' again.")
+		}
+	},
+}
+
+var syncTodoistCmd = &cobra.Command{
+	Use:   "todoist",
+	Short: "Sync lists with Todoist projects",
+	Long: `Keep lists and Todoist projects in step, in both directions. Bind a list
+(default: the current list) to a project, by name or id, then sync:
+
+  todo sync todoist --project Groceries   Bind the current list and sync
+  todo sync todoist                       Sync every bound list
+  todo sync todoist --project none        Stop syncing the current list
+
+Pulling adds the project's open tasks to the list, and titles, due dates,
+completion and reopening follow from Todoist; pushing creates tasks for new
+pending items and sends the same changes the other way. An item checked
+here while its task changed in Todoist takes the change made last: Todoist's
+when the task was updated after the item was checked, yours otherwise. Other
+items changed on both sides are left as conflicts, which
+'todo sync pull --provider todoist --force' settles in Todoist's favour and
+'todo sync push --provider todoist --force' in yours.
+
+'todo sync --provider todoist' syncs the bound lists as well. A Todoist API
+token is read from TODO_TODOIST_TOKEN or 'todo auth login todoist'.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		if cmd.Flags().Changed("project") {
+			listName, _ := cmd.Flags().GetString("list")
+			if listName == "" {
+				var err error
+				if listName, err = pkg.GetCurrentList(); err != nil {
+					fmt.Printf("Error getting current list: %v\n", err)
+					return
+				}
+			} else {
+				listName = pkg.ResolveListName(listName)
+			}
+			if !pkg.ListExists(listName) {
+				fmt.Printf("Error: list '%s' does not exist\n", listName)
+				return
+			}
+
+			project, _ := cmd.Flags().GetString("project")
+			if strings.TrimSpace(project) == "" {
+				fmt.Println("Error: give the Todoist project to bind the list to, or 'none'")
+				return
+			}
+			if project == "none" {
+				if err := pkg.SetListTodoist(listName, ""); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					return
+				}
+				fmt.Printf("List '%s' is no longer synced with Todoist\n", listName)
+				return
+			}
+			if err := pkg.SetListTodoist(listName, project); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Printf("List '%s' now syncs with the Todoist project '%s'\n", listName, project)
+		}
+
+		provider, err := pkg.GetSyncProvider("todoist")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		ctx, stop := interruptible(cmd)
+		defer stop()
+		if !dryRun {
+			done := pkg.MarkWriting("sync todoist")
+			defer done()
+		}
+
+		changes, err := provider.Pull(ctx, pkg.SyncOptions{DryRun: dryRun})
+		if printInterrupted(err) {
+			return
+		}
+		if err != nil {
+			if errors.Is(err, pkg.ErrSyncOffline) && !dryRun {
+				queueOfflineChanges(provider.Name(), provider, err)
+				return
+			}
+			fmt.Printf("Error pulling changes: %v\n", err)
+			return
+		}
+		reportSyncChanges(changes, dryRun, "pulled", "pull")
+
+		changes, err = provider.Push(ctx, pkg.SyncOptions{DryRun: dryRun})
+		if printInterrupted(err) {
+			return
+		}
+		if err != nil {
+			fmt.Printf("Error pushing changes: %v\n", err)
+			return
+		}
+		reportSyncChanges(changes, dryRun, "pushed", "push")
+		if !dryRun {
+			flushSyncQueue(provider.Name())
+		}
+	},
+}
+
+// filterConflicts returns the conflicts among changes, or everything else
+func filterConflicts(changes []pkg.SyncChange, conflicts bool) []pkg.SyncChange {
+	var filtered []pkg.SyncChange
+	for _, change := range changes {
+		if (change.Action == pkg.SyncConflict) == conflicts {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
+
+// syncProvider returns the name and provider selected by --provider,
+// printing an error and returning a nil provider if it can't be used
+func syncProvider(cmd *cobra.Command) (string, pkg.SyncProvider) {
+	if requiresInit() {
+		return "", nil
+	}
+
+	name, _ := cmd.Flags().GetString("provider")
+	provider, err := pkg.GetSyncProvider(name)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return name, nil
+	}
+	return name, provider
+}
+
+// queueOfflineChanges records the local changes in the journal when the
+// remote can't be reached
+func queueOfflineChanges(name string, provider pkg.SyncProvider, offlineErr error) {
+	changes, err := provider.LocalChanges()
+	if err != nil {
+		fmt.Printf("Error: %v\n", offlineErr)
+		return
+	}
+	if err := pkg.QueueSyncChanges(name, changes); err != nil {
+		fmt.Printf("Error queueing changes: %v\n", err)
+		return
+	}
+
+	queued, _ := pkg.QueuedSyncChanges(name)
+	fmt.Printf("Offline: %v\n", offlineErr)
+	if len(queued) == 0 {
+		fmt.Println("Nothing to queue.")
+		return
+	}
+	printSyncChanges(fmt.Sprintf("Queued (%d)", len(queued)), queued)
+	pkg.Tip("\nQueued changes will be sent on the next successful 'todo sync'.")
+}
+
+// flushSyncQueue clears the offline queue after a successful push
+func flushSyncQueue(name string) {
+	queued, err := pkg.QueuedSyncChanges(name)
+	if err != nil || len(queued) == 0 {
+		return
+	}
+	if err := pkg.FlushSyncQueue(name); err != nil {
+		fmt.Printf("Warning: failed to clear the sync queue: %v\n", err)
+	}
+}
+
+// printSyncStatus prints the changes waiting in both directions as JSON.
+// Only the changes queued are known while the remote is offline.
+func printSyncStatus(name string, status *pkg.SyncStatus, queued []pkg.SyncChange, offline error) {
+	out := struct {
+		pkg.SyncStatus
+		Offline string           `json:"offline,omitempty"`
+		Queued  []pkg.SyncChange `json:"queued"`
+	}{Queued: queued}
+	out.Remote = name
+	if offline != nil {
+		out.Offline = offline.Error()
+	} else {
+		out.SyncStatus = *status
+	}
+	out.Incoming = append([]pkg.SyncChange{}, out.Incoming...)
+	out.Outgoing = append([]pkg.SyncChange{}, out.Outgoing...)
+	printJSON(out)
+}
+
+func printSyncChanges(heading string, changes []pkg.SyncChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	pkg.Blank()
+	fmt.Printf("%s:\n", heading)
+	for _, change := range changes {
+		line := fmt.Sprintf("  %-8s %s", change.Action, change.List)
+		if change.Detail != "" {
+			line += " (" + change.Detail + ")"
+		}
+		fmt.Println(line)
+	}
+}
+
+func reportSyncChanges(changes []pkg.SyncChange, dryRun bool, pastTense, verb string) {
+	if len(changes) == 0 {
+		fmt.Println("Nothing to " + verb + ".")
+		return
+	}
+
+	heading := strings.ToUpper(pastTense[:1]) + pastTense[1:]
+	if dryRun {
+		heading = "Would " + verb
+	}
+	printSyncChanges(heading, changes)
+
+	for _, change := range changes {
+		if change.Action == pkg.SyncConflict {
+			pkg.Blank()
+			fmt.Println("Conflicting lists were left untouched.")
+			pkg.Tip("Use 'todo sync pull --force' to take the remote version or 'todo sync push --force' to keep yours.")
+			break
+		}
+	}
+}
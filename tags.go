@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "List the #tags used across all lists with their item counts",
+	Long: `List every #tag written in item text across all lists, most used first,
+with how many items carry it and how many of those are pending.
+
+Show the items of one tag with 'todo progress --tag <tag>' or
+'todo history --tag <tag>'.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		names, err := pkg.GetAllLists()
+		if err != nil {
+			fmt.Printf("Error reading lists: %v\n", err)
+			return
+		}
+		lists := pkg.ParseLists(names)
+		for _, list := range lists {
+			if list.Err != nil {
+				fmt.Printf("Error reading list '%s': %v\n", list.Name, list.Err)
+				return
+			}
+		}
+
+		counts := pkg.CountTags(lists)
+		if jsonOutput(cmd) {
+			printJSON(counts)
+			return
+		}
+		if len(counts) == 0 {
+			fmt.Println("No tags found.")
+			pkg.Tip("Tag an item by writing #word in its text, e.g. todo add \"Fix login #backend\"")
+			return
+		}
+
+		width := 0
+		for _, count := range counts {
+			width = max(width, len(count.Tag)+1)
+		}
+		fmt.Println("Tags:")
+		for _, count := range counts {
+			fmt.Printf("  %s  %s\n", pkg.PadRight("#"+count.Tag, width), count.Summary())
+		}
+	},
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/scttymn/todo-cli/pkg/notify"
+	"github.com/spf13/cobra"
+)
+
+var tickCmd = &cobra.Command{
+	Use:   "tick",
+	Short: "Run periodic work such as notification rules",
+	Long: `Run the periodic work for this project once. Schedule it with cron or a
+systemd timer, e.g. every 15 minutes:
+
+  */15 * * * * cd /path/to/project && todo tick
+
+Each tick records the day's progress of every list for the sparklines of
+'todo list', then evaluates the notification rules in .todo/config.yaml and
+sends anything new. Rules look like:
+
+  notify:
+    channels:
+      team: {type: slack, url: https://hooks.slack.com/services/...}
+      me:   {type: email, to: me@example.com, smtp: smtp.example.com:587, username: me@example.com}
+    rules:
+      - {list: release, when: complete, notify: team}
+      - {list: ops, when: overdue, notify: me}
+
+Conditions are complete, overdue and "progress >= N". Secrets left out of a
+channel (a Slack URL, an SMTP password) are read from 'todo auth login <channel>'.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		cfg, err := pkg.LoadConfig()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		now := pkg.Now()
+		if err := pkg.RecordAllSnapshots(cfg, now); err != nil {
+			fmt.Printf("Warning: failed to record progress snapshots: %v\n", err)
+		}
+
+		eval, err := notify.Evaluate(cfg, now)
+		if err != nil {
+			fmt.Printf("Error evaluating notification rules: %v\n", err)
+			return
+		}
+
+		for _, n := range eval.Notifications {
+			fmt.Printf("%s%s: %s\n", pkg.Emoji("→"), n.Channel, n.Subject)
+		}
+
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			if len(eval.Notifications) > 0 {
+				pkg.Blank()
+				fmt.Println("Dry run: nothing was sent.")
+			} else {
+				fmt.Println("No new notifications.")
+			}
+			return
+		}
+
+		// Sending also records conditions that stopped holding, so send even
+		// when there is nothing new
+		sent, errs := eval.Send(cfg)
+		for _, err := range errs {
+			fmt.Printf("Error: %v\n", err)
+		}
+		if len(eval.Notifications) == 0 {
+			fmt.Println("No new notifications.")
+			return
+		}
+		pkg.Blank()
+		fmt.Printf("Sent %d of %d notification(s).\n", sent, len(eval.Notifications))
+	},
+}
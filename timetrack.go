@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var startCmd = &cobra.Command{
+	Use:   "start [item-number|section.item|id]",
+	Short: "Start tracking time against an item",
+	Long: `Start a timer on an item of the current list. Only one timer runs at a
+time: starting another stops the running one.
+
+Tracked time is recorded in .todo/journal.jsonl; see 'todo timesheet'.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+
+		currentList, itemID, err := pkg.ResolveViewItemRef(currentList, args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		todoList, err := pkg.ParseTodoFile(currentList)
+		if err != nil {
+			fmt.Printf("Error reading list: %v\n", err)
+			return
+		}
+		if itemID < 1 || itemID > len(todoList.Items) {
+			fmt.Printf("Error: invalid item number: %s\n", args[0])
+			return
+		}
+		item := todoList.Items[itemID-1]
+
+		now := pkg.Now()
+		stopped, err := pkg.StartTimer(currentList, item.Text, now)
+		if err != nil {
+			fmt.Printf("Error starting timer: %v\n", err)
+			return
+		}
+		if stopped != nil {
+			fmt.Printf("Stopped timer on '%s' in list '%s' after %s\n", stopped.Item, stopped.List, pkg.FormatDuration(stopped.Duration(now)))
+		}
+		fmt.Printf("Started timer on '%s' in list '%s'\n", item.Text, currentList)
+	},
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running timer",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		now := pkg.Now()
+		session, err := pkg.StopTimer(now)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Stopped timer on '%s' in list '%s' after %s\n", session.Item, session.List, pkg.FormatDuration(session.Duration(now)))
+	},
+}
+
+var timesheetCmd = &cobra.Command{
+	Use:   "timesheet",
+	Short: "Show tracked time per day and list",
+	Long: `Show the time tracked with 'todo start' and 'todo stop' for one week
+(Monday to Sunday), broken down per day and per list.
+
+  todo timesheet --week              This week
+  todo timesheet --week 2024-07-01   The week containing that date
+  todo timesheet --week --csv        The same as CSV (date,list,minutes,hours)`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		now := pkg.Now()
+		day := now
+		if week, _ := cmd.Flags().GetString("week"); week != "" && week != "this" {
+			parsed, err := time.ParseInLocation(pkg.DueDateFormat, week, time.Local)
+			if err != nil {
+				fmt.Printf("Error: invalid week %q (expected YYYY-MM-DD)\n", week)
+				return
+			}
+			day = parsed
+		}
+		from := pkg.WeekStart(day)
+		to := from.AddDate(0, 0, 7)
+
+		sessions, err := pkg.Sessions()
+		if err != nil {
+			fmt.Printf("Failed to read tracked time: %v\n", err)
+			return
+		}
+		rows := pkg.Timesheet(sessions, from, to, now)
+
+		if jsonOutput(cmd) {
+			type jsonRow struct {
+				Date    string `json:"date"`
+				List    string `json:"list"`
+				Minutes int    `json:"minutes"`
+			}
+			out := make([]jsonRow, 0, len(rows))
+			for _, row := range rows {
+				out = append(out, jsonRow{row.Day.Format(pkg.DueDateFormat), row.List, int(row.Duration.Minutes())})
+			}
+			printJSON(out)
+			return
+		}
+		if asCSV, _ := cmd.Flags().GetBool("csv"); asCSV {
+			if err := pkg.WriteTimesheetCSV(os.Stdout, rows); err != nil {
+				fmt.Printf("Error writing CSV: %v\n", err)
+			}
+			return
+		}
+
+		fmt.Printf("Timesheet for the week of %s:\n", from.Format(pkg.DueDateFormat))
+		if len(rows) == 0 {
+			pkg.Blank()
+			fmt.Println("No time tracked.")
+			return
+		}
+
+		var total time.Duration
+		var currentDay time.Time
+		for _, row := range rows {
+			if !row.Day.Equal(currentDay) {
+				pkg.Blank()
+				fmt.Printf("  %s\n", row.Day.Format("Mon 2006-01-02"))
+				currentDay = row.Day
+			}
+			fmt.Printf("    %s %s\n", pkg.PadRight(row.List, 20), pkg.FormatDuration(row.Duration))
+			total += row.Duration
+		}
+		pkg.Blank()
+		fmt.Printf("Total: %s\n", pkg.FormatDuration(total))
+	},
+}
+
+// checkIdleTimer asks what to do with the idle time of a timer that has run
+// without any commands for longer than the idle threshold, then records this
+// command as activity. Only commands run from a terminal count, so scripts
+// and git hooks calling todo don't keep a forgotten timer looking busy.
+func checkIdleTimer(cmd *cobra.Command) {
+	// git runs mergetool in the middle of a merge, like the hooks
+	if cmd.Hidden || cmd == mergetoolCmd || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return
+	}
+
+	now := pkg.Now()
+	cfg, err := pkg.LoadConfig()
+	if err != nil {
+		return
+	}
+	threshold, _ := cfg.IdleTimeout()
+
+	session, idleSince, err := pkg.IdleTimer(now, threshold)
+	if err == nil && session != nil {
+		idle := pkg.FormatDuration(now.Sub(idleSince))
+		fmt.Printf("The timer on '%s' in list '%s' has been idle since %s (%s).\n", session.Item, session.List, idleSince.Format("Mon 15:04"), idle)
+
+		choice := promptIdleChoice(bufio.NewReader(os.Stdin))
+		if err := pkg.SettleIdle(choice, session, idleSince, now); err != nil {
+			fmt.Printf("Error updating timer: %v\n", err)
+		} else {
+			switch choice {
+			case pkg.IdleKeep:
+				fmt.Printf("Kept %s of idle time\n", idle)
+			case pkg.IdleTrim:
+				fmt.Printf("Trimmed %s of idle time; the timer is still running\n", idle)
+			case pkg.IdleDiscard:
+				fmt.Printf("Stopped the timer at %s\n", idleSince.Format("Mon 15:04"))
+			}
+		}
+		fmt.Println()
+	}
+
+	pkg.RecordActivity(now)
+}
+
+// promptIdleChoice asks whether to keep, trim or discard an idle period
+func promptIdleChoice(reader *bufio.Reader) string {
+	for {
+		response := strings.ToLower(prompt(reader, "[k]eep the idle time, [t]rim it and keep the timer running, or [d]iscard it and stop the timer?", "k"))
+		switch response {
+		case "k", pkg.IdleKeep:
+			return pkg.IdleKeep
+		case "t", pkg.IdleTrim:
+			return pkg.IdleTrim
+		case "d", pkg.IdleDiscard:
+			return pkg.IdleDiscard
+		}
+		fmt.Println("Please answer k, t or d.")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var todayCmd = &cobra.Command{
+	Use:   "today",
+	Short: "Show the reminders and due items of today",
+	Long: `Show what needs attention today across all lists: the reminders set for
+today with 'todo remind --at' or '--in', then the pending items that are
+overdue, then the ones due today.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		now := pkg.Now()
+		today, err := pkg.GetToday(now)
+		if err != nil {
+			fmt.Printf("Failed to load today's items: %v\n", err)
+			return
+		}
+		if jsonOutput(cmd) {
+			items, err := pkg.TodayJSON(today)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			printJSON(items)
+			return
+		}
+
+		fmt.Printf("%sToday, %s\n", pkg.Emoji("📅"), now.Format("Mon 2006-01-02"))
+		if today.IsEmpty() {
+			pkg.Blank()
+			fmt.Println("Nothing needs attention today.")
+			return
+		}
+
+		var shown []pkg.ListItem
+		if len(today.Reminders) > 0 {
+			pkg.Blank()
+			fmt.Println("Reminders:")
+			for _, entry := range today.Reminders {
+				at, _ := pkg.ItemReminder(entry.Item)
+				shown = append(shown, entry)
+				fmt.Printf("  %d. %s [ ] %s [%s #%d]\n", len(shown), at.Format("15:04"), entry.Item.Text, entry.List, entry.Item.ID)
+			}
+		}
+		if len(today.Overdue) > 0 {
+			pkg.Blank()
+			fmt.Println("Overdue:")
+			for _, entry := range today.Overdue {
+				shown = append(shown, entry)
+				fmt.Printf("  %d. [ ] %s [%s #%d] (due %s)\n", len(shown), entry.Item.Text, entry.List, entry.Item.ID, entry.Item.DueDate.Format(pkg.DueDateFormat))
+			}
+		}
+		if len(today.Due) > 0 {
+			pkg.Blank()
+			fmt.Println("Due today:")
+			for _, entry := range today.Due {
+				shown = append(shown, entry)
+				fmt.Printf("  %d. [ ] %s [%s #%d]\n", len(shown), entry.Item.Text, entry.List, entry.Item.ID)
+			}
+		}
+		pkg.SaveView(shown)
+	},
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// tourStep is one command the tour shows and offers to run
+type tourStep struct {
+	explain string
+	args    []string
+}
+
+var tourSteps = []tourStep{
+	{"Lists are markdown files in .todo. Switching to a list shows its items, grouped by section:", []string{"list", pkg.SampleListName}},
+	{"Add an item to the current list. #tags and @contexts can go anywhere in the text:", []string{"add", "Try the tour #example @desk"}},
+	{"Check an item off by its number; 'todo uncheck' undoes it:", []string{"check", "2"}},
+	{"Search every list for text or a tag, and check items off by their number in the results:", []string{"search", "#words"}},
+	{"See what is due today and overdue across all lists:", []string{"today"}},
+	{"Give an item a priority and a due date when adding it:", []string{"add", "Read the README", "--priority", "p2", "--due", "friday"}},
+	{"Show how far along the list is:", []string{"progress"}},
+}
+
+var tourCmd = &cobra.Command{
+	Use:   "tour",
+	Short: "Walk through the core commands on a sample list",
+	Long: `Walk through the core commands one at a time, running each on the sample
+'getting-started' list, which is created if it doesn't exist. Press enter to
+run a step, s to skip it or q to stop. The list you were on is made current
+again at the end.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		previous, _ := pkg.GetCurrentList()
+		if !pkg.TodoFileExists(pkg.SampleListName) {
+			if err := pkg.CreateSampleList(pkg.Now()); err != nil {
+				fmt.Printf("Error creating the sample list: %v\n", err)
+				return
+			}
+			fmt.Printf("Created the sample list '%s'\n", pkg.SampleListName)
+		}
+
+		reader := bufio.NewReader(os.Stdin)
+		fmt.Printf("%sWelcome to the tour! There are %d steps.\n", pkg.Emoji("👋"), len(tourSteps))
+	steps:
+		for i, step := range tourSteps {
+			pkg.Blank()
+			fmt.Printf("Step %d of %d. %s\n", i+1, len(tourSteps), step.explain)
+			fmt.Printf("  $ todo %s\n", shellQuote(step.args))
+
+			switch strings.ToLower(prompt(reader, "Press enter to run it, s to skip or q to quit", "")) {
+			case "q", "quit":
+				break steps
+			case "s", "skip":
+				continue
+			}
+			run := exec.Command(executable, step.args...)
+			run.Stdin, run.Stdout, run.Stderr = os.Stdin, os.Stdout, os.Stderr
+			run.Run()
+		}
+
+		pkg.Blank()
+		if previous != pkg.SampleListName && pkg.TodoFileExists(previous) {
+			if err := pkg.SetCurrentList(previous); err == nil {
+				fmt.Printf("Switched back to list '%s'\n", previous)
+			}
+		}
+		fmt.Println("That's the tour. 'todo --help' lists every command, and 'todo edit' opens a list in your editor.")
+		pkg.Tip("Delete the sample list with 'todo list --delete %s'", pkg.SampleListName)
+	},
+}
+
+// shellQuote joins arguments as they would be typed in a shell
+func shellQuote(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " #@'\"") {
+			arg = `"` + arg + `"`
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// offerSampleList offers the sample list on the first command run in a
+// directory, when someone is at the terminal to answer
+func offerSampleList(cmd *cobra.Command) {
+	switch cmd.Name() {
+	case "init", "tour", "help", "completion", "version", "mergetool":
+		return
+	}
+	if cmd.Hidden || !cmd.Runnable() || !pkg.IsFirstRun() || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return
+	}
+
+	fmt.Printf("%sWelcome to todo! This is the first time it has been used here.\n", pkg.Emoji("👋"))
+	if !promptYesNo(bufio.NewReader(os.Stdin), fmt.Sprintf("Create a sample '%s' list showing tags, priorities, subtasks and due dates?", pkg.SampleListName), true) {
+		fmt.Println()
+		return
+	}
+	if err := pkg.CreateSampleList(pkg.Now()); err != nil {
+		fmt.Printf("Error creating the sample list: %v\n", err)
+		return
+	}
+	fmt.Printf("Created list '%s'. Run 'todo tour' for a walkthrough of the core commands.\n\n", pkg.SampleListName)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var triageCmd = &cobra.Command{
+	Use:   "triage [list]",
+	Short: "Step through pending items one key at a time",
+	Long: `Go through the pending items of a list (default: the current list) one at
+a time and decide what to do with each with a single key:
+
+  d  done          mark the item completed
+  x  delete        remove the item
+  s  snooze        make the item due tomorrow
+  m  move          move the item to another list
+  t  tag           add a #tag to the item
+  space  skip      leave the item as it is
+  q  quit          stop here and save
+  Ctrl-C  abort    stop without changing anything
+
+Nothing is written until the end, when all changes are saved together.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		listName, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		if len(args) == 1 {
+			listName = pkg.ResolveListName(args[0])
+		}
+		if !pkg.TodoFileExists(listName) {
+			fmt.Printf("Error: list '%s' does not exist\n", listName)
+			return
+		}
+
+		todoList, err := pkg.ParseTodoFile(listName)
+		if err != nil {
+			fmt.Printf("Error reading list: %v\n", err)
+			return
+		}
+		var pending []pkg.TodoItem
+		for _, item := range todoList.Items {
+			if !item.Completed {
+				pending = append(pending, item)
+			}
+		}
+		if len(pending) == 0 {
+			fmt.Printf("No pending items in list '%s'\n", listName)
+			return
+		}
+
+		fmt.Printf("Triage list '%s': %d pending item(s)\n", listName, len(pending))
+		pkg.Tip("[d]one  [x] delete  [s]nooze  [m]ove  [t]ag  [space] skip  [q]uit and save  [Ctrl-C] abort")
+
+		reader := bufio.NewReader(os.Stdin)
+		decisions := make(map[int]pkg.TriageDecision)
+	items:
+		for i, item := range pending {
+			fmt.Printf("\n(%d/%d) %s\n", i+1, len(pending), item.Text)
+
+			for {
+				fmt.Print("> ")
+				key, err := readKey(reader)
+				if key == 3 {
+					fmt.Println("\nTriage aborted; nothing was changed.")
+					return
+				}
+				if err != nil || key == 'q' {
+					fmt.Println("quit")
+					break items
+				}
+
+				decision, ok := triageDecision(reader, key, listName)
+				if !ok {
+					continue
+				}
+				if decision.Action != pkg.TriageSkip {
+					decisions[item.ID] = decision
+				}
+				break
+			}
+		}
+
+		if len(decisions) == 0 {
+			fmt.Println("\nNo changes.")
+			return
+		}
+		if !confirm(reader, pkg.ConfirmTriage, fmt.Sprintf("Save changes (%s)?", triageSummary(decisions))) {
+			fmt.Println("Triage cancelled; nothing was changed.")
+			return
+		}
+		if err := pkg.ApplyTriage(listName, decisions, pkg.Now()); err != nil {
+			fmt.Printf("Error saving triage: %v\n", err)
+			return
+		}
+		fmt.Printf("\nSaved: %s\n", triageSummary(decisions))
+	},
+}
+
+// triageDecision turns a key into a decision, asking for the list or tag
+// when needed. It reports false when the key should be asked for again.
+func triageDecision(reader *bufio.Reader, key byte, listName string) (pkg.TriageDecision, bool) {
+	switch key {
+	case 'd':
+		fmt.Println("done")
+		return pkg.TriageDecision{Action: pkg.TriageDone}, true
+	case 'x':
+		fmt.Println("delete")
+		return pkg.TriageDecision{Action: pkg.TriageDelete}, true
+	case 's':
+		fmt.Println("snooze until tomorrow")
+		return pkg.TriageDecision{Action: pkg.TriageSnooze}, true
+	case ' ', '\r', '\n':
+		fmt.Println("skip")
+		return pkg.TriageDecision{Action: pkg.TriageSkip}, true
+	case 'm':
+		fmt.Println("move")
+		target := pkg.ResolveListName(prompt(reader, "Move to list", ""))
+		if target == "" || target == listName || !pkg.TodoFileExists(target) {
+			fmt.Printf("No other list named '%s'\n", target)
+			return pkg.TriageDecision{}, false
+		}
+		return pkg.TriageDecision{Action: pkg.TriageMove, Target: target}, true
+	case 't':
+		fmt.Println("tag")
+		tag := strings.TrimPrefix(prompt(reader, "Tag", ""), "#")
+		if !pkg.ValidTagName(tag) {
+			fmt.Printf("Invalid tag '%s'\n", tag)
+			return pkg.TriageDecision{}, false
+		}
+		return pkg.TriageDecision{Action: pkg.TriageTag, Target: tag}, true
+	}
+
+	fmt.Println("Please press d, x, s, m, t, space or q.")
+	return pkg.TriageDecision{}, false
+}
+
+// readKey reads a single key press from a terminal, or the first character
+// of a line when input is piped. An empty line counts as a space.
+func readKey(reader *bufio.Reader) (byte, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		if state, err := term.MakeRaw(fd); err == nil {
+			defer term.Restore(fd, state)
+			return reader.ReadByte()
+		}
+	}
+
+	line, err := reader.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line == "" {
+		if err != nil {
+			return 0, io.EOF
+		}
+		return ' ', nil
+	}
+	return line[0], nil
+}
+
+// triageSummary describes how many items each action was applied to
+func triageSummary(decisions map[int]pkg.TriageDecision) string {
+	counts := make(map[string]int)
+	for _, decision := range decisions {
+		counts[decision.Action]++
+	}
+
+	var parts []string
+	for _, action := range []struct{ name, label string }{
+		{pkg.TriageDone, "done"},
+		{pkg.TriageDelete, "deleted"},
+		{pkg.TriageSnooze, "snoozed"},
+		{pkg.TriageMove, "moved"},
+		{pkg.TriageTag, "tagged"},
+	} {
+		if counts[action.name] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[action.name], action.label))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
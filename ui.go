@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Browse and edit lists in a full-screen terminal UI",
+	Long: `Open a full-screen view of your lists: the lists in a sidebar, the items
+of the selected one beside them. Items are picked with the arrow keys rather
+than by number:
+
+  ↑/↓ or j/k   select an item, or a list in the sidebar
+  tab or ←/→   switch between the sidebar and the items
+  space        check or uncheck the item
+  a            add an item to the end of the list
+  A            add a subtask under the item
+  m            move the item to another list
+  d            delete the item, after asking
+  q            quit
+
+Every change is written to the list file straight away.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if requiresInit() {
+			return
+		}
+
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+			fmt.Println("Error: 'todo ui' needs a terminal")
+			return
+		}
+
+		currentList, err := pkg.GetCurrentList()
+		if err != nil {
+			fmt.Printf("Error getting current list: %v\n", err)
+			return
+		}
+		ui, err := pkg.NewUI(currentList)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		defer term.Restore(fd, state)
+
+		// Draw on the alternate screen, so the terminal comes back as it
+		// was on the way out
+		fmt.Print("\033[?1049h\033[?25l")
+		defer fmt.Print("\033[?25h\033[?1049l")
+
+		reader := bufio.NewReader(os.Stdin)
+		for {
+			width, height, err := term.GetSize(int(os.Stdout.Fd()))
+			if err != nil || width <= 0 || height <= 0 {
+				width, height = 80, 24
+			}
+			// Raw mode doesn't turn \n into \r\n
+			fmt.Print("\033[H" + strings.Join(ui.View(width, height), "\r\n"))
+
+			key, err := pkg.ReadUIKey(reader)
+			if err != nil || ui.HandleKey(key) {
+				return
+			}
+		}
+	},
+}
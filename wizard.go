@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scttymn/todo-cli/pkg"
+)
+
+// prompt asks a question and returns the answer, or defaultValue when the
+// answer is empty or stdin is exhausted
+func prompt(reader *bufio.Reader, question, defaultValue string) string {
+	if defaultValue != "" {
+		fmt.Printf("%s [%s]: ", question, defaultValue)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+
+	response, err := reader.ReadString('\n')
+	response = strings.TrimSpace(response)
+	if err != nil && response == "" {
+		fmt.Println()
+		return defaultValue
+	}
+	if response == "" {
+		return defaultValue
+	}
+	return response
+}
+
+// promptYesNo asks a yes/no question and returns the answer
+func promptYesNo(reader *bufio.Reader, question string, defaultValue bool) bool {
+	hint := "y/N"
+	if defaultValue {
+		hint = "Y/n"
+	}
+
+	for {
+		response := strings.ToLower(prompt(reader, fmt.Sprintf("%s (%s)", question, hint), ""))
+		switch response {
+		case "":
+			return defaultValue
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+		fmt.Println("Please answer y or n.")
+	}
+}
+
+// confirm asks whether to go ahead with a destructive operation. The confirm
+// settings in .todo/config.yaml decide whether it asks at all; operations
+// that don't need confirmation go ahead without a prompt.
+func confirm(reader *bufio.Reader, operation, question string) bool {
+	if cfg, err := pkg.LoadConfig(); err == nil && !cfg.NeedsConfirmation(operation) {
+		return true
+	}
+	return promptYesNo(reader, question, false)
+}
+
+// runInitWizard walks through the optional project setup and writes the
+// resulting .todo/config.yaml. A non-empty visibility skips that question.
+func runInitWizard(visibility string) error {
+	reader := bufio.NewReader(os.Stdin)
+
+	cfg, err := pkg.LoadProjectConfig()
+	if err != nil {
+		return err
+	}
+	inGit := pkg.GitEnabled(cfg)
+
+	pkg.Tip("Setting up todo management. Press enter to accept the default shown in brackets.\n")
+
+	// Storage location
+	if inGit {
+		root, err := pkg.GetRepoRoot()
+		cwd, cwdErr := os.Getwd()
+		if err == nil && cwdErr == nil && !sameDir(root, cwd) {
+			fmt.Println("Where should the .todo directory be created?")
+			fmt.Println("  1. This directory")
+			fmt.Printf("  2. The repository root (%s)\n", root)
+			if prompt(reader, "Choose", "1") == "2" {
+				if err := os.Chdir(root); err != nil {
+					return fmt.Errorf("failed to switch to repository root: %w", err)
+				}
+				if cfg, err = pkg.LoadProjectConfig(); err != nil {
+					return err
+				}
+			}
+		}
+	} else if cfg.Git != pkg.GitOff && !pkg.Global {
+		fmt.Println("This directory isn't a git repository, so git features (branch tracking,")
+		fmt.Println("hooks, visibility) will be turned off. Set 'git: auto' in the config to")
+		fmt.Println("enable them later.")
+		fmt.Println()
+		cfg.Git = pkg.GitOff
+	}
+
+	cfg.DefaultList = prompt(reader, "Default list name", cfg.DefaultList)
+
+	if inGit {
+		if visibility != "" {
+			cfg.Visibility = visibility
+		} else {
+			fmt.Println("Should todo lists be shared through git?")
+			fmt.Println("  local     - add .todo/ to .gitignore (personal lists)")
+			fmt.Println("  committed - commit .todo/ so the team shares lists")
+			defaultVisibility := cfg.Visibility
+			if defaultVisibility == "" {
+				defaultVisibility = pkg.VisibilityLocal
+			}
+			for {
+				cfg.Visibility = prompt(reader, "Visibility", defaultVisibility)
+				if err := pkg.ValidateVisibility(cfg.Visibility); err == nil {
+					break
+				}
+				fmt.Printf("Please answer %s or %s.\n", pkg.VisibilityLocal, pkg.VisibilityCommitted)
+			}
+		}
+		if err := pkg.ApplyVisibility(cfg.Visibility); err != nil {
+			return err
+		}
+
+		cfg.BranchTracking = promptYesNo(reader, "Use the current git branch to pick the active list?", cfg.BranchTracking)
+
+		cfg.Hooks = promptYesNo(reader, "Install git hooks?", cfg.Hooks)
+		if cfg.Hooks {
+			if err := pkg.InstallHooks(); err != nil {
+				return fmt.Errorf("failed to install hooks: %w", err)
+			}
+		}
+	}
+
+	if err := pkg.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	fmt.Println()
+	fmt.Printf("Wrote %s\n", pkg.GetConfigPath())
+	for _, warning := range pkg.VisibilityWarnings(cfg) {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	return nil
+}
+
+func sameDir(a, b string) bool {
+	a, errA := filepath.EvalSymlinks(a)
+	b, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && a == b
+}
+
+// setVisibility records the visibility in the config and updates .gitignore
+func setVisibility(visibility string) error {
+	if err := pkg.RequireGit(); err != nil {
+		return err
+	}
+
+	cfg, err := pkg.LoadProjectConfig()
+	if err != nil {
+		return err
+	}
+
+	cfg.Visibility = visibility
+	if err := pkg.ApplyVisibility(visibility); err != nil {
+		return err
+	}
+	if err := pkg.SaveConfig(cfg); err != nil {
+		return err
+	}
+
+	fmt.Printf("Set visibility to '%s'\n", visibility)
+	for _, warning := range pkg.VisibilityWarnings(cfg) {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/scttymn/todo-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Manage the registry of projects with todo lists",
+	Long: `Register the projects you keep todo lists in, so commands such as
+'todo standup --all-workspaces' can look across all of them.
+
+The registry is kept in your user config directory.`,
+}
+
+var workspaceAddCmd = &cobra.Command{
+	Use:   "add [path]",
+	Short: "Register a project (default: the current directory)",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) == 1 {
+			dir = argPath(args[0])
+		}
+
+		path, err := pkg.AddWorkspace(dir)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Registered workspace %s\n", path)
+	},
+}
+
+var workspaceRemoveCmd = &cobra.Command{
+	Use:   "remove [path]",
+	Short: "Unregister a project (default: the current directory)",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) == 1 {
+			dir = argPath(args[0])
+		}
+
+		path, err := pkg.RemoveWorkspace(dir)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Removed workspace %s\n", path)
+	},
+}
+
+var workspaceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show the registered projects",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		workspaces, err := pkg.LoadWorkspaces()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if jsonOutput(cmd) {
+			printJSON(append([]string{}, workspaces...))
+			return
+		}
+
+		if len(workspaces) == 0 {
+			fmt.Println("No workspaces registered. Run 'todo workspace add' in a project to add it.")
+			return
+		}
+
+		fmt.Println("Workspaces:")
+		pkg.Blank()
+		for _, workspace := range workspaces {
+			fmt.Printf("  %s\n", workspace)
+		}
+	},
+}